@@ -0,0 +1,266 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package airflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/httputils"
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// TriggerDagOperation creates a new run of a DAG.
+	TriggerDagOperation bindings.OperationKind = "triggerDag"
+	// GetDagRunStatusOperation returns a run of a DAG, including its state.
+	GetDagRunStatusOperation bindings.OperationKind = "getDagRunStatus"
+	// PauseDagOperation pauses or unpauses a DAG.
+	PauseDagOperation bindings.OperationKind = "pauseDag"
+
+	// Invoke metadata keys.
+	dagIDKey       = "dagId"
+	dagRunIDKey    = "dagRunId"
+	logicalDateKey = "logicalDate"
+	pausedKey      = "paused"
+
+	// Response metadata keys.
+	stateKey = "state"
+
+	defaultTimeout = 30 * time.Second
+)
+
+// Airflow is an output binding for the stable REST API of Apache Airflow.
+type Airflow struct {
+	metadata   *airflowMetadata
+	httpClient *http.Client
+	logger     logger.Logger
+}
+
+type airflowMetadata struct {
+	// Base URL of the Airflow webserver, e.g. http://localhost:8080
+	URL string `mapstructure:"url"`
+	// Credentials for the basic auth backend.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Bearer token, used instead of basic auth when set.
+	Token string `mapstructure:"token"`
+	// Default DAG, which can be overridden with the dagId request metadata.
+	DagID   string        `mapstructure:"dagId"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type dagRun struct {
+	DagRunID string `json:"dag_run_id"`
+	State    string `json:"state"`
+}
+
+// NewAirflow returns a new Airflow binding instance.
+func NewAirflow(logger logger.Logger) bindings.OutputBinding {
+	return &Airflow{logger: logger}
+}
+
+// Init does metadata parsing.
+func (a *Airflow) Init(meta bindings.Metadata) error {
+	m, err := a.parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	a.metadata = m
+	transport, err := httputils.NewTransport(meta.Properties)
+	if err != nil {
+		return err
+	}
+	a.httpClient = &http.Client{
+		Timeout:   m.Timeout,
+		Transport: transport,
+	}
+
+	return nil
+}
+
+func (a *Airflow) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfilePassword, metadata.AuthProfileToken},
+	}
+}
+
+func (a *Airflow) parseMetadata(meta bindings.Metadata) (*airflowMetadata, error) {
+	m := airflowMetadata{
+		Timeout: defaultTimeout,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	m.URL = strings.TrimSuffix(m.URL, "/")
+	if m.URL == "" {
+		return nil, errors.New("airflow binding error: url is required")
+	}
+	if m.Token == "" && m.Username == "" {
+		return nil, errors.New("airflow binding error: either token or username and password are required")
+	}
+
+	return &m, nil
+}
+
+// Operations returns the supported operations for this binding.
+func (a *Airflow) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{TriggerDagOperation, GetDagRunStatusOperation, PauseDagOperation}
+}
+
+// Invoke performs an operation against the Airflow REST API.
+func (a *Airflow) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	dagID := req.Metadata[dagIDKey]
+	if dagID == "" {
+		dagID = a.metadata.DagID
+	}
+	if dagID == "" {
+		return nil, errors.New("airflow binding error: dagId is required")
+	}
+	dagURL := a.metadata.URL + "/api/v1/dags/" + url.PathEscape(dagID)
+
+	switch req.Operation { //nolint:exhaustive
+	case TriggerDagOperation:
+		return a.triggerDag(ctx, dagURL, req)
+	case GetDagRunStatusOperation:
+		return a.getDagRunStatus(ctx, dagURL, req)
+	case PauseDagOperation:
+		return a.pauseDag(ctx, dagURL, req)
+	default:
+		return nil, fmt.Errorf("airflow binding error: unsupported operation %s", req.Operation)
+	}
+}
+
+func (a *Airflow) triggerDag(ctx context.Context, dagURL string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	// The request data is the configuration of the run
+	body := map[string]interface{}{}
+	if len(req.Data) > 0 {
+		var conf map[string]interface{}
+		if err := json.Unmarshal(req.Data, &conf); err != nil {
+			return nil, fmt.Errorf("airflow binding error: the DAG run configuration must be a JSON object: %w", err)
+		}
+		body["conf"] = conf
+	}
+	if v := req.Metadata[dagRunIDKey]; v != "" {
+		body["dag_run_id"] = v
+	}
+	if v := req.Metadata[logicalDateKey]; v != "" {
+		body["logical_date"] = v
+	}
+
+	return a.doDagRunRequest(ctx, http.MethodPost, dagURL+"/dagRuns", body)
+}
+
+func (a *Airflow) getDagRunStatus(ctx context.Context, dagURL string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	dagRunID := req.Metadata[dagRunIDKey]
+	if dagRunID == "" {
+		return nil, errors.New("airflow binding error: dagRunId is required")
+	}
+
+	return a.doDagRunRequest(ctx, http.MethodGet, dagURL+"/dagRuns/"+url.PathEscape(dagRunID), nil)
+}
+
+func (a *Airflow) pauseDag(ctx context.Context, dagURL string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	paused := true
+	if v, ok := req.Metadata[pausedKey]; ok && v != "" {
+		paused = utils.IsTruthy(v)
+	}
+
+	res, err := a.do(ctx, http.MethodPatch, dagURL+"?update_mask=is_paused", map[string]interface{}{"is_paused": paused})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     res,
+		Metadata: map[string]string{pausedKey: strconv.FormatBool(paused)},
+	}, nil
+}
+
+// doDagRunRequest performs a request returning a DAG run, and reports its ID and state in the response metadata.
+func (a *Airflow) doDagRunRequest(ctx context.Context, method string, u string, body interface{}) (*bindings.InvokeResponse, error) {
+	res, err := a.do(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var run dagRun
+	if err = json.Unmarshal(res, &run); err != nil {
+		return nil, fmt.Errorf("airflow binding error: failed to decode the DAG run: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: res,
+		Metadata: map[string]string{
+			dagRunIDKey: run.DagRunID,
+			stateKey:    run.State,
+		},
+	}, nil
+}
+
+func (a *Airflow) do(ctx context.Context, method string, u string, body interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if reqBody != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if a.metadata.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.metadata.Token)
+	} else {
+		httpReq.SetBasicAuth(a.metadata.Username, a.metadata.Password)
+	}
+
+	resp, err := a.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("airflow binding error: request to %s failed: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	res, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("airflow binding error: request to %s failed with status %d: %s", u, resp.StatusCode, string(res))
+	}
+	a.logger.Debugf("airflow binding: %s %s completed with status %d", method, u, resp.StatusCode)
+
+	return res, nil
+}
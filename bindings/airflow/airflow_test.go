@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package airflow
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("all properties", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"url": "http://airflow:8080/", "username": "u", "password": "p", "dagId": "etl", "timeout": "5s",
+		}
+		a := Airflow{}
+		meta, err := a.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, "http://airflow:8080", meta.URL)
+		assert.Equal(t, "u", meta.Username)
+		assert.Equal(t, "p", meta.Password)
+		assert.Equal(t, "etl", meta.DagID)
+		assert.Equal(t, 5*time.Second, meta.Timeout)
+	})
+
+	t.Run("default timeout", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"url": "http://airflow:8080", "token": "t"}
+		a := Airflow{}
+		meta, err := a.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, defaultTimeout, meta.Timeout)
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"token": "t"}
+		a := Airflow{}
+		_, err := a.parseMetadata(m)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"url": "http://airflow:8080"}
+		a := Airflow{}
+		_, err := a.parseMetadata(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestInvoke(t *testing.T) {
+	var (
+		method string
+		path   string
+		query  string
+		body   string
+		auth   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, path, query, body = r.Method, r.URL.Path, r.URL.RawQuery, string(b)
+		auth = r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/api/v1/dags/missing/dagRuns":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"title":"DAG not found"}`))
+		case r.Method == http.MethodPatch:
+			_, _ = w.Write([]byte(`{"dag_id":"etl","is_paused":true}`))
+		default:
+			_, _ = w.Write([]byte(`{"dag_id":"etl","dag_run_id":"run-1","state":"queued"}`))
+		}
+	}))
+	defer srv.Close()
+
+	a := &Airflow{
+		metadata:   &airflowMetadata{URL: srv.URL, Username: "u", Password: "p", DagID: "etl"},
+		httpClient: srv.Client(),
+		logger:     logger.NewLogger("test"),
+	}
+
+	t.Run("trigger DAG", func(t *testing.T) {
+		resp, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerDagOperation,
+			Data:      []byte(`{"date":"2022-01-01"}`),
+			Metadata:  map[string]string{"dagRunId": "run-1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPost, method)
+		assert.Equal(t, "/api/v1/dags/etl/dagRuns", path)
+		assert.JSONEq(t, `{"conf":{"date":"2022-01-01"},"dag_run_id":"run-1"}`, body)
+		assert.Equal(t, "run-1", resp.Metadata[dagRunIDKey])
+		assert.Equal(t, "queued", resp.Metadata[stateKey])
+		assert.Contains(t, auth, "Basic ")
+	})
+
+	t.Run("trigger DAG with invalid configuration", func(t *testing.T) {
+		_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerDagOperation,
+			Data:      []byte(`[1]`),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("get DAG run status", func(t *testing.T) {
+		resp, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: GetDagRunStatusOperation,
+			Metadata:  map[string]string{"dagId": "other", "dagRunId": "run-1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodGet, method)
+		assert.Equal(t, "/api/v1/dags/other/dagRuns/run-1", path)
+		assert.Equal(t, "queued", resp.Metadata[stateKey])
+	})
+
+	t.Run("get DAG run status without run ID", func(t *testing.T) {
+		_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{Operation: GetDagRunStatusOperation})
+		assert.Error(t, err)
+	})
+
+	t.Run("pause DAG", func(t *testing.T) {
+		resp, err := a.Invoke(context.Background(), &bindings.InvokeRequest{Operation: PauseDagOperation})
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPatch, method)
+		assert.Equal(t, "/api/v1/dags/etl", path)
+		assert.Equal(t, "update_mask=is_paused", query)
+		assert.JSONEq(t, `{"is_paused":true}`, body)
+		assert.Equal(t, "true", resp.Metadata[pausedKey])
+	})
+
+	t.Run("unpause DAG", func(t *testing.T) {
+		_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PauseDagOperation,
+			Metadata:  map[string]string{"paused": "false"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"is_paused":false}`, body)
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerDagOperation,
+			Metadata:  map[string]string{"dagId": "missing"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DAG not found")
+	})
+}
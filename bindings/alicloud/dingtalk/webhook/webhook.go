@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -92,6 +93,12 @@ func (t *DingTalkWebhook) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (t *DingTalkWebhook) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
+
 // Read triggers the outgoing webhook, not yet production ready.
 func (t *DingTalkWebhook) Read(ctx context.Context, handler bindings.Handler) error {
 	t.logger.Debugf("dingtalk webhook: start read input binding")
@@ -27,6 +27,7 @@ import (
 	dubboImpl "dubbo.apache.org/dubbo-go/v3/protocol/dubbo/impl"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -52,6 +53,12 @@ func (out *DubboOutputBinding) Init(_ bindings.Metadata) error {
 	return nil
 }
 
+func (out *DubboOutputBinding) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 func (out *DubboOutputBinding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	var (
 		cachedDubboCtx *dubboContext
@@ -29,6 +29,7 @@ import (
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -105,6 +106,12 @@ func (n *Nacos) Init(metadata bindings.Metadata) error {
 	return n.createConfigClient()
 }
 
+func (n *Nacos) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword, metadata.AuthProfileAccessKey},
+	}
+}
+
 func (n *Nacos) createConfigClient() error {
 	logRollingConfig := constant.ClientLogRollingConfig{
 		MaxSize: n.settings.MaxSize,
@@ -60,6 +60,12 @@ func (s *AliCloudOSS) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (s *AliCloudOSS) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 func (s *AliCloudOSS) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
@@ -26,6 +26,7 @@ import (
 	mqw "github.com/cinience/go_rocketmq"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
@@ -73,6 +74,12 @@ func (a *AliCloudRocketMQ) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (a *AliCloudRocketMQ) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 // Read triggers the rocketmq subscription.
 func (a *AliCloudRocketMQ) Read(ctx context.Context, handler bindings.Handler) error {
 	a.logger.Debugf("binding rocketmq: start read input binding")
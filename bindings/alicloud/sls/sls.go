@@ -48,6 +48,12 @@ func (s *AliCloudSlsLogstorage) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (s *AliCloudSlsLogstorage) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 func NewAliCloudSlsLogstorage(logger logger.Logger) bindings.OutputBinding {
 	logger.Debug("initialized Sls log storage binding component")
 	s := &AliCloudSlsLogstorage{
@@ -22,6 +22,7 @@ import (
 	"github.com/aliyun/aliyun-tablestore-go-sdk/tablestore"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 
 	"github.com/pkg/errors"
@@ -70,6 +71,12 @@ func (s *AliCloudTableStore) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (s *AliCloudTableStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 func (s *AliCloudTableStore) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	if req == nil {
 		return nil, errors.Errorf("invoke request required")
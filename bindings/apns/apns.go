@@ -27,6 +27,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -94,6 +95,12 @@ func (a *APNS) Init(metadata bindings.Metadata) error {
 	return a.extractPrivateKey(metadata)
 }
 
+func (a *APNS) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileJWT},
+	}
+}
+
 // Operations will return the set of operations supported by the APNS output
 // binding. The APNS output binding only supports the "create" operation for
 // sending new push notifications to the APNS service.
@@ -66,6 +66,12 @@ func (d *DynamoDB) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (d *DynamoDB) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAWSIAM},
+	}
+}
+
 func (d *DynamoDB) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
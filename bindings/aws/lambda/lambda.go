@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lambda
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+
+	"github.com/dapr/components-contrib/bindings"
+	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// InvokeOperation invokes a Lambda function with the request data as payload.
+	InvokeOperation bindings.OperationKind = "invoke"
+
+	metadataFunctionName   = "functionName"
+	metadataQualifier      = "qualifier"
+	metadataInvocationType = "invocationType"
+	metadataLogTail        = "logTail"
+	metadataClientContext  = "clientContext"
+
+	metadataStatusCode      = "statusCode"
+	metadataExecutedVersion = "executedVersion"
+	metadataFunctionError   = "functionError"
+	metadataLogResult       = "logResult"
+)
+
+// AWSLambda is a binding for invoking AWS Lambda functions.
+type AWSLambda struct {
+	metadata *lambdaMetadata
+	client   lambdaiface.LambdaAPI
+	logger   logger.Logger
+}
+
+type lambdaMetadata struct {
+	Region         string `json:"region"`
+	Endpoint       string `json:"endpoint"`
+	AccessKey      string `json:"accessKey"`
+	SecretKey      string `json:"secretKey"`
+	SessionToken   string `json:"sessionToken"`
+	FunctionName   string `json:"functionName"`
+	Qualifier      string `json:"qualifier"`
+	InvocationType string `json:"invocationType"`
+	LogTail        bool   `json:"logTail,string"`
+}
+
+// NewAWSLambda returns a new AWS Lambda binding instance.
+func NewAWSLambda(logger logger.Logger) bindings.OutputBinding {
+	return &AWSLambda{logger: logger}
+}
+
+// Init does metadata parsing and connection creation.
+func (a *AWSLambda) Init(metadata bindings.Metadata) error {
+	m, err := a.parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	sess, err := awsAuth.GetClient(m.AccessKey, m.SecretKey, m.SessionToken, m.Region, m.Endpoint)
+	if err != nil {
+		return err
+	}
+	a.metadata = m
+	a.client = lambda.New(sess)
+
+	return nil
+}
+
+func (a *AWSLambda) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAWSIAM},
+	}
+}
+
+func (a *AWSLambda) parseMetadata(metadata bindings.Metadata) (*lambdaMetadata, error) {
+	b, err := json.Marshal(metadata.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	var m lambdaMetadata
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+	if m.InvocationType == "" {
+		m.InvocationType = lambda.InvocationTypeRequestResponse
+	}
+	if err = validateInvocationType(m.InvocationType); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func validateInvocationType(invocationType string) error {
+	for _, t := range lambda.InvocationType_Values() {
+		if t == invocationType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid invocation type %s, must be one of %v", invocationType, lambda.InvocationType_Values())
+}
+
+// mergeWithRequestMetadata returns the metadata of the component, overridden by the metadata of the request.
+func (metadata lambdaMetadata) mergeWithRequestMetadata(req *bindings.InvokeRequest) (lambdaMetadata, error) {
+	merged := metadata
+	if val, ok := req.Metadata[metadataFunctionName]; ok && val != "" {
+		merged.FunctionName = val
+	}
+	if val, ok := req.Metadata[metadataQualifier]; ok && val != "" {
+		merged.Qualifier = val
+	}
+	if val, ok := req.Metadata[metadataInvocationType]; ok && val != "" {
+		if err := validateInvocationType(val); err != nil {
+			return merged, err
+		}
+		merged.InvocationType = val
+	}
+	if val, ok := req.Metadata[metadataLogTail]; ok && val != "" {
+		merged.LogTail = utils.IsTruthy(val)
+	}
+
+	return merged, nil
+}
+
+func (a *AWSLambda) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{InvokeOperation}
+}
+
+func (a *AWSLambda) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req.Operation != InvokeOperation {
+		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
+	}
+
+	metadata, err := a.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.FunctionName == "" {
+		return nil, errors.New("lambda binding error: functionName is required")
+	}
+
+	input := &lambda.InvokeInput{
+		FunctionName:   aws.String(metadata.FunctionName),
+		InvocationType: aws.String(metadata.InvocationType),
+		Payload:        req.Data,
+	}
+	if metadata.Qualifier != "" {
+		input.Qualifier = aws.String(metadata.Qualifier)
+	}
+	// the log tail is only returned for synchronous invocations.
+	if metadata.LogTail && metadata.InvocationType == lambda.InvocationTypeRequestResponse {
+		input.LogType = aws.String(lambda.LogTypeTail)
+	}
+	if val, ok := req.Metadata[metadataClientContext]; ok && val != "" {
+		input.ClientContext = aws.String(b64.StdEncoding.EncodeToString([]byte(val)))
+	}
+
+	output, err := a.client.InvokeWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("lambda binding error: failed to invoke function %s: %w", metadata.FunctionName, err)
+	}
+
+	responseMetadata := map[string]string{}
+	if output.StatusCode != nil {
+		responseMetadata[metadataStatusCode] = strconv.FormatInt(*output.StatusCode, 10)
+	}
+	if output.ExecutedVersion != nil {
+		responseMetadata[metadataExecutedVersion] = *output.ExecutedVersion
+	}
+	if output.LogResult != nil {
+		logResult, err := b64.StdEncoding.DecodeString(*output.LogResult)
+		if err != nil {
+			a.logger.Warnf("lambda binding: failed to decode the log tail of function %s: %v", metadata.FunctionName, err)
+		} else {
+			responseMetadata[metadataLogResult] = string(logResult)
+		}
+	}
+
+	// errors raised by the function are reported in the output instead of failing the request.
+	if output.FunctionError != nil && *output.FunctionError != "" {
+		responseMetadata[metadataFunctionError] = *output.FunctionError
+		a.logger.Debugf("lambda binding: function %s returned error %s", metadata.FunctionName, *output.FunctionError)
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     output.Payload,
+		Metadata: responseMetadata,
+	}, nil
+}
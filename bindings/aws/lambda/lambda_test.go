@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lambda
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+type mockLambdaClient struct {
+	lambdaiface.LambdaAPI
+	input  *lambda.InvokeInput
+	output *lambda.InvokeOutput
+	err    error
+}
+
+func (m *mockLambdaClient) InvokeWithContext(_ aws.Context, input *lambda.InvokeInput, _ ...request.Option) (*lambda.InvokeOutput, error) {
+	m.input = input
+
+	return m.output, m.err
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("has correct metadata", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"region": "r", "accessKey": "a", "secretKey": "s", "sessionToken": "t", "endpoint": "e",
+			"functionName": "fn", "qualifier": "live", "invocationType": "Event", "logTail": "true",
+		}
+		l := AWSLambda{}
+		meta, err := l.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, "r", meta.Region)
+		assert.Equal(t, "a", meta.AccessKey)
+		assert.Equal(t, "s", meta.SecretKey)
+		assert.Equal(t, "t", meta.SessionToken)
+		assert.Equal(t, "e", meta.Endpoint)
+		assert.Equal(t, "fn", meta.FunctionName)
+		assert.Equal(t, "live", meta.Qualifier)
+		assert.Equal(t, "Event", meta.InvocationType)
+		assert.True(t, meta.LogTail)
+	})
+
+	t.Run("defaults to synchronous invocation", func(t *testing.T) {
+		l := AWSLambda{}
+		meta, err := l.parseMetadata(bindings.Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, lambda.InvocationTypeRequestResponse, meta.InvocationType)
+	})
+
+	t.Run("invalid invocation type", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"invocationType": "Async"}
+		l := AWSLambda{}
+		_, err := l.parseMetadata(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestInvoke(t *testing.T) {
+	newBinding := func(client *mockLambdaClient) *AWSLambda {
+		return &AWSLambda{
+			metadata: &lambdaMetadata{FunctionName: "fn", InvocationType: lambda.InvocationTypeRequestResponse, LogTail: true},
+			client:   client,
+			logger:   logger.NewLogger("test"),
+		}
+	}
+
+	t.Run("synchronous invocation returns output and log tail", func(t *testing.T) {
+		client := &mockLambdaClient{output: &lambda.InvokeOutput{
+			Payload:         []byte(`{"result":1}`),
+			StatusCode:      aws.Int64(200),
+			ExecutedVersion: aws.String("3"),
+			LogResult:       aws.String(b64.StdEncoding.EncodeToString([]byte("START RequestId"))),
+		}}
+		l := newBinding(client)
+
+		resp, err := l.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: InvokeOperation,
+			Data:      []byte(`{"input":1}`),
+			Metadata:  map[string]string{"qualifier": "live", "clientContext": `{"custom":{}}`},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"result":1}`, string(resp.Data))
+		assert.Equal(t, "200", resp.Metadata[metadataStatusCode])
+		assert.Equal(t, "3", resp.Metadata[metadataExecutedVersion])
+		assert.Equal(t, "START RequestId", resp.Metadata[metadataLogResult])
+
+		assert.Equal(t, "fn", *client.input.FunctionName)
+		assert.Equal(t, "live", *client.input.Qualifier)
+		assert.Equal(t, lambda.LogTypeTail, *client.input.LogType)
+		assert.Equal(t, `{"input":1}`, string(client.input.Payload))
+		assert.Equal(t, b64.StdEncoding.EncodeToString([]byte(`{"custom":{}}`)), *client.input.ClientContext)
+	})
+
+	t.Run("event invocation", func(t *testing.T) {
+		client := &mockLambdaClient{output: &lambda.InvokeOutput{StatusCode: aws.Int64(202)}}
+		l := newBinding(client)
+
+		resp, err := l.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: InvokeOperation,
+			Metadata:  map[string]string{"invocationType": "Event", "functionName": "other"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "202", resp.Metadata[metadataStatusCode])
+		assert.Equal(t, "other", *client.input.FunctionName)
+		assert.Equal(t, lambda.InvocationTypeEvent, *client.input.InvocationType)
+		assert.Nil(t, client.input.LogType)
+		assert.Nil(t, client.input.Qualifier)
+	})
+
+	t.Run("function error is reported in metadata", func(t *testing.T) {
+		client := &mockLambdaClient{output: &lambda.InvokeOutput{
+			Payload:       []byte(`{"errorMessage":"boom"}`),
+			StatusCode:    aws.Int64(200),
+			FunctionError: aws.String("Unhandled"),
+		}}
+		l := newBinding(client)
+
+		resp, err := l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: InvokeOperation})
+		require.NoError(t, err)
+		assert.Equal(t, "Unhandled", resp.Metadata[metadataFunctionError])
+		assert.Equal(t, `{"errorMessage":"boom"}`, string(resp.Data))
+	})
+
+	t.Run("invocation error", func(t *testing.T) {
+		l := newBinding(&mockLambdaClient{err: errors.New("throttled")})
+		_, err := l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: InvokeOperation})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid invocation type in request", func(t *testing.T) {
+		l := newBinding(&mockLambdaClient{})
+		_, err := l.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: InvokeOperation,
+			Metadata:  map[string]string{"invocationType": "Async"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing function name", func(t *testing.T) {
+		l := newBinding(&mockLambdaClient{})
+		l.metadata.FunctionName = ""
+		_, err := l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: InvokeOperation})
+		assert.Error(t, err)
+	})
+}
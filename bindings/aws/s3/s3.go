@@ -35,6 +35,7 @@ import (
 	"github.com/dapr/components-contrib/bindings"
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
 	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -133,6 +134,12 @@ func (s *AWSS3) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (s *AWSS3) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAWSIAM},
+	}
+}
+
 func (s *AWSS3) Close() error {
 	return nil
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	"github.com/dapr/components-contrib/metadata"
 
 	"github.com/aws/aws-sdk-go/service/ses"
 
@@ -78,6 +79,12 @@ func (a *AWSSES) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (a *AWSSES) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAWSIAM},
+	}
+}
+
 func (a *AWSSES) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
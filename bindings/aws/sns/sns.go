@@ -22,6 +22,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -68,6 +69,12 @@ func (a *AWSSNS) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (a *AWSSNS) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAWSIAM},
+	}
+}
+
 func (a *AWSSNS) parseMetadata(metadata bindings.Metadata) (*snsMetadata, error) {
 	b, err := json.Marshal(metadata.Properties)
 	if err != nil {
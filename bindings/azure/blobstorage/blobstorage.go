@@ -30,6 +30,8 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	storageinternal "github.com/dapr/components-contrib/internal/component/azure/blobstorage"
+	"github.com/dapr/components-contrib/internal/redact"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
 )
@@ -92,8 +94,14 @@ func NewAzureBlobStorage(logger logger.Logger) bindings.OutputBinding {
 }
 
 // Init performs metadata parsing.
-func (a *AzureBlobStorage) Init(metadata bindings.Metadata) error {
-	var err error
+func (a *AzureBlobStorage) Init(metadata bindings.Metadata) (err error) {
+	// The errors of the storage client may quote the account key
+	redactor := redact.New(metadata.Properties, nil)
+	a.logger = redactor.Logger(a.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	a.containerClient, a.metadata, err = storageinternal.CreateContainerStorageClient(a.logger, metadata.Properties)
 	if err != nil {
 		return err
@@ -101,6 +109,12 @@ func (a *AzureBlobStorage) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (a *AzureBlobStorage) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey, metadata.AuthProfileAzureAD},
+	}
+}
+
 func (a *AzureBlobStorage) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		bindings.CreateOperation,
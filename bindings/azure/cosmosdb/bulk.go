@@ -0,0 +1,224 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosmosdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	// Maximum number of operations Cosmos DB accepts in a single transactional batch.
+	maxBatchOperations = 100
+
+	// Header returned by Cosmos DB on throttled (429) responses.
+	retryAfterHeader = "x-ms-retry-after-ms"
+
+	// Delay used when a throttled response does not carry a retry-after hint.
+	defaultRetryAfter = time.Second
+)
+
+// bulkItemResult is the per-document summary returned by the bulkUpsert operation.
+type bulkItemResult struct {
+	ID            string  `json:"id"`
+	PartitionKey  string  `json:"partitionKey"`
+	StatusCode    int32   `json:"statusCode"`
+	RequestCharge float32 `json:"requestCharge"`
+	Error         string  `json:"error,omitempty"`
+}
+
+type bulkItem struct {
+	index int
+	id    string
+	data  []byte
+}
+
+type bulkBatch struct {
+	partitionKey string
+	items        []bulkItem
+}
+
+func (c *CosmosDB) bulkUpsert(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var docs []json.RawMessage
+	err := json.Unmarshal(req.Data, &docs)
+	if err != nil {
+		return nil, fmt.Errorf("bulkUpsert requires a JSON array of documents: %w", err)
+	}
+
+	batches, err := c.groupByPartitionKey(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]bulkItemResult, len(docs))
+	failed := 0
+	for _, b := range batches {
+		for i, res := range c.executeBatch(ctx, b) {
+			if res.Error != "" {
+				failed++
+			}
+			results[b.items[i].index] = res
+		}
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data: data,
+		Metadata: map[string]string{
+			"succeeded": strconv.Itoa(len(docs) - failed),
+			"failed":    strconv.Itoa(failed),
+		},
+	}, nil
+}
+
+// groupByPartitionKey splits the documents into batches sharing the same partition key value,
+// each holding at most maxBatchOperations items. Batches preserve the order of the input.
+func (c *CosmosDB) groupByPartitionKey(docs []json.RawMessage) ([]bulkBatch, error) {
+	batches := []bulkBatch{}
+	// Index of the batch currently being filled for each partition key
+	open := map[string]int{}
+	for i, doc := range docs {
+		var obj interface{}
+		err := json.Unmarshal(doc, &obj)
+		if err != nil {
+			return nil, fmt.Errorf("invalid document at index %d: %w", i, err)
+		}
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("document at index %d is not a JSON object", i)
+		}
+
+		pk, err := c.getPartitionKeyValue(c.partitionKey, obj)
+		if err != nil {
+			return nil, fmt.Errorf("document at index %d: %w", i, err)
+		}
+		id, _ := m["id"].(string)
+
+		idx, ok := open[pk]
+		if !ok || len(batches[idx].items) >= maxBatchOperations {
+			batches = append(batches, bulkBatch{partitionKey: pk})
+			idx = len(batches) - 1
+			open[pk] = idx
+		}
+		batches[idx].items = append(batches[idx].items, bulkItem{index: i, id: id, data: doc})
+	}
+
+	return batches, nil
+}
+
+// executeBatch upserts all items of a batch in a single transactional batch, retrying while throttled.
+func (c *CosmosDB) executeBatch(ctx context.Context, b bulkBatch) []bulkItemResult {
+	batch := c.client.NewTransactionalBatch(azcosmos.NewPartitionKeyString(b.partitionKey))
+	for _, item := range b.items {
+		batch.UpsertItem(item.data, nil)
+	}
+
+	var (
+		res azcosmos.TransactionalBatchResponse
+		err error
+	)
+	for attempt := 0; ; attempt++ {
+		res, err = c.client.ExecuteTransactionalBatch(ctx, batch, nil)
+		delay, throttled := getRetryAfter(res, err)
+		if !throttled || attempt >= c.maxBulkRetries {
+			break
+		}
+
+		c.logger.Debugf("cosmosdb bulk batch for partition key %s throttled, retrying in %v", b.partitionKey, delay)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+		break
+	}
+
+	results := make([]bulkItemResult, len(b.items))
+	for i, item := range b.items {
+		results[i] = bulkItemResult{
+			ID:           item.id,
+			PartitionKey: b.partitionKey,
+		}
+
+		switch {
+		case err != nil:
+			var respErr *azcore.ResponseError
+			if errors.As(err, &respErr) {
+				results[i].StatusCode = int32(respErr.StatusCode)
+			}
+			results[i].Error = err.Error()
+		case i < len(res.OperationResults):
+			op := res.OperationResults[i]
+			results[i].StatusCode = op.StatusCode
+			results[i].RequestCharge = op.RequestCharge
+			if !res.Success {
+				results[i].Error = http.StatusText(int(op.StatusCode))
+			}
+		default:
+			results[i].Error = "missing operation result in batch response"
+		}
+	}
+
+	return results
+}
+
+// getRetryAfter returns the delay requested by Cosmos DB if the batch was throttled.
+func getRetryAfter(res azcosmos.TransactionalBatchResponse, err error) (time.Duration, bool) {
+	var rawResponse *http.Response
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusTooManyRequests {
+			return 0, false
+		}
+		rawResponse = respErr.RawResponse
+	} else {
+		if res.Success {
+			return 0, false
+		}
+		throttled := false
+		for _, op := range res.OperationResults {
+			if op.StatusCode == http.StatusTooManyRequests {
+				throttled = true
+				break
+			}
+		}
+		if !throttled {
+			return 0, false
+		}
+		rawResponse = res.RawResponse
+	}
+
+	if rawResponse != nil {
+		if ms, parseErr := strconv.ParseFloat(rawResponse.Header.Get(retryAfterHeader), 64); parseErr == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	return defaultRetryAfter, true
+}
@@ -25,13 +25,16 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
 // CosmosDB allows performing state operations on collections.
 type CosmosDB struct {
-	client       *azcosmos.ContainerClient
-	partitionKey string
+	client         *azcosmos.ContainerClient
+	partitionKey   string
+	maxBulkRetries int
 
 	logger logger.Logger
 }
@@ -47,6 +50,14 @@ type cosmosDBCredentials struct {
 // Value used for timeout durations
 const timeoutValue = 30
 
+const (
+	// BulkUpsertOperation upserts an array of documents, batched per partition key.
+	BulkUpsertOperation bindings.OperationKind = "bulkUpsert"
+
+	maxBulkRetriesKey     = "maxBulkRetries"
+	defaultMaxBulkRetries = 5
+)
+
 // NewCosmosDB returns a new CosmosDB instance.
 func NewCosmosDB(logger logger.Logger) bindings.OutputBinding {
 	return &CosmosDB{logger: logger}
@@ -60,6 +71,7 @@ func (c *CosmosDB) Init(metadata bindings.Metadata) error {
 	}
 
 	c.partitionKey = m.PartitionKey
+	c.maxBulkRetries = utils.GetElemOrDefaultFromMap(metadata.Properties, maxBulkRetriesKey, defaultMaxBulkRetries)
 
 	opts := azcosmos.ClientOptions{
 		ClientOptions: policy.ClientOptions{
@@ -109,6 +121,12 @@ func (c *CosmosDB) Init(metadata bindings.Metadata) error {
 	return err
 }
 
+func (c *CosmosDB) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey, metadata.AuthProfileAzureAD},
+	}
+}
+
 func (c *CosmosDB) parseMetadata(metadata bindings.Metadata) (*cosmosDBCredentials, error) {
 	connInfo := metadata.Properties
 	b, err := json.Marshal(connInfo)
@@ -126,7 +144,7 @@ func (c *CosmosDB) parseMetadata(metadata bindings.Metadata) (*cosmosDBCredentia
 }
 
 func (c *CosmosDB) Operations() []bindings.OperationKind {
-	return []bindings.OperationKind{bindings.CreateOperation}
+	return []bindings.OperationKind{bindings.CreateOperation, BulkUpsertOperation}
 }
 
 func (c *CosmosDB) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
@@ -149,6 +167,8 @@ func (c *CosmosDB) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bi
 			return nil, err
 		}
 		return nil, nil
+	case BulkUpsertOperation:
+		return c.bulkUpsert(ctx, req)
 	default:
 		return nil, fmt.Errorf("operation kind %s not supported", req.Operation)
 	}
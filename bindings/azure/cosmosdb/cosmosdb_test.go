@@ -15,7 +15,13 @@ package cosmosdb
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 
 	"github.com/stretchr/testify/assert"
 
@@ -75,3 +81,59 @@ func TestPartitionKeyValue(t *testing.T) {
 	_, err = cosmosDB.getPartitionKeyValue("", obj)
 	assert.NotNil(t, err)
 }
+
+func TestGroupByPartitionKey(t *testing.T) {
+	cosmosDB := CosmosDB{partitionKey: "tenant", logger: logger.NewLogger("test")}
+
+	t.Run("groups documents per partition key", func(t *testing.T) {
+		var docs []json.RawMessage
+		json.Unmarshal([]byte(`[{"id":"1","tenant":"a"},{"id":"2","tenant":"b"},{"id":"3","tenant":"a"}]`), &docs)
+
+		batches, err := cosmosDB.groupByPartitionKey(docs)
+		assert.Nil(t, err)
+		assert.Len(t, batches, 2)
+		assert.Equal(t, "a", batches[0].partitionKey)
+		assert.Equal(t, []int{0, 2}, []int{batches[0].items[0].index, batches[0].items[1].index})
+		assert.Equal(t, "3", batches[0].items[1].id)
+		assert.Equal(t, "b", batches[1].partitionKey)
+	})
+
+	t.Run("splits batches above the operation limit", func(t *testing.T) {
+		docs := make([]json.RawMessage, maxBatchOperations+1)
+		for i := range docs {
+			docs[i] = json.RawMessage(fmt.Sprintf(`{"id":"%d","tenant":"a"}`, i))
+		}
+
+		batches, err := cosmosDB.groupByPartitionKey(docs)
+		assert.Nil(t, err)
+		assert.Len(t, batches, 2)
+		assert.Len(t, batches[0].items, maxBatchOperations)
+		assert.Len(t, batches[1].items, 1)
+	})
+
+	t.Run("missing partition key", func(t *testing.T) {
+		_, err := cosmosDB.groupByPartitionKey([]json.RawMessage{json.RawMessage(`{"id":"1"}`)})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetRetryAfter(t *testing.T) {
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	throttled.Header.Set(retryAfterHeader, "250")
+
+	delay, ok := getRetryAfter(azcosmos.TransactionalBatchResponse{}, &azcore.ResponseError{StatusCode: http.StatusTooManyRequests, RawResponse: throttled})
+	assert.True(t, ok)
+	assert.Equal(t, 250*time.Millisecond, delay)
+
+	delay, ok = getRetryAfter(azcosmos.TransactionalBatchResponse{
+		OperationResults: []azcosmos.TransactionalBatchResult{{StatusCode: http.StatusTooManyRequests}, {StatusCode: http.StatusFailedDependency}},
+	}, nil)
+	assert.True(t, ok)
+	assert.Equal(t, defaultRetryAfter, delay)
+
+	_, ok = getRetryAfter(azcosmos.TransactionalBatchResponse{Success: true}, nil)
+	assert.False(t, ok)
+
+	_, ok = getRetryAfter(azcosmos.TransactionalBatchResponse{}, &azcore.ResponseError{StatusCode: http.StatusConflict})
+	assert.False(t, ok)
+}
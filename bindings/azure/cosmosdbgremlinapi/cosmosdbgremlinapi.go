@@ -23,6 +23,7 @@ import (
 	gremcos "github.com/supplyon/gremcos"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -79,6 +80,12 @@ func (c *CosmosDBGremlinAPI) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (c *CosmosDBGremlinAPI) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 func (c *CosmosDBGremlinAPI) parseMetadata(metadata bindings.Metadata) (*cosmosDBGremlinAPICredentials, error) {
 	b, err := json.Marshal(metadata.Properties)
 	if err != nil {
@@ -14,47 +14,94 @@ limitations under the License.
 package eventgrid
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/services/eventgrid/mgmt/2021-12-01/eventgrid"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/google/uuid"
 	"github.com/valyala/fasthttp"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/contenttype"
+	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
+const (
+	defaultHandshakePort = "8080"
+	// Origin of the validation handshakes of Event Grid.
+	defaultAllowedOrigin = "eventgrid.azure.net"
+	// Scope of the Microsoft Entra ID tokens used to publish events.
+	eventGridScope = "https://eventgrid.azure.net/.default"
+	publishTimeout = 10 * time.Second
+
+	cloudEventsSpecVersion      = "1.0"
+	cloudEventsContentType      = "application/cloudevents+json"
+	cloudEventsBatchContentType = "application/cloudevents-batch+json"
+	defaultCloudEventType       = "com.dapr.event.sent"
+	defaultCloudEventSource     = "dapr"
+
+	// Request metadata setting the attributes of the events built from the data of the requests.
+	metadataCloudEventID      = "cloudevent.id"
+	metadataCloudEventSource  = "cloudevent.source"
+	metadataCloudEventType    = "cloudevent.type"
+	metadataCloudEventSubject = "cloudevent.subject"
+	metadataContentType       = "contentType"
+)
+
 // AzureEventGrid allows sending/receiving Azure Event Grid events.
+// Events are sent and received with the CloudEvents v1.0 schema. Output requests authenticate with the access key of
+// the topic if set, or else with Microsoft Entra ID (client credentials, certificate or managed identity).
 type AzureEventGrid struct {
 	metadata  *azureEventGridMetadata
 	logger    logger.Logger
 	userAgent string
+	// Properties of the component, with the Microsoft Entra ID credentials.
+	properties map[string]string
+	// Credential used to publish events when the component has no access key.
+	aadToken azcore.TokenCredential
+	client   *fasthttp.Client
 }
 
 type azureEventGridMetadata struct {
 	// Component Name
-	Name string
+	Name string `mapstructure:"-"`
+
+	// Microsoft Entra ID credentials, which are optional with a managed identity.
+	// They're parsed separately, with their aliases, and only listed here for backwards-compatibility.
+	TenantID     string `mapstructure:"tenantId"`
+	ClientID     string `mapstructure:"clientId"`
+	ClientSecret string `mapstructure:"clientSecret"`
 
 	// Required Input Binding Metadata
-	TenantID           string `json:"tenantId"`
-	SubscriptionID     string `json:"subscriptionId"`
-	ClientID           string `json:"clientId"`
-	ClientSecret       string `json:"clientSecret"`
-	SubscriberEndpoint string `json:"subscriberEndpoint"`
-	HandshakePort      string `json:"handshakePort"`
-	Scope              string `json:"scope"`
+	SubscriptionID     string `mapstructure:"subscriptionId"`
+	SubscriberEndpoint string `mapstructure:"subscriberEndpoint"`
+	HandshakePort      string `mapstructure:"handshakePort"`
+	Scope              string `mapstructure:"scope"`
 
 	// Optional Input Binding Metadata
-	EventSubscriptionName string `json:"eventSubscriptionName"`
+	EventSubscriptionName string `mapstructure:"eventSubscriptionName"`
+	// Comma-separated origins accepted in the validation handshakes of CloudEvents webhooks; "*" accepts any origin,
+	// e.g. to validate the endpoint with local tools.
+	AllowedOrigins string `mapstructure:"allowedOrigins"`
 
 	// Required Output Binding Metadata
-	AccessKey     string `json:"accessKey"`
-	TopicEndpoint string `json:"topicEndpoint"`
+	TopicEndpoint string `mapstructure:"topicEndpoint"`
+
+	// Optional Output Binding Metadata; Microsoft Entra ID is used without access key.
+	AccessKey string `mapstructure:"accessKey"`
 }
 
 // NewAzureEventGrid returns a new Azure Event Grid instance.
@@ -70,10 +117,33 @@ func (a *AzureEventGrid) Init(metadata bindings.Metadata) error {
 		return err
 	}
 	a.metadata = m
+	a.properties = metadata.Properties
+	a.client = &fasthttp.Client{
+		ReadTimeout:  publishTimeout,
+		WriteTimeout: publishTimeout,
+	}
+
+	// Publish with Microsoft Entra ID when the binding is used as output binding without access key
+	if m.TopicEndpoint != "" && m.AccessKey == "" {
+		settings, err := azauth.NewEnvironmentSettings("eventgrid", metadata.Properties)
+		if err != nil {
+			return err
+		}
+		a.aadToken, err = settings.GetTokenCredential()
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+func (a *AzureEventGrid) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey, metadata.AuthProfileAzureAD},
+	}
+}
+
 func (a *AzureEventGrid) Read(ctx context.Context, handler bindings.Handler) error {
 	err := a.ensureInputBindingMetadata()
 	if err != nil {
@@ -85,38 +155,13 @@ func (a *AzureEventGrid) Read(ctx context.Context, handler bindings.Handler) err
 		return err
 	}
 
-	m := func(ctx *fasthttp.RequestCtx) {
-		if string(ctx.Path()) == "/api/events" {
-			switch string(ctx.Method()) {
-			case "OPTIONS":
-				ctx.Response.Header.Add("WebHook-Allowed-Origin", string(ctx.Request.Header.Peek("WebHook-Request-Origin")))
-				ctx.Response.Header.Add("WebHook-Allowed-Rate", "*")
-				ctx.Response.Header.SetStatusCode(fasthttp.StatusOK)
-				_, err = ctx.Response.BodyWriter().Write([]byte(""))
-				if err != nil {
-					a.logger.Error(err.Error())
-				}
-			case "POST":
-				bodyBytes := ctx.PostBody()
-
-				_, err = handler(ctx, &bindings.ReadResponse{
-					Data: bodyBytes,
-				})
-				if err != nil {
-					a.logger.Error(err.Error())
-					ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
-				}
-			}
-		}
-	}
-
 	srv := &fasthttp.Server{
-		Handler: m,
+		Handler: a.requestHandler(handler),
 	}
 
 	// Run the server in background
 	go func() {
-		a.logger.Debugf("About to start listening for Event Grid events at http://localhost:%s/api/events", a.metadata.HandshakePort)
+		a.logger.Debugf("About to start listening for Event Grid events at http://localhost:%s%s", a.metadata.HandshakePort, webhookPath)
 		err := srv.ListenAndServe(fmt.Sprintf(":%s", a.metadata.HandshakePort))
 		if err != nil {
 			a.logger.Errorf("Error starting server: %v", err)
@@ -139,6 +184,9 @@ func (a *AzureEventGrid) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
 
+// Invoke publishes the data of the request to the topic.
+// Data that is already a CloudEvent, or an array of CloudEvents, is published as is; other data is the data of a
+// new CloudEvent, with the attributes of the cloudevent.* request metadata.
 func (a *AzureEventGrid) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	err := a.ensureOutputBindingMetadata()
 	if err != nil {
@@ -147,31 +195,45 @@ func (a *AzureEventGrid) Invoke(ctx context.Context, req *bindings.InvokeRequest
 		return nil, err
 	}
 
+	body, contentType, err := a.buildEvents(req)
+	if err != nil {
+		return nil, err
+	}
+
 	request := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(request)
 	request.Header.SetMethod(fasthttp.MethodPost)
-	request.Header.Set("Content-Type", "application/cloudevents+json")
-	request.Header.Set("aeg-sas-key", a.metadata.AccessKey)
+	request.Header.Set("Content-Type", contentType)
 	request.Header.Set("User-Agent", a.userAgent)
+	if a.metadata.AccessKey != "" {
+		request.Header.Set("aeg-sas-key", a.metadata.AccessKey)
+	} else {
+		token, err := a.aadToken.GetToken(ctx, policy.TokenRequestOptions{
+			Scopes: []string{eventGridScope},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get a Microsoft Entra ID token to publish to Event Grid: %w", err)
+		}
+		request.Header.Set("Authorization", "Bearer "+token.Token)
+	}
 	request.SetRequestURI(a.metadata.TopicEndpoint)
-	request.SetBody(req.Data)
+	request.SetBody(body)
 
 	response := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(response)
 
-	client := &fasthttp.Client{WriteTimeout: time.Second * 10}
-	err = client.Do(request, response)
+	err = a.client.DoTimeout(request, response, publishTimeout)
 	if err != nil {
 		a.logger.Error(err.Error())
 
 		return nil, err
 	}
 
-	if response.StatusCode() != fasthttp.StatusOK {
+	if response.StatusCode() < fasthttp.StatusOK || response.StatusCode() >= fasthttp.StatusMultipleChoices {
 		body := response.Body()
 		a.logger.Error(string(body))
 
-		return nil, errors.New(string(body))
+		return nil, fmt.Errorf("publishing to Event Grid failed with status %d: %s", response.StatusCode(), string(body))
 	}
 
 	a.logger.Debugf("Successfully posted event to %s", a.metadata.TopicEndpoint)
@@ -179,22 +241,109 @@ func (a *AzureEventGrid) Invoke(ctx context.Context, req *bindings.InvokeRequest
 	return nil, nil
 }
 
-func (a *AzureEventGrid) ensureInputBindingMetadata() error {
-	if a.metadata.TenantID == "" {
-		return errors.New("metadata field 'TenantID' is empty in EventGrid binding")
+// buildEvents returns the body of the request publishing the data of an invoke request, and its content type.
+func (a *AzureEventGrid) buildEvents(req *bindings.InvokeRequest) ([]byte, string, error) {
+	data := bytes.TrimSpace(req.Data)
+
+	switch {
+	case len(data) > 0 && data[0] == '[':
+		var events []map[string]json.RawMessage
+		if json.Unmarshal(data, &events) == nil && len(events) > 0 && isCloudEvent(events[0]) {
+			for i, event := range events {
+				err := validateCloudEvent(event)
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid CloudEvent at index %d: %w", i, err)
+				}
+			}
+			return data, cloudEventsBatchContentType, nil
+		}
+	case len(data) > 0 && data[0] == '{':
+		var event map[string]json.RawMessage
+		if json.Unmarshal(data, &event) == nil && isCloudEvent(event) {
+			err := validateCloudEvent(event)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid CloudEvent: %w", err)
+			}
+			return data, cloudEventsContentType, nil
+		}
 	}
-	if a.metadata.SubscriptionID == "" {
-		return errors.New("metadata field 'SubscriptionID' is empty in EventGrid binding")
+
+	source := a.metadata.Name
+	if source == "" {
+		source = defaultCloudEventSource
 	}
-	if a.metadata.ClientID == "" {
-		return errors.New("metadata field 'ClientID' is empty in EventGrid binding")
+	event := map[string]any{
+		"specversion": cloudEventsSpecVersion,
+		"id":          valueOrDefault(req.Metadata[metadataCloudEventID], uuid.New().String()),
+		"source":      valueOrDefault(req.Metadata[metadataCloudEventSource], source),
+		"type":        valueOrDefault(req.Metadata[metadataCloudEventType], defaultCloudEventType),
+		"time":        time.Now().UTC().Format(time.RFC3339Nano),
 	}
-	if a.metadata.ClientSecret == "" {
-		return errors.New("metadata field 'ClientSecret' is empty in EventGrid binding")
+	if subject := req.Metadata[metadataCloudEventSubject]; subject != "" {
+		event["subject"] = subject
+	}
+	if len(req.Data) > 0 {
+		contentType := req.Metadata[metadataContentType]
+		switch {
+		case (contentType == "" || contenttype.IsJSONContentType(contentType)) && json.Valid(req.Data):
+			event["datacontenttype"] = valueOrDefault(contentType, contenttype.JSONContentType)
+			event["data"] = json.RawMessage(req.Data)
+		case contenttype.IsBinaryContentType(contentType) || !utf8.Valid(req.Data):
+			event["datacontenttype"] = valueOrDefault(contentType, "application/octet-stream")
+			event["data_base64"] = base64.StdEncoding.EncodeToString(req.Data)
+		default:
+			event["datacontenttype"] = valueOrDefault(contentType, "text/plain")
+			event["data"] = string(req.Data)
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, cloudEventsContentType, nil
+}
+
+// isCloudEvent returns true if a JSON object is a CloudEvent, rather than data to publish as a CloudEvent.
+func isCloudEvent(event map[string]json.RawMessage) bool {
+	_, ok := event["specversion"]
+	return ok
+}
+
+// validateCloudEvent checks the required attributes of a CloudEvent, which Event Grid rejects otherwise.
+func validateCloudEvent(event map[string]json.RawMessage) error {
+	var specVersion string
+	if json.Unmarshal(event["specversion"], &specVersion) != nil || specVersion != cloudEventsSpecVersion {
+		return fmt.Errorf("specversion must be %s", cloudEventsSpecVersion)
+	}
+	for _, attr := range []string{"id", "source", "type"} {
+		var v string
+		if json.Unmarshal(event[attr], &v) != nil || v == "" {
+			return fmt.Errorf("missing %s attribute", attr)
+		}
+	}
+	return nil
+}
+
+func valueOrDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func (a *AzureEventGrid) ensureInputBindingMetadata() error {
+	if a.metadata.SubscriptionID == "" {
+		return errors.New("metadata field 'SubscriptionID' is empty in EventGrid binding")
 	}
 	if a.metadata.SubscriberEndpoint == "" {
 		return errors.New("metadata field 'SubscriberEndpoint' is empty in EventGrid binding")
 	}
+	u, err := url.Parse(a.metadata.SubscriberEndpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		// Event Grid only delivers events to HTTPS endpoints
+		return fmt.Errorf("metadata field 'SubscriberEndpoint' must be an HTTPS URL in EventGrid binding: %s", a.metadata.SubscriberEndpoint)
+	}
 	if a.metadata.HandshakePort == "" {
 		return errors.New("metadata field 'HandshakePort' is empty in EventGrid binding")
 	}
@@ -206,11 +355,6 @@ func (a *AzureEventGrid) ensureInputBindingMetadata() error {
 }
 
 func (a *AzureEventGrid) ensureOutputBindingMetadata() error {
-	if a.metadata.AccessKey == "" {
-		msg := fmt.Sprintf("metadata field 'AccessKey' is empty in EventGrid binding (%s)", a.metadata.Name)
-
-		return errors.New(msg)
-	}
 	if a.metadata.TopicEndpoint == "" {
 		msg := fmt.Sprintf("metadata field 'TopicEndpoint' is empty in EventGrid binding (%s)", a.metadata.Name)
 
@@ -220,40 +364,56 @@ func (a *AzureEventGrid) ensureOutputBindingMetadata() error {
 	return nil
 }
 
-func (a *AzureEventGrid) parseMetadata(metadata bindings.Metadata) (*azureEventGridMetadata, error) {
-	b, err := json.Marshal(metadata.Properties)
-	if err != nil {
-		return nil, err
-	}
-
+func (a *AzureEventGrid) parseMetadata(md bindings.Metadata) (*azureEventGridMetadata, error) {
 	var eventGridMetadata azureEventGridMetadata
-	err = json.Unmarshal(b, &eventGridMetadata)
+	err := metadata.DecodeMetadata(md.Properties, &eventGridMetadata)
 	if err != nil {
 		return nil, err
 	}
 
-	eventGridMetadata.Name = metadata.Name
+	eventGridMetadata.Name = md.Name
 
 	if eventGridMetadata.HandshakePort == "" {
-		eventGridMetadata.HandshakePort = "8080"
+		eventGridMetadata.HandshakePort = defaultHandshakePort
 	}
 
 	if eventGridMetadata.EventSubscriptionName == "" {
-		eventGridMetadata.EventSubscriptionName = metadata.Name
+		eventGridMetadata.EventSubscriptionName = md.Name
+	}
+
+	if eventGridMetadata.AllowedOrigins == "" {
+		eventGridMetadata.AllowedOrigins = defaultAllowedOrigin
 	}
 
 	return &eventGridMetadata, nil
 }
 
-func (a *AzureEventGrid) createSubscription(ctx context.Context) error {
-	clientCredentialsConfig := auth.NewClientCredentialsConfig(a.metadata.ClientID, a.metadata.ClientSecret, a.metadata.TenantID)
+// allowedOrigin returns true if the origin of a validation handshake is allowed.
+func (a *AzureEventGrid) allowedOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range strings.Split(a.metadata.AllowedOrigins, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
 
-	subscriptionClient := eventgrid.NewEventSubscriptionsClient(a.metadata.SubscriptionID)
-	subscriptionClient.AddToUserAgent(a.userAgent)
-	authorizer, err := clientCredentialsConfig.Authorizer()
+func (a *AzureEventGrid) createSubscription(ctx context.Context) error {
+	settings, err := azauth.NewEnvironmentSettings("azure", a.properties)
 	if err != nil {
 		return err
 	}
+	authorizer, err := settings.GetAuthorizer()
+	if err != nil {
+		return err
+	}
+
+	subscriptionClient := eventgrid.NewEventSubscriptionsClientWithBaseURI(settings.AzureEnvironment.ResourceManagerEndpoint, a.metadata.SubscriptionID)
+	subscriptionClient.AddToUserAgent(a.userAgent)
 	subscriptionClient.Authorizer = authorizer
 
 	eventInfo := eventgrid.EventSubscription{
@@ -278,7 +438,8 @@ func (a *AzureEventGrid) createSubscription(ctx context.Context) error {
 
 	res := result.FutureAPI.Response()
 
-	if res.StatusCode != fasthttp.StatusCreated {
+	// Existing subscriptions are updated with 200 OK
+	if res.StatusCode != fasthttp.StatusCreated && res.StatusCode != fasthttp.StatusOK {
 		bodyBytes, err := io.ReadAll(res.Body)
 		if err != nil {
 			a.logger.Debugf("Failed reading error body when creating or updating Event Grid subscription: %v", err)
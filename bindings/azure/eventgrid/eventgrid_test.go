@@ -14,11 +14,24 @@ limitations under the License.
 package eventgrid
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
 )
 
 func TestParseMetadata(t *testing.T) {
@@ -50,4 +63,241 @@ func TestParseMetadata(t *testing.T) {
 	assert.Equal(t, "a", meta.EventSubscriptionName)
 	assert.Equal(t, "a", meta.AccessKey)
 	assert.Equal(t, "a", meta.TopicEndpoint)
+	assert.Equal(t, defaultAllowedOrigin, meta.AllowedOrigins)
+}
+
+func TestEnsureInputBindingMetadata(t *testing.T) {
+	eh := AzureEventGrid{metadata: &azureEventGridMetadata{
+		SubscriptionID:     "sub",
+		SubscriberEndpoint: "https://example.com/api/events",
+		HandshakePort:      "8080",
+		Scope:              "/subscriptions/sub",
+	}}
+	assert.NoError(t, eh.ensureInputBindingMetadata())
+
+	eh.metadata.SubscriberEndpoint = "http://example.com/api/events"
+	assert.Error(t, eh.ensureInputBindingMetadata())
+}
+
+func TestBuildEvents(t *testing.T) {
+	eh := AzureEventGrid{metadata: &azureEventGridMetadata{Name: "eventgrid"}}
+
+	t.Run("CloudEvent", func(t *testing.T) {
+		data := []byte(`{"specversion":"1.0","id":"1","source":"app","type":"order.created","data":{"id":42}}`)
+		body, contentType, err := eh.buildEvents(&bindings.InvokeRequest{Data: data})
+		require.NoError(t, err)
+		assert.Equal(t, cloudEventsContentType, contentType)
+		assert.Equal(t, data, body)
+	})
+
+	t.Run("Batch of CloudEvents", func(t *testing.T) {
+		data := []byte(`[{"specversion":"1.0","id":"1","source":"app","type":"t"},{"specversion":"1.0","id":"2","source":"app","type":"t"}]`)
+		body, contentType, err := eh.buildEvents(&bindings.InvokeRequest{Data: data})
+		require.NoError(t, err)
+		assert.Equal(t, cloudEventsBatchContentType, contentType)
+		assert.Equal(t, data, body)
+	})
+
+	t.Run("Invalid CloudEvents", func(t *testing.T) {
+		_, _, err := eh.buildEvents(&bindings.InvokeRequest{Data: []byte(`{"specversion":"0.3","id":"1","source":"app","type":"t"}`)})
+		assert.Error(t, err)
+		_, _, err = eh.buildEvents(&bindings.InvokeRequest{Data: []byte(`[{"specversion":"1.0","id":"1","source":"app","type":"t"},{"specversion":"1.0","id":"2"}]`)})
+		assert.ErrorContains(t, err, "index 1")
+	})
+
+	t.Run("JSON data", func(t *testing.T) {
+		body, contentType, err := eh.buildEvents(&bindings.InvokeRequest{
+			Data: []byte(`{"id":42}`),
+			Metadata: map[string]string{
+				"cloudevent.id":      "order-42",
+				"cloudevent.type":    "order.created",
+				"cloudevent.subject": "orders/42",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, cloudEventsContentType, contentType)
+
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, "1.0", event["specversion"])
+		assert.Equal(t, "order-42", event["id"])
+		assert.Equal(t, "eventgrid", event["source"])
+		assert.Equal(t, "order.created", event["type"])
+		assert.Equal(t, "orders/42", event["subject"])
+		assert.Equal(t, "application/json", event["datacontenttype"])
+		assert.Equal(t, map[string]any{"id": float64(42)}, event["data"])
+		assert.NotEmpty(t, event["time"])
+	})
+
+	t.Run("Text and binary data", func(t *testing.T) {
+		body, _, err := eh.buildEvents(&bindings.InvokeRequest{Data: []byte("hello")})
+		require.NoError(t, err)
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, "text/plain", event["datacontenttype"])
+		assert.Equal(t, "hello", event["data"])
+		assert.Equal(t, defaultCloudEventType, event["type"])
+		assert.NotEmpty(t, event["id"])
+
+		body, _, err = eh.buildEvents(&bindings.InvokeRequest{Data: []byte{0xff, 0xfe}})
+		require.NoError(t, err)
+		event = nil
+		require.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, "application/octet-stream", event["datacontenttype"])
+		assert.Equal(t, "//4=", event["data_base64"])
+	})
+}
+
+type fakeCredential struct {
+	scopes []string
+}
+
+func (c *fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.scopes = opts.Scopes
+	if len(opts.Scopes) == 0 {
+		return azcore.AccessToken{}, errors.New("missing scope")
+	}
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestInvoke(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	newBinding := func(t *testing.T, props map[string]string) *AzureEventGrid {
+		eh := NewAzureEventGrid(logger.NewLogger("test")).(*AzureEventGrid)
+		props["topicEndpoint"] = srv.URL
+		require.NoError(t, eh.Init(bindings.Metadata{Base: metadata.Base{Name: "eventgrid", Properties: props}}))
+		return eh
+	}
+
+	t.Run("With access key", func(t *testing.T) {
+		eh := newBinding(t, map[string]string{"accessKey": "key"})
+		assert.Nil(t, eh.aadToken)
+
+		_, err := eh.Invoke(context.Background(), &bindings.InvokeRequest{Data: []byte(`{"id":42}`)})
+		require.NoError(t, err)
+		assert.Equal(t, "key", headers.Get("aeg-sas-key"))
+		assert.Empty(t, headers.Get("Authorization"))
+		assert.Equal(t, cloudEventsContentType, headers.Get("Content-Type"))
+		assert.Contains(t, string(body), `"data":{"id":42}`)
+	})
+
+	t.Run("With Microsoft Entra ID", func(t *testing.T) {
+		eh := newBinding(t, map[string]string{})
+		cred := &fakeCredential{}
+		eh.aadToken = cred
+
+		_, err := eh.Invoke(context.Background(), &bindings.InvokeRequest{Data: []byte("hello")})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+		assert.Empty(t, headers.Get("aeg-sas-key"))
+		assert.Equal(t, []string{eventGridScope}, cred.scopes)
+	})
+
+	t.Run("Failed request", func(t *testing.T) {
+		status = http.StatusUnauthorized
+		defer func() { status = http.StatusOK }()
+		eh := newBinding(t, map[string]string{"accessKey": "key"})
+
+		_, err := eh.Invoke(context.Background(), &bindings.InvokeRequest{Data: []byte("hello")})
+		assert.ErrorContains(t, err, "status 401")
+	})
+}
+
+func TestRequestHandler(t *testing.T) {
+	eh := AzureEventGrid{
+		logger:   logger.NewLogger("test"),
+		metadata: &azureEventGridMetadata{AllowedOrigins: defaultAllowedOrigin},
+	}
+	var received []*bindings.ReadResponse
+	var handlerErr error
+	handle := eh.requestHandler(func(ctx context.Context, msg *bindings.ReadResponse) ([]byte, error) {
+		received = append(received, msg)
+		return nil, handlerErr
+	})
+
+	request := func(method string, path string, headers map[string]string, body string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(path)
+		for k, v := range headers {
+			ctx.Request.Header.Set(k, v)
+		}
+		ctx.Request.SetBodyString(body)
+		handle(ctx)
+		return ctx
+	}
+
+	t.Run("CloudEvents validation handshake", func(t *testing.T) {
+		ctx := request(fasthttp.MethodOptions, webhookPath, map[string]string{"WebHook-Request-Origin": "eventgrid.azure.net"}, "")
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+		assert.Equal(t, "eventgrid.azure.net", string(ctx.Response.Header.Peek("WebHook-Allowed-Origin")))
+
+		ctx = request(fasthttp.MethodOptions, webhookPath, map[string]string{"WebHook-Request-Origin": "attacker.example.com"}, "")
+		assert.Equal(t, fasthttp.StatusForbidden, ctx.Response.StatusCode())
+		assert.Empty(t, ctx.Response.Header.Peek("WebHook-Allowed-Origin"))
+
+		ctx = request(fasthttp.MethodOptions, webhookPath, nil, "")
+		assert.Equal(t, fasthttp.StatusForbidden, ctx.Response.StatusCode())
+	})
+
+	t.Run("Subscription validation code", func(t *testing.T) {
+		ctx := request(fasthttp.MethodPost, webhookPath, map[string]string{"aeg-event-type": "SubscriptionValidation"},
+			`[{"id":"1","eventType":"Microsoft.EventGrid.SubscriptionValidationEvent","data":{"validationCode":"512d38b6","validationUrl":"https://example.com"}}]`)
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+		assert.JSONEq(t, `{"validationResponse":"512d38b6"}`, string(ctx.Response.Body()))
+
+		ctx = request(fasthttp.MethodPost, webhookPath, map[string]string{"aeg-event-type": "SubscriptionValidation"}, `[{"eventType":"other"}]`)
+		assert.Equal(t, fasthttp.StatusBadRequest, ctx.Response.StatusCode())
+		assert.Empty(t, received)
+	})
+
+	t.Run("CloudEvent", func(t *testing.T) {
+		received = nil
+		ctx := request(fasthttp.MethodPost, webhookPath, nil, `{"specversion":"1.0","id":"1","source":"app","type":"t","data":{"id":42}}`)
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+		require.Len(t, received, 1)
+		assert.Equal(t, "1", received[0].Metadata["id"])
+		assert.Equal(t, "t", received[0].Metadata["type"])
+		assert.NotContains(t, received[0].Metadata, "data")
+		assert.Equal(t, cloudEventsContentType, *received[0].ContentType)
+	})
+
+	t.Run("Batch of events", func(t *testing.T) {
+		received = nil
+		ctx := request(fasthttp.MethodPost, webhookPath, nil, `[{"specversion":"1.0","id":"1","source":"app","type":"t"},{"specversion":"1.0","id":"2","source":"app","type":"t"}]`)
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+		require.Len(t, received, 2)
+		assert.Equal(t, "2", received[1].Metadata["id"])
+	})
+
+	t.Run("Failed handler", func(t *testing.T) {
+		handlerErr = errors.New("failed")
+		defer func() { handlerErr = nil }()
+		ctx := request(fasthttp.MethodPost, webhookPath, nil, `{"specversion":"1.0","id":"1","source":"app","type":"t"}`)
+		assert.Equal(t, fasthttp.StatusInternalServerError, ctx.Response.StatusCode())
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		received = nil
+		assert.Equal(t, fasthttp.StatusBadRequest, request(fasthttp.MethodPost, webhookPath, nil, "not json").Response.StatusCode())
+		assert.Equal(t, fasthttp.StatusNotFound, request(fasthttp.MethodPost, "/other", nil, "{}").Response.StatusCode())
+		assert.Equal(t, fasthttp.StatusMethodNotAllowed, request(fasthttp.MethodGet, webhookPath, nil, "").Response.StatusCode())
+		assert.Empty(t, received)
+	})
+
+	t.Run("Any origin", func(t *testing.T) {
+		eh.metadata.AllowedOrigins = "*"
+		defer func() { eh.metadata.AllowedOrigins = defaultAllowedOrigin }()
+		ctx := request(fasthttp.MethodOptions, webhookPath, map[string]string{"WebHook-Request-Origin": "localhost"}, "")
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	})
 }
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventgrid
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	webhookPath = "/api/events"
+
+	// Event Grid schema subscriptions are validated with a code sent in a validation event, which must be returned.
+	eventTypeHeader             = "aeg-event-type"
+	subscriptionValidationType  = "SubscriptionValidation"
+	subscriptionValidationEvent = "Microsoft.EventGrid.SubscriptionValidationEvent"
+)
+
+// validationEvent is the event validating the endpoint of a subscription with the Event Grid schema.
+type validationEvent struct {
+	EventType string `json:"eventType"`
+	Data      struct {
+		ValidationCode string `json:"validationCode"`
+	} `json:"data"`
+}
+
+// requestHandler returns the handler of the requests of Event Grid to the webhook: the validation handshakes, and the
+// deliveries of events, each of which is passed to the handler of the binding.
+func (a *AzureEventGrid) requestHandler(handler bindings.Handler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) != webhookPath {
+			ctx.Error("not found", fasthttp.StatusNotFound)
+			return
+		}
+
+		switch string(ctx.Method()) {
+		case fasthttp.MethodOptions:
+			a.handleAbuseProtection(ctx)
+		case fasthttp.MethodPost:
+			if string(ctx.Request.Header.Peek(eventTypeHeader)) == subscriptionValidationType {
+				a.handleSubscriptionValidation(ctx)
+				return
+			}
+			a.handleEvents(ctx, handler)
+		default:
+			ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleAbuseProtection performs the validation handshake of CloudEvents webhooks, which Event Grid uses with the
+// CloudEvents schema: the endpoint accepts the deliveries of the origin of the request, if it's allowed.
+// See https://github.com/cloudevents/spec/blob/v1.0/http-webhook.md#4-abuse-protection
+func (a *AzureEventGrid) handleAbuseProtection(ctx *fasthttp.RequestCtx) {
+	origin := string(ctx.Request.Header.Peek("WebHook-Request-Origin"))
+	if !a.allowedOrigin(origin) {
+		a.logger.Warnf("Rejected Event Grid validation handshake from origin %q", origin)
+		ctx.Error("origin not allowed", fasthttp.StatusForbidden)
+		return
+	}
+
+	ctx.Response.Header.Set("WebHook-Allowed-Origin", origin)
+	ctx.Response.Header.Set("WebHook-Allowed-Rate", "*")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
+// handleSubscriptionValidation responds to the validation event of subscriptions with the Event Grid schema with its
+// validation code.
+func (a *AzureEventGrid) handleSubscriptionValidation(ctx *fasthttp.RequestCtx) {
+	var events []validationEvent
+	err := json.Unmarshal(ctx.PostBody(), &events)
+	if err != nil || len(events) == 0 || events[0].EventType != subscriptionValidationEvent || events[0].Data.ValidationCode == "" {
+		ctx.Error("invalid subscription validation event", fasthttp.StatusBadRequest)
+		return
+	}
+
+	res, err := json.Marshal(map[string]string{"validationResponse": events[0].Data.ValidationCode})
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+	a.logger.Info("Validated Event Grid subscription")
+	ctx.SetContentType("application/json")
+	ctx.SetBody(res)
+}
+
+// handleEvents passes the events of a delivery to the handler, in order.
+// Batches are retried as a whole when an event fails.
+func (a *AzureEventGrid) handleEvents(ctx *fasthttp.RequestCtx, handler bindings.Handler) {
+	events, err := splitEvents(ctx.PostBody())
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
+
+	for _, event := range events {
+		md := eventMetadata(event)
+		contentType := cloudEventsContentType
+		if _, ok := md["specversion"]; !ok {
+			// Event of a subscription with the Event Grid schema
+			contentType = "application/json"
+		}
+		_, err = handler(ctx, &bindings.ReadResponse{
+			Data:        event,
+			Metadata:    md,
+			ContentType: ptr.Of(contentType),
+		})
+		if err != nil {
+			a.logger.Error(err.Error())
+			ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+			return
+		}
+	}
+	ctx.SetStatusCode(fasthttp.StatusOK)
+}
+
+// splitEvents returns the events of the body of a delivery: a single event, or a batch of events.
+func splitEvents(body []byte) ([]json.RawMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, errors.New("empty delivery")
+	}
+
+	if body[0] == '[' {
+		var events []json.RawMessage
+		err := json.Unmarshal(body, &events)
+		if err != nil {
+			return nil, errors.New("invalid batch of events")
+		}
+		return events, nil
+	}
+
+	if !json.Valid(body) {
+		return nil, errors.New("invalid event")
+	}
+	return []json.RawMessage{body}, nil
+}
+
+// eventMetadata returns the attributes of an event, such as its id, source, type and subject, as metadata of the
+// response of the binding.
+func eventMetadata(event []byte) map[string]string {
+	var attrs map[string]json.RawMessage
+	if json.Unmarshal(event, &attrs) != nil {
+		return nil
+	}
+
+	md := make(map[string]string, len(attrs))
+	for name, value := range attrs {
+		if name == "data" || name == "data_base64" {
+			continue
+		}
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			md[name] = s
+		}
+	}
+	return md
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-amqp-common-go/v3/aad"
@@ -31,6 +32,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -127,6 +129,10 @@ type AzureEventHubs struct {
 	azureEnvironment  *azure.Environment
 	logger            logger.Logger
 	userAgent         string
+
+	// Senders for the partitions requested in the metadata of each message
+	partitionSenders     map[string]*eventhub.Hub
+	partitionSendersLock sync.Mutex
 }
 
 type azureEventHubsMetadata struct {
@@ -242,6 +248,12 @@ func (a *AzureEventHubs) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (a *AzureEventHubs) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString, metadata.AuthProfileAzureAD},
+	}
+}
+
 func parseMetadata(meta bindings.Metadata) (*azureEventHubsMetadata, error) {
 	m := &azureEventHubsMetadata{}
 
@@ -301,22 +313,36 @@ func (a *AzureEventHubs) Operations() []bindings.OperationKind {
 }
 
 // Write posts an event hubs message.
+// The partitionKey or partitionID metadata of the request, if any, take precedence over the ones of the component.
 func (a *AzureEventHubs) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	event := &eventhub.Event{
 		Data: req.Data,
 	}
 
-	// Send partitionKey in event.
-	if a.metadata.partitionKey != "" {
-		event.PartitionKey = &a.metadata.partitionKey
-	} else {
-		partitionKey, ok := req.Metadata[partitionKeyName]
-		if partitionKey != "" && ok {
-			event.PartitionKey = &partitionKey
+	partitionKey := req.Metadata[partitionKeyName]
+	partitionID := req.Metadata[partitionIDName]
+	if partitionKey != "" && partitionID != "" {
+		return nil, fmt.Errorf("only one of %s and %s can be set", partitionKeyName, partitionIDName)
+	}
+
+	sender := a.hub
+	if partitionID != "" && partitionID != a.metadata.partitionID {
+		var err error
+		sender, err = a.getPartitionSender(partitionID)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	err := a.hub.Send(ctx, event)
+	// Send partitionKey in event.
+	if partitionKey == "" && partitionID == "" {
+		partitionKey = a.metadata.partitionKey
+	}
+	if partitionKey != "" {
+		event.PartitionKey = &partitionKey
+	}
+
+	err := sender.Send(ctx, event)
 	if err != nil {
 		return nil, err
 	}
@@ -324,6 +350,40 @@ func (a *AzureEventHubs) Invoke(ctx context.Context, req *bindings.InvokeRequest
 	return nil, nil
 }
 
+// getPartitionSender returns a client sending events to the given partition.
+func (a *AzureEventHubs) getPartitionSender(partitionID string) (*eventhub.Hub, error) {
+	a.partitionSendersLock.Lock()
+	defer a.partitionSendersLock.Unlock()
+
+	if sender, ok := a.partitionSenders[partitionID]; ok {
+		return sender, nil
+	}
+
+	var (
+		sender *eventhub.Hub
+		err    error
+	)
+	opts := []eventhub.HubOption{
+		eventhub.HubWithPartitionedSender(partitionID),
+		eventhub.HubWithUserAgent(a.userAgent),
+	}
+	if a.metadata.connectionString != "" {
+		sender, err = eventhub.NewHubFromConnectionString(a.metadata.connectionString, opts...)
+	} else {
+		sender, err = eventhub.NewHub(a.metadata.eventHubNamespaceName, a.metadata.eventHubName, a.tokenProvider, opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to partition %s of azure event hubs: %w", partitionID, err)
+	}
+
+	if a.partitionSenders == nil {
+		a.partitionSenders = make(map[string]*eventhub.Hub)
+	}
+	a.partitionSenders[partitionID] = sender
+
+	return sender, nil
+}
+
 // Read gets messages from eventhubs in a non-blocking way.
 func (a *AzureEventHubs) Read(ctx context.Context, handler bindings.Handler) error {
 	if !a.metadata.partitioned() {
@@ -442,5 +502,17 @@ func (a *AzureEventHubs) Close() (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	err = a.hub.Close(ctx)
 	cancel()
+
+	a.partitionSendersLock.Lock()
+	defer a.partitionSendersLock.Unlock()
+	for partitionID, sender := range a.partitionSenders {
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		if closeErr := sender.Close(ctx); closeErr != nil {
+			a.logger.Warnf("error closing sender for partition %s: %v", partitionID, closeErr)
+		}
+		cancel()
+	}
+	a.partitionSenders = nil
+
 	return err
 }
@@ -14,6 +14,7 @@ limitations under the License.
 package eventhubs
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -120,3 +121,12 @@ func TestParseMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestInvokeWithPartitionKeyAndPartitionID(t *testing.T) {
+	aeh := &AzureEventHubs{logger: testLogger, metadata: &azureEventHubsMetadata{}}
+	_, err := aeh.Invoke(context.Background(), &bindings.InvokeRequest{
+		Data:     []byte("hello"),
+		Metadata: map[string]string{"partitionKey": "key1", "partitionID": "2"},
+	})
+	assert.Error(t, err)
+}
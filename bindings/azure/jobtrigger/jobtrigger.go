@@ -0,0 +1,313 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobtrigger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/dapr/components-contrib/bindings"
+	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/internal/httputils"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	errorPrefix = "azure job trigger error:"
+	logPrefix   = "azure job trigger:"
+
+	// StartJobOperation starts an execution of an Azure Container Apps job.
+	StartJobOperation bindings.OperationKind = "startJob"
+	// InvokeFunctionOperation calls an HTTP-triggered Azure Function.
+	InvokeFunctionOperation bindings.OperationKind = "invokeFunction"
+	// TriggerFunctionOperation runs a non HTTP-triggered Azure Function through the admin endpoint of the Function App.
+	TriggerFunctionOperation bindings.OperationKind = "triggerFunction"
+
+	// Metadata keys.
+	// Azure AD credentials are parsed separately and not listed here.
+	subscriptionIDKey   = "subscriptionId"
+	resourceGroupKey    = "resourceGroup"
+	jobNameKey          = "jobName"
+	functionAppURLKey   = "functionAppUrl"
+	functionNameKey     = "functionName"
+	functionKeyKey      = "functionKey"
+	functionAudienceKey = "functionAudience"
+
+	// Invoke metadata keys.
+	httpMethodKey = "httpMethod"
+
+	// Response metadata keys.
+	statusCodeKey    = "statusCode"
+	executionNameKey = "executionName"
+
+	containerAppsAPIVersion = "2023-05-01"
+)
+
+// NewJobTrigger returns a new output binding that starts Azure Container Apps jobs and calls Azure Functions.
+func NewJobTrigger(logger logger.Logger) bindings.OutputBinding {
+	return &JobTrigger{logger: logger}
+}
+
+// JobTrigger is an output binding for offloading work to Azure Container Apps jobs and Azure Functions.
+type JobTrigger struct {
+	subscriptionID   string
+	resourceGroup    string
+	jobName          string
+	functionAppURL   string
+	functionName     string
+	functionKey      string
+	functionAudience string
+	armEndpoint      string
+	armAudience      string
+	userAgent        string
+	aadToken         azcore.TokenCredential
+
+	httpClient *http.Client
+	logger     logger.Logger
+}
+
+// Init is responsible for initializing the binding based on the metadata.
+func (j *JobTrigger) Init(metadata bindings.Metadata) error {
+	j.userAgent = "dapr-" + logger.DaprVersion
+
+	err := j.parseMetadata(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
+	transport, err := httputils.NewTransport(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	j.httpClient = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+
+	settings, err := azauth.NewEnvironmentSettings("azure", metadata.Properties)
+	if err != nil {
+		return err
+	}
+	j.armEndpoint = strings.TrimSuffix(settings.AzureEnvironment.ResourceManagerEndpoint, "/")
+	j.armAudience = strings.TrimSuffix(settings.AzureEnvironment.TokenAudience, "/")
+
+	// Functions called with a key don't need an Azure AD token, but jobs are always started with one
+	if j.functionKey == "" || j.subscriptionID != "" {
+		j.aadToken, err = settings.GetTokenCredential()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *JobTrigger) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAzureAD, metadata.AuthProfileToken},
+	}
+}
+
+func (j *JobTrigger) parseMetadata(md map[string]string) error {
+	j.subscriptionID = md[subscriptionIDKey]
+	j.resourceGroup = md[resourceGroupKey]
+	j.jobName = md[jobNameKey]
+	j.functionAppURL = strings.TrimSuffix(md[functionAppURLKey], "/")
+	j.functionName = md[functionNameKey]
+	j.functionKey = md[functionKeyKey]
+	j.functionAudience = strings.TrimSuffix(md[functionAudienceKey], "/")
+
+	if j.subscriptionID == "" && j.functionAppURL == "" {
+		return fmt.Errorf("%s either %s or %s is required", errorPrefix, subscriptionIDKey, functionAppURLKey)
+	}
+	if j.functionAppURL != "" && j.functionKey == "" && j.functionAudience == "" {
+		return fmt.Errorf("%s %s or %s is required to call Azure Functions", errorPrefix, functionKeyKey, functionAudienceKey)
+	}
+
+	return nil
+}
+
+func (j *JobTrigger) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{StartJobOperation, InvokeFunctionOperation, TriggerFunctionOperation}
+}
+
+func (j *JobTrigger) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation { //nolint:exhaustive
+	case StartJobOperation:
+		return j.startJob(ctx, req)
+	case InvokeFunctionOperation, TriggerFunctionOperation:
+		return j.callFunction(ctx, req)
+	default:
+		return nil, fmt.Errorf("%s unsupported operation %s", errorPrefix, req.Operation)
+	}
+}
+
+func (j *JobTrigger) startJob(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if j.subscriptionID == "" {
+		return nil, fmt.Errorf("%s %s is required to start jobs", errorPrefix, subscriptionIDKey)
+	}
+	resourceGroup := metadataOrDefault(req.Metadata, resourceGroupKey, j.resourceGroup)
+	jobName := metadataOrDefault(req.Metadata, jobNameKey, j.jobName)
+	if resourceGroup == "" || jobName == "" {
+		return nil, fmt.Errorf("%s %s and %s are required to start jobs", errorPrefix, resourceGroupKey, jobNameKey)
+	}
+
+	u := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.App/jobs/%s/start?api-version=%s",
+		j.armEndpoint, url.PathEscape(j.subscriptionID), url.PathEscape(resourceGroup), url.PathEscape(jobName), containerAppsAPIVersion)
+
+	token, err := j.getAADToken(ctx, j.armAudience)
+	if err != nil {
+		return nil, err
+	}
+
+	// The request data, if any, is the execution template overriding the containers of the job
+	status, body, err := j.send(ctx, http.MethodPost, u, req.Data, map[string]string{"Authorization": "Bearer " + token})
+	if err != nil {
+		return nil, err
+	}
+
+	responseMetadata := map[string]string{statusCodeKey: strconv.Itoa(status)}
+	var execution struct {
+		Name string `json:"name"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &execution) == nil && execution.Name != "" {
+		responseMetadata[executionNameKey] = execution.Name
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     body,
+		Metadata: responseMetadata,
+	}, nil
+}
+
+func (j *JobTrigger) callFunction(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if j.functionAppURL == "" {
+		return nil, fmt.Errorf("%s %s is required to call functions", errorPrefix, functionAppURLKey)
+	}
+	functionName := metadataOrDefault(req.Metadata, functionNameKey, j.functionName)
+	if functionName == "" {
+		return nil, fmt.Errorf("%s missing %s", errorPrefix, functionNameKey)
+	}
+
+	method := http.MethodPost
+	data := req.Data
+	var u string
+	if req.Operation == TriggerFunctionOperation {
+		// The admin endpoint passes the input to the trigger of the function as a string
+		u = fmt.Sprintf("%s/admin/functions/%s", j.functionAppURL, url.PathEscape(functionName))
+		var err error
+		data, err = json.Marshal(map[string]string{"input": string(req.Data)})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		u = fmt.Sprintf("%s/api/%s", j.functionAppURL, url.PathEscape(functionName))
+		method = strings.ToUpper(metadataOrDefault(req.Metadata, httpMethodKey, http.MethodPost))
+	}
+
+	headers := map[string]string{}
+	if j.functionKey != "" {
+		headers["x-functions-key"] = j.functionKey
+	} else {
+		token, err := j.getAADToken(ctx, j.functionAudience)
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	status, body, err := j.send(ctx, method, u, data, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     body,
+		Metadata: map[string]string{statusCodeKey: strconv.Itoa(status)},
+	}, nil
+}
+
+func (j *JobTrigger) send(ctx context.Context, method string, u string, data []byte, headers map[string]string) (int, []byte, error) {
+	var reqBody io.Reader
+	if len(data) > 0 {
+		reqBody = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+	if reqBody != nil {
+		httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	httpReq.Header.Set("User-Agent", j.userAgent)
+
+	resp, err := j.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s request to '%s' failed: %w", errorPrefix, u, err)
+	}
+	defer resp.Body.Close()
+
+	// Read the body regardless to drain it and ensure the connection can be reused
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, nil, fmt.Errorf("%s request to '%s' failed with code %d, content is '%s'", errorPrefix, u, resp.StatusCode, string(body))
+	}
+
+	j.logger.Debugf("%s call to '%s' completed with code %d", logPrefix, u, resp.StatusCode)
+
+	return resp.StatusCode, body, nil
+}
+
+// Returns an Azure AD access token for the given audience
+func (j *JobTrigger) getAADToken(ctx context.Context, audience string) (string, error) {
+	if j.aadToken == nil {
+		return "", fmt.Errorf("%s no Azure AD credentials configured", errorPrefix)
+	}
+	at, err := j.aadToken.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{audience + "/.default"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return at.Token, nil
+}
+
+func metadataOrDefault(md map[string]string, key string, defaultValue string) string {
+	if v, ok := md[key]; ok && v != "" {
+		return v
+	}
+
+	return defaultValue
+}
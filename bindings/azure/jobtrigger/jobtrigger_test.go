@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobtrigger
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeCredential struct {
+	scopes []string
+}
+
+func (f *fakeCredential) GetToken(_ context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.scopes = opts.Scopes
+
+	return azcore.AccessToken{Token: "aad-token"}, nil
+}
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	body   string
+}
+
+func newTestServer(t *testing.T, status int, response string, rec *recordedRequest) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*rec = recordedRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.RawQuery,
+			header: r.Header,
+			body:   string(body),
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("container apps job", func(t *testing.T) {
+		j := &JobTrigger{}
+		err := j.parseMetadata(map[string]string{"subscriptionId": "sub", "resourceGroup": "rg", "jobName": "job"})
+		require.NoError(t, err)
+		assert.Equal(t, "sub", j.subscriptionID)
+		assert.Equal(t, "rg", j.resourceGroup)
+		assert.Equal(t, "job", j.jobName)
+	})
+
+	t.Run("function app", func(t *testing.T) {
+		j := &JobTrigger{}
+		err := j.parseMetadata(map[string]string{"functionAppUrl": "https://app.azurewebsites.net/", "functionKey": "key"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://app.azurewebsites.net", j.functionAppURL)
+		assert.Equal(t, "key", j.functionKey)
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		j := &JobTrigger{}
+		assert.Error(t, j.parseMetadata(map[string]string{}))
+	})
+
+	t.Run("function app without auth", func(t *testing.T) {
+		j := &JobTrigger{}
+		assert.Error(t, j.parseMetadata(map[string]string{"functionAppUrl": "https://app.azurewebsites.net"}))
+	})
+}
+
+func TestStartJob(t *testing.T) {
+	var rec recordedRequest
+	srv := newTestServer(t, http.StatusAccepted, `{"id":"/jobs/job/executions/job-abc","name":"job-abc"}`, &rec)
+	cred := &fakeCredential{}
+	j := &JobTrigger{
+		subscriptionID: "sub",
+		resourceGroup:  "rg",
+		jobName:        "job",
+		armEndpoint:    srv.URL,
+		armAudience:    "https://management.azure.com",
+		aadToken:       cred,
+		httpClient:     srv.Client(),
+		logger:         logger.NewLogger("test"),
+	}
+
+	resp, err := j.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: StartJobOperation,
+		Metadata:  map[string]string{"jobName": "other"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "job-abc", resp.Metadata[executionNameKey])
+	assert.Equal(t, "202", resp.Metadata[statusCodeKey])
+	assert.Equal(t, http.MethodPost, rec.method)
+	assert.Equal(t, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.App/jobs/other/start", rec.path)
+	assert.Equal(t, "api-version="+containerAppsAPIVersion, rec.query)
+	assert.Equal(t, "Bearer aad-token", rec.header.Get("Authorization"))
+	assert.Equal(t, []string{"https://management.azure.com/.default"}, cred.scopes)
+}
+
+func TestCallFunction(t *testing.T) {
+	newBinding := func(srv *httptest.Server) *JobTrigger {
+		return &JobTrigger{
+			functionAppURL: srv.URL,
+			functionName:   "fn",
+			functionKey:    "key",
+			httpClient:     srv.Client(),
+			logger:         logger.NewLogger("test"),
+		}
+	}
+
+	t.Run("invoke HTTP function with key", func(t *testing.T) {
+		var rec recordedRequest
+		srv := newTestServer(t, http.StatusOK, "done", &rec)
+		j := newBinding(srv)
+
+		resp, err := j.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: InvokeFunctionOperation,
+			Data:      []byte(`{"a":1}`),
+			Metadata:  map[string]string{"httpMethod": "put"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "done", string(resp.Data))
+		assert.Equal(t, http.MethodPut, rec.method)
+		assert.Equal(t, "/api/fn", rec.path)
+		assert.Equal(t, "key", rec.header.Get("x-functions-key"))
+		assert.Equal(t, `{"a":1}`, rec.body)
+	})
+
+	t.Run("trigger function with managed identity", func(t *testing.T) {
+		var rec recordedRequest
+		srv := newTestServer(t, http.StatusAccepted, "", &rec)
+		cred := &fakeCredential{}
+		j := newBinding(srv)
+		j.functionKey = ""
+		j.functionAudience = "api://func-app"
+		j.aadToken = cred
+
+		resp, err := j.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerFunctionOperation,
+			Data:      []byte(`{"a":1}`),
+			Metadata:  map[string]string{"functionName": "queued"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "202", resp.Metadata[statusCodeKey])
+		assert.Equal(t, "/admin/functions/queued", rec.path)
+		assert.Equal(t, "Bearer aad-token", rec.header.Get("Authorization"))
+		assert.JSONEq(t, `{"input":"{\"a\":1}"}`, rec.body)
+		assert.Equal(t, []string{"api://func-app/.default"}, cred.scopes)
+	})
+
+	t.Run("failed call", func(t *testing.T) {
+		var rec recordedRequest
+		srv := newTestServer(t, http.StatusInternalServerError, "boom", &rec)
+		j := newBinding(srv)
+
+		_, err := j.Invoke(context.Background(), &bindings.InvokeRequest{Operation: InvokeFunctionOperation})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+	})
+
+	t.Run("start job without subscription", func(t *testing.T) {
+		var rec recordedRequest
+		srv := newTestServer(t, http.StatusOK, "", &rec)
+		j := newBinding(srv)
+
+		_, err := j.Invoke(context.Background(), &bindings.InvokeRequest{Operation: StartJobOperation})
+		assert.Error(t, err)
+	})
+}
@@ -17,6 +17,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
@@ -24,13 +26,20 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	impl "github.com/dapr/components-contrib/internal/component/azure/servicebus"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
 const (
-	correlationID = "correlationID"
-	label         = "label"
-	id            = "id"
+	correlationID  = "correlationID"
+	label          = "label"
+	id             = "id"
+	sequenceNumber = "sequenceNumber"
+
+	// DeferOperation defers a message being processed by the input binding, identified by the sequenceNumber metadata.
+	DeferOperation bindings.OperationKind = "defer"
+	// ReceiveDeferredOperation receives and removes from the queue a deferred message, identified by the sequenceNumber metadata.
+	ReceiveDeferredOperation bindings.OperationKind = "receiveDeferred"
 )
 
 // AzureServiceBusQueues is an input/output binding reading from and sending events to Azure Service Bus queues.
@@ -39,6 +48,10 @@ type AzureServiceBusQueues struct {
 	client   *impl.Client
 	timeout  time.Duration
 	logger   logger.Logger
+
+	// Subscription of the input binding, used to defer the messages being processed
+	subscription     *impl.Subscription
+	subscriptionLock sync.RWMutex
 }
 
 // NewAzureServiceBusQueues returns a new AzureServiceBusQueues instance.
@@ -70,11 +83,29 @@ func (a *AzureServiceBusQueues) Init(metadata bindings.Metadata) (err error) {
 	return nil
 }
 
+func (a *AzureServiceBusQueues) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: []metadata.Capability{metadata.CapabilityTTL},
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString, metadata.AuthProfileAzureAD},
+	}
+}
+
 func (a *AzureServiceBusQueues) Operations() []bindings.OperationKind {
-	return []bindings.OperationKind{bindings.CreateOperation}
+	return []bindings.OperationKind{bindings.CreateOperation, DeferOperation, ReceiveDeferredOperation}
 }
 
 func (a *AzureServiceBusQueues) Invoke(invokeCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case DeferOperation:
+		return a.deferMessage(invokeCtx, req)
+	case ReceiveDeferredOperation:
+		return a.receiveDeferred(invokeCtx, req)
+	default:
+		return a.sendMessage(invokeCtx, req)
+	}
+}
+
+func (a *AzureServiceBusQueues) sendMessage(invokeCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	sender, err := a.client.GetSender(invokeCtx, a.metadata.QueueName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a sender for the Service Bus queue: %w", err)
@@ -100,6 +131,73 @@ func (a *AzureServiceBusQueues) Invoke(invokeCtx context.Context, req *bindings.
 	return nil, nil
 }
 
+func (a *AzureServiceBusQueues) deferMessage(invokeCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	seq, err := getSequenceNumber(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	a.subscriptionLock.RLock()
+	sub := a.subscription
+	a.subscriptionLock.RUnlock()
+	if sub == nil {
+		return nil, errors.New("messages can only be deferred while they are processed by the input binding")
+	}
+
+	ctx, cancel := context.WithTimeout(invokeCtx, a.timeout)
+	defer cancel()
+	err = sub.DeferMessage(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (a *AzureServiceBusQueues) receiveDeferred(invokeCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	seq, err := getSequenceNumber(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := a.client.GetClient().NewReceiverForQueue(a.metadata.QueueName, &servicebus.ReceiverOptions{
+		ReceiveMode: servicebus.ReceiveModeReceiveAndDelete,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a receiver for the Service Bus queue: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(invokeCtx, a.timeout)
+	defer cancel()
+	defer receiver.Close(ctx)
+
+	msgs, err := receiver.ReceiveDeferredMessages(ctx, []int64{seq}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive deferred message with sequence number %d: %w", seq, err)
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("deferred message with sequence number %d not found", seq)
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     msgs[0].Body,
+		Metadata: getMessageMetadata(msgs[0]),
+	}, nil
+}
+
+func getSequenceNumber(md map[string]string) (int64, error) {
+	val, ok := md[sequenceNumber]
+	if !ok || val == "" {
+		return 0, fmt.Errorf("metadata property %s is required", sequenceNumber)
+	}
+	seq, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s: %w", sequenceNumber, val, err)
+	}
+
+	return seq, nil
+}
+
 func (a *AzureServiceBusQueues) Read(subscribeCtx context.Context, handler bindings.Handler) error {
 	// Reconnection backoff policy
 	bo := backoff.NewExponentialBackOff()
@@ -132,6 +230,7 @@ func (a *AzureServiceBusQueues) Read(subscribeCtx context.Context, handler bindi
 				}
 				return
 			}
+			a.setSubscription(sub)
 
 			// ReceiveAndBlock will only return with an error that it cannot handle internally. The subscription connection is closed when this method returns.
 			// If that occurs, we will log the error and attempt to re-establish the subscription connection until we exhaust the number of reconnect attempts.
@@ -150,6 +249,7 @@ func (a *AzureServiceBusQueues) Read(subscribeCtx context.Context, handler bindi
 
 			// Gracefully close the connection (in case it's not closed already)
 			// Use a background context here (with timeout) because ctx may be closed already
+			a.setSubscription(nil)
 			closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second*time.Duration(a.metadata.TimeoutInSec))
 			sub.Close(closeCtx)
 			closeCancel()
@@ -169,6 +269,12 @@ func (a *AzureServiceBusQueues) Read(subscribeCtx context.Context, handler bindi
 	return nil
 }
 
+func (a *AzureServiceBusQueues) setSubscription(sub *impl.Subscription) {
+	a.subscriptionLock.Lock()
+	a.subscription = sub
+	a.subscriptionLock.Unlock()
+}
+
 func (a *AzureServiceBusQueues) getHandlerFunc(handler bindings.Handler) impl.HandlerFunc {
 	return func(ctx context.Context, asbMsgs []*servicebus.ReceivedMessage) ([]impl.HandlerResponseItem, error) {
 		if len(asbMsgs) != 1 {
@@ -176,30 +282,37 @@ func (a *AzureServiceBusQueues) getHandlerFunc(handler bindings.Handler) impl.Ha
 		}
 
 		msg := asbMsgs[0]
-		metadata := make(map[string]string)
-		metadata[id] = msg.MessageID
-		if msg.CorrelationID != nil {
-			metadata[correlationID] = *msg.CorrelationID
-		}
-		if msg.Subject != nil {
-			metadata[label] = *msg.Subject
-		}
-
-		// Passthrough any custom metadata to the handler.
-		for key, val := range msg.ApplicationProperties {
-			if stringVal, ok := val.(string); ok {
-				metadata[key] = stringVal
-			}
-		}
-
 		_, err := handler(ctx, &bindings.ReadResponse{
 			Data:     msg.Body,
-			Metadata: metadata,
+			Metadata: getMessageMetadata(msg),
 		})
 		return []impl.HandlerResponseItem{}, err
 	}
 }
 
+func getMessageMetadata(msg *servicebus.ReceivedMessage) map[string]string {
+	metadata := make(map[string]string)
+	metadata[id] = msg.MessageID
+	if msg.CorrelationID != nil {
+		metadata[correlationID] = *msg.CorrelationID
+	}
+	if msg.Subject != nil {
+		metadata[label] = *msg.Subject
+	}
+	if msg.SequenceNumber != nil {
+		metadata[sequenceNumber] = strconv.FormatInt(*msg.SequenceNumber, 10)
+	}
+
+	// Passthrough any custom metadata to the handler.
+	for key, val := range msg.ApplicationProperties {
+		if stringVal, ok := val.(string); ok {
+			metadata[key] = stringVal
+		}
+	}
+
+	return metadata
+}
+
 func (a *AzureServiceBusQueues) Close() (err error) {
 	a.logger.Debug("Closing component")
 	a.client.CloseSender(a.metadata.QueueName)
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebusqueues
+
+import (
+	"context"
+	"testing"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestGetSequenceNumber(t *testing.T) {
+	seq, err := getSequenceNumber(map[string]string{"sequenceNumber": "42"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), seq)
+
+	_, err = getSequenceNumber(map[string]string{})
+	assert.Error(t, err)
+
+	_, err = getSequenceNumber(map[string]string{"sequenceNumber": "abc"})
+	assert.Error(t, err)
+}
+
+func TestGetMessageMetadata(t *testing.T) {
+	md := getMessageMetadata(&servicebus.ReceivedMessage{
+		MessageID:      "id1",
+		CorrelationID:  ptr.Of("corr1"),
+		Subject:        ptr.Of("label1"),
+		SequenceNumber: ptr.Of(int64(7)),
+		ApplicationProperties: map[string]interface{}{
+			"custom": "value",
+			"number": 1,
+		},
+	})
+
+	assert.Equal(t, map[string]string{
+		"id":             "id1",
+		"correlationID":  "corr1",
+		"label":          "label1",
+		"sequenceNumber": "7",
+		"custom":         "value",
+	}, md)
+}
+
+func TestDeferWithoutSubscription(t *testing.T) {
+	a := NewAzureServiceBusQueues(logger.NewLogger("test")).(*AzureServiceBusQueues)
+
+	_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: DeferOperation,
+		Metadata:  map[string]string{"sequenceNumber": "1"},
+	})
+	assert.Error(t, err)
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -102,6 +103,12 @@ func (s *SignalR) Init(metadata bindings.Metadata) (err error) {
 	return nil
 }
 
+func (s *SignalR) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString, metadata.AuthProfileAccessKey, metadata.AuthProfileAzureAD},
+	}
+}
+
 func (s *SignalR) parseMetadata(md map[string]string) (err error) {
 	// Start by parsing the connection string if present
 	connectionString, ok := md[connectionStringKey]
@@ -25,6 +25,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/internal/redact"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
@@ -55,7 +56,14 @@ type AzureQueueHelper struct {
 }
 
 // Init sets up this helper.
-func (d *AzureQueueHelper) Init(metadata bindings.Metadata) (*storageQueuesMetadata, error) {
+func (d *AzureQueueHelper) Init(metadata bindings.Metadata) (_ *storageQueuesMetadata, err error) {
+	// The errors of the queue credentials may quote the account key
+	redactor := redact.New(metadata.Properties, nil)
+	d.logger = redactor.Logger(d.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	m, err := parseMetadata(metadata)
 	if err != nil {
 		return nil, err
@@ -202,6 +210,13 @@ func (a *AzureStorageQueues) Init(metadata bindings.Metadata) (err error) {
 	return nil
 }
 
+func (a *AzureStorageQueues) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		Capabilities: []contribMetadata.Capability{contribMetadata.CapabilityTTL},
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileAccessKey, contribMetadata.AuthProfileAzureAD},
+	}
+}
+
 func parseMetadata(meta bindings.Metadata) (*storageQueuesMetadata, error) {
 	m := storageQueuesMetadata{
 		VisibilityTimeout: ptr.Of(time.Second * 30),
@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cidispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/httputils"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// TriggerOperation starts a pipeline: a Jenkins job build or a GitHub workflow run.
+	TriggerOperation bindings.OperationKind = "trigger"
+
+	providerJenkins = "jenkins"
+	providerGitHub  = "github"
+
+	defaultGitHubAPIURL     = "https://api.github.com"
+	defaultRef              = "main"
+	defaultTimeout          = 30 * time.Second
+	defaultRunLookupTimeout = 10 * time.Second
+)
+
+// CIDispatch is an output binding triggering CI pipelines on Jenkins or GitHub Actions.
+type CIDispatch struct {
+	metadata   *ciDispatchMetadata
+	httpClient *http.Client
+	logger     logger.Logger
+
+	// Interval between the lookups of a dispatched GitHub workflow run.
+	runLookupInterval time.Duration
+}
+
+type ciDispatchMetadata struct {
+	// Either "jenkins" or "github".
+	Provider string `mapstructure:"provider"`
+
+	// Jenkins settings.
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"`
+	APIToken string `mapstructure:"apiToken"`
+	Job      string `mapstructure:"job"`
+
+	// GitHub settings.
+	APIURL   string `mapstructure:"apiUrl"`
+	Token    string `mapstructure:"token"`
+	Owner    string `mapstructure:"owner"`
+	Repo     string `mapstructure:"repo"`
+	Workflow string `mapstructure:"workflow"`
+	Ref      string `mapstructure:"ref"`
+	// How long to look for the run created by a workflow dispatch. Zero disables the lookup.
+	RunLookupTimeout time.Duration `mapstructure:"runLookupTimeout"`
+
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// NewCIDispatch returns a new CI dispatch binding instance.
+func NewCIDispatch(logger logger.Logger) bindings.OutputBinding {
+	return &CIDispatch{
+		logger:            logger,
+		runLookupInterval: time.Second,
+	}
+}
+
+// Init does metadata parsing.
+func (c *CIDispatch) Init(meta bindings.Metadata) error {
+	m, err := c.parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	c.metadata = m
+	transport, err := httputils.NewTransport(meta.Properties)
+	if err != nil {
+		return err
+	}
+	c.httpClient = &http.Client{
+		Timeout:   m.Timeout,
+		Transport: transport,
+	}
+
+	return nil
+}
+
+func (c *CIDispatch) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
+
+func (c *CIDispatch) parseMetadata(meta bindings.Metadata) (*ciDispatchMetadata, error) {
+	m := ciDispatchMetadata{
+		APIURL:           defaultGitHubAPIURL,
+		Ref:              defaultRef,
+		RunLookupTimeout: defaultRunLookupTimeout,
+		Timeout:          defaultTimeout,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Provider = strings.ToLower(m.Provider)
+	switch m.Provider {
+	case providerJenkins:
+		m.URL = strings.TrimSuffix(m.URL, "/")
+		if m.URL == "" {
+			return nil, errors.New("ci dispatch binding error: url is required for jenkins")
+		}
+	case providerGitHub:
+		m.APIURL = strings.TrimSuffix(m.APIURL, "/")
+		if m.Token == "" {
+			return nil, errors.New("ci dispatch binding error: token is required for github")
+		}
+	default:
+		return nil, fmt.Errorf("ci dispatch binding error: invalid provider '%s', must be jenkins or github", m.Provider)
+	}
+
+	return &m, nil
+}
+
+// Operations returns the supported operations for this binding.
+func (c *CIDispatch) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{TriggerOperation}
+}
+
+// Invoke triggers a pipeline. The request data is a JSON object with the parameters of the Jenkins build
+// or the inputs of the GitHub workflow.
+func (c *CIDispatch) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req.Operation != TriggerOperation {
+		return nil, fmt.Errorf("ci dispatch binding error: unsupported operation %s", req.Operation)
+	}
+
+	var params map[string]interface{}
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &params); err != nil {
+			return nil, fmt.Errorf("ci dispatch binding error: the parameters must be a JSON object: %w", err)
+		}
+	}
+
+	var (
+		res map[string]string
+		err error
+	)
+	if c.metadata.Provider == providerJenkins {
+		res, err = c.triggerJenkinsBuild(ctx, req.Metadata, params)
+	} else {
+		res, err = c.dispatchGitHubWorkflow(ctx, req.Metadata, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     data,
+		Metadata: res,
+	}, nil
+}
+
+// do sends a request and returns the response, failing on non-2xx status codes.
+func (c *CIDispatch) do(httpReq *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ci dispatch binding error: request to %s failed: %w", httpReq.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("ci dispatch binding error: request to %s failed with status %d: %s", httpReq.URL, resp.StatusCode, string(body))
+	}
+
+	return resp, body, nil
+}
+
+func newJSONRequest(ctx context.Context, method string, u string, body interface{}) (*http.Request, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if reqBody != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	return httpReq, nil
+}
+
+func metadataOrDefault(md map[string]string, key string, defaultValue string) string {
+	if v, ok := md[key]; ok && v != "" {
+		return v
+	}
+
+	return defaultValue
+}
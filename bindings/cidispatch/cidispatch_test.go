@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cidispatch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("jenkins", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"provider": "Jenkins", "url": "http://jenkins/", "username": "u", "apiToken": "t", "job": "build"}
+		c := CIDispatch{}
+		meta, err := c.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, providerJenkins, meta.Provider)
+		assert.Equal(t, "http://jenkins", meta.URL)
+		assert.Equal(t, "u", meta.Username)
+		assert.Equal(t, "t", meta.APIToken)
+		assert.Equal(t, "build", meta.Job)
+	})
+
+	t.Run("github defaults", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"provider": "github", "token": "t"}
+		c := CIDispatch{}
+		meta, err := c.parseMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGitHubAPIURL, meta.APIURL)
+		assert.Equal(t, defaultRef, meta.Ref)
+		assert.Equal(t, defaultRunLookupTimeout, meta.RunLookupTimeout)
+	})
+
+	t.Run("invalid provider", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"provider": "travis"}
+		c := CIDispatch{}
+		_, err := c.parseMetadata(m)
+		assert.Error(t, err)
+	})
+
+	t.Run("github without token", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"provider": "github"}
+		c := CIDispatch{}
+		_, err := c.parseMetadata(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestJenkins(t *testing.T) {
+	var path, query, user string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+		user, _, _ = r.BasicAuth()
+		w.Header().Set("Location", "http://jenkins/queue/item/42/")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := &CIDispatch{
+		metadata:   &ciDispatchMetadata{Provider: providerJenkins, URL: srv.URL, Username: "u", APIToken: "t", Job: "build"},
+		httpClient: srv.Client(),
+		logger:     logger.NewLogger("test"),
+	}
+
+	t.Run("build with parameters", func(t *testing.T) {
+		resp, err := c.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerOperation,
+			Data:      []byte(`{"env":"prod","count":2}`),
+			Metadata:  map[string]string{"job": "folder/deploy"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/job/folder/job/deploy/buildWithParameters", path)
+		assert.Equal(t, "count=2&env=prod", query)
+		assert.Equal(t, "u", user)
+		assert.Equal(t, "42", resp.Metadata[queueIDKey])
+		assert.JSONEq(t, `{"queueId":"42","queueUrl":"http://jenkins/queue/item/42/"}`, string(resp.Data))
+	})
+
+	t.Run("build without parameters", func(t *testing.T) {
+		_, err := c.Invoke(context.Background(), &bindings.InvokeRequest{Operation: TriggerOperation})
+		require.NoError(t, err)
+		assert.Equal(t, "/job/build/build", path)
+	})
+}
+
+func TestGitHub(t *testing.T) {
+	var (
+		dispatchBody string
+		auth         string
+		lookups      atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/dapr/dapr/actions/workflows/ci.yml/dispatches":
+			b, _ := io.ReadAll(r.Body)
+			dispatchBody = string(b)
+			auth = r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusNoContent)
+		case "/repos/dapr/dapr/actions/workflows/ci.yml/runs":
+			// The run shows up on the second lookup
+			if lookups.Add(1) < 2 {
+				_, _ = w.Write([]byte(`{"workflow_runs":[]}`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"workflow_runs":[{"id":7,"html_url":"https://github.com/dapr/dapr/actions/runs/7","created_at":"%s"}]}`,
+				time.Now().UTC().Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := &CIDispatch{
+		metadata: &ciDispatchMetadata{
+			Provider: providerGitHub, APIURL: srv.URL, Token: "t", Owner: "dapr", Repo: "dapr", Workflow: "ci.yml", Ref: "main",
+			RunLookupTimeout: 5 * time.Second,
+		},
+		httpClient:        srv.Client(),
+		logger:            logger.NewLogger("test"),
+		runLookupInterval: 10 * time.Millisecond,
+	}
+
+	t.Run("dispatch and look up the run", func(t *testing.T) {
+		resp, err := c.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerOperation,
+			Data:      []byte(`{"version":"1.10","dryRun":true}`),
+			Metadata:  map[string]string{"ref": "release-1.10"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ref":"release-1.10","inputs":{"version":"1.10","dryRun":"true"}}`, dispatchBody)
+		assert.Equal(t, "Bearer t", auth)
+		assert.Equal(t, "7", resp.Metadata[runIDKey])
+		assert.Equal(t, "https://github.com/dapr/dapr/actions/runs/7", resp.Metadata[runURLKey])
+	})
+
+	t.Run("unknown workflow", func(t *testing.T) {
+		_, err := c.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerOperation,
+			Metadata:  map[string]string{"workflow": "missing.yml"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		_, err := c.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TriggerOperation,
+			Data:      []byte(`"not an object"`),
+		})
+		assert.Error(t, err)
+	})
+}
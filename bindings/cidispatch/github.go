@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cidispatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// Invoke metadata keys.
+	ownerKey    = "owner"
+	repoKey     = "repo"
+	workflowKey = "workflow"
+	refKey      = "ref"
+
+	// Response keys.
+	runIDKey  = "runId"
+	runURLKey = "runUrl"
+
+	// Allowance for the clock skew between this host and GitHub when looking up the dispatched run.
+	runLookupClockSkew = 5 * time.Second
+)
+
+type gitHubWorkflowRuns struct {
+	WorkflowRuns []struct {
+		ID        int64     `json:"id"`
+		HTMLURL   string    `json:"html_url"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"workflow_runs"`
+}
+
+// dispatchGitHubWorkflow creates a workflow_dispatch event for a GitHub Actions workflow, with the given inputs if any.
+func (c *CIDispatch) dispatchGitHubWorkflow(ctx context.Context, md map[string]string, inputs map[string]interface{}) (map[string]string, error) {
+	owner := metadataOrDefault(md, ownerKey, c.metadata.Owner)
+	repo := metadataOrDefault(md, repoKey, c.metadata.Repo)
+	workflow := metadataOrDefault(md, workflowKey, c.metadata.Workflow)
+	ref := metadataOrDefault(md, refKey, c.metadata.Ref)
+	if owner == "" || repo == "" || workflow == "" {
+		return nil, errors.New("ci dispatch binding error: owner, repo and workflow are required for github")
+	}
+
+	// Workflow inputs are always strings
+	stringInputs := make(map[string]string, len(inputs))
+	for k, v := range inputs {
+		if s, ok := v.(string); ok {
+			stringInputs[k] = s
+		} else {
+			b, _ := json.Marshal(v)
+			stringInputs[k] = string(b)
+		}
+	}
+
+	workflowURL := fmt.Sprintf("%s/repos/%s/%s/actions/workflows/%s", c.metadata.APIURL, url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(workflow))
+	dispatchedAt := time.Now().Add(-runLookupClockSkew).UTC()
+	httpReq, err := newJSONRequest(ctx, http.MethodPost, workflowURL+"/dispatches", map[string]interface{}{
+		"ref":    ref,
+		"inputs": stringInputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.setGitHubHeaders(httpReq)
+	if _, _, err = c.do(httpReq); err != nil {
+		return nil, err
+	}
+
+	res := map[string]string{}
+	// The dispatch API doesn't return the run it creates, so it's looked up among the latest runs.
+	// With concurrent dispatches of the same workflow and ref, the most recent run is reported.
+	if c.metadata.RunLookupTimeout > 0 {
+		runID, runURL, err := c.lookupGitHubRun(ctx, workflowURL, ref, dispatchedAt)
+		if err != nil {
+			c.logger.Warnf("ci dispatch binding: failed to look up the run of github workflow %s: %v", workflow, err)
+		} else if runID != 0 {
+			res[runIDKey] = strconv.FormatInt(runID, 10)
+			res[runURLKey] = runURL
+		}
+	}
+	c.logger.Debugf("ci dispatch binding: dispatched github workflow %s on %s/%s@%s", workflow, owner, repo, ref)
+
+	return res, nil
+}
+
+func (c *CIDispatch) lookupGitHubRun(ctx context.Context, workflowURL string, ref string, since time.Time) (int64, string, error) {
+	query := url.Values{
+		"event":   []string{"workflow_dispatch"},
+		"branch":  []string{ref},
+		"created": []string{">=" + since.Format(time.RFC3339)},
+	}
+	u := workflowURL + "/runs?" + query.Encode()
+
+	lookupCtx, cancel := context.WithTimeout(ctx, c.metadata.RunLookupTimeout)
+	defer cancel()
+	for {
+		httpReq, err := http.NewRequestWithContext(lookupCtx, http.MethodGet, u, nil)
+		if err != nil {
+			return 0, "", err
+		}
+		c.setGitHubHeaders(httpReq)
+		_, body, err := c.do(httpReq)
+		if err != nil {
+			if lookupCtx.Err() != nil && ctx.Err() == nil {
+				return 0, "", nil
+			}
+			return 0, "", err
+		}
+
+		var runs gitHubWorkflowRuns
+		if err = json.Unmarshal(body, &runs); err != nil {
+			return 0, "", err
+		}
+		// Runs are returned newest first
+		if len(runs.WorkflowRuns) > 0 && !runs.WorkflowRuns[0].CreatedAt.Before(since) {
+			return runs.WorkflowRuns[0].ID, runs.WorkflowRuns[0].HTMLURL, nil
+		}
+
+		select {
+		case <-time.After(c.runLookupInterval):
+		case <-lookupCtx.Done():
+			// The run wasn't created in time, which isn't an error
+			return 0, "", nil
+		}
+	}
+}
+
+func (c *CIDispatch) setGitHubHeaders(httpReq *http.Request) {
+	httpReq.Header.Set("Accept", "application/vnd.github+json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.metadata.Token)
+}
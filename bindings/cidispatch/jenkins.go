@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cidispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Invoke metadata keys.
+	jobKey = "job"
+
+	// Response keys.
+	queueIDKey  = "queueId"
+	queueURLKey = "queueUrl"
+)
+
+// triggerJenkinsBuild queues a build of a Jenkins job, with the given parameters if any.
+func (c *CIDispatch) triggerJenkinsBuild(ctx context.Context, md map[string]string, params map[string]interface{}) (map[string]string, error) {
+	job := metadataOrDefault(md, jobKey, c.metadata.Job)
+	if job == "" {
+		return nil, errors.New("ci dispatch binding error: job is required for jenkins")
+	}
+
+	u := c.metadata.URL + jenkinsJobPath(job)
+	if len(params) > 0 {
+		values := url.Values{}
+		for k, v := range params {
+			values.Set(k, fmt.Sprint(v))
+		}
+		u += "/buildWithParameters?" + values.Encode()
+	} else {
+		u += "/build"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// API tokens don't require a CSRF crumb
+	if c.metadata.Username != "" {
+		httpReq.SetBasicAuth(c.metadata.Username, c.metadata.APIToken)
+	}
+
+	resp, _, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Jenkins returns the URL of the queue item, e.g. http://jenkins/queue/item/42/
+	res := map[string]string{}
+	if location := resp.Header.Get("Location"); location != "" {
+		res[queueURLKey] = location
+		res[queueIDKey] = jenkinsQueueID(location)
+	}
+	c.logger.Debugf("ci dispatch binding: queued build of jenkins job %s: %s", job, res[queueURLKey])
+
+	return res, nil
+}
+
+// jenkinsJobPath returns the path of a job, which can be nested in folders, e.g. "folder/job".
+func jenkinsJobPath(job string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(strings.Trim(job, "/"), "/") {
+		sb.WriteString("/job/")
+		sb.WriteString(url.PathEscape(part))
+	}
+
+	return sb.String()
+}
+
+func jenkinsQueueID(location string) string {
+	trimmed := strings.TrimSuffix(location, "/")
+	idx := strings.LastIndex(trimmed, "/")
+	if idx < 0 || !strings.HasSuffix(trimmed[:idx], "/queue/item") {
+		return ""
+	}
+
+	return trimmed[idx+1:]
+}
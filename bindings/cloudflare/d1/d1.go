@@ -0,0 +1,318 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package d1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// List of operations.
+const (
+	execOperation  bindings.OperationKind = "exec"
+	queryOperation bindings.OperationKind = "query"
+
+	commandSQLKey    = "sql"
+	commandParamsKey = "params"
+
+	respOpKey           = "operation"
+	respSQLKey          = "sql"
+	respStartTimeKey    = "start-time"
+	respEndTimeKey      = "end-time"
+	respDurationKey     = "duration"
+	respRowsAffectedKey = "rows-affected"
+	respLastInsertIDKey = "last-insert-id"
+	respRowsReadKey     = "rows-read"
+
+	defaultAPIURL  = "https://api.cloudflare.com/client/v4"
+	defaultTimeout = 30 * time.Second
+
+	// Bodies of the responses are truncated in errors.
+	maxErrorBodySize = 1024
+	maxResponseSize  = 100 << 20
+)
+
+// D1 is an output binding running SQL statements on a Cloudflare D1 database, either with the REST API of Cloudflare
+// or through a worker bound to the database.
+type D1 struct {
+	metadata d1Metadata
+	// URL of the Cloudflare API, which tests replace.
+	apiURL string
+	client *http.Client
+	logger logger.Logger
+}
+
+type d1Metadata struct {
+	// Identifiers of the account and of the database, and API token with the D1 edit permission, to use the REST API.
+	AccountID  string `mapstructure:"accountID"`
+	DatabaseID string `mapstructure:"databaseID"`
+	APIToken   string `mapstructure:"apiToken"`
+	// URL of a worker bound to the database, used instead of the REST API. The worker receives the statements as
+	// {"sql": "...", "params": [...]} and responds with the result of D1PreparedStatement.all().
+	WorkerURL string `mapstructure:"workerURL"`
+	// Key sent to the worker as a bearer token.
+	WorkerKey string        `mapstructure:"workerKey"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// statement is the body of the requests to the REST API and to the worker.
+type statement struct {
+	SQL    string `json:"sql"`
+	Params []any  `json:"params,omitempty"`
+}
+
+// queryResult is the result of a statement, as returned by D1.
+type queryResult struct {
+	Results []json.RawMessage `json:"results"`
+	Success bool              `json:"success"`
+	Error   string            `json:"error,omitempty"`
+	Meta    struct {
+		Changes     int64 `json:"changes"`
+		LastRowID   int64 `json:"last_row_id"`
+		RowsRead    int64 `json:"rows_read"`
+		RowsWritten int64 `json:"rows_written"`
+	} `json:"meta"`
+}
+
+// apiResponse is the envelope of the responses of the REST API.
+type apiResponse struct {
+	Result  []queryResult `json:"result"`
+	Success bool          `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// NewD1 returns a new Cloudflare D1 output binding.
+func NewD1(logger logger.Logger) bindings.OutputBinding {
+	return &D1{
+		apiURL: defaultAPIURL,
+		logger: logger,
+	}
+}
+
+// Init performs metadata parsing.
+func (d *D1) Init(meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	d.metadata = m
+	d.client = &http.Client{Timeout: m.Timeout}
+
+	return nil
+}
+
+func (d *D1) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
+
+func parseMetadata(meta bindings.Metadata) (d1Metadata, error) {
+	m := d1Metadata{
+		Timeout: defaultTimeout,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.WorkerURL != "" {
+		if m.AccountID != "" || m.DatabaseID != "" || m.APIToken != "" {
+			return m, errors.New("cloudflare d1 binding error: workerURL can't be used with accountID, databaseID and apiToken")
+		}
+		u, err := url.Parse(m.WorkerURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return m, fmt.Errorf("cloudflare d1 binding error: invalid workerURL %s", m.WorkerURL)
+		}
+	} else if m.AccountID == "" || m.DatabaseID == "" || m.APIToken == "" {
+		return m, errors.New("cloudflare d1 binding error: accountID, databaseID and apiToken are required, unless workerURL is set")
+	}
+	if m.Timeout <= 0 {
+		return m, errors.New("cloudflare d1 binding error: timeout must be greater than 0")
+	}
+
+	return m, nil
+}
+
+// Operations returns list of operations supported by the binding.
+func (d *D1) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		execOperation,
+		queryOperation,
+	}
+}
+
+// Invoke runs the SQL statement of the request, with the parameters bound to its placeholders.
+func (d *D1) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req.Operation != execOperation && req.Operation != queryOperation {
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s or %s", req.Operation, execOperation, queryOperation)
+	}
+
+	sql := req.Metadata[commandSQLKey]
+	if sql == "" {
+		return nil, fmt.Errorf("required metadata not set: %s", commandSQLKey)
+	}
+	stmt := statement{SQL: sql}
+	if params := req.Metadata[commandParamsKey]; params != "" {
+		err := json.Unmarshal([]byte(params), &stmt.Params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s metadata, must be a JSON array: %w", commandParamsKey, err)
+		}
+	}
+
+	startTime := time.Now().UTC()
+	res, err := d.run(ctx, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("error executing %s: %w", sql, err)
+	}
+	endTime := time.Now().UTC()
+
+	resp := &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			respOpKey:        string(req.Operation),
+			respSQLKey:       sql,
+			respStartTimeKey: startTime.Format(time.RFC3339Nano),
+			respEndTimeKey:   endTime.Format(time.RFC3339Nano),
+			respDurationKey:  endTime.Sub(startTime).String(),
+			respRowsReadKey:  strconv.FormatInt(res.Meta.RowsRead, 10),
+		},
+	}
+	if req.Operation == execOperation {
+		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(res.Meta.Changes, 10)
+		resp.Metadata[respLastInsertIDKey] = strconv.FormatInt(res.Meta.LastRowID, 10)
+	} else {
+		rows := res.Results
+		if rows == nil {
+			rows = []json.RawMessage{}
+		}
+		resp.Data, err = json.Marshal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing results: %w", err)
+		}
+		contentType := "application/json"
+		resp.ContentType = &contentType
+	}
+
+	return resp, nil
+}
+
+// run sends a statement to the REST API or to the worker and returns its result.
+func (d *D1) run(ctx context.Context, stmt statement) (*queryResult, error) {
+	body, err := json.Marshal(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := d.metadata.WorkerURL
+	token := d.metadata.WorkerKey
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("%s/accounts/%s/d1/database/%s/query", d.apiURL, url.PathEscape(d.metadata.AccountID), url.PathEscape(d.metadata.DatabaseID))
+		token = d.metadata.APIToken
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpRes, err := d.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+	resBody, err := io.ReadAll(io.LimitReader(httpRes.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if d.metadata.WorkerURL != "" {
+		return parseWorkerResponse(httpRes.StatusCode, resBody)
+	}
+	return parseAPIResponse(httpRes.StatusCode, resBody)
+}
+
+func parseAPIResponse(status int, body []byte) (*queryResult, error) {
+	var res apiResponse
+	err := json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response with status %d: %s", status, truncate(body))
+	}
+	if !res.Success || status >= http.StatusBadRequest {
+		msgs := make([]string, len(res.Errors))
+		for i, e := range res.Errors {
+			msgs[i] = fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", status, strings.Join(msgs, "; "))
+	}
+	if len(res.Result) == 0 {
+		return nil, errors.New("response without result")
+	}
+
+	return checkResult(&res.Result[0])
+}
+
+func parseWorkerResponse(status int, body []byte) (*queryResult, error) {
+	if status >= http.StatusBadRequest {
+		return nil, fmt.Errorf("worker responded with status %d: %s", status, truncate(body))
+	}
+	var res queryResult
+	err := json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response of the worker: %s", truncate(body))
+	}
+
+	return checkResult(&res)
+}
+
+func checkResult(res *queryResult) (*queryResult, error) {
+	if !res.Success {
+		if res.Error != "" {
+			return nil, errors.New(res.Error)
+		}
+		return nil, errors.New("statement failed")
+	}
+
+	return res, nil
+}
+
+func truncate(body []byte) string {
+	if len(body) > maxErrorBodySize {
+		return string(body[:maxErrorBodySize]) + "..."
+	}
+	return string(body)
+}
+
+// Close is a no-op, as the binding doesn't hold connections.
+func (d *D1) Close() error {
+	return nil
+}
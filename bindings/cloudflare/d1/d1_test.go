@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package d1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("REST API", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accountID":  "account",
+			"databaseID": "database",
+			"apiToken":   "token",
+			"timeout":    "5s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "account", m.AccountID)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+	})
+
+	t.Run("worker", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"workerURL": "https://d1.example.workers.dev/",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+	})
+
+	invalid := map[string]map[string]string{
+		"missing token":      {"accountID": "account", "databaseID": "database"},
+		"worker and API":     {"workerURL": "https://d1.example.workers.dev/", "apiToken": "token"},
+		"invalid worker URL": {"workerURL": "d1.example.workers.dev"},
+		"invalid timeout":    {"workerURL": "https://d1.example.workers.dev/", "timeout": "0s"},
+	}
+	for name, props := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInvokeAPI(t *testing.T) {
+	var received statement
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/accounts/account/d1/database/database/query", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		received = statement{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		if received.SQL == "SELECT * FROM missing" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"result":[],"success":false,"errors":[{"code":7500,"message":"no such table: missing"}]}`))
+			return
+		}
+		w.Write([]byte(`{"result":[{"results":[{"id":1,"name":"a"},{"id":2,"name":"b"}],"success":true,"meta":{"changes":2,"last_row_id":2,"rows_read":3}}],"success":true,"errors":[]}`))
+	}))
+	defer srv.Close()
+
+	d := NewD1(logger.NewLogger("test")).(*D1)
+	d.apiURL = srv.URL
+	require.NoError(t, d.Init(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"accountID":  "account",
+		"databaseID": "database",
+		"apiToken":   "token",
+	}}}))
+
+	t.Run("query", func(t *testing.T) {
+		res, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{"sql": "SELECT * FROM items WHERE id > ?", "params": `[0]`},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`, string(res.Data))
+		assert.Equal(t, "3", res.Metadata["rows-read"])
+		assert.Equal(t, "SELECT * FROM items WHERE id > ?", received.SQL)
+		assert.Equal(t, []any{float64(0)}, received.Params)
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		res, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: execOperation,
+			Metadata:  map[string]string{"sql": "INSERT INTO items (name) VALUES ('a'), ('b')"},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		assert.Equal(t, "2", res.Metadata["rows-affected"])
+		assert.Equal(t, "2", res.Metadata["last-insert-id"])
+		assert.Nil(t, received.Params)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{"sql": "SELECT * FROM missing"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no such table: missing")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := d.Invoke(context.Background(), &bindings.InvokeRequest{Operation: queryOperation})
+		assert.Error(t, err)
+		_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{"sql": "SELECT 1", "params": `{"id":1}`},
+		})
+		assert.Error(t, err)
+		_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: "close",
+			Metadata:  map[string]string{"sql": "SELECT 1"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestInvokeWorker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		var stmt statement
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&stmt))
+		if stmt.SQL == "SELECT" {
+			w.Write([]byte(`{"success":false,"error":"incomplete input"}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"n":1}],"success":true,"meta":{"rows_read":1}}`))
+	}))
+	defer srv.Close()
+
+	d := NewD1(logger.NewLogger("test"))
+	require.NoError(t, d.Init(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"workerURL": srv.URL,
+		"workerKey": "key",
+	}}}))
+
+	res, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: queryOperation,
+		Metadata:  map[string]string{"sql": "SELECT 1 AS n"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"n":1}]`, string(res.Data))
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: queryOperation,
+		Metadata:  map[string]string{"sql": "SELECT"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "incomplete input")
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package r2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/component/cloudflare"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	metadataKey = "key"
+
+	defaultMaxResults = 1000
+)
+
+// R2 is an output binding for a bucket of Cloudflare R2 object storage.
+type R2 struct {
+	metadata r2Metadata
+	bucket   *cloudflare.R2
+	logger   logger.Logger
+}
+
+type r2Metadata struct {
+	// Identifier of the account, which is part of the endpoint of R2.
+	AccountID string `mapstructure:"accountID"`
+	Bucket    string `mapstructure:"bucket"`
+	// Access key of an R2 API token with access to the bucket.
+	AccessKeyID     string `mapstructure:"accessKeyID"`
+	SecretAccessKey string `mapstructure:"secretAccessKey"`
+	// Endpoint of the S3-compatible API, which defaults to the endpoint of the account.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+type createResponse struct {
+	Key  string `json:"key"`
+	ETag string `json:"etag"`
+}
+
+type listPayload struct {
+	Prefix     string `json:"prefix"`
+	StartAfter string `json:"startAfter"`
+	MaxResults int64  `json:"maxResults"`
+}
+
+type listResponse struct {
+	Objects []cloudflare.R2Object `json:"objects"`
+	// Key to start after to list the next page, which is empty on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// NewR2 returns a new Cloudflare R2 output binding.
+func NewR2(logger logger.Logger) bindings.OutputBinding {
+	return &R2{logger: logger}
+}
+
+// Init performs metadata parsing and creates the client of the bucket.
+func (r *R2) Init(meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	r.metadata = m
+
+	r.bucket, err = cloudflare.NewR2(m.Endpoint, m.Bucket, m.AccessKeyID, m.SecretAccessKey)
+	if err != nil {
+		return fmt.Errorf("cloudflare r2 binding error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *R2) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
+func parseMetadata(meta bindings.Metadata) (r2Metadata, error) {
+	m := r2Metadata{}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.Bucket == "" || m.AccessKeyID == "" || m.SecretAccessKey == "" {
+		return m, errors.New("cloudflare r2 binding error: bucket, accessKeyID and secretAccessKey are required")
+	}
+	if m.Endpoint == "" {
+		if m.AccountID == "" {
+			return m, errors.New("cloudflare r2 binding error: accountID is required, unless endpoint is set")
+		}
+		m.Endpoint = cloudflare.R2Endpoint(m.AccountID)
+	} else if u, err := url.Parse(m.Endpoint); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return m, fmt.Errorf("cloudflare r2 binding error: invalid endpoint %s", m.Endpoint)
+	}
+
+	return m, nil
+}
+
+// Operations returns list of operations supported by the binding.
+func (r *R2) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		bindings.CreateOperation,
+		bindings.GetOperation,
+		bindings.DeleteOperation,
+		bindings.ListOperation,
+	}
+}
+
+// Invoke runs the operation of the request on the bucket.
+func (r *R2) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case bindings.CreateOperation:
+		return r.create(ctx, req)
+	case bindings.GetOperation:
+		return r.get(ctx, req)
+	case bindings.DeleteOperation:
+		return r.delete(ctx, req)
+	case bindings.ListOperation:
+		return r.list(ctx, req)
+	default:
+		return nil, fmt.Errorf("cloudflare r2 binding error: unsupported operation %s", req.Operation)
+	}
+}
+
+func (r *R2) create(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	key := req.Metadata[metadataKey]
+	if key == "" {
+		key = uuid.New().String()
+		r.logger.Debugf("cloudflare r2 binding: key not found, generated key %s", key)
+	}
+
+	etag, err := r.bucket.Put(ctx, key, req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare r2 binding error: %w", err)
+	}
+
+	data, err := json.Marshal(createResponse{Key: key, ETag: etag})
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare r2 binding error: error marshalling create response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     data,
+		Metadata: map[string]string{metadataKey: key},
+	}, nil
+}
+
+func (r *R2) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	key := req.Metadata[metadataKey]
+	if key == "" {
+		return nil, fmt.Errorf("cloudflare r2 binding error: required metadata '%s' missing", metadataKey)
+	}
+
+	data, err := r.bucket.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare r2 binding error: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: data,
+	}, nil
+}
+
+func (r *R2) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	key := req.Metadata[metadataKey]
+	if key == "" {
+		return nil, fmt.Errorf("cloudflare r2 binding error: required metadata '%s' missing", metadataKey)
+	}
+
+	err := r.bucket.Delete(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare r2 binding error: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (r *R2) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload listPayload
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("cloudflare r2 binding error: invalid list payload: %w", err)
+		}
+	}
+	if payload.MaxResults <= 0 {
+		payload.MaxResults = defaultMaxResults
+	}
+
+	objects, next, err := r.bucket.List(ctx, payload.Prefix, payload.StartAfter, payload.MaxResults)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare r2 binding error: %w", err)
+	}
+
+	data, err := json.Marshal(listResponse{Objects: objects, Next: next})
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare r2 binding error: error marshalling list response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: data,
+	}, nil
+}
+
+// Close is a no-op, as the binding doesn't hold connections.
+func (r *R2) Close() error {
+	return nil
+}
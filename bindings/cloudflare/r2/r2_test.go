@@ -0,0 +1,252 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package r2
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("account endpoint", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accountID":       "account",
+			"bucket":          "bucket",
+			"accessKeyID":     "key",
+			"secretAccessKey": "secret",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "https://account.r2.cloudflarestorage.com", m.Endpoint)
+	})
+
+	t.Run("custom endpoint", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint":        "https://account.eu.r2.cloudflarestorage.com",
+			"bucket":          "bucket",
+			"accessKeyID":     "key",
+			"secretAccessKey": "secret",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "https://account.eu.r2.cloudflarestorage.com", m.Endpoint)
+	})
+
+	invalid := map[string]map[string]string{
+		"missing bucket":     {"accountID": "account", "accessKeyID": "key", "secretAccessKey": "secret"},
+		"missing secret":     {"accountID": "account", "bucket": "bucket", "accessKeyID": "key"},
+		"missing account ID": {"bucket": "bucket", "accessKeyID": "key", "secretAccessKey": "secret"},
+		"invalid endpoint":   {"endpoint": "r2.example.com", "bucket": "bucket", "accessKeyID": "key", "secretAccessKey": "secret"},
+	}
+	for name, props := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	srv := httptest.NewServer(newFakeBucket(t, "bucket"))
+	defer srv.Close()
+
+	r := NewR2(logger.NewLogger("test"))
+	err := r.Init(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"endpoint":        srv.URL,
+		"bucket":          "bucket",
+		"accessKeyID":     "key",
+		"secretAccessKey": "secret",
+	}}})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("create", func(t *testing.T) {
+		for _, key := range []string{"a/1", "a/2", "b/1"} {
+			res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+				Operation: bindings.CreateOperation,
+				Data:      []byte("data of " + key),
+				Metadata:  map[string]string{"key": key},
+			})
+			require.NoError(t, err)
+			var created createResponse
+			require.NoError(t, json.Unmarshal(res.Data, &created))
+			assert.Equal(t, key, created.Key)
+			assert.NotEmpty(t, created.ETag)
+		}
+	})
+
+	t.Run("create with generated key", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte("data"),
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, res.Metadata["key"])
+
+		_, err = r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.DeleteOperation,
+			Metadata:  map[string]string{"key": res.Metadata["key"]},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{"key": "a/1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "data of a/1", string(res.Data))
+
+		_, err = r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{"key": "missing"},
+		})
+		assert.ErrorContains(t, err, "object not found")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.ListOperation,
+			Data:      []byte(`{"prefix":"a/","maxResults":1}`),
+		})
+		require.NoError(t, err)
+		var list listResponse
+		require.NoError(t, json.Unmarshal(res.Data, &list))
+		require.Len(t, list.Objects, 1)
+		assert.Equal(t, "a/1", list.Objects[0].Key)
+		assert.Equal(t, int64(len("data of a/1")), list.Objects[0].Size)
+		assert.Equal(t, "a/1", list.Next)
+
+		res, err = r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.ListOperation,
+			Data:      []byte(`{"prefix":"a/","startAfter":"a/1"}`),
+		})
+		require.NoError(t, err)
+		list = listResponse{}
+		require.NoError(t, json.Unmarshal(res.Data, &list))
+		require.Len(t, list.Objects, 1)
+		assert.Equal(t, "a/2", list.Objects[0].Key)
+		assert.Empty(t, list.Next)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.DeleteOperation,
+			Metadata:  map[string]string{"key": "a/1"},
+		})
+		require.NoError(t, err)
+
+		_, err = r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{"key": "a/1"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.GetOperation})
+		assert.Error(t, err)
+	})
+}
+
+// fakeBucket serves the requests of the S3-compatible API used by the binding.
+type fakeBucket struct {
+	t       *testing.T
+	name    string
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeBucket(t *testing.T, name string) *fakeBucket {
+	return &fakeBucket{t: t, name: name, objects: map[string][]byte{}}
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	assert.Contains(b.t, r.Header.Get("Authorization"), "Credential=key/")
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+b.name), "/")
+
+	switch {
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		require.NoError(b.t, err)
+		b.objects[key] = data
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodGet && key == "":
+		b.list(w, r)
+	case r.Method == http.MethodGet:
+		data, ok := b.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		w.Header().Set("ETag", etag(data))
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (b *fakeBucket) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	maxKeys, err := strconv.Atoi(q.Get("max-keys"))
+	require.NoError(b.t, err)
+
+	keys := []string{}
+	for k := range b.objects {
+		if strings.HasPrefix(k, q.Get("prefix")) && k > q.Get("start-after") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	truncated := len(keys) > maxKeys
+	if truncated {
+		keys = keys[:maxKeys]
+	}
+
+	fmt.Fprintf(w, `<ListBucketResult><Name>%s</Name><IsTruncated>%t</IsTruncated>`, b.name, truncated)
+	for _, k := range keys {
+		fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>%d</Size><ETag>%s</ETag><LastModified>2023-01-02T03:04:05.000Z</LastModified></Contents>`,
+			k, len(b.objects[k]), etag(b.objects[k]))
+	}
+	fmt.Fprint(w, `</ListBucketResult>`)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data) //nolint:gosec
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 
 	"github.com/labd/commercetools-go-sdk/platform"
@@ -88,6 +89,12 @@ func (ct *Binding) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (ct *Binding) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileOAuth2},
+	}
+}
+
 func (ct *Binding) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
@@ -24,6 +24,7 @@ import (
 	cron "github.com/dapr/kit/cron"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -71,6 +72,12 @@ func (b *Binding) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (b *Binding) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 // Read triggers the Cron scheduler.
 func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk))
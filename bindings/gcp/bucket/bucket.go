@@ -20,16 +20,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -42,6 +47,15 @@ const (
 	maxResults  = 1000
 
 	metadataKeyBC = "name"
+
+	composeOperation bindings.OperationKind = "compose"
+	signURLOperation bindings.OperationKind = "signURL"
+
+	metadataSignTTL    = "signTTL"
+	metadataSignMethod = "signMethod"
+
+	// Maximum number of source objects of a compose request.
+	maxComposeSources = 32
 )
 
 // GCPStorage allows saving data to GCP bucket storage.
@@ -49,6 +63,10 @@ type GCPStorage struct {
 	metadata *gcpMetadata
 	client   *storage.Client
 	logger   logger.Logger
+
+	// Authorized client of the JSON API, for the resumable upload sessions.
+	httpClient    *http.Client
+	uploadURLBase string
 }
 
 type gcpMetadata struct {
@@ -77,6 +95,15 @@ type createResponse struct {
 	ObjectURL string `json:"objectURL"`
 }
 
+type composePayload struct {
+	Sources     []string `json:"sources"`
+	ContentType string   `json:"contentType"`
+}
+
+type signURLResponse struct {
+	SignedURL string `json:"signedURL"`
+}
+
 // NewGCPStorage returns a new GCP storage instance.
 func NewGCPStorage(logger logger.Logger) bindings.OutputBinding {
 	return &GCPStorage{logger: logger}
@@ -96,12 +123,25 @@ func (g *GCPStorage) Init(metadata bindings.Metadata) error {
 		return err
 	}
 
+	httpClient, _, err := htransport.NewClient(ctx, clientOptions, option.WithScopes(storage.ScopeFullControl))
+	if err != nil {
+		return err
+	}
+
 	g.metadata = m
 	g.client = client
+	g.httpClient = httpClient
+	g.uploadURLBase = uploadURLBase
 
 	return nil
 }
 
+func (g *GCPStorage) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileGCPServiceAccount},
+	}
+}
+
 func (g *GCPStorage) parseMetadata(metadata bindings.Metadata) (*gcpMetadata, []byte, error) {
 	b, err := json.Marshal(metadata.Properties)
 	if err != nil {
@@ -123,6 +163,11 @@ func (g *GCPStorage) Operations() []bindings.OperationKind {
 		bindings.GetOperation,
 		bindings.DeleteOperation,
 		bindings.ListOperation,
+		composeOperation,
+		signURLOperation,
+		startUploadOperation,
+		uploadChunkOperation,
+		uploadStatusOperation,
 	}
 }
 
@@ -138,6 +183,16 @@ func (g *GCPStorage) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		return g.delete(ctx, req)
 	case bindings.ListOperation:
 		return g.list(ctx, req)
+	case composeOperation:
+		return g.compose(ctx, req)
+	case signURLOperation:
+		return g.signURL(ctx, req)
+	case startUploadOperation:
+		return g.startUpload(ctx, req)
+	case uploadChunkOperation:
+		return g.uploadChunk(ctx, req)
+	case uploadStatusOperation:
+		return g.uploadStatus(ctx, req)
 	default:
 		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
 	}
@@ -280,6 +335,94 @@ func (g *GCPStorage) list(ctx context.Context, req *bindings.InvokeRequest) (*bi
 	}, nil
 }
 
+// compose assembles source objects of the bucket, in order, into the object of the key.
+func (g *GCPStorage) compose(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var key string
+	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
+		key = val
+	} else {
+		return nil, fmt.Errorf("gcp bucket binding error: can't read key value")
+	}
+
+	var payload composePayload
+	err := json.Unmarshal(req.Data, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. compose operation. invalid payload: %w", err)
+	}
+	if len(payload.Sources) == 0 || len(payload.Sources) > maxComposeSources {
+		return nil, fmt.Errorf("gcp bucket binding error. compose operation. between 1 and %d sources are required", maxComposeSources)
+	}
+
+	bucket := g.client.Bucket(g.metadata.Bucket)
+	srcs := make([]*storage.ObjectHandle, len(payload.Sources))
+	for i, name := range payload.Sources {
+		srcs[i] = bucket.Object(name)
+	}
+	composer := bucket.Object(key).ComposerFrom(srcs...)
+	composer.ContentType = payload.ContentType
+	_, err = composer.Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. compose operation: %w", err)
+	}
+
+	b, err := json.Marshal(createResponse{
+		ObjectURL: fmt.Sprintf(objectURLBase, g.metadata.Bucket, key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcp binding error. error marshalling compose response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+// signURL returns a V4 signed URL of the object, signed with the key of the service account.
+func (g *GCPStorage) signURL(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var key string
+	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
+		key = val
+	} else {
+		return nil, fmt.Errorf("gcp bucket binding error: can't read key value")
+	}
+	ttl := req.Metadata[metadataSignTTL]
+	if ttl == "" {
+		return nil, fmt.Errorf("gcp bucket binding error: required metadata '%s' missing", metadataSignTTL)
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error: cannot parse duration %s: %w", ttl, err)
+	}
+	method := strings.ToUpper(req.Metadata[metadataSignMethod])
+	switch method {
+	case "":
+		method = http.MethodGet
+	case http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodHead:
+	default:
+		return nil, fmt.Errorf("gcp bucket binding error: unsupported %s %s", metadataSignMethod, method)
+	}
+
+	signedURL, err := storage.SignedURL(g.metadata.Bucket, key, &storage.SignedURLOptions{
+		GoogleAccessID: g.metadata.ClientEmail,
+		PrivateKey:     []byte(g.metadata.PrivateKey),
+		Method:         method,
+		Expires:        time.Now().Add(d),
+		Scheme:         storage.SigningSchemeV4,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error: failed to sign URL: %w", err)
+	}
+
+	b, err := json.Marshal(signURLResponse{SignedURL: signedURL})
+	if err != nil {
+		return nil, fmt.Errorf("gcp binding error. error marshalling sign URL response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
 func (g *GCPStorage) Close() error {
 	return g.client.Close()
 }
@@ -14,10 +14,24 @@ limitations under the License.
 package bucket
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -238,3 +252,158 @@ func TestDeleteOption(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestComposeOption(t *testing.T) {
+	gs := GCPStorage{logger: logger.NewLogger("test")}
+	gs.metadata = &gcpMetadata{}
+
+	t.Run("return error if key is missing", func(t *testing.T) {
+		r := bindings.InvokeRequest{Data: []byte(`{"sources":["a","b"]}`)}
+		_, err := gs.compose(context.TODO(), &r)
+		assert.Error(t, err)
+	})
+
+	t.Run("return error if sources are missing", func(t *testing.T) {
+		r := bindings.InvokeRequest{Data: []byte(`{}`), Metadata: map[string]string{"key": "c"}}
+		_, err := gs.compose(context.TODO(), &r)
+		assert.ErrorContains(t, err, "between 1 and 32 sources are required")
+	})
+}
+
+func TestSignURLOption(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	gs := GCPStorage{logger: logger.NewLogger("test")}
+	gs.metadata = &gcpMetadata{Bucket: "my_bucket", ClientEmail: "sa@project.iam.gserviceaccount.com", PrivateKey: string(keyPEM)}
+
+	t.Run("sign URL", func(t *testing.T) {
+		r := bindings.InvokeRequest{Metadata: map[string]string{"key": "my/object", "signTTL": "15m", "signMethod": "put"}}
+		res, err := gs.signURL(context.TODO(), &r)
+		require.NoError(t, err)
+
+		var payload signURLResponse
+		require.NoError(t, json.Unmarshal(res.Data, &payload))
+		u, err := url.Parse(payload.SignedURL)
+		require.NoError(t, err)
+		assert.Equal(t, "/my_bucket/my/object", u.Path)
+		assert.Contains(t, []string{"899", "900"}, u.Query().Get("X-Goog-Expires"))
+		assert.Contains(t, u.Query().Get("X-Goog-Credential"), "sa@project.iam.gserviceaccount.com")
+		assert.NotEmpty(t, u.Query().Get("X-Goog-Signature"))
+	})
+
+	t.Run("return error if signTTL is missing", func(t *testing.T) {
+		r := bindings.InvokeRequest{Metadata: map[string]string{"key": "my/object"}}
+		_, err := gs.signURL(context.TODO(), &r)
+		assert.ErrorContains(t, err, "signTTL")
+	})
+
+	t.Run("return error if method is unsupported", func(t *testing.T) {
+		r := bindings.InvokeRequest{Metadata: map[string]string{"key": "my/object", "signTTL": "1h", "signMethod": "POST"}}
+		_, err := gs.signURL(context.TODO(), &r)
+		assert.ErrorContains(t, err, "unsupported signMethod POST")
+	})
+}
+
+func TestResumableUpload(t *testing.T) {
+	var lock sync.Mutex
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/my_bucket/o":
+			assert.Equal(t, "resumable", r.URL.Query().Get("uploadType"))
+			var object map[string]string
+			json.NewDecoder(r.Body).Decode(&object)
+			assert.Equal(t, map[string]string{"name": "big", "contentType": "text/plain"}, object)
+			w.Header().Set("Location", "http://"+r.Host+"/session/1")
+		case r.Method == http.MethodPut && r.URL.Path == "/session/1":
+			data, _ := io.ReadAll(r.Body)
+			var start, end int
+			var total string
+			if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%s", &start, &end, &total); err == nil {
+				if start != len(uploaded) {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				uploaded = append(uploaded, data...)
+			}
+			if total == "" || total == "*" {
+				if len(uploaded) > 0 {
+					w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(uploaded)-1))
+				}
+				w.WriteHeader(statusResumeIncomplete)
+				return
+			}
+			fmt.Fprintf(w, `{"name":"big","size":"%d"}`, len(uploaded))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	gs := GCPStorage{logger: logger.NewLogger("test")}
+	gs.metadata = &gcpMetadata{Bucket: "my_bucket"}
+	gs.httpClient = server.Client()
+	gs.uploadURLBase = server.URL + "/upload/%s/o"
+
+	res, err := gs.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: startUploadOperation,
+		Metadata:  map[string]string{"key": "big", "contentType": "text/plain"},
+	})
+	require.NoError(t, err)
+	var session startUploadResponse
+	require.NoError(t, json.Unmarshal(res.Data, &session))
+	assert.Equal(t, server.URL+"/session/1", session.SessionURI)
+	assert.Equal(t, "big", session.Key)
+
+	upload := func(metadata map[string]string, data []byte) (uploadChunkResponse, error) {
+		metadata["sessionURI"] = session.SessionURI
+		res, err := gs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: uploadChunkOperation, Metadata: metadata, Data: data})
+		if err != nil {
+			return uploadChunkResponse{}, err
+		}
+		var payload uploadChunkResponse
+		require.NoError(t, json.Unmarshal(res.Data, &payload))
+		return payload, nil
+	}
+
+	status, err := gs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: uploadStatusOperation, Metadata: map[string]string{"sessionURI": session.SessionURI}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"uploadedBytes":0,"completed":false}`, string(status.Data))
+
+	chunk := bytes.Repeat([]byte("a"), uploadChunkSizeMultiple)
+	payload, err := upload(map[string]string{"offset": "0"}, chunk)
+	require.NoError(t, err)
+	assert.Equal(t, uploadChunkResponse{UploadedBytes: uploadChunkSizeMultiple}, payload)
+
+	_, err = upload(map[string]string{"offset": "262144"}, []byte("short"))
+	assert.ErrorContains(t, err, "multiple of 262144 bytes")
+	_, err = upload(map[string]string{}, chunk)
+	assert.ErrorContains(t, err, "invalid metadata 'offset'")
+
+	status, err = gs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: uploadStatusOperation, Metadata: map[string]string{"sessionURI": session.SessionURI}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"uploadedBytes":262144,"completed":false}`, string(status.Data))
+
+	payload, err = upload(map[string]string{"offset": "262144", "final": "true", "decodeBase64": "true"}, []byte(b64.StdEncoding.EncodeToString([]byte("end"))))
+	require.NoError(t, err)
+	assert.Equal(t, uploadChunkResponse{UploadedBytes: uploadChunkSizeMultiple + 3, Completed: true, ObjectURL: "https://storage.googleapis.com/my_bucket/big"}, payload)
+	assert.Equal(t, append(chunk, []byte("end")...), uploaded)
+}
+
+func TestParseUploadedRange(t *testing.T) {
+	n, err := parseUploadedRange("")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = parseUploadedRange("bytes=0-1048575")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1048576), n)
+
+	_, err = parseUploadedRange("bytes=0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"bytes"
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/utils"
+)
+
+const (
+	// Resumable upload sessions let large objects be uploaded in chunks across several invocations.
+	// See https://cloud.google.com/storage/docs/performing-resumable-uploads
+	startUploadOperation  bindings.OperationKind = "startUpload"
+	uploadChunkOperation  bindings.OperationKind = "uploadChunk"
+	uploadStatusOperation bindings.OperationKind = "uploadStatus"
+
+	uploadURLBase = "https://storage.googleapis.com/upload/storage/v1/b/%s/o"
+
+	metadataContentType = "contentType"
+	metadataSessionURI  = "sessionURI"
+	metadataOffset      = "offset"
+	metadataFinal       = "final"
+
+	// Chunks other than the last one must be a multiple of this size.
+	uploadChunkSizeMultiple = 256 * 1024
+	// Status code of the responses of incomplete uploads.
+	statusResumeIncomplete = 308
+)
+
+type startUploadResponse struct {
+	SessionURI string `json:"sessionURI"`
+	Key        string `json:"key"`
+}
+
+type uploadChunkResponse struct {
+	UploadedBytes int64  `json:"uploadedBytes"`
+	Completed     bool   `json:"completed"`
+	ObjectURL     string `json:"objectURL,omitempty"`
+}
+
+// startUpload starts a resumable upload session for an object, and returns the URI of the session.
+// The session expires after a week.
+func (g *GCPStorage) startUpload(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var name string
+	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
+		name = val
+	} else {
+		name = uuid.New().String()
+		g.logger.Debugf("key not found. generating name %s", name)
+	}
+
+	object := map[string]string{"name": name}
+	if val := req.Metadata[metadataContentType]; val != "" {
+		object["contentType"] = val
+	}
+	body, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf(g.uploadURLBase, url.PathEscape(g.metadata.Bucket)) + "?uploadType=resumable"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. error building upload session request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	httpResp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. starting upload session: %w", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gcp bucket binding error. starting upload session: %w", newUploadError(httpResp))
+	}
+	sessionURI := httpResp.Header.Get("Location")
+	if sessionURI == "" {
+		return nil, fmt.Errorf("gcp bucket binding error. starting upload session: response has no session URI")
+	}
+
+	b, err := json.Marshal(startUploadResponse{SessionURI: sessionURI, Key: name})
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. error marshalling start upload response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+// uploadChunk uploads the data of the request at the offset of a resumable upload session.
+// The object is created when the final chunk is uploaded.
+func (g *GCPStorage) uploadChunk(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := g.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. error merge metadata : %w", err)
+	}
+	sessionURI := req.Metadata[metadataSessionURI]
+	if sessionURI == "" {
+		return nil, fmt.Errorf("gcp bucket binding error: required metadata '%s' missing", metadataSessionURI)
+	}
+	offset, err := strconv.ParseInt(req.Metadata[metadataOffset], 10, 64)
+	if err != nil || offset < 0 {
+		return nil, fmt.Errorf("gcp bucket binding error: invalid metadata '%s': must be the number of bytes already uploaded", metadataOffset)
+	}
+	final := utils.IsTruthy(req.Metadata[metadataFinal])
+
+	data := req.Data
+	if metadata.DecodeBase64 {
+		data, err = io.ReadAll(b64.NewDecoder(b64.StdEncoding, bytes.NewReader(data)))
+		if err != nil {
+			return nil, fmt.Errorf("gcp bucket binding error. decoding chunk: %w", err)
+		}
+	}
+	size := int64(len(data))
+	if !final && (size == 0 || size%uploadChunkSizeMultiple != 0) {
+		return nil, fmt.Errorf("gcp bucket binding error: chunks other than the final one must be a multiple of %d bytes", uploadChunkSizeMultiple)
+	}
+
+	var contentRange string
+	switch {
+	case size == 0:
+		contentRange = fmt.Sprintf("bytes */%d", offset)
+	case final:
+		contentRange = fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, offset+size)
+	default:
+		contentRange = fmt.Sprintf("bytes %d-%d/*", offset, offset+size-1)
+	}
+
+	res, err := g.putSession(ctx, sessionURI, contentRange, data)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. uploading chunk: %w", err)
+	}
+	if !res.Completed && res.UploadedBytes != offset+size {
+		// The chunk has been partially persisted; the client resumes from the returned offset
+		g.logger.Debugf("gcp bucket binding: %d bytes of upload session persisted instead of %d", res.UploadedBytes, offset+size)
+	}
+
+	return marshalUploadChunkResponse(res)
+}
+
+// uploadStatus returns the number of bytes persisted by a resumable upload session, to resume an interrupted upload.
+func (g *GCPStorage) uploadStatus(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	sessionURI := req.Metadata[metadataSessionURI]
+	if sessionURI == "" {
+		return nil, fmt.Errorf("gcp bucket binding error: required metadata '%s' missing", metadataSessionURI)
+	}
+
+	res, err := g.putSession(ctx, sessionURI, "bytes */*", nil)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. getting upload status: %w", err)
+	}
+
+	return marshalUploadChunkResponse(res)
+}
+
+func marshalUploadChunkResponse(res *uploadChunkResponse) (*bindings.InvokeResponse, error) {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. error marshalling upload response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+// putSession sends a PUT request to an upload session, and returns the state of the upload.
+func (g *GCPStorage) putSession(ctx context.Context, sessionURI string, contentRange string, data []byte) (*uploadChunkResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURI, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.ContentLength = int64(len(data))
+	httpReq.Header.Set("Content-Range", contentRange)
+
+	httpResp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	switch httpResp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var object struct {
+			Name string `json:"name"`
+			Size string `json:"size"`
+		}
+		err = json.NewDecoder(httpResp.Body).Decode(&object)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding uploaded object: %w", err)
+		}
+		size, _ := strconv.ParseInt(object.Size, 10, 64)
+		return &uploadChunkResponse{
+			UploadedBytes: size,
+			Completed:     true,
+			ObjectURL:     fmt.Sprintf(objectURLBase, g.metadata.Bucket, object.Name),
+		}, nil
+	case statusResumeIncomplete:
+		uploaded, err := parseUploadedRange(httpResp.Header.Get("Range"))
+		if err != nil {
+			return nil, err
+		}
+		return &uploadChunkResponse{UploadedBytes: uploaded}, nil
+	default:
+		return nil, newUploadError(httpResp)
+	}
+}
+
+// parseUploadedRange returns the number of bytes persisted from the Range header of an incomplete upload, as in "bytes=0-1048575".
+func parseUploadedRange(val string) (int64, error) {
+	if val == "" {
+		// Nothing has been persisted yet
+		return 0, nil
+	}
+	_, end, ok := strings.Cut(strings.TrimPrefix(val, "bytes="), "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid range %s", val)
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range %s", val)
+	}
+	return last + 1, nil
+}
+
+func newUploadError(httpResp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+	if httpResp.StatusCode == http.StatusNotFound || httpResp.StatusCode == http.StatusGone {
+		return fmt.Errorf("upload session not found or expired, status code %d", httpResp.StatusCode)
+	}
+	return fmt.Errorf("status code %d, body %s", httpResp.StatusCode, string(body))
+}
@@ -22,6 +22,7 @@ import (
 	"google.golang.org/api/option"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -83,6 +84,12 @@ func (g *GCPPubSub) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (g *GCPPubSub) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileGCPServiceAccount},
+	}
+}
+
 func (g *GCPPubSub) parseMetadata(metadata bindings.Metadata) ([]byte, error) {
 	return json.Marshal(metadata.Properties)
 }
@@ -24,6 +24,7 @@ import (
 	graphql "github.com/machinebox/graphql"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -81,6 +82,12 @@ func (gql *GraphQL) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (gql *GraphQL) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileToken},
+	}
+}
+
 // Operations returns list of operations supported by GraphQL binding.
 func (gql *GraphQL) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
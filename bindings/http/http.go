@@ -28,7 +28,9 @@ import (
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/httputils"
 	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -66,6 +68,10 @@ func (h *HTTPSource) Init(metadata bindings.Metadata) error {
 		Dial:                dialer.Dial,
 		TLSHandshakeTimeout: 5 * time.Second,
 	}
+	err := httputils.ConfigureTransport(netTransport, dialer, metadata.Properties)
+	if err != nil {
+		return err
+	}
 	h.client = &http.Client{
 		Timeout:   time.Second * 30,
 		Transport: netTransport,
@@ -81,6 +87,12 @@ func (h *HTTPSource) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (h *HTTPSource) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 // Operations returns the supported operations for this binding.
 func (h *HTTPSource) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
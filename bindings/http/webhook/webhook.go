@@ -0,0 +1,307 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultPath          = "/"
+	defaultMethods       = http.MethodPost
+	defaultHMACHeader    = "X-Hub-Signature-256"
+	defaultHMACAlgorithm = "sha256"
+	defaultMaxBodySize   = 4 << 20
+
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+
+	// Metadata of the requests delivered to the app, in addition to their headers.
+	methodMetadata = "method"
+	pathMetadata   = "path"
+	queryMetadata  = "query"
+)
+
+// Webhook is an input binding that listens for HTTP requests and delivers them to the app.
+type Webhook struct {
+	metadata webhookMetadata
+	methods  map[string]struct{}
+	newHash  func() hash.Hash
+	logger   logger.Logger
+
+	// Address the server listens on, once Read is called.
+	addr net.Addr
+}
+
+type webhookMetadata struct {
+	// Port to listen on.
+	Port *int `mapstructure:"port"`
+	// Path of the webhook; requests to other paths are rejected.
+	Path string `mapstructure:"path"`
+	// Comma-separated HTTP methods accepted.
+	Methods string `mapstructure:"methods"`
+	// Secret of the HMAC signature of the body; if set, requests without a valid signature are rejected.
+	HMACSecret string `mapstructure:"hmacSecret"`
+	// Header of the HMAC signature, as hex with an optional "<algorithm>=" prefix.
+	HMACHeader string `mapstructure:"hmacHeader"`
+	// Hash of the HMAC signature: sha1, sha256 or sha512.
+	HMACAlgorithm string `mapstructure:"hmacAlgorithm"`
+	// Credentials of the basic authentication; if set, requests without them are rejected.
+	BasicAuthUsername string `mapstructure:"basicAuthUsername"`
+	BasicAuthPassword string `mapstructure:"basicAuthPassword"`
+	// Maximum size of the request bodies, in bytes.
+	MaxBodySize int64 `mapstructure:"maxBodySize"`
+}
+
+// NewWebhook returns a new HTTP webhook input binding.
+func NewWebhook(logger logger.Logger) bindings.InputBinding {
+	return &Webhook{logger: logger}
+}
+
+// Init performs metadata parsing.
+func (w *Webhook) Init(meta bindings.Metadata) error {
+	m := webhookMetadata{
+		Path:          defaultPath,
+		Methods:       defaultMethods,
+		HMACHeader:    defaultHMACHeader,
+		HMACAlgorithm: defaultHMACAlgorithm,
+		MaxBodySize:   defaultMaxBodySize,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	if m.Port == nil {
+		return errors.New("http webhook binding error: port is required")
+	}
+	if *m.Port < 0 || *m.Port > 65535 {
+		return fmt.Errorf("http webhook binding error: invalid port %d", *m.Port)
+	}
+	if !strings.HasPrefix(m.Path, "/") {
+		m.Path = "/" + m.Path
+	}
+	if m.MaxBodySize <= 0 {
+		return errors.New("http webhook binding error: maxBodySize must be greater than 0")
+	}
+	if (m.BasicAuthUsername == "") != (m.BasicAuthPassword == "") {
+		return errors.New("http webhook binding error: basicAuthUsername and basicAuthPassword must be set together")
+	}
+
+	w.methods = map[string]struct{}{}
+	for _, method := range strings.Split(m.Methods, ",") {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method != "" {
+			w.methods[method] = struct{}{}
+		}
+	}
+	if len(w.methods) == 0 {
+		return errors.New("http webhook binding error: methods must not be empty")
+	}
+
+	if m.HMACSecret != "" {
+		switch strings.ToLower(m.HMACAlgorithm) {
+		case "sha1":
+			w.newHash = sha1.New
+		case "sha256":
+			w.newHash = sha256.New
+		case "sha512":
+			w.newHash = sha512.New
+		default:
+			return fmt.Errorf("http webhook binding error: invalid hmacAlgorithm %s, accepted values are sha1, sha256 or sha512", m.HMACAlgorithm)
+		}
+	}
+
+	w.metadata = m
+
+	return nil
+}
+
+func (w *Webhook) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
+
+// Read starts listening for requests, and stops when the context is canceled.
+func (w *Webhook) Read(ctx context.Context, handler bindings.Handler) error {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(*w.metadata.Port))
+	if err != nil {
+		return fmt.Errorf("http webhook binding error: couldn't listen on port %d: %w", *w.metadata.Port, err)
+	}
+	w.addr = ln.Addr()
+
+	srv := &http.Server{
+		Handler:           w.handlerFunc(handler),
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	// Run the server in background
+	go func() {
+		w.logger.Debugf("http webhook binding: listening for requests at %s%s", w.addr, w.metadata.Path)
+		err := srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			w.logger.Errorf("http webhook binding: error serving requests: %v", err)
+		}
+	}()
+
+	// Close the server when context is canceled
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			w.logger.Errorf("http webhook binding: error shutting down server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (w *Webhook) handlerFunc(handler bindings.Handler) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != w.metadata.Path {
+			http.NotFound(rw, r)
+			return
+		}
+		if _, ok := w.methods[r.Method]; !ok {
+			allowed := make([]string, 0, len(w.methods))
+			for method := range w.methods {
+				allowed = append(allowed, method)
+			}
+			sort.Strings(allowed)
+			rw.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !w.checkBasicAuth(r) {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="webhook"`)
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, w.metadata.MaxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if !w.checkSignature(r, body) {
+			w.logger.Debugf("http webhook binding: rejected request with invalid signature from %s", r.RemoteAddr)
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		res, err := handler(r.Context(), readResponse(r, body))
+		if err != nil {
+			// The error of the app isn't returned to the caller, as it's untrusted
+			w.logger.Errorf("http webhook binding: error handling request: %v", err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		if len(res) > 0 {
+			_, err = rw.Write(res)
+			if err != nil {
+				w.logger.Debugf("http webhook binding: error writing response: %v", err)
+			}
+		}
+	}
+}
+
+func (w *Webhook) checkBasicAuth(r *http.Request) bool {
+	if w.metadata.BasicAuthUsername == "" {
+		return true
+	}
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(w.metadata.BasicAuthUsername)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(w.metadata.BasicAuthPassword)) == 1
+	return usernameOK && passwordOK
+}
+
+func (w *Webhook) checkSignature(r *http.Request, body []byte) bool {
+	if w.metadata.HMACSecret == "" {
+		return true
+	}
+	signature := r.Header.Get(w.metadata.HMACHeader)
+	// Signatures may be prefixed with the algorithm, as in "sha256=<hex>"
+	if _, after, ok := strings.Cut(signature, "="); ok {
+		signature = after
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	mac := hmac.New(w.newHash, []byte(w.metadata.HMACSecret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// readResponse returns the event of a request, with its headers, method, path and query string as metadata.
+func readResponse(r *http.Request, body []byte) *bindings.ReadResponse {
+	md := make(map[string]string, len(r.Header)+3)
+	for k, v := range r.Header {
+		if k == "Authorization" {
+			// Credentials aren't delivered to the app
+			continue
+		}
+		md[k] = strings.Join(v, ", ")
+	}
+	md[methodMetadata] = r.Method
+	md[pathMetadata] = r.URL.Path
+	if r.URL.RawQuery != "" {
+		md[queryMetadata] = r.URL.RawQuery
+	}
+
+	res := &bindings.ReadResponse{
+		Data:     body,
+		Metadata: md,
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		res.ContentType = &contentType
+	}
+	return res
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		err        string
+	}{
+		{"defaults", map[string]string{"port": "8080"}, ""},
+		{"no port", map[string]string{}, "port is required"},
+		{"invalid port", map[string]string{"port": "70000"}, "invalid port 70000"},
+		{"no methods", map[string]string{"port": "8080", "methods": " , "}, "methods must not be empty"},
+		{"username without password", map[string]string{"port": "8080", "basicAuthUsername": "user"}, "must be set together"},
+		{"invalid hmac algorithm", map[string]string{"port": "8080", "hmacSecret": "secret", "hmacAlgorithm": "md5"}, "invalid hmacAlgorithm md5"},
+		{"invalid max body size", map[string]string{"port": "8080", "maxBodySize": "0"}, "maxBodySize must be greater than 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWebhook(logger.NewLogger("test")).(*Webhook)
+			err := w.Init(bindings.Metadata{Base: metadata.Base{Properties: tt.properties}})
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, defaultPath, w.metadata.Path)
+			assert.Equal(t, map[string]struct{}{http.MethodPost: {}}, w.methods)
+		})
+	}
+}
+
+// startWebhook starts a webhook on a free port, and returns its URL.
+func startWebhook(t *testing.T, properties map[string]string, handler bindings.Handler) string {
+	t.Helper()
+
+	properties["port"] = "0"
+	w := NewWebhook(logger.NewLogger("test")).(*Webhook)
+	err := w.Init(bindings.Metadata{Base: metadata.Base{Properties: properties}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	err = w.Read(ctx, handler)
+	require.NoError(t, err)
+
+	return fmt.Sprintf("http://127.0.0.1:%d", w.addr.(*net.TCPAddr).Port)
+}
+
+func doRequest(t *testing.T, method, url, body string, headers map[string]string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(b)
+}
+
+func TestRead(t *testing.T) {
+	var received *bindings.ReadResponse
+	url := startWebhook(t, map[string]string{
+		"path":    "/hooks/orders",
+		"methods": "post,put",
+	}, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		received = res
+		if string(res.Data) == "fail" {
+			return nil, errors.New("app error")
+		}
+		return []byte("ok"), nil
+	})
+
+	t.Run("delivers request", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodPut, url+"/hooks/orders?id=1", `{"id":1}`, map[string]string{
+			"Content-Type": "application/json",
+			"X-Request-Id": "abc",
+		})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", body)
+		require.NotNil(t, received)
+		assert.Equal(t, `{"id":1}`, string(received.Data))
+		assert.Equal(t, "application/json", *received.ContentType)
+		assert.Equal(t, "PUT", received.Metadata["method"])
+		assert.Equal(t, "/hooks/orders", received.Metadata["path"])
+		assert.Equal(t, "id=1", received.Metadata["query"])
+		assert.Equal(t, "abc", received.Metadata["X-Request-Id"])
+	})
+
+	t.Run("app error", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodPost, url+"/hooks/orders", "fail", nil)
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.NotContains(t, body, "app error")
+	})
+
+	t.Run("other path", func(t *testing.T) {
+		received = nil
+		status, _ := doRequest(t, http.MethodPost, url+"/hooks", "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Nil(t, received)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		received = nil
+		req, err := http.NewRequest(http.MethodGet, url+"/hooks/orders", nil)
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+		assert.Equal(t, "POST, PUT", res.Header.Get("Allow"))
+		assert.Nil(t, received)
+	})
+}
+
+func TestReadBasicAuth(t *testing.T) {
+	var received *bindings.ReadResponse
+	url := startWebhook(t, map[string]string{
+		"basicAuthUsername": "user",
+		"basicAuthPassword": "pass",
+	}, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		received = res
+		return nil, nil
+	})
+
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("user", "wrong")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Nil(t, received)
+
+	req, err = http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("user", "pass")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	require.NotNil(t, received)
+	assert.NotContains(t, received.Metadata, "Authorization")
+}
+
+func TestReadHMAC(t *testing.T) {
+	var received *bindings.ReadResponse
+	url := startWebhook(t, map[string]string{
+		"hmacSecret":  "secret",
+		"maxBodySize": "16",
+	}, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		received = res
+		return nil, nil
+	})
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		status    int
+	}{
+		{"with prefix", "payload", "sha256=" + sign("payload"), http.StatusOK},
+		{"without prefix", "payload", sign("payload"), http.StatusOK},
+		{"wrong signature", "payload", sign("other"), http.StatusUnauthorized},
+		{"no signature", "payload", "", http.StatusUnauthorized},
+		{"body too large", "payload larger than max", sign("payload larger than max"), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			status, _ := doRequest(t, http.MethodPost, url, tt.body, map[string]string{
+				defaultHMACHeader: tt.signature,
+			})
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.status == http.StatusOK, received != nil)
+		})
+	}
+}
+
+func TestReadPortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	w := NewWebhook(logger.NewLogger("test"))
+	err = w.Init(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"port": fmt.Sprint(ln.Addr().(*net.TCPAddr).Port),
+	}}})
+	require.NoError(t, err)
+	err = w.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		return nil, nil
+	})
+	assert.Error(t, err)
+}
@@ -92,6 +92,12 @@ func (o *HuaweiOBS) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (o *HuaweiOBS) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 func (o *HuaweiOBS) parseMetadata(meta bindings.Metadata) (*obsMetadata, error) {
 	var m obsMetadata
 	err := metadata.DecodeMetadata(meta.Properties, &m)
@@ -0,0 +1,491 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum time to wait for the response of a command.
+const commandTimeout = time.Minute
+
+var (
+	literalRe  = regexp.MustCompile(`\{(\d+)\+?\}$`)
+	uidRe      = regexp.MustCompile(`\bUID (\d+)\b`)
+	sizeRe     = regexp.MustCompile(`\bRFC822\.SIZE (\d+)\b`)
+	capsCodeRe = regexp.MustCompile(`\[CAPABILITY ([^\]]*)\]`)
+)
+
+// response is a response of the server; the literals of the response are replaced by their size in its text.
+type response struct {
+	// "*" for untagged responses, "+" for continuation requests, or the tag of the command completed.
+	tag      string
+	text     string
+	literals [][]byte
+}
+
+// client is a minimal IMAP4rev1 client (RFC 3501), with the IDLE (RFC 2177) and MOVE (RFC 6851) extensions.
+// Commands aren't safe for concurrent use.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+
+	maxLiteral int64
+	tagNum     int
+	caps       map[string]struct{}
+
+	// Responses read in background once the connection is set up, so that IDLE can be interrupted.
+	responses chan *response
+	readErr   error
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// dial connects to an IMAP server, upgrading the connection with STARTTLS if tlsConfig is set and implicitTLS is false.
+func dial(ctx context.Context, addr string, tlsConfig *tls.Config, implicitTLS bool, maxLiteral int64) (*client, error) {
+	d := &net.Dialer{Timeout: commandTimeout}
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil && implicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: d, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{
+		conn:       conn,
+		r:          bufio.NewReader(conn),
+		w:          bufio.NewWriter(conn),
+		maxLiteral: maxLiteral,
+		caps:       map[string]struct{}{},
+		closeCh:    make(chan struct{}),
+	}
+	err = c.setup(tlsConfig, implicitTLS)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.responses = make(chan *response)
+	go c.readLoop()
+
+	return c, nil
+}
+
+// setup reads the greeting of the server, and upgrades the connection with STARTTLS if needed.
+func (c *client) setup(tlsConfig *tls.Config, implicitTLS bool) error {
+	c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	greeting, err := c.readResponse()
+	if err != nil {
+		return fmt.Errorf("error reading greeting: %w", err)
+	}
+	if greeting.tag != "*" || !strings.HasPrefix(greeting.text, "OK") {
+		return fmt.Errorf("server rejected the connection: %s", greeting.text)
+	}
+	c.parseCapabilities(greeting.text)
+
+	if tlsConfig == nil || implicitTLS {
+		return nil
+	}
+	_, err = c.execute(context.Background(), "STARTTLS")
+	if err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.conn, tlsConfig)
+	err = tlsConn.Handshake()
+	if err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	c.w = bufio.NewWriter(tlsConn)
+	// Capabilities advertised before STARTTLS must be discarded
+	c.caps = map[string]struct{}{}
+
+	return nil
+}
+
+func (c *client) readLoop() {
+	defer close(c.responses)
+	for {
+		res, err := c.readResponse()
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		select {
+		case c.responses <- res:
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// readResponse reads a response with its literals.
+func (c *client) readResponse() (*response, error) {
+	var text strings.Builder
+	var literals [][]byte
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		text.WriteString(line)
+
+		m := literalRe.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+		size, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || size > c.maxLiteral {
+			return nil, fmt.Errorf("literal of %s bytes exceeds the limit of %d bytes", m[1], c.maxLiteral)
+		}
+		literal := make([]byte, size)
+		_, err = io.ReadFull(c.r, literal)
+		if err != nil {
+			return nil, err
+		}
+		literals = append(literals, literal)
+	}
+
+	tag, rest, _ := strings.Cut(text.String(), " ")
+	return &response{tag: tag, text: rest, literals: literals}, nil
+}
+
+// next returns the next response, reading it directly while the connection is set up.
+func (c *client) next(ctx context.Context) (*response, error) {
+	if c.responses == nil {
+		return c.readResponse()
+	}
+	timer := time.NewTimer(commandTimeout)
+	defer timer.Stop()
+	select {
+	case res, ok := <-c.responses:
+		if !ok {
+			return nil, fmt.Errorf("connection closed: %w", c.readErr)
+		}
+		return res, nil
+	case <-timer.C:
+		return nil, errors.New("timed out waiting for the response of the server")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *client) writeLine(line string) error {
+	c.conn.SetWriteDeadline(time.Now().Add(commandTimeout))
+	_, err := c.w.WriteString(line + "\r\n")
+	if err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// execute sends a command, and returns its untagged responses once it completes successfully.
+func (c *client) execute(ctx context.Context, command string) ([]*response, error) {
+	c.tagNum++
+	tag := "A" + strconv.Itoa(c.tagNum)
+	err := c.writeLine(tag + " " + command)
+	if err != nil {
+		return nil, err
+	}
+
+	var untagged []*response
+	for {
+		res, err := c.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch res.tag {
+		case "*":
+			c.parseCapabilities(res.text)
+			untagged = append(untagged, res)
+		case tag:
+			c.parseCapabilities(res.text)
+			if !strings.HasPrefix(res.text, "OK") {
+				name, _, _ := strings.Cut(command, " ")
+				if name == "LOGIN" {
+					// Don't log the credentials
+					return nil, fmt.Errorf("LOGIN failed: %s", res.text)
+				}
+				return nil, fmt.Errorf("%s failed: %s", command, res.text)
+			}
+			return untagged, nil
+		}
+	}
+}
+
+// parseCapabilities updates the capabilities of the server from a CAPABILITY response or response code.
+func (c *client) parseCapabilities(text string) {
+	var list string
+	if strings.HasPrefix(text, "CAPABILITY ") {
+		list = strings.TrimPrefix(text, "CAPABILITY ")
+	} else if m := capsCodeRe.FindStringSubmatch(text); m != nil {
+		list = m[1]
+	} else {
+		return
+	}
+	c.caps = map[string]struct{}{}
+	for _, capability := range strings.Fields(list) {
+		c.caps[strings.ToUpper(capability)] = struct{}{}
+	}
+}
+
+func (c *client) hasCapability(capability string) bool {
+	_, ok := c.caps[capability]
+	return ok
+}
+
+func (c *client) login(ctx context.Context, user, password string) error {
+	if c.hasCapability("LOGINDISABLED") {
+		return errors.New("the server doesn't allow logging in without TLS")
+	}
+	u, err := quote(user)
+	if err != nil {
+		return err
+	}
+	p, err := quote(password)
+	if err != nil {
+		return err
+	}
+	_, err = c.execute(ctx, "LOGIN "+u+" "+p)
+	if err != nil {
+		return err
+	}
+	// Servers may advertise more capabilities once authenticated
+	_, err = c.execute(ctx, "CAPABILITY")
+	return err
+}
+
+func (c *client) selectMailbox(ctx context.Context, mailbox string) error {
+	q, err := quote(mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = c.execute(ctx, "SELECT "+q)
+	return err
+}
+
+// search returns the UIDs of the messages that match the search criteria.
+func (c *client) search(ctx context.Context, criteria string) ([]uint32, error) {
+	responses, err := c.execute(ctx, "UID SEARCH "+criteria)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, res := range responses {
+		if !strings.HasPrefix(res.text, "SEARCH") {
+			continue
+		}
+		for _, f := range strings.Fields(strings.TrimPrefix(res.text, "SEARCH")) {
+			uid, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SEARCH response: %s", res.text)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// sizes returns the sizes of messages by UID.
+func (c *client) sizes(ctx context.Context, uids []uint32) (map[uint32]int64, error) {
+	responses, err := c.execute(ctx, "UID FETCH "+uidSet(uids)+" (UID RFC822.SIZE)")
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint32]int64, len(uids))
+	for _, r := range responses {
+		uid, ok := fetchUID(r)
+		if !ok {
+			continue
+		}
+		if m := sizeRe.FindStringSubmatch(r.text); m != nil {
+			size, _ := strconv.ParseInt(m[1], 10, 64)
+			res[uid] = size
+		}
+	}
+	return res, nil
+}
+
+// fetch returns the full content of a message, without setting the \Seen flag.
+func (c *client) fetch(ctx context.Context, uid uint32) ([]byte, error) {
+	responses, err := c.execute(ctx, "UID FETCH "+strconv.FormatUint(uint64(uid), 10)+" (UID BODY.PEEK[])")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range responses {
+		fetched, ok := fetchUID(r)
+		if ok && fetched == uid && strings.Contains(r.text, "BODY[]") && len(r.literals) > 0 {
+			return r.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message with UID %d not found", uid)
+}
+
+func (c *client) addFlags(ctx context.Context, uid uint32, flags string) error {
+	_, err := c.execute(ctx, "UID STORE "+strconv.FormatUint(uint64(uid), 10)+" +FLAGS.SILENT ("+flags+")")
+	return err
+}
+
+// expunge permanently removes a message flagged as \Deleted.
+func (c *client) expunge(ctx context.Context, uid uint32) error {
+	if c.hasCapability("UIDPLUS") {
+		_, err := c.execute(ctx, "UID EXPUNGE "+strconv.FormatUint(uint64(uid), 10))
+		return err
+	}
+	// Without UIDPLUS, other messages flagged as deleted are expunged as well
+	_, err := c.execute(ctx, "EXPUNGE")
+	return err
+}
+
+// move moves a message to another mailbox, with MOVE if supported or else by copying and deleting it.
+func (c *client) move(ctx context.Context, uid uint32, mailbox string) error {
+	q, err := quote(mailbox)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatUint(uint64(uid), 10)
+	if c.hasCapability("MOVE") {
+		_, err = c.execute(ctx, "UID MOVE "+id+" "+q)
+		return err
+	}
+	_, err = c.execute(ctx, "UID COPY "+id+" "+q)
+	if err != nil {
+		return err
+	}
+	err = c.addFlags(ctx, uid, `\Deleted`)
+	if err != nil {
+		return err
+	}
+	return c.expunge(ctx, uid)
+}
+
+// idle waits until the server notifies new messages, the timeout expires or ctx is done.
+// It returns true if new messages arrived.
+func (c *client) idle(ctx context.Context, timeout time.Duration) (bool, error) {
+	c.tagNum++
+	tag := "A" + strconv.Itoa(c.tagNum)
+	err := c.writeLine(tag + " IDLE")
+	if err != nil {
+		return false, err
+	}
+	res, err := c.next(ctx)
+	if err != nil {
+		return false, err
+	}
+	if res.tag != "+" {
+		return false, fmt.Errorf("IDLE failed: %s", res.text)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	received := false
+	waiting := true
+	for waiting {
+		select {
+		case res, ok := <-c.responses:
+			if !ok {
+				return false, fmt.Errorf("connection closed: %w", c.readErr)
+			}
+			if res.tag == "*" && (strings.HasSuffix(res.text, " EXISTS") || strings.HasSuffix(res.text, " RECENT")) {
+				received = true
+				waiting = false
+			}
+		case <-timer.C:
+			waiting = false
+		case <-ctx.Done():
+			waiting = false
+		}
+	}
+
+	err = c.writeLine("DONE")
+	if err != nil {
+		return false, err
+	}
+	// ctx may be done already, so the completion of IDLE is awaited with a new context
+	for {
+		res, err := c.next(context.Background())
+		if err != nil {
+			return false, err
+		}
+		if res.tag == tag {
+			if !strings.HasPrefix(res.text, "OK") {
+				return false, fmt.Errorf("IDLE failed: %s", res.text)
+			}
+			return received, nil
+		}
+	}
+}
+
+// close logs out and closes the connection.
+func (c *client) close() {
+	c.closeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		// The server may have closed the connection already
+		_, _ = c.execute(ctx, "LOGOUT")
+		close(c.closeCh)
+		c.conn.Close()
+	})
+}
+
+// fetchUID returns the UID of a FETCH response.
+func fetchUID(r *response) (uint32, bool) {
+	if r.tag != "*" || !strings.Contains(r.text, " FETCH ") {
+		return 0, false
+	}
+	m := uidRe.FindStringSubmatch(r.text)
+	if m == nil {
+		return 0, false
+	}
+	uid, err := strconv.ParseUint(m[1], 10, 32)
+	return uint32(uid), err == nil
+}
+
+func uidSet(uids []uint32) string {
+	ids := make([]string, len(uids))
+	for i, uid := range uids {
+		ids[i] = strconv.FormatUint(uint64(uid), 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+// quote returns a string as an IMAP quoted string.
+func quote(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n\x00") {
+		return "", errors.New("strings with line breaks aren't supported")
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`, nil
+}
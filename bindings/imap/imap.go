@@ -0,0 +1,389 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	securityTLS      = "tls"
+	securityStartTLS = "starttls"
+	securityNone     = "none"
+
+	actionMarkSeen = "markSeen"
+	actionMove     = "move"
+	actionDelete   = "delete"
+
+	defaultTLSPort        = 993
+	defaultPort           = 143
+	defaultMailbox        = "INBOX"
+	defaultPollInterval   = time.Minute
+	defaultMaxMessageSize = 25 << 20
+	defaultSearchCriteria = "UNSEEN"
+
+	// IDLE is restarted before the 30 minutes after which servers may log the client out (RFC 2177).
+	idleTimeout = 25 * time.Minute
+
+	// Metadata of the events delivered to the app.
+	mailboxMetadata   = "mailbox"
+	uidMetadata       = "uid"
+	subjectMetadata   = "subject"
+	fromMetadata      = "from"
+	messageIDMetadata = "messageId"
+)
+
+// IMAP is an input binding that receives the messages of a mailbox and delivers them as events.
+// Messages are marked as seen, moved or deleted once the app processes them successfully.
+type IMAP struct {
+	metadata imapMetadata
+	// UIDs of the messages larger than maxMessageSize, which are skipped.
+	skipped map[uint32]struct{}
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger logger.Logger
+}
+
+type imapMetadata struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	// Connection security: tls (default), starttls or none.
+	Security      string `mapstructure:"security"`
+	SkipTLSVerify bool   `mapstructure:"skipTLSVerify"`
+	Mailbox       string `mapstructure:"mailbox"`
+	// Search criteria of the messages to receive, as in the IMAP SEARCH command.
+	SearchCriteria string `mapstructure:"searchCriteria"`
+	// Interval between checks for new messages, when IDLE isn't used.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// Wait for new messages with IDLE, if the server supports it.
+	UseIdle bool `mapstructure:"useIdle"`
+	// Action on the messages processed by the app: markSeen (default), move or delete.
+	ProcessedAction string `mapstructure:"processedAction"`
+	// Mailbox to move the processed messages to, with the move action.
+	MoveToMailbox  string `mapstructure:"moveToMailbox"`
+	MaxMessageSize int64  `mapstructure:"maxMessageSize"`
+}
+
+// NewIMAP returns a new IMAP input binding.
+func NewIMAP(logger logger.Logger) bindings.InputBinding {
+	return &IMAP{
+		logger:  logger,
+		skipped: map[uint32]struct{}{},
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init performs metadata parsing.
+func (i *IMAP) Init(meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	i.metadata = m
+
+	return nil
+}
+
+func (i *IMAP) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfilePassword},
+	}
+}
+
+func parseMetadata(meta bindings.Metadata) (imapMetadata, error) {
+	m := imapMetadata{
+		Security:        securityTLS,
+		Mailbox:         defaultMailbox,
+		SearchCriteria:  defaultSearchCriteria,
+		PollInterval:    defaultPollInterval,
+		UseIdle:         true,
+		ProcessedAction: actionMarkSeen,
+		MaxMessageSize:  defaultMaxMessageSize,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.Host == "" {
+		return m, errors.New("imap binding error: host is required")
+	}
+	if m.User == "" || m.Password == "" {
+		return m, errors.New("imap binding error: user and password are required")
+	}
+	switch m.Security {
+	case securityTLS:
+		if m.Port == 0 {
+			m.Port = defaultTLSPort
+		}
+	case securityStartTLS, securityNone:
+		if m.Port == 0 {
+			m.Port = defaultPort
+		}
+	default:
+		return m, fmt.Errorf("imap binding error: invalid security %s, accepted values are tls, starttls or none", m.Security)
+	}
+	switch m.ProcessedAction {
+	case actionMarkSeen, actionDelete:
+	case actionMove:
+		if m.MoveToMailbox == "" {
+			return m, errors.New("imap binding error: moveToMailbox is required with the move action")
+		}
+	default:
+		return m, fmt.Errorf("imap binding error: invalid processedAction %s, accepted values are markSeen, move or delete", m.ProcessedAction)
+	}
+	if m.PollInterval <= 0 {
+		return m, errors.New("imap binding error: pollInterval must be greater than 0")
+	}
+	if m.MaxMessageSize <= 0 {
+		return m, errors.New("imap binding error: maxMessageSize must be greater than 0")
+	}
+
+	return m, nil
+}
+
+// Read connects to the server and starts receiving messages, until ctx is done or the binding is closed.
+func (i *IMAP) Read(ctx context.Context, handler bindings.Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Connect before returning, so that invalid credentials are reported
+	c, err := i.connect(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		select {
+		case <-i.closeCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		defer cancel()
+		i.receive(ctx, c, handler)
+	}()
+
+	return nil
+}
+
+// receive processes the messages of the mailbox as they arrive, reconnecting on errors, until ctx is done.
+func (i *IMAP) receive(ctx context.Context, c *client, handler bindings.Handler) {
+	for {
+		var err error
+		if c == nil {
+			c, err = i.connect(ctx)
+		}
+		if err == nil {
+			err = i.watch(ctx, c, handler)
+		}
+		if c != nil {
+			c.close()
+			c = nil
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		i.logger.Errorf("imap binding: error receiving messages, retrying in %v: %v", i.metadata.PollInterval, err)
+		select {
+		case <-time.After(i.metadata.PollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (i *IMAP) connect(ctx context.Context) (*client, error) {
+	var tlsConfig *tls.Config
+	if i.metadata.Security != securityNone {
+		tlsConfig = &tls.Config{
+			ServerName:         i.metadata.Host,
+			InsecureSkipVerify: i.metadata.SkipTLSVerify, //nolint:gosec
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
+	addr := net.JoinHostPort(i.metadata.Host, strconv.Itoa(i.metadata.Port))
+	// Literals larger than the maximum size of the messages are only sent by misbehaving servers
+	c, err := dial(ctx, addr, tlsConfig, i.metadata.Security == securityTLS, 2*i.metadata.MaxMessageSize)
+	if err != nil {
+		return nil, fmt.Errorf("imap binding error: couldn't connect to %s: %w", addr, err)
+	}
+
+	err = c.login(ctx, i.metadata.User, i.metadata.Password)
+	if err == nil {
+		err = c.selectMailbox(ctx, i.metadata.Mailbox)
+	}
+	if err != nil {
+		c.close()
+		return nil, fmt.Errorf("imap binding error: %w", err)
+	}
+	i.logger.Debugf("imap binding: connected to %s, receiving messages of %s", addr, i.metadata.Mailbox)
+
+	return c, nil
+}
+
+// watch processes the messages of the mailbox, then waits for new ones with IDLE or by polling.
+func (i *IMAP) watch(ctx context.Context, c *client, handler bindings.Handler) error {
+	idle := i.metadata.UseIdle && c.hasCapability("IDLE")
+	for {
+		err := i.poll(ctx, c, handler)
+		if err != nil {
+			return err
+		}
+
+		if idle {
+			_, err = c.idle(ctx, idleTimeout)
+			if err != nil {
+				return err
+			}
+		} else {
+			select {
+			case <-time.After(i.metadata.PollInterval):
+			case <-ctx.Done():
+			}
+			// NOOP lets the server report new messages
+			_, err = c.execute(ctx, "NOOP")
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// poll delivers the messages that match the search criteria.
+func (i *IMAP) poll(ctx context.Context, c *client, handler bindings.Handler) error {
+	uids, err := c.search(ctx, i.metadata.SearchCriteria)
+	if err != nil {
+		return err
+	}
+	pending := make([]uint32, 0, len(uids))
+	for _, uid := range uids {
+		if _, ok := i.skipped[uid]; !ok {
+			pending = append(pending, uid)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	sizes, err := c.sizes(ctx, pending)
+	if err != nil {
+		return err
+	}
+	for _, uid := range pending {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if sizes[uid] > i.metadata.MaxMessageSize {
+			i.logger.Warnf("imap binding: skipping message %d of %d bytes, larger than maxMessageSize", uid, sizes[uid])
+			i.skipped[uid] = struct{}{}
+			continue
+		}
+		err = i.process(ctx, c, uid, handler)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process delivers a message to the app, and applies the processed action if the app handles it successfully.
+// Messages that the app fails to handle are delivered again on the next check.
+func (i *IMAP) process(ctx context.Context, c *client, uid uint32, handler bindings.Handler) error {
+	raw, err := c.fetch(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	e, err := parseEmail(uid, raw)
+	if err != nil {
+		// The message can't be parsed now or later, so it isn't delivered again
+		i.logger.Warnf("imap binding: skipping message %d: %v", uid, err)
+		i.skipped[uid] = struct{}{}
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/json"
+	_, err = handler(ctx, &bindings.ReadResponse{
+		Data:        data,
+		ContentType: &contentType,
+		Metadata: map[string]string{
+			mailboxMetadata:   i.metadata.Mailbox,
+			uidMetadata:       strconv.FormatUint(uint64(uid), 10),
+			subjectMetadata:   e.Subject,
+			fromMetadata:      e.From,
+			messageIDMetadata: e.MessageID,
+		},
+	})
+	if err != nil {
+		i.logger.Errorf("imap binding: error handling message %d: %v", uid, err)
+		return nil
+	}
+
+	switch i.metadata.ProcessedAction {
+	case actionMove:
+		err = c.move(ctx, uid, i.metadata.MoveToMailbox)
+	case actionDelete:
+		err = c.addFlags(ctx, uid, `\Deleted`)
+		if err == nil {
+			err = c.expunge(ctx, uid)
+		}
+	default:
+		err = c.addFlags(ctx, uid, `\Seen`)
+	}
+	if err != nil {
+		return fmt.Errorf("error applying %s to message %d: %w", i.metadata.ProcessedAction, uid, err)
+	}
+	return nil
+}
+
+// Close stops receiving messages.
+func (i *IMAP) Close() error {
+	select {
+	case <-i.closeCh:
+	default:
+		close(i.closeCh)
+	}
+	i.wg.Wait()
+
+	return nil
+}
@@ -0,0 +1,455 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const testMessage = "From: =?UTF-8?Q?Jos=C3=A9?= <jose@example.com>\r\n" +
+	"To: orders@example.com, Support <support@example.com>\r\n" +
+	"Subject: =?UTF-8?B?T3JkZXIg4pyU?=\r\n" +
+	"Message-ID: <1234@example.com>\r\n" +
+	"Date: Mon, 02 Jan 2006 15:04:05 -0700\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Order =E2=9C=94\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Order</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"order.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"order.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0x\r\n" +
+	"LjQ=\r\n" +
+	"--outer--\r\n"
+
+func simpleMessage(subject string) string {
+	return "From: sender@example.com\r\nSubject: " + subject + "\r\n\r\nHello\r\n"
+}
+
+func TestParseEmail(t *testing.T) {
+	e, err := parseEmail(7, []byte(testMessage))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(7), e.UID)
+	assert.Equal(t, "1234@example.com", e.MessageID)
+	assert.Equal(t, "José <jose@example.com>", e.From)
+	assert.Equal(t, []string{"orders@example.com", "Support <support@example.com>"}, e.To)
+	assert.Equal(t, "Order ✔", e.Subject)
+	assert.Equal(t, "2006-01-02T22:04:05Z", e.Date)
+	assert.Equal(t, "Order ✔", e.Text)
+	assert.Equal(t, "<p>Order</p>", e.HTML)
+	require.Len(t, e.Attachments, 1)
+	assert.Equal(t, "order.pdf", e.Attachments[0].Filename)
+	assert.Equal(t, "application/pdf", e.Attachments[0].ContentType)
+	assert.Equal(t, []byte("%PDF-1.4"), e.Attachments[0].Data)
+	assert.Equal(t, 8, e.Attachments[0].Size)
+
+	e, err = parseEmail(8, []byte(simpleMessage("Hi")))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello\r\n", e.Text)
+	assert.Empty(t, e.Attachments)
+}
+
+func TestParseMetadata(t *testing.T) {
+	base := func() map[string]string {
+		return map[string]string{"host": "imap.example.com", "user": "user", "password": "pass"}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: base()}})
+		require.NoError(t, err)
+		assert.Equal(t, 993, m.Port)
+		assert.Equal(t, "INBOX", m.Mailbox)
+		assert.Equal(t, "UNSEEN", m.SearchCriteria)
+		assert.Equal(t, time.Minute, m.PollInterval)
+		assert.True(t, m.UseIdle)
+		assert.Equal(t, actionMarkSeen, m.ProcessedAction)
+	})
+
+	t.Run("starttls port", func(t *testing.T) {
+		p := base()
+		p["security"] = "starttls"
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: p}})
+		require.NoError(t, err)
+		assert.Equal(t, 143, m.Port)
+	})
+
+	tests := []struct {
+		name   string
+		update func(p map[string]string)
+		err    string
+	}{
+		{"no host", func(p map[string]string) { delete(p, "host") }, "host is required"},
+		{"no password", func(p map[string]string) { delete(p, "password") }, "user and password are required"},
+		{"invalid security", func(p map[string]string) { p["security"] = "ssl" }, "invalid security ssl"},
+		{"invalid action", func(p map[string]string) { p["processedAction"] = "archive" }, "invalid processedAction archive"},
+		{"move without mailbox", func(p map[string]string) { p["processedAction"] = "move" }, "moveToMailbox is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := base()
+			tt.update(p)
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: p}})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+type fakeMessage struct {
+	uid     uint32
+	mailbox string
+	raw     string
+	flags   map[string]bool
+}
+
+// fakeServer is an in-memory IMAP server, with the commands used by the binding.
+type fakeServer struct {
+	ln   net.Listener
+	caps string
+
+	lock     sync.Mutex
+	messages []*fakeMessage
+	nextUID  uint32
+	notify   chan struct{}
+}
+
+func newFakeServer(t *testing.T, caps string) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{
+		ln:      ln,
+		caps:    caps,
+		nextUID: 1,
+		notify:  make(chan struct{}, 1),
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) properties(extra map[string]string) map[string]string {
+	p := map[string]string{
+		"host":         "127.0.0.1",
+		"port":         strconv.Itoa(s.ln.Addr().(*net.TCPAddr).Port),
+		"user":         "user",
+		"password":     `pa"ss`,
+		"security":     "none",
+		"pollInterval": "50ms",
+	}
+	for k, v := range extra {
+		p[k] = v
+	}
+	return p
+}
+
+func (s *fakeServer) add(raw string) {
+	s.lock.Lock()
+	s.messages = append(s.messages, &fakeMessage{uid: s.nextUID, mailbox: "INBOX", raw: raw, flags: map[string]bool{}})
+	s.nextUID++
+	s.lock.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *fakeServer) find(uid uint32) *fakeMessage {
+	for _, m := range s.messages {
+		if m.uid == uid && m.mailbox == "INBOX" {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *fakeServer) snapshot() []fakeMessage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := make([]fakeMessage, len(s.messages))
+	for i, m := range s.messages {
+		res[i] = *m
+		res[i].flags = map[string]bool{}
+		for k, v := range m.flags {
+			res[i].flags[k] = v
+		}
+	}
+	return res
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	write := func(format string, args ...any) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+	write("* OK [CAPABILITY IMAP4rev1 %s] ready", s.caps)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		name, args, _ := strings.Cut(command, " ")
+		if name == "UID" {
+			name, args, _ = strings.Cut(args, " ")
+			name = "UID " + name
+		}
+
+		s.lock.Lock()
+		switch name {
+		case "LOGIN":
+			if args != `"user" "pa\"ss"` {
+				write("%s NO [AUTHENTICATIONFAILED] invalid credentials", tag)
+				break
+			}
+			write("%s OK logged in", tag)
+		case "CAPABILITY":
+			write("* CAPABILITY IMAP4rev1 %s", s.caps)
+			write("%s OK done", tag)
+		case "SELECT", "NOOP", "LOGOUT":
+			write("%s OK done", tag)
+		case "UID SEARCH":
+			uids := []string{}
+			for _, m := range s.messages {
+				if m.mailbox == "INBOX" && !m.flags[`\Seen`] && !m.flags[`\Deleted`] {
+					uids = append(uids, strconv.FormatUint(uint64(m.uid), 10))
+				}
+			}
+			write("* SEARCH %s", strings.Join(uids, " "))
+			write("%s OK done", tag)
+		case "UID FETCH":
+			set, items, _ := strings.Cut(args, " ")
+			for i, id := range strings.Split(set, ",") {
+				uid, _ := strconv.ParseUint(id, 10, 32)
+				m := s.find(uint32(uid))
+				if m == nil {
+					continue
+				}
+				if strings.Contains(items, "BODY.PEEK[]") {
+					write("* %d FETCH (UID %d BODY[] {%d}\r\n%s)", i+1, m.uid, len(m.raw), m.raw)
+				} else {
+					write("* %d FETCH (UID %d RFC822.SIZE %d)", i+1, m.uid, len(m.raw))
+				}
+			}
+			write("%s OK done", tag)
+		case "UID STORE":
+			id, flags, _ := strings.Cut(args, " +FLAGS.SILENT ")
+			uid, _ := strconv.ParseUint(id, 10, 32)
+			if m := s.find(uint32(uid)); m != nil {
+				m.flags[strings.Trim(flags, "()")] = true
+			}
+			write("%s OK done", tag)
+		case "UID MOVE", "UID COPY":
+			id, mailbox, _ := strings.Cut(args, " ")
+			uid, _ := strconv.ParseUint(id, 10, 32)
+			if m := s.find(uint32(uid)); m != nil {
+				moved := &fakeMessage{uid: s.nextUID, mailbox: strings.Trim(mailbox, `"`), raw: m.raw, flags: map[string]bool{}}
+				s.nextUID++
+				s.messages = append(s.messages, moved)
+				if name == "UID MOVE" {
+					m.mailbox = ""
+				}
+			}
+			write("%s OK done", tag)
+		case "UID EXPUNGE", "EXPUNGE":
+			for _, m := range s.messages {
+				if m.flags[`\Deleted`] {
+					m.mailbox = ""
+				}
+			}
+			write("%s OK done", tag)
+		case "IDLE":
+			s.lock.Unlock()
+			write("+ idling")
+			go func() {
+				select {
+				case <-s.notify:
+					s.lock.Lock()
+					write("* %d EXISTS", len(s.messages))
+					s.lock.Unlock()
+				case <-time.After(5 * time.Second):
+				}
+			}()
+			line, err = r.ReadString('\n')
+			if err != nil || strings.TrimSpace(line) != "DONE" {
+				return
+			}
+			s.lock.Lock()
+			write("%s OK idle done", tag)
+		default:
+			write("%s BAD unknown command", tag)
+		}
+		s.lock.Unlock()
+	}
+}
+
+type received struct {
+	lock   sync.Mutex
+	events []*bindings.ReadResponse
+	fail   bool
+}
+
+func (r *received) handler(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, res)
+	if r.fail {
+		return nil, errors.New("app error")
+	}
+	return nil, nil
+}
+
+func (r *received) count() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.events)
+}
+
+func startBinding(t *testing.T, properties map[string]string, r *received) *IMAP {
+	b := NewIMAP(logger.NewLogger("test")).(*IMAP)
+	err := b.Init(bindings.Metadata{Base: metadata.Base{Properties: properties}})
+	require.NoError(t, err)
+	err = b.Read(context.Background(), r.handler)
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestRead(t *testing.T) {
+	t.Run("marks messages as seen and receives new ones with IDLE", func(t *testing.T) {
+		s := newFakeServer(t, "IDLE")
+		s.add(testMessage)
+		r := &received{}
+		startBinding(t, s.properties(nil), r)
+
+		require.Eventually(t, func() bool { return r.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+		r.lock.Lock()
+		event := r.events[0]
+		r.lock.Unlock()
+		assert.Equal(t, "1", event.Metadata[uidMetadata])
+		assert.Equal(t, "Order ✔", event.Metadata[subjectMetadata])
+		assert.Equal(t, "application/json", *event.ContentType)
+		var e email
+		require.NoError(t, json.Unmarshal(event.Data, &e))
+		assert.Equal(t, "Order ✔", e.Text)
+		assert.Eventually(t, func() bool { return s.snapshot()[0].flags[`\Seen`] }, 5*time.Second, 10*time.Millisecond)
+
+		s.add(simpleMessage("Second"))
+		require.Eventually(t, func() bool { return r.count() == 2 }, 5*time.Second, 10*time.Millisecond)
+		r.lock.Lock()
+		assert.Equal(t, "Second", r.events[1].Metadata[subjectMetadata])
+		r.lock.Unlock()
+	})
+
+	t.Run("moves messages with polling", func(t *testing.T) {
+		s := newFakeServer(t, "MOVE")
+		s.add(simpleMessage("First"))
+		r := &received{}
+		startBinding(t, s.properties(map[string]string{
+			"processedAction": "move",
+			"moveToMailbox":   "Processed",
+		}), r)
+
+		require.Eventually(t, func() bool {
+			messages := s.snapshot()
+			return len(messages) == 2 && messages[1].mailbox == "Processed"
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, "", s.snapshot()[0].mailbox)
+		assert.Equal(t, 1, r.count())
+	})
+
+	t.Run("deletes messages", func(t *testing.T) {
+		s := newFakeServer(t, "UIDPLUS")
+		s.add(simpleMessage("First"))
+		r := &received{}
+		startBinding(t, s.properties(map[string]string{"processedAction": "delete"}), r)
+
+		require.Eventually(t, func() bool { return s.snapshot()[0].mailbox == "" }, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, s.snapshot()[0].flags[`\Deleted`])
+	})
+
+	t.Run("delivers again the messages the app fails to handle", func(t *testing.T) {
+		s := newFakeServer(t, "")
+		s.add(simpleMessage("First"))
+		r := &received{fail: true}
+		startBinding(t, s.properties(nil), r)
+
+		require.Eventually(t, func() bool { return r.count() >= 2 }, 5*time.Second, 10*time.Millisecond)
+		assert.False(t, s.snapshot()[0].flags[`\Seen`])
+	})
+
+	t.Run("skips messages larger than maxMessageSize", func(t *testing.T) {
+		s := newFakeServer(t, "")
+		s.add(testMessage)
+		s.add(simpleMessage("Small"))
+		r := &received{}
+		startBinding(t, s.properties(map[string]string{"maxMessageSize": "100"}), r)
+
+		require.Eventually(t, func() bool { return s.snapshot()[1].flags[`\Seen`] }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 1, r.count())
+		assert.False(t, s.snapshot()[0].flags[`\Seen`])
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		s := newFakeServer(t, "")
+		b := NewIMAP(logger.NewLogger("test"))
+		err := b.Init(bindings.Metadata{Base: metadata.Base{Properties: s.properties(map[string]string{"password": "wrong"})}})
+		require.NoError(t, err)
+		err = b.Read(context.Background(), (&received{}).handler)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LOGIN failed")
+		assert.NotContains(t, err.Error(), "wrong")
+	})
+}
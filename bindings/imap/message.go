@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Maximum nesting of multipart bodies.
+const maxPartDepth = 10
+
+// email is a received message, delivered to the app as JSON.
+type email struct {
+	UID         uint32       `json:"uid"`
+	MessageID   string       `json:"messageId,omitempty"`
+	From        string       `json:"from,omitempty"`
+	To          []string     `json:"to,omitempty"`
+	Cc          []string     `json:"cc,omitempty"`
+	ReplyTo     []string     `json:"replyTo,omitempty"`
+	Subject     string       `json:"subject,omitempty"`
+	Date        string       `json:"date,omitempty"`
+	Text        string       `json:"text,omitempty"`
+	HTML        string       `json:"html,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+// attachment is a file attached to a message; its data is base64-encoded in JSON.
+type attachment struct {
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"contentType"`
+	ContentID   string `json:"contentId,omitempty"`
+	Size        int    `json:"size"`
+	Data        []byte `json:"data"`
+}
+
+var wordDecoder = &mime.WordDecoder{}
+
+// parseEmail parses a message in the Internet Message Format (RFC 5322) with MIME bodies.
+func parseEmail(uid uint32, raw []byte) (*email, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	e := &email{
+		UID:       uid,
+		MessageID: strings.Trim(msg.Header.Get("Message-Id"), "<>"),
+		Subject:   decodeHeader(msg.Header.Get("Subject")),
+		To:        parseAddresses(msg.Header.Get("To")),
+		Cc:        parseAddresses(msg.Header.Get("Cc")),
+		ReplyTo:   parseAddresses(msg.Header.Get("Reply-To")),
+	}
+	if from := parseAddresses(msg.Header.Get("From")); len(from) > 0 {
+		e.From = from[0]
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		e.Date = date.UTC().Format(time.RFC3339)
+	}
+
+	err = e.parsePart(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// parsePart adds the text, HTML and attachments of a MIME part to the email.
+func (e *email) parsePart(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return errors.New("invalid message: too many nested parts")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid multipart body: %w", err)
+			}
+			err = e.parsePart(part.Header, part, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("invalid body: %w", err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = decodeHeader(filename)
+
+	switch {
+	case disposition != "attachment" && filename == "" && mediaType == "text/plain" && e.Text == "":
+		e.Text = string(data)
+	case disposition != "attachment" && filename == "" && mediaType == "text/html" && e.HTML == "":
+		e.HTML = string(data)
+	default:
+		e.Attachments = append(e.Attachments, attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			ContentID:   strings.Trim(header.Get("Content-Id"), "<>"),
+			Size:        len(data),
+			Data:        data,
+		})
+	}
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// The decoder skips the line breaks
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeHeader decodes the encoded words of a header (RFC 2047), or returns it as is if they use an unsupported charset.
+func decodeHeader(s string) string {
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+func parseAddresses(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	list, err := parser.ParseList(s)
+	if err != nil {
+		// Keep malformed addresses as they're in the header
+		return []string{decodeHeader(s)}
+	}
+	res := make([]string, len(list))
+	for i, addr := range list {
+		if addr.Name != "" {
+			res[i] = fmt.Sprintf("%s <%s>", addr.Name, addr.Address)
+		} else {
+			res[i] = addr.Address
+		}
+	}
+	return res
+}
@@ -95,6 +95,12 @@ func (i *Influx) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (i *Influx) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
+
 // GetInfluxMetadata returns new Influx metadata.
 func (i *Influx) getInfluxMetadata(meta bindings.Metadata) (*influxMetadata, error) {
 	var iMetadata influxMetadata
@@ -21,6 +21,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/internal/component/kafka"
+	"github.com/dapr/components-contrib/metadata"
 )
 
 const (
@@ -69,6 +70,12 @@ func (b *Binding) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (b *Binding) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword, metadata.AuthProfileOAuth2, metadata.AuthProfileMTLS, metadata.AuthProfileSPIFFE},
+	}
+}
+
 func (b *Binding) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
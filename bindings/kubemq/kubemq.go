@@ -9,6 +9,7 @@ import (
 	qs "github.com/kubemq-io/kubemq-go/queues_stream"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -58,6 +59,12 @@ func (k *kubeMQ) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (k *kubeMQ) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileToken},
+	}
+}
+
 func (k *kubeMQ) Read(ctx context.Context, handler bindings.Handler) error {
 	go func() {
 		for {
@@ -78,10 +85,14 @@ func (k *kubeMQ) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bind
 	queueMessage := qs.NewQueueMessage().
 		SetChannel(k.opts.channel).
 		SetBody(req.Data).
-		SetPolicyDelaySeconds(parsePolicyDelaySeconds(req.Metadata)).
-		SetPolicyExpirationSeconds(parsePolicyExpirationSeconds(req.Metadata)).
-		SetPolicyMaxReceiveCount(parseSetPolicyMaxReceiveCount(req.Metadata)).
-		SetPolicyMaxReceiveQueue(parsePolicyMaxReceiveQueue(req.Metadata))
+		SetPolicyDelaySeconds(policyOrDefault(req.Metadata, "delaySeconds", parsePolicyDelaySeconds, k.opts.delaySeconds)).
+		SetPolicyExpirationSeconds(policyOrDefault(req.Metadata, "expirationSeconds", parsePolicyExpirationSeconds, k.opts.expirationSeconds)).
+		SetPolicyMaxReceiveCount(policyOrDefault(req.Metadata, "maxReceiveCount", parseSetPolicyMaxReceiveCount, k.opts.maxReceiveCount))
+	maxReceiveQueue := parsePolicyMaxReceiveQueue(req.Metadata)
+	if maxReceiveQueue == "" {
+		maxReceiveQueue = k.opts.maxReceiveQueue
+	}
+	queueMessage.SetPolicyMaxReceiveQueue(maxReceiveQueue)
 	result, err := k.client.Send(k.ctx, queueMessage)
 	if err != nil {
 		return nil, err
@@ -120,7 +131,7 @@ func (k *kubeMQ) processQueueMessage(ctx context.Context, handler bindings.Handl
 	}
 
 	for _, message := range pollResp.Messages {
-		_, err := handler(ctx, &bindings.ReadResponse{
+		_, err := k.handleWithVisibility(ctx, handler, &bindings.ReadResponse{
 			Data: message.Body,
 		})
 		if err != nil {
@@ -141,3 +152,29 @@ func (k *kubeMQ) processQueueMessage(ctx context.Context, handler bindings.Handl
 	}
 	return nil
 }
+
+// handleWithVisibility invokes the handler, failing the delivery if it takes longer than the visibility timeout
+// so that the message is nacked and becomes visible to other consumers again.
+func (k *kubeMQ) handleWithVisibility(ctx context.Context, handler bindings.Handler, msg *bindings.ReadResponse) ([]byte, error) {
+	if k.opts.visibilitySeconds <= 0 || k.opts.autoAcknowledged {
+		return handler(ctx, msg)
+	}
+
+	handlerCtx, cancel := context.WithTimeout(ctx, time.Duration(k.opts.visibilitySeconds)*time.Second)
+	defer cancel()
+	type result struct {
+		data []byte
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		data, err := handler(handlerCtx, msg)
+		resCh <- result{data: data, err: err}
+	}()
+	select {
+	case res := <-resCh:
+		return res.data, res.err
+	case <-handlerCtx.Done():
+		return nil, fmt.Errorf("visibility timeout of %d seconds expired", k.opts.visibilitySeconds)
+	}
+}
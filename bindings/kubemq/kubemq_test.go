@@ -42,6 +42,51 @@ func Test_createOptions(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "create valid opts with queue policy",
+			meta: bindings.Metadata{
+				Base: metadata.Base{
+					Name: "kubemq",
+					Properties: map[string]string{
+						"address":           "localhost:50000",
+						"channel":           "test",
+						"visibilitySeconds": "30",
+						"delaySeconds":      "5",
+						"expirationSeconds": "600",
+						"maxReceiveCount":   "3",
+						"maxReceiveQueue":   "dead-letter",
+					},
+				},
+			},
+			want: &options{
+				host:               "localhost",
+				port:               50000,
+				channel:            "test",
+				pollMaxItems:       1,
+				pollTimeoutSeconds: 3600,
+				visibilitySeconds:  30,
+				delaySeconds:       5,
+				expirationSeconds:  600,
+				maxReceiveCount:    3,
+				maxReceiveQueue:    "dead-letter",
+			},
+			wantErr: false,
+		},
+		{
+			name: "create invalid opts with bad visibility",
+			meta: bindings.Metadata{
+				Base: metadata.Base{
+					Name: "kubemq",
+					Properties: map[string]string{
+						"address":           "localhost:50000",
+						"channel":           "test",
+						"visibilitySeconds": "-1",
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "create invalid opts with bad host",
 			meta: bindings.Metadata{
@@ -402,3 +447,10 @@ func Test_parsePolicyMaxReceiveQueue(t *testing.T) {
 		})
 	}
 }
+
+func Test_policyOrDefault(t *testing.T) {
+	assert.Equal(t, 5, policyOrDefault(nil, "delaySeconds", parsePolicyDelaySeconds, 5))
+	assert.Equal(t, 5, policyOrDefault(map[string]string{"delaySeconds": ""}, "delaySeconds", parsePolicyDelaySeconds, 5))
+	assert.Equal(t, 0, policyOrDefault(map[string]string{"delaySeconds": "0"}, "delaySeconds", parsePolicyDelaySeconds, 5))
+	assert.Equal(t, 10, policyOrDefault(map[string]string{"delaySeconds": "10"}, "delaySeconds", parsePolicyDelaySeconds, 5))
+}
@@ -16,6 +16,13 @@ type options struct {
 	autoAcknowledged   bool
 	pollMaxItems       int
 	pollTimeoutSeconds int
+	visibilitySeconds  int
+
+	// Default queue policy, used when not set in the request metadata
+	delaySeconds      int
+	expirationSeconds int
+	maxReceiveCount   int
+	maxReceiveQueue   string
 }
 
 func parseAddress(address string) (string, int, error) {
@@ -97,9 +104,31 @@ func createOptions(md bindings.Metadata) (*options, error) {
 			result.pollTimeoutSeconds = timeoutSecond
 		}
 	}
+	if val, found := md.Properties["visibilitySeconds"]; found && val != "" {
+		visibilitySeconds, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubemq visibilitySeconds value, %s", err.Error())
+		}
+		if visibilitySeconds < 0 {
+			return nil, fmt.Errorf("invalid kubemq visibilitySeconds value, value must be greater or equal to 0")
+		}
+		result.visibilitySeconds = visibilitySeconds
+	}
+	result.delaySeconds = parsePolicyDelaySeconds(md.Properties)
+	result.expirationSeconds = parsePolicyExpirationSeconds(md.Properties)
+	result.maxReceiveCount = parseSetPolicyMaxReceiveCount(md.Properties)
+	result.maxReceiveQueue = parsePolicyMaxReceiveQueue(md.Properties)
 	return result, nil
 }
 
+// policyOrDefault parses a queue policy from the request metadata, falling back to the component default when not set.
+func policyOrDefault(md map[string]string, key string, parse func(map[string]string) int, defaultValue int) int {
+	if val, found := md[key]; found && val != "" {
+		return parse(md)
+	}
+	return defaultValue
+}
+
 func parsePolicyDelaySeconds(md map[string]string) int {
 	if md == nil {
 		return 0
@@ -27,6 +27,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	kubeclient "github.com/dapr/components-contrib/internal/authentication/kubernetes"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -58,6 +59,12 @@ func (k *kubernetesInput) Init(metadata bindings.Metadata) error {
 	return k.parseMetadata(metadata)
 }
 
+func (k *kubernetesInput) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileKubernetes},
+	}
+}
+
 func (k *kubernetesInput) parseMetadata(metadata bindings.Metadata) error {
 	if val, ok := metadata.Properties["namespace"]; ok && val != "" {
 		k.namespace = val
@@ -72,6 +72,12 @@ func (ls *LocalStorage) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (ls *LocalStorage) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 func (ls *LocalStorage) parseMetadata(meta bindings.Metadata) (*Metadata, error) {
 	var m Metadata
 	err := metadata.DecodeMetadata(meta.Properties, &m)
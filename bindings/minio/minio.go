@@ -0,0 +1,423 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minio
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// CreateBucketOperation creates a bucket, optionally with object locking and a default retention.
+	CreateBucketOperation bindings.OperationKind = "createBucket"
+	// SetLifecycleOperation sets the ILM policy of a bucket. An empty policy removes the lifecycle of the bucket.
+	SetLifecycleOperation bindings.OperationKind = "setLifecycle"
+	// GetLifecycleOperation returns the ILM policy of a bucket.
+	GetLifecycleOperation bindings.OperationKind = "getLifecycle"
+	// PresignPostOperation returns a presigned POST policy for browser-based uploads.
+	PresignPostOperation bindings.OperationKind = "presignPost"
+
+	metadataBucket     = "bucket"
+	metadataPresignTTL = "presignTTL"
+
+	defaultPresignTTL = 15 * time.Minute
+	// defaultTimeout is the timeout of the calls of an invocation without the operationTimeout metadata.
+	defaultTimeout = 5 * time.Minute
+)
+
+// MinIO is a binding for the operations specific to MinIO, beyond the generic S3 API.
+type MinIO struct {
+	metadata minioMetadata
+	client   minioClient
+	logger   logger.Logger
+}
+
+type minioMetadata struct {
+	Endpoint     string        `mapstructure:"endpoint"`
+	AccessKey    string        `mapstructure:"accessKey"`
+	SecretKey    string        `mapstructure:"secretKey"`
+	SessionToken string        `mapstructure:"sessionToken"`
+	Region       string        `mapstructure:"region"`
+	Bucket       string        `mapstructure:"bucket"`
+	UseSSL       bool          `mapstructure:"useSSL"`
+	InsecureSSL  bool          `mapstructure:"insecureSSL"`
+	PresignTTL   time.Duration `mapstructure:"presignTTL"`
+	// Timeout of the calls of an invocation.
+	OperationTimeout time.Duration `mapstructure:"-"`
+}
+
+// minioClient are the operations of the MinIO client used by the binding.
+type minioClient interface {
+	MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error
+	SetObjectLockConfig(ctx context.Context, bucketName string, mode *minio.RetentionMode, validity *uint, unit *minio.ValidityUnit) error
+	SetBucketLifecycle(ctx context.Context, bucketName string, config *lifecycle.Configuration) error
+	GetBucketLifecycle(ctx context.Context, bucketName string) (*lifecycle.Configuration, error)
+	PresignedPostPolicy(ctx context.Context, p *minio.PostPolicy) (*url.URL, map[string]string, error)
+}
+
+type createBucketPayload struct {
+	Bucket        string            `json:"bucket"`
+	Region        string            `json:"region"`
+	ObjectLocking bool              `json:"objectLocking"`
+	Retention     *retentionPayload `json:"retention"`
+}
+
+type retentionPayload struct {
+	Mode     string `json:"mode"`
+	Validity uint   `json:"validity"`
+	Unit     string `json:"unit"`
+}
+
+type presignPostPayload struct {
+	Key              string            `json:"key"`
+	KeyStartsWith    string            `json:"keyStartsWith"`
+	ContentType      string            `json:"contentType"`
+	ContentLengthMin int64             `json:"contentLengthMin"`
+	ContentLengthMax int64             `json:"contentLengthMax"`
+	UserMetadata     map[string]string `json:"userMetadata"`
+}
+
+type presignPostResponse struct {
+	URL       string            `json:"url"`
+	FormData  map[string]string `json:"formData"`
+	ExpiresAt string            `json:"expiresAt"`
+}
+
+// NewMinIO returns a new MinIO instance.
+func NewMinIO(logger logger.Logger) bindings.OutputBinding {
+	return &MinIO{logger: logger}
+}
+
+// Init does metadata parsing and client creation.
+func (m *MinIO) Init(metadata bindings.Metadata) error {
+	meta, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	opts := &minio.Options{
+		Creds:  credentials.NewStaticV4(meta.AccessKey, meta.SecretKey, meta.SessionToken),
+		Secure: meta.UseSSL,
+		Region: meta.Region,
+	}
+	// Use a custom transport to allow self-signed certs
+	if meta.InsecureSSL {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{
+			//nolint:gosec
+			InsecureSkipVerify: true,
+		}
+		opts.Transport = transport
+	}
+
+	client, err := minio.New(meta.Endpoint, opts)
+	if err != nil {
+		return fmt.Errorf("minio binding error: failed to create client: %w", err)
+	}
+
+	m.metadata = meta
+	m.client = client
+
+	return nil
+}
+
+func (m *MinIO) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
+func (m *MinIO) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		CreateBucketOperation,
+		SetLifecycleOperation,
+		GetLifecycleOperation,
+		PresignPostOperation,
+	}
+}
+
+func (m *MinIO) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ctx, cancel := metadata.ContextWithOperationTimeout(ctx, m.metadata.OperationTimeout)
+	defer cancel()
+
+	switch req.Operation { //nolint:exhaustive
+	case CreateBucketOperation:
+		return m.createBucket(ctx, req)
+	case SetLifecycleOperation:
+		return m.setLifecycle(ctx, req)
+	case GetLifecycleOperation:
+		return m.getLifecycle(ctx, req)
+	case PresignPostOperation:
+		return m.presignPost(ctx, req)
+	default:
+		return nil, fmt.Errorf("minio binding error: unsupported operation %s", req.Operation)
+	}
+}
+
+func (m *MinIO) createBucket(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload createBucketPayload
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("minio binding error: invalid createBucket payload: %w", err)
+		}
+	}
+	if payload.Bucket == "" {
+		payload.Bucket = m.bucket(req)
+	}
+	if payload.Bucket == "" {
+		return nil, fmt.Errorf("minio binding error: required metadata '%s' missing", metadataBucket)
+	}
+	if payload.Region == "" {
+		payload.Region = m.metadata.Region
+	}
+
+	var (
+		mode *minio.RetentionMode
+		unit *minio.ValidityUnit
+	)
+	if payload.Retention != nil {
+		// A default retention requires object locking, which can only be enabled when the bucket is created
+		if !payload.ObjectLocking {
+			return nil, errors.New("minio binding error: a default retention requires objectLocking")
+		}
+		var err error
+		mode, unit, err = payload.Retention.parse()
+		if err != nil {
+			return nil, fmt.Errorf("minio binding error: %w", err)
+		}
+	}
+
+	err := m.client.MakeBucket(ctx, payload.Bucket, minio.MakeBucketOptions{
+		Region:        payload.Region,
+		ObjectLocking: payload.ObjectLocking,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: failed to create bucket %s: %w", payload.Bucket, err)
+	}
+	m.logger.Debugf("minio binding: created bucket %s", payload.Bucket)
+
+	if mode != nil {
+		err = m.client.SetObjectLockConfig(ctx, payload.Bucket, mode, &payload.Retention.Validity, unit)
+		if err != nil {
+			return nil, fmt.Errorf("minio binding error: failed to set default retention of bucket %s: %w", payload.Bucket, err)
+		}
+	}
+
+	return nil, nil
+}
+
+func (r retentionPayload) parse() (*minio.RetentionMode, *minio.ValidityUnit, error) {
+	mode := minio.RetentionMode(strings.ToUpper(r.Mode))
+	if !mode.IsValid() {
+		return nil, nil, fmt.Errorf("invalid retention mode '%s', must be %s or %s", r.Mode, minio.Governance, minio.Compliance)
+	}
+	unit := minio.ValidityUnit(strings.ToUpper(r.Unit))
+	if unit == "" {
+		unit = minio.Days
+	}
+	if unit != minio.Days && unit != minio.Years {
+		return nil, nil, fmt.Errorf("invalid retention unit '%s', must be %s or %s", r.Unit, minio.Days, minio.Years)
+	}
+	if r.Validity == 0 {
+		return nil, nil, errors.New("retention validity must be positive")
+	}
+
+	return &mode, &unit, nil
+}
+
+func (m *MinIO) setLifecycle(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	bucket := m.bucket(req)
+	if bucket == "" {
+		return nil, fmt.Errorf("minio binding error: required metadata '%s' missing", metadataBucket)
+	}
+
+	// The policy has the JSON format of the lifecycle configurations exported by the MinIO client
+	config := lifecycle.NewConfiguration()
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, config)
+		if err != nil {
+			return nil, fmt.Errorf("minio binding error: invalid lifecycle configuration: %w", err)
+		}
+	}
+
+	err := m.client.SetBucketLifecycle(ctx, bucket, config)
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: failed to set lifecycle of bucket %s: %w", bucket, err)
+	}
+
+	return nil, nil
+}
+
+func (m *MinIO) getLifecycle(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	bucket := m.bucket(req)
+	if bucket == "" {
+		return nil, fmt.Errorf("minio binding error: required metadata '%s' missing", metadataBucket)
+	}
+
+	config, err := m.client.GetBucketLifecycle(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: failed to get lifecycle of bucket %s: %w", bucket, err)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: error marshalling lifecycle configuration: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: data,
+	}, nil
+}
+
+func (m *MinIO) presignPost(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	bucket := m.bucket(req)
+	if bucket == "" {
+		return nil, fmt.Errorf("minio binding error: required metadata '%s' missing", metadataBucket)
+	}
+
+	var payload presignPostPayload
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("minio binding error: invalid presignPost payload: %w", err)
+		}
+	}
+
+	ttl := m.metadata.PresignTTL
+	if val, ok := req.Metadata[metadataPresignTTL]; ok && val != "" {
+		var err error
+		ttl, err = time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("minio binding error: cannot parse duration %s: %w", val, err)
+		}
+	}
+	expiresAt := time.Now().UTC().Add(ttl)
+
+	policy, err := payload.policy(bucket, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: %w", err)
+	}
+
+	u, formData, err := m.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: failed to presign POST policy: %w", err)
+	}
+
+	data, err := json.Marshal(presignPostResponse{
+		URL:       u.String(),
+		FormData:  formData,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("minio binding error: error marshalling presignPost response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: data,
+	}, nil
+}
+
+// policy returns the POST policy restricting the uploads to the conditions of the payload.
+func (p presignPostPayload) policy(bucket string, expiresAt time.Time) (*minio.PostPolicy, error) {
+	if (p.Key == "") == (p.KeyStartsWith == "") {
+		return nil, errors.New("exactly one of key or keyStartsWith is required")
+	}
+
+	policy := minio.NewPostPolicy()
+	err := policy.SetBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	err = policy.SetExpires(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if p.Key != "" {
+		err = policy.SetKey(p.Key)
+	} else {
+		err = policy.SetKeyStartsWith(p.KeyStartsWith)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.ContentType != "" {
+		err = policy.SetContentType(p.ContentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.ContentLengthMax > 0 {
+		err = policy.SetContentLengthRange(p.ContentLengthMin, p.ContentLengthMax)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range p.UserMetadata {
+		err = policy.SetUserMetadata(k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// bucket returns the bucket of the request, or the bucket of the component if none is set.
+func (m *MinIO) bucket(req *bindings.InvokeRequest) string {
+	if val, ok := req.Metadata[metadataBucket]; ok && val != "" {
+		return val
+	}
+
+	return m.metadata.Bucket
+}
+
+func parseMetadata(meta bindings.Metadata) (minioMetadata, error) {
+	m := minioMetadata{
+		UseSSL:     true,
+		PresignTTL: defaultPresignTTL,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.Endpoint == "" {
+		return m, errors.New("minio binding error: missing endpoint")
+	}
+	if m.PresignTTL <= 0 {
+		return m, fmt.Errorf("minio binding error: %s must be positive", metadataPresignTTL)
+	}
+	m.OperationTimeout, err = metadata.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return m, fmt.Errorf("minio binding error: %w", err)
+	}
+
+	return m, nil
+}
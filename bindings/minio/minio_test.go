@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minio
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeClient struct {
+	bucket       string
+	bucketOpts   minio.MakeBucketOptions
+	lockMode     *minio.RetentionMode
+	lockValidity *uint
+	lockUnit     *minio.ValidityUnit
+	lifecycle    *lifecycle.Configuration
+	policy       *minio.PostPolicy
+}
+
+func (c *fakeClient) MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error {
+	c.bucket = bucketName
+	c.bucketOpts = opts
+	return nil
+}
+
+func (c *fakeClient) SetObjectLockConfig(ctx context.Context, bucketName string, mode *minio.RetentionMode, validity *uint, unit *minio.ValidityUnit) error {
+	c.lockMode, c.lockValidity, c.lockUnit = mode, validity, unit
+	return nil
+}
+
+func (c *fakeClient) SetBucketLifecycle(ctx context.Context, bucketName string, config *lifecycle.Configuration) error {
+	c.bucket = bucketName
+	c.lifecycle = config
+	return nil
+}
+
+func (c *fakeClient) GetBucketLifecycle(ctx context.Context, bucketName string) (*lifecycle.Configuration, error) {
+	return c.lifecycle, nil
+}
+
+func (c *fakeClient) PresignedPostPolicy(ctx context.Context, p *minio.PostPolicy) (*url.URL, map[string]string, error) {
+	c.policy = p
+	return &url.URL{Scheme: "https", Host: "minio.local:9000", Path: "/uploads/"}, map[string]string{"policy": "encoded"}, nil
+}
+
+func newTestBinding() (*MinIO, *fakeClient) {
+	client := &fakeClient{}
+	return &MinIO{
+		metadata: minioMetadata{Bucket: "uploads", Region: "us-east-1", PresignTTL: defaultPresignTTL},
+		client:   client,
+		logger:   logger.NewLogger("test"),
+	}, client
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("Has correct metadata", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint":   "minio.local:9000",
+			"accessKey":  "key",
+			"secretKey":  "secret",
+			"bucket":     "uploads",
+			"useSSL":     "false",
+			"presignTTL": "1h",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "minio.local:9000", m.Endpoint)
+		assert.Equal(t, "key", m.AccessKey)
+		assert.Equal(t, "secret", m.SecretKey)
+		assert.Equal(t, "uploads", m.Bucket)
+		assert.False(t, m.UseSSL)
+		assert.Equal(t, time.Hour, m.PresignTTL)
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{"endpoint": "minio.local:9000"}}})
+		require.NoError(t, err)
+		assert.True(t, m.UseSSL)
+		assert.Equal(t, defaultPresignTTL, m.PresignTTL)
+		assert.Equal(t, defaultTimeout, m.OperationTimeout)
+	})
+
+	t.Run("Missing endpoint", func(t *testing.T) {
+		_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateBucket(t *testing.T) {
+	t.Run("With object locking and retention", func(t *testing.T) {
+		m, client := newTestBinding()
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: CreateBucketOperation,
+			Data:      []byte(`{"bucket":"audit","objectLocking":true,"retention":{"mode":"compliance","validity":7}}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "audit", client.bucket)
+		assert.Equal(t, minio.MakeBucketOptions{Region: "us-east-1", ObjectLocking: true}, client.bucketOpts)
+		assert.Equal(t, minio.Compliance, *client.lockMode)
+		assert.Equal(t, uint(7), *client.lockValidity)
+		assert.Equal(t, minio.Days, *client.lockUnit)
+	})
+
+	t.Run("Default bucket", func(t *testing.T) {
+		m, client := newTestBinding()
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{Operation: CreateBucketOperation})
+		require.NoError(t, err)
+		assert.Equal(t, "uploads", client.bucket)
+		assert.False(t, client.bucketOpts.ObjectLocking)
+		assert.Nil(t, client.lockMode)
+	})
+
+	t.Run("Retention without object locking", func(t *testing.T) {
+		m, client := newTestBinding()
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: CreateBucketOperation,
+			Data:      []byte(`{"retention":{"mode":"GOVERNANCE","validity":1,"unit":"YEARS"}}`),
+		})
+		assert.Error(t, err)
+		assert.Empty(t, client.bucket)
+	})
+
+	t.Run("Invalid retention mode", func(t *testing.T) {
+		m, client := newTestBinding()
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: CreateBucketOperation,
+			Data:      []byte(`{"objectLocking":true,"retention":{"mode":"forever","validity":1}}`),
+		})
+		assert.Error(t, err)
+		assert.Empty(t, client.bucket)
+	})
+}
+
+func TestLifecycle(t *testing.T) {
+	m, client := newTestBinding()
+	policy := `{"Rules":[{"ID":"expire-logs","Status":"Enabled","Filter":{"Prefix":"logs/"},"Expiration":{"Days":30}}]}`
+	_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: SetLifecycleOperation,
+		Data:      []byte(policy),
+		Metadata:  map[string]string{"bucket": "archive"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "archive", client.bucket)
+	require.Len(t, client.lifecycle.Rules, 1)
+	assert.Equal(t, "expire-logs", client.lifecycle.Rules[0].ID)
+	assert.Equal(t, "logs/", client.lifecycle.Rules[0].RuleFilter.Prefix)
+	assert.Equal(t, lifecycle.ExpirationDays(30), client.lifecycle.Rules[0].Expiration.Days)
+
+	resp, err := m.Invoke(context.Background(), &bindings.InvokeRequest{Operation: GetLifecycleOperation})
+	require.NoError(t, err)
+	var config lifecycle.Configuration
+	require.NoError(t, json.Unmarshal(resp.Data, &config))
+	assert.Equal(t, client.lifecycle.Rules, config.Rules)
+
+	t.Run("Empty policy", func(t *testing.T) {
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{Operation: SetLifecycleOperation})
+		require.NoError(t, err)
+		assert.True(t, client.lifecycle.Empty())
+	})
+}
+
+func TestPresignPost(t *testing.T) {
+	t.Run("Returns the URL and form data", func(t *testing.T) {
+		m, client := newTestBinding()
+		resp, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PresignPostOperation,
+			Data:      []byte(`{"keyStartsWith":"user-1/","contentType":"image/png","contentLengthMax":1048576}`),
+			Metadata:  map[string]string{"presignTTL": "5m"},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, client.policy)
+
+		var result presignPostResponse
+		require.NoError(t, json.Unmarshal(resp.Data, &result))
+		assert.Equal(t, "https://minio.local:9000/uploads/", result.URL)
+		assert.Equal(t, "encoded", result.FormData["policy"])
+		expiresAt, err := time.Parse(time.RFC3339, result.ExpiresAt)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Minute)
+	})
+
+	t.Run("Requires a key condition", func(t *testing.T) {
+		m, _ := newTestBinding()
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PresignPostOperation,
+			Data:      []byte(`{"contentType":"image/png"}`),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid TTL", func(t *testing.T) {
+		m, _ := newTestBinding()
+		_, err := m.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PresignPostOperation,
+			Data:      []byte(`{"key":"file.png"}`),
+			Metadata:  map[string]string{"presignTTL": "soon"},
+		})
+		assert.Error(t, err)
+	})
+}
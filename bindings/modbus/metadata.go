@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	defaultPort         = "502"
+	defaultUnitID       = 1
+	defaultTimeout      = 10 * time.Second
+	defaultIdleTimeout  = 60 * time.Second
+	defaultPollInterval = time.Second
+
+	// Limits of the Modbus protocol on the number of items of a request.
+	maxReadCoils      = 2000
+	maxReadRegisters  = 125
+	maxWriteCoils     = 1968
+	maxWriteRegisters = 123
+)
+
+type modbusMetadata struct {
+	// Address of the device, such as 192.168.1.10:502; the port defaults to 502.
+	Endpoint string `mapstructure:"endpoint"`
+	// Unit identifier of the device, for gateways to serial devices.
+	UnitID uint8 `mapstructure:"unitId"`
+	// Timeout of the connection and of each request.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Time after which an idle connection is closed.
+	IdleTimeout time.Duration `mapstructure:"idleTimeout"`
+	// Comma-separated ranges of coils polled by the input binding, such as 0-15,100.
+	PollCoils string `mapstructure:"pollCoils"`
+	// Comma-separated ranges of holding registers polled by the input binding, such as 0-9,40.
+	PollHoldingRegisters string `mapstructure:"pollHoldingRegisters"`
+	// Interval between two polls of the input binding.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+
+	pollCoils            []addressRange
+	pollHoldingRegisters []addressRange
+}
+
+// addressRange is a range of contiguous coils or registers.
+type addressRange struct {
+	address  uint16
+	quantity uint16
+}
+
+func parseMetadata(meta bindings.Metadata) (modbusMetadata, error) {
+	m := modbusMetadata{
+		UnitID:       defaultUnitID,
+		Timeout:      defaultTimeout,
+		IdleTimeout:  defaultIdleTimeout,
+		PollInterval: defaultPollInterval,
+	}
+	err := contribMetadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.Endpoint == "" {
+		return m, errors.New("missing endpoint")
+	}
+	if _, _, err = net.SplitHostPort(m.Endpoint); err != nil {
+		m.Endpoint = net.JoinHostPort(m.Endpoint, defaultPort)
+	}
+	if m.Timeout <= 0 {
+		return m, fmt.Errorf("invalid timeout: %v", m.Timeout)
+	}
+	if m.IdleTimeout < 0 {
+		return m, fmt.Errorf("invalid idleTimeout: %v", m.IdleTimeout)
+	}
+	if m.PollInterval <= 0 {
+		return m, fmt.Errorf("invalid pollInterval: %v", m.PollInterval)
+	}
+
+	m.pollCoils, err = parseRanges(m.PollCoils, maxReadCoils)
+	if err != nil {
+		return m, fmt.Errorf("invalid pollCoils: %w", err)
+	}
+	m.pollHoldingRegisters, err = parseRanges(m.PollHoldingRegisters, maxReadRegisters)
+	if err != nil {
+		return m, fmt.Errorf("invalid pollHoldingRegisters: %w", err)
+	}
+
+	return m, nil
+}
+
+// parseRanges parses comma-separated ranges of addresses, such as 0-15,100.
+// Ranges larger than max are rejected, as they can't be read in a single request.
+func parseRanges(s string, max uint16) ([]addressRange, error) {
+	var ranges []addressRange
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		first, last, found := strings.Cut(r, "-")
+		start, err := strconv.ParseUint(strings.TrimSpace(first), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address in '%s'", r)
+		}
+		end := start
+		if found {
+			end, err = strconv.ParseUint(strings.TrimSpace(last), 10, 16)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid range '%s'", r)
+			}
+		}
+		if end-start+1 > uint64(max) {
+			return nil, fmt.Errorf("range '%s' has more than %d addresses", r, max)
+		}
+
+		ranges = append(ranges, addressRange{address: uint16(start), quantity: uint16(end - start + 1)})
+	}
+	return ranges, nil
+}
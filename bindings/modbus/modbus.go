@@ -0,0 +1,437 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goburrow/modbus"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// list of operations.
+	readCoilsOperation             bindings.OperationKind = "readCoils"
+	readHoldingRegistersOperation  bindings.OperationKind = "readHoldingRegisters"
+	writeCoilsOperation            bindings.OperationKind = "writeCoils"
+	writeHoldingRegistersOperation bindings.OperationKind = "writeHoldingRegisters"
+
+	// keys from request's metadata.
+	addressKey  = "address"
+	quantityKey = "quantity"
+
+	// keys of the metadata of input events.
+	tableKey = "table"
+
+	coilsTable            = "coils"
+	holdingRegistersTable = "holdingRegisters"
+
+	coilOn  = 0xFF00
+	coilOff = 0x0000
+)
+
+// Modbus is a binding to read and write the coils and holding registers of a Modbus TCP device,
+// and to poll them for changes.
+type Modbus struct {
+	metadata modbusMetadata
+	handler  *modbus.TCPClientHandler
+	client   modbus.Client
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger logger.Logger
+}
+
+// change is an input event, published when the value of a coil or holding register changes.
+type change struct {
+	Table   string `json:"table"`
+	Address uint16 `json:"address"`
+	Value   any    `json:"value"`
+	// Previous value; absent in the events of the first poll.
+	Previous any `json:"previous,omitempty"`
+}
+
+// NewModbus returns a new Modbus TCP binding.
+func NewModbus(logger logger.Logger) *Modbus {
+	return &Modbus{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init connects to the device.
+func (m *Modbus) Init(metadata bindings.Metadata) error {
+	meta, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	m.metadata = meta
+
+	m.handler = modbus.NewTCPClientHandler(meta.Endpoint)
+	m.handler.SlaveId = meta.UnitID
+	m.handler.Timeout = meta.Timeout
+	m.handler.IdleTimeout = meta.IdleTimeout
+	err = m.handler.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", meta.Endpoint, err)
+	}
+	m.client = modbus.NewClient(m.handler)
+
+	return nil
+}
+
+func (m *Modbus) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
+// Operations returns the operations supported by the binding.
+func (m *Modbus) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		readCoilsOperation,
+		readHoldingRegistersOperation,
+		writeCoilsOperation,
+		writeHoldingRegistersOperation,
+	}
+}
+
+// Invoke reads or writes coils or holding registers, starting at the address of the metadata.
+// Reads return a JSON array of the values, and writes take a JSON array of values, or a single value.
+func (m *Modbus) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	address, err := parseUint16(req.Metadata, addressKey, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var res any
+	switch req.Operation { //nolint:exhaustive
+	case readCoilsOperation:
+		res, err = m.readCoils(req.Metadata, address)
+	case readHoldingRegistersOperation:
+		res, err = m.readHoldingRegisters(req.Metadata, address)
+	case writeCoilsOperation:
+		err = m.writeCoils(req.Data, address)
+	case writeHoldingRegistersOperation:
+		err = m.writeHoldingRegisters(req.Data, address)
+	default:
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s, %s, %s or %s", req.Operation,
+			readCoilsOperation, readHoldingRegistersOperation, writeCoilsOperation, writeHoldingRegistersOperation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{Data: data}, nil
+}
+
+func (m *Modbus) readCoils(reqMetadata map[string]string, address uint16) ([]bool, error) {
+	quantity, err := parseQuantity(reqMetadata, maxReadCoils)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.getCoils(addressRange{address: address, quantity: quantity})
+}
+
+func (m *Modbus) readHoldingRegisters(reqMetadata map[string]string, address uint16) ([]uint16, error) {
+	quantity, err := parseQuantity(reqMetadata, maxReadRegisters)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.getHoldingRegisters(addressRange{address: address, quantity: quantity})
+}
+
+func (m *Modbus) getCoils(r addressRange) ([]bool, error) {
+	res, err := m.client.ReadCoils(r.address, r.quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %d coils at %d: %w", r.quantity, r.address, err)
+	}
+	if len(res) < (int(r.quantity)+7)/8 {
+		return nil, fmt.Errorf("expected %d coils, got %d bytes", r.quantity, len(res))
+	}
+
+	return unpackBits(res, int(r.quantity)), nil
+}
+
+func (m *Modbus) getHoldingRegisters(r addressRange) ([]uint16, error) {
+	res, err := m.client.ReadHoldingRegisters(r.address, r.quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %d holding registers at %d: %w", r.quantity, r.address, err)
+	}
+	if len(res) != 2*int(r.quantity) {
+		return nil, fmt.Errorf("expected %d holding registers, got %d bytes", r.quantity, len(res))
+	}
+
+	registers := make([]uint16, r.quantity)
+	for i := range registers {
+		registers[i] = binary.BigEndian.Uint16(res[2*i:])
+	}
+	return registers, nil
+}
+
+func (m *Modbus) writeCoils(data []byte, address uint16) error {
+	var values []bool
+	err := unmarshalValues(data, &values)
+	if err != nil {
+		return fmt.Errorf("invalid coils: %w", err)
+	}
+	if len(values) == 0 || len(values) > maxWriteCoils {
+		return fmt.Errorf("expected between 1 and %d coils, got %d", maxWriteCoils, len(values))
+	}
+
+	if len(values) == 1 {
+		value := uint16(coilOff)
+		if values[0] {
+			value = coilOn
+		}
+		_, err = m.client.WriteSingleCoil(address, value)
+	} else {
+		_, err = m.client.WriteMultipleCoils(address, uint16(len(values)), packBits(values))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %d coils at %d: %w", len(values), address, err)
+	}
+	return nil
+}
+
+func (m *Modbus) writeHoldingRegisters(data []byte, address uint16) error {
+	var values []uint16
+	err := unmarshalValues(data, &values)
+	if err != nil {
+		return fmt.Errorf("invalid holding registers: %w", err)
+	}
+	if len(values) == 0 || len(values) > maxWriteRegisters {
+		return fmt.Errorf("expected between 1 and %d holding registers, got %d", maxWriteRegisters, len(values))
+	}
+
+	if len(values) == 1 {
+		_, err = m.client.WriteSingleRegister(address, values[0])
+	} else {
+		buf := make([]byte, 2*len(values))
+		for i, v := range values {
+			binary.BigEndian.PutUint16(buf[2*i:], v)
+		}
+		_, err = m.client.WriteMultipleRegisters(address, uint16(len(values)), buf)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %d holding registers at %d: %w", len(values), address, err)
+	}
+	return nil
+}
+
+// Read polls the ranges of the pollCoils and pollHoldingRegisters metadata, and publishes an event for each value
+// that changed since the previous poll. The first poll publishes all the values.
+func (m *Modbus) Read(ctx context.Context, handler bindings.Handler) error {
+	if len(m.metadata.pollCoils) == 0 && len(m.metadata.pollHoldingRegisters) == 0 {
+		return errors.New("pollCoils or pollHoldingRegisters is required to poll the device")
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		p := newPoller(m)
+		ticker := time.NewTicker(m.metadata.PollInterval)
+		defer ticker.Stop()
+		for {
+			for _, c := range p.poll() {
+				m.publish(ctx, c, handler)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.closeCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (m *Modbus) publish(ctx context.Context, c change, handler bindings.Handler) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		m.logger.Errorf("Error marshalling change of %s %d: %v", c.Table, c.Address, err)
+		return
+	}
+	_, err = handler(ctx, &bindings.ReadResponse{
+		Data: data,
+		Metadata: map[string]string{
+			tableKey:   c.Table,
+			addressKey: strconv.FormatUint(uint64(c.Address), 10),
+		},
+	})
+	if err != nil {
+		m.logger.Errorf("Error handling change of %s %d: %v", c.Table, c.Address, err)
+	}
+}
+
+// Close stops polling and closes the connection.
+func (m *Modbus) Close() error {
+	select {
+	case <-m.closeCh:
+	default:
+		close(m.closeCh)
+	}
+	m.wg.Wait()
+
+	if m.handler == nil {
+		return nil
+	}
+	return m.handler.Close()
+}
+
+// poller keeps the values of the last poll, to detect changes.
+type poller struct {
+	m         *Modbus
+	coils     map[uint16]bool
+	registers map[uint16]uint16
+}
+
+func newPoller(m *Modbus) *poller {
+	return &poller{
+		m:         m,
+		coils:     map[uint16]bool{},
+		registers: map[uint16]uint16{},
+	}
+}
+
+// poll reads the configured ranges and returns the values that changed.
+// Ranges that fail to be read are logged and skipped until the next poll.
+func (p *poller) poll() []change {
+	var changes []change
+	for _, r := range p.m.metadata.pollCoils {
+		values, err := p.m.getCoils(r)
+		if err != nil {
+			p.m.logger.Errorf("Error polling coils: %v", err)
+			continue
+		}
+		for i, v := range values {
+			address := r.address + uint16(i)
+			c := change{Table: coilsTable, Address: address, Value: v}
+			if prev, ok := p.coils[address]; ok {
+				if prev == v {
+					continue
+				}
+				c.Previous = prev
+			}
+			p.coils[address] = v
+			changes = append(changes, c)
+		}
+	}
+	for _, r := range p.m.metadata.pollHoldingRegisters {
+		values, err := p.m.getHoldingRegisters(r)
+		if err != nil {
+			p.m.logger.Errorf("Error polling holding registers: %v", err)
+			continue
+		}
+		for i, v := range values {
+			address := r.address + uint16(i)
+			c := change{Table: holdingRegistersTable, Address: address, Value: v}
+			if prev, ok := p.registers[address]; ok {
+				if prev == v {
+					continue
+				}
+				c.Previous = prev
+			}
+			p.registers[address] = v
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func parseUint16(reqMetadata map[string]string, key string, defaultValue uint16) (uint16, error) {
+	s, ok := reqMetadata[key]
+	if !ok || s == "" {
+		return defaultValue, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", key, s)
+	}
+	return uint16(v), nil
+}
+
+func parseQuantity(reqMetadata map[string]string, max uint16) (uint16, error) {
+	quantity, err := parseUint16(reqMetadata, quantityKey, 1)
+	if err != nil {
+		return 0, err
+	}
+	if quantity == 0 || quantity > max {
+		return 0, fmt.Errorf("invalid quantity %d: expected between 1 and %d", quantity, max)
+	}
+	return quantity, nil
+}
+
+// unmarshalValues unmarshals a JSON array of values, or a single value.
+func unmarshalValues[T any](data []byte, values *[]T) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, values)
+	}
+
+	var v T
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	*values = []T{v}
+	return nil
+}
+
+// unpackBits unpacks the coils of a response, where the first coil is the least significant bit of the first byte.
+func unpackBits(b []byte, n int) []bool {
+	bits := make([]bool, n)
+	for i := range bits {
+		bits[i] = b[i/8]&(1<<(i%8)) != 0
+	}
+	return bits
+}
+
+// packBits packs coils as unpackBits expects them.
+func packBits(bits []bool) []byte {
+	b := make([]byte, (len(bits)+7)/8)
+	for i, v := range bits {
+		if v {
+			b[i/8] |= 1 << (i % 8)
+		}
+	}
+	return b
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goburrow/modbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeClient is a device with 100 coils and 100 holding registers.
+type fakeClient struct {
+	modbus.Client
+
+	lock      sync.Mutex
+	coils     [100]bool
+	registers [100]uint16
+	calls     []string
+}
+
+func (c *fakeClient) ReadCoils(address, quantity uint16) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if int(address)+int(quantity) > len(c.coils) {
+		return nil, &modbus.ModbusError{FunctionCode: modbus.FuncCodeReadCoils, ExceptionCode: modbus.ExceptionCodeIllegalDataAddress}
+	}
+	return packBits(c.coils[address : address+quantity]), nil
+}
+
+func (c *fakeClient) ReadHoldingRegisters(address, quantity uint16) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if int(address)+int(quantity) > len(c.registers) {
+		return nil, &modbus.ModbusError{FunctionCode: modbus.FuncCodeReadHoldingRegisters, ExceptionCode: modbus.ExceptionCodeIllegalDataAddress}
+	}
+	b := make([]byte, 2*quantity)
+	for i := uint16(0); i < quantity; i++ {
+		binary.BigEndian.PutUint16(b[2*i:], c.registers[address+i])
+	}
+	return b, nil
+}
+
+func (c *fakeClient) WriteSingleCoil(address, value uint16) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, "WriteSingleCoil")
+	c.coils[address] = value == coilOn
+	return nil, nil
+}
+
+func (c *fakeClient) WriteMultipleCoils(address, quantity uint16, value []byte) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, "WriteMultipleCoils")
+	copy(c.coils[address:], unpackBits(value, int(quantity)))
+	return nil, nil
+}
+
+func (c *fakeClient) WriteSingleRegister(address, value uint16) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, "WriteSingleRegister")
+	c.registers[address] = value
+	return nil, nil
+}
+
+func (c *fakeClient) WriteMultipleRegisters(address, quantity uint16, value []byte) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, "WriteMultipleRegisters")
+	for i := uint16(0); i < quantity; i++ {
+		c.registers[address+i] = binary.BigEndian.Uint16(value[2*i:])
+	}
+	return nil, nil
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint": "192.168.1.10",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "192.168.1.10:502", m.Endpoint)
+		assert.Equal(t, uint8(1), m.UnitID)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, defaultPollInterval, m.PollInterval)
+		assert.Empty(t, m.pollCoils)
+		assert.Empty(t, m.pollHoldingRegisters)
+	})
+
+	t.Run("polling", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint":             "plc.local:5020",
+			"unitId":               "17",
+			"pollCoils":            "0-15, 100",
+			"pollHoldingRegisters": "40-164",
+			"pollInterval":         "250ms",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "plc.local:5020", m.Endpoint)
+		assert.Equal(t, uint8(17), m.UnitID)
+		assert.Equal(t, []addressRange{{0, 16}, {100, 1}}, m.pollCoils)
+		assert.Equal(t, []addressRange{{40, 125}}, m.pollHoldingRegisters)
+		assert.Equal(t, 250*time.Millisecond, m.PollInterval)
+	})
+
+	tests := map[string]map[string]string{
+		"missing endpoint":       {},
+		"invalid unitId":         {"endpoint": "plc.local", "unitId": "256"},
+		"invalid pollInterval":   {"endpoint": "plc.local", "pollInterval": "0"},
+		"invalid range":          {"endpoint": "plc.local", "pollCoils": "10-5"},
+		"invalid address":        {"endpoint": "plc.local", "pollCoils": "70000"},
+		"too many registers":     {"endpoint": "plc.local", "pollHoldingRegisters": "0-125"},
+		"invalid register range": {"endpoint": "plc.local", "pollHoldingRegisters": "a-b"},
+	}
+	for name, props := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBits(t *testing.T) {
+	bits := []bool{true, false, true, true, false, false, false, false, false, true}
+	packed := packBits(bits)
+	assert.Equal(t, []byte{0x0D, 0x02}, packed)
+	assert.Equal(t, bits, unpackBits(packed, len(bits)))
+}
+
+func TestInvoke(t *testing.T) {
+	client := &fakeClient{}
+	b := NewModbus(logger.NewLogger("test"))
+	b.client = client
+
+	invoke := func(op bindings.OperationKind, md map[string]string, data string) (*bindings.InvokeResponse, error) {
+		return b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: op,
+			Metadata:  md,
+			Data:      []byte(data),
+		})
+	}
+
+	t.Run("coils", func(t *testing.T) {
+		_, err := invoke(writeCoilsOperation, map[string]string{"address": "10"}, `[true,false,true]`)
+		require.NoError(t, err)
+		_, err = invoke(writeCoilsOperation, map[string]string{"address": "13"}, `true`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"WriteMultipleCoils", "WriteSingleCoil"}, client.calls)
+
+		res, err := invoke(readCoilsOperation, map[string]string{"address": "9", "quantity": "6"}, "")
+		require.NoError(t, err)
+		assert.JSONEq(t, `[false,true,false,true,true,false]`, string(res.Data))
+	})
+
+	t.Run("holding registers", func(t *testing.T) {
+		client.calls = nil
+		_, err := invoke(writeHoldingRegistersOperation, map[string]string{"address": "20"}, `[1,65535]`)
+		require.NoError(t, err)
+		_, err = invoke(writeHoldingRegistersOperation, map[string]string{"address": "22"}, `300`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"WriteMultipleRegisters", "WriteSingleRegister"}, client.calls)
+
+		res, err := invoke(readHoldingRegistersOperation, map[string]string{"address": "20", "quantity": "4"}, "")
+		require.NoError(t, err)
+		assert.JSONEq(t, `[1,65535,300,0]`, string(res.Data))
+
+		res, err = invoke(readHoldingRegistersOperation, map[string]string{"address": "21"}, "")
+		require.NoError(t, err)
+		assert.JSONEq(t, `[65535]`, string(res.Data))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := invoke(readCoilsOperation, map[string]string{"address": "-1"}, "")
+		assert.Error(t, err)
+		_, err = invoke(readHoldingRegistersOperation, map[string]string{"quantity": "126"}, "")
+		assert.Error(t, err)
+		_, err = invoke(readHoldingRegistersOperation, map[string]string{"address": "99", "quantity": "2"}, "")
+		assert.ErrorContains(t, err, "illegal data address")
+		_, err = invoke(writeHoldingRegistersOperation, nil, `[65536]`)
+		assert.Error(t, err)
+		_, err = invoke(writeCoilsOperation, nil, `[]`)
+		assert.Error(t, err)
+		_, err = invoke(writeCoilsOperation, nil, `1`)
+		assert.Error(t, err)
+		_, err = invoke(bindings.GetOperation, nil, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestPoll(t *testing.T) {
+	client := &fakeClient{}
+	client.coils[1] = true
+	client.registers[40] = 7
+	b := NewModbus(logger.NewLogger("test"))
+	b.client = client
+	b.metadata = modbusMetadata{
+		pollCoils:            []addressRange{{0, 2}},
+		pollHoldingRegisters: []addressRange{{40, 2}, {99, 2}},
+	}
+
+	p := newPoller(b)
+	changes := p.poll()
+	assert.Equal(t, []change{
+		{Table: coilsTable, Address: 0, Value: false},
+		{Table: coilsTable, Address: 1, Value: true},
+		{Table: holdingRegistersTable, Address: 40, Value: uint16(7)},
+		{Table: holdingRegistersTable, Address: 41, Value: uint16(0)},
+	}, changes)
+
+	assert.Empty(t, p.poll())
+
+	client.coils[1] = false
+	client.registers[41] = 12
+	changes = p.poll()
+	assert.Equal(t, []change{
+		{Table: coilsTable, Address: 1, Value: false, Previous: true},
+		{Table: holdingRegistersTable, Address: 41, Value: uint16(12), Previous: uint16(0)},
+	}, changes)
+}
+
+func TestRead(t *testing.T) {
+	client := &fakeClient{}
+	b := NewModbus(logger.NewLogger("test"))
+	b.client = client
+	b.metadata = modbusMetadata{
+		pollHoldingRegisters: []addressRange{{5, 1}},
+		PollInterval:         10 * time.Millisecond,
+	}
+
+	events := make(chan *bindings.ReadResponse, 10)
+	err := b.Read(context.Background(), func(ctx context.Context, msg *bindings.ReadResponse) ([]byte, error) {
+		events <- msg
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	next := func() change {
+		select {
+		case msg := <-events:
+			assert.Equal(t, holdingRegistersTable, msg.Metadata[tableKey])
+			assert.Equal(t, "5", msg.Metadata[addressKey])
+			var c change
+			require.NoError(t, json.Unmarshal(msg.Data, &c))
+			return c
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for an event")
+			return change{}
+		}
+	}
+	assert.Equal(t, change{Table: holdingRegistersTable, Address: 5, Value: 0.0}, next())
+
+	client.lock.Lock()
+	client.registers[5] = 42
+	client.lock.Unlock()
+	assert.Equal(t, change{Table: holdingRegistersTable, Address: 5, Value: 42.0, Previous: 0.0}, next())
+
+	require.NoError(t, b.Close())
+}
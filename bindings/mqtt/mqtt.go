@@ -27,7 +27,10 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 
 	"github.com/dapr/components-contrib/bindings"
+	mqttcerts "github.com/dapr/components-contrib/internal/component/mqtt"
 	"github.com/dapr/components-contrib/internal/utils"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
@@ -66,6 +69,9 @@ type MQTT struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	backOff backoff.BackOff
+
+	secretResolver secretstores.SecretStoreResolver
+	certReloader   *mqttcerts.CertReloader
 }
 
 // NewMQTT returns a new MQTT instance.
@@ -152,6 +158,11 @@ func parseMQTTMetaData(md bindings.Metadata) (*metadata, error) {
 	return &m, nil
 }
 
+// SetSecretStoreResolver sets the resolver of the secret store holding the TLS certificates.
+func (m *MQTT) SetSecretStoreResolver(resolver secretstores.SecretStoreResolver) {
+	m.secretResolver = resolver
+}
+
 // Init does MQTT connection parsing.
 func (m *MQTT) Init(metadata bindings.Metadata) error {
 	mqttMeta, err := parseMQTTMetaData(metadata)
@@ -160,15 +171,26 @@ func (m *MQTT) Init(metadata bindings.Metadata) error {
 	}
 	m.metadata = mqttMeta
 
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	m.certReloader, err = mqttcerts.StartCertReloader(m.ctx, metadata.Properties, m.secretResolver, mqttcerts.Certs{
+		CACert:     m.metadata.caCert,
+		ClientCert: m.metadata.clientCert,
+		ClientKey:  m.metadata.clientKey,
+	}, m.logger)
+	if err != nil {
+		m.cancel()
+		return fmt.Errorf("%s %w", errorMsgPrefix, err)
+	}
+
 	// mqtt broker allows only one connection at a given time from a clientID.
 	producerClientID := fmt.Sprintf("%s-producer", m.metadata.clientID)
 	p, err := m.connect(producerClientID)
 	if err != nil {
+		m.cancel()
 		return err
 	}
 
-	m.ctx, m.cancel = context.WithCancel(context.Background())
-
 	// TODO: Make the backoff configurable for constant or exponential
 	b := backoff.NewConstantBackOff(5 * time.Second)
 	m.backOff = backoff.WithContext(b, m.ctx)
@@ -180,6 +202,12 @@ func (m *MQTT) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (m *MQTT) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfilePassword, contribMetadata.AuthProfileMTLS},
+	}
+}
+
 func (m *MQTT) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
@@ -341,7 +369,11 @@ func (m *MQTT) createClientOptions(uri *url.URL, clientID string) *mqtt.ClientOp
 	password, _ := uri.User.Password()
 	opts.SetPassword(password)
 	// tls config
-	opts.SetTLSConfig(m.newTLSConfig())
+	if m.certReloader != nil {
+		opts.SetTLSConfig(m.certReloader.TLSConfig())
+	} else {
+		opts.SetTLSConfig(m.newTLSConfig())
+	}
 
 	return opts
 }
@@ -30,6 +30,8 @@ import (
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/redact"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -81,7 +83,14 @@ func NewMysql(logger logger.Logger) bindings.OutputBinding {
 }
 
 // Init initializes the MySQL binding.
-func (m *Mysql) Init(metadata bindings.Metadata) error {
+func (m *Mysql) Init(metadata bindings.Metadata) (err error) {
+	// The DSN, with its password, may be quoted when it's invalid
+	redactor := redact.New(metadata.Properties, nil)
+	m.logger = redactor.Logger(m.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	m.logger.Debug("Initializing MySql binding")
 
 	p := metadata.Properties
@@ -125,6 +134,12 @@ func (m *Mysql) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (m *Mysql) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString},
+	}
+}
+
 // Invoke handles all invoke operations.
 func (m *Mysql) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	if req == nil {
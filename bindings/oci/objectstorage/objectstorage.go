@@ -0,0 +1,470 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oracle/oci-go-sdk/v54/common"
+	"github.com/oracle/oci-go-sdk/v54/objectstorage"
+	"github.com/oracle/oci-go-sdk/v54/objectstorage/transfer"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/authentication/oci"
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	metadataDecodeBase64 = "decodeBase64"
+	metadataEncodeBase64 = "encodeBase64"
+	metadataPresignTTL   = "presignTTL"
+	metadataContentType  = "contentType"
+
+	metadataKey = "key"
+
+	maxResults       = 1000
+	presignOperation = "presign"
+
+	// Data larger than the part size is uploaded in multiple parts.
+	defaultMultipartPartSize = 10 * 1024 * 1024
+	// OCI Object Storage doesn't accept parts smaller than 1 MiB, besides the last one.
+	minMultipartPartSize = 1024 * 1024
+	// Timeout of the calls of an invocation without the operationTimeout metadata, long enough to transfer large objects.
+	defaultTimeout = 5 * time.Minute
+
+	listFields = "name,size,etag,md5,timeCreated,timeModified,storageTier"
+)
+
+// ObjectStorage is a binding for an OCI Object Storage bucket.
+type ObjectStorage struct {
+	metadata  *objectStorageMetadata
+	client    objectStorageClient
+	uploader  streamUploader
+	sdkClient *objectstorage.ObjectStorageClient
+	endpoint  string
+	logger    logger.Logger
+}
+
+type objectStorageMetadata struct {
+	oci.Settings      `mapstructure:",squash"`
+	BucketName        string `mapstructure:"bucketName"`
+	Namespace         string `mapstructure:"namespace"`
+	DecodeBase64      bool   `mapstructure:"decodeBase64"`
+	EncodeBase64      bool   `mapstructure:"encodeBase64"`
+	PresignTTL        string `mapstructure:"presignTTL"`
+	MultipartPartSize int64  `mapstructure:"multipartPartSize"`
+	// Timeout of the calls of an invocation.
+	OperationTimeout time.Duration `mapstructure:"-"`
+}
+
+// objectStorageClient are the operations of the OCI Object Storage client used by the binding.
+type objectStorageClient interface {
+	PutObject(ctx context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error)
+	GetObject(ctx context.Context, request objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error)
+	DeleteObject(ctx context.Context, request objectstorage.DeleteObjectRequest) (objectstorage.DeleteObjectResponse, error)
+	ListObjects(ctx context.Context, request objectstorage.ListObjectsRequest) (objectstorage.ListObjectsResponse, error)
+	CreatePreauthenticatedRequest(ctx context.Context, request objectstorage.CreatePreauthenticatedRequestRequest) (objectstorage.CreatePreauthenticatedRequestResponse, error)
+}
+
+// streamUploader uploads data in multiple parts.
+type streamUploader interface {
+	UploadStream(ctx context.Context, request transfer.UploadStreamRequest) (transfer.UploadResponse, error)
+}
+
+type createResponse struct {
+	ObjectName string  `json:"objectName"`
+	ETag       *string `json:"etag"`
+	Multipart  bool    `json:"multipart"`
+	PresignURL string  `json:"presignURL,omitempty"`
+}
+
+type presignResponse struct {
+	PresignURL string `json:"presignURL"`
+	ExpiresAt  string `json:"expiresAt"`
+}
+
+type listPayload struct {
+	Marker     string `json:"marker"`
+	Prefix     string `json:"prefix"`
+	MaxResults int    `json:"maxResults"`
+	Delimiter  string `json:"delimiter"`
+}
+
+// NewObjectStorage returns a new OCI Object Storage instance.
+func NewObjectStorage(logger logger.Logger) bindings.OutputBinding {
+	return &ObjectStorage{logger: logger}
+}
+
+// Init does metadata parsing and client creation.
+func (o *ObjectStorage) Init(metadata bindings.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	configurationProvider, err := m.Settings.ConfigurationProvider()
+	if err != nil {
+		return err
+	}
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(configurationProvider)
+	if err != nil {
+		return fmt.Errorf("oci objectstorage binding error: failed to create client: %w", err)
+	}
+
+	if m.Namespace == "" {
+		resp, nsErr := client.GetNamespace(context.Background(), objectstorage.GetNamespaceRequest{})
+		if nsErr != nil {
+			return fmt.Errorf("oci objectstorage binding error: failed to get namespace: %w", nsErr)
+		}
+		m.Namespace = *resp.Value
+	}
+
+	o.metadata = m
+	o.sdkClient = &client
+	o.client = client
+	o.uploader = transfer.NewUploadManager()
+	o.endpoint = client.Host
+
+	return nil
+}
+
+func (o *ObjectStorage) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey, metadata.AuthProfileInstancePrincipal},
+	}
+}
+
+func (o *ObjectStorage) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		bindings.CreateOperation,
+		bindings.GetOperation,
+		bindings.DeleteOperation,
+		bindings.ListOperation,
+		presignOperation,
+	}
+}
+
+func (o *ObjectStorage) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ctx, cancel := metadata.ContextWithOperationTimeout(ctx, o.metadata.OperationTimeout)
+	defer cancel()
+
+	switch req.Operation {
+	case bindings.CreateOperation:
+		return o.create(ctx, req)
+	case bindings.GetOperation:
+		return o.get(ctx, req)
+	case bindings.DeleteOperation:
+		return o.delete(ctx, req)
+	case bindings.ListOperation:
+		return o.list(ctx, req)
+	case presignOperation:
+		return o.presign(ctx, req)
+	default:
+		return nil, fmt.Errorf("oci objectstorage binding error. unsupported operation %s", req.Operation)
+	}
+}
+
+func (o *ObjectStorage) create(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata := o.metadata.mergeWithRequestMetadata(req)
+	var key string
+	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
+		key = val
+	} else {
+		key = uuid.New().String()
+		o.logger.Debugf("oci objectstorage binding: key not found. generating key %s", key)
+	}
+
+	data := req.Data
+	if d, err := strconv.Unquote(string(data)); err == nil {
+		data = []byte(d)
+	}
+	if metadata.DecodeBase64 {
+		decoded, err := b64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("oci objectstorage binding error: cannot decode base64 data: %w", err)
+		}
+		data = decoded
+	}
+
+	var contentType *string
+	if val, ok := req.Metadata[metadataContentType]; ok && val != "" {
+		contentType = common.String(val)
+	}
+
+	resp := createResponse{ObjectName: key}
+	if int64(len(data)) > metadata.MultipartPartSize {
+		// The upload manager uploads the parts in parallel and commits the upload once all parts are uploaded
+		result, err := o.uploader.UploadStream(ctx, transfer.UploadStreamRequest{
+			UploadRequest: transfer.UploadRequest{
+				NamespaceName:       common.String(metadata.Namespace),
+				BucketName:          common.String(metadata.BucketName),
+				ObjectName:          common.String(key),
+				PartSize:            common.Int64(metadata.MultipartPartSize),
+				ObjectStorageClient: o.sdkClient,
+				ContentType:         contentType,
+			},
+			StreamReader: bytes.NewReader(data),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("oci objectstorage binding error: multipart upload: %w", err)
+		}
+		resp.Multipart = true
+		if result.MultipartUploadResponse != nil {
+			resp.ETag = result.MultipartUploadResponse.ETag
+		}
+	} else {
+		result, err := o.client.PutObject(ctx, objectstorage.PutObjectRequest{
+			NamespaceName: common.String(metadata.Namespace),
+			BucketName:    common.String(metadata.BucketName),
+			ObjectName:    common.String(key),
+			ContentLength: common.Int64(int64(len(data))),
+			PutObjectBody: io.NopCloser(bytes.NewReader(data)),
+			ContentType:   contentType,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("oci objectstorage binding error: uploading: %w", err)
+		}
+		resp.ETag = result.ETag
+	}
+
+	if metadata.PresignTTL != "" {
+		url, _, err := o.presignObject(ctx, metadata, key)
+		if err != nil {
+			return nil, fmt.Errorf("oci objectstorage binding error: %w", err)
+		}
+		resp.PresignURL = url
+	}
+
+	jsonResponse, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: error marshalling create response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+	}, nil
+}
+
+func (o *ObjectStorage) presign(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata := o.metadata.mergeWithRequestMetadata(req)
+	key, ok := req.Metadata[metadataKey]
+	if !ok || key == "" {
+		return nil, fmt.Errorf("oci objectstorage binding error: required metadata '%s' missing", metadataKey)
+	}
+	if metadata.PresignTTL == "" {
+		return nil, fmt.Errorf("oci objectstorage binding error: required metadata '%s' missing", metadataPresignTTL)
+	}
+
+	url, expiresAt, err := o.presignObject(ctx, metadata, key)
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: %w", err)
+	}
+
+	jsonResponse, err := json.Marshal(presignResponse{
+		PresignURL: url,
+		ExpiresAt:  expiresAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: error marshalling presign response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+	}, nil
+}
+
+// presignObject creates a pre-authenticated request granting read access to the object until the TTL expires.
+func (o *ObjectStorage) presignObject(ctx context.Context, metadata objectStorageMetadata, key string) (string, time.Time, error) {
+	d, err := time.ParseDuration(metadata.PresignTTL)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("cannot parse duration %s: %w", metadata.PresignTTL, err)
+	}
+	if d <= 0 {
+		return "", time.Time{}, fmt.Errorf("%s must be positive, got %s", metadataPresignTTL, metadata.PresignTTL)
+	}
+
+	expiresAt := time.Now().Add(d)
+	resp, err := o.client.CreatePreauthenticatedRequest(ctx, objectstorage.CreatePreauthenticatedRequestRequest{
+		NamespaceName: common.String(metadata.Namespace),
+		BucketName:    common.String(metadata.BucketName),
+		CreatePreauthenticatedRequestDetails: objectstorage.CreatePreauthenticatedRequestDetails{
+			Name:        common.String("dapr-" + uuid.New().String()),
+			AccessType:  objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectread,
+			ObjectName:  common.String(key),
+			TimeExpires: &common.SDKTime{Time: expiresAt},
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create pre-authenticated request: %w", err)
+	}
+	if resp.AccessUri == nil {
+		return "", time.Time{}, fmt.Errorf("failed to create pre-authenticated request: missing access URI")
+	}
+
+	// The access URI is relative to the endpoint of the region
+	return o.endpoint + *resp.AccessUri, expiresAt, nil
+}
+
+func (o *ObjectStorage) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata := o.metadata.mergeWithRequestMetadata(req)
+	key, ok := req.Metadata[metadataKey]
+	if !ok || key == "" {
+		return nil, fmt.Errorf("oci objectstorage binding error: can't read key value")
+	}
+
+	resp, err := o.client.GetObject(ctx, objectstorage.GetObjectRequest{
+		NamespaceName: common.String(metadata.Namespace),
+		BucketName:    common.String(metadata.BucketName),
+		ObjectName:    common.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: error downloading object: %w", err)
+	}
+	defer resp.Content.Close()
+
+	data, err := io.ReadAll(resp.Content)
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: error reading object: %w", err)
+	}
+	if metadata.EncodeBase64 {
+		data = []byte(b64.StdEncoding.EncodeToString(data))
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     data,
+		Metadata: nil,
+	}, nil
+}
+
+func (o *ObjectStorage) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	key, ok := req.Metadata[metadataKey]
+	if !ok || key == "" {
+		return nil, fmt.Errorf("oci objectstorage binding error: can't read key value")
+	}
+
+	_, err := o.client.DeleteObject(ctx, objectstorage.DeleteObjectRequest{
+		NamespaceName: common.String(o.metadata.Namespace),
+		BucketName:    common.String(o.metadata.BucketName),
+		ObjectName:    common.String(key),
+	})
+
+	return nil, err
+}
+
+func (o *ObjectStorage) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload listPayload
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if payload.MaxResults == 0 {
+		payload.MaxResults = maxResults
+	}
+
+	request := objectstorage.ListObjectsRequest{
+		NamespaceName: common.String(o.metadata.Namespace),
+		BucketName:    common.String(o.metadata.BucketName),
+		Limit:         common.Int(payload.MaxResults),
+		Fields:        common.String(listFields),
+	}
+	if payload.Prefix != "" {
+		request.Prefix = common.String(payload.Prefix)
+	}
+	if payload.Marker != "" {
+		request.Start = common.String(payload.Marker)
+	}
+	if payload.Delimiter != "" {
+		request.Delimiter = common.String(payload.Delimiter)
+	}
+
+	// The response contains the objects, the prefixes of the objects grouped by the delimiter,
+	// and nextStartWith which is the marker of the next page
+	resp, err := o.client.ListObjects(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error. list operation. cannot list objects: %w", err)
+	}
+
+	jsonResponse, err := json.Marshal(resp.ListObjects)
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error. list operation. cannot marshal objects to json: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+	}, nil
+}
+
+func parseMetadata(meta bindings.Metadata) (*objectStorageMetadata, error) {
+	m := objectStorageMetadata{
+		MultipartPartSize: defaultMultipartPartSize,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.BucketName == "" {
+		return nil, fmt.Errorf("oci objectstorage binding error: missing or empty bucketName field from metadata")
+	}
+	if m.MultipartPartSize < minMultipartPartSize {
+		return nil, fmt.Errorf("oci objectstorage binding error: multipartPartSize must be at least %d bytes", minMultipartPartSize)
+	}
+	if m.PresignTTL != "" {
+		if _, err = time.ParseDuration(m.PresignTTL); err != nil {
+			return nil, fmt.Errorf("oci objectstorage binding error: cannot parse presignTTL: %w", err)
+		}
+	}
+	err = m.Settings.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: %w", err)
+	}
+	m.OperationTimeout, err = metadata.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("oci objectstorage binding error: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Helper to merge config and request metadata.
+func (metadata objectStorageMetadata) mergeWithRequestMetadata(req *bindings.InvokeRequest) objectStorageMetadata {
+	merged := metadata
+
+	if val, ok := req.Metadata[metadataDecodeBase64]; ok && val != "" {
+		merged.DecodeBase64 = utils.IsTruthy(val)
+	}
+
+	if val, ok := req.Metadata[metadataEncodeBase64]; ok && val != "" {
+		merged.EncodeBase64 = utils.IsTruthy(val)
+	}
+
+	if val, ok := req.Metadata[metadataPresignTTL]; ok && val != "" {
+		merged.PresignTTL = val
+	}
+
+	return merged
+}
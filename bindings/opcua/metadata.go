@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opcua
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+
+	"github.com/dapr/components-contrib/bindings"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	securityPolicyNone = "None"
+
+	defaultPublishingInterval = time.Second
+)
+
+type opcuaMetadata struct {
+	// URL of the server, such as opc.tcp://localhost:4840.
+	Endpoint string `mapstructure:"endpoint"`
+	// Security policy of the connection, such as None or Basic256Sha256.
+	SecurityPolicy string `mapstructure:"securityPolicy"`
+	// Security mode of the connection: None, Sign or SignAndEncrypt.
+	// Defaults to None without security policy, and SignAndEncrypt otherwise.
+	SecurityMode string `mapstructure:"securityMode"`
+	// PEM-encoded certificate and RSA private key of the client, required with a security policy.
+	Certificate string `mapstructure:"certificate"`
+	PrivateKey  string `mapstructure:"privateKey"`
+	// URI of the client application, which must match the URI in the certificate of the client.
+	ApplicationURI string `mapstructure:"applicationURI"`
+	// PEM-encoded certificates of the trusted servers, or of the CAs that issued them.
+	TrustedCertificates string `mapstructure:"trustedCertificates"`
+	// Accepts any server certificate; for tests only.
+	InsecureSkipVerify bool `mapstructure:"insecureSkipVerify"`
+	// Credentials of the user; the session is anonymous without them.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Comma-separated IDs of the nodes monitored by the input binding, such as ns=2;s=Temperature.
+	SubscribeNodeIDs string `mapstructure:"subscribeNodeIds"`
+	// Interval at which the server publishes the changes of the monitored nodes.
+	PublishingInterval time.Duration `mapstructure:"publishingInterval"`
+
+	securityMode ua.MessageSecurityMode
+	certificate  []byte
+	privateKey   *rsa.PrivateKey
+	trustedCerts []*x509.Certificate
+	nodeIDs      []*ua.NodeID
+}
+
+func parseMetadata(meta bindings.Metadata) (*opcuaMetadata, error) {
+	m := opcuaMetadata{
+		SecurityPolicy:     securityPolicyNone,
+		PublishingInterval: defaultPublishingInterval,
+	}
+	err := contribMetadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Endpoint == "" {
+		return nil, errors.New("missing endpoint")
+	}
+	if !strings.HasPrefix(m.Endpoint, "opc.tcp://") {
+		return nil, fmt.Errorf("unsupported endpoint '%s': expected opc.tcp://", m.Endpoint)
+	}
+	if m.PublishingInterval <= 0 {
+		return nil, fmt.Errorf("invalid publishingInterval: %v", m.PublishingInterval)
+	}
+	if (m.Username == "") != (m.Password == "") {
+		return nil, errors.New("username and password must be set together")
+	}
+
+	err = m.parseSecurity()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range strings.Split(m.SubscribeNodeIDs, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := ua.ParseNodeID(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node ID '%s' in subscribeNodeIds: %w", s, err)
+		}
+		m.nodeIDs = append(m.nodeIDs, id)
+	}
+
+	return &m, nil
+}
+
+func (m *opcuaMetadata) parseSecurity() error {
+	if m.SecurityPolicy == "" {
+		m.SecurityPolicy = securityPolicyNone
+	}
+	if ua.FormatSecurityPolicyURI(m.SecurityPolicy) == "" {
+		return fmt.Errorf("invalid securityPolicy: %s", m.SecurityPolicy)
+	}
+
+	switch {
+	case m.SecurityMode != "":
+		m.securityMode = ua.MessageSecurityModeFromString(m.SecurityMode)
+		if m.securityMode == ua.MessageSecurityModeInvalid {
+			return fmt.Errorf("invalid securityMode: %s", m.SecurityMode)
+		}
+	case m.SecurityPolicy == securityPolicyNone:
+		m.securityMode = ua.MessageSecurityModeNone
+	default:
+		m.securityMode = ua.MessageSecurityModeSignAndEncrypt
+	}
+
+	if m.SecurityPolicy == securityPolicyNone {
+		if m.securityMode != ua.MessageSecurityModeNone {
+			return fmt.Errorf("securityMode %s requires a securityPolicy", m.SecurityMode)
+		}
+		return nil
+	}
+	if m.securityMode == ua.MessageSecurityModeNone {
+		return fmt.Errorf("securityPolicy %s requires the Sign or SignAndEncrypt securityMode", m.SecurityPolicy)
+	}
+
+	if m.Certificate == "" || m.PrivateKey == "" {
+		return fmt.Errorf("securityPolicy %s requires a certificate and a privateKey", m.SecurityPolicy)
+	}
+	block, _ := pem.Decode([]byte(m.Certificate))
+	if block == nil {
+		return errors.New("invalid certificate: expected PEM")
+	}
+	m.certificate = block.Bytes
+	var err error
+	m.privateKey, err = parsePrivateKey(m.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	if !m.InsecureSkipVerify {
+		m.trustedCerts, err = parseCertificates(m.TrustedCertificates)
+		if err != nil {
+			return err
+		}
+		if len(m.trustedCerts) == 0 {
+			return fmt.Errorf("securityPolicy %s requires trustedCertificates, or insecureSkipVerify", m.SecurityPolicy)
+		}
+	}
+
+	return nil
+}
+
+func parsePrivateKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("invalid privateKey: expected PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid privateKey: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid privateKey: expected an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func parseCertificates(s string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(s)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
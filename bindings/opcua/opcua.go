@@ -0,0 +1,526 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opcua
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+
+	"github.com/dapr/components-contrib/bindings"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// list of operations.
+	readOperation  bindings.OperationKind = "read"
+	writeOperation bindings.OperationKind = "write"
+
+	// keys from request's metadata.
+	nodeIDKey = "nodeId"
+
+	defaultTimeout = 30 * time.Second
+)
+
+// OPCUA is a binding to read and write the nodes of an OPC UA server, and to receive the changes of monitored nodes
+// as input events.
+type OPCUA struct {
+	metadata *opcuaMetadata
+	client   opcuaClient
+	timeout  time.Duration
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger logger.Logger
+}
+
+// opcuaClient is the subset of the OPC UA client used by the binding.
+type opcuaClient interface {
+	ReadWithContext(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error)
+	WriteWithContext(ctx context.Context, req *ua.WriteRequest) (*ua.WriteResponse, error)
+	SubscribeWithContext(ctx context.Context, params *opcua.SubscriptionParameters, notifyCh chan<- *opcua.PublishNotificationData) (*opcua.Subscription, error)
+	CloseWithContext(ctx context.Context) error
+}
+
+// nodeValue is the value of a node, as returned by read and delivered to the input binding.
+type nodeValue struct {
+	NodeID          string     `json:"nodeId"`
+	Value           any        `json:"value"`
+	DataType        string     `json:"dataType,omitempty"`
+	StatusCode      uint32     `json:"statusCode"`
+	Status          string     `json:"status,omitempty"`
+	SourceTimestamp *time.Time `json:"sourceTimestamp,omitempty"`
+	ServerTimestamp *time.Time `json:"serverTimestamp,omitempty"`
+}
+
+type readRequest struct {
+	NodeIDs []string `json:"nodeIds"`
+}
+
+type writeValue struct {
+	NodeID   string          `json:"nodeId"`
+	Value    json.RawMessage `json:"value"`
+	DataType string          `json:"dataType"`
+}
+
+type writeResult struct {
+	NodeID     string `json:"nodeId"`
+	StatusCode uint32 `json:"statusCode"`
+	Status     string `json:"status,omitempty"`
+}
+
+// NewOPCUA returns a new OPC UA binding.
+func NewOPCUA(logger logger.Logger) *OPCUA {
+	return &OPCUA{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init connects to the server.
+func (o *OPCUA) Init(metadata bindings.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	o.metadata = m
+	o.timeout, err = contribMetadata.GetOperationTimeout(metadata.Properties, defaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+
+	endpoints, err := opcua.GetEndpoints(ctx, m.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to get the endpoints of %s: %w", m.Endpoint, err)
+	}
+	ep := opcua.SelectEndpoint(endpoints, m.SecurityPolicy, m.securityMode)
+	if ep == nil {
+		return fmt.Errorf("the server doesn't have an endpoint with securityPolicy %s and securityMode %s", m.SecurityPolicy, m.securityMode)
+	}
+
+	opts := []opcua.Option{
+		opcua.SecurityPolicy(m.SecurityPolicy),
+		opcua.SecurityMode(m.securityMode),
+	}
+	if m.SecurityPolicy != securityPolicyNone {
+		if !m.InsecureSkipVerify {
+			err = verifyServerCertificate(ep.ServerCertificate, m.trustedCerts)
+			if err != nil {
+				return err
+			}
+		}
+		opts = append(opts, opcua.Certificate(m.certificate), opcua.PrivateKey(m.privateKey))
+	}
+	if m.ApplicationURI != "" {
+		opts = append(opts, opcua.ApplicationURI(m.ApplicationURI))
+	}
+	if m.Username != "" {
+		opts = append(opts,
+			opcua.AuthUsername(m.Username, m.Password),
+			opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeUserName))
+	} else {
+		opts = append(opts,
+			opcua.AuthAnonymous(),
+			opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeAnonymous))
+	}
+
+	// Connect to the configured endpoint, as the URL advertised by the server may not be reachable
+	client := opcua.NewClient(m.Endpoint, opts...)
+	err = client.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", m.Endpoint, err)
+	}
+	o.client = client
+
+	return nil
+}
+
+func (o *OPCUA) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfilePassword, contribMetadata.AuthProfileMTLS},
+	}
+}
+
+// verifyServerCertificate checks that the server certificate is trusted: either it is one of the trusted
+// certificates, or it was issued by one of them.
+func verifyServerCertificate(der []byte, trusted []*x509.Certificate) error {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("invalid server certificate: %w", err)
+	}
+
+	now := time.Now()
+	roots := x509.NewCertPool()
+	for _, t := range trusted {
+		if t.Equal(cert) {
+			if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+				return errors.New("the server certificate is expired or not yet valid")
+			}
+			return nil
+		}
+		roots.AddCert(t)
+	}
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("the server certificate is not trusted: %w", err)
+	}
+
+	return nil
+}
+
+// Operations returns the operations supported by the binding.
+func (o *OPCUA) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{readOperation, writeOperation}
+}
+
+// Invoke reads or writes nodes.
+func (o *OPCUA) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ctx, cancel := contribMetadata.ContextWithOperationTimeout(ctx, o.timeout)
+	defer cancel()
+
+	var (
+		res any
+		err error
+	)
+	switch req.Operation { //nolint:exhaustive
+	case readOperation:
+		res, err = o.read(ctx, req)
+	case writeOperation:
+		res, err = o.write(ctx, req)
+	default:
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s or %s", req.Operation, readOperation, writeOperation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{Data: data}, nil
+}
+
+// read reads the values of the nodes in the nodeIds of the data, or of the node in the nodeId metadata.
+func (o *OPCUA) read(ctx context.Context, req *bindings.InvokeRequest) ([]nodeValue, error) {
+	var r readRequest
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read request: %w", err)
+		}
+	}
+	if id := req.Metadata[nodeIDKey]; id != "" {
+		r.NodeIDs = append(r.NodeIDs, id)
+	}
+	if len(r.NodeIDs) == 0 {
+		return nil, errors.New("read requires at least one node ID")
+	}
+
+	nodes := make([]*ua.ReadValueID, len(r.NodeIDs))
+	for i, s := range r.NodeIDs {
+		id, err := ua.ParseNodeID(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node ID '%s': %w", s, err)
+		}
+		nodes[i] = &ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDValue}
+	}
+
+	res, err := o.client.ReadWithContext(ctx, &ua.ReadRequest{
+		NodesToRead:        nodes,
+		TimestampsToReturn: ua.TimestampsToReturnBoth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes: %w", err)
+	}
+	if len(res.Results) != len(nodes) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(nodes), len(res.Results))
+	}
+
+	values := make([]nodeValue, len(res.Results))
+	for i, dv := range res.Results {
+		values[i] = newNodeValue(r.NodeIDs[i], dv)
+	}
+
+	return values, nil
+}
+
+// write writes the values of the data, which is either a single value or an array of values.
+// Values without dataType are converted to the type of the current value of the node.
+func (o *OPCUA) write(ctx context.Context, req *bindings.InvokeRequest) ([]writeResult, error) {
+	var values []writeValue
+	data := bytes.TrimSpace(req.Data)
+	if len(data) > 0 && data[0] == '[' {
+		err := json.Unmarshal(data, &values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid write request: %w", err)
+		}
+	} else {
+		var v writeValue
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid write request: %w", err)
+		}
+		values = []writeValue{v}
+	}
+	if len(values) == 0 {
+		return nil, errors.New("write requires at least one value")
+	}
+
+	nodes := make([]*ua.WriteValue, len(values))
+	for i, v := range values {
+		id, err := ua.ParseNodeID(v.NodeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node ID '%s': %w", v.NodeID, err)
+		}
+
+		var typ ua.TypeID
+		if v.DataType != "" {
+			typ, err = parseTypeID(v.DataType)
+		} else {
+			typ, err = o.nodeType(ctx, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		variant, err := toVariant(v.Value, typ)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for node '%s': %w", v.NodeID, err)
+		}
+		nodes[i] = &ua.WriteValue{
+			NodeID:      id,
+			AttributeID: ua.AttributeIDValue,
+			Value: &ua.DataValue{
+				EncodingMask: ua.DataValueValue,
+				Value:        variant,
+			},
+		}
+	}
+
+	res, err := o.client.WriteWithContext(ctx, &ua.WriteRequest{NodesToWrite: nodes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write nodes: %w", err)
+	}
+	if len(res.Results) != len(nodes) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(nodes), len(res.Results))
+	}
+
+	results := make([]writeResult, len(res.Results))
+	var failed []string
+	for i, status := range res.Results {
+		results[i] = writeResult{NodeID: values[i].NodeID, StatusCode: uint32(status)}
+		if status != ua.StatusOK {
+			results[i].Status = status.Error()
+			failed = append(failed, values[i].NodeID+": "+status.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("failed to write nodes: %s", strings.Join(failed, "; "))
+	}
+
+	return results, nil
+}
+
+// nodeType returns the type of the current value of a node.
+func (o *OPCUA) nodeType(ctx context.Context, id *ua.NodeID) (ua.TypeID, error) {
+	res, err := o.client.ReadWithContext(ctx, &ua.ReadRequest{
+		NodesToRead:        []*ua.ReadValueID{{NodeID: id, AttributeID: ua.AttributeIDValue}},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the type of node '%s': %w", id, err)
+	}
+	if len(res.Results) != 1 || res.Results[0].Status != ua.StatusOK || res.Results[0].Value == nil {
+		return 0, fmt.Errorf("failed to read the type of node '%s'", id)
+	}
+
+	return res.Results[0].Value.Type(), nil
+}
+
+// Read monitors the nodes of the subscribeNodeIds metadata, and delivers their changes to the handler.
+func (o *OPCUA) Read(ctx context.Context, handler bindings.Handler) error {
+	if len(o.metadata.nodeIDs) == 0 {
+		return errors.New("subscribeNodeIds is required to receive the changes of nodes")
+	}
+
+	notifyCh := make(chan *opcua.PublishNotificationData, len(o.metadata.nodeIDs))
+	sub, err := o.client.SubscribeWithContext(ctx, &opcua.SubscriptionParameters{
+		Interval: o.metadata.PublishingInterval,
+	}, notifyCh)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	// The client handle of each monitored item is the index of its node
+	items := make([]*ua.MonitoredItemCreateRequest, len(o.metadata.nodeIDs))
+	for i, id := range o.metadata.nodeIDs {
+		items[i] = opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, uint32(i))
+	}
+	res, err := sub.MonitorWithContext(ctx, ua.TimestampsToReturnBoth, items...)
+	if err == nil {
+		for i, r := range res.Results {
+			if r.StatusCode != ua.StatusOK {
+				err = fmt.Errorf("node '%s': %w", o.metadata.nodeIDs[i], r.StatusCode)
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = sub.Cancel(ctx)
+		return fmt.Errorf("failed to monitor nodes: %w", err)
+	}
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer func() {
+			cancelCtx, cancel := context.WithTimeout(context.Background(), o.timeout)
+			defer cancel()
+			if err := sub.Cancel(cancelCtx); err != nil {
+				o.logger.Warnf("Failed to cancel subscription: %v", err)
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.closeCh:
+				return
+			case n := <-notifyCh:
+				o.handleNotification(ctx, n, handler)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// handleNotification delivers the changes of the monitored nodes of a notification.
+func (o *OPCUA) handleNotification(ctx context.Context, n *opcua.PublishNotificationData, handler bindings.Handler) {
+	if n.Error != nil {
+		o.logger.Errorf("Error in subscription: %v", n.Error)
+		return
+	}
+
+	changes, ok := n.Value.(*ua.DataChangeNotification)
+	if !ok {
+		o.logger.Debugf("Ignoring notification of type %T", n.Value)
+		return
+	}
+
+	for _, item := range changes.MonitoredItems {
+		if int(item.ClientHandle) >= len(o.metadata.nodeIDs) {
+			o.logger.Warnf("Ignoring change of unknown monitored item %d", item.ClientHandle)
+			continue
+		}
+		id := o.metadata.nodeIDs[item.ClientHandle].String()
+
+		data, err := json.Marshal(newNodeValue(id, item.Value))
+		if err != nil {
+			o.logger.Errorf("Error marshalling the value of node '%s': %v", id, err)
+			continue
+		}
+		_, err = handler(ctx, &bindings.ReadResponse{
+			Data:     data,
+			Metadata: map[string]string{nodeIDKey: id},
+		})
+		if err != nil {
+			o.logger.Errorf("Error handling the change of node '%s': %v", id, err)
+		}
+	}
+}
+
+// Close stops the subscriptions and closes the connection.
+func (o *OPCUA) Close() error {
+	select {
+	case <-o.closeCh:
+	default:
+		close(o.closeCh)
+	}
+	o.wg.Wait()
+
+	if o.client == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+	return o.client.CloseWithContext(ctx)
+}
+
+func newNodeValue(id string, dv *ua.DataValue) nodeValue {
+	v := nodeValue{NodeID: id}
+	if dv == nil {
+		return v
+	}
+
+	v.StatusCode = uint32(dv.Status)
+	if dv.Status != ua.StatusOK {
+		v.Status = dv.Status.Error()
+	}
+	if dv.Value != nil {
+		v.Value = jsonValue(dv.Value.Value())
+		v.DataType = typeName(dv.Value.Type())
+	}
+	if !dv.SourceTimestamp.IsZero() {
+		v.SourceTimestamp = &dv.SourceTimestamp
+	}
+	if !dv.ServerTimestamp.IsZero() {
+		v.ServerTimestamp = &dv.ServerTimestamp
+	}
+
+	return v
+}
+
+// jsonValue converts the OPC UA types that don't have a natural JSON encoding.
+func jsonValue(v any) any {
+	switch x := v.(type) {
+	case *ua.NodeID:
+		return x.String()
+	case *ua.ExpandedNodeID:
+		return x.NodeID.String()
+	case *ua.GUID:
+		return x.String()
+	case *ua.LocalizedText:
+		return x.Text
+	case *ua.QualifiedName:
+		return x.Name
+	case ua.StatusCode:
+		return uint32(x)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,377 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opcua
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeClient struct {
+	reads  []*ua.ReadRequest
+	writes []*ua.WriteRequest
+
+	values      map[string]*ua.DataValue
+	writeStatus ua.StatusCode
+}
+
+func (c *fakeClient) ReadWithContext(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error) {
+	c.reads = append(c.reads, req)
+	res := &ua.ReadResponse{Results: make([]*ua.DataValue, len(req.NodesToRead))}
+	for i, n := range req.NodesToRead {
+		dv, ok := c.values[n.NodeID.String()]
+		if !ok {
+			dv = &ua.DataValue{Status: ua.StatusBadNodeIDUnknown}
+		}
+		res.Results[i] = dv
+	}
+	return res, nil
+}
+
+func (c *fakeClient) WriteWithContext(ctx context.Context, req *ua.WriteRequest) (*ua.WriteResponse, error) {
+	c.writes = append(c.writes, req)
+	res := &ua.WriteResponse{Results: make([]ua.StatusCode, len(req.NodesToWrite))}
+	for i := range res.Results {
+		res.Results[i] = c.writeStatus
+	}
+	return res, nil
+}
+
+func (c *fakeClient) SubscribeWithContext(ctx context.Context, params *opcua.SubscriptionParameters, notifyCh chan<- *opcua.PublishNotificationData) (*opcua.Subscription, error) {
+	return nil, ua.StatusBadNotImplemented
+}
+
+func (c *fakeClient) CloseWithContext(ctx context.Context) error {
+	return nil
+}
+
+func generateCertificate(t *testing.T, isCA bool, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "opcua-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func certPEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+func keyPEM(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestParseMetadata(t *testing.T) {
+	cert, key := generateCertificate(t, false, nil, nil)
+	server, _ := generateCertificate(t, false, nil, nil)
+
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint":         "opc.tcp://localhost:4840",
+			"subscribeNodeIds": "ns=2;s=Temperature, i=2258",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, securityPolicyNone, m.SecurityPolicy)
+		assert.Equal(t, ua.MessageSecurityModeNone, m.securityMode)
+		assert.Equal(t, time.Second, m.PublishingInterval)
+		require.Len(t, m.nodeIDs, 2)
+		assert.Equal(t, "ns=2;s=Temperature", m.nodeIDs[0].String())
+		assert.Equal(t, "i=2258", m.nodeIDs[1].String())
+	})
+
+	t.Run("Basic256Sha256", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint":            "opc.tcp://localhost:4840",
+			"securityPolicy":      "Basic256Sha256",
+			"certificate":         certPEM(cert),
+			"privateKey":          keyPEM(key),
+			"trustedCertificates": certPEM(server),
+			"publishingInterval":  "500ms",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, ua.MessageSecurityModeSignAndEncrypt, m.securityMode)
+		assert.Equal(t, cert.Raw, m.certificate)
+		assert.True(t, key.Equal(m.privateKey))
+		require.Len(t, m.trustedCerts, 1)
+		assert.True(t, server.Equal(m.trustedCerts[0]))
+		assert.Equal(t, 500*time.Millisecond, m.PublishingInterval)
+	})
+
+	tests := map[string]map[string]string{
+		"missing endpoint": {},
+		"invalid endpoint": {"endpoint": "http://localhost:4840"},
+		"invalid policy":   {"endpoint": "opc.tcp://localhost:4840", "securityPolicy": "Basic1"},
+		"invalid mode":     {"endpoint": "opc.tcp://localhost:4840", "securityPolicy": "Basic256Sha256", "securityMode": "Encrypt"},
+		"mode without policy": {
+			"endpoint": "opc.tcp://localhost:4840", "securityMode": "Sign",
+		},
+		"policy without certificate": {
+			"endpoint": "opc.tcp://localhost:4840", "securityPolicy": "Basic256Sha256",
+		},
+		"policy without trusted certificates": {
+			"endpoint": "opc.tcp://localhost:4840", "securityPolicy": "Basic256Sha256",
+			"certificate": certPEM(cert), "privateKey": keyPEM(key),
+		},
+		"username without password": {"endpoint": "opc.tcp://localhost:4840", "username": "user"},
+		"invalid node ID":           {"endpoint": "opc.tcp://localhost:4840", "subscribeNodeIds": "ns=x;s=y"},
+	}
+	for name, props := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("insecureSkipVerify", func(t *testing.T) {
+		_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoint":           "opc.tcp://localhost:4840",
+			"securityPolicy":     "Basic256Sha256",
+			"securityMode":       "Sign",
+			"certificate":        certPEM(cert),
+			"privateKey":         keyPEM(key),
+			"insecureSkipVerify": "true",
+		}}})
+		assert.NoError(t, err)
+	})
+}
+
+func TestVerifyServerCertificate(t *testing.T) {
+	ca, caKey := generateCertificate(t, true, nil, nil)
+	issued, _ := generateCertificate(t, false, ca, caKey)
+	selfSigned, _ := generateCertificate(t, false, nil, nil)
+	other, _ := generateCertificate(t, false, nil, nil)
+
+	assert.NoError(t, verifyServerCertificate(selfSigned.Raw, []*x509.Certificate{other, selfSigned}))
+	assert.NoError(t, verifyServerCertificate(issued.Raw, []*x509.Certificate{ca}))
+	assert.Error(t, verifyServerCertificate(selfSigned.Raw, []*x509.Certificate{other}))
+	assert.Error(t, verifyServerCertificate(issued.Raw, []*x509.Certificate{other}))
+	assert.Error(t, verifyServerCertificate([]byte("invalid"), []*x509.Certificate{ca}))
+}
+
+func TestToVariant(t *testing.T) {
+	date := time.Date(2022, 10, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		dataType string
+		value    string
+		expected any
+	}{
+		{"Boolean", `true`, true},
+		{"SByte", `-8`, int8(-8)},
+		{"Byte", `200`, uint8(200)},
+		{"Int16", `-300`, int16(-300)},
+		{"UInt16", `60000`, uint16(60000)},
+		{"Int32", `-70000`, int32(-70000)},
+		{"uint32", `70000`, uint32(70000)},
+		{"Int64", `-9007199254740993`, int64(-9007199254740993)},
+		{"UInt64", `18446744073709551615`, uint64(18446744073709551615)},
+		{"Float", `1.5`, float32(1.5)},
+		{"Double", `21.25`, 21.25},
+		{"String", `"hello"`, "hello"},
+		{"DateTime", `"2022-10-01T12:30:00Z"`, date},
+		{"ByteString", `"aGVsbG8="`, []byte("hello")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			typ, err := parseTypeID(tt.dataType)
+			require.NoError(t, err)
+			v, err := toVariant(json.RawMessage(tt.value), typ)
+			require.NoError(t, err)
+			assert.Equal(t, typ, v.Type())
+			assert.Equal(t, tt.expected, v.Value())
+		})
+	}
+
+	_, err := parseTypeID("Variant")
+	assert.Error(t, err)
+	_, err = toVariant(json.RawMessage(`256`), ua.TypeIDByte)
+	assert.Error(t, err)
+	_, err = toVariant(json.RawMessage(`-1`), ua.TypeIDUint32)
+	assert.Error(t, err)
+	_, err = toVariant(json.RawMessage(`1.5`), ua.TypeIDInt32)
+	assert.Error(t, err)
+	_, err = toVariant(json.RawMessage(`"true"`), ua.TypeIDBoolean)
+	assert.Error(t, err)
+}
+
+func TestInvoke(t *testing.T) {
+	ts := time.Date(2022, 10, 1, 12, 30, 0, 0, time.UTC)
+	client := &fakeClient{
+		values: map[string]*ua.DataValue{
+			"ns=2;s=Temperature": {
+				Value:           ua.MustVariant(21.5),
+				Status:          ua.StatusOK,
+				SourceTimestamp: ts,
+			},
+			"ns=2;s=Setpoint": {
+				Value:  ua.MustVariant(int32(20)),
+				Status: ua.StatusOK,
+			},
+		},
+		writeStatus: ua.StatusOK,
+	}
+	b := NewOPCUA(logger.NewLogger("test"))
+	b.client = client
+	b.timeout = time.Second
+
+	t.Run("read", func(t *testing.T) {
+		res, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: readOperation,
+			Data:      []byte(`{"nodeIds":["ns=2;s=Temperature","ns=2;s=Missing"]}`),
+		})
+		require.NoError(t, err)
+
+		var values []nodeValue
+		require.NoError(t, json.Unmarshal(res.Data, &values))
+		require.Len(t, values, 2)
+		assert.Equal(t, "ns=2;s=Temperature", values[0].NodeID)
+		assert.Equal(t, 21.5, values[0].Value)
+		assert.Equal(t, "Double", values[0].DataType)
+		assert.Equal(t, uint32(0), values[0].StatusCode)
+		require.NotNil(t, values[0].SourceTimestamp)
+		assert.True(t, ts.Equal(*values[0].SourceTimestamp))
+		assert.Equal(t, uint32(ua.StatusBadNodeIDUnknown), values[1].StatusCode)
+		assert.NotEmpty(t, values[1].Status)
+	})
+
+	t.Run("read with metadata", func(t *testing.T) {
+		res, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: readOperation,
+			Metadata:  map[string]string{nodeIDKey: "ns=2;s=Setpoint"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"nodeId":"ns=2;s=Setpoint","value":20,"dataType":"Int32","statusCode":0}]`, string(res.Data))
+	})
+
+	t.Run("read without nodes", func(t *testing.T) {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{Operation: readOperation})
+		assert.Error(t, err)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		client.writes = nil
+		res, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: writeOperation,
+			Data:      []byte(`[{"nodeId":"ns=2;s=Setpoint","value":22},{"nodeId":"ns=2;s=Label","value":"hot","dataType":"String"}]`),
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"nodeId":"ns=2;s=Setpoint","statusCode":0},{"nodeId":"ns=2;s=Label","statusCode":0}]`, string(res.Data))
+
+		require.Len(t, client.writes, 1)
+		nodes := client.writes[0].NodesToWrite
+		require.Len(t, nodes, 2)
+		// The type of the setpoint is the type of its current value
+		assert.Equal(t, int32(22), nodes[0].Value.Value.Value())
+		assert.Equal(t, "hot", nodes[1].Value.Value.Value())
+		assert.Equal(t, ua.AttributeIDValue, nodes[1].AttributeID)
+	})
+
+	t.Run("write single value", func(t *testing.T) {
+		client.writes = nil
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: writeOperation,
+			Data:      []byte(`{"nodeId":"ns=2;s=Temperature","value":23}`),
+		})
+		require.NoError(t, err)
+		require.Len(t, client.writes, 1)
+		assert.Equal(t, 23.0, client.writes[0].NodesToWrite[0].Value.Value.Value())
+	})
+
+	t.Run("write unknown node without dataType", func(t *testing.T) {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: writeOperation,
+			Data:      []byte(`{"nodeId":"ns=2;s=Missing","value":1}`),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("write rejected", func(t *testing.T) {
+		client.writeStatus = ua.StatusBadNotWritable
+		defer func() { client.writeStatus = ua.StatusOK }()
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: writeOperation,
+			Data:      []byte(`{"nodeId":"ns=2;s=Setpoint","value":1,"dataType":"Int32"}`),
+		})
+		assert.ErrorContains(t, err, "ns=2;s=Setpoint")
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{Operation: bindings.DeleteOperation})
+		assert.Error(t, err)
+	})
+}
+
+func TestHandleNotification(t *testing.T) {
+	b := NewOPCUA(logger.NewLogger("test"))
+	b.metadata = &opcuaMetadata{
+		nodeIDs: []*ua.NodeID{ua.NewStringNodeID(2, "Temperature"), ua.NewNumericNodeID(0, 2258)},
+	}
+
+	var received []*bindings.ReadResponse
+	handler := func(ctx context.Context, msg *bindings.ReadResponse) ([]byte, error) {
+		received = append(received, msg)
+		return nil, nil
+	}
+
+	b.handleNotification(context.Background(), &opcua.PublishNotificationData{
+		Value: &ua.DataChangeNotification{
+			MonitoredItems: []*ua.MonitoredItemNotification{
+				{ClientHandle: 1, Value: &ua.DataValue{Value: ua.MustVariant(uint32(7)), Status: ua.StatusOK}},
+				{ClientHandle: 5, Value: &ua.DataValue{Value: ua.MustVariant(true), Status: ua.StatusOK}},
+				{ClientHandle: 0, Value: &ua.DataValue{Value: ua.MustVariant(21.5), Status: ua.StatusOK}},
+			},
+		},
+	}, handler)
+	b.handleNotification(context.Background(), &opcua.PublishNotificationData{Error: ua.StatusBadTimeout}, handler)
+	b.handleNotification(context.Background(), &opcua.PublishNotificationData{Value: &ua.EventNotificationList{}}, handler)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "i=2258", received[0].Metadata[nodeIDKey])
+	assert.JSONEq(t, `{"nodeId":"i=2258","value":7,"dataType":"UInt32","statusCode":0}`, string(received[0].Data))
+	assert.Equal(t, "ns=2;s=Temperature", received[1].Metadata[nodeIDKey])
+	assert.JSONEq(t, `{"nodeId":"ns=2;s=Temperature","value":21.5,"dataType":"Double","statusCode":0}`, string(received[1].Data))
+}
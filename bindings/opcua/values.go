@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opcua
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// Types of the values that can be written.
+var writableTypes = []ua.TypeID{
+	ua.TypeIDBoolean,
+	ua.TypeIDSByte,
+	ua.TypeIDByte,
+	ua.TypeIDInt16,
+	ua.TypeIDUint16,
+	ua.TypeIDInt32,
+	ua.TypeIDUint32,
+	ua.TypeIDInt64,
+	ua.TypeIDUint64,
+	ua.TypeIDFloat,
+	ua.TypeIDDouble,
+	ua.TypeIDString,
+	ua.TypeIDDateTime,
+	ua.TypeIDByteString,
+}
+
+// typeName returns the name of a type as spelled by the OPC UA specification, such as Double or UInt32.
+func typeName(t ua.TypeID) string {
+	name := strings.TrimPrefix(t.String(), "TypeID")
+	if strings.HasPrefix(name, "Uint") {
+		name = "UInt" + name[len("Uint"):]
+	}
+	return name
+}
+
+// parseTypeID parses the name of a writable type, case-insensitively so both UInt16 and Uint16 are accepted.
+func parseTypeID(name string) (ua.TypeID, error) {
+	for _, t := range writableTypes {
+		if strings.EqualFold(typeName(t), name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported dataType: %s", name)
+}
+
+// toVariant converts a JSON value to a variant of the type.
+// DateTime values are RFC 3339 strings, and ByteString values are base64 strings.
+func toVariant(raw json.RawMessage, t ua.TypeID) (*ua.Variant, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	var val any
+	switch t { //nolint:exhaustive
+	case ua.TypeIDBoolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean")
+		}
+		val = b
+	case ua.TypeIDSByte:
+		var i int64
+		i, err = toInt(v, math.MinInt8, math.MaxInt8)
+		val = int8(i)
+	case ua.TypeIDByte:
+		var i uint64
+		i, err = toUint(v, math.MaxUint8)
+		val = uint8(i)
+	case ua.TypeIDInt16:
+		var i int64
+		i, err = toInt(v, math.MinInt16, math.MaxInt16)
+		val = int16(i)
+	case ua.TypeIDUint16:
+		var i uint64
+		i, err = toUint(v, math.MaxUint16)
+		val = uint16(i)
+	case ua.TypeIDInt32:
+		var i int64
+		i, err = toInt(v, math.MinInt32, math.MaxInt32)
+		val = int32(i)
+	case ua.TypeIDUint32:
+		var i uint64
+		i, err = toUint(v, math.MaxUint32)
+		val = uint32(i)
+	case ua.TypeIDInt64:
+		val, err = toInt(v, math.MinInt64, math.MaxInt64)
+	case ua.TypeIDUint64:
+		val, err = toUint(v, math.MaxUint64)
+	case ua.TypeIDFloat:
+		var f float64
+		f, err = toFloat(v)
+		val = float32(f)
+	case ua.TypeIDDouble:
+		val, err = toFloat(v)
+	case ua.TypeIDString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		val = s
+	case ua.TypeIDDateTime:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an RFC 3339 date")
+		}
+		val, err = time.Parse(time.RFC3339Nano, s)
+	case ua.TypeIDByteString:
+		var b []byte
+		err = json.Unmarshal(raw, &b)
+		val = b
+	default:
+		return nil, fmt.Errorf("unsupported dataType: %s", typeName(t))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ua.NewVariant(val)
+}
+
+func toInt(v any, min, max int64) (int64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected an integer")
+	}
+	i, err := strconv.ParseInt(n.String(), 10, 64)
+	if err != nil || i < min || i > max {
+		return 0, fmt.Errorf("expected an integer between %d and %d", min, max)
+	}
+	return i, nil
+}
+
+func toUint(v any, max uint64) (uint64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected an integer")
+	}
+	i, err := strconv.ParseUint(n.String(), 10, 64)
+	if err != nil || i > max {
+		return 0, fmt.Errorf("expected an integer between 0 and %d", max)
+	}
+	return i, nil
+}
+
+func toFloat(v any) (float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected a number")
+	}
+	return n.Float64()
+}
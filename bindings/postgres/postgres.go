@@ -23,6 +23,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/redact"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -48,7 +50,14 @@ func NewPostgres(logger logger.Logger) bindings.OutputBinding {
 }
 
 // Init initializes the PostgreSql binding.
-func (p *Postgres) Init(metadata bindings.Metadata) error {
+func (p *Postgres) Init(metadata bindings.Metadata) (err error) {
+	// The errors of the URL parser quote the connection URL with its password
+	redactor := redact.New(metadata.Properties, nil)
+	p.logger = redactor.Logger(p.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	url, ok := metadata.Properties[connectionURLKey]
 	if !ok || url == "" {
 		return errors.Errorf("required metadata not set: %s", connectionURLKey)
@@ -67,6 +76,12 @@ func (p *Postgres) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (p *Postgres) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString},
+	}
+}
+
 // Operations returns list of operations supported by PostgreSql binding.
 func (p *Postgres) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
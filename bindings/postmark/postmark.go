@@ -22,6 +22,7 @@ import (
 	"github.com/mrz1836/postmark"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -87,6 +88,12 @@ func (p *Postmark) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (p *Postmark) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
+
 // Operations returns list of operations supported by Postmark binding.
 func (p *Postmark) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
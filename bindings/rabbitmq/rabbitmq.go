@@ -24,6 +24,7 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/component/rabbitmq"
 	"github.com/dapr/components-contrib/internal/utils"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
@@ -37,19 +38,23 @@ const (
 	deleteWhenUnused           = "deleteWhenUnused"
 	prefetchCount              = "prefetchCount"
 	maxPriority                = "maxPriority"
+	publisherConfirm           = "publisherConfirm"
+	publisherConfirmWindow     = "publisherConfirmWindow"
+	reconnectWaitSeconds       = "reconnectWaitSeconds"
 	rabbitMQQueueMessageTTLKey = "x-message-ttl"
 	rabbitMQMaxPriorityKey     = "x-max-priority"
 	defaultBase                = 10
 	defaultBitSize             = 0
+	defaultReconnectWait       = 3 * time.Second
 )
 
 // RabbitMQ allows sending/receiving data to/from RabbitMQ.
 type RabbitMQ struct {
-	connection *amqp.Connection
-	channel    *amqp.Channel
+	connection *rabbitmq.Connection
 	metadata   rabbitMQMetadata
 	logger     logger.Logger
-	queue      amqp.Queue
+
+	confirmWindow *rabbitmq.ConfirmWindow
 }
 
 // Metadata is the rabbitmq config.
@@ -61,6 +66,10 @@ type rabbitMQMetadata struct {
 	DeleteWhenUnused bool   `json:"deleteWhenUnused,string"`
 	PrefetchCount    int    `json:"prefetchCount"`
 	MaxPriority      *uint8 `json:"maxPriority"` // Priority Queue deactivated if nil
+	PublisherConfirm bool   `json:"publisherConfirm,string"`
+	ConfirmWindow    int    `json:"publisherConfirmWindow"`
+	ReconnectWait    time.Duration
+	Connection       rabbitmq.ConnectionConfig
 	defaultQueueTTL  *time.Duration
 }
 
@@ -76,27 +85,36 @@ func (r *RabbitMQ) Init(metadata bindings.Metadata) error {
 		return err
 	}
 
-	conn, err := amqp.Dial(r.metadata.Host)
-	if err != nil {
-		return err
+	if r.metadata.PublisherConfirm {
+		r.confirmWindow = rabbitmq.NewConfirmWindow(r.metadata.ConfirmWindow)
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		return err
+	// The queue is declared again, and the consumers restarted, when the connection is recovered
+	r.connection = rabbitmq.NewConnection(r.metadata.Host, r.metadata.Connection, r.setupChannel, r.metadata.ReconnectWait, r.logger)
+
+	return r.connection.Connect()
+}
+
+func (r *RabbitMQ) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		Capabilities: []contribMetadata.Capability{contribMetadata.CapabilityTTL},
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfilePassword, contribMetadata.AuthProfileMTLS},
 	}
-	ch.Qos(r.metadata.PrefetchCount, 0, true)
-	r.connection = conn
-	r.channel = ch
+}
 
-	q, err := r.declareQueue()
-	if err != nil {
-		return err
+// setupChannel sets up a new channel and declares the queue on it.
+func (r *RabbitMQ) setupChannel(ch *amqp.Channel) error {
+	ch.Qos(r.metadata.PrefetchCount, 0, true)
+	if r.metadata.PublisherConfirm {
+		err := ch.Confirm(false)
+		if err != nil {
+			return err
+		}
 	}
 
-	r.queue = q
+	_, err := r.declareQueue(ch)
 
-	return nil
+	return err
 }
 
 func (r *RabbitMQ) Operations() []bindings.OperationKind {
@@ -137,17 +155,35 @@ func (r *RabbitMQ) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bi
 		pub.Priority = priority
 	}
 
-	err = r.channel.PublishWithContext(ctx, "", r.metadata.QueueName, false, false, pub)
+	if r.confirmWindow == nil {
+		err = r.connection.Channel().PublishWithContext(ctx, "", r.metadata.QueueName, false, false, pub)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	err = r.confirmWindow.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.confirmWindow.Release()
 
+	confirm, err := r.connection.Channel().PublishWithDeferredConfirmWithContext(ctx, "", r.metadata.QueueName, false, false, pub)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	// Invocations are confirmed independently, so concurrent invocations don't wait for each other
+	return nil, rabbitmq.WaitConfirm(confirm)
 }
 
 func (r *RabbitMQ) parseMetadata(metadata bindings.Metadata) error {
-	m := rabbitMQMetadata{}
+	m := rabbitMQMetadata{
+		ConfirmWindow: rabbitmq.DefaultConfirmWindow,
+		ReconnectWait: defaultReconnectWait,
+	}
 
 	if val, ok := metadata.Properties[host]; ok && val != "" {
 		m.Host = val
@@ -196,6 +232,32 @@ func (r *RabbitMQ) parseMetadata(metadata bindings.Metadata) error {
 		m.MaxPriority = &maxPriority
 	}
 
+	if val, ok := metadata.Properties[publisherConfirm]; ok && val != "" {
+		m.PublisherConfirm = utils.IsTruthy(val)
+	}
+
+	if val, ok := metadata.Properties[publisherConfirmWindow]; ok && val != "" {
+		parsedVal, err := strconv.Atoi(val)
+		if err != nil || parsedVal <= 0 {
+			return fmt.Errorf("rabbitMQ binding error: invalid publisherConfirmWindow field: %s", val)
+		}
+		m.ConfirmWindow = parsedVal
+	}
+
+	if val, ok := metadata.Properties[reconnectWaitSeconds]; ok && val != "" {
+		parsedVal, err := strconv.Atoi(val)
+		if err != nil || parsedVal <= 0 {
+			return fmt.Errorf("rabbitMQ binding error: invalid reconnectWaitSeconds field: %s", val)
+		}
+		m.ReconnectWait = time.Duration(parsedVal) * time.Second
+	}
+
+	var err error
+	m.Connection, err = rabbitmq.ParseConnectionConfig(metadata.Properties)
+	if err != nil {
+		return fmt.Errorf("rabbitMQ binding error: %w", err)
+	}
+
 	ttl, ok, err := contribMetadata.TryGetTTL(metadata.Properties)
 	if err != nil {
 		return err
@@ -210,7 +272,7 @@ func (r *RabbitMQ) parseMetadata(metadata bindings.Metadata) error {
 	return nil
 }
 
-func (r *RabbitMQ) declareQueue() (amqp.Queue, error) {
+func (r *RabbitMQ) declareQueue(ch *amqp.Channel) (amqp.Queue, error) {
 	args := amqp.Table{}
 	if r.metadata.defaultQueueTTL != nil {
 		// Value in ms
@@ -222,41 +284,76 @@ func (r *RabbitMQ) declareQueue() (amqp.Queue, error) {
 		args[rabbitMQMaxPriorityKey] = *r.metadata.MaxPriority
 	}
 
-	return r.channel.QueueDeclare(r.metadata.QueueName, r.metadata.Durable, r.metadata.DeleteWhenUnused, r.metadata.Exclusive, false, args)
+	return ch.QueueDeclare(r.metadata.QueueName, r.metadata.Durable, r.metadata.DeleteWhenUnused, r.metadata.Exclusive, false, args)
 }
 
 func (r *RabbitMQ) Read(ctx context.Context, handler bindings.Handler) error {
-	msgs, err := r.channel.Consume(
-		r.queue.Name,
-		"",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
+	ch := r.connection.Channel()
+	msgs, err := r.consume(ch)
 	if err != nil {
 		return err
 	}
 
 	go func() {
-		var err error
 		for {
-			select {
-			case <-ctx.Done():
-				return
-			case d := <-msgs:
-				_, err = handler(ctx, &bindings.ReadResponse{
-					Data: d.Body,
-				})
+			// Consume again on the new channel when the connection is recovered
+			r.handleMessages(ctx, ch, msgs, handler)
+			for {
+				ch, err = r.connection.NextChannel(ctx, ch)
 				if err != nil {
-					r.channel.Nack(d.DeliveryTag, false, true)
-				} else {
-					r.channel.Ack(d.DeliveryTag, false)
+					return
+				}
+				msgs, err = r.consume(ch)
+				if err == nil {
+					break
 				}
+				r.logger.Errorf("rabbitMQ binding error: failed to consume from queue %s: %v", r.metadata.QueueName, err)
 			}
 		}
 	}()
 
 	return nil
 }
+
+func (r *RabbitMQ) consume(ch *amqp.Channel) (<-chan amqp.Delivery, error) {
+	return ch.Consume(
+		r.metadata.QueueName,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+}
+
+// handleMessages handles the messages until the context is canceled or the channel is closed.
+func (r *RabbitMQ) handleMessages(ctx context.Context, ch *amqp.Channel, msgs <-chan amqp.Delivery, handler bindings.Handler) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d, ok := <-msgs:
+			if !ok {
+				return
+			}
+			_, err := handler(ctx, &bindings.ReadResponse{
+				Data: d.Body,
+			})
+			if err != nil {
+				ch.Nack(d.DeliveryTag, false, true)
+			} else {
+				ch.Ack(d.DeliveryTag, false)
+			}
+		}
+	}
+}
+
+// Close stops recovering the connection, and closes it.
+func (r *RabbitMQ) Close() error {
+	if r.connection == nil {
+		return nil
+	}
+
+	return r.connection.Close()
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/component/rabbitmq"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
@@ -38,6 +39,8 @@ func TestParseMetadata(t *testing.T) {
 		expectedTTL              *time.Duration
 		expectedPrefetchCount    int
 		expectedMaxPriority      *uint8
+		expectedPublisherConfirm bool
+		expectedConfirmWindow    int
 	}{
 		{
 			name:                     "Delete / Durable",
@@ -100,6 +103,14 @@ func TestParseMetadata(t *testing.T) {
 				return &v
 			}(),
 		},
+		{
+			name:                     "With publisherConfirm",
+			properties:               map[string]string{"queueName": queueName, "host": host, "deleteWhenUnused": "false", "durable": "false", "publisherConfirm": "true", "publisherConfirmWindow": "16"},
+			expectedDeleteWhenUnused: false,
+			expectedDurable:          false,
+			expectedPublisherConfirm: true,
+			expectedConfirmWindow:    16,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -117,6 +128,12 @@ func TestParseMetadata(t *testing.T) {
 			assert.Equal(t, tt.expectedPrefetchCount, r.metadata.PrefetchCount)
 			assert.Equal(t, tt.expectedExclusive, r.metadata.Exclusive)
 			assert.Equal(t, tt.expectedMaxPriority, r.metadata.MaxPriority)
+			assert.Equal(t, tt.expectedPublisherConfirm, r.metadata.PublisherConfirm)
+			if tt.expectedConfirmWindow != 0 {
+				assert.Equal(t, tt.expectedConfirmWindow, r.metadata.ConfirmWindow)
+			} else {
+				assert.Equal(t, rabbitmq.DefaultConfirmWindow, r.metadata.ConfirmWindow)
+			}
 		})
 	}
 }
@@ -190,3 +207,55 @@ func TestParseMetadataWithInvalidMaxPriority(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMetadataWithInvalidConfirmWindow(t *testing.T) {
+	const queueName = "test-queue"
+	const host = "test-host"
+
+	for _, val := range []string{"0", "-1", "abc"} {
+		t.Run(val, func(t *testing.T) {
+			m := bindings.Metadata{}
+			m.Properties = map[string]string{"queueName": queueName, "host": host, "publisherConfirmWindow": val}
+			r := RabbitMQ{logger: logger.NewLogger("test")}
+			err := r.parseMetadata(m)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestParseMetadataWithConnectionRecovery(t *testing.T) {
+	const queueName = "test-queue"
+	const host = "test-host"
+
+	t.Run("default reconnect wait", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"queueName": queueName, "host": host}
+		r := RabbitMQ{logger: logger.NewLogger("test")}
+		err := r.parseMetadata(m)
+		assert.NoError(t, err)
+		assert.Equal(t, defaultReconnectWait, r.metadata.ReconnectWait)
+	})
+
+	t.Run("reconnect wait", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"queueName": queueName, "host": host, "reconnectWaitSeconds": "10"}
+		r := RabbitMQ{logger: logger.NewLogger("test")}
+		err := r.parseMetadata(m)
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, r.metadata.ReconnectWait)
+	})
+
+	for _, props := range []map[string]string{
+		{"reconnectWaitSeconds": "0"},
+		{"saslExternal": "true"},
+	} {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{"queueName": queueName, "host": host}
+		for k, v := range props {
+			m.Properties[k] = v
+		}
+		r := RabbitMQ{logger: logger.NewLogger("test")}
+		err := r.parseMetadata(m)
+		assert.Error(t, err)
+	}
+}
@@ -22,6 +22,8 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/internal/redact"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -42,6 +44,13 @@ func NewRedis(logger logger.Logger) bindings.OutputBinding {
 
 // Init performs metadata parsing and connection creation.
 func (r *Redis) Init(meta bindings.Metadata) (err error) {
+	// Connection errors may quote the Redis password
+	redactor := redact.New(meta.Properties, nil)
+	r.logger = redactor.Logger(r.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	r.client, r.clientSettings, err = rediscomponent.ParseClientFromProperties(meta.Properties, nil)
 	if err != nil {
 		return err
@@ -57,6 +66,12 @@ func (r *Redis) Init(meta bindings.Metadata) (err error) {
 	return err
 }
 
+func (r *Redis) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword, metadata.AuthProfileSPIFFE},
+	}
+}
+
 func (r *Redis) Ping() error {
 	if _, err := r.client.Ping(r.ctx).Result(); err != nil {
 		return fmt.Errorf("redis binding: error connecting to redis at %s: %s", r.clientSettings.Host, err)
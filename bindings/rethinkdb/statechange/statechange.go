@@ -25,6 +25,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -66,6 +67,12 @@ func (b *Binding) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (b *Binding) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
+
 // Read triggers the RethinkDB scheduler.
 func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 	b.logger.Infof("subscribing to state changes in %s.%s...", b.config.Database, b.config.Table)
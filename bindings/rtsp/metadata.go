@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rtsp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	defaultFrameRate         = 1.0
+	defaultJPEGQuality       = 85
+	defaultTransport         = "tcp"
+	defaultReconnectInterval = 5 * time.Second
+)
+
+type rtspMetadata struct {
+	// URL of the stream, such as rtsp://camera.local:554/stream1.
+	URL string `mapstructure:"url"`
+	// Number of frames captured per second; fractions like 0.2 capture a frame every 5 seconds.
+	FrameRate float64 `mapstructure:"frameRate"`
+	// Quality of the JPEG frames, from 1 (lowest) to 100 (highest).
+	JPEGQuality int `mapstructure:"jpegQuality"`
+	// Width the frames are scaled to, keeping the aspect ratio; 0 keeps the size of the stream.
+	Width int `mapstructure:"width"`
+	// Transport of the RTP packets: tcp or udp.
+	Transport string `mapstructure:"transport"`
+	// Time to wait before reconnecting when the stream ends or fails.
+	ReconnectInterval time.Duration `mapstructure:"reconnectInterval"`
+}
+
+func parseMetadata(meta bindings.Metadata) (rtspMetadata, error) {
+	m := rtspMetadata{
+		FrameRate:         defaultFrameRate,
+		JPEGQuality:       defaultJPEGQuality,
+		Transport:         defaultTransport,
+		ReconnectInterval: defaultReconnectInterval,
+	}
+	err := contribMetadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.URL == "" {
+		return m, errors.New("missing url")
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil {
+		return m, errors.New("invalid url")
+	}
+	if u.Scheme != "rtsp" && u.Scheme != "rtsps" {
+		return m, fmt.Errorf("unsupported url scheme '%s': expected rtsp or rtsps", u.Scheme)
+	}
+	if m.FrameRate <= 0 {
+		return m, fmt.Errorf("invalid frameRate: %v", m.FrameRate)
+	}
+	if m.JPEGQuality < 1 || m.JPEGQuality > 100 {
+		return m, fmt.Errorf("invalid jpegQuality: %d", m.JPEGQuality)
+	}
+	if m.Width < 0 {
+		return m, fmt.Errorf("invalid width: %d", m.Width)
+	}
+	m.Transport = strings.ToLower(m.Transport)
+	if m.Transport != "tcp" && m.Transport != "udp" {
+		return m, fmt.Errorf("invalid transport: %s", m.Transport)
+	}
+	if m.ReconnectInterval <= 0 {
+		return m, fmt.Errorf("invalid reconnectInterval: %v", m.ReconnectInterval)
+	}
+
+	return m, nil
+}
+
+// ffmpegArgs returns the arguments of ffmpeg to decode the stream and write the frames as JPEG images to stdout.
+func (m rtspMetadata) ffmpegArgs() []string {
+	filter := "fps=" + strconv.FormatFloat(m.FrameRate, 'f', -1, 64)
+	if m.Width > 0 {
+		// -2 keeps the aspect ratio with an even height, which the encoder requires
+		filter += ",scale=" + strconv.Itoa(m.Width) + ":-2"
+	}
+
+	return []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-rtsp_transport", m.Transport,
+		"-i", m.URL,
+		"-an",
+		"-vf", filter,
+		"-f", "image2pipe",
+		"-vcodec", "mjpeg",
+		"-q:v", strconv.Itoa(m.qscale()),
+		"pipe:1",
+	}
+}
+
+// qscale maps the JPEG quality to the ffmpeg quality scale, from 31 (lowest) to 2 (highest).
+func (m rtspMetadata) qscale() int {
+	return 31 - (m.JPEGQuality-1)*29/99
+}
+
+// redactedURL returns the URL of the stream without the password, for logs.
+func (m rtspMetadata) redactedURL() string {
+	u, err := url.Parse(m.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
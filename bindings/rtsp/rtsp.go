@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rtsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// keys of the metadata of the frames.
+	timestampKey   = "timestamp"
+	frameNumberKey = "frameNumber"
+	contentTypeKey = "contentType"
+	droppedKey     = "droppedFrames"
+
+	jpegContentType = "image/jpeg"
+
+	// Maximum size of a frame, to bound the memory used if the stream isn't made of JPEG images.
+	maxFrameSize = 32 << 20
+
+	// Name of the ffmpeg executable, which is looked up in the PATH.
+	// It isn't configurable, so component metadata can't run arbitrary executables.
+	ffmpegCommand = "ffmpeg"
+)
+
+// RTSP is an input binding that captures frames of an RTSP stream and delivers them as JPEG images.
+// The stream is decoded by ffmpeg, which must be installed and in the PATH.
+// When the app is slower than the frame rate, only the latest frame is kept, so the app always receives recent frames.
+type RTSP struct {
+	metadata rtspMetadata
+
+	// Starts the decoder and returns its output, for tests.
+	startStream func(ctx context.Context) (io.ReadCloser, func() error, error)
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger logger.Logger
+}
+
+// frame is a JPEG image captured from the stream.
+type frame struct {
+	data      []byte
+	timestamp time.Time
+	number    uint64
+	dropped   uint64
+}
+
+// NewRTSP returns a new RTSP input binding.
+func NewRTSP(logger logger.Logger) bindings.InputBinding {
+	r := &RTSP{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+	r.startStream = r.startFFmpeg
+
+	return r
+}
+
+// Init parses the metadata and checks that ffmpeg is available.
+func (r *RTSP) Init(metadata bindings.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	r.metadata = m
+
+	_, err = exec.LookPath(ffmpegCommand)
+	if err != nil {
+		return fmt.Errorf("ffmpeg is required to decode the stream: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RTSP) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
+
+// Read starts capturing frames, until ctx is done or the binding is closed.
+func (r *RTSP) Read(ctx context.Context, handler bindings.Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-r.closeCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	frames := make(chan frame, 1)
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		defer close(frames)
+		r.capture(ctx, frames)
+	}()
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		for f := range frames {
+			_, err := handler(ctx, &bindings.ReadResponse{
+				Data: f.data,
+				Metadata: map[string]string{
+					timestampKey:   f.timestamp.UTC().Format(time.RFC3339Nano),
+					frameNumberKey: strconv.FormatUint(f.number, 10),
+					contentTypeKey: jpegContentType,
+					droppedKey:     strconv.FormatUint(f.dropped, 10),
+				},
+			})
+			if err != nil {
+				r.logger.Errorf("Error handling frame %d: %v", f.number, err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// capture reads the frames of the stream, reconnecting when the stream ends, until ctx is done.
+func (r *RTSP) capture(ctx context.Context, frames chan frame) {
+	var number, dropped uint64
+	for {
+		err := r.readStream(ctx, func(data []byte) {
+			number++
+			f := frame{data: data, timestamp: time.Now(), number: number}
+			// Replace the pending frame if the app hasn't received it yet
+			select {
+			case <-frames:
+				dropped++
+			default:
+			}
+			f.dropped = dropped
+			frames <- f
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.logger.Errorf("Error reading stream %s: %v", r.metadata.redactedURL(), err)
+		} else {
+			r.logger.Warnf("Stream %s ended", r.metadata.redactedURL())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.metadata.ReconnectInterval):
+			r.logger.Infof("Reconnecting to stream %s", r.metadata.redactedURL())
+		}
+	}
+}
+
+// readStream runs the decoder and invokes fn for each frame, until the stream ends.
+func (r *RTSP) readStream(ctx context.Context, fn func([]byte)) error {
+	out, wait, err := r.startStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	readErr := readFrames(out, fn)
+	out.Close()
+	waitErr := wait()
+	if readErr != nil {
+		return readErr
+	}
+
+	return waitErr
+}
+
+func (r *RTSP) startFFmpeg(ctx context.Context) (io.ReadCloser, func() error, error) {
+	var stderr bytes.Buffer
+	// The arguments are built from the validated metadata, and the URL has an rtsp or rtsps scheme
+	cmd := exec.CommandContext(ctx, ffmpegCommand, r.metadata.ffmpegArgs()...) //nolint:gosec
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.logger.Debugf("Connecting to stream %s", r.metadata.redactedURL())
+	err = cmd.Start()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	wait := func() error {
+		err := cmd.Wait()
+		if err != nil && stderr.Len() > 0 {
+			return fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return err
+	}
+
+	return out, wait, nil
+}
+
+// readFrames splits a stream of concatenated JPEG images, invoking fn for each image.
+// Images start with the SOI marker (FF D8) and end with the EOI marker (FF D9); within the entropy-coded data of an
+// image, FF bytes are always followed by 00 or a restart marker, so EOI can't appear before the end of the image.
+func readFrames(r io.Reader, fn func([]byte)) error {
+	br := bufio.NewReaderSize(r, 64<<10)
+	var (
+		buf     []byte
+		inFrame bool
+	)
+	for {
+		// Markers start with FF, so only the bytes following FF need to be checked
+		chunk, err := br.ReadSlice(0xFF)
+		if inFrame {
+			buf = append(buf, chunk...)
+			if len(buf) > maxFrameSize {
+				return fmt.Errorf("frame larger than %d bytes", maxFrameSize)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		next, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		switch {
+		case !inFrame && next[0] == 0xD8:
+			// Start of image; the bytes before it are skipped
+			inFrame = true
+			buf = []byte{0xFF}
+		case inFrame && next[0] == 0xD9:
+			// End of image
+			_, _ = br.ReadByte()
+			fn(append(buf, 0xD9))
+			buf = nil
+			inFrame = false
+		}
+	}
+}
+
+// Close stops capturing frames.
+func (r *RTSP) Close() error {
+	select {
+	case <-r.closeCh:
+	default:
+		close(r.closeCh)
+	}
+	r.wg.Wait()
+
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"gopkg.in/gomail.v2"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -72,6 +73,12 @@ func (s *Mailer) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (s *Mailer) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
+
 // Operations returns the allowed binding operations.
 func (s *Mailer) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
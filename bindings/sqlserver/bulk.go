@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/utils"
+)
+
+const (
+	// keys from the metadata of bulk insert requests.
+	bulkTableKey             = "table"
+	bulkColumnsKey           = "columns"
+	bulkCheckConstraintsKey  = "checkConstraints"
+	bulkFireTriggersKey      = "fireTriggers"
+	bulkKeepNullsKey         = "keepNulls"
+	bulkTablockKey           = "tablock"
+	bulkRowsPerBatchKey      = "rowsPerBatch"
+	bulkKilobytesPerBatchKey = "kilobytesPerBatch"
+)
+
+// bulkColumn maps a field of the JSON rows to a column of the table.
+type bulkColumn struct {
+	field string
+	name  string
+}
+
+// bulkInsertRequest is a parsed bulk insert request.
+type bulkInsertRequest struct {
+	table   string
+	columns []bulkColumn
+	options mssql.BulkOptions
+	rows    [][]interface{}
+}
+
+func (b *bulkInsertRequest) columnNames() []string {
+	names := make([]string, len(b.columns))
+	for i, c := range b.columns {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// parseBulkInsertRequest parses the rows of a bulk insert request.
+// The data is a JSON array of rows, which are either objects or arrays of values.
+// The columns metadata is a comma-separated list of columns, each optionally mapped from a field of the object rows with
+// field:column. Object rows without the columns metadata are inserted in the columns named after their fields, and array
+// rows are inserted in the columns in order.
+func parseBulkInsertRequest(req *bindings.InvokeRequest) (*bulkInsertRequest, error) {
+	b := &bulkInsertRequest{
+		table:   req.Metadata[bulkTableKey],
+		columns: parseBulkColumns(req.Metadata[bulkColumnsKey]),
+	}
+	if b.table == "" {
+		return nil, fmt.Errorf("required metadata not set: %s", bulkTableKey)
+	}
+
+	err := b.parseOptions(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are decoded as json.Number so integers don't lose precision
+	dec := json.NewDecoder(bytes.NewReader(req.Data))
+	dec.UseNumber()
+	var rows []interface{}
+	err = dec.Decode(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("bulk insert data must be a JSON array of rows: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("bulk insert requires at least one row")
+	}
+
+	switch rows[0].(type) {
+	case map[string]interface{}:
+		err = b.parseObjectRows(rows)
+	case []interface{}:
+		err = b.parseArrayRows(rows)
+	default:
+		err = errors.New("bulk insert rows must be JSON objects or arrays")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func parseBulkColumns(val string) []bulkColumn {
+	var columns []bulkColumn
+	for _, c := range strings.Split(val, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		field, name, found := strings.Cut(c, ":")
+		if !found {
+			name = field
+		}
+		columns = append(columns, bulkColumn{
+			field: strings.TrimSpace(field),
+			name:  strings.TrimSpace(name),
+		})
+	}
+
+	return columns
+}
+
+func (b *bulkInsertRequest) parseOptions(md map[string]string) error {
+	b.options.CheckConstraints = utils.IsTruthy(md[bulkCheckConstraintsKey])
+	b.options.FireTriggers = utils.IsTruthy(md[bulkFireTriggersKey])
+	b.options.KeepNulls = utils.IsTruthy(md[bulkKeepNullsKey])
+	b.options.Tablock = utils.IsTruthy(md[bulkTablockKey])
+
+	var err error
+	if v := md[bulkRowsPerBatchKey]; v != "" {
+		b.options.RowsPerBatch, err = strconv.Atoi(v)
+		if err != nil || b.options.RowsPerBatch < 0 {
+			return fmt.Errorf("invalid %s: %s", bulkRowsPerBatchKey, v)
+		}
+	}
+	if v := md[bulkKilobytesPerBatchKey]; v != "" {
+		b.options.KilobytesPerBatch, err = strconv.Atoi(v)
+		if err != nil || b.options.KilobytesPerBatch < 0 {
+			return fmt.Errorf("invalid %s: %s", bulkKilobytesPerBatchKey, v)
+		}
+	}
+
+	return nil
+}
+
+func (b *bulkInsertRequest) parseObjectRows(rows []interface{}) error {
+	objects := make([]map[string]interface{}, len(rows))
+	for i, r := range rows {
+		o, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("bulk insert row %d is not a JSON object", i)
+		}
+		objects[i] = o
+	}
+
+	if len(b.columns) == 0 {
+		// Without a mapping, the columns are the fields of all rows
+		fields := map[string]struct{}{}
+		for _, o := range objects {
+			for f := range o {
+				fields[f] = struct{}{}
+			}
+		}
+		for f := range fields {
+			b.columns = append(b.columns, bulkColumn{field: f, name: f})
+		}
+		sort.Slice(b.columns, func(i, j int) bool {
+			return b.columns[i].name < b.columns[j].name
+		})
+	}
+
+	b.rows = make([][]interface{}, len(objects))
+	for i, o := range objects {
+		row := make([]interface{}, len(b.columns))
+		for j, c := range b.columns {
+			v, err := bulkValue(o[c.field])
+			if err != nil {
+				return fmt.Errorf("bulk insert row %d: invalid value of %s: %w", i, c.field, err)
+			}
+			row[j] = v
+		}
+		b.rows[i] = row
+	}
+
+	return nil
+}
+
+func (b *bulkInsertRequest) parseArrayRows(rows []interface{}) error {
+	if len(b.columns) == 0 {
+		return fmt.Errorf("required metadata not set for rows of values: %s", bulkColumnsKey)
+	}
+
+	b.rows = make([][]interface{}, len(rows))
+	for i, r := range rows {
+		values, ok := r.([]interface{})
+		if !ok {
+			return fmt.Errorf("bulk insert row %d is not a JSON array", i)
+		}
+		if len(values) != len(b.columns) {
+			return fmt.Errorf("bulk insert row %d has %d values for %d columns", i, len(values), len(b.columns))
+		}
+		row := make([]interface{}, len(values))
+		for j, v := range values {
+			var err error
+			row[j], err = bulkValue(v)
+			if err != nil {
+				return fmt.Errorf("bulk insert row %d: invalid value of %s: %w", i, b.columns[j].name, err)
+			}
+		}
+		b.rows[i] = row
+	}
+
+	return nil
+}
+
+// bulkValue converts a JSON value to a value of a column.
+// Nested objects and arrays are inserted as JSON strings.
+func bulkValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+		return val.Float64()
+	case map[string]interface{}, []interface{}:
+		j, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		return string(j), nil
+	default:
+		return val, nil
+	}
+}
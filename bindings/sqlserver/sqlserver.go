@@ -0,0 +1,359 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// list of operations.
+	execOperation       bindings.OperationKind = "exec"
+	queryOperation      bindings.OperationKind = "query"
+	closeOperation      bindings.OperationKind = "close"
+	bulkInsertOperation bindings.OperationKind = "bulkInsert"
+
+	// configurations to connect to SQL Server.
+	connectionStringKey = "connectionString"
+
+	// other general settings for DB connections.
+	maxIdleConnsKey    = "maxIdleConns"
+	maxOpenConnsKey    = "maxOpenConns"
+	connMaxLifetimeKey = "connMaxLifetime"
+	connMaxIdleTimeKey = "connMaxIdleTime"
+
+	// keys from request's metadata.
+	commandSQLKey = "sql"
+
+	// keys from response's metadata.
+	respOpKey           = "operation"
+	respSQLKey          = "sql"
+	respStartTimeKey    = "start-time"
+	respRowsAffectedKey = "rows-affected"
+	respEndTimeKey      = "end-time"
+	respDurationKey     = "duration"
+)
+
+// SQLServer represents SQL Server output bindings.
+type SQLServer struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+// NewSQLServer returns a new SQL Server output binding.
+func NewSQLServer(logger logger.Logger) bindings.OutputBinding {
+	return &SQLServer{logger: logger}
+}
+
+// Init initializes the SQL Server binding.
+func (s *SQLServer) Init(metadata bindings.Metadata) error {
+	s.logger.Debug("Initializing SQL Server binding")
+
+	p := metadata.Properties
+	connectionString, ok := p[connectionStringKey]
+	if !ok || connectionString == "" {
+		return fmt.Errorf("missing SQL Server connection string")
+	}
+
+	db, err := sql.Open("sqlserver", connectionString)
+	if err != nil {
+		return fmt.Errorf("error opening DB connection: %w", err)
+	}
+
+	err = propertyToInt(p, maxIdleConnsKey, db.SetMaxIdleConns)
+	if err != nil {
+		return err
+	}
+
+	err = propertyToInt(p, maxOpenConnsKey, db.SetMaxOpenConns)
+	if err != nil {
+		return err
+	}
+
+	err = propertyToDuration(p, connMaxIdleTimeKey, db.SetConnMaxIdleTime)
+	if err != nil {
+		return err
+	}
+
+	err = propertyToDuration(p, connMaxLifetimeKey, db.SetConnMaxLifetime)
+	if err != nil {
+		return err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		return fmt.Errorf("unable to ping the DB: %w", err)
+	}
+
+	s.db = db
+
+	return nil
+}
+
+func (s *SQLServer) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString},
+	}
+}
+
+// Invoke handles all invoke operations.
+func (s *SQLServer) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req == nil {
+		return nil, errors.New("invoke request required")
+	}
+
+	if req.Operation == closeOperation {
+		return nil, s.db.Close()
+	}
+
+	if req.Metadata == nil {
+		return nil, errors.New("metadata required")
+	}
+	s.logger.Debugf("operation: %v", req.Operation)
+
+	startTime := time.Now()
+
+	resp := &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			respOpKey:        string(req.Operation),
+			respStartTimeKey: startTime.Format(time.RFC3339Nano),
+		},
+	}
+
+	switch req.Operation { //nolint:exhaustive
+	case execOperation, queryOperation:
+		q, ok := req.Metadata[commandSQLKey]
+		if !ok || q == "" {
+			return nil, fmt.Errorf("required metadata not set: %s", commandSQLKey)
+		}
+		resp.Metadata[respSQLKey] = q
+
+		if req.Operation == execOperation {
+			r, err := s.exec(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+			resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+		} else {
+			d, err := s.query(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+			resp.Data = d
+		}
+
+	case bulkInsertOperation:
+		r, err := s.bulkInsert(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+
+	default:
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s, %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, bulkInsertOperation, closeOperation)
+	}
+
+	endTime := time.Now()
+	resp.Metadata[respEndTimeKey] = endTime.Format(time.RFC3339Nano)
+	resp.Metadata[respDurationKey] = endTime.Sub(startTime).String()
+
+	return resp, nil
+}
+
+// Operations returns list of operations supported by SQL Server binding.
+func (s *SQLServer) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		execOperation,
+		queryOperation,
+		bulkInsertOperation,
+		closeOperation,
+	}
+}
+
+// Close will close the DB.
+func (s *SQLServer) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+
+	return nil
+}
+
+func (s *SQLServer) query(ctx context.Context, sql string) ([]byte, error) {
+	rows, err := s.db.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+		_ = rows.Err()
+	}()
+
+	result, err := jsonify(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling query result for query: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *SQLServer) exec(ctx context.Context, sql string) (int64, error) {
+	s.logger.Debugf("exec: %s", sql)
+
+	res, err := s.db.ExecContext(ctx, sql)
+	if err != nil {
+		return 0, fmt.Errorf("error executing query: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// bulkInsert copies the rows of the request to the table with a single bulk copy, in a transaction.
+func (s *SQLServer) bulkInsert(ctx context.Context, req *bindings.InvokeRequest) (int64, error) {
+	b, err := parseBulkInsertRequest(req)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Debugf("bulk insert of %d rows in %s", len(b.rows), b.table)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting bulk insert transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction is committed
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, mssql.CopyIn(b.table, b.options, b.columnNames()...))
+	if err != nil {
+		return 0, fmt.Errorf("error preparing bulk insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, row := range b.rows {
+		_, err = stmt.ExecContext(ctx, row...)
+		if err != nil {
+			return 0, fmt.Errorf("error adding row %d to bulk insert: %w", i, err)
+		}
+	}
+
+	// Executing the statement without arguments sends the rows
+	res, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error executing bulk insert: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error committing bulk insert: %w", err)
+	}
+
+	return affected, nil
+}
+
+func propertyToInt(props map[string]string, key string, setter func(int)) error {
+	if v, ok := props[key]; ok {
+		if i, err := strconv.Atoi(v); err == nil {
+			setter(i)
+		} else {
+			return fmt.Errorf("error converting %s:%s to int: %w", key, v, err)
+		}
+	}
+
+	return nil
+}
+
+func propertyToDuration(props map[string]string, key string, setter func(time.Duration)) error {
+	if v, ok := props[key]; ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			setter(d)
+		} else {
+			return fmt.Errorf("error converting %s:%s to duration: %w", key, v, err)
+		}
+	}
+
+	return nil
+}
+
+func jsonify(rows *sql.Rows) ([]byte, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columnTypes))
+		pointers := make([]interface{}, len(columnTypes))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return nil, err
+		}
+
+		r := make(map[string]interface{}, len(columnTypes))
+		for i, ct := range columnTypes {
+			if values[i] == nil {
+				continue
+			}
+			r[ct.Name()] = convert(ct, values[i])
+		}
+		ret = append(ret, r)
+	}
+
+	return jsoniter.ConfigFastest.Marshal(ret)
+}
+
+func convert(ct *sql.ColumnType, value interface{}) interface{} {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	switch ct.DatabaseTypeName() {
+	case "UNIQUEIDENTIFIER":
+		var id mssql.UniqueIdentifier
+		if err := id.Scan(b); err == nil {
+			return id.String()
+		}
+		return b
+	case "BINARY", "VARBINARY", "IMAGE":
+		// Binary values are encoded as base64 strings
+		return b
+	default:
+		// Decimals and strings of the char types are scanned as bytes
+		return string(b)
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestInvoke(t *testing.T) {
+	s, mock := mockDatabase(t)
+	defer s.Close()
+
+	t.Run("exec operation succeeds", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO foo \\(id, v1\\) VALUES \\(.*\\)").WillReturnResult(sqlmock.NewResult(1, 1))
+		req := &bindings.InvokeRequest{
+			Metadata:  map[string]string{commandSQLKey: "INSERT INTO foo (id, v1) VALUES (1, 'test-1')"},
+			Operation: execOperation,
+		}
+		resp, err := s.Invoke(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", resp.Metadata[respRowsAffectedKey])
+	})
+
+	t.Run("exec operation fails", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO foo \\(id, v1\\) VALUES \\(.*\\)").WillReturnError(errors.New("insert failed"))
+		req := &bindings.InvokeRequest{
+			Metadata:  map[string]string{commandSQLKey: "INSERT INTO foo (id, v1) VALUES (1, 'test-1')"},
+			Operation: execOperation,
+		}
+		resp, err := s.Invoke(context.Background(), req)
+		assert.Nil(t, resp)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("query operation succeeds", func(t *testing.T) {
+		col1 := sqlmock.NewColumn("id").OfType("BIGINT", int64(1))
+		col2 := sqlmock.NewColumn("price").OfType("DECIMAL", []byte("1.50"))
+		col3 := sqlmock.NewColumn("data").OfType("VARBINARY", []byte{})
+		rows := sqlmock.NewRowsWithColumnDefinition(col1, col2, col3).AddRow(int64(1), []byte("1.50"), []byte("abc"))
+		mock.ExpectQuery("SELECT \\* FROM foo WHERE id < \\d+").WillReturnRows(rows)
+
+		req := &bindings.InvokeRequest{
+			Metadata:  map[string]string{commandSQLKey: "SELECT * FROM foo WHERE id < 2"},
+			Operation: queryOperation,
+		}
+		resp, err := s.Invoke(context.Background(), req)
+		require.NoError(t, err)
+		var data []map[string]interface{}
+		err = json.Unmarshal(resp.Data, &data)
+		require.NoError(t, err)
+		require.Len(t, data, 1)
+		assert.Equal(t, float64(1), data[0]["id"])
+		assert.Equal(t, "1.50", data[0]["price"])
+		assert.Equal(t, "YWJj", data[0]["data"])
+	})
+
+	t.Run("bulk insert operation succeeds", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectPrepare("INSERTBULK")
+		mock.ExpectExec("INSERTBULK").WithArgs(int64(1), "test-1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERTBULK").WithArgs(int64(2), "test-2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERTBULK").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		req := &bindings.InvokeRequest{
+			Data: []byte(`[{"id": 1, "value": "test-1"}, {"id": 2, "value": "test-2"}]`),
+			Metadata: map[string]string{
+				bulkTableKey:   "foo",
+				bulkColumnsKey: "id, value:v1",
+			},
+			Operation: bulkInsertOperation,
+		}
+		resp, err := s.Invoke(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "2", resp.Metadata[respRowsAffectedKey])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("bulk insert operation rolls back on failure", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectPrepare("INSERTBULK")
+		mock.ExpectExec("INSERTBULK").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERTBULK").WillReturnError(errors.New("bulk copy failed"))
+		mock.ExpectRollback()
+
+		req := &bindings.InvokeRequest{
+			Data:      []byte(`[[1, "test-1"]]`),
+			Metadata:  map[string]string{bulkTableKey: "foo", bulkColumnsKey: "id,v1"},
+			Operation: bulkInsertOperation,
+		}
+		resp, err := s.Invoke(context.Background(), req)
+		assert.Nil(t, resp)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("close operation", func(t *testing.T) {
+		mock.ExpectClose()
+		req := &bindings.InvokeRequest{
+			Operation: closeOperation,
+		}
+		resp, _ := s.Invoke(context.Background(), req)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("unsupported operation", func(t *testing.T) {
+		req := &bindings.InvokeRequest{
+			Metadata:  map[string]string{},
+			Operation: "unsupported",
+		}
+		resp, err := s.Invoke(context.Background(), req)
+		assert.Nil(t, resp)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestParseBulkInsertRequest(t *testing.T) {
+	t.Run("object rows without columns", func(t *testing.T) {
+		b, err := parseBulkInsertRequest(&bindings.InvokeRequest{
+			Data:     []byte(`[{"id": 1, "name": "a", "tags": ["x"]}, {"id": 2, "price": 1.5}]`),
+			Metadata: map[string]string{bulkTableKey: "foo"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "name", "price", "tags"}, b.columnNames())
+		assert.Equal(t, [][]interface{}{
+			{int64(1), "a", nil, `["x"]`},
+			{int64(2), nil, 1.5, nil},
+		}, b.rows)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		b, err := parseBulkInsertRequest(&bindings.InvokeRequest{
+			Data: []byte(`[{"id": 1}]`),
+			Metadata: map[string]string{
+				bulkTableKey:             "foo",
+				bulkTablockKey:           "true",
+				bulkKeepNullsKey:         "true",
+				bulkRowsPerBatchKey:      "100",
+				bulkKilobytesPerBatchKey: "64",
+			},
+		})
+		require.NoError(t, err)
+		assert.True(t, b.options.Tablock)
+		assert.True(t, b.options.KeepNulls)
+		assert.False(t, b.options.FireTriggers)
+		assert.Equal(t, 100, b.options.RowsPerBatch)
+		assert.Equal(t, 64, b.options.KilobytesPerBatch)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tests := map[string]*bindings.InvokeRequest{
+			"missing table": {
+				Data:     []byte(`[{"id": 1}]`),
+				Metadata: map[string]string{},
+			},
+			"no rows": {
+				Data:     []byte(`[]`),
+				Metadata: map[string]string{bulkTableKey: "foo"},
+			},
+			"not an array": {
+				Data:     []byte(`{"id": 1}`),
+				Metadata: map[string]string{bulkTableKey: "foo"},
+			},
+			"array rows without columns": {
+				Data:     []byte(`[[1, "a"]]`),
+				Metadata: map[string]string{bulkTableKey: "foo"},
+			},
+			"array row with wrong length": {
+				Data:     []byte(`[[1, "a"], [2]]`),
+				Metadata: map[string]string{bulkTableKey: "foo", bulkColumnsKey: "id,name"},
+			},
+			"mixed rows": {
+				Data:     []byte(`[{"id": 1}, [2]]`),
+				Metadata: map[string]string{bulkTableKey: "foo"},
+			},
+			"invalid rowsPerBatch": {
+				Data:     []byte(`[{"id": 1}]`),
+				Metadata: map[string]string{bulkTableKey: "foo", bulkRowsPerBatchKey: "many"},
+			},
+		}
+		for name, req := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := parseBulkInsertRequest(req)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func mockDatabase(t *testing.T) (*SQLServer, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	s := NewSQLServer(logger.NewLogger("test")).(*SQLServer)
+	s.db = db
+
+	return s, mock
+}
@@ -17,28 +17,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
 const (
-	toNumber      = "toNumber"
-	fromNumber    = "fromNumber"
-	accountSid    = "accountSid"
-	authToken     = "authToken"
-	timeout       = "timeout"
-	twilioURLBase = "https://api.twilio.com/2010-04-01/Accounts/"
+	toNumber          = "toNumber"
+	fromNumber        = "fromNumber"
+	accountSid        = "accountSid"
+	authToken         = "authToken"
+	timeout           = "timeout"
+	webhookPort       = "webhookPort"
+	webhookBaseURL    = "webhookBaseURL"
+	inboundPath       = "inboundPath"
+	statusPath        = "statusCallbackPath"
+	validateSignature = "validateSignature"
+	statusCallback    = "statusCallback"
+	twilioURLBase     = "https://api.twilio.com/2010-04-01/Accounts/"
+
+	defaultInboundPath = "/sms/inbound"
+	defaultStatusPath  = "/sms/status"
 )
 
 type SMS struct {
 	metadata   twilioMetadata
 	logger     logger.Logger
 	httpClient *http.Client
+
+	// Address the webhook server listens on, once Read is called.
+	addr net.Addr
 }
 
 type twilioMetadata struct {
@@ -47,9 +62,16 @@ type twilioMetadata struct {
 	accountSid string
 	authToken  string
 	timeout    time.Duration
+
+	// Webhooks of the input binding, for inbound messages and delivery status callbacks.
+	webhookPort       string
+	webhookBaseURL    string
+	inboundPath       string
+	statusPath        string
+	validateSignature bool
 }
 
-func NewSMS(logger logger.Logger) bindings.OutputBinding {
+func NewSMS(logger logger.Logger) bindings.InputOutputBinding {
 	return &SMS{
 		logger: logger,
 		httpClient: &http.Client{
@@ -60,7 +82,10 @@ func NewSMS(logger logger.Logger) bindings.OutputBinding {
 
 func (t *SMS) Init(metadata bindings.Metadata) error {
 	twilioM := twilioMetadata{
-		timeout: time.Minute * 5,
+		timeout:           time.Minute * 5,
+		inboundPath:       defaultInboundPath,
+		statusPath:        defaultStatusPath,
+		validateSignature: true,
 	}
 
 	if metadata.Properties[fromNumber] == "" {
@@ -85,12 +110,39 @@ func (t *SMS) Init(metadata bindings.Metadata) error {
 		twilioM.timeout = t
 	}
 
+	twilioM.webhookPort = metadata.Properties[webhookPort]
+	twilioM.webhookBaseURL = strings.TrimRight(metadata.Properties[webhookBaseURL], "/")
+	if twilioM.webhookBaseURL != "" {
+		u, err := url.Parse(twilioM.webhookBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid \"%s\": must be an absolute http or https URL", webhookBaseURL)
+		}
+	}
+	if val := metadata.Properties[inboundPath]; val != "" {
+		twilioM.inboundPath = "/" + strings.TrimLeft(val, "/")
+	}
+	if val := metadata.Properties[statusPath]; val != "" {
+		twilioM.statusPath = "/" + strings.TrimLeft(val, "/")
+	}
+	if twilioM.inboundPath == twilioM.statusPath {
+		return fmt.Errorf("\"%s\" and \"%s\" must be different", inboundPath, statusPath)
+	}
+	if val, ok := metadata.Properties[validateSignature]; ok && val != "" {
+		twilioM.validateSignature = utils.IsTruthy(val)
+	}
+
 	t.metadata = twilioM
 	t.httpClient.Timeout = twilioM.timeout
 
 	return nil
 }
 
+func (t *SMS) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 func (t *SMS) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.CreateOperation}
 }
@@ -109,6 +161,12 @@ func (t *SMS) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*binding
 	v.Set("To", toNumberValue)
 	v.Set("From", t.metadata.fromNumber)
 	v.Set("Body", string(req.Data))
+	// Delivery status updates are sent to the webhook of the input binding, or to the URL of the request
+	if val := req.Metadata[statusCallback]; val != "" {
+		v.Set("StatusCallback", val)
+	} else if t.metadata.webhookBaseURL != "" && t.metadata.webhookPort != "" {
+		v.Set("StatusCallback", t.metadata.webhookBaseURL+t.metadata.statusPath)
+	}
 	vDr := *strings.NewReader(v.Encode())
 
 	twilioURL := fmt.Sprintf("%s%s/Messages.json", twilioURLBase, t.metadata.accountSid)
@@ -15,14 +15,21 @@ package sms
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -157,3 +164,164 @@ func TestWriteShouldFail(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestWriteWithStatusCallback(t *testing.T) {
+	httpTransport := &mockTransport{
+		response: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))},
+	}
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"fromNumber": "fromNumber", "accountSid": "accountSid", "authToken": "authToken",
+		"webhookPort": "0", "webhookBaseURL": "https://example.com/",
+	}
+	tw := NewSMS(logger.NewLogger("test")).(*SMS)
+	tw.httpClient = &http.Client{
+		Transport: httpTransport,
+	}
+	err := tw.Init(m)
+	require.NoError(t, err)
+
+	t.Run("webhook of the input binding", func(t *testing.T) {
+		httpTransport.reset()
+		_, err := tw.Invoke(context.Background(), &bindings.InvokeRequest{
+			Data:     []byte("hello world"),
+			Metadata: map[string]string{toNumber: "toNumber"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, httpTransport.request.ParseForm())
+		assert.Equal(t, "https://example.com/sms/status", httpTransport.request.PostForm.Get("StatusCallback"))
+	})
+
+	t.Run("URL of the request", func(t *testing.T) {
+		httpTransport.reset()
+		_, err := tw.Invoke(context.Background(), &bindings.InvokeRequest{
+			Data:     []byte("hello world"),
+			Metadata: map[string]string{toNumber: "toNumber", statusCallback: "https://other.example.com/status"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, httpTransport.request.ParseForm())
+		assert.Equal(t, "https://other.example.com/status", httpTransport.request.PostForm.Get("StatusCallback"))
+	})
+}
+
+func TestComputeSignature(t *testing.T) {
+	// Example of https://www.twilio.com/docs/usage/security#validating-requests
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	signature := computeSignature("12345", "https://mycompany.com/myapp.php?foo=1&bar=2", params)
+	assert.Equal(t, "0/KCTR6DLpKmkAf8muzZqo1nDgQ=", base64.StdEncoding.EncodeToString(signature))
+}
+
+func TestRead(t *testing.T) {
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"fromNumber": "fromNumber", "accountSid": "AC123", "authToken": "authToken",
+		"webhookPort": "0", "webhookBaseURL": "https://example.com",
+	}
+	tw := NewSMS(logger.NewLogger("test")).(*SMS)
+	err := tw.Init(m)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	var events []*bindings.ReadResponse
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = tw.Read(ctx, func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, res)
+		if res.Metadata[eventTypeMetadata] == inboundEventType {
+			return []byte("<Response><Message>Thanks</Message></Response>"), nil
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	base := fmt.Sprintf("http://127.0.0.1:%d", tw.addr.(*net.TCPAddr).Port)
+
+	post := func(path string, params url.Values, signature string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, base+path, strings.NewReader(params.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(signatureHeader, signature)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return res
+	}
+	sign := func(path string, params url.Values) string {
+		return base64.StdEncoding.EncodeToString(computeSignature("authToken", "https://example.com"+path, params))
+	}
+
+	t.Run("inbound message", func(t *testing.T) {
+		params := url.Values{"AccountSid": {"AC123"}, "MessageSid": {"SM1"}, "SmsStatus": {"received"}, "From": {"+15550001"}, "To": {"+15550002"}, "Body": {"Hi"}}
+		res := post("/sms/inbound", params, sign("/sms/inbound", params))
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/xml; charset=utf-8", res.Header.Get("Content-Type"))
+		assert.Equal(t, "<Response><Message>Thanks</Message></Response>", string(body))
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, events, 1)
+		assert.Equal(t, map[string]string{
+			eventTypeMetadata:     inboundEventType,
+			messageSidMetadata:    "SM1",
+			messageStatusMetadata: "received",
+			fromNumber:            "+15550001",
+			toNumber:              "+15550002",
+		}, events[0].Metadata)
+		var data map[string]string
+		require.NoError(t, json.Unmarshal(events[0].Data, &data))
+		assert.Equal(t, "Hi", data["Body"])
+	})
+
+	t.Run("status callback", func(t *testing.T) {
+		params := url.Values{"AccountSid": {"AC123"}, "MessageSid": {"SM2"}, "MessageStatus": {"delivered"}}
+		res := post("/sms/status?attempt=1", params, sign("/sms/status?attempt=1", params))
+		res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, events, 2)
+		assert.Equal(t, statusEventType, events[1].Metadata[eventTypeMetadata])
+		assert.Equal(t, "delivered", events[1].Metadata[messageStatusMetadata])
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		params := url.Values{"AccountSid": {"AC123"}, "MessageSid": {"SM3"}}
+		res := post("/sms/status", params, sign("/sms/inbound", params))
+		res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("other account", func(t *testing.T) {
+		params := url.Values{"AccountSid": {"AC456"}, "MessageSid": {"SM4"}}
+		res := post("/sms/status", params, sign("/sms/status", params))
+		res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Len(t, events, 2)
+	})
+}
+
+func TestReadRequiresBaseURL(t *testing.T) {
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"fromNumber": "fromNumber", "accountSid": "accountSid", "authToken": "authToken",
+		"webhookPort": "0",
+	}
+	tw := NewSMS(logger.NewLogger("test"))
+	err := tw.Init(m)
+	require.NoError(t, err)
+
+	err = tw.Read(context.Background(), nil)
+	assert.ErrorContains(t, err, "webhookBaseURL")
+}
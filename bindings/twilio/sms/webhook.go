@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	signatureHeader = "X-Twilio-Signature"
+
+	// Types of the events delivered to the app, in the eventType metadata.
+	eventTypeMetadata     = "eventType"
+	inboundEventType      = "inbound"
+	statusEventType       = "status"
+	messageSidMetadata    = "messageSid"
+	messageStatusMetadata = "messageStatus"
+
+	// Twilio webhooks are form posts of a few fields.
+	maxWebhookBodySize = 64 << 10
+	readHeaderTimeout  = 10 * time.Second
+	shutdownTimeout    = 10 * time.Second
+
+	emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+)
+
+// Read starts a server for the webhooks of inbound messages and delivery status callbacks, which are delivered to the app.
+// It stops when ctx is done.
+func (t *SMS) Read(ctx context.Context, handler bindings.Handler) error {
+	if t.metadata.webhookPort == "" {
+		return fmt.Errorf("\"%s\" is required for the input binding", webhookPort)
+	}
+	if t.metadata.validateSignature && t.metadata.webhookBaseURL == "" {
+		return fmt.Errorf("\"%s\" is required to validate the signature of the webhooks; set \"%s\" to false to disable the validation", webhookBaseURL, validateSignature)
+	}
+	if !t.metadata.validateSignature {
+		t.logger.Warn("The signature of Twilio webhooks isn't validated: anyone able to reach the webhooks can send events to the app")
+	}
+
+	ln, err := net.Listen("tcp", ":"+t.metadata.webhookPort)
+	if err != nil {
+		return fmt.Errorf("error listening for Twilio webhooks on port %s: %w", t.metadata.webhookPort, err)
+	}
+	t.addr = ln.Addr()
+
+	mux := http.NewServeMux()
+	mux.Handle(t.metadata.inboundPath, t.webhookHandler(inboundEventType, handler))
+	mux.Handle(t.metadata.statusPath, t.webhookHandler(statusEventType, handler))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	// Run the server in background
+	go func() {
+		t.logger.Debugf("Listening for Twilio webhooks at %s", t.addr)
+		err := srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.logger.Errorf("Error serving Twilio webhooks: %v", err)
+		}
+	}()
+
+	// Close the server when context is canceled
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			t.logger.Errorf("Error shutting down Twilio webhooks server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// webhookHandler delivers the parameters of the webhooks to the app as a JSON object.
+// For inbound messages, the response of the app, if any, is returned to Twilio as TwiML.
+func (t *SMS) webhookHandler(eventType string, handler bindings.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBodySize)
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if t.metadata.validateSignature && !t.validSignature(r) {
+			t.logger.Debugf("Rejected Twilio webhook with invalid signature from %s", r.RemoteAddr)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if r.PostForm.Get("AccountSid") != t.metadata.accountSid {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		params := make(map[string]string, len(r.PostForm))
+		for k := range r.PostForm {
+			params[k] = r.PostForm.Get(k)
+		}
+		data, err := json.Marshal(params)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		status := params["MessageStatus"]
+		if status == "" {
+			status = params["SmsStatus"]
+		}
+		contentType := "application/json"
+		res, err := handler(r.Context(), &bindings.ReadResponse{
+			Data:        data,
+			ContentType: &contentType,
+			Metadata: map[string]string{
+				eventTypeMetadata:     eventType,
+				messageSidMetadata:    params["MessageSid"],
+				messageStatusMetadata: status,
+				fromNumber:            params["From"],
+				toNumber:              params["To"],
+			},
+		})
+		if err != nil {
+			// Twilio retries the webhooks that fail
+			t.logger.Errorf("Error handling Twilio %s webhook: %v", eventType, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if eventType == statusEventType {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if len(res) == 0 {
+			res = []byte(emptyTwiML)
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(res)
+		if err != nil {
+			t.logger.Debugf("Error writing response of Twilio webhook: %v", err)
+		}
+	}
+}
+
+// validSignature checks the X-Twilio-Signature header of a webhook.
+// See https://www.twilio.com/docs/usage/security#validating-requests
+func (t *SMS) validSignature(r *http.Request) bool {
+	signature, err := base64.StdEncoding.DecodeString(r.Header.Get(signatureHeader))
+	if err != nil || len(signature) == 0 {
+		return false
+	}
+	expected := computeSignature(t.metadata.authToken, t.metadata.webhookBaseURL+r.URL.RequestURI(), r.PostForm)
+	return hmac.Equal(signature, expected)
+}
+
+// computeSignature returns the HMAC-SHA1 of the URL of a webhook followed by its POST parameters sorted by name.
+func computeSignature(authToken string, webhookURL string, params url.Values) []byte {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(webhookURL)
+	for _, k := range keys {
+		values := append([]string(nil), params[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return mac.Sum(nil)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -75,6 +76,12 @@ func (t *Binding) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (t *Binding) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
+
 // Operations returns list of operations supported by twitter binding.
 func (t *Binding) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{bindings.GetOperation}
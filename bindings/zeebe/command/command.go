@@ -22,6 +22,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/bindings/zeebe"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -29,6 +30,7 @@ const (
 	// operations.
 	TopologyOperation         bindings.OperationKind = "topology"
 	DeployProcessOperation    bindings.OperationKind = "deploy-process"
+	DeployResourceOperation   bindings.OperationKind = "deploy-resource"
 	CreateInstanceOperation   bindings.OperationKind = "create-instance"
 	CancelInstanceOperation   bindings.OperationKind = "cancel-instance"
 	SetVariablesOperation     bindings.OperationKind = "set-variables"
@@ -39,6 +41,7 @@ const (
 	FailJobOperation          bindings.OperationKind = "fail-job"
 	UpdateJobRetriesOperation bindings.OperationKind = "update-job-retries"
 	ThrowErrorOperation       bindings.OperationKind = "throw-error"
+	EvaluateDecisionOperation bindings.OperationKind = "evaluate-decision"
 )
 
 var (
@@ -72,10 +75,17 @@ func (z *ZeebeCommand) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (z *ZeebeCommand) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileOAuth2},
+	}
+}
+
 func (z *ZeebeCommand) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		TopologyOperation,
 		DeployProcessOperation,
+		DeployResourceOperation,
 		CreateInstanceOperation,
 		CancelInstanceOperation,
 		SetVariablesOperation,
@@ -86,6 +96,7 @@ func (z *ZeebeCommand) Operations() []bindings.OperationKind {
 		FailJobOperation,
 		UpdateJobRetriesOperation,
 		ThrowErrorOperation,
+		EvaluateDecisionOperation,
 	}
 }
 
@@ -95,6 +106,8 @@ func (z *ZeebeCommand) Invoke(ctx context.Context, req *bindings.InvokeRequest)
 		return z.topology(ctx)
 	case DeployProcessOperation:
 		return z.deployProcess(ctx, req)
+	case DeployResourceOperation:
+		return z.deployResource(ctx, req)
 	case CreateInstanceOperation:
 		return z.createInstance(ctx, req)
 	case CancelInstanceOperation:
@@ -115,6 +128,8 @@ func (z *ZeebeCommand) Invoke(ctx context.Context, req *bindings.InvokeRequest)
 		return z.updateJobRetries(ctx, req)
 	case ThrowErrorOperation:
 		return z.throwError(req)
+	case EvaluateDecisionOperation:
+		return z.evaluateDecision(ctx, req)
 	case bindings.GetOperation:
 		fallthrough
 	case bindings.CreateOperation:
@@ -93,17 +93,19 @@ func TestInvoke(t *testing.T) {
 func TestOperations(t *testing.T) {
 	testBinding := ZeebeCommand{logger: logger.NewLogger("test")}
 	operations := testBinding.Operations()
-	require.Equal(t, 12, len(operations))
+	require.Equal(t, 14, len(operations))
 	assert.Equal(t, TopologyOperation, operations[0])
 	assert.Equal(t, DeployProcessOperation, operations[1])
-	assert.Equal(t, CreateInstanceOperation, operations[2])
-	assert.Equal(t, CancelInstanceOperation, operations[3])
-	assert.Equal(t, SetVariablesOperation, operations[4])
-	assert.Equal(t, ResolveIncidentOperation, operations[5])
-	assert.Equal(t, PublishMessageOperation, operations[6])
-	assert.Equal(t, ActivateJobsOperation, operations[7])
-	assert.Equal(t, CompleteJobOperation, operations[8])
-	assert.Equal(t, FailJobOperation, operations[9])
-	assert.Equal(t, UpdateJobRetriesOperation, operations[10])
-	assert.Equal(t, ThrowErrorOperation, operations[11])
+	assert.Equal(t, DeployResourceOperation, operations[2])
+	assert.Equal(t, CreateInstanceOperation, operations[3])
+	assert.Equal(t, CancelInstanceOperation, operations[4])
+	assert.Equal(t, SetVariablesOperation, operations[5])
+	assert.Equal(t, ResolveIncidentOperation, operations[6])
+	assert.Equal(t, PublishMessageOperation, operations[7])
+	assert.Equal(t, ActivateJobsOperation, operations[8])
+	assert.Equal(t, CompleteJobOperation, operations[9])
+	assert.Equal(t, FailJobOperation, operations[10])
+	assert.Equal(t, UpdateJobRetriesOperation, operations[11])
+	assert.Equal(t, ThrowErrorOperation, operations[12])
+	assert.Equal(t, EvaluateDecisionOperation, operations[13])
 }
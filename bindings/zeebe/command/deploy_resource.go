@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+var (
+	ErrMissingResources        = errors.New("at least one resource is required")
+	ErrMissingResourceFileName = errors.New("fileName is a required attribute of every resource")
+)
+
+// deployResourcePayload lists the resources deployed together, such as BPMN processes, DMN decisions and forms.
+type deployResourcePayload struct {
+	Resources []deployResource `json:"resources"`
+}
+
+type deployResource struct {
+	FileName string `json:"fileName"`
+	// Content of the file, base64-encoded in JSON.
+	Content []byte `json:"content"`
+}
+
+// deployResource deploys one or more resources in a single deployment, so that resources referencing each other are
+// deployed atomically. A single resource is deployed from the data if the fileName metadata is set, like deploy-process;
+// otherwise the data is a list of resources.
+func (z *ZeebeCommand) deployResource(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload deployResourcePayload
+	if val, ok := req.Metadata[fileName]; ok && val != "" {
+		payload.Resources = []deployResource{{FileName: val, Content: req.Data}}
+	} else {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(payload.Resources) == 0 {
+		return nil, ErrMissingResources
+	}
+
+	cmd := z.client.NewDeployResourceCommand()
+	fileNames := make([]string, len(payload.Resources))
+	for i, resource := range payload.Resources {
+		if resource.FileName == "" {
+			return nil, ErrMissingResourceFileName
+		}
+		fileNames[i] = resource.FileName
+		cmd = cmd.AddResource(resource.Content, resource.FileName)
+	}
+
+	response, err := cmd.Send(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot deploy resources with fileNames %v: %w", fileNames, err)
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal response to json: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+	}, nil
+}
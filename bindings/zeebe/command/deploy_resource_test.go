@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/camunda/zeebe/clients/go/v8/pkg/commands"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/pb"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/zbc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+type mockDeployResourceGateway struct {
+	pb.GatewayClient
+	request *pb.DeployResourceRequest
+}
+
+func (mg *mockDeployResourceGateway) DeployResource(ctx context.Context, in *pb.DeployResourceRequest, opts ...grpc.CallOption) (*pb.DeployResourceResponse, error) {
+	mg.request = in
+
+	return &pb.DeployResourceResponse{Key: 1}, nil
+}
+
+type mockDeployResourceClient struct {
+	zbc.Client
+	gateway *mockDeployResourceGateway
+}
+
+func (mc *mockDeployResourceClient) NewDeployResourceCommand() *commands.DeployResourceCommand {
+	return commands.NewDeployResourceCommand(mc.gateway, func(context.Context, error) bool { return false })
+}
+
+func TestDeployResource(t *testing.T) {
+	testLogger := logger.NewLogger("test")
+
+	t.Run("resources are mandatory", func(t *testing.T) {
+		cmd := ZeebeCommand{logger: testLogger, client: &mockDeployResourceClient{gateway: &mockDeployResourceGateway{}}}
+		req := &bindings.InvokeRequest{Data: []byte(`{"resources":[]}`), Operation: DeployResourceOperation}
+		_, err := cmd.Invoke(context.TODO(), req)
+		assert.ErrorIs(t, err, ErrMissingResources)
+	})
+
+	t.Run("fileName of resources is mandatory", func(t *testing.T) {
+		cmd := ZeebeCommand{logger: testLogger, client: &mockDeployResourceClient{gateway: &mockDeployResourceGateway{}}}
+		req := &bindings.InvokeRequest{Data: []byte(`{"resources":[{"content":"PGRlZmluaXRpb25zLz4="}]}`), Operation: DeployResourceOperation}
+		_, err := cmd.Invoke(context.TODO(), req)
+		assert.ErrorIs(t, err, ErrMissingResourceFileName)
+	})
+
+	t.Run("deploy multiple resources", func(t *testing.T) {
+		payload := deployResourcePayload{
+			Resources: []deployResource{
+				{FileName: "order.bpmn", Content: []byte("<bpmn/>")},
+				{FileName: "discount.dmn", Content: []byte("<dmn/>")},
+				{FileName: "approval.form", Content: []byte("{}")},
+			},
+		}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		mg := &mockDeployResourceGateway{}
+		cmd := ZeebeCommand{logger: testLogger, client: &mockDeployResourceClient{gateway: mg}}
+		req := &bindings.InvokeRequest{Data: data, Operation: DeployResourceOperation}
+		_, err = cmd.Invoke(context.TODO(), req)
+		require.NoError(t, err)
+
+		require.Len(t, mg.request.Resources, 3)
+		assert.Equal(t, "order.bpmn", mg.request.Resources[0].Name)
+		assert.Equal(t, []byte("<bpmn/>"), mg.request.Resources[0].Content)
+		assert.Equal(t, "discount.dmn", mg.request.Resources[1].Name)
+		assert.Equal(t, "approval.form", mg.request.Resources[2].Name)
+	})
+
+	t.Run("deploy a single resource from the data", func(t *testing.T) {
+		mg := &mockDeployResourceGateway{}
+		cmd := ZeebeCommand{logger: testLogger, client: &mockDeployResourceClient{gateway: mg}}
+		req := &bindings.InvokeRequest{Data: []byte("<dmn/>"), Metadata: map[string]string{"fileName": "discount.dmn"}, Operation: DeployResourceOperation}
+		_, err := cmd.Invoke(context.TODO(), req)
+		require.NoError(t, err)
+
+		require.Len(t, mg.request.Resources, 1)
+		assert.Equal(t, "discount.dmn", mg.request.Resources[0].Name)
+		assert.Equal(t, []byte("<dmn/>"), mg.request.Resources[0].Content)
+	})
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/camunda/zeebe/clients/go/v8/pkg/commands"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/pb"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/zbc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+type mockEvaluateDecisionClient struct {
+	zbc.Client
+	cmd1 *mockEvaluateDecisionCommandStep1
+}
+
+type mockEvaluateDecisionCommandStep1 struct {
+	commands.EvaluateDecisionCommandStep1
+	cmd2        *mockEvaluateDecisionCommandStep2
+	decisionID  string
+	decisionKey int64
+}
+
+type mockEvaluateDecisionCommandStep2 struct {
+	commands.EvaluateDecisionCommandStep2
+	variables interface{}
+}
+
+func (mc *mockEvaluateDecisionClient) NewEvaluateDecisionCommand() commands.EvaluateDecisionCommandStep1 {
+	mc.cmd1 = &mockEvaluateDecisionCommandStep1{
+		cmd2: &mockEvaluateDecisionCommandStep2{},
+	}
+
+	return mc.cmd1
+}
+
+// DecisionId comes from the Zeebe client API and cannot be written as DecisionID
+func (cmd1 *mockEvaluateDecisionCommandStep1) DecisionId(decisionID string) commands.EvaluateDecisionCommandStep2 { //nolint:stylecheck
+	cmd1.decisionID = decisionID
+
+	return cmd1.cmd2
+}
+
+func (cmd1 *mockEvaluateDecisionCommandStep1) DecisionKey(decisionKey int64) commands.EvaluateDecisionCommandStep2 {
+	cmd1.decisionKey = decisionKey
+
+	return cmd1.cmd2
+}
+
+func (cmd2 *mockEvaluateDecisionCommandStep2) VariablesFromObject(variables interface{}) (commands.EvaluateDecisionCommandStep2, error) {
+	cmd2.variables = variables
+
+	return cmd2, nil
+}
+
+func (cmd2 *mockEvaluateDecisionCommandStep2) Send(context.Context) (*pb.EvaluateDecisionResponse, error) {
+	return &pb.EvaluateDecisionResponse{DecisionId: "decision", DecisionOutput: `"approved"`}, nil
+}
+
+func TestEvaluateDecision(t *testing.T) {
+	testLogger := logger.NewLogger("test")
+
+	t.Run("decisionId and decisionKey are not allowed at the same time", func(t *testing.T) {
+		decisionKey := int64(1)
+		payload := evaluateDecisionPayload{
+			DecisionID:  "some-id",
+			DecisionKey: &decisionKey,
+		}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := &bindings.InvokeRequest{Data: data, Operation: EvaluateDecisionOperation}
+
+		var mc mockEvaluateDecisionClient
+
+		cmd := ZeebeCommand{logger: testLogger, client: &mc}
+		_, err = cmd.Invoke(context.TODO(), req)
+		assert.ErrorIs(t, err, ErrAmbiguousDecisionVars)
+	})
+
+	t.Run("decisionId or decisionKey must be given", func(t *testing.T) {
+		data, err := json.Marshal(evaluateDecisionPayload{})
+		require.NoError(t, err)
+
+		req := &bindings.InvokeRequest{Data: data, Operation: EvaluateDecisionOperation}
+
+		var mc mockEvaluateDecisionClient
+
+		cmd := ZeebeCommand{logger: testLogger, client: &mc}
+		_, err = cmd.Invoke(context.TODO(), req)
+		assert.ErrorIs(t, err, ErrMissingDecisionVars)
+	})
+
+	t.Run("evaluate decision by decisionId", func(t *testing.T) {
+		payload := evaluateDecisionPayload{
+			DecisionID: "decision",
+			Variables: map[string]interface{}{
+				"amount": float64(100),
+			},
+		}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := &bindings.InvokeRequest{Data: data, Operation: EvaluateDecisionOperation}
+
+		var mc mockEvaluateDecisionClient
+
+		cmd := ZeebeCommand{logger: testLogger, client: &mc}
+		res, err := cmd.Invoke(context.TODO(), req)
+		require.NoError(t, err)
+
+		assert.Equal(t, payload.DecisionID, mc.cmd1.decisionID)
+		assert.Equal(t, payload.Variables, mc.cmd1.cmd2.variables)
+
+		var response pb.EvaluateDecisionResponse
+		require.NoError(t, json.Unmarshal(res.Data, &response))
+		assert.Equal(t, `"approved"`, response.DecisionOutput)
+	})
+
+	t.Run("evaluate decision by decisionKey", func(t *testing.T) {
+		decisionKey := int64(2251799813685249)
+		payload := evaluateDecisionPayload{
+			DecisionKey: &decisionKey,
+		}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := &bindings.InvokeRequest{Data: data, Operation: EvaluateDecisionOperation}
+
+		var mc mockEvaluateDecisionClient
+
+		cmd := ZeebeCommand{logger: testLogger, client: &mc}
+		_, err = cmd.Invoke(context.TODO(), req)
+		require.NoError(t, err)
+
+		assert.Equal(t, decisionKey, mc.cmd1.decisionKey)
+		assert.Nil(t, mc.cmd1.cmd2.variables)
+	})
+}
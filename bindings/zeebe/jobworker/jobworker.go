@@ -89,6 +89,12 @@ func (z *ZeebeJobWorker) Init(metadata bindings.Metadata) error {
 	return nil
 }
 
+func (z *ZeebeJobWorker) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileOAuth2},
+	}
+}
+
 func (z *ZeebeJobWorker) Read(ctx context.Context, handler bindings.Handler) error {
 	h := jobHandler{
 		callback:     handler,
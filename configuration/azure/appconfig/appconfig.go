@@ -30,6 +30,7 @@ import (
 
 	"github.com/dapr/components-contrib/configuration"
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 
 	"github.com/dapr/kit/logger"
 )
@@ -122,6 +123,12 @@ func (r *ConfigurationStore) Init(metadata configuration.Metadata) error {
 	return nil
 }
 
+func (r *ConfigurationStore) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileConnectionString, contribMetadata.AuthProfileAzureAD},
+	}
+}
+
 func parseMetadata(meta configuration.Metadata) (metadata, error) {
 	m := metadata{}
 
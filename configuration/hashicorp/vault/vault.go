@@ -0,0 +1,458 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultVaultAddress    = "https://127.0.0.1:8200"
+	defaultEnginePath      = "secret"
+	defaultPollInterval    = 30 * time.Second
+	defaultRequestTimeout  = 15 * time.Second
+	vaultHTTPHeader        = "X-Vault-Token"
+	vaultHTTPRequestHeader = "X-Vault-Request"
+
+	// Field of the secrets that holds the value of the items, when it's their only field.
+	valueField = "value"
+)
+
+// errNotFound is returned when a secret doesn't exist, or when its latest version is deleted or destroyed.
+var errNotFound = errors.New("secret not found")
+
+// ConfigurationStore is a configuration store backed by the KV version 2 secrets engine of HashiCorp Vault.
+// Each key is a secret under the path of the component; its value is the value field of the secret when it's
+// the only field, or the JSON of the fields otherwise. The version of the items is the version of the secrets.
+type ConfigurationStore struct {
+	client   *http.Client
+	metadata vaultMetadata
+
+	lock                 sync.Mutex
+	subscribeStopChanMap map[string]chan struct{}
+
+	logger logger.Logger
+}
+
+type vaultMetadata struct {
+	VaultAddr           string `mapstructure:"vaultAddr"`
+	VaultToken          string `mapstructure:"vaultToken"`
+	VaultTokenMountPath string `mapstructure:"vaultTokenMountPath"`
+	// Mount path of the KV version 2 secrets engine; defaults to secret.
+	EnginePath string `mapstructure:"enginePath"`
+	// Path of the secrets of the keys in the secrets engine; defaults to the root of the engine.
+	Path string `mapstructure:"path"`
+	// Interval at which subscriptions poll the versions of the secrets; defaults to 30s.
+	PollInterval   time.Duration `mapstructure:"pollInterval"`
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+	CaPem          string        `mapstructure:"caPem"`
+	SkipVerify     bool          `mapstructure:"skipVerify"`
+	TLSServerName  string        `mapstructure:"tlsServerName"`
+}
+
+// NewVaultConfigurationStore returns a new HashiCorp Vault configuration store.
+func NewVaultConfigurationStore(logger logger.Logger) configuration.Store {
+	return &ConfigurationStore{
+		subscribeStopChanMap: make(map[string]chan struct{}),
+		logger:               logger,
+	}
+}
+
+// Init parses the metadata and creates the HTTP client of Vault.
+func (s *ConfigurationStore) Init(metadata configuration.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	s.metadata = m
+
+	tlsConf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: m.SkipVerify, //nolint:gosec
+		ServerName:         m.TLSServerName,
+	}
+	if m.CaPem != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(m.CaPem)) {
+			return errors.New("vault configuration store: couldn't read the certificates of caPem")
+		}
+		tlsConf.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	s.client = &http.Client{Transport: transport}
+
+	return nil
+}
+
+func (s *ConfigurationStore) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileToken},
+	}
+}
+
+func parseMetadata(metadata configuration.Metadata) (vaultMetadata, error) {
+	m := vaultMetadata{
+		VaultAddr:      defaultVaultAddress,
+		EnginePath:     defaultEnginePath,
+		PollInterval:   defaultPollInterval,
+		RequestTimeout: defaultRequestTimeout,
+	}
+	err := contribMetadata.DecodeMetadata(metadata.Properties, &m)
+	if err != nil {
+		return m, fmt.Errorf("vault configuration store: %w", err)
+	}
+
+	if m.VaultToken != "" && m.VaultTokenMountPath != "" {
+		return m, errors.New("vault configuration store: vaultToken and vaultTokenMountPath can't be both set")
+	}
+	if m.VaultToken == "" && m.VaultTokenMountPath == "" {
+		return m, errors.New("vault configuration store: vaultToken or vaultTokenMountPath is required")
+	}
+	if m.PollInterval <= 0 {
+		return m, fmt.Errorf("vault configuration store: invalid pollInterval %s", m.PollInterval)
+	}
+	m.VaultAddr = strings.TrimSuffix(m.VaultAddr, "/")
+	m.EnginePath = strings.Trim(m.EnginePath, "/")
+	m.Path = strings.Trim(m.Path, "/")
+
+	return m, nil
+}
+
+// Get returns the items of the keys, or of all the keys under the path if none is requested.
+// Keys that don't exist are omitted.
+func (s *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	keys := req.Keys
+	if len(keys) == 0 {
+		var err error
+		keys, err = s.listKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := make(map[string]*configuration.Item, len(keys))
+	for _, key := range keys {
+		item, err := s.getItem(ctx, key, 0)
+		if err != nil {
+			if errors.Is(err, errNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		items[key] = item
+	}
+
+	return &configuration.GetResponse{
+		Items: items,
+	}, nil
+}
+
+// Subscribe polls the versions of the keys, or of all the keys under the path if none is requested, and calls the
+// handler with the keys whose version changed. Deleted keys are notified with an empty value.
+func (s *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	// The versions of the first poll are the baseline of the changes
+	versions, err := s.pollVersions(ctx, req.Keys)
+	if err != nil {
+		return "", err
+	}
+
+	subscribeID := uuid.New().String()
+	stop := make(chan struct{})
+	s.lock.Lock()
+	s.subscribeStopChanMap[subscribeID] = stop
+	s.lock.Unlock()
+
+	go s.doSubscribe(ctx, req.Keys, versions, handler, subscribeID, stop)
+
+	return subscribeID, nil
+}
+
+// Unsubscribe stops the polling of a subscription.
+func (s *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stop, ok := s.subscribeStopChanMap[req.ID]
+	if !ok {
+		return fmt.Errorf("subscription with id %s does not exist", req.ID)
+	}
+	delete(s.subscribeStopChanMap, req.ID)
+	close(stop)
+
+	return nil
+}
+
+func (s *ConfigurationStore) doSubscribe(ctx context.Context, keys []string, versions map[string]int, handler configuration.UpdateHandler, id string, stop chan struct{}) {
+	ticker := time.NewTicker(s.metadata.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := s.pollVersions(ctx, keys)
+		if err != nil {
+			s.logger.Errorf("vault configuration store: failed to poll the versions of the keys: %s", err)
+			continue
+		}
+
+		items := map[string]*configuration.Item{}
+		for key, version := range current {
+			if version == versions[key] {
+				continue
+			}
+			if version == 0 {
+				items[key] = &configuration.Item{Metadata: map[string]string{}}
+				continue
+			}
+			item, err := s.getItem(ctx, key, version)
+			if err != nil {
+				if errors.Is(err, errNotFound) {
+					// Deleted since it was polled; the next poll notifies it
+					current[key] = versions[key]
+					continue
+				}
+				s.logger.Errorf("vault configuration store: failed to get key %s: %s", key, err)
+				current[key] = versions[key]
+				continue
+			}
+			items[key] = item
+		}
+		// Keys whose secret has been removed from the path
+		for key, version := range versions {
+			if _, ok := current[key]; !ok && version != 0 {
+				items[key] = &configuration.Item{Metadata: map[string]string{}}
+			}
+		}
+		versions = current
+
+		if len(items) == 0 {
+			continue
+		}
+		err = handler(ctx, &configuration.UpdateEvent{
+			ID:    id,
+			Items: items,
+		})
+		if err != nil {
+			s.logger.Errorf("vault configuration store: fail to call handler to notify event for configuration update subscribe: %s", err)
+		}
+	}
+}
+
+// pollVersions returns the current versions of the keys, or of all the keys under the path if keys is empty.
+// The version of keys that don't exist, or whose latest version is deleted, is 0.
+func (s *ConfigurationStore) pollVersions(ctx context.Context, keys []string) (map[string]int, error) {
+	if len(keys) == 0 {
+		var err error
+		keys, err = s.listKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	versions := make(map[string]int, len(keys))
+	for _, key := range keys {
+		version, err := s.currentVersion(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		versions[key] = version
+	}
+	return versions, nil
+}
+
+// kvMetadataResponse is the response of the metadata of a secret.
+type kvMetadataResponse struct {
+	Data struct {
+		CurrentVersion int `json:"current_version"`
+		Versions       map[string]struct {
+			DeletionTime string `json:"deletion_time"`
+			Destroyed    bool   `json:"destroyed"`
+		} `json:"versions"`
+	} `json:"data"`
+}
+
+// currentVersion returns the latest version of a secret, or 0 if it doesn't exist or is deleted.
+func (s *ConfigurationStore) currentVersion(ctx context.Context, key string) (int, error) {
+	var res kvMetadataResponse
+	err := s.request(ctx, http.MethodGet, s.secretURL("metadata", key), &res)
+	if errors.Is(err, errNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the metadata of key %s: %w", key, err)
+	}
+
+	v := res.Data.Versions[strconv.Itoa(res.Data.CurrentVersion)]
+	if v.DeletionTime != "" || v.Destroyed {
+		return 0, nil
+	}
+	return res.Data.CurrentVersion, nil
+}
+
+// kvDataResponse is the response of a version of a secret.
+type kvDataResponse struct {
+	Data struct {
+		Data     map[string]any `json:"data"`
+		Metadata struct {
+			CreatedTime string `json:"created_time"`
+			Version     int    `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// getItem reads a version of a secret, or the latest version if version is 0.
+func (s *ConfigurationStore) getItem(ctx context.Context, key string, version int) (*configuration.Item, error) {
+	u := s.secretURL("data", key)
+	if version > 0 {
+		u += "?version=" + strconv.Itoa(version)
+	}
+	var res kvDataResponse
+	err := s.request(ctx, http.MethodGet, u, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	if res.Data.Data == nil {
+		// Deleted versions have metadata but no data
+		return nil, fmt.Errorf("failed to get key %s: %w", key, errNotFound)
+	}
+
+	item := &configuration.Item{
+		Version:  strconv.Itoa(res.Data.Metadata.Version),
+		Metadata: map[string]string{},
+	}
+	if res.Data.Metadata.CreatedTime != "" {
+		item.Metadata["createdTime"] = res.Data.Metadata.CreatedTime
+	}
+	if v, ok := res.Data.Data[valueField].(string); ok && len(res.Data.Data) == 1 {
+		item.Value = v
+	} else {
+		b, err := json.Marshal(res.Data.Data)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = string(b)
+	}
+	return item, nil
+}
+
+// listKeys returns the keys of the secrets under the path, without the subfolders.
+func (s *ConfigurationStore) listKeys(ctx context.Context) ([]string, error) {
+	var res struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	err := s.request(ctx, "LIST", s.secretURL("metadata", ""), &res)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	keys := make([]string, 0, len(res.Data.Keys))
+	for _, k := range res.Data.Keys {
+		if !strings.HasSuffix(k, "/") {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// secretURL returns the URL of the data or metadata of the secret of a key.
+func (s *ConfigurationStore) secretURL(kind string, key string) string {
+	p := s.metadata.EnginePath + "/" + kind
+	if s.metadata.Path != "" {
+		p += "/" + s.metadata.Path
+	}
+	if key != "" {
+		segments := strings.Split(key, "/")
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+		p += "/" + strings.Join(segments, "/")
+	}
+	return s.metadata.VaultAddr + "/v1/" + p
+}
+
+// request sends a request to Vault and decodes its response. Not found responses are returned as errNotFound.
+func (s *ConfigurationStore) request(ctx context.Context, method string, u string, res any) error {
+	token, err := s.token()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.metadata.RequestTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set(vaultHTTPHeader, token)
+	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return fmt.Errorf("couldn't get successful response, status code %d, body %s", httpResp.StatusCode, string(b))
+	}
+
+	return json.NewDecoder(httpResp.Body).Decode(res)
+}
+
+// token returns the token of the metadata, or reads it from the token mount path, so that rotated tokens are used.
+func (s *ConfigurationStore) token() (string, error) {
+	if s.metadata.VaultToken != "" {
+		return s.metadata.VaultToken, nil
+	}
+
+	b, err := os.ReadFile(s.metadata.VaultTokenMountPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read vault token from mount path %s: %w", s.metadata.VaultTokenMountPath, err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeVault is a fake of the KV version 2 secrets engine mounted at secret.
+type fakeVault struct {
+	lock    sync.Mutex
+	secrets map[string][]fakeVersion
+}
+
+type fakeVersion struct {
+	data    map[string]any
+	deleted bool
+}
+
+func (f *fakeVault) put(name string, data map[string]any) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.secrets[name] = append(f.secrets[name], fakeVersion{data: data})
+}
+
+func (f *fakeVault) delete(name string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	versions := f.secrets[name]
+	versions[len(versions)-1].deleted = true
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/app":
+		keys := []string{"folder/"}
+		for name := range f.secrets {
+			keys = append(keys, name)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"keys": keys}})
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/app/"):
+		versions, ok := f.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/app/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		meta := map[string]any{}
+		for i, v := range versions {
+			deletion := ""
+			if v.deleted {
+				deletion = "2022-11-01T00:00:00Z"
+			}
+			meta[strconv.Itoa(i+1)] = map[string]any{"deletion_time": deletion, "destroyed": false}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"current_version": len(versions), "versions": meta}})
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/app/"):
+		versions, ok := f.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/app/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		version := len(versions)
+		if v := r.URL.Query().Get("version"); v != "" {
+			version, _ = strconv.Atoi(v)
+		}
+		v := versions[version-1]
+		if v.deleted {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"data":     v.data,
+			"metadata": map[string]any{"version": version, "created_time": "2022-11-01T00:00:00Z"},
+		}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestStore(t *testing.T) (*ConfigurationStore, *fakeVault) {
+	t.Helper()
+
+	fake := &fakeVault{secrets: map[string][]fakeVersion{}}
+	fake.put("color", map[string]any{"value": "red"})
+	fake.put("color", map[string]any{"value": "blue"})
+	fake.put("limits", map[string]any{"max": 10, "min": "1"})
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s := NewVaultConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	err := s.Init(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"vaultAddr":    server.URL,
+		"vaultToken":   "token",
+		"path":         "/app/",
+		"pollInterval": "10ms",
+	}}})
+	require.NoError(t, err)
+	return s, fake
+}
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"vaultToken": "token",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, defaultVaultAddress, m.VaultAddr)
+	assert.Equal(t, defaultEnginePath, m.EnginePath)
+	assert.Equal(t, defaultPollInterval, m.PollInterval)
+
+	invalid := []map[string]string{
+		{},
+		{"vaultToken": "token", "vaultTokenMountPath": "/token"},
+		{"vaultToken": "token", "pollInterval": "0"},
+	}
+	for _, props := range invalid {
+		_, err = parseMetadata(configuration.Metadata{Base: mdata.Base{Properties: props}})
+		assert.Error(t, err, props)
+	}
+}
+
+func TestGet(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	t.Run("keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{Keys: []string{"color", "missing"}})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 1)
+		assert.Equal(t, "blue", res.Items["color"].Value)
+		assert.Equal(t, "2", res.Items["color"].Version)
+		assert.Equal(t, "2022-11-01T00:00:00Z", res.Items["color"].Metadata["createdTime"])
+	})
+
+	t.Run("all keys", func(t *testing.T) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, "blue", res.Items["color"].Value)
+		assert.JSONEq(t, `{"max":10,"min":"1"}`, res.Items["limits"].Value)
+		assert.Equal(t, "1", res.Items["limits"].Version)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	s, fake := newTestStore(t)
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	handler := func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	}
+	next := func() *configuration.UpdateEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "no update event")
+			return nil
+		}
+	}
+
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{}, handler)
+	require.NoError(t, err)
+
+	fake.put("color", map[string]any{"value": "green"})
+	e := next()
+	assert.Equal(t, id, e.ID)
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "green", e.Items["color"].Value)
+	assert.Equal(t, "3", e.Items["color"].Version)
+
+	fake.put("size", map[string]any{"value": "xl"})
+	e = next()
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "xl", e.Items["size"].Value)
+
+	fake.delete("color")
+	e = next()
+	require.Len(t, e.Items, 1)
+	assert.Equal(t, "", e.Items["color"].Value)
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	assert.Error(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	fake.put("size", map[string]any{"value": "xs"})
+	select {
+	case e := <-events:
+		assert.Fail(t, "unexpected event after unsubscribe", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTokenMountPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("token\n"), 0o600))
+
+	s := &ConfigurationStore{metadata: vaultMetadata{VaultTokenMountPath: path}}
+	token, err := s.token()
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/components-contrib/internal/component/jetstream"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// ConfigurationStore is a configuration store backed by a NATS JetStream key-value bucket.
+// The revision of the keys is used as the version of the items.
+type ConfigurationStore struct {
+	nc     *nats.Conn
+	bucket nats.KeyValue
+
+	lock                 sync.Mutex
+	subscribeStopChanMap map[string]chan struct{}
+
+	logger logger.Logger
+}
+
+// NewJetstreamConfigurationStore returns a new NATS JetStream KV configuration store.
+func NewJetstreamConfigurationStore(logger logger.Logger) configuration.Store {
+	return &ConfigurationStore{
+		subscribeStopChanMap: make(map[string]chan struct{}),
+		logger:               logger,
+	}
+}
+
+// Init parses the metadata and opens the bucket.
+func (s *ConfigurationStore) Init(metadata configuration.Metadata) error {
+	m, err := jetstream.ParseKVMetadata(metadata.Properties, "dapr.io - configuration.jetstream")
+	if err != nil {
+		return err
+	}
+
+	s.nc, s.bucket, err = jetstream.ConnectKV(m)
+	return err
+}
+
+func (s *ConfigurationStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileJWT},
+	}
+}
+
+// Get returns the items of the keys, or of all the keys in the bucket if none is requested.
+// Keys that don't exist are omitted.
+func (s *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	keys := req.Keys
+	if len(keys) == 0 {
+		var err error
+		keys, err = s.bucket.Keys(nats.Context(ctx))
+		if err != nil && !errors.Is(err, nats.ErrNoKeysFound) {
+			return nil, fmt.Errorf("jetstream configuration store: failed to list keys: %w", err)
+		}
+	}
+
+	items := make(map[string]*configuration.Item, len(keys))
+	for _, key := range keys {
+		entry, err := s.bucket.Get(key)
+		if err != nil {
+			if errors.Is(err, nats.ErrKeyNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("jetstream configuration store: failed to get key %s: %w", key, err)
+		}
+		items[key] = newItem(entry)
+	}
+
+	return &configuration.GetResponse{
+		Items: items,
+	}, nil
+}
+
+// Subscribe watches the keys, or all the keys in the bucket if none is requested, and calls the handler on every change.
+// Deleted keys are notified with an empty value.
+func (s *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	var watchers []nats.KeyWatcher
+	if len(req.Keys) == 0 {
+		w, err := s.bucket.WatchAll()
+		if err != nil {
+			return "", fmt.Errorf("jetstream configuration store: failed to watch keys: %w", err)
+		}
+		watchers = append(watchers, w)
+	}
+	for _, key := range req.Keys {
+		w, err := s.bucket.Watch(key)
+		if err != nil {
+			for _, w := range watchers {
+				w.Stop()
+			}
+			return "", fmt.Errorf("jetstream configuration store: failed to watch key %s: %w", key, err)
+		}
+		watchers = append(watchers, w)
+	}
+
+	subscribeID := uuid.New().String()
+	stop := make(chan struct{})
+	s.lock.Lock()
+	s.subscribeStopChanMap[subscribeID] = stop
+	s.lock.Unlock()
+
+	for _, w := range watchers {
+		go s.doSubscribe(ctx, w, handler, subscribeID, stop)
+	}
+
+	return subscribeID, nil
+}
+
+// Unsubscribe stops the watchers of a subscription.
+func (s *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stop, ok := s.subscribeStopChanMap[req.ID]
+	if !ok {
+		return fmt.Errorf("subscription with id %s does not exist", req.ID)
+	}
+	delete(s.subscribeStopChanMap, req.ID)
+	close(stop)
+
+	return nil
+}
+
+func (s *ConfigurationStore) doSubscribe(ctx context.Context, w nats.KeyWatcher, handler configuration.UpdateHandler, id string, stop chan struct{}) {
+	defer w.Stop()
+
+	// The watcher first sends the current entries, followed by nil, which aren't changes
+	initialized := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case entry, ok := <-w.Updates():
+			if !ok {
+				return
+			}
+			if entry == nil {
+				initialized = true
+				continue
+			}
+			if !initialized {
+				continue
+			}
+
+			err := handler(ctx, &configuration.UpdateEvent{
+				ID:    id,
+				Items: map[string]*configuration.Item{entry.Key(): newItem(entry)},
+			})
+			if err != nil {
+				s.logger.Errorf("fail to call handler to notify event for configuration update subscribe: %s", err)
+			}
+		}
+	}
+}
+
+func newItem(entry nats.KeyValueEntry) *configuration.Item {
+	item := &configuration.Item{
+		Version:  jetstream.FormatRevision(entry.Revision()),
+		Metadata: map[string]string{},
+	}
+	if entry.Operation() == nats.KeyValuePut {
+		item.Value = string(entry.Value())
+	}
+
+	return item
+}
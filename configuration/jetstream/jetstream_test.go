@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jetstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestStore(t *testing.T) (*ConfigurationStore, nats.KeyValue) {
+	t.Helper()
+
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natsserver.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	jsc, err := nc.JetStream()
+	require.NoError(t, err)
+	kv, err := jsc.CreateKeyValue(&nats.KeyValueConfig{Bucket: "config"})
+	require.NoError(t, err)
+
+	store := NewJetstreamConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	err = store.Init(configuration.Metadata{
+		Base: metadata.Base{Properties: map[string]string{
+			"natsURL": s.ClientURL(),
+			"bucket":  "config",
+		}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(store.nc.Close)
+
+	return store, kv
+}
+
+func TestGet(t *testing.T) {
+	store, kv := newTestStore(t)
+
+	resp, err := store.Get(context.Background(), &configuration.GetRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Items)
+
+	_, err = kv.PutString("a", "1")
+	require.NoError(t, err)
+	rev, err := kv.PutString("b", "2")
+	require.NoError(t, err)
+
+	resp, err = store.Get(context.Background(), &configuration.GetRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.Items, 2)
+
+	resp, err = store.Get(context.Background(), &configuration.GetRequest{Keys: []string{"b", "missing"}})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "2", resp.Items["b"].Value)
+	assert.Equal(t, "2", resp.Items["b"].Version)
+	assert.Equal(t, uint64(2), rev)
+}
+
+func TestSubscribe(t *testing.T) {
+	store, kv := newTestStore(t)
+
+	_, err := kv.PutString("a", "initial")
+	require.NoError(t, err)
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	handler := func(ctx context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	}
+	id, err := store.Subscribe(context.Background(), &configuration.SubscribeRequest{Keys: []string{"a"}}, handler)
+	require.NoError(t, err)
+
+	receive := func() *configuration.Item {
+		select {
+		case e := <-events:
+			assert.Equal(t, id, e.ID)
+			require.Len(t, e.Items, 1)
+			return e.Items["a"]
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "no update received")
+			return nil
+		}
+	}
+
+	// Changes of other keys aren't notified
+	_, err = kv.PutString("b", "ignored")
+	require.NoError(t, err)
+	rev, err := kv.PutString("a", "updated")
+	require.NoError(t, err)
+	item := receive()
+	assert.Equal(t, "updated", item.Value)
+	assert.Equal(t, "3", item.Version)
+	assert.Equal(t, uint64(3), rev)
+
+	require.NoError(t, kv.Delete("a"))
+	assert.Equal(t, "", receive().Value)
+
+	require.NoError(t, store.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	assert.Error(t, store.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	_, err = kv.PutString("a", "after")
+	require.NoError(t, err)
+	select {
+	case <-events:
+		assert.Fail(t, "update received after unsubscribing")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
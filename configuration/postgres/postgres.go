@@ -33,6 +33,8 @@ import (
 	"k8s.io/utils/strings/slices"
 
 	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/components-contrib/internal/redact"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -87,7 +89,14 @@ func NewPostgresConfigurationStore(logger logger.Logger) configuration.Store {
 	}
 }
 
-func (p *ConfigurationStore) Init(metadata configuration.Metadata) error {
+func (p *ConfigurationStore) Init(metadata configuration.Metadata) (err error) {
+	// The connection string and its password may be quoted by the errors of pgx
+	redactor := redact.New(metadata.Properties, nil)
+	p.logger = redactor.Logger(p.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	p.logger.Debug(InfoStartInit)
 	if p.client != nil {
 		return fmt.Errorf(ErrorAlreadyInitialized)
@@ -122,6 +131,12 @@ func (p *ConfigurationStore) Init(metadata configuration.Metadata) error {
 	return nil
 }
 
+func (p *ConfigurationStore) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileConnectionString},
+	}
+}
+
 func (p *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
 	if err := validateInput(req.Keys); err != nil {
 		p.logger.Error(err)
@@ -29,6 +29,8 @@ import (
 
 	"github.com/dapr/components-contrib/configuration"
 	"github.com/dapr/components-contrib/configuration/redis/internal"
+	"github.com/dapr/components-contrib/internal/redact"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -135,7 +137,14 @@ func parseRedisMetadata(meta configuration.Metadata) (metadata, error) {
 }
 
 // Init does metadata and connection parsing.
-func (r *ConfigurationStore) Init(metadata configuration.Metadata) error {
+func (r *ConfigurationStore) Init(metadata configuration.Metadata) (err error) {
+	// The Redis password may be part of the errors when connecting
+	redactor := redact.New(metadata.Properties, nil)
+	r.logger = redactor.Logger(r.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	m, err := parseRedisMetadata(metadata)
 	if err != nil {
 		return err
@@ -157,6 +166,12 @@ func (r *ConfigurationStore) Init(metadata configuration.Metadata) error {
 	return err
 }
 
+func (r *ConfigurationStore) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfilePassword},
+	}
+}
+
 func (r *ConfigurationStore) newClient(m metadata) *redis.Client {
 	opts := &redis.Options{
 		Addr:            m.Host,
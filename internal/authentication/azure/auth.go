@@ -66,6 +66,11 @@ func NewEnvironmentSettings(resourceName string, values map[string]string) (Envi
 	case "signalr":
 		// Azure SignalR (data plane)
 		es.Resource = "https://signalr.azure.com"
+	case "eventgrid":
+		// Azure Event Grid (data plane)
+		// For documentation https://learn.microsoft.com/en-us/azure/event-grid/authenticate-with-microsoft-entra-id
+		// The resource name to request a token is https://eventgrid.azure.net, and it's the same for all clouds/tenants.
+		es.Resource = "https://eventgrid.azure.net"
 	case "appconfig":
 		// Azure App Configuration (data plane)
 		// For documentation https://docs.microsoft.com/en-us/azure/azure-app-configuration/rest-api-authentication-azure-ad#audience
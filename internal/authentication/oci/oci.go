@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v54/common"
+	"github.com/oracle/oci-go-sdk/v54/common/auth"
+)
+
+// Metadata keys of the authentication settings.
+const (
+	InstancePrincipalAuthenticationKey = "instancePrincipalAuthentication"
+	ConfigFileAuthenticationKey        = "configFileAuthentication"
+	ConfigFilePathKey                  = "configFilePath"
+	TenancyKey                         = "tenancyOCID"
+	RegionKey                          = "region"
+	FingerPrintKey                     = "fingerPrint"
+	PrivateKeyKey                      = "privateKey"
+	UserKey                            = "userOCID"
+)
+
+// Settings are the authentication settings of OCI components.
+// Without instance principal or configuration file authentication, the identity of the user is in the settings.
+type Settings struct {
+	UserOCID                        string
+	Region                          string
+	TenancyOCID                     string
+	FingerPrint                     string
+	PrivateKey                      string
+	ConfigFilePath                  string
+	ConfigFileProfile               string
+	InstancePrincipalAuthentication bool
+	ConfigFileAuthentication        bool
+}
+
+// Validate returns an error if the settings of the authentication method are missing.
+func (s Settings) Validate() error {
+	if s.ConfigFileAuthentication {
+		if err := validateConfigFilePath(s.ConfigFilePath); err != nil {
+			return err
+		}
+	}
+	if s.InstancePrincipalAuthentication || s.ConfigFileAuthentication {
+		return nil
+	}
+
+	if s.Region == "" {
+		return fmt.Errorf("missing or empty %s field from metadata", RegionKey)
+	}
+	if s.UserOCID == "" {
+		return fmt.Errorf("missing or empty %s field from metadata", UserKey)
+	}
+	if s.FingerPrint == "" {
+		return fmt.Errorf("missing or empty %s field from metadata", FingerPrintKey)
+	}
+	if s.PrivateKey == "" {
+		return fmt.Errorf("missing or empty %s field from metadata", PrivateKeyKey)
+	}
+	if s.TenancyOCID == "" {
+		return fmt.Errorf("missing or empty %s field from metadata", TenancyKey)
+	}
+
+	return nil
+}
+
+func validateConfigFilePath(value string) error {
+	if strings.HasPrefix(value, "~/") {
+		return fmt.Errorf("%s is set to %s which starts with ~/; this is not supported - please provide absolute path to configuration file", ConfigFilePathKey, value)
+	}
+	if value != "" {
+		if _, err := os.Stat(value); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("oci configuration file %s does not exist %w", value, err)
+			}
+			return fmt.Errorf("error %w with reading oci configuration file %s", err, value)
+		}
+	}
+
+	return nil
+}
+
+// ConfigurationProvider returns the provider of the credentials of the authentication method.
+func (s Settings) ConfigurationProvider() (common.ConfigurationProvider, error) {
+	if s.InstancePrincipalAuthentication {
+		provider, err := auth.InstancePrincipalConfigurationProvider()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get oci configurationprovider based on instance principal authentication : %w", err)
+		}
+		return provider, nil
+	}
+	if s.ConfigFileAuthentication {
+		return common.CustomProfileConfigProvider(s.ConfigFilePath, s.ConfigFileProfile), nil
+	}
+
+	return common.NewRawConfigurationProvider(s.TenancyOCID, s.UserOCID, s.Region, s.FingerPrint, s.PrivateKey, nil), nil
+}
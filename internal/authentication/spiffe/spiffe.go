@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spiffe obtains the client certificates of TLS connections from the SPIFFE Workload API,
+// as exposed by the agents of SPIRE and of service meshes, and keeps them up to date as they're rotated.
+package spiffe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// Timeout for the first X.509 SVID of a Workload API endpoint.
+const defaultFetchTimeout = 30 * time.Second
+
+// Metadata is the SPIFFE configuration in the component metadata.
+type Metadata struct {
+	// Address of the Workload API, as in the SPIFFE_ENDPOINT_SOCKET environment variable: unix:///path/to/socket or tcp://ip:port.
+	EndpointSocket string `mapstructure:"spiffeEndpointSocket"`
+	// Verify the server certificate with the X.509 bundle of the trust domain, instead of the default root CAs.
+	TrustBundle bool `mapstructure:"spiffeTrustBundle"`
+	// SPIFFE ID expected in the server certificate, instead of its host name; requires spiffeTrustBundle.
+	ServerID string `mapstructure:"spiffeServerId"`
+	// Timeout for the first X.509 SVID.
+	FetchTimeout time.Duration `mapstructure:"spiffeFetchTimeout"`
+}
+
+// Config is a parsed SPIFFE configuration.
+// Once TLS is configured, it holds the connection to the Workload API, which the component closes with Close.
+type Config struct {
+	address      string
+	trustBundle  bool
+	serverID     spiffeid.ID
+	fetchTimeout time.Duration
+
+	lock   sync.Mutex
+	source *workloadapi.X509Source
+}
+
+// Parse returns the SPIFFE configuration of the component metadata, or nil if no Workload API endpoint is configured.
+func Parse(properties map[string]string) (*Config, error) {
+	m := Metadata{}
+	err := metadata.DecodeMetadata(properties, &m)
+	if err != nil {
+		return nil, err
+	}
+	if m.EndpointSocket == "" {
+		if m.TrustBundle || m.ServerID != "" {
+			return nil, errors.New("spiffeTrustBundle and spiffeServerId require a spiffeEndpointSocket")
+		}
+		return nil, nil
+	}
+
+	c := &Config{
+		address:      m.EndpointSocket,
+		trustBundle:  m.TrustBundle,
+		fetchTimeout: m.FetchTimeout,
+	}
+	if m.ServerID != "" {
+		if !m.TrustBundle {
+			return nil, errors.New("spiffeServerId requires spiffeTrustBundle")
+		}
+		c.serverID, err = spiffeid.FromString(m.ServerID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spiffeServerId '%s': %w", m.ServerID, err)
+		}
+	}
+	err = validateEndpoint(m.EndpointSocket)
+	if err != nil {
+		return nil, err
+	}
+	if c.fetchTimeout == 0 {
+		c.fetchTimeout = defaultFetchTimeout
+	}
+
+	return c, nil
+}
+
+// validateEndpoint validates a Workload API address.
+func validateEndpoint(endpoint string) error {
+	// The Workload API client takes the host of unix URLs as part of the path
+	if u, err := url.Parse(endpoint); err == nil && u.Scheme == "unix" && u.Host != "" {
+		return fmt.Errorf("invalid spiffeEndpointSocket '%s': expected unix:///path/to/socket", endpoint)
+	}
+	err := workloadapi.ValidateAddress(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid spiffeEndpointSocket '%s': %w", endpoint, err)
+	}
+	return nil
+}
+
+// ConfigureTLS makes a TLS config present the current X.509 SVID of the workload as client certificate,
+// and verify the server certificate with the current bundle of the trust domain if configured.
+// It waits for the first SVID of the Workload API.
+func (c *Config) ConfigureTLS(tlsConfig *tls.Config) error {
+	src, err := c.x509Source()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig.Certificates = nil
+	tlsConfig.GetClientCertificate = tlsconfig.GetClientCertificate(src)
+	if c.trustBundle {
+		// The bundle rotates, so the server certificate is verified with the source instead of RootCAs
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec
+		if !c.serverID.IsZero() {
+			tlsConfig.VerifyPeerCertificate = tlsconfig.VerifyPeerCertificate(src, tlsconfig.AuthorizeID(c.serverID))
+			tlsConfig.VerifyConnection = nil
+		} else {
+			tlsConfig.VerifyPeerCertificate = nil
+			tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+				return verifyServerName(cs, src)
+			}
+		}
+	}
+
+	return nil
+}
+
+// x509Source returns the source of the X.509 SVIDs, connecting to the Workload API the first time.
+func (c *Config) x509Source() (*workloadapi.X509Source, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.source != nil {
+		return c.source, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.fetchTimeout)
+	defer cancel()
+	src, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(c.address)))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch X.509 SVID from SPIFFE Workload API %s: %w", c.address, err)
+	}
+	c.source = src
+
+	return src, nil
+}
+
+// Close closes the connection to the Workload API, if TLS was configured.
+func (c *Config) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.source == nil {
+		return nil
+	}
+	err := c.source.Close()
+	c.source = nil
+	return err
+}
+
+// verifyServerName verifies the server certificate for its host name, with the bundle of the trust domain of the workload.
+func verifyServerName(cs tls.ConnectionState, src *workloadapi.X509Source) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server didn't present a certificate")
+	}
+
+	svid, err := src.GetX509SVID()
+	if err != nil {
+		return err
+	}
+	bundle, err := src.GetX509BundleForTrustDomain(svid.ID.TrustDomain())
+	if err != nil {
+		return err
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range bundle.X509Authorities() {
+		opts.Roots.AddCert(cert)
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err = cs.PeerCertificates[0].Verify(opts)
+	return err
+}
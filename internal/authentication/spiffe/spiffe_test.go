@@ -0,0 +1,315 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		c, err := Parse(map[string]string{})
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("unix socket", func(t *testing.T) {
+		c, err := Parse(map[string]string{
+			"spiffeEndpointSocket": "unix:///run/spire/sockets/agent.sock",
+			"spiffeTrustBundle":    "true",
+			"spiffeServerId":       "spiffe://example.org/redis",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "unix:///run/spire/sockets/agent.sock", c.address)
+		assert.True(t, c.trustBundle)
+		assert.Equal(t, "spiffe://example.org/redis", c.serverID.String())
+		assert.Equal(t, defaultFetchTimeout, c.fetchTimeout)
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		c, err := Parse(map[string]string{"spiffeEndpointSocket": "tcp://127.0.0.1:8081", "spiffeFetchTimeout": "5s"})
+		require.NoError(t, err)
+		assert.Equal(t, "tcp://127.0.0.1:8081", c.address)
+		assert.Equal(t, 5*time.Second, c.fetchTimeout)
+	})
+
+	invalid := []map[string]string{
+		{"spiffeTrustBundle": "true"},
+		{"spiffeEndpointSocket": "/run/spire/sockets/agent.sock"},
+		{"spiffeEndpointSocket": "unix://agent.sock"},
+		{"spiffeEndpointSocket": "tcp://127.0.0.1"},
+		{"spiffeEndpointSocket": "unix:///agent.sock", "spiffeServerId": "spiffe://example.org/redis"},
+		{"spiffeEndpointSocket": "unix:///agent.sock", "spiffeTrustBundle": "true", "spiffeServerId": "redis"},
+	}
+	for _, props := range invalid {
+		_, err := Parse(props)
+		assert.Error(t, err, props)
+	}
+}
+
+// testCA issues the certificates of a trust domain.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example.org"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a certificate with a SPIFFE ID and its PKCS8 key.
+func (ca *testCA) issue(t *testing.T, serial int64, id string, dnsName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	if dnsName != "" {
+		tmpl.DNSNames = []string{dnsName}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return der, keyDER
+}
+
+// x509SVIDResponse returns a response of the Workload API with an X.509 SVID.
+func (ca *testCA) x509SVIDResponse(id string, certDER []byte, keyDER []byte) *workload.X509SVIDResponse {
+	return &workload.X509SVIDResponse{
+		Svids: []*workload.X509SVID{{
+			SpiffeId:    id,
+			X509Svid:    certDER,
+			X509SvidKey: keyDER,
+			Bundle:      ca.cert.Raw,
+		}},
+	}
+}
+
+// fakeWorkloadAPI streams the last X.509 SVID response to each client, and then its updates.
+type fakeWorkloadAPI struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+
+	lock    sync.Mutex
+	res     *workload.X509SVIDResponse
+	updated chan struct{}
+}
+
+func (f *fakeWorkloadAPI) set(res *workload.X509SVIDResponse) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.res = res
+	close(f.updated)
+	f.updated = make(chan struct{})
+}
+
+func (f *fakeWorkloadAPI) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	md, _ := grpcMetadata.FromIncomingContext(stream.Context())
+	if len(md.Get("workload.spiffe.io")) == 0 {
+		return status.Error(codes.InvalidArgument, "missing security header")
+	}
+	for {
+		f.lock.Lock()
+		res, updated := f.res, f.updated
+		f.lock.Unlock()
+		if res != nil {
+			err := stream.Send(res)
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-updated:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// startWorkloadAPI starts a fake Workload API.
+func startWorkloadAPI(t *testing.T) (string, *fakeWorkloadAPI) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	lis, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	api := &fakeWorkloadAPI{updated: make(chan struct{})}
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, api)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return "unix://" + socket, api
+}
+
+func TestConfigureTLS(t *testing.T) {
+	ca := newTestCA(t)
+	endpoint, api := startWorkloadAPI(t)
+
+	certDER, keyDER := ca.issue(t, 2, "spiffe://example.org/app", "")
+	api.set(ca.x509SVIDResponse("spiffe://example.org/app", certDER, keyDER))
+
+	serverDER, serverKeyDER := ca.issue(t, 3, "spiffe://example.org/redis", "redis.local")
+	serverKey, err := x509.ParsePKCS8PrivateKey(serverKeyDER)
+	require.NoError(t, err)
+	serverCert := tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	// handshake connects to a TLS server requiring client certificates of the trust domain, and returns the client certificate
+	handshake := func(clientConfig *tls.Config) (*x509.Certificate, error) {
+		clientConn, serverConn := net.Pipe()
+		defer clientConn.Close()
+		defer serverConn.Close()
+
+		server := tls.Server(serverConn, &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			MinVersion:   tls.VersionTLS12,
+		})
+		serverErr := make(chan error, 1)
+		go func() {
+			serverErr <- server.Handshake()
+		}()
+
+		err := tls.Client(clientConn, clientConfig).Handshake()
+		if err != nil {
+			return nil, err
+		}
+		err = <-serverErr
+		if err != nil {
+			return nil, err
+		}
+		return server.ConnectionState().PeerCertificates[0], nil
+	}
+
+	newConfig := func(props map[string]string) *tls.Config {
+		props["spiffeEndpointSocket"] = endpoint
+		c, err := Parse(props)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+		})
+		tlsConfig := &tls.Config{ServerName: "redis.local", MinVersion: tls.VersionTLS12}
+		require.NoError(t, c.ConfigureTLS(tlsConfig))
+		return tlsConfig
+	}
+
+	t.Run("trust bundle", func(t *testing.T) {
+		cert, err := handshake(newConfig(map[string]string{"spiffeTrustBundle": "true"}))
+		require.NoError(t, err)
+		assert.Equal(t, "spiffe://example.org/app", cert.URIs[0].String())
+	})
+
+	t.Run("server ID", func(t *testing.T) {
+		tlsConfig := newConfig(map[string]string{"spiffeTrustBundle": "true", "spiffeServerId": "spiffe://example.org/redis"})
+		tlsConfig.ServerName = "other.local"
+		_, err := handshake(tlsConfig)
+		require.NoError(t, err)
+
+		tlsConfig = newConfig(map[string]string{"spiffeTrustBundle": "true", "spiffeServerId": "spiffe://example.org/kafka"})
+		_, err = handshake(tlsConfig)
+		assert.ErrorContains(t, err, `unexpected ID "spiffe://example.org/redis"`)
+	})
+
+	t.Run("default root CAs", func(t *testing.T) {
+		_, err := handshake(newConfig(map[string]string{}))
+		assert.Error(t, err)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		tlsConfig := newConfig(map[string]string{"spiffeTrustBundle": "true"})
+
+		rotatedDER, rotatedKeyDER := ca.issue(t, 4, "spiffe://example.org/app", "")
+		api.set(ca.x509SVIDResponse("spiffe://example.org/app", rotatedDER, rotatedKeyDER))
+		assert.Eventually(t, func() bool {
+			cert, err := handshake(tlsConfig)
+			return err == nil && cert.SerialNumber.Int64() == 4
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestClose(t *testing.T) {
+	ca := newTestCA(t)
+	endpoint, api := startWorkloadAPI(t)
+	certDER, keyDER := ca.issue(t, 2, "spiffe://example.org/app", "")
+	api.set(ca.x509SVIDResponse("spiffe://example.org/app", certDER, keyDER))
+
+	c, err := Parse(map[string]string{"spiffeEndpointSocket": endpoint})
+	require.NoError(t, err)
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	require.NoError(t, c.ConfigureTLS(tlsConfig))
+	cert, err := tlsConfig.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, cert.Certificate)
+
+	require.NoError(t, c.Close())
+	_, err = tlsConfig.GetClientCertificate(nil)
+	assert.Error(t, err)
+	assert.NoError(t, c.Close())
+
+	var unconfigured *Config
+	assert.NoError(t, unconfigured.Close())
+}
+
+func TestConfigureTLSTimeout(t *testing.T) {
+	endpoint, _ := startWorkloadAPI(t)
+	c, err := Parse(map[string]string{"spiffeEndpointSocket": endpoint, "spiffeFetchTimeout": "100ms"})
+	require.NoError(t, err)
+	err = c.ConfigureTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	assert.ErrorContains(t, err, "couldn't fetch X.509 SVID")
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
 
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
 	mdutils "github.com/dapr/components-contrib/metadata"
@@ -35,17 +36,28 @@ const (
 	defaultBlobRetryCount = 3
 )
 
+// CreateContainerStorageClient returns a client of the blob container of the metadata, and creates the container if it doesn't exist.
 func CreateContainerStorageClient(log logger.Logger, meta map[string]string) (*container.Client, *BlobStorageMetadata, error) {
+	_, client, m, err := CreateStorageClients(log, meta)
+	return client, m, err
+}
+
+// CreateStorageClients returns a client of the storage account and a client of the blob container of the metadata,
+// and creates the container if it doesn't exist.
+// The client of the account is needed by the operations on all the containers, like finding blobs by tags.
+func CreateStorageClients(log logger.Logger, meta map[string]string) (*service.Client, *container.Client, *BlobStorageMetadata, error) {
 	m, err := parseMetadata(meta)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	userAgent := "dapr-" + logger.DaprVersion
-	options := container.ClientOptions{
+	options := service.ClientOptions{
 		ClientOptions: azcore.ClientOptions{
 			Retry: policy.RetryOptions{
-				MaxRetries: m.RetryCount,
+				MaxRetries:    m.RetryCount,
+				RetryDelay:    time.Duration(m.RetryIntervalMs) * time.Millisecond,
+				MaxRetryDelay: time.Duration(m.MaxRetryDelayMs) * time.Millisecond,
 			},
 			Telemetry: policy.TelemetryOptions{
 				ApplicationID: userAgent,
@@ -55,7 +67,7 @@ func CreateContainerStorageClient(log logger.Logger, meta map[string]string) (*c
 
 	settings, err := azauth.NewEnvironmentSettings("storage", meta)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	var customEndpoint string
 	if val, ok := mdutils.GetMetadataProperty(meta, azauth.StorageEndpointKeys...); ok && val != "" {
@@ -64,38 +76,38 @@ func CreateContainerStorageClient(log logger.Logger, meta map[string]string) (*c
 	var URL *url.URL
 	if customEndpoint != "" {
 		var parseErr error
-		URL, parseErr = url.Parse(fmt.Sprintf("%s/%s/%s", customEndpoint, m.AccountName, m.ContainerName))
+		URL, parseErr = url.Parse(fmt.Sprintf("%s/%s", customEndpoint, m.AccountName))
 		if parseErr != nil {
-			return nil, nil, parseErr
+			return nil, nil, nil, parseErr
 		}
 	} else {
 		env := settings.AzureEnvironment
-		URL, _ = url.Parse(fmt.Sprintf("https://%s.blob.%s/%s", m.AccountName, env.StorageEndpointSuffix, m.ContainerName))
+		URL, _ = url.Parse(fmt.Sprintf("https://%s.blob.%s", m.AccountName, env.StorageEndpointSuffix))
 	}
 
 	var clientErr error
-	var client *container.Client
+	var serviceClient *service.Client
 	// Try using shared key credentials first
 	if m.AccountKey != "" {
 		credential, newSharedKeyErr := azblob.NewSharedKeyCredential(m.AccountName, m.AccountKey)
-		if err != nil {
-			return nil, nil, fmt.Errorf("invalid shared key credentials with error: %w", newSharedKeyErr)
-		}
-		client, clientErr = container.NewClientWithSharedKeyCredential(URL.String(), credential, &options)
-		if clientErr != nil {
-			return nil, nil, fmt.Errorf("cannot init Blobstorage container client: %w", err)
+		if newSharedKeyErr != nil {
+			return nil, nil, nil, fmt.Errorf("invalid shared key credentials with error: %w", newSharedKeyErr)
 		}
+		serviceClient, clientErr = service.NewClientWithSharedKeyCredential(URL.String(), credential, &options)
 	} else {
 		// fallback to AAD
-		credential, tokenErr := settings.GetTokenCredential()
-		if err != nil {
-			return nil, nil, fmt.Errorf("invalid token credentials with error: %w", tokenErr)
+		// The credential is re-created when it stops working, so the tokens are renewed after the identity is rotated
+		credential, tokenErr := newRefreshingCredential(settings.GetTokenCredential, log)
+		if tokenErr != nil {
+			return nil, nil, nil, fmt.Errorf("invalid token credentials with error: %w", tokenErr)
 		}
-		client, clientErr = container.NewClient(URL.String(), credential, &options)
+		options.PerRetryPolicies = append(options.PerRetryPolicies, newTokenRefreshPolicy(credential))
+		serviceClient, clientErr = service.NewClientWithNoCredential(URL.String(), &options)
 	}
 	if clientErr != nil {
-		return nil, nil, fmt.Errorf("cannot init Blobstorage client: %w", clientErr)
+		return nil, nil, nil, fmt.Errorf("cannot init Blobstorage client: %w", clientErr)
 	}
+	client := serviceClient.NewContainerClient(m.ContainerName)
 
 	createContainerOptions := container.CreateOptions{
 		Access:   &m.PublicAccessLevel,
@@ -107,5 +119,5 @@ func CreateContainerStorageClient(log logger.Logger, meta map[string]string) (*c
 	// Don't return error, container might already exist
 	log.Debugf("error creating container: %v", err)
 
-	return client, m, nil
+	return serviceClient, client, m, nil
 }
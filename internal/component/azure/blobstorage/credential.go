@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// Scope of the Azure AD tokens for Azure Storage.
+	storageTokenScope = "https://storage.azure.com/.default"
+
+	// Tokens are refreshed this long before they expire, so requests never use an expired token.
+	tokenRefreshWindow = 5 * time.Minute
+
+	// Minimum time between two re-creations of the credential, so a misconfigured identity doesn't flood Azure AD.
+	minCredentialRefreshInterval = 30 * time.Second
+)
+
+// refreshingCredential is a credential that is re-created when it fails to get a token, such as after the workload
+// identity token file or the managed identity was rotated, so that long-lived clients don't keep a broken credential.
+type refreshingCredential struct {
+	newCredential func() (azcore.TokenCredential, error)
+	logger        logger.Logger
+
+	lock        sync.Mutex
+	credential  azcore.TokenCredential
+	refreshedAt time.Time
+}
+
+func newRefreshingCredential(newCredential func() (azcore.TokenCredential, error), logger logger.Logger) (*refreshingCredential, error) {
+	credential, err := newCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	return &refreshingCredential{
+		newCredential: newCredential,
+		logger:        logger,
+		credential:    credential,
+		refreshedAt:   time.Now(),
+	}, nil
+}
+
+// GetToken returns a token of the credential. If it fails, the credential is re-created and the token requested again.
+func (c *refreshingCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	credential := c.current()
+	token, err := credential.GetToken(ctx, opts)
+	if err == nil {
+		return token, nil
+	}
+
+	refreshed, rerr := c.refresh(credential)
+	if rerr != nil {
+		c.logger.Warnf("Error re-creating the Azure AD credential: %v", rerr)
+		return token, err
+	}
+
+	return refreshed.GetToken(ctx, opts)
+}
+
+func (c *refreshingCredential) current() azcore.TokenCredential {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.credential
+}
+
+// refresh re-creates the credential, unless it was already re-created since the failed credential was returned,
+// or less than minCredentialRefreshInterval ago.
+func (c *refreshingCredential) refresh(failed azcore.TokenCredential) (azcore.TokenCredential, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.credential != failed {
+		return c.credential, nil
+	}
+	if time.Since(c.refreshedAt) < minCredentialRefreshInterval {
+		return nil, errors.New("credential was re-created recently")
+	}
+
+	c.logger.Info("Re-creating the Azure AD credential")
+	credential, err := c.newCredential()
+	if err != nil {
+		return nil, err
+	}
+	c.credential = credential
+	c.refreshedAt = time.Now()
+
+	return credential, nil
+}
+
+// tokenRefreshPolicy authenticates requests with the tokens of a refreshingCredential.
+// Unlike the bearer token policy of the SDK, which keeps using a cached token until it expires, it discards the token
+// and re-creates the credential when Azure Storage rejects the token, then sends the request again once.
+type tokenRefreshPolicy struct {
+	credential *refreshingCredential
+
+	lock  sync.Mutex
+	token *azcore.AccessToken
+}
+
+func newTokenRefreshPolicy(credential *refreshingCredential) *tokenRefreshPolicy {
+	return &tokenRefreshPolicy{
+		credential: credential,
+	}
+}
+
+// Do implements policy.Policy.
+func (p *tokenRefreshPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if req.Raw().URL.Scheme != "https" {
+		return nil, errors.New("authenticated requests are not permitted for non TLS protected (https) endpoints")
+	}
+
+	token, err := p.getToken(req.Raw().Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	req.Raw().Header.Set("Authorization", "Bearer "+token)
+
+	res, err := req.Next()
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// The token was rejected, for example because the identity was rotated: get a new token and try again
+	token, err = p.getToken(req.Raw().Context(), true)
+	if err != nil {
+		return res, nil
+	}
+	if err = req.RewindBody(); err != nil {
+		return res, nil
+	}
+	res.Body.Close()
+	req.Raw().Header.Set("Authorization", "Bearer "+token)
+
+	return req.Next()
+}
+
+// getToken returns the cached token, unless it's about to expire or a new token is required.
+func (p *tokenRefreshPolicy) getToken(ctx context.Context, renew bool) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if renew {
+		p.token = nil
+		// The credential may keep returning the rejected token from its own cache, so it's re-created
+		if _, err := p.credential.refresh(p.credential.current()); err != nil {
+			p.credential.logger.Debugf("Azure AD credential not re-created: %v", err)
+		}
+	}
+	if p.token != nil && time.Until(p.token.ExpiresOn) > tokenRefreshWindow {
+		return p.token.Token, nil
+	}
+
+	token, err := p.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{storageTokenScope}})
+	if err != nil {
+		return "", fmt.Errorf("error getting Azure AD token: %w", err)
+	}
+	p.token = &token
+
+	return token.Token, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+// fakeCredential returns the token of the generation of the credential, or fails if it's broken.
+type fakeCredential struct {
+	generation int
+	broken     bool
+}
+
+func (c *fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if c.broken {
+		return azcore.AccessToken{}, errors.New("token file not found")
+	}
+	return azcore.AccessToken{Token: "token" + strconv.Itoa(c.generation), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// fakeTransport accepts the requests with the valid token.
+type fakeTransport struct {
+	validToken string
+	bodies     []string
+}
+
+func (t *fakeTransport) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		t.bodies = append(t.bodies, string(body))
+	}
+	status := http.StatusOK
+	if req.Header.Get("Authorization") != "Bearer "+t.validToken {
+		status = http.StatusUnauthorized
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func newTestRefreshingCredential(t *testing.T, brokenGenerations ...int) (*refreshingCredential, *int) {
+	t.Helper()
+	created := 0
+	cred, err := newRefreshingCredential(func() (azcore.TokenCredential, error) {
+		created++
+		c := &fakeCredential{generation: created}
+		for _, g := range brokenGenerations {
+			c.broken = c.broken || g == created
+		}
+		return c, nil
+	}, logger.NewLogger("test"))
+	require.NoError(t, err)
+	// Allows re-creating the credential right away
+	cred.refreshedAt = time.Time{}
+
+	return cred, &created
+}
+
+func TestRefreshingCredential(t *testing.T) {
+	t.Run("uses the credential while it works", func(t *testing.T) {
+		cred, created := newTestRefreshingCredential(t)
+
+		token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "token1", token.Token)
+		assert.Equal(t, 1, *created)
+	})
+
+	t.Run("re-creates the credential when it fails", func(t *testing.T) {
+		cred, created := newTestRefreshingCredential(t, 1)
+
+		token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "token2", token.Token)
+		assert.Equal(t, 2, *created)
+	})
+
+	t.Run("doesn't re-create the credential too often", func(t *testing.T) {
+		cred, created := newTestRefreshingCredential(t, 1, 2)
+
+		_, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+		assert.Error(t, err)
+		_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+		assert.Error(t, err)
+		assert.Equal(t, 2, *created)
+	})
+
+	t.Run("initial credential error", func(t *testing.T) {
+		_, err := newRefreshingCredential(func() (azcore.TokenCredential, error) {
+			return nil, errors.New("no identity")
+		}, logger.NewLogger("test"))
+		assert.Error(t, err)
+	})
+}
+
+func TestTokenRefreshPolicy(t *testing.T) {
+	newPipeline := func(cred *refreshingCredential, transport *fakeTransport) runtime.Pipeline {
+		return runtime.NewPipeline("test", "v1", runtime.PipelineOptions{
+			PerRetry: []policy.Policy{newTokenRefreshPolicy(cred)},
+		}, &policy.ClientOptions{
+			Transport: transport,
+			Retry:     policy.RetryOptions{MaxRetries: -1},
+		})
+	}
+
+	t.Run("caches the token", func(t *testing.T) {
+		cred, created := newTestRefreshingCredential(t)
+		pl := newPipeline(cred, &fakeTransport{validToken: "token1"})
+
+		for i := 0; i < 2; i++ {
+			req, err := runtime.NewRequest(context.Background(), http.MethodGet, "https://acc.blob.core.windows.net/c/b")
+			require.NoError(t, err)
+			res, err := pl.Do(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		}
+		assert.Equal(t, 1, *created)
+	})
+
+	t.Run("renews the rejected token and sends the request again", func(t *testing.T) {
+		cred, created := newTestRefreshingCredential(t)
+		transport := &fakeTransport{validToken: "token2"}
+		pl := newPipeline(cred, transport)
+
+		req, err := runtime.NewRequest(context.Background(), http.MethodPut, "https://acc.blob.core.windows.net/c/b")
+		require.NoError(t, err)
+		require.NoError(t, req.SetBody(streaming.NopCloser(strings.NewReader("data")), "text/plain"))
+		res, err := pl.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, *created)
+		assert.Equal(t, []string{"data", "data"}, transport.bodies)
+	})
+
+	t.Run("returns the response if the token is still rejected", func(t *testing.T) {
+		cred, _ := newTestRefreshingCredential(t)
+		pl := newPipeline(cred, &fakeTransport{validToken: "other"})
+
+		req, err := runtime.NewRequest(context.Background(), http.MethodGet, "https://acc.blob.core.windows.net/c/b")
+		require.NoError(t, err)
+		res, err := pl.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("requires https", func(t *testing.T) {
+		cred, _ := newTestRefreshingCredential(t)
+		pl := newPipeline(cred, &fakeTransport{validToken: "token1"})
+
+		req, err := runtime.NewRequest(context.Background(), http.MethodGet, "http://acc.blob.core.windows.net/c/b")
+		require.NoError(t, err)
+		_, err = pl.Do(req)
+		assert.Error(t, err)
+	})
+}
@@ -28,6 +28,8 @@ type BlobStorageMetadata struct {
 	AccountKey        string
 	ContainerName     string
 	RetryCount        int32 `json:"retryCount,string"`
+	RetryIntervalMs   int64 `json:"retryIntervalMs,string"`
+	MaxRetryDelayMs   int64 `json:"maxRetryDelayMs,string"`
 	DecodeBase64      bool  `json:"decodeBase64,string"`
 	PublicAccessLevel azblob.PublicAccessType
 }
@@ -73,6 +75,17 @@ func parseMetadata(meta map[string]string) (*BlobStorageMetadata, error) {
 		m.RetryCount = int32(parseInt)
 	}
 
+	// A zero interval or delay keeps the default of the SDK
+	if m.RetryIntervalMs < 0 {
+		return nil, fmt.Errorf("invalid retryIntervalMs %d: must not be negative", m.RetryIntervalMs)
+	}
+	if m.MaxRetryDelayMs < 0 {
+		return nil, fmt.Errorf("invalid maxRetryDelayMs %d: must not be negative", m.MaxRetryDelayMs)
+	}
+	if m.MaxRetryDelayMs > 0 && m.RetryIntervalMs > m.MaxRetryDelayMs {
+		return nil, fmt.Errorf("invalid retryIntervalMs %d: must not be greater than maxRetryDelayMs %d", m.RetryIntervalMs, m.MaxRetryDelayMs)
+	}
+
 	return &m, nil
 }
 
@@ -66,6 +66,48 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, azblob.PublicAccessTypeContainer, meta.PublicAccessLevel)
 	})
 
+	t.Run("parse metadata with retry options", func(t *testing.T) {
+		m = map[string]string{
+			"storageAccount":  "account",
+			"container":       "test",
+			"retryCount":      "7",
+			"retryIntervalMs": "200",
+			"maxRetryDelayMs": "5000",
+		}
+		meta, err := parseMetadata(m)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(7), meta.RetryCount)
+		assert.Equal(t, int64(200), meta.RetryIntervalMs)
+		assert.Equal(t, int64(5000), meta.MaxRetryDelayMs)
+	})
+
+	t.Run("parse metadata with default retry options", func(t *testing.T) {
+		m = map[string]string{
+			"storageAccount": "account",
+			"container":      "test",
+		}
+		meta, err := parseMetadata(m)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(defaultBlobRetryCount), meta.RetryCount)
+		assert.Equal(t, int64(0), meta.RetryIntervalMs)
+		assert.Equal(t, int64(0), meta.MaxRetryDelayMs)
+	})
+
+	t.Run("parse metadata with invalid retry options", func(t *testing.T) {
+		m = map[string]string{
+			"storageAccount":  "account",
+			"container":       "test",
+			"retryIntervalMs": "-1",
+		}
+		_, err := parseMetadata(m)
+		assert.Error(t, err)
+
+		m["retryIntervalMs"] = "10000"
+		m["maxRetryDelayMs"] = "5000"
+		_, err = parseMetadata(m)
+		assert.Error(t, err)
+	})
+
 	t.Run("parse metadata with invalid publicAccessLevel", func(t *testing.T) {
 		m = map[string]string{
 			"storageAccount":    "account",
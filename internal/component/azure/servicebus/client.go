@@ -261,7 +261,9 @@ func (c *Client) createTopic(parentCtx context.Context, topic string) error {
 	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
 	defer cancel()
 
-	_, err := c.adminClient.CreateTopic(ctx, topic, nil)
+	_, err := c.adminClient.CreateTopic(ctx, topic, &sbadmin.CreateTopicOptions{
+		Properties: c.metadata.CreateTopicProperties(),
+	})
 	if err != nil {
 		return fmt.Errorf("could not create topic %s: %w", topic, err)
 	}
@@ -45,6 +45,9 @@ type Metadata struct {
 	LockDurationInSec               *int   `json:"lockDurationInSec"`             // Only used during subscription creation - default is set by the server (60s)
 	DefaultMessageTimeToLiveInSec   *int   `json:"defaultMessageTimeToLiveInSec"` // Only used during subscription creation - default is set by the server (depends on the tier)
 	AutoDeleteOnIdleInSec           *int   `json:"autoDeleteOnIdleInSec"`         // Only used during subscription creation - default is set by the server (disabled)
+	MaxSizeInMegabytes              *int32 `json:"maxSizeInMegabytes"`            // Only used during topic and queue creation - default is set by the server (1024)
+	DuplicateDetectionWindowInSec   *int   `json:"duplicateDetectionWindowInSec"` // Only used during topic and queue creation - default is set by the server (disabled)
+	EnablePartitioning              *bool  `json:"enablePartitioning"`            // Only used during topic and queue creation - default is set by the server (false)
 	MaxConcurrentHandlers           int    `json:"maxConcurrentHandlers"`
 	PublishMaxRetries               int    `json:"publishMaxRetries"`
 	PublishInitialRetryIntervalInMs int    `json:"publishInitialRetryInternalInMs"`
@@ -70,6 +73,9 @@ const (
 	keyLockDurationInSec               = "lockDurationInSec"
 	keyDefaultMessageTimeToLiveInSec   = "defaultMessageTimeToLiveInSec" // Alias: "ttlInSeconds" (mdutils.TTLMetadataKey)
 	keyAutoDeleteOnIdleInSec           = "autoDeleteOnIdleInSec"
+	keyMaxSizeInMegabytes              = "maxSizeInMegabytes"
+	keyDuplicateDetectionWindowInSec   = "duplicateDetectionWindowInSec"
+	keyEnablePartitioning              = "enablePartitioning"
 	keyMaxConcurrentHandlers           = "maxConcurrentHandlers"
 	keyPublishMaxRetries               = "publishMaxRetries"
 	keyPublishInitialRetryInternalInMs = "publishInitialRetryInternalInMs"
@@ -306,6 +312,34 @@ func ParseMetadata(md map[string]string, logger logger.Logger, mode byte) (m *Me
 		m.AutoDeleteOnIdleInSec = &valAsInt
 	}
 
+	if val, ok := md[keyMaxSizeInMegabytes]; ok && val != "" {
+		var valAsInt int64
+		valAsInt, err = strconv.ParseInt(val, 10, 32)
+		if err == nil && valAsInt < 1 {
+			err = errors.New("must be 1 or greater")
+		}
+		if err != nil {
+			return m, fmt.Errorf("invalid maxSizeInMegabytes %s: %s", val, err)
+		}
+		m.MaxSizeInMegabytes = ptr.Of(int32(valAsInt))
+	}
+
+	if val, ok := md[keyDuplicateDetectionWindowInSec]; ok && val != "" {
+		var valAsInt int
+		valAsInt, err = strconv.Atoi(val)
+		if err == nil && valAsInt < 1 {
+			err = errors.New("must be 1 or greater")
+		}
+		if err != nil {
+			return m, fmt.Errorf("invalid duplicateDetectionWindowInSec %s: %s", val, err)
+		}
+		m.DuplicateDetectionWindowInSec = &valAsInt
+	}
+
+	if val, ok := md[keyEnablePartitioning]; ok && val != "" {
+		m.EnablePartitioning = ptr.Of(utils.IsTruthy(val))
+	}
+
 	return m, nil
 }
 
@@ -352,6 +386,47 @@ func (a Metadata) CreateQueueProperties() *sbadmin.QueueProperties {
 		properties.AutoDeleteOnIdle = toDurationISOString(*a.AutoDeleteOnIdleInSec)
 	}
 
+	if a.MaxSizeInMegabytes != nil {
+		properties.MaxSizeInMegabytes = a.MaxSizeInMegabytes
+	}
+
+	if a.DuplicateDetectionWindowInSec != nil {
+		properties.RequiresDuplicateDetection = ptr.Of(true)
+		properties.DuplicateDetectionHistoryTimeWindow = toDurationISOString(*a.DuplicateDetectionWindowInSec)
+	}
+
+	if a.EnablePartitioning != nil {
+		properties.EnablePartitioning = a.EnablePartitioning
+	}
+
+	return properties
+}
+
+// CreateTopicProperties returns the TopicProperties object to create new Topics in Service Bus.
+func (a Metadata) CreateTopicProperties() *sbadmin.TopicProperties {
+	properties := &sbadmin.TopicProperties{}
+
+	if a.DefaultMessageTimeToLiveInSec != nil {
+		properties.DefaultMessageTimeToLive = toDurationISOString(*a.DefaultMessageTimeToLiveInSec)
+	}
+
+	if a.AutoDeleteOnIdleInSec != nil {
+		properties.AutoDeleteOnIdle = toDurationISOString(*a.AutoDeleteOnIdleInSec)
+	}
+
+	if a.MaxSizeInMegabytes != nil {
+		properties.MaxSizeInMegabytes = a.MaxSizeInMegabytes
+	}
+
+	if a.DuplicateDetectionWindowInSec != nil {
+		properties.RequiresDuplicateDetection = ptr.Of(true)
+		properties.DuplicateDetectionHistoryTimeWindow = toDurationISOString(*a.DuplicateDetectionWindowInSec)
+	}
+
+	if a.EnablePartitioning != nil {
+		properties.EnablePartitioning = a.EnablePartitioning
+	}
+
 	return properties
 }
 
@@ -559,4 +559,83 @@ func TestParseServiceBusMetadata(t *testing.T) {
 		// assert.
 		assert.Error(t, err)
 	})
+
+	t.Run("entity creation properties", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyMaxSizeInMegabytes] = "2048"
+		fakeProperties[keyDuplicateDetectionWindowInSec] = "300"
+		fakeProperties[keyEnablePartitioning] = "true"
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2048), *m.MaxSizeInMegabytes)
+		assert.Equal(t, 300, *m.DuplicateDetectionWindowInSec)
+		assert.True(t, *m.EnablePartitioning)
+	})
+
+	t.Run("missing nullable entity creation properties", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Nil(t, m.MaxSizeInMegabytes)
+		assert.Nil(t, m.DuplicateDetectionWindowInSec)
+		assert.Nil(t, m.EnablePartitioning)
+	})
+
+	t.Run("invalid nullable maxSizeInMegabytes", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyMaxSizeInMegabytes] = "0"
+
+		// act.
+		_, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid nullable duplicateDetectionWindowInSec", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyDuplicateDetectionWindowInSec] = invalidNumber
+
+		// act.
+		_, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateEntityProperties(t *testing.T) {
+	fakeProperties := getFakeProperties()
+	fakeProperties[keyMaxSizeInMegabytes] = "2048"
+	fakeProperties[keyDuplicateDetectionWindowInSec] = "300"
+	fakeProperties[keyEnablePartitioning] = "true"
+	m, err := ParseMetadata(fakeProperties, nil, 0)
+	assert.NoError(t, err)
+
+	topic := m.CreateTopicProperties()
+	assert.Equal(t, "PT40M", *topic.DefaultMessageTimeToLive)
+	assert.Equal(t, "PT4M", *topic.AutoDeleteOnIdle)
+	assert.Equal(t, int32(2048), *topic.MaxSizeInMegabytes)
+	assert.True(t, *topic.RequiresDuplicateDetection)
+	assert.Equal(t, "PT5M", *topic.DuplicateDetectionHistoryTimeWindow)
+	assert.True(t, *topic.EnablePartitioning)
+
+	queue := m.CreateQueueProperties()
+	assert.Equal(t, int32(2048), *queue.MaxSizeInMegabytes)
+	assert.True(t, *queue.RequiresDuplicateDetection)
+	assert.Equal(t, "PT5M", *queue.DuplicateDetectionHistoryTimeWindow)
+	assert.True(t, *queue.EnablePartitioning)
+
+	// Duplicate detection is left to the server when the window isn't set
+	m.DuplicateDetectionWindowInSec = nil
+	assert.Nil(t, m.CreateTopicProperties().RequiresDuplicateDetection)
+	assert.Nil(t, m.CreateQueueProperties().RequiresDuplicateDetection)
 }
@@ -23,6 +23,7 @@ import (
 	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
 	"go.uber.org/ratelimit"
 
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
 	"github.com/dapr/kit/retry"
@@ -42,12 +43,15 @@ type Subscription struct {
 	entity               string
 	mu                   sync.RWMutex
 	activeMessages       map[int64]*azservicebus.ReceivedMessage
+	deferredMessages     map[int64]struct{}
 	activeOperationsChan chan struct{}
 	receiver             *azservicebus.Receiver
 	timeout              time.Duration
 	maxBulkSubCount      int
 	retriableErrLimit    ratelimit.Limiter
 	handleChan           chan struct{}
+	pauseGate            *pubsub.PauseGate
+	pauseTopic           string
 	logger               logger.Logger
 	ctx                  context.Context
 	cancel               context.CancelFunc
@@ -83,13 +87,14 @@ func NewSubscription(
 	}
 
 	s := &Subscription{
-		entity:          entity,
-		activeMessages:  make(map[int64]*azservicebus.ReceivedMessage),
-		timeout:         time.Duration(timeoutInSec) * time.Second,
-		maxBulkSubCount: *maxBulkSubCount,
-		logger:          logger,
-		ctx:             ctx,
-		cancel:          cancel,
+		entity:           entity,
+		activeMessages:   make(map[int64]*azservicebus.ReceivedMessage),
+		deferredMessages: make(map[int64]struct{}),
+		timeout:          time.Duration(timeoutInSec) * time.Second,
+		maxBulkSubCount:  *maxBulkSubCount,
+		logger:           logger,
+		ctx:              ctx,
+		cancel:           cancel,
 		// This is a pessimistic estimate of the number of total operations that can be active at any given time.
 		// In case of a non-bulk subscription, one operation is one message.
 		activeOperationsChan: make(chan struct{}, maxActiveMessages/(*maxBulkSubCount)),
@@ -139,6 +144,12 @@ func (s *Subscription) Connect(newReceiverFunc func() (*azservicebus.Receiver, e
 	return err
 }
 
+// SetPauseGate makes the subscription stop receiving messages while the topic is paused in the gate.
+func (s *Subscription) SetPauseGate(gate *pubsub.PauseGate, topic string) {
+	s.pauseGate = gate
+	s.pauseTopic = topic
+}
+
 // ReceiveAndBlock is a blocking call to receive messages on an Azure Service Bus subscription from a topic or queue.
 func (s *Subscription) ReceiveAndBlock(handler HandlerFunc, lockRenewalInSec int, bulkEnabled bool, onFirstSuccess func()) error {
 	ctx, cancel := context.WithCancel(s.ctx)
@@ -166,6 +177,12 @@ func (s *Subscription) ReceiveAndBlock(handler HandlerFunc, lockRenewalInSec int
 
 	// Receiver loop
 	for {
+		// Stop receiving while the subscription is paused, leaving the messages in Service Bus
+		if err := s.pauseGate.Wait(ctx, s.pauseTopic); err != nil {
+			s.logger.Debugf("Receive context for %s done", s.entity)
+			return err
+		}
+
 		select {
 		case s.activeOperationsChan <- struct{}{}:
 			// No-op
@@ -238,6 +255,12 @@ func (s *Subscription) ReceiveAndBlock(handler HandlerFunc, lockRenewalInSec int
 			finalizeCtx, finalizeCancel := context.WithTimeout(context.Background(), s.timeout)
 			defer finalizeCancel()
 
+			// Deferred messages are settled already
+			if s.isDeferred(*msg.SequenceNumber) {
+				s.logger.Debugf("Message %s on %s was deferred", msg.MessageID, s.entity)
+				return
+			}
+
 			if err != nil {
 				// Log the error only, as we're running asynchronously
 				s.logger.Errorf("App handler returned an error for message %s on %s: %s", msg.MessageID, s.entity, err)
@@ -261,6 +284,9 @@ func (s *Subscription) ReceiveAndBlock(handler HandlerFunc, lockRenewalInSec int
 				// Handle the error and mark messages accordingly.
 				// Note, the order of the responses match the order of the messages.
 				for i, resp := range resps {
+					if s.isDeferred(*msgs[i].SequenceNumber) {
+						continue
+					}
 					if resp.Error != nil {
 						// Log the error only, as we're running asynchronously.
 						s.logger.Errorf("App handler returned an error for message %s on %s: %s", msgs[i].MessageID, s.entity, resp.Error)
@@ -274,6 +300,9 @@ func (s *Subscription) ReceiveAndBlock(handler HandlerFunc, lockRenewalInSec int
 
 			// No error, so we can complete all messages.
 			for _, msg := range msgs {
+				if s.isDeferred(*msg.SequenceNumber) {
+					continue
+				}
 				s.CompleteMessage(finalizeCtx, msg)
 			}
 		}
@@ -413,6 +442,35 @@ func (s *Subscription) CompleteMessage(ctx context.Context, m *azservicebus.Rece
 	}
 }
 
+// DeferMessage defers a message being processed, identified by its sequence number, so it can be retrieved later with ReceiveDeferredMessages.
+// Deferred messages are neither completed nor abandoned when the handler returns.
+func (s *Subscription) DeferMessage(ctx context.Context, sequenceNumber int64) error {
+	s.mu.RLock()
+	m, ok := s.activeMessages[sequenceNumber]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("message with sequence number %d is not being processed on %s", sequenceNumber, s.entity)
+	}
+
+	s.logger.Debugf("Deferring message %s on %s", m.MessageID, s.entity)
+	err := s.receiver.DeferMessage(ctx, m, nil)
+	if err != nil {
+		return fmt.Errorf("error deferring message %s on %s: %w", m.MessageID, s.entity, err)
+	}
+
+	s.mu.Lock()
+	s.deferredMessages[sequenceNumber] = struct{}{}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Subscription) isDeferred(sequenceNumber int64) bool {
+	s.mu.RLock()
+	_, ok := s.deferredMessages[sequenceNumber]
+	s.mu.RUnlock()
+	return ok
+}
+
 func (s *Subscription) addActiveMessage(m *azservicebus.ReceivedMessage) error {
 	if m.SequenceNumber == nil {
 		return fmt.Errorf("message sequence number is nil")
@@ -428,5 +486,6 @@ func (s *Subscription) removeActiveMessage(messageID string, messageKey int64) {
 	s.logger.Debugf("Removing message %s with sequence number %d from active messages on %s", messageID, messageKey, s.entity)
 	s.mu.Lock()
 	delete(s.activeMessages, messageKey)
+	delete(s.deferredMessages, messageKey)
 	s.mu.Unlock()
 }
@@ -74,3 +74,24 @@ func TestNewSubscription(t *testing.T) {
 		})
 	}
 }
+
+func TestDeferInactiveMessage(t *testing.T) {
+	sub := NewSubscription(
+		context.Background(),
+		1000,
+		1,
+		nil,
+		10,
+		100,
+		"test",
+		logger.NewLogger("test"),
+	)
+
+	err := sub.DeferMessage(context.Background(), 1)
+	if err == nil {
+		t.Error("Expected an error deferring a message that isn't being processed")
+	}
+	if sub.isDeferred(1) {
+		t.Error("Expected message not to be deferred")
+	}
+}
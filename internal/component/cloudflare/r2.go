@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+)
+
+// ErrR2ObjectNotFound is returned when an object doesn't exist in the bucket.
+var ErrR2ObjectNotFound = errors.New("object not found")
+
+// R2 is a bucket of Cloudflare R2, accessed through its S3-compatible API.
+type R2 struct {
+	client *s3.S3
+	bucket string
+}
+
+// R2Object is an object listed in a bucket.
+type R2Object struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"lastModified"`
+}
+
+// R2Endpoint returns the endpoint of the S3-compatible API of R2 for an account.
+func R2Endpoint(accountID string) string {
+	return "https://" + accountID + ".r2.cloudflarestorage.com"
+}
+
+// NewR2 returns a client of a bucket, authenticated with the access key of an R2 API token.
+func NewR2(endpoint, bucket, accessKeyID, secretAccessKey string) (*R2, error) {
+	// R2 ignores the region, but the SDK requires one
+	sess, err := awsAuth.GetClient(accessKeyID, secretAccessKey, "", "auto", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &R2{
+		client: s3.New(sess, aws.NewConfig().WithS3ForcePathStyle(true)),
+		bucket: bucket,
+	}, nil
+}
+
+// Put stores an object and returns its ETag.
+func (r *R2) Put(ctx context.Context, key string, data []byte) (string, error) {
+	res, err := r.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+
+	return aws.StringValue(res.ETag), nil
+}
+
+// Get returns the content of an object, or ErrR2ObjectNotFound.
+func (r *R2) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := r.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrR2ObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete deletes an object; deleting an object that doesn't exist isn't an error.
+func (r *R2) Delete(ctx context.Context, key string) error {
+	_, err := r.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns the objects whose key has the prefix, in lexicographic order after startAfter, and the key to start
+// after for the next page, which is empty on the last page.
+func (r *R2) List(ctx context.Context, prefix, startAfter string, maxResults int64) ([]R2Object, string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(r.bucket),
+		MaxKeys: aws.Int64(maxResults),
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	if startAfter != "" {
+		input.StartAfter = aws.String(startAfter)
+	}
+	res, err := r.client.ListObjectsV2WithContext(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	objects := make([]R2Object, len(res.Contents))
+	for i, o := range res.Contents {
+		objects[i] = R2Object{
+			Key:  aws.StringValue(o.Key),
+			Size: aws.Int64Value(o.Size),
+			ETag: aws.StringValue(o.ETag),
+		}
+		if o.LastModified != nil {
+			objects[i].LastModified = o.LastModified.UTC().Format(time.RFC3339)
+		}
+	}
+	var next string
+	if aws.BoolValue(res.IsTruncated) && len(objects) > 0 {
+		next = objects[len(objects)-1].Key
+	}
+
+	return objects, next, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jetstream contains the connection to NATS JetStream key-value buckets shared by the state and configuration stores.
+package jetstream
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// errCodeWrongLastSequence is the JetStream error code returned when the expected revision of a key doesn't match.
+const errCodeWrongLastSequence nats.ErrorCode = 10071
+
+// KVMetadata is the metadata of components using a key-value bucket.
+type KVMetadata struct {
+	Name    string
+	NatsURL string
+	Jwt     string
+	SeedKey string
+	Bucket  string
+}
+
+// ParseKVMetadata parses the metadata, using defaultName as the name of the connection if none is set.
+func ParseKVMetadata(properties map[string]string, defaultName string) (KVMetadata, error) {
+	var m KVMetadata
+	err := metadata.DecodeMetadata(properties, &m)
+	if err != nil {
+		return KVMetadata{}, err
+	}
+
+	if m.NatsURL == "" {
+		return KVMetadata{}, errors.New("missing nats URL")
+	}
+
+	if m.Jwt != "" && m.SeedKey == "" {
+		return KVMetadata{}, errors.New("missing seed key")
+	}
+
+	if m.Jwt == "" && m.SeedKey != "" {
+		return KVMetadata{}, errors.New("missing jwt")
+	}
+
+	if m.Name == "" {
+		m.Name = defaultName
+	}
+
+	if m.Bucket == "" {
+		return KVMetadata{}, errors.New("missing bucket")
+	}
+
+	return m, nil
+}
+
+// ConnectKV connects to the NATS server and opens the bucket. The connection must be closed by the caller.
+func ConnectKV(m KVMetadata) (*nats.Conn, nats.KeyValue, error) {
+	var opts []nats.Option
+	opts = append(opts, nats.Name(m.Name))
+
+	// Set nats.UserJWT options when jwt and seed key is provided.
+	if m.Jwt != "" && m.SeedKey != "" {
+		opts = append(opts, nats.UserJWT(func() (string, error) {
+			return m.Jwt, nil
+		}, func(nonce []byte) ([]byte, error) {
+			return sigHandler(m.SeedKey, nonce)
+		}))
+	}
+
+	nc, err := nats.Connect(m.NatsURL, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jsc, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+
+	bucket, err := jsc.KeyValue(m.Bucket)
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+
+	return nc, bucket, nil
+}
+
+// Handle nats signature request for challenge response authentication.
+func sigHandler(seedKey string, nonce []byte) ([]byte, error) {
+	kp, err := nkeys.FromSeed([]byte(seedKey))
+	if err != nil {
+		return nil, err
+	}
+	// Wipe our key on exit.
+	defer kp.Wipe()
+
+	sig, _ := kp.Sign(nonce)
+	return sig, nil
+}
+
+// FormatRevision returns the revision of an entry as a string, used as the ETag or version of the key.
+func FormatRevision(revision uint64) string {
+	return strconv.FormatUint(revision, 10)
+}
+
+// ParseRevision parses a revision formatted with FormatRevision.
+func ParseRevision(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// IsRevisionMismatch returns true if the error was caused by a write expecting another revision of the key.
+func IsRevisionMismatch(err error) bool {
+	var apiErr *nats.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == errCodeWrongLastSequence
+}
@@ -18,8 +18,14 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 
 	"github.com/Shopify/sarama"
+	netproxy "golang.org/x/net/proxy"
+
+	"github.com/dapr/components-contrib/internal/authentication/spiffe"
+	"github.com/dapr/components-contrib/internal/dialer"
+	"github.com/dapr/components-contrib/internal/proxy"
 )
 
 func updatePasswordAuthInfo(config *sarama.Config, metadata *kafkaMetadata, saslUsername, saslPassword string) {
@@ -37,16 +43,36 @@ func updatePasswordAuthInfo(config *sarama.Config, metadata *kafkaMetadata, sasl
 	}
 }
 
-func updateMTLSAuthInfo(config *sarama.Config, metadata *kafkaMetadata) error {
+// updateMTLSAuthInfo configures the client certificate, from the metadata or from the SPIFFE Workload API if configured.
+// The returned SPIFFE configuration, if any, must be closed with the clients.
+func updateMTLSAuthInfo(config *sarama.Config, metadata *kafkaMetadata, properties map[string]string) (*spiffe.Config, error) {
 	if metadata.TLSDisable {
-		return fmt.Errorf("kafka: cannot configure mTLS authentication when TLSDisable is 'true'")
+		return nil, fmt.Errorf("kafka: cannot configure mTLS authentication when TLSDisable is 'true'")
+	}
+	spiffeConfig, err := spiffe.Parse(properties)
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: %w", err)
+	}
+	if spiffeConfig != nil {
+		if metadata.TLSClientCert != "" {
+			return nil, errors.New("kafka error: clientCert and spiffeEndpointSocket can't be set together")
+		}
+		if config.Net.TLS.Config == nil {
+			config.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		err = spiffeConfig.ConfigureTLS(config.Net.TLS.Config)
+		if err != nil {
+			spiffeConfig.Close()
+			return nil, err
+		}
+		return spiffeConfig, nil
 	}
 	cert, err := tls.X509KeyPair([]byte(metadata.TLSClientCert), []byte(metadata.TLSClientKey))
 	if err != nil {
-		return fmt.Errorf("unable to load client certificate and key pair. Err: %w", err)
+		return nil, fmt.Errorf("unable to load client certificate and key pair. Err: %w", err)
 	}
 	config.Net.TLS.Config.Certificates = []tls.Certificate{cert}
-	return nil
+	return nil, nil
 }
 
 func updateTLSConfig(config *sarama.Config, metadata *kafkaMetadata) error {
@@ -72,6 +98,38 @@ func updateTLSConfig(config *sarama.Config, metadata *kafkaMetadata) error {
 	return nil
 }
 
+// updateDialerConfig connects to the brokers with the name resolution settings and through the SOCKS5 proxy configured in the metadata, if any.
+func updateDialerConfig(config *sarama.Config, metadata map[string]string) error {
+	proxyConfig, err := proxy.Parse(metadata)
+	if err != nil {
+		return err
+	}
+	dialerConfig, err := dialer.Parse(metadata)
+	if err != nil {
+		return err
+	}
+	if proxyConfig == nil && dialerConfig == nil {
+		return nil
+	}
+
+	var d netproxy.Dialer = dialerConfig.Dialer(&net.Dialer{
+		Timeout:   config.Net.DialTimeout,
+		KeepAlive: config.Net.KeepAlive,
+		LocalAddr: config.Net.LocalAddr,
+	})
+	if proxyConfig != nil {
+		d, err = proxyConfig.Dialer(d)
+		if err != nil {
+			return fmt.Errorf("kafka error: %w", err)
+		}
+	}
+	// Sarama only accepts custom dialers as proxies
+	config.Net.Proxy.Enable = true
+	config.Net.Proxy.Dialer = d
+
+	return nil
+}
+
 func updateOidcAuthInfo(config *sarama.Config, metadata *kafkaMetadata) error {
 	tokenProvider := newOAuthTokenSource(metadata.OidcTokenEndpoint, metadata.OidcClientID, metadata.OidcClientSecret, metadata.OidcScopes)
 
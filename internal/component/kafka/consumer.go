@@ -36,6 +36,8 @@ type consumer struct {
 }
 
 func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	consumer.k.pauseClaim(claim.Topic(), claim.Partition())
+
 	b := consumer.k.backOffConfig.NewBackOffWithContext(session.Context())
 	isBulkSubscribe := consumer.k.checkBulkSubscribe(claim.Topic())
 
@@ -181,7 +183,7 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 	if err != nil {
 		return err
 	}
-	if !handlerConfig.IsBulkSubscribe && handlerConfig.Handler == nil {
+	if !handlerConfig.IsBulkSubscribe && handlerConfig.Handler == nil && handlerConfig.TransformHandler == nil {
 		return errors.New("invalid handler config for subscribe call")
 	}
 	event := NewEvent{
@@ -189,6 +191,16 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 		Data:  message.Value,
 	}
 
+	if handlerConfig.TransformHandler != nil {
+		// The offset is committed in the transaction of the transformed messages rather than marked in the session
+		var transformed []TransformedMessage
+		transformed, err = handlerConfig.TransformHandler(session.Context(), &event)
+		if err != nil {
+			return err
+		}
+		return consumer.k.produceInTransaction(message, transformed)
+	}
+
 	err = handlerConfig.Handler(session.Context(), &event)
 	if err == nil {
 		session.MarkMessage(message, "")
@@ -200,7 +212,9 @@ func (consumer *consumer) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
 
-func (consumer *consumer) Setup(sarama.ConsumerGroupSession) error {
+func (consumer *consumer) Setup(session sarama.ConsumerGroupSession) error {
+	consumer.k.setClaims(session.Claims())
+
 	consumer.once.Do(func() {
 		close(consumer.ready)
 	})
@@ -267,7 +281,9 @@ func (k *Kafka) Subscribe(ctx context.Context) error {
 		return err
 	}
 
+	k.pauseLock.Lock()
 	k.cg = cg
+	k.pauseLock.Unlock()
 
 	ctx, cancel := context.WithCancel(ctx)
 	k.cancel = cancel
@@ -0,0 +1,318 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/dapr/kit/logger"
+)
+
+// failoverProducer is a producer that publishes to the primary cluster, or to the secondary cluster while the primary
+// cluster is unavailable, for active/passive topologies where the secondary cluster takes over in case of disaster.
+// While it publishes to the secondary cluster, the primary cluster is probed periodically, and it switches back once
+// the primary cluster is available.
+type failoverProducer struct {
+	config          sarama.Config
+	primary         []string
+	secondary       []string
+	maxMessageBytes int
+	probeInterval   time.Duration
+	logger          logger.Logger
+
+	// Overridden in tests.
+	newProducer  func(config sarama.Config, brokers []string, maxMessageBytes int) (sarama.SyncProducer, error)
+	probePrimary func() error
+
+	lock     sync.RWMutex
+	producer *clusterProducer
+	// Serializes the switches between the clusters, which wait for the sends of the replaced producer
+	failoverLock sync.Mutex
+	closeCh      chan struct{}
+	wg           sync.WaitGroup
+}
+
+// clusterProducer is the producer of a cluster, with the sends in progress.
+// A replaced producer is only closed once its sends are done, as sarama panics when sending with a closed producer.
+type clusterProducer struct {
+	sarama.SyncProducer
+	onSecondary bool
+	inflight    sync.WaitGroup
+}
+
+var _ sarama.SyncProducer = (*failoverProducer)(nil)
+
+func newFailoverProducer(config sarama.Config, meta *kafkaMetadata, logger logger.Logger) (*failoverProducer, error) {
+	p := &failoverProducer{
+		config:          config,
+		primary:         meta.Brokers,
+		secondary:       meta.SecondaryBrokers,
+		maxMessageBytes: meta.MaxMessageBytes,
+		probeInterval:   meta.PrimaryProbeInterval,
+		logger:          logger,
+		newProducer:     getSyncProducer,
+		closeCh:         make(chan struct{}),
+	}
+	p.probePrimary = p.probe
+
+	return p, p.start()
+}
+
+// start creates the producer of the primary cluster, or of the secondary cluster if the primary cluster is unavailable,
+// and starts probing the primary cluster.
+func (p *failoverProducer) start() error {
+	producer, err := p.newProducer(p.config, p.primary, p.maxMessageBytes)
+	onSecondary := false
+	if err != nil {
+		p.logger.Warnf("Kafka primary cluster is unavailable, publishing to the secondary cluster: %v", err)
+		producer, err = p.newProducer(p.config, p.secondary, p.maxMessageBytes)
+		if err != nil {
+			return err
+		}
+		onSecondary = true
+	}
+	p.producer = &clusterProducer{SyncProducer: producer, onSecondary: onSecondary}
+
+	p.wg.Add(1)
+	go p.probeLoop()
+
+	return nil
+}
+
+// SendMessage publishes the message, to the secondary cluster if the primary cluster is unavailable.
+func (p *failoverProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	producer := p.acquire()
+	partition, offset, err = producer.SendMessage(msg)
+	producer.inflight.Done()
+	if producer.onSecondary || !isClusterUnavailable(err) {
+		return partition, offset, err
+	}
+
+	if ferr := p.failover(producer, err); ferr != nil {
+		return partition, offset, err
+	}
+	producer = p.acquire()
+	defer producer.inflight.Done()
+	return producer.SendMessage(msg)
+}
+
+// SendMessages publishes the messages. If the primary cluster is unavailable, the messages that failed are published to
+// the secondary cluster.
+func (p *failoverProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	producer := p.acquire()
+	err := producer.SendMessages(msgs)
+	producer.inflight.Done()
+	if err == nil || producer.onSecondary {
+		return err
+	}
+
+	var pErrs sarama.ProducerErrors
+	if !errors.As(err, &pErrs) || len(pErrs) == 0 {
+		return err
+	}
+	failed := make([]*sarama.ProducerMessage, len(pErrs))
+	for i, pErr := range pErrs {
+		if !isClusterUnavailable(pErr.Err) {
+			return err
+		}
+		failed[i] = pErr.Msg
+	}
+
+	if ferr := p.failover(producer, pErrs[0].Err); ferr != nil {
+		return err
+	}
+	producer = p.acquire()
+	defer producer.inflight.Done()
+	return producer.SendMessages(failed)
+}
+
+// Close stops probing the primary cluster, and closes the producer once its sends are done.
+func (p *failoverProducer) Close() error {
+	close(p.closeCh)
+	p.wg.Wait()
+
+	p.failoverLock.Lock()
+	defer p.failoverLock.Unlock()
+	p.lock.RLock()
+	producer := p.producer
+	p.lock.RUnlock()
+	producer.inflight.Wait()
+	return producer.Close()
+}
+
+func (p *failoverProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.TxnStatus()
+}
+
+func (p *failoverProducer) IsTransactional() bool {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.IsTransactional()
+}
+
+func (p *failoverProducer) BeginTxn() error {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.BeginTxn()
+}
+
+func (p *failoverProducer) CommitTxn() error {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.CommitTxn()
+}
+
+func (p *failoverProducer) AbortTxn() error {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.AbortTxn()
+}
+
+func (p *failoverProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.AddOffsetsToTxn(offsets, groupID)
+}
+
+func (p *failoverProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	producer := p.acquire()
+	defer producer.inflight.Done()
+	return producer.AddMessageToTxn(msg, groupID, metadata)
+}
+
+// current returns the producer of the cluster that messages are published to.
+func (p *failoverProducer) current() (sarama.SyncProducer, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.producer.SyncProducer, p.producer.onSecondary
+}
+
+// acquire returns the producer of the cluster that messages are published to, counting a send in progress.
+// The caller must call inflight.Done once the send is done.
+func (p *failoverProducer) acquire() *clusterProducer {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	// Sends are only added while holding the lock, so none are added to a producer after it's replaced
+	p.producer.inflight.Add(1)
+	return p.producer
+}
+
+// replace switches to the producer, and closes the replaced producer once its sends are done.
+func (p *failoverProducer) replace(producer *clusterProducer) {
+	p.lock.Lock()
+	replaced := p.producer
+	p.producer = producer
+	p.lock.Unlock()
+
+	replaced.inflight.Wait()
+	if err := replaced.Close(); err != nil {
+		p.logger.Warnf("Error closing the producer of the replaced cluster: %v", err)
+	}
+}
+
+// failover switches to the secondary cluster, after the producer of the primary cluster failed.
+// It does nothing if another publish already switched.
+func (p *failoverProducer) failover(failed *clusterProducer, cause error) error {
+	p.lock.RLock()
+	switched := p.producer != failed
+	p.lock.RUnlock()
+	if switched {
+		return nil
+	}
+
+	p.failoverLock.Lock()
+	defer p.failoverLock.Unlock()
+	p.lock.RLock()
+	switched = p.producer != failed
+	p.lock.RUnlock()
+	if switched {
+		return nil
+	}
+
+	p.logger.Warnf("Kafka primary cluster is unavailable, failing over to the secondary cluster: %v", cause)
+	producer, err := p.newProducer(p.config, p.secondary, p.maxMessageBytes)
+	if err != nil {
+		p.logger.Errorf("Kafka secondary cluster is unavailable: %v", err)
+		return err
+	}
+	p.replace(&clusterProducer{SyncProducer: producer, onSecondary: true})
+
+	return nil
+}
+
+// probeLoop switches back to the primary cluster once it's available again.
+func (p *failoverProducer) probeLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+			if _, onSecondary := p.current(); onSecondary {
+				p.failback()
+			}
+		}
+	}
+}
+
+// failback switches back to the primary cluster, if it's available.
+func (p *failoverProducer) failback() {
+	if err := p.probePrimary(); err != nil {
+		p.logger.Debugf("Kafka primary cluster is still unavailable: %v", err)
+		return
+	}
+	producer, err := p.newProducer(p.config, p.primary, p.maxMessageBytes)
+	if err != nil {
+		p.logger.Debugf("Kafka primary cluster is still unavailable: %v", err)
+		return
+	}
+
+	p.failoverLock.Lock()
+	defer p.failoverLock.Unlock()
+	p.logger.Info("Kafka primary cluster is available again, publishing to the primary cluster")
+	p.replace(&clusterProducer{SyncProducer: producer})
+}
+
+// probe connects to the primary cluster and fetches its metadata.
+func (p *failoverProducer) probe() error {
+	client, err := sarama.NewClient(p.primary, &p.config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.RefreshMetadata()
+}
+
+// isClusterUnavailable returns true if a publish failed because no broker of the cluster could be reached.
+func isClusterUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, sarama.ErrOutOfBrokers) ||
+		errors.Is(err, sarama.ErrNotConnected) ||
+		errors.Is(err, sarama.ErrBrokerNotAvailable) ||
+		errors.As(err, &netErr)
+}
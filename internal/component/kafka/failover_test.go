@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+// newTestFailoverProducer returns a failover producer that creates the producers of the clusters with the functions.
+func newTestFailoverProducer(t *testing.T, primary, secondary func() (sarama.SyncProducer, error), probe func() error) *failoverProducer {
+	t.Helper()
+	p := &failoverProducer{
+		primary:       []string{"primary"},
+		secondary:     []string{"secondary"},
+		probeInterval: 10 * time.Millisecond,
+		logger:        logger.NewLogger("kafka_test"),
+		newProducer: func(_ sarama.Config, brokers []string, _ int) (sarama.SyncProducer, error) {
+			if brokers[0] == "primary" {
+				return primary()
+			}
+			return secondary()
+		},
+		probePrimary: probe,
+		closeCh:      make(chan struct{}),
+	}
+	require.NoError(t, p.start())
+	t.Cleanup(func() { p.Close() })
+
+	return p
+}
+
+func TestFailoverProducer(t *testing.T) {
+	unavailable := func() error { return sarama.ErrOutOfBrokers }
+
+	t.Run("publishes to the primary cluster", func(t *testing.T) {
+		primary := mocks.NewSyncProducer(t, nil)
+		primary.ExpectSendMessageAndSucceed()
+		p := newTestFailoverProducer(t, func() (sarama.SyncProducer, error) { return primary, nil }, nil, unavailable)
+
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "a"})
+		require.NoError(t, err)
+		_, onSecondary := p.current()
+		assert.False(t, onSecondary)
+	})
+
+	t.Run("fails over when the primary cluster is unavailable", func(t *testing.T) {
+		primary := mocks.NewSyncProducer(t, nil)
+		primary.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+		secondary := mocks.NewSyncProducer(t, nil)
+		secondary.ExpectSendMessageAndSucceed()
+		secondary.ExpectSendMessageAndSucceed()
+		p := newTestFailoverProducer(t,
+			func() (sarama.SyncProducer, error) { return primary, nil },
+			func() (sarama.SyncProducer, error) { return secondary, nil },
+			unavailable)
+
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "a"})
+		require.NoError(t, err)
+		_, _, err = p.SendMessage(&sarama.ProducerMessage{Topic: "a"})
+		require.NoError(t, err)
+		_, onSecondary := p.current()
+		assert.True(t, onSecondary)
+	})
+
+	t.Run("doesn't fail over on other errors", func(t *testing.T) {
+		primary := mocks.NewSyncProducer(t, nil)
+		primary.ExpectSendMessageAndFail(sarama.ErrMessageSizeTooLarge)
+		p := newTestFailoverProducer(t, func() (sarama.SyncProducer, error) { return primary, nil }, nil, unavailable)
+
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "a"})
+		assert.ErrorIs(t, err, sarama.ErrMessageSizeTooLarge)
+		_, onSecondary := p.current()
+		assert.False(t, onSecondary)
+	})
+
+	t.Run("starts on the secondary cluster and fails back", func(t *testing.T) {
+		primaryUp := make(chan struct{})
+		primary := mocks.NewSyncProducer(t, nil)
+		secondary := mocks.NewSyncProducer(t, nil)
+		p := newTestFailoverProducer(t,
+			func() (sarama.SyncProducer, error) {
+				select {
+				case <-primaryUp:
+					return primary, nil
+				default:
+					return nil, sarama.ErrOutOfBrokers
+				}
+			},
+			func() (sarama.SyncProducer, error) { return secondary, nil },
+			func() error {
+				select {
+				case <-primaryUp:
+					return nil
+				default:
+					return sarama.ErrOutOfBrokers
+				}
+			})
+
+		_, onSecondary := p.current()
+		assert.True(t, onSecondary)
+
+		close(primaryUp)
+		assert.Eventually(t, func() bool {
+			_, onSecondary := p.current()
+			return !onSecondary
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("both clusters unavailable", func(t *testing.T) {
+		p := &failoverProducer{
+			logger: logger.NewLogger("kafka_test"),
+			newProducer: func(sarama.Config, []string, int) (sarama.SyncProducer, error) {
+				return nil, sarama.ErrOutOfBrokers
+			},
+			closeCh: make(chan struct{}),
+		}
+		assert.ErrorIs(t, p.start(), sarama.ErrOutOfBrokers)
+	})
+}
+
+// partialFailProducer fails to publish the messages of the failing topic, like the sync producer of sarama.
+type partialFailProducer struct {
+	*mocks.SyncProducer
+	failingTopic string
+	sent         []string
+}
+
+func (p *partialFailProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	var errs sarama.ProducerErrors
+	for _, msg := range msgs {
+		if msg.Topic == p.failingTopic {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: sarama.ErrOutOfBrokers})
+		} else {
+			p.sent = append(p.sent, msg.Topic)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func TestFailoverProducerSendMessages(t *testing.T) {
+	primary := &partialFailProducer{SyncProducer: mocks.NewSyncProducer(t, nil), failingTopic: "b"}
+	secondary := &partialFailProducer{SyncProducer: mocks.NewSyncProducer(t, nil)}
+	p := newTestFailoverProducer(t,
+		func() (sarama.SyncProducer, error) { return primary, nil },
+		func() (sarama.SyncProducer, error) { return secondary, nil },
+		func() error { return sarama.ErrOutOfBrokers })
+
+	err := p.SendMessages([]*sarama.ProducerMessage{{Topic: "a"}, {Topic: "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, primary.sent)
+	// Only the message that failed is published to the secondary cluster
+	assert.Equal(t, []string{"b"}, secondary.sent)
+}
+
+// blockingProducer blocks the first send until it's released, and fails the others.
+// Like the sync producer of sarama, it panics when sending after it's closed.
+type blockingProducer struct {
+	*mocks.SyncProducer
+	release chan struct{}
+	sends   atomic.Int32
+	closed  atomic.Bool
+}
+
+func (p *blockingProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if p.closed.Load() {
+		panic("send on closed producer")
+	}
+	if p.sends.Add(1) > 1 {
+		return -1, -1, sarama.ErrOutOfBrokers
+	}
+	<-p.release
+	if p.closed.Load() {
+		panic("send on closed producer")
+	}
+	return 0, 0, nil
+}
+
+func (p *blockingProducer) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func TestFailoverProducerWithSendsInProgress(t *testing.T) {
+	primary := &blockingProducer{SyncProducer: mocks.NewSyncProducer(t, nil), release: make(chan struct{})}
+	secondary := mocks.NewSyncProducer(t, nil)
+	secondary.ExpectSendMessageAndSucceed()
+	p := newTestFailoverProducer(t,
+		func() (sarama.SyncProducer, error) { return primary, nil },
+		func() (sarama.SyncProducer, error) { return secondary, nil },
+		func() error { return sarama.ErrOutOfBrokers })
+
+	var wg sync.WaitGroup
+	send := func() {
+		defer wg.Done()
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "a"})
+		assert.NoError(t, err)
+	}
+	wg.Add(1)
+	go send()
+	assert.Eventually(t, func() bool { return primary.sends.Load() == 1 }, time.Second, time.Millisecond)
+
+	// Fails over while the first send is in progress
+	wg.Add(1)
+	go send()
+	assert.Eventually(t, func() bool {
+		_, onSecondary := p.current()
+		return onSecondary
+	}, time.Second, time.Millisecond)
+	assert.False(t, primary.closed.Load())
+
+	close(primary.release)
+	wg.Wait()
+	assert.Eventually(t, primary.closed.Load, time.Second, time.Millisecond)
+}
+
+func TestIsClusterUnavailable(t *testing.T) {
+	assert.False(t, isClusterUnavailable(nil))
+	assert.True(t, isClusterUnavailable(sarama.ErrOutOfBrokers))
+	assert.True(t, isClusterUnavailable(sarama.ErrBrokerNotAvailable))
+	assert.False(t, isClusterUnavailable(sarama.ErrMessageSizeTooLarge))
+	assert.False(t, isClusterUnavailable(errors.New("other")))
+}
+
+func TestParseFailoverMetadata(t *testing.T) {
+	k := getKafka()
+
+	m := getBaseMetadata()
+	meta, err := k.getKafkaMetadata(m)
+	require.NoError(t, err)
+	assert.Empty(t, meta.SecondaryBrokers)
+	assert.Equal(t, defaultPrimaryProbeInterval, meta.PrimaryProbeInterval)
+
+	m[secondaryBrokers] = "b1,b2"
+	m[primaryProbeInterval] = "1m"
+	meta, err = k.getKafkaMetadata(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "b2"}, meta.SecondaryBrokers)
+	assert.Equal(t, time.Minute, meta.PrimaryProbeInterval)
+
+	m[primaryProbeInterval] = "0s"
+	_, err = k.getKafkaMetadata(m)
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Shopify/sarama"
 
+	"github.com/dapr/components-contrib/internal/authentication/spiffe"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -28,6 +29,8 @@ import (
 // Kafka allows reading/writing to a Kafka consumer group.
 type Kafka struct {
 	producer        sarama.SyncProducer
+	txnProducer     sarama.SyncProducer
+	txnLock         sync.Mutex
 	consumerGroup   string
 	brokers         []string
 	logger          logger.Logger
@@ -39,9 +42,15 @@ type Kafka struct {
 	cancel          context.CancelFunc
 	consumer        consumer
 	config          *sarama.Config
+	spiffe          *spiffe.Config
 	subscribeTopics TopicHandlerConfig
 	subscribeLock   sync.Mutex
 
+	// Paused topics, and the partitions of each topic claimed by the consumer group session
+	pauseLock    sync.Mutex
+	pausedTopics map[string]struct{}
+	claims       map[string][]int32
+
 	backOffConfig retry.Config
 
 	// The default value should be true for kafka pubsub component and false for kafka binding component
@@ -56,6 +65,7 @@ func NewKafka(logger logger.Logger) *Kafka {
 		logger:          logger,
 		subscribeTopics: make(TopicHandlerConfig),
 		subscribeLock:   sync.Mutex{},
+		pausedTopics:    make(map[string]struct{}),
 	}
 }
 
@@ -84,11 +94,21 @@ func (k *Kafka) Init(metadata map[string]string) error {
 		config.ClientID = meta.ClientID
 	}
 
+	config.Consumer.Group.InstanceId = meta.GroupInstanceID
+	if meta.SessionTimeout > 0 {
+		config.Consumer.Group.Session.Timeout = meta.SessionTimeout
+	}
+
 	err = updateTLSConfig(config, meta)
 	if err != nil {
 		return err
 	}
 
+	err = updateDialerConfig(config, metadata)
+	if err != nil {
+		return err
+	}
+
 	switch k.authType {
 	case oidcAuthType:
 		k.logger.Info("Configuring SASL OAuth2/OIDC authentication")
@@ -103,20 +123,36 @@ func (k *Kafka) Init(metadata map[string]string) error {
 		updatePasswordAuthInfo(config, meta, k.saslUsername, k.saslPassword)
 	case mtlsAuthType:
 		k.logger.Info("Configuring mTLS authentcation")
-		err = updateMTLSAuthInfo(config, meta)
+		k.spiffe, err = updateMTLSAuthInfo(config, meta, metadata)
 		if err != nil {
 			return err
 		}
 	}
 
+	if meta.TransactionalID != "" {
+		// Only read the messages of committed transactions
+		config.Consumer.IsolationLevel = sarama.ReadCommitted
+	}
+
 	k.config = config
 	sarama.Logger = SaramaLogBridge{daprLogger: k.logger}
 
-	k.producer, err = getSyncProducer(*k.config, k.brokers, meta.MaxMessageBytes)
+	if len(meta.SecondaryBrokers) > 0 {
+		k.producer, err = newFailoverProducer(*k.config, meta, k.logger)
+	} else {
+		k.producer, err = getSyncProducer(*k.config, k.brokers, meta.MaxMessageBytes)
+	}
 	if err != nil {
 		return err
 	}
 
+	if meta.TransactionalID != "" {
+		k.txnProducer, err = getTransactionalProducer(*k.config, k.brokers, meta)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Default retry configuration is used if no
 	// backOff properties are set.
 	if err := retry.DecodeConfigWithPrefix(
@@ -141,6 +177,19 @@ func (k *Kafka) Close() (err error) {
 		k.producer = nil
 	}
 
+	k.txnLock.Lock()
+	if k.txnProducer != nil {
+		if txnErr := k.txnProducer.Close(); txnErr != nil && err == nil {
+			err = txnErr
+		}
+		k.txnProducer = nil
+	}
+	k.txnLock.Unlock()
+
+	if spiffeErr := k.spiffe.Close(); spiffeErr != nil && err == nil {
+		err = spiffeErr
+	}
+
 	return err
 }
 
@@ -156,6 +205,9 @@ type SubscriptionHandlerConfig struct {
 	SubscribeConfig pubsub.BulkSubscribeConfig
 	BulkHandler     BulkEventHandler
 	Handler         EventHandler
+	// If set, the messages returned by the handler are produced, and the offset of the consumed message committed,
+	// in a transaction. It requires the transactionalID metadata, and takes precedence over Handler.
+	TransformHandler TransformEventHandler
 }
 
 // NewEvent is an event arriving from a message bus instance.
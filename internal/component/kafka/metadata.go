@@ -16,6 +16,7 @@ package kafka
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +32,11 @@ const (
 	clientKey            = "clientKey"
 	consumeRetryEnabled  = "consumeRetryEnabled"
 	consumeRetryInterval = "consumeRetryInterval"
+	groupInstanceID      = "groupInstanceID"
+	sessionTimeout       = "sessionTimeout"
+	secondaryBrokers     = "secondaryBrokers"
+	primaryProbeInterval = "primaryProbeInterval"
+	transactionalID      = "transactionalID"
 	authType             = "authType"
 	passwordAuthType     = "password"
 	oidcAuthType         = "oidc"
@@ -38,6 +44,13 @@ const (
 	noAuthType           = "none"
 )
 
+const (
+	maxGroupInstanceIDLength    = 249
+	defaultPrimaryProbeInterval = 30 * time.Second
+)
+
+var groupInstanceIDRegexp = regexp.MustCompile(`^[0-9a-zA-Z._-]+$`)
+
 type kafkaMetadata struct {
 	Brokers              []string
 	ConsumerGroup        string
@@ -60,6 +73,17 @@ type kafkaMetadata struct {
 	ConsumeRetryEnabled  bool
 	ConsumeRetryInterval time.Duration
 	Version              sarama.KafkaVersion
+	// Static membership (KIP-345): members keep their partitions when they rejoin within the session timeout,
+	// so restarting a member doesn't rebalance the group. The instance ID must be unique in the group and stable across restarts.
+	GroupInstanceID string
+	SessionTimeout  time.Duration
+	// Brokers of a secondary cluster, which messages are published to while the cluster of Brokers is unavailable.
+	// The cluster of Brokers is probed every PrimaryProbeInterval, and publishing switches back to it once it's available.
+	SecondaryBrokers     []string
+	PrimaryProbeInterval time.Duration
+	// Transactional ID of the producer of transformed messages, which commits the offsets of the consumed messages in
+	// its transactions for exactly-once processing. It must be unique and stable across restarts of each instance.
+	TransactionalID string
 }
 
 // upgradeMetadata updates metadata properties based on deprecated usage.
@@ -102,6 +126,7 @@ func (k *Kafka) upgradeMetadata(metadata map[string]string) (map[string]string,
 func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, error) {
 	meta := kafkaMetadata{
 		ConsumeRetryInterval: 100 * time.Millisecond,
+		PrimaryProbeInterval: defaultPrimaryProbeInterval,
 	}
 	// use the runtimeConfig.ID as the consumer group so that each dapr runtime creates its own consumergroup
 	if val, ok := metadata["consumerID"]; ok && val != "" {
@@ -280,5 +305,48 @@ func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, er
 		meta.Version = sarama.V2_0_0_0 //nolint:nosnakecase
 	}
 
+	if val, ok := metadata[groupInstanceID]; ok && val != "" {
+		if !meta.Version.IsAtLeast(sarama.V2_3_0_0) { //nolint:nosnakecase
+			return nil, fmt.Errorf("kafka error: '%s' requires version 2.3.0 or later", groupInstanceID)
+		}
+		if len(val) > maxGroupInstanceIDLength || !groupInstanceIDRegexp.MatchString(val) {
+			return nil, fmt.Errorf("kafka error: invalid value for '%s' attribute: only up to %d alphanumeric characters, '.', '_' and '-' are allowed", groupInstanceID, maxGroupInstanceIDLength)
+		}
+		meta.GroupInstanceID = val
+		k.logger.Debugf("Using %s as group instance ID", meta.GroupInstanceID)
+	}
+
+	if val, ok := metadata[sessionTimeout]; ok && val != "" {
+		durationVal, err := time.ParseDuration(val)
+		if err != nil || durationVal <= 0 {
+			return nil, fmt.Errorf("kafka error: invalid value for '%s' attribute: %s", sessionTimeout, val)
+		}
+		meta.SessionTimeout = durationVal
+	}
+
+	if val, ok := metadata[secondaryBrokers]; ok && val != "" {
+		meta.SecondaryBrokers = strings.Split(val, ",")
+		k.logger.Debugf("Found secondary brokers: %v", meta.SecondaryBrokers)
+	}
+
+	if val, ok := metadata[primaryProbeInterval]; ok && val != "" {
+		durationVal, err := time.ParseDuration(val)
+		if err != nil || durationVal <= 0 {
+			return nil, fmt.Errorf("kafka error: invalid value for '%s' attribute: %s", primaryProbeInterval, val)
+		}
+		meta.PrimaryProbeInterval = durationVal
+	}
+
+	if val, ok := metadata[transactionalID]; ok && val != "" {
+		if !meta.Version.IsAtLeast(sarama.V0_11_0_0) { //nolint:nosnakecase
+			return nil, fmt.Errorf("kafka error: '%s' requires version 0.11.0 or later", transactionalID)
+		}
+		if meta.ConsumerGroup == "" {
+			return nil, fmt.Errorf("kafka error: '%s' requires a consumer group", transactionalID)
+		}
+		meta.TransactionalID = val
+		k.logger.Debugf("Using %s as transactional ID", meta.TransactionalID)
+	}
+
 	return &meta, nil
 }
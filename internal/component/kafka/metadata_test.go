@@ -319,3 +319,73 @@ func TestTls(t *testing.T) {
 		require.Equal(t, "kafka error: invalid ca certificate", err.Error())
 	})
 }
+
+func TestStaticMembership(t *testing.T) {
+	k := getKafka()
+
+	t.Run("group instance id", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["version"] = "2.3.0"
+		m["groupInstanceID"] = "app-0"
+		m["sessionTimeout"] = "45s"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.Equal(t, "app-0", meta.GroupInstanceID)
+		require.Equal(t, 45*time.Second, meta.SessionTimeout)
+	})
+
+	t.Run("unsupported kafka version", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["groupInstanceID"] = "app-0"
+		meta, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+		require.Nil(t, meta)
+	})
+
+	t.Run("invalid group instance id", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["version"] = "2.3.0"
+		m["groupInstanceID"] = "app 0"
+		meta, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+		require.Nil(t, meta)
+	})
+
+	t.Run("invalid session timeout", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["sessionTimeout"] = "-1s"
+		meta, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+		require.Nil(t, meta)
+	})
+}
+
+func TestTransactionalID(t *testing.T) {
+	k := getKafka()
+
+	t.Run("transactional id", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["transactionalID"] = "app-0"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.Equal(t, "app-0", meta.TransactionalID)
+	})
+
+	t.Run("unsupported kafka version", func(t *testing.T) {
+		m := getCompleteMetadata()
+		m["version"] = "0.10.2.0"
+		m["transactionalID"] = "app-0"
+		meta, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+		require.Nil(t, meta)
+	})
+
+	t.Run("missing consumer group", func(t *testing.T) {
+		m := getCompleteMetadata()
+		delete(m, "consumerGroup")
+		m["transactionalID"] = "app-0"
+		meta, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+		require.Nil(t, meta)
+	})
+}
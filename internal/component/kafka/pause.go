@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+// PauseTopic suspends fetching the messages of the topic from the brokers, until ResumeTopic is called.
+// The partitions of the topic assigned to the consumer later, after a rebalance, are paused too.
+func (k *Kafka) PauseTopic(topic string) {
+	k.pauseLock.Lock()
+	defer k.pauseLock.Unlock()
+
+	k.pausedTopics[topic] = struct{}{}
+	if k.cg != nil && len(k.claims[topic]) > 0 {
+		k.cg.Pause(map[string][]int32{topic: k.claims[topic]})
+	}
+}
+
+// ResumeTopic resumes fetching the messages of the topic.
+func (k *Kafka) ResumeTopic(topic string) {
+	k.pauseLock.Lock()
+	defer k.pauseLock.Unlock()
+
+	delete(k.pausedTopics, topic)
+	if k.cg != nil && len(k.claims[topic]) > 0 {
+		k.cg.Resume(map[string][]int32{topic: k.claims[topic]})
+	}
+}
+
+// IsTopicPaused returns true if the topic is paused.
+func (k *Kafka) IsTopicPaused(topic string) bool {
+	k.pauseLock.Lock()
+	defer k.pauseLock.Unlock()
+
+	_, ok := k.pausedTopics[topic]
+
+	return ok
+}
+
+// setClaims sets the partitions assigned to the consumer group session.
+func (k *Kafka) setClaims(claims map[string][]int32) {
+	k.pauseLock.Lock()
+	k.claims = claims
+	k.pauseLock.Unlock()
+}
+
+// pauseClaim pauses the partition of a new claim if its topic is paused.
+// Partitions are consumed once claimed, so a paused topic must be paused again on every rebalance.
+func (k *Kafka) pauseClaim(topic string, partition int32) {
+	k.pauseLock.Lock()
+	defer k.pauseLock.Unlock()
+
+	if _, ok := k.pausedTopics[topic]; ok && k.cg != nil {
+		k.cg.Pause(map[string][]int32{topic: {partition}})
+	}
+}
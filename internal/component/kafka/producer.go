@@ -48,6 +48,20 @@ func (k *Kafka) Publish(topic string, data []byte, metadata map[string]string) e
 	// k.logger.Debugf("Publishing topic %v with data: %v", topic, string(data))
 	k.logger.Debugf("Publishing on topic %v", topic)
 
+	msg := newProducerMessage(topic, data, metadata)
+	partition, offset, err := k.producer.SendMessage(msg)
+
+	k.logger.Debugf("Partition: %v, offset: %v", partition, offset)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newProducerMessage returns the message of the data, with the partition key and headers of the metadata.
+func newProducerMessage(topic string, data []byte, metadata map[string]string) *sarama.ProducerMessage {
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(data),
@@ -67,15 +81,7 @@ func (k *Kafka) Publish(topic string, data []byte, metadata map[string]string) e
 		}
 	}
 
-	partition, offset, err := k.producer.SendMessage(msg)
-
-	k.logger.Debugf("Partition: %v, offset: %v", partition, offset)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return msg
 }
 
 func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+// TransformedMessage is a message to produce, as the result of a consumed message.
+type TransformedMessage struct {
+	Topic    string
+	Data     []byte
+	Metadata map[string]string
+}
+
+// TransformEventHandler handles a consumed event, returning the messages to produce as its result.
+type TransformEventHandler func(ctx context.Context, msg *NewEvent) ([]TransformedMessage, error)
+
+func getTransactionalProducer(config sarama.Config, brokers []string, meta *kafkaMetadata) (sarama.SyncProducer, error) {
+	// Transactions require an idempotent producer, which requires a single in-flight request per broker
+	config.Producer.Idempotent = true
+	config.Producer.Transaction.ID = meta.TransactionalID
+	config.Net.MaxOpenRequests = 1
+
+	return getSyncProducer(config, brokers, meta.MaxMessageBytes)
+}
+
+// produceInTransaction produces the transformed messages of the consumed message, and commits the offset of the
+// consumed message for the consumer group, in a transaction. Either both are committed or neither is, so consumers
+// reading committed messages only see the results of each consumed message once.
+func (k *Kafka) produceInTransaction(consumed *sarama.ConsumerMessage, msgs []TransformedMessage) error {
+	k.txnLock.Lock()
+	defer k.txnLock.Unlock()
+
+	p := k.txnProducer
+	if p == nil {
+		return errors.New("kafka error: transform handlers require the 'transactionalID' attribute")
+	}
+
+	err := p.BeginTxn()
+	if err != nil {
+		return fmt.Errorf("kafka error: failed to begin transaction: %w", err)
+	}
+
+	err = k.sendInTransaction(p, consumed, msgs)
+	if err == nil {
+		err = p.CommitTxn()
+	}
+	if err == nil {
+		return nil
+	}
+
+	if p.TxnStatus()&sarama.ProducerTxnFlagFatalError != 0 {
+		return fmt.Errorf("kafka error: fatal transaction error, the producer can't be used anymore: %w", err)
+	}
+	if p.TxnStatus()&(sarama.ProducerTxnFlagInTransaction|sarama.ProducerTxnFlagAbortableError) != 0 {
+		if abortErr := p.AbortTxn(); abortErr != nil {
+			k.logger.Errorf("Error aborting Kafka transaction: %v", abortErr)
+		}
+	}
+
+	return fmt.Errorf("kafka error: transaction failed: %w", err)
+}
+
+func (k *Kafka) sendInTransaction(p sarama.SyncProducer, consumed *sarama.ConsumerMessage, msgs []TransformedMessage) error {
+	if len(msgs) > 0 {
+		producerMsgs := make([]*sarama.ProducerMessage, len(msgs))
+		for i, msg := range msgs {
+			producerMsgs[i] = newProducerMessage(msg.Topic, msg.Data, msg.Metadata)
+		}
+		err := p.SendMessages(producerMsgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.AddMessageToTxn(consumed, k.consumerGroup, nil)
+}
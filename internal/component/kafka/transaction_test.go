@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+// txnTrackingProducer records the transactions of a transactional mock producer.
+type txnTrackingProducer struct {
+	*mocks.SyncProducer
+	committed []*sarama.ConsumerMessage
+	pending   []*sarama.ConsumerMessage
+	aborted   int
+}
+
+func (p *txnTrackingProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	p.pending = append(p.pending, msg)
+	return p.SyncProducer.AddMessageToTxn(msg, groupID, metadata)
+}
+
+func (p *txnTrackingProducer) CommitTxn() error {
+	p.committed = append(p.committed, p.pending...)
+	p.pending = nil
+	return p.SyncProducer.CommitTxn()
+}
+
+func (p *txnTrackingProducer) AbortTxn() error {
+	p.pending = nil
+	p.aborted++
+	return p.SyncProducer.AbortTxn()
+}
+
+func newTxnTestKafka(t *testing.T) (*Kafka, *txnTrackingProducer) {
+	t.Helper()
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_0_0_0 //nolint:nosnakecase
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Idempotent = true
+	config.Producer.Transaction.ID = "test"
+	config.Net.MaxOpenRequests = 1
+	p := &txnTrackingProducer{SyncProducer: mocks.NewSyncProducer(t, config)}
+
+	return &Kafka{
+		logger:        logger.NewLogger("kafka_test"),
+		consumerGroup: "group",
+		txnProducer:   p,
+	}, p
+}
+
+func TestProduceInTransaction(t *testing.T) {
+	consumed := &sarama.ConsumerMessage{Topic: "in", Partition: 1, Offset: 42}
+
+	t.Run("commits the messages and the offset", func(t *testing.T) {
+		k, p := newTxnTestKafka(t)
+		p.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			if msg.Topic != "out" || msg.Key != sarama.StringEncoder("k") {
+				return errors.New("unexpected message")
+			}
+			return nil
+		})
+		p.ExpectSendMessageAndSucceed()
+
+		err := k.produceInTransaction(consumed, []TransformedMessage{
+			{Topic: "out", Data: []byte("a"), Metadata: map[string]string{"partitionKey": "k"}},
+			{Topic: "out", Data: []byte("b")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*sarama.ConsumerMessage{consumed}, p.committed)
+		assert.Equal(t, 0, p.aborted)
+	})
+
+	t.Run("commits the offset without messages", func(t *testing.T) {
+		k, p := newTxnTestKafka(t)
+
+		require.NoError(t, k.produceInTransaction(consumed, nil))
+		assert.Equal(t, []*sarama.ConsumerMessage{consumed}, p.committed)
+	})
+
+	t.Run("aborts when producing fails", func(t *testing.T) {
+		k, p := newTxnTestKafka(t)
+		p.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+		err := k.produceInTransaction(consumed, []TransformedMessage{{Topic: "out", Data: []byte("a")}})
+		require.ErrorIs(t, err, sarama.ErrOutOfBrokers)
+		assert.Empty(t, p.committed)
+		assert.Equal(t, 1, p.aborted)
+	})
+
+	t.Run("requires a transactional producer", func(t *testing.T) {
+		k := &Kafka{logger: logger.NewLogger("kafka_test")}
+
+		err := k.produceInTransaction(consumed, nil)
+		assert.ErrorContains(t, err, "transactionalID")
+	})
+}
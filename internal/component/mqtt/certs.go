@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const defaultCertRefreshInterval = 5 * time.Minute
+
+// CertSecrets references the TLS certificates of an MQTT connection in a secret store.
+// Each reference is the name of a secret, optionally followed by the key in the secret, as in "name:key".
+type CertSecrets struct {
+	SecretStore     string        `mapstructure:"secretStore"`
+	CACert          string        `mapstructure:"caCertSecret"`
+	ClientCert      string        `mapstructure:"clientCertSecret"`
+	ClientKey       string        `mapstructure:"clientKeySecret"`
+	RefreshInterval time.Duration `mapstructure:"certRefreshInterval"`
+}
+
+// ParseCertSecrets parses the certificate references of the component metadata.
+// It returns nil if no secret store is configured.
+func ParseCertSecrets(properties map[string]string) (*CertSecrets, error) {
+	s := CertSecrets{}
+	err := metadata.DecodeMetadata(properties, &s)
+	if err != nil {
+		return nil, err
+	}
+	if s.SecretStore == "" {
+		if s.CACert != "" || s.ClientCert != "" || s.ClientKey != "" {
+			return nil, errors.New("certificate secrets require a secretStore")
+		}
+		return nil, nil
+	}
+	if s.CACert == "" && s.ClientCert == "" && s.ClientKey == "" {
+		return nil, errors.New("secretStore requires at least one of caCertSecret, clientCertSecret and clientKeySecret")
+	}
+	if (s.ClientCert == "") != (s.ClientKey == "") {
+		return nil, errors.New("clientCertSecret and clientKeySecret must be set together")
+	}
+	if s.RefreshInterval == 0 {
+		s.RefreshInterval = defaultCertRefreshInterval
+	} else if s.RefreshInterval < time.Second {
+		return nil, errors.New("certRefreshInterval must be at least 1s")
+	}
+
+	return &s, nil
+}
+
+// Certs are the PEM encoded certificates of an MQTT connection.
+type Certs struct {
+	CACert     string
+	ClientCert string
+	ClientKey  string
+}
+
+// CertReloader keeps the certificates of an MQTT connection up to date with a secret store.
+// The TLS config it returns reads the current certificates on every handshake, so the client picks up rotated certificates
+// when it reconnects, while the established connection stays up.
+type CertReloader struct {
+	store   secretstores.SecretStore
+	secrets CertSecrets
+	logger  logger.Logger
+
+	lock       sync.RWMutex
+	current    Certs
+	loaded     bool
+	rootCAs    *x509.CertPool
+	clientCert *tls.Certificate
+}
+
+// NewCertReloader returns a CertReloader, using the static certificates for those not referenced in the secret store.
+func NewCertReloader(store secretstores.SecretStore, secrets CertSecrets, static Certs, logger logger.Logger) *CertReloader {
+	return &CertReloader{
+		store:   store,
+		secrets: secrets,
+		current: static,
+		logger:  logger,
+	}
+}
+
+// Reload reads the certificates from the secret store and applies them if they changed.
+func (r *CertReloader) Reload(ctx context.Context) error {
+	r.lock.RLock()
+	certs := r.current
+	r.lock.RUnlock()
+
+	var err error
+	for _, s := range []struct {
+		ref string
+		val *string
+	}{
+		{r.secrets.CACert, &certs.CACert},
+		{r.secrets.ClientCert, &certs.ClientCert},
+		{r.secrets.ClientKey, &certs.ClientKey},
+	} {
+		if s.ref == "" {
+			continue
+		}
+		*s.val, err = r.getSecret(ctx, s.ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.lock.RLock()
+	changed := !r.loaded || r.current != certs
+	r.lock.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	var rootCAs *x509.CertPool
+	if certs.CACert != "" {
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM([]byte(certs.CACert)) {
+			return errors.New("invalid ca certificate")
+		}
+	}
+	var clientCert *tls.Certificate
+	if certs.ClientCert != "" && certs.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(certs.ClientCert), []byte(certs.ClientKey))
+		if err != nil {
+			return fmt.Errorf("invalid client certificate and key pair: %w", err)
+		}
+		clientCert = &cert
+	}
+
+	r.lock.Lock()
+	r.current = certs
+	r.rootCAs = rootCAs
+	r.clientCert = clientCert
+	r.loaded = true
+	r.lock.Unlock()
+	r.logger.Debug("mqtt certificates loaded from secret store " + r.secrets.SecretStore)
+
+	return nil
+}
+
+func (r *CertReloader) getSecret(ctx context.Context, ref string) (string, error) {
+	name, key, ok := strings.Cut(ref, ":")
+	if !ok {
+		key = name
+	}
+	res, err := r.store.GetSecret(ctx, secretstores.GetSecretRequest{Name: name})
+	if err != nil {
+		return "", fmt.Errorf("error reading secret %s: %w", name, err)
+	}
+
+	val, ok := res.Data[key]
+	if !ok && len(res.Data) == 1 {
+		// Secrets holding a single value can be referenced without the key
+		for _, v := range res.Data {
+			val = v
+		}
+	}
+	if val == "" {
+		return "", fmt.Errorf("secret %s has no value for key %s", name, key)
+	}
+	if block, _ := pem.Decode([]byte(val)); block == nil {
+		return "", fmt.Errorf("secret %s isn't PEM encoded", ref)
+	}
+
+	return val, nil
+}
+
+// Run reloads the certificates periodically until the context is canceled.
+func (r *CertReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.secrets.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(ctx); err != nil && ctx.Err() == nil {
+				// The previous certificates stay in use
+				r.logger.Warnf("mqtt failed to reload certificates from secret store %s: %v", r.secrets.SecretStore, err)
+			}
+		}
+	}
+}
+
+// TLSConfig returns a TLS config using the current certificates.
+func (r *CertReloader) TLSConfig() *tls.Config {
+	tlsConfig := &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.lock.RLock()
+			defer r.lock.RUnlock()
+
+			if r.clientCert == nil {
+				// No certificate is sent
+				return &tls.Certificate{}, nil
+			}
+			return r.clientCert, nil
+		},
+	}
+
+	r.lock.RLock()
+	hasCA := r.rootCAs != nil
+	r.lock.RUnlock()
+	if hasCA {
+		// RootCAs can't change after the config is created, so the server certificate is verified in VerifyConnection instead
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec
+		tlsConfig.VerifyConnection = r.verifyConnection
+	}
+
+	return tlsConfig
+}
+
+func (r *CertReloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server didn't present a certificate")
+	}
+
+	r.lock.RLock()
+	rootCAs := r.rootCAs
+	r.lock.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         rootCAs,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+
+	return err
+}
+
+// StartCertReloader loads the certificates referenced in the component metadata and reloads them periodically until the context is canceled.
+// It returns nil if the metadata doesn't reference a secret store.
+func StartCertReloader(ctx context.Context, properties map[string]string, resolver secretstores.SecretStoreResolver, static Certs, logger logger.Logger) (*CertReloader, error) {
+	secrets, err := ParseCertSecrets(properties)
+	if err != nil || secrets == nil {
+		return nil, err
+	}
+	if resolver == nil {
+		return nil, errors.New("no resolver for the secret store " + secrets.SecretStore)
+	}
+	store, err := resolver(secrets.SecretStore)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewCertReloader(store, *secrets, static, logger)
+	err = r.Reload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go r.Run(ctx)
+
+	return r, nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeSecretStore struct {
+	secretstores.SecretStore
+	lock    sync.Mutex
+	secrets map[string]map[string]string
+}
+
+func (f *fakeSecretStore) set(name string, data map[string]string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.secrets[name] = data
+}
+
+func (f *fakeSecretStore) GetSecret(_ context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return secretstores.GetSecretResponse{Data: f.secrets[req.Name]}, nil
+}
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// newTestCert returns a certificate signed by the parent, or a self-signed CA if the parent is nil.
+func newTestCert(t *testing.T, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func TestParseCertSecrets(t *testing.T) {
+	t.Run("no secret store", func(t *testing.T) {
+		s, err := ParseCertSecrets(map[string]string{"url": "tcp://localhost:1883"})
+		require.NoError(t, err)
+		assert.Nil(t, s)
+	})
+
+	t.Run("valid references", func(t *testing.T) {
+		s, err := ParseCertSecrets(map[string]string{
+			"secretStore": "vault", "caCertSecret": "mqtt-ca", "clientCertSecret": "mqtt-client:tls.crt", "clientKeySecret": "mqtt-client:tls.key",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "mqtt-client:tls.crt", s.ClientCert)
+		assert.Equal(t, defaultCertRefreshInterval, s.RefreshInterval)
+	})
+
+	t.Run("references without secret store", func(t *testing.T) {
+		_, err := ParseCertSecrets(map[string]string{"caCertSecret": "mqtt-ca"})
+		assert.Error(t, err)
+	})
+
+	t.Run("client certificate without key", func(t *testing.T) {
+		_, err := ParseCertSecrets(map[string]string{"secretStore": "vault", "clientCertSecret": "mqtt-client"})
+		assert.Error(t, err)
+	})
+
+	t.Run("refresh interval too short", func(t *testing.T) {
+		_, err := ParseCertSecrets(map[string]string{"secretStore": "vault", "caCertSecret": "mqtt-ca", "certRefreshInterval": "10ms"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCertReloader(t *testing.T) {
+	ca1 := newTestCert(t, nil)
+	ca2 := newTestCert(t, nil)
+	server := newTestCert(t, ca1)
+	client1 := newTestCert(t, ca1)
+	client2 := newTestCert(t, ca1)
+
+	store := &fakeSecretStore{secrets: map[string]map[string]string{
+		"mqtt-ca":     {"mqtt-ca": ca2.certPEM},
+		"mqtt-client": {"tls.crt": client1.certPEM, "tls.key": client1.keyPEM},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, err := StartCertReloader(ctx, map[string]string{
+		"secretStore": "vault", "caCertSecret": "mqtt-ca", "clientCertSecret": "mqtt-client:tls.crt", "clientKeySecret": "mqtt-client:tls.key",
+	}, secretstores.NewMapSecretStoreResolver(map[string]secretstores.SecretStore{"vault": store}), Certs{}, logger.NewLogger("test"))
+	require.NoError(t, err)
+	tlsConfig := r.TLSConfig()
+
+	serverCert, err := tls.X509KeyPair([]byte(server.certPEM), []byte(server.keyPEM))
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}}) //nolint:gosec
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	handshake := func() error {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), tlsConfig)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	clientCert := func() *x509.Certificate {
+		cert, err := tlsConfig.GetClientCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf
+	}
+
+	// The server certificate isn't signed by the first CA bundle
+	assert.Error(t, handshake())
+	assert.Equal(t, client1.cert.SerialNumber, clientCert().SerialNumber)
+
+	// Rotated certificates are used by the TLS config created before
+	store.set("mqtt-ca", map[string]string{"mqtt-ca": ca1.certPEM})
+	store.set("mqtt-client", map[string]string{"tls.crt": client2.certPEM, "tls.key": client2.keyPEM})
+	require.NoError(t, r.Reload(ctx))
+	assert.NoError(t, handshake())
+	assert.Equal(t, client2.cert.SerialNumber, clientCert().SerialNumber)
+
+	// Invalid secrets leave the previous certificates in use
+	store.set("mqtt-client", map[string]string{"tls.crt": "invalid", "tls.key": client1.keyPEM})
+	assert.Error(t, r.Reload(ctx))
+	assert.Equal(t, client2.cert.SerialNumber, clientCert().SerialNumber)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrations versions the schema of the components storing data in PostgreSQL or in databases compatible
+// with it, such as CockroachDB.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/dapr/kit/logger"
+)
+
+// DefaultMetadataTableName is the default name of the table storing the schema versions.
+const DefaultMetadataTableName = "dapr_metadata"
+
+// Names of tables are added to the queries, so they're restricted to identifiers, optionally qualified by a schema.
+var tableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// MigrationFn upgrades the schema by one version, in the transaction of the migrations.
+type MigrationFn func(ctx context.Context, tx *sql.Tx) error
+
+// Migrations applies the migrations of a component.
+type Migrations struct {
+	DB     *sql.DB
+	Logger logger.Logger
+	// Name of the table storing the schema versions, which is created if it doesn't exist.
+	MetadataTableName string
+	// Key of the schema version of the component in the metadata table.
+	// Components sharing a metadata table, such as state stores using different tables, must use different keys.
+	MetadataKey string
+}
+
+// Perform applies the migrations not applied yet, in order, and returns the resulting schema version, which is the
+// number of migrations.
+// Migrations run in a transaction locking the version of the component, so that instances starting at the same time
+// don't apply them twice; if any fails, none is applied.
+func (m Migrations) Perform(ctx context.Context, migrationFns []MigrationFn) (int, error) {
+	if !ValidTableName(m.MetadataTableName) {
+		return 0, fmt.Errorf("invalid metadata table name: %s", m.MetadataTableName)
+	}
+	if m.MetadataKey == "" {
+		return 0, errors.New("missing metadata key")
+	}
+
+	_, err := m.DB.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key text NOT NULL PRIMARY KEY,
+		value text NOT NULL
+	)`, m.MetadataTableName))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create metadata table: %w", err)
+	}
+	// The row must exist before it's locked
+	_, err = m.DB.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (key, value) VALUES ($1, '0') ON CONFLICT (key) DO NOTHING", m.MetadataTableName),
+		m.MetadataKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize schema version: %w", err)
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var value string
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT value FROM %s WHERE key = $1 FOR UPDATE", m.MetadataTableName),
+		m.MetadataKey).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid schema version %q", value)
+	}
+	if version > len(migrationFns) {
+		// The schema was upgraded by a newer version, which may not be compatible
+		return version, fmt.Errorf("schema version %d is newer than the latest supported version %d", version, len(migrationFns))
+	}
+	if version == len(migrationFns) {
+		return version, nil
+	}
+
+	for i := version; i < len(migrationFns); i++ {
+		m.Logger.Infof("Performing schema migration %d", i+1)
+		err = migrationFns[i](ctx, tx)
+		if err != nil {
+			return version, fmt.Errorf("failed to perform schema migration %d: %w", i+1, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %s SET value = $1 WHERE key = $2", m.MetadataTableName),
+		strconv.Itoa(len(migrationFns)), m.MetadataKey)
+	if err != nil {
+		return version, fmt.Errorf("failed to update schema version: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return version, fmt.Errorf("failed to commit schema migrations: %w", err)
+	}
+
+	return len(migrationFns), nil
+}
+
+// ValidTableName returns true if a name can be used as the name of a table in the queries.
+func ValidTableName(name string) bool {
+	return tableNameRegex.MatchString(name)
+}
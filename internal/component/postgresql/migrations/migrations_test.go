@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func newMigrations(t *testing.T) (Migrations, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO dapr_metadata").WithArgs("migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	return Migrations{
+		DB:                db,
+		Logger:            logger.NewLogger("test"),
+		MetadataTableName: DefaultMetadataTableName,
+		MetadataKey:       "migrations",
+	}, mock
+}
+
+func TestPerform(t *testing.T) {
+	var applied []int
+	migrationFns := []MigrationFn{
+		func(ctx context.Context, tx *sql.Tx) error {
+			applied = append(applied, 1)
+			return nil
+		},
+		func(ctx context.Context, tx *sql.Tx) error {
+			applied = append(applied, 2)
+			_, err := tx.ExecContext(ctx, "ALTER TABLE state ADD COLUMN expiredate TIMESTAMP WITH TIME ZONE")
+			return err
+		},
+	}
+
+	t.Run("Applies the migrations not applied yet", func(t *testing.T) {
+		applied = nil
+		m, mock := newMigrations(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT value FROM dapr_metadata WHERE key = \\$1 FOR UPDATE").
+			WithArgs("migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec("ALTER TABLE state").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE dapr_metadata SET value").WithArgs("2", "migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		version, err := m.Perform(context.Background(), migrationFns)
+		require.NoError(t, err)
+		assert.Equal(t, 2, version)
+		assert.Equal(t, []int{2}, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up to date", func(t *testing.T) {
+		applied = nil
+		m, mock := newMigrations(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT value FROM dapr_metadata").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("2"))
+		mock.ExpectRollback()
+
+		version, err := m.Perform(context.Background(), migrationFns)
+		require.NoError(t, err)
+		assert.Equal(t, 2, version)
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failed migration", func(t *testing.T) {
+		applied = nil
+		m, mock := newMigrations(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT value FROM dapr_metadata").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("0"))
+		mock.ExpectExec("ALTER TABLE state").WillReturnError(errors.New("column exists"))
+		mock.ExpectRollback()
+
+		version, err := m.Perform(context.Background(), migrationFns)
+		assert.ErrorContains(t, err, "failed to perform schema migration 2")
+		assert.Equal(t, 0, version)
+		assert.Equal(t, []int{1, 2}, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Newer schema", func(t *testing.T) {
+		m, mock := newMigrations(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT value FROM dapr_metadata").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("3"))
+		mock.ExpectRollback()
+
+		version, err := m.Perform(context.Background(), migrationFns)
+		assert.ErrorContains(t, err, "newer than the latest supported version")
+		assert.Equal(t, 3, version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid metadata table name", func(t *testing.T) {
+		m := Migrations{MetadataTableName: "metadata; DROP TABLE state", MetadataKey: "migrations"}
+		_, err := m.Perform(context.Background(), migrationFns)
+		assert.ErrorContains(t, err, "invalid metadata table name")
+	})
+}
+
+func TestValidTableName(t *testing.T) {
+	assert.True(t, ValidTableName("dapr_metadata"))
+	assert.True(t, ValidTableName("public.dapr_metadata"))
+	assert.False(t, ValidTableName(""))
+	assert.False(t, ValidTableName("1table"))
+	assert.False(t, ValidTableName("dapr-metadata"))
+	assert.False(t, ValidTableName("a.b.c"))
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultConfirmWindow is the default number of publishings awaiting a publisher confirm.
+const DefaultConfirmWindow = 256
+
+// ErrNotConfirmed is returned when the broker nacks a publishing, or the channel is closed before confirming it.
+var ErrNotConfirmed = errors.New("did not receive confirmation of publishing")
+
+// ConfirmWindow bounds the number of publishings awaiting a publisher confirm.
+// Publishers wait for their confirm outside of any lock, so concurrent publishings share the round trips to the broker,
+// while the window keeps the broker from being flooded with unconfirmed messages.
+type ConfirmWindow struct {
+	slots chan struct{}
+}
+
+// NewConfirmWindow returns a window for the given number of publishings.
+func NewConfirmWindow(size int) *ConfirmWindow {
+	return &ConfirmWindow{slots: make(chan struct{}, size)}
+}
+
+// Acquire blocks until a publishing fits in the window. Release must be called once the publishing is confirmed.
+func (w *ConfirmWindow) Acquire(ctx context.Context) error {
+	select {
+	case w.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the slot of a confirmed publishing.
+func (w *ConfirmWindow) Release() {
+	<-w.slots
+}
+
+// InFlight returns the number of publishings awaiting a confirm.
+func (w *ConfirmWindow) InFlight() int {
+	return len(w.slots)
+}
+
+// Confirmation is a publishing awaiting its confirm, as *amqp.DeferredConfirmation.
+type Confirmation interface {
+	Wait() bool
+}
+
+// WaitConfirm blocks until the broker confirms the publishing. It returns ErrNotConfirmed if it was nacked.
+func WaitConfirm(confirm Confirmation) error {
+	if !confirm.Wait() {
+		return ErrNotConfirmed
+	}
+
+	return nil
+}
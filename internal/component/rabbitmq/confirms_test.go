@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConfirmation bool
+
+func (f fakeConfirmation) Wait() bool {
+	return bool(f)
+}
+
+func TestConfirmWindow(t *testing.T) {
+	t.Run("blocks when full", func(t *testing.T) {
+		w := NewConfirmWindow(2)
+		require.NoError(t, w.Acquire(context.Background()))
+		require.NoError(t, w.Acquire(context.Background()))
+		assert.Equal(t, 2, w.InFlight())
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- w.Acquire(context.Background())
+		}()
+		select {
+		case <-acquired:
+			t.Fatal("acquired a slot of a full window")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		w.Release()
+		select {
+		case err := <-acquired:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("slot wasn't released")
+		}
+		assert.Equal(t, 2, w.InFlight())
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		w := NewConfirmWindow(1)
+		require.NoError(t, w.Acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, w.Acquire(ctx), context.DeadlineExceeded)
+		assert.Equal(t, 1, w.InFlight())
+	})
+}
+
+func TestWaitConfirm(t *testing.T) {
+	assert.NoError(t, WaitConfirm(fakeConfirmation(true)))
+	assert.ErrorIs(t, WaitConfirm(fakeConfirmation(false)), ErrNotConfirmed)
+}
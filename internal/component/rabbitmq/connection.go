@@ -0,0 +1,270 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// Same as the defaults of amqp.Dial.
+	defaultHeartbeat = 10 * time.Second
+	defaultLocale    = "en_US"
+)
+
+// ConnectionConfig is the TLS configuration of an AMQP connection, and whether it authenticates with the client
+// certificate, with the SASL EXTERNAL mechanism, instead of the credentials of the URI.
+type ConnectionConfig struct {
+	CACert       string `mapstructure:"caCert"`
+	ClientCert   string `mapstructure:"clientCert"`
+	ClientKey    string `mapstructure:"clientKey"`
+	SaslExternal bool   `mapstructure:"saslExternal"`
+}
+
+// ParseConnectionConfig parses the connection configuration of the component metadata.
+func ParseConnectionConfig(properties map[string]string) (ConnectionConfig, error) {
+	c := ConnectionConfig{}
+	err := metadata.DecodeMetadata(properties, &c)
+	if err != nil {
+		return c, err
+	}
+
+	for name, val := range map[string]string{"caCert": c.CACert, "clientCert": c.ClientCert, "clientKey": c.ClientKey} {
+		if val == "" {
+			continue
+		}
+		if block, _ := pem.Decode([]byte(val)); block == nil {
+			return c, fmt.Errorf("invalid %s", name)
+		}
+	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return c, errors.New("clientCert and clientKey must be set together")
+	}
+	if c.SaslExternal && c.ClientCert == "" {
+		return c, errors.New("saslExternal requires clientCert and clientKey")
+	}
+
+	return c, nil
+}
+
+// TLSConfig returns the TLS configuration of the certificates, or nil if there's none.
+func (c ConnectionConfig) TLSConfig() (*tls.Config, error) {
+	if c.CACert == "" && c.ClientCert == "" {
+		return nil, nil
+	}
+
+	//nolint:gosec
+	tlsConfig := &tls.Config{}
+	if c.CACert != "" {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(c.CACert)) {
+			return nil, errors.New("invalid ca certificate")
+		}
+	}
+	if c.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate and key pair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Dial connects to the broker of the URI with the connection configuration.
+func Dial(uri string, c ConnectionConfig) (*amqp.Connection, error) {
+	config := amqp.Config{
+		Heartbeat: defaultHeartbeat,
+		Locale:    defaultLocale,
+	}
+
+	var err error
+	config.TLSClientConfig, err = c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if c.SaslExternal {
+		u, err := amqp.ParseURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "amqps" {
+			return nil, errors.New("saslExternal requires an amqps connection")
+		}
+		config.SASL = []amqp.Authentication{&amqp.ExternalAuth{}}
+	}
+
+	return amqp.DialConfig(uri, config)
+}
+
+// Topology declares the exchanges, queues and bindings of a component, and sets up the channel.
+type Topology func(ch *amqp.Channel) error
+
+// Connection is an AMQP connection and its channel, which are recovered when the broker closes either of them:
+// it connects again, and declares the topology on the new channel before it's used.
+type Connection struct {
+	uri           string
+	config        ConnectionConfig
+	topology      Topology
+	reconnectWait time.Duration
+	logger        logger.Logger
+
+	lock      sync.RWMutex
+	conn      *amqp.Connection
+	channel   *amqp.Channel
+	recovered chan struct{}
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewConnection returns a Connection, which connects when Connect is called.
+func NewConnection(uri string, config ConnectionConfig, topology Topology, reconnectWait time.Duration, logger logger.Logger) *Connection {
+	return &Connection{
+		uri:           uri,
+		config:        config,
+		topology:      topology,
+		reconnectWait: reconnectWait,
+		logger:        logger,
+		recovered:     make(chan struct{}),
+		closeCh:       make(chan struct{}),
+	}
+}
+
+// Connect connects to the broker and declares the topology, then keeps the connection up until Close is called.
+func (c *Connection) Connect() error {
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	c.wg.Add(1)
+	go c.recoverForever()
+
+	return nil
+}
+
+func (c *Connection) connect() error {
+	conn, err := Dial(c.uri, c.config)
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if err = c.topology(ch); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.lock.Lock()
+	c.conn = conn
+	c.channel = ch
+	// Wake up those waiting for a new channel
+	close(c.recovered)
+	c.recovered = make(chan struct{})
+	c.lock.Unlock()
+
+	return nil
+}
+
+func (c *Connection) recoverForever() {
+	defer c.wg.Done()
+
+	for {
+		c.lock.RLock()
+		conn, ch := c.conn, c.channel
+		c.lock.RUnlock()
+
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+		var err *amqp.Error
+		select {
+		case <-c.closeCh:
+			return
+		case err = <-connClosed:
+		case err = <-chClosed:
+		}
+		c.logger.Warnf("rabbitmq connection closed, recovering in %s: %v", c.reconnectWait, err)
+		conn.Close()
+
+		for {
+			select {
+			case <-c.closeCh:
+				return
+			case <-time.After(c.reconnectWait):
+			}
+			rErr := c.connect()
+			if rErr == nil {
+				c.logger.Info("rabbitmq connection recovered")
+				break
+			}
+			c.logger.Errorf("rabbitmq connection recovery failed, retrying in %s: %v", c.reconnectWait, rErr)
+		}
+	}
+}
+
+// Channel returns the current channel.
+func (c *Connection) Channel() *amqp.Channel {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.channel
+}
+
+// NextChannel blocks until the channel is recovered, if the current channel is ch, and returns the new channel.
+func (c *Connection) NextChannel(ctx context.Context, ch *amqp.Channel) (*amqp.Channel, error) {
+	c.lock.RLock()
+	current, recovered := c.channel, c.recovered
+	c.lock.RUnlock()
+	if current != ch {
+		return current, nil
+	}
+
+	select {
+	case <-recovered:
+		return c.Channel(), nil
+	case <-c.closeCh:
+		return nil, errors.New("connection is closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops recovering the connection, and closes it.
+func (c *Connection) Close() error {
+	close(c.closeCh)
+	c.wg.Wait()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+
+	return c.conn.Close()
+}
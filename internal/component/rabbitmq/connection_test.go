@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCert returns the PEM encoded self-signed certificate and key.
+func newTestCert(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestParseConnectionConfig(t *testing.T) {
+	cert, key := newTestCert(t)
+
+	t.Run("no TLS", func(t *testing.T) {
+		c, err := ParseConnectionConfig(map[string]string{})
+		require.NoError(t, err)
+		tlsConfig, err := c.TLSConfig()
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+
+	t.Run("external auth", func(t *testing.T) {
+		c, err := ParseConnectionConfig(map[string]string{
+			"caCert":       cert,
+			"clientCert":   cert,
+			"clientKey":    key,
+			"saslExternal": "true",
+		})
+		require.NoError(t, err)
+		assert.True(t, c.SaslExternal)
+
+		tlsConfig, err := c.TLSConfig()
+		require.NoError(t, err)
+		assert.NotNil(t, tlsConfig.RootCAs)
+		assert.Len(t, tlsConfig.Certificates, 1)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseConnectionConfig(map[string]string{"caCert": "not a certificate"})
+		assert.ErrorContains(t, err, "invalid caCert")
+
+		_, err = ParseConnectionConfig(map[string]string{"clientCert": cert})
+		assert.ErrorContains(t, err, "must be set together")
+
+		_, err = ParseConnectionConfig(map[string]string{"saslExternal": "true"})
+		assert.ErrorContains(t, err, "requires clientCert")
+	})
+}
+
+func TestDialExternalAuthRequiresTLS(t *testing.T) {
+	cert, key := newTestCert(t)
+
+	_, err := Dial("amqp://localhost:5672", ConnectionConfig{ClientCert: cert, ClientKey: key, SaslExternal: true})
+	assert.ErrorContains(t, err, "requires an amqps connection")
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const defaultPipelineMaxBatchSize = 100
+
+// ErrBatcherClosed is returned for commands issued after the batcher was closed.
+var ErrBatcherClosed = errors.New("redis pipeline batcher is closed")
+
+// Doer executes a single Redis command.
+// It's implemented by the Redis clients and by PipelineBatcher.
+type Doer interface {
+	Do(ctx context.Context, args ...interface{}) *redis.Cmd
+}
+
+// NewDoer returns a PipelineBatcher if a pipeline window is configured in the settings, or the client otherwise.
+func NewDoer(client redis.UniversalClient, s *Settings) Doer {
+	if s == nil || s.PipelineWindow <= 0 {
+		return client
+	}
+
+	return NewPipelineBatcher(client, time.Duration(s.PipelineWindow), s.PipelineMaxBatchSize)
+}
+
+type batchRequest struct {
+	args []interface{}
+	cmd  *redis.Cmd
+	done chan struct{}
+}
+
+// PipelineBatcher groups the commands issued concurrently within a short window
+// and sends them to Redis in a single pipeline, trading a little latency for fewer round trips.
+type PipelineBatcher struct {
+	client       redis.UniversalClient
+	window       time.Duration
+	maxBatchSize int
+
+	reqCh   chan *batchRequest
+	closeCh chan struct{}
+	closed  bool
+	lock    sync.RWMutex
+	wg      sync.WaitGroup
+}
+
+// NewPipelineBatcher returns a new PipelineBatcher that flushes every window or every maxBatchSize commands.
+func NewPipelineBatcher(client redis.UniversalClient, window time.Duration, maxBatchSize int) *PipelineBatcher {
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultPipelineMaxBatchSize
+	}
+
+	b := &PipelineBatcher{
+		client:       client,
+		window:       window,
+		maxBatchSize: maxBatchSize,
+		reqCh:        make(chan *batchRequest, maxBatchSize),
+		closeCh:      make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+// Do queues the command and waits for the pipeline it's part of to be executed.
+func (b *PipelineBatcher) Do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	req := &batchRequest{
+		args: args,
+		done: make(chan struct{}),
+	}
+
+	// The read lock guarantees the batcher isn't stopped while the request is being queued
+	b.lock.RLock()
+	if b.closed {
+		b.lock.RUnlock()
+		return errorCmd(ctx, args, ErrBatcherClosed)
+	}
+	select {
+	case b.reqCh <- req:
+		b.lock.RUnlock()
+	case <-ctx.Done():
+		b.lock.RUnlock()
+		return errorCmd(ctx, args, ctx.Err())
+	}
+
+	select {
+	case <-req.done:
+		return req.cmd
+	case <-ctx.Done():
+		return errorCmd(ctx, args, ctx.Err())
+	}
+}
+
+// Close flushes the pending commands and stops the batcher.
+func (b *PipelineBatcher) Close() error {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.closeCh)
+	}
+	b.lock.Unlock()
+	b.wg.Wait()
+
+	return nil
+}
+
+func (b *PipelineBatcher) run() {
+	defer b.wg.Done()
+
+	for {
+		var first *batchRequest
+		select {
+		case first = <-b.reqCh:
+		case <-b.closeCh:
+			b.drain()
+			return
+		}
+
+		batch := []*batchRequest{first}
+		timer := time.NewTimer(b.window)
+	collect:
+		for len(batch) < b.maxBatchSize {
+			select {
+			case req := <-b.reqCh:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			case <-b.closeCh:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.exec(batch)
+	}
+}
+
+// drain executes the commands queued before the batcher was closed.
+func (b *PipelineBatcher) drain() {
+	for {
+		batch := make([]*batchRequest, 0, b.maxBatchSize)
+	collect:
+		for len(batch) < b.maxBatchSize {
+			select {
+			case req := <-b.reqCh:
+				batch = append(batch, req)
+			default:
+				break collect
+			}
+		}
+		if len(batch) == 0 {
+			return
+		}
+		b.exec(batch)
+	}
+}
+
+func (b *PipelineBatcher) exec(batch []*batchRequest) {
+	// Requests may come from different contexts: the pipeline is executed with its own.
+	ctx := context.Background()
+	pipe := b.client.Pipeline()
+	for _, req := range batch {
+		req.cmd = pipe.Do(ctx, req.args...)
+	}
+
+	// Errors are reported on each command
+	_, _ = pipe.Exec(ctx)
+
+	for _, req := range batch {
+		close(req.done)
+	}
+}
+
+func errorCmd(ctx context.Context, args []interface{}, err error) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	cmd.SetErr(err)
+
+	return cmd
+}
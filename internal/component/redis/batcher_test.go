@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDoer(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer client.Close()
+
+	t.Run("without pipeline window returns the client", func(t *testing.T) {
+		assert.Equal(t, client, NewDoer(client, &Settings{}))
+		assert.Equal(t, client, NewDoer(client, nil))
+	})
+
+	t.Run("with pipeline window returns a batcher", func(t *testing.T) {
+		doer := NewDoer(client, &Settings{PipelineWindow: Duration(time.Millisecond), PipelineMaxBatchSize: 10})
+		batcher, ok := doer.(*PipelineBatcher)
+		require.True(t, ok)
+		assert.Equal(t, time.Millisecond, batcher.window)
+		assert.Equal(t, 10, batcher.maxBatchSize)
+		batcher.Close()
+	})
+}
+
+func TestPipelineBatcher(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+
+	t.Run("executes concurrent commands", func(t *testing.T) {
+		b := NewPipelineBatcher(client, 5*time.Millisecond, 4)
+		defer b.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				key := fmt.Sprintf("key%d", i)
+				assert.NoError(t, b.Do(context.Background(), "SET", key, i).Err())
+				val, err := b.Do(context.Background(), "GET", key).Text()
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("%d", i), val)
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("reports errors per command", func(t *testing.T) {
+		b := NewPipelineBatcher(client, time.Millisecond, 0)
+		defer b.Close()
+
+		err := b.Do(context.Background(), "GET", "missing").Err()
+		assert.Equal(t, redis.Nil, err)
+	})
+
+	t.Run("fails after close", func(t *testing.T) {
+		b := NewPipelineBatcher(client, time.Millisecond, 0)
+		b.Close()
+
+		err := b.Do(context.Background(), "GET", "key1").Err()
+		assert.ErrorIs(t, err, ErrBatcherClosed)
+	})
+}
@@ -24,10 +24,17 @@ const (
 	maxRetryBackoff        = "maxRetryBackoff"
 	ttlInSeconds           = "ttlInSeconds"
 	queryIndexes           = "queryIndexes"
+	valueType              = "valueType"
+	valueFormat            = "valueFormat" // Alias of valueType
 	defaultBase            = 10
 	defaultBitSize         = 0
 	defaultMaxRetries      = 3
 	defaultMaxRetryBackoff = time.Second * 2
+
+	// ValueTypeString stores the values of the state store as strings in hashes.
+	ValueTypeString = "string"
+	// ValueTypeJSON stores the values of the state store as RedisJSON documents.
+	ValueTypeJSON = "json"
 )
 
 type Metadata struct {
@@ -35,6 +42,7 @@ type Metadata struct {
 	MaxRetryBackoff time.Duration
 	TTLInSeconds    *int
 	QueryIndexes    string
+	ValueType       string
 }
 
 func ParseRedisMetadata(properties map[string]string) (Metadata, error) {
@@ -72,5 +80,17 @@ func ParseRedisMetadata(properties map[string]string) (Metadata, error) {
 	if val, ok := properties[queryIndexes]; ok && val != "" {
 		m.QueryIndexes = val
 	}
+
+	m.ValueType = ValueTypeString
+	val, ok := properties[valueType]
+	if !ok || val == "" {
+		val, ok = properties[valueFormat]
+	}
+	if ok && val != "" {
+		if val != ValueTypeString && val != ValueTypeJSON {
+			return m, fmt.Errorf("redis store error: invalid valueType %s: must be %s or %s", val, ValueTypeString, ValueTypeJSON)
+		}
+		m.ValueType = val
+	}
 	return m, nil
 }
@@ -14,17 +14,25 @@ limitations under the License.
 package redis
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+
+	"github.com/dapr/components-contrib/internal/authentication/spiffe"
 )
 
 const (
 	ClusterType = "cluster"
 	NodeType    = "node"
+
+	ReadFromMaster  = "master"
+	ReadFromReplica = "replica"
 )
 
 func ParseClientFromProperties(properties map[string]string, defaultSettings *Settings) (client redis.UniversalClient, settings *Settings, err error) {
@@ -37,21 +45,91 @@ func ParseClientFromProperties(properties map[string]string, defaultSettings *Se
 	if err != nil {
 		return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
 	}
+	switch settings.ReadFrom {
+	case "", ReadFromMaster, ReadFromReplica:
+	default:
+		return nil, nil, fmt.Errorf("redis client configuration error: invalid readFrom %s, accepted values are %s or %s", settings.ReadFrom, ReadFromMaster, ReadFromReplica)
+	}
+	if settings.Failover && settings.ReadFrom == ReadFromReplica && settings.DB != 0 {
+		// Reads are routed to the replicas by the failover cluster client, which can't select a DB
+		return nil, nil, fmt.Errorf("redis client configuration error: readFrom %s with failover requires the DB 0", ReadFromReplica)
+	}
+	tlsConfig, spiffeConfig, err := newTLSConfig(settings, properties)
+	if err != nil {
+		return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
+	}
 	if settings.Failover {
-		return newFailoverClient(settings), settings, nil
+		tlsServerNames, err := parseTLSServerNames(settings.SentinelTLSServerNames)
+		if err != nil {
+			spiffeConfig.Close()
+			return nil, nil, fmt.Errorf("redis client configuration error: %w", err)
+		}
+		client = newFailoverClient(settings, tlsConfig, tlsServerNames)
+	} else {
+		client = newClient(settings, tlsConfig)
+	}
+	if spiffeConfig != nil {
+		client = &spiffeClient{UniversalClient: client, spiffe: spiffeConfig}
 	}
 
-	return newClient(settings), settings, nil
+	return client, settings, nil
 }
 
-func newFailoverClient(s *Settings) redis.UniversalClient {
+// spiffeClient is a client that closes the source of its SPIFFE client certificates when closed.
+type spiffeClient struct {
+	redis.UniversalClient
+	spiffe *spiffe.Config
+}
+
+func (c *spiffeClient) Close() error {
+	err := c.UniversalClient.Close()
+	if spiffeErr := c.spiffe.Close(); err == nil {
+		err = spiffeErr
+	}
+
+	return err
+}
+
+// newTLSConfig returns the TLS config of the connections, or nil if TLS isn't enabled.
+// The client certificate is obtained from the SPIFFE Workload API if configured, in which case the returned
+// SPIFFE configuration must be closed with the client.
+func newTLSConfig(s *Settings, properties map[string]string) (*tls.Config, *spiffe.Config, error) {
+	spiffeConfig, err := spiffe.Parse(properties)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.EnableTLS {
+		if spiffeConfig != nil {
+			return nil, nil, errors.New("spiffeEndpointSocket requires enableTLS")
+		}
+		return nil, nil, nil
+	}
+
+	/* #nosec */
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: s.EnableTLS,
+	}
+	if spiffeConfig != nil {
+		err = spiffeConfig.ConfigureTLS(tlsConfig)
+		if err != nil {
+			spiffeConfig.Close()
+			return nil, nil, err
+		}
+	}
+
+	return tlsConfig, spiffeConfig, nil
+}
+
+func newFailoverClient(s *Settings, tlsConfig *tls.Config, tlsServerNames map[string]string) redis.UniversalClient {
 	if s == nil {
 		return nil
 	}
 	opts := &redis.FailoverOptions{
 		DB:                 s.DB,
 		MasterName:         s.SentinelMasterName,
-		SentinelAddrs:      []string{s.Host},
+		SentinelAddrs:      strings.Split(s.Host, ","),
+		SentinelUsername:   s.SentinelUsername,
+		SentinelPassword:   s.SentinelPassword,
 		Password:           s.Password,
 		Username:           s.Username,
 		MaxRetries:         s.RedisMaxRetries,
@@ -68,23 +146,30 @@ func newFailoverClient(s *Settings) redis.UniversalClient {
 		IdleTimeout:        time.Duration(s.IdleTimeout),
 	}
 
-	/* #nosec */
-	if s.EnableTLS {
-		opts.TLSConfig = &tls.Config{
-			InsecureSkipVerify: s.EnableTLS,
+	if tlsConfig != nil {
+		opts.TLSConfig = tlsConfig
+		if len(tlsServerNames) > 0 {
+			opts.Dialer = newTLSServerNameDialer(opts.TLSConfig, time.Duration(s.DialTimeout), tlsServerNames)
 		}
 	}
 
-	if s.RedisType == ClusterType {
-		opts.SentinelAddrs = strings.Split(s.Host, ",")
+	if s.ReadFrom == ReadFromReplica {
+		// The failover cluster client sends the read-only commands to a random node, master or replica, and all the
+		// other commands to the master. Setting SlaveOnly on the failover client instead would send the writes to a
+		// replica too, which rejects them.
+		opts.RouteRandomly = true
 
 		return redis.NewFailoverClusterClient(opts)
 	}
 
+	if s.RedisType == ClusterType {
+		return redis.NewFailoverClusterClient(opts)
+	}
+
 	return redis.NewFailoverClient(opts)
 }
 
-func newClient(s *Settings) redis.UniversalClient {
+func newClient(s *Settings, tlsConfig *tls.Config) redis.UniversalClient {
 	if s == nil {
 		return nil
 	}
@@ -105,13 +190,9 @@ func newClient(s *Settings) redis.UniversalClient {
 			PoolTimeout:        time.Duration(s.PoolTimeout),
 			IdleCheckFrequency: time.Duration(s.IdleCheckFrequency),
 			IdleTimeout:        time.Duration(s.IdleTimeout),
+			ReadOnly:           s.ReadFrom == ReadFromReplica,
 		}
-		/* #nosec */
-		if s.EnableTLS {
-			options.TLSConfig = &tls.Config{
-				InsecureSkipVerify: s.EnableTLS,
-			}
-		}
+		options.TLSConfig = tlsConfig
 
 		return redis.NewClusterClient(options)
 	}
@@ -135,12 +216,53 @@ func newClient(s *Settings) redis.UniversalClient {
 		IdleTimeout:        time.Duration(s.IdleTimeout),
 	}
 
-	/* #nosec */
-	if s.EnableTLS {
-		options.TLSConfig = &tls.Config{
-			InsecureSkipVerify: s.EnableTLS,
+	options.TLSConfig = tlsConfig
+
+	return redis.NewClient(options)
+}
+
+// parseTLSServerNames parses a comma-separated list of "host:port=serverName" pairs.
+func parseTLSServerNames(val string) (map[string]string, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	res := map[string]string{}
+	for _, pair := range strings.Split(val, ",") {
+		addr, serverName, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || addr == "" || serverName == "" {
+			return nil, fmt.Errorf("invalid sentinelTLSServerNames entry '%s', expected format host:port=serverName", pair)
 		}
+		res[addr] = serverName
 	}
 
-	return redis.NewClient(options)
+	return res, nil
+}
+
+// newTLSServerNameDialer returns a dialer establishing TLS connections with a server name specific to each address.
+// Addresses without a configured server name use the base TLS configuration.
+func newTLSServerNameDialer(tlsConfig *tls.Config, dialTimeout time.Duration, serverNames map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dialTimeout == 0 {
+		// Same default as the Redis client
+		dialTimeout = 5 * time.Second
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conf := tlsConfig
+		if serverName, ok := serverNames[addr]; ok {
+			conf = tlsConfig.Clone()
+			conf.ServerName = serverName
+		}
+
+		netDialer := &net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 5 * time.Minute,
+		}
+		tlsDialer := &tls.Dialer{
+			NetDialer: netDialer,
+			Config:    conf,
+		}
+
+		return tlsDialer.DialContext(ctx, network, addr)
+	}
 }
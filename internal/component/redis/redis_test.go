@@ -14,11 +14,22 @@ limitations under the License.
 package redis
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -42,6 +53,8 @@ const (
 	enableTLS             = "enableTLS"
 	failover              = "failover"
 	sentinelMasterName    = "sentinelMasterName"
+	sentinelPassword      = "sentinelPassword"
+	readFrom              = "readFrom"
 )
 
 func getFakeProperties() map[string]string {
@@ -101,6 +114,30 @@ func TestParseRedisMetadata(t *testing.T) {
 		assert.Equal(t, "master", m.SentinelMasterName)
 	})
 
+	t.Run("sentinel options", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[sentinelPassword] = "sentinelFakePassword"
+		fakeProperties[readFrom] = ReadFromReplica
+
+		// act
+		m := &Settings{}
+		err := m.Decode(fakeProperties)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "sentinelFakePassword", m.SentinelPassword)
+		assert.Equal(t, fakeProperties[password], m.Password)
+		assert.Equal(t, ReadFromReplica, m.ReadFrom)
+	})
+
+	t.Run("invalid readFrom", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[readFrom] = "any"
+
+		_, _, err := ParseClientFromProperties(fakeProperties, nil)
+		assert.Error(t, err)
+	})
+
 	t.Run("host is not given", func(t *testing.T) {
 		fakeProperties := getFakeProperties()
 
@@ -136,3 +173,181 @@ func TestParseRedisMetadata(t *testing.T) {
 		assert.True(t, m.RedisMinRetryInterval == -1)
 	})
 }
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tlsConfig, _, err := newTLSConfig(&Settings{}, map[string]string{})
+		assert.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		tlsConfig, _, err := newTLSConfig(&Settings{EnableTLS: true}, map[string]string{})
+		assert.NoError(t, err)
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+	})
+
+	t.Run("spiffe without tls", func(t *testing.T) {
+		_, _, err := newTLSConfig(&Settings{}, map[string]string{"spiffeEndpointSocket": "unix:///run/spire/sockets/agent.sock"})
+		assert.ErrorContains(t, err, "spiffeEndpointSocket requires enableTLS")
+	})
+}
+
+func TestParseTLSServerNames(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		names, err := parseTLSServerNames("")
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		names, err := parseTLSServerNames("10.0.0.1:26379=sentinel-1.example.com, 10.0.0.2:26379=sentinel-2.example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"10.0.0.1:26379": "sentinel-1.example.com",
+			"10.0.0.2:26379": "sentinel-2.example.com",
+		}, names)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseTLSServerNames("10.0.0.1:26379")
+		assert.Error(t, err)
+	})
+}
+
+func TestTLSServerNameDialer(t *testing.T) {
+	serverNames := make(chan string, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverNames <- hello.ServerName
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	/* #nosec */
+	dialer := newTLSServerNameDialer(&tls.Config{InsecureSkipVerify: true}, 0, map[string]string{
+		addr: "sentinel-1.example.com",
+	})
+	conn, err := dialer(context.Background(), "tcp", addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "sentinel-1.example.com", <-serverNames)
+}
+
+func TestFailoverClientReadFromReplica(t *testing.T) {
+	master := miniredis.RunT(t)
+	replica := miniredis.RunT(t)
+	sentinelAddr := startFakeSentinel(t, master.Addr(), replica.Addr())
+
+	client, _, err := ParseClientFromProperties(map[string]string{
+		host:               sentinelAddr,
+		failover:           "true",
+		sentinelMasterName: "mymaster",
+		readFrom:           ReadFromReplica,
+	}, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, client.Set(ctx, "key", "value", 0).Err())
+	require.NoError(t, client.Del(ctx, "deleted").Err())
+
+	// Writes reach the master
+	val, err := master.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", val)
+	assert.False(t, replica.Exists("key"))
+
+	t.Run("requires the DB 0", func(t *testing.T) {
+		_, _, err := ParseClientFromProperties(map[string]string{
+			host:               sentinelAddr,
+			db:                 "2",
+			failover:           "true",
+			sentinelMasterName: "mymaster",
+			readFrom:           ReadFromReplica,
+		}, nil)
+		assert.Error(t, err)
+	})
+}
+
+// startFakeSentinel starts a server answering the Sentinel commands of the client with the given master and replica.
+func startFakeSentinel(t *testing.T, masterAddr, replicaAddr string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	masterHost, masterPort, _ := net.SplitHostPort(masterAddr)
+	replicaHost, replicaPort, _ := net.SplitHostPort(replicaAddr)
+
+	writeArray := func(w *bufio.Writer, vals ...string) {
+		fmt.Fprintf(w, "*%d\r\n", len(vals))
+		for _, v := range vals {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+		}
+	}
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		w := bufio.NewWriter(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			args := make([]string, n)
+			for i := range args {
+				if _, err = r.ReadString('\n'); err != nil {
+					return
+				}
+				arg, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				args[i] = strings.ToLower(strings.TrimSpace(arg))
+			}
+
+			switch {
+			case len(args) > 1 && args[0] == "sentinel" && args[1] == "get-master-addr-by-name":
+				writeArray(w, masterHost, masterPort)
+			case len(args) > 1 && args[0] == "sentinel" && (args[1] == "slaves" || args[1] == "replicas"):
+				w.WriteString("*1\r\n")
+				writeArray(w, "ip", replicaHost, "port", replicaPort, "flags", "slave")
+			case len(args) > 1 && args[0] == "sentinel" && args[1] == "sentinels":
+				w.WriteString("*0\r\n")
+			case len(args) > 0 && args[0] == "subscribe":
+				for i, channel := range args[1:] {
+					fmt.Fprintf(w, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n", len(channel), channel, i+1)
+				}
+			case len(args) > 0 && args[0] == "ping":
+				w.WriteString("+PONG\r\n")
+			default:
+				w.WriteString("-ERR unknown command\r\n")
+			}
+			if err = w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
@@ -74,9 +74,25 @@ type Settings struct {
 	SentinelMasterName string `mapstructure:"sentinelMasterName"`
 	// Use Redis Sentinel for automatic failover.
 	Failover bool `mapstructure:"failover"`
+	// The Sentinel username, if different from the Redis username
+	SentinelUsername string `mapstructure:"sentinelUsername"`
+	// The Sentinel password, if different from the Redis password
+	SentinelPassword string `mapstructure:"sentinelPassword"`
+	// TLS server names to use for each Sentinel endpoint, as a comma-separated list of "host:port=serverName"
+	SentinelTLSServerNames string `mapstructure:"sentinelTLSServerNames"`
+	// Where read-only commands are routed to: master (default) or replica.
+	// With failover, replica sends them to a random node, master or replica; writes always go to the master.
+	ReadFrom string `mapstructure:"readFrom"`
 
 	// A flag to enables TLS by setting InsecureSkipVerify to true
 	EnableTLS bool `mapstructure:"enableTLS"`
+
+	// Time window during which concurrent commands are grouped into a single pipeline.
+	// Default is 0, which disables pipelining.
+	PipelineWindow Duration `mapstructure:"pipelineWindow"`
+	// Maximum number of commands sent in a single pipeline.
+	// Default is 100.
+	PipelineMaxBatchSize int `mapstructure:"pipelineMaxBatchSize"`
 }
 
 func (s *Settings) Decode(in interface{}) error {
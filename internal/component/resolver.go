@@ -0,0 +1,31 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package component contains the helpers shared by the components of all kinds.
+package component
+
+import "fmt"
+
+// NewMapResolver returns a function resolving a fixed set of components, keyed by component name.
+// The kind of the components, such as "state store", is used in the error returned for unknown names.
+func NewMapResolver[T any](kind string, components map[string]T) func(name string) (T, error) {
+	return func(name string) (T, error) {
+		c, ok := components[name]
+		if !ok {
+			var zero T
+			return zero, fmt.Errorf("%s %s not found", kind, name)
+		}
+
+		return c, nil
+	}
+}
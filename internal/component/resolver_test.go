@@ -0,0 +1,32 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package component
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMapResolver(t *testing.T) {
+	resolve := NewMapResolver("state store", map[string]int{"a": 1})
+
+	c, err := resolve("a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, c)
+
+	_, err = resolve("b")
+	assert.EqualError(t, err, "state store b not found")
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dialer parses the name resolution settings shared by components making outbound connections,
+// for environments where the system resolver doesn't know the hosts of the services.
+package dialer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const defaultDNSPort = "53"
+
+// Metadata is the name resolution configuration in the component metadata.
+type Metadata struct {
+	// DNS servers queried instead of the system resolver, as host or host:port, in order.
+	DNSServers []string `mapstructure:"dnsServers"`
+	// Static addresses of hosts, as host=ip, which take precedence over DNS.
+	HostOverrides []string `mapstructure:"hostOverrides"`
+}
+
+// Config is a parsed name resolution configuration.
+type Config struct {
+	dnsServers    []string
+	hostOverrides map[string]string
+}
+
+// Parse returns the name resolution configuration of the component metadata, or nil if the system resolver is used.
+func Parse(properties map[string]string) (*Config, error) {
+	m := Metadata{}
+	err := metadata.DecodeMetadata(properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Config{
+		hostOverrides: make(map[string]string, len(m.HostOverrides)),
+	}
+	for _, s := range m.DNSServers {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, defaultDNSPort)
+		}
+		c.dnsServers = append(c.dnsServers, s)
+	}
+	for _, o := range m.HostOverrides {
+		host, ip, ok := strings.Cut(strings.TrimSpace(o), "=")
+		if !ok && strings.TrimSpace(o) == "" {
+			continue
+		}
+		host, ip = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(ip)
+		if host == "" || net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid host override '%s': must be host=ip", o)
+		}
+		c.hostOverrides[host] = ip
+	}
+	if len(c.dnsServers) == 0 && len(c.hostOverrides) == 0 {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+// Dialer dials connections resolving hosts with the configured overrides and DNS servers.
+type Dialer struct {
+	config *Config
+	base   *net.Dialer
+}
+
+// Dialer returns a dialer using the settings of base, such as the timeouts.
+// If c is nil, the dialer resolves hosts like base.
+func (c *Config) Dialer(base *net.Dialer) *Dialer {
+	if base == nil {
+		base = &net.Dialer{}
+	}
+	d := *base
+	if c != nil && len(c.dnsServers) > 0 {
+		d.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial:     c.dialDNS(base),
+		}
+	}
+
+	return &Dialer{config: c, base: &d}
+}
+
+// dialDNS returns the function connecting the resolver to the first DNS server available.
+func (c *Config) dialDNS(base *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var err error
+		for _, server := range c.dnsServers {
+			var conn net.Conn
+			conn, err = base.DialContext(ctx, network, server)
+			if err == nil {
+				return conn, nil
+			}
+		}
+
+		return nil, fmt.Errorf("no DNS server available: %w", err)
+	}
+}
+
+// DialContext connects to the address on the named network, replacing overridden hosts with their address.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.config != nil && len(d.config.hostOverrides) > 0 {
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if ip, ok := d.config.hostOverrides[strings.ToLower(host)]; ok {
+				address = net.JoinHostPort(ip, port)
+			}
+		}
+	}
+
+	return d.base.DialContext(ctx, network, address)
+}
+
+// Dial connects to the address on the named network, replacing overridden hosts with their address.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// ConfigureTransport makes an HTTP transport dial connections with the configuration.
+// It doesn't change the transport if c is nil.
+func (c *Config) ConfigureTransport(transport *http.Transport, base *net.Dialer) {
+	if c == nil {
+		return
+	}
+	transport.DialContext = c.Dialer(base).DialContext
+}
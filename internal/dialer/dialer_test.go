@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dialer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("system resolver", func(t *testing.T) {
+		c, err := Parse(map[string]string{"url": "http://example.com"})
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("valid settings", func(t *testing.T) {
+		c, err := Parse(map[string]string{"dnsServers": "10.0.0.53, 10.0.1.53:5353", "hostOverrides": "Broker.internal=10.1.2.3,api.internal = ::1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.53:53", "10.0.1.53:5353"}, c.dnsServers)
+		assert.Equal(t, map[string]string{"broker.internal": "10.1.2.3", "api.internal": "::1"}, c.hostOverrides)
+	})
+
+	t.Run("invalid host override", func(t *testing.T) {
+		_, err := Parse(map[string]string{"hostOverrides": "broker.internal=broker.local"})
+		assert.Error(t, err)
+
+		_, err = Parse(map[string]string{"hostOverrides": "broker.internal"})
+		assert.Error(t, err)
+	})
+}
+
+func newListener(t *testing.T) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	return l
+}
+
+func TestDialer(t *testing.T) {
+	l := newListener(t)
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+
+	t.Run("host override", func(t *testing.T) {
+		c, err := Parse(map[string]string{"hostOverrides": "broker.internal=127.0.0.1"})
+		require.NoError(t, err)
+		conn, err := c.Dialer(nil).Dial("tcp", net.JoinHostPort("BROKER.internal", port))
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		var c *Config
+		conn, err := c.Dialer(&net.Dialer{}).Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("dns server fallback", func(t *testing.T) {
+		// The first server isn't listening anymore
+		closed := newListener(t)
+		closed.Close()
+		c, err := Parse(map[string]string{"dnsServers": closed.Addr().String() + "," + l.Addr().String()})
+		require.NoError(t, err)
+
+		conn, err := c.dialDNS(&net.Dialer{})(context.Background(), "tcp", "")
+		require.NoError(t, err)
+		assert.Equal(t, l.Addr().String(), conn.RemoteAddr().String())
+		conn.Close()
+	})
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dapr/components-contrib/internal/dialer"
+	"github.com/dapr/components-contrib/internal/proxy"
+)
+
+// ConfigureTransport applies the proxy and name resolution settings of the component metadata to an HTTP transport,
+// which dials connections with the settings of base.
+func ConfigureTransport(transport *http.Transport, base *net.Dialer, properties map[string]string) error {
+	proxyConfig, err := proxy.Parse(properties)
+	if err != nil {
+		return err
+	}
+	dialerConfig, err := dialer.Parse(properties)
+	if err != nil {
+		return err
+	}
+
+	proxyConfig.ConfigureTransport(transport)
+	dialerConfig.ConfigureTransport(transport, base)
+
+	return nil
+}
+
+// NewTransport returns a copy of the default HTTP transport with the proxy and name resolution settings of the component metadata.
+func NewTransport(properties map[string]string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Same as the dialer of the default transport
+	base := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	err := ConfigureTransport(transport, base, properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport, nil
+}
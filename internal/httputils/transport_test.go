@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, transport *http.Transport, url string) string {
+	t.Helper()
+
+	res, err := (&http.Client{Transport: transport}).Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestNewTransport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "backend "+r.Host)
+	}))
+	defer backend.Close()
+	_, port, err := net.SplitHostPort(backend.Listener.Addr().String())
+	require.NoError(t, err)
+
+	t.Run("host override", func(t *testing.T) {
+		transport, err := NewTransport(map[string]string{"hostOverrides": "backend.example.com=127.0.0.1"})
+		require.NoError(t, err)
+		assert.Equal(t, "backend backend.example.com:"+port, get(t, transport, "http://backend.example.com:"+port))
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		// For plain HTTP requests, the proxy receives the absolute URL of the request
+		proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "proxied "+r.URL.String())
+		}))
+		defer proxyServer.Close()
+
+		transport, err := NewTransport(map[string]string{"proxyURL": proxyServer.URL})
+		require.NoError(t, err)
+		assert.Equal(t, "proxied http://backend.example.com/path", get(t, transport, "http://backend.example.com/path"))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := NewTransport(map[string]string{"proxyURL": "ftp://proxy"})
+		assert.Error(t, err)
+
+		_, err = NewTransport(map[string]string{"hostOverrides": "backend"})
+		assert.Error(t, err)
+	})
+}
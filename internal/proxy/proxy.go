@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proxy parses the proxy configuration shared by components making outbound connections.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	schemeHTTP   = "http"
+	schemeHTTPS  = "https"
+	schemeSOCKS5 = "socks5"
+)
+
+// Metadata is the proxy configuration in the component metadata.
+type Metadata struct {
+	// URL of the proxy, with the http or https scheme for HTTP CONNECT proxies, or socks5 for SOCKS5 proxies.
+	// Credentials are passed as the user info of the URL.
+	ProxyURL string `mapstructure:"proxyURL"`
+	// Comma-separated hosts, domains, IP addresses and CIDR ranges reached without the proxy, as in the NO_PROXY environment variable.
+	NoProxy string `mapstructure:"noProxy"`
+}
+
+// Config is a parsed proxy configuration.
+type Config struct {
+	url     *url.URL
+	noProxy string
+}
+
+// Parse returns the proxy configuration of the component metadata, or nil if no proxy is configured.
+func Parse(properties map[string]string) (*Config, error) {
+	m := Metadata{}
+	err := metadata.DecodeMetadata(properties, &m)
+	if err != nil {
+		return nil, err
+	}
+	if m.ProxyURL == "" {
+		if m.NoProxy != "" {
+			return nil, errors.New("noProxy requires a proxyURL")
+		}
+		return nil, nil
+	}
+
+	u, err := url.Parse(m.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxyURL: %w", err)
+	}
+	switch u.Scheme {
+	case schemeHTTP, schemeHTTPS, schemeSOCKS5:
+	default:
+		return nil, fmt.Errorf("invalid proxyURL: unsupported scheme '%s'", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("invalid proxyURL: missing host")
+	}
+
+	return &Config{url: u, noProxy: m.NoProxy}, nil
+}
+
+// URL returns the URL of the proxy.
+func (c *Config) URL() *url.URL {
+	return c.url
+}
+
+// ProxyFunc returns the function selecting the proxy of HTTP requests, for http.Transport.
+// Requests to hosts matching noProxy and to loopback addresses aren't proxied.
+func (c *Config) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	fn := (&httpproxy.Config{
+		HTTPProxy:  c.url.String(),
+		HTTPSProxy: c.url.String(),
+		NoProxy:    c.noProxy,
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return fn(req.URL)
+	}
+}
+
+// ConfigureTransport sets the proxy of an HTTP transport. It doesn't change the transport if c is nil,
+// so the transport keeps using the proxy of the environment if any.
+func (c *Config) ConfigureTransport(transport *http.Transport) {
+	if c == nil {
+		return
+	}
+	transport.Proxy = c.ProxyFunc()
+}
+
+// Dialer returns a dialer connecting through a SOCKS5 proxy, for socket-based connections.
+// The forward dialer connects to the proxy, and to the hosts matching noProxy.
+func (c *Config) Dialer(forward proxy.Dialer) (proxy.Dialer, error) {
+	if c.url.Scheme != schemeSOCKS5 {
+		return nil, fmt.Errorf("only socks5 proxies are supported for socket connections, got '%s'", c.url.Scheme)
+	}
+
+	d, err := proxy.FromURL(c.url, forward)
+	if err != nil {
+		return nil, err
+	}
+	if c.noProxy == "" {
+		return d, nil
+	}
+	perHost := proxy.NewPerHost(d, forward)
+	perHost.AddFromString(c.noProxy)
+
+	return perHost, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redact
+
+import (
+	"fmt"
+
+	"github.com/dapr/kit/logger"
+)
+
+// Logger returns a logger that replaces the sensitive values in the messages and fields logged with l.
+func (r *Redactor) Logger(l logger.Logger) logger.Logger {
+	if r == nil {
+		return l
+	}
+	return &redactedLogger{Logger: l, redactor: r}
+}
+
+type redactedLogger struct {
+	logger.Logger
+	redactor *Redactor
+}
+
+func (l *redactedLogger) WithLogType(logType string) logger.Logger {
+	return &redactedLogger{Logger: l.Logger.WithLogType(logType), redactor: l.redactor}
+}
+
+func (l *redactedLogger) WithFields(fields map[string]any) logger.Logger {
+	redacted := make(map[string]any, len(fields))
+	for k, v := range fields {
+		switch val := v.(type) {
+		case string:
+			redacted[k] = l.redactor.String(val)
+		case error:
+			redacted[k] = l.redactor.Error(val)
+		default:
+			redacted[k] = v
+		}
+	}
+	return &redactedLogger{Logger: l.Logger.WithFields(redacted), redactor: l.redactor}
+}
+
+func (l *redactedLogger) Info(args ...interface{}) {
+	l.Logger.Info(l.redactor.String(fmt.Sprint(args...)))
+}
+
+func (l *redactedLogger) Infof(format string, args ...interface{}) {
+	l.Logger.Info(l.redactor.String(fmt.Sprintf(format, args...)))
+}
+
+func (l *redactedLogger) Debug(args ...interface{}) {
+	if !l.Logger.IsOutputLevelEnabled(logger.DebugLevel) {
+		return
+	}
+	l.Logger.Debug(l.redactor.String(fmt.Sprint(args...)))
+}
+
+func (l *redactedLogger) Debugf(format string, args ...interface{}) {
+	if !l.Logger.IsOutputLevelEnabled(logger.DebugLevel) {
+		return
+	}
+	l.Logger.Debug(l.redactor.String(fmt.Sprintf(format, args...)))
+}
+
+func (l *redactedLogger) Warn(args ...interface{}) {
+	l.Logger.Warn(l.redactor.String(fmt.Sprint(args...)))
+}
+
+func (l *redactedLogger) Warnf(format string, args ...interface{}) {
+	l.Logger.Warn(l.redactor.String(fmt.Sprintf(format, args...)))
+}
+
+func (l *redactedLogger) Error(args ...interface{}) {
+	l.Logger.Error(l.redactor.String(fmt.Sprint(args...)))
+}
+
+func (l *redactedLogger) Errorf(format string, args ...interface{}) {
+	l.Logger.Error(l.redactor.String(fmt.Sprintf(format, args...)))
+}
+
+func (l *redactedLogger) Fatal(args ...interface{}) {
+	l.Logger.Fatal(l.redactor.String(fmt.Sprint(args...)))
+}
+
+func (l *redactedLogger) Fatalf(format string, args ...interface{}) {
+	l.Logger.Fatal(l.redactor.String(fmt.Sprintf(format, args...)))
+}
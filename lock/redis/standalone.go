@@ -24,6 +24,7 @@ import (
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -92,6 +93,12 @@ func (r *StandaloneRedisLock) InitLockStore(metadata lock.Metadata) error {
 	return nil
 }
 
+func (r *StandaloneRedisLock) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword, metadata.AuthProfileSPIFFE},
+	}
+}
+
 func needFailover(properties map[string]string) bool {
 	if val, ok := properties["failover"]; ok && val != "" {
 		parsedVal, err := strconv.ParseBool(val)
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+// Capability is an optional capability that a component declares to support.
+type Capability string
+
+const (
+	// CapabilityETag means the component supports optimistic concurrency with ETags.
+	CapabilityETag Capability = "etag"
+	// CapabilityTTL means the component expires items, or messages, after the TTL of the request.
+	CapabilityTTL Capability = "ttl"
+	// CapabilityTransactions means the component applies multiple operations atomically.
+	CapabilityTransactions Capability = "transactions"
+	// CapabilityQuery means the component supports the query API.
+	CapabilityQuery Capability = "query"
+	// CapabilityPartialUpdate means the component supports partial updates of JSON documents.
+	CapabilityPartialUpdate Capability = "partialUpdate"
+	// CapabilityKeysList means the component lists its keys.
+	CapabilityKeysList Capability = "keysList"
+	// CapabilitySubscribeWildcards means the component supports subscribing to topics with wildcards.
+	CapabilitySubscribeWildcards Capability = "subscribeWildcards"
+	// CapabilityMultipleKeyValuesPerSecret means the component stores multiple key-values in a secret.
+	CapabilityMultipleKeyValuesPerSecret Capability = "multipleKeyValuesPerSecret"
+)
+
+// AuthProfile is a way a component authenticates to its service.
+type AuthProfile string
+
+const (
+	// AuthProfileNone means the component connects without credentials.
+	AuthProfileNone AuthProfile = "none"
+	// AuthProfileConnectionString means the credentials are part of a connection string.
+	AuthProfileConnectionString AuthProfile = "connectionString"
+	// AuthProfilePassword means a username and password.
+	AuthProfilePassword AuthProfile = "password"
+	// AuthProfileAccessKey means an access key, such as a key ID and secret or an account key.
+	AuthProfileAccessKey AuthProfile = "accessKey"
+	// AuthProfileToken means a static token, such as an API or bearer token.
+	AuthProfileToken AuthProfile = "token"
+	// AuthProfileJWT means a signed JWT, with the key to answer the challenges of the server.
+	AuthProfileJWT AuthProfile = "jwt"
+	// AuthProfileMTLS means a client certificate.
+	AuthProfileMTLS AuthProfile = "mtls"
+	// AuthProfileOAuth2 means OAuth 2.0 client credentials.
+	AuthProfileOAuth2 AuthProfile = "oauth2"
+	// AuthProfileSPIFFE means the X.509 SVID of the workload, from the SPIFFE Workload API.
+	AuthProfileSPIFFE AuthProfile = "spiffe"
+	// AuthProfileAppRole means a role ID and secret ID.
+	AuthProfileAppRole AuthProfile = "appRole"
+	// AuthProfileKubernetes means the Kubernetes service account of the workload.
+	AuthProfileKubernetes AuthProfile = "kubernetes"
+	// AuthProfileAzureAD means Azure AD credentials, including managed identities.
+	AuthProfileAzureAD AuthProfile = "azureAD"
+	// AuthProfileAWSIAM means AWS IAM credentials, including the default credential chain.
+	AuthProfileAWSIAM AuthProfile = "awsIAM"
+	// AuthProfileGCPServiceAccount means a GCP service account.
+	AuthProfileGCPServiceAccount AuthProfile = "gcpServiceAccount"
+	// AuthProfileInstancePrincipal means the identity of the compute instance the workload runs on.
+	AuthProfileInstancePrincipal AuthProfile = "instancePrincipal"
+)
+
+// ComponentProfile is the capabilities and authentication profiles a component declares, so tooling can document and
+// validate components. It is marshaled to JSON as {"capabilities": [...], "authProfiles": [...]}.
+type ComponentProfile struct {
+	Capabilities []Capability  `json:"capabilities"`
+	AuthProfiles []AuthProfile `json:"authProfiles"`
+}
+
+// ComponentWithProfile is implemented by the components of all the types that declare their profile.
+// The profile is available without initializing the component. Components derive the capabilities of their features
+// from Features(), and list by hand only those that Features() can't express. The few components whose features depend
+// on their configuration, such as the wrapper state stores, the bridge pubsub and the local file secret store with
+// multiValued, only declare those capabilities once initialized.
+type ComponentWithProfile interface {
+	ComponentProfile() ComponentProfile
+}
+
+// GetComponentProfile returns the profile declared by a component, or false if the component doesn't declare one.
+func GetComponentProfile(component any) (ComponentProfile, bool) {
+	c, ok := component.(ComponentWithProfile)
+	if !ok {
+		return ComponentProfile{}, false
+	}
+
+	return c.ComponentProfile(), true
+}
+
+// HasCapability returns true if the capability is in the profile.
+func (p ComponentProfile) HasCapability(capability Capability) bool {
+	for _, c := range p.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type profiledComponent struct{}
+
+func (profiledComponent) ComponentProfile() ComponentProfile {
+	return ComponentProfile{
+		Capabilities: []Capability{CapabilityETag, CapabilityTTL},
+		AuthProfiles: []AuthProfile{AuthProfileNone, AuthProfilePassword},
+	}
+}
+
+func TestComponentProfile(t *testing.T) {
+	t.Run("declared profile", func(t *testing.T) {
+		p, ok := GetComponentProfile(profiledComponent{})
+		require.True(t, ok)
+		assert.True(t, p.HasCapability(CapabilityTTL))
+		assert.False(t, p.HasCapability(CapabilityQuery))
+
+		data, err := json.Marshal(p)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"capabilities": ["etag", "ttl"], "authProfiles": ["none", "password"]}`, string(data))
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		_, ok := GetComponentProfile(struct{}{})
+		assert.False(t, ok)
+	})
+}
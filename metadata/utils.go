@@ -14,6 +14,7 @@ limitations under the License.
 package metadata
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"reflect"
@@ -52,6 +53,10 @@ const (
 
 	// MaxBulkPubBytesKey defines the maximum bytes to publish in a bulk publish request metadata.
 	MaxBulkPubBytesKey string = "maxBulkPubBytes"
+
+	// OperationTimeoutKey defines the metadata key for the timeout of the outbound calls of a component, as a duration such as "30s".
+	// It's only supported by the components that document it, each with a default timeout of its own.
+	OperationTimeoutKey = "operationTimeout"
 )
 
 // TryGetTTL tries to get the ttl as a time.Duration value for pubsub, binding and any other building block.
@@ -78,6 +83,48 @@ func TryGetTTL(props map[string]string) (time.Duration, bool, error) {
 	return 0, false, nil
 }
 
+// TryGetOperationTimeout tries to get the timeout of the outbound calls of a component.
+func TryGetOperationTimeout(props map[string]string) (time.Duration, bool, error) {
+	if val, ok := props[OperationTimeoutKey]; ok && val != "" {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "%s value must be a valid duration: actual is '%s'", OperationTimeoutKey, val)
+		}
+
+		if timeout <= 0 {
+			return 0, false, fmt.Errorf("%s value must be higher than zero: actual is %s", OperationTimeoutKey, val)
+		}
+
+		return timeout, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// GetOperationTimeout returns the timeout of the outbound calls of a component, or defaultTimeout if it isn't set.
+// A zero defaultTimeout means the calls don't time out unless the timeout is set.
+func GetOperationTimeout(props map[string]string, defaultTimeout time.Duration) (time.Duration, error) {
+	timeout, ok, err := TryGetOperationTimeout(props)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return defaultTimeout, nil
+	}
+
+	return timeout, nil
+}
+
+// ContextWithOperationTimeout returns a context which is canceled when the timeout of the operation elapses.
+// A zero timeout doesn't set a deadline, so the context is only canceled with its parent or by the cancel function.
+func ContextWithOperationTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout)
+}
+
 // TryGetPriority tries to get the priority for binding and any other building block.
 func TryGetPriority(props map[string]string) (uint8, bool, error) {
 	if val, ok := props[PriorityMetadataKey]; ok && val != "" {
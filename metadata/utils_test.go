@@ -14,6 +14,7 @@ limitations under the License.
 package metadata
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -94,6 +95,69 @@ func TestTryGetContentType(t *testing.T) {
 	})
 }
 
+func TestTryGetOperationTimeout(t *testing.T) {
+	t.Run("Metadata without operation timeout", func(t *testing.T) {
+		val, ok, err := TryGetOperationTimeout(map[string]string{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, time.Duration(0), val)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("Metadata with operation timeout", func(t *testing.T) {
+		val, ok, err := TryGetOperationTimeout(map[string]string{
+			"operationTimeout": "1m30s",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 90*time.Second, val)
+		assert.Equal(t, true, ok)
+	})
+
+	t.Run("Metadata with invalid operation timeout", func(t *testing.T) {
+		_, _, err := TryGetOperationTimeout(map[string]string{
+			"operationTimeout": "30",
+		})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Metadata with negative operation timeout", func(t *testing.T) {
+		_, _, err := TryGetOperationTimeout(map[string]string{
+			"operationTimeout": "-5s",
+		})
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Default operation timeout", func(t *testing.T) {
+		val, err := GetOperationTimeout(map[string]string{}, 20*time.Second)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 20*time.Second, val)
+	})
+}
+
+func TestContextWithOperationTimeout(t *testing.T) {
+	t.Run("With timeout", func(t *testing.T) {
+		ctx, cancel := ContextWithOperationTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("Without timeout", func(t *testing.T) {
+		ctx, cancel := ContextWithOperationTimeout(context.Background(), 0)
+
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		cancel()
+		assert.Error(t, ctx.Err())
+	})
+}
+
 func TestMetadataDecode(t *testing.T) {
 	t.Run("Test metadata decoding", func(t *testing.T) {
 		type testMetadata struct {
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwt
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	oidc "github.com/coreos/go-oidc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+type jwtMiddlewareMetadata struct {
+	IssuerURL string `json:"issuerURL"`
+	// Optional URL of the JSON Web Key Set; when empty it's discovered from the issuer.
+	JWKSURL string `json:"jwksURL"`
+	// Optional audience the token must be issued for.
+	Audience string `json:"audience"`
+}
+
+// tokenVerifier verifies a raw JWT, allowing to replace the OIDC verifier in tests.
+type tokenVerifier interface {
+	Verify(ctx context.Context, rawToken string) (*oidc.IDToken, error)
+}
+
+// NewJWTMiddleware returns a new gRPC JWT validation middleware.
+func NewJWTMiddleware(_ logger.Logger) middleware.GRPCMiddleware {
+	return &Middleware{}
+}
+
+// Middleware is a gRPC middleware validating the bearer JWT in the "authorization" metadata of each call.
+type Middleware struct{}
+
+const (
+	authorizationKey   = "authorization"
+	bearerPrefix       = "bearer "
+	bearerPrefixLength = len(bearerPrefix)
+)
+
+// GetUnaryInterceptor returns the unary server interceptor provided by the middleware.
+func (m *Middleware) GetUnaryInterceptor(metadata middleware.Metadata) (grpc.UnaryServerInterceptor, error) {
+	verifier, err := m.getVerifier(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return unaryInterceptor(verifier), nil
+}
+
+// GetStreamInterceptor returns the stream server interceptor provided by the middleware.
+func (m *Middleware) GetStreamInterceptor(metadata middleware.Metadata) (grpc.StreamServerInterceptor, error) {
+	verifier, err := m.getVerifier(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return streamInterceptor(verifier), nil
+}
+
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileNone},
+	}
+}
+
+func unaryInterceptor(verifier tokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authenticate(ctx, verifier); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamInterceptor(verifier tokenVerifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authenticate(ss.Context(), verifier); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func authenticate(ctx context.Context, verifier tokenVerifier) error {
+	md, _ := grpcMetadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationKey)
+	if len(values) == 0 || !strings.HasPrefix(strings.ToLower(values[0]), bearerPrefix) {
+		return status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	_, err := verifier.Verify(ctx, values[0][bearerPrefixLength:])
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+
+	return nil
+}
+
+func (m *Middleware) getVerifier(metadata middleware.Metadata) (tokenVerifier, error) {
+	meta, err := m.getNativeMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &oidc.Config{
+		ClientID:          meta.Audience,
+		SkipClientIDCheck: meta.Audience == "",
+	}
+
+	if meta.JWKSURL != "" {
+		keySet := oidc.NewRemoteKeySet(context.Background(), meta.JWKSURL)
+		return oidc.NewVerifier(meta.IssuerURL, keySet, config), nil
+	}
+
+	provider, err := oidc.NewProvider(context.Background(), meta.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Verifier(config), nil
+}
+
+func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*jwtMiddlewareMetadata, error) {
+	var middlewareMetadata jwtMiddlewareMetadata
+	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
+	if err != nil {
+		return nil, err
+	}
+	if middlewareMetadata.IssuerURL == "" {
+		return nil, errors.New("jwt middleware property issuerURL is required")
+	}
+	return &middlewareMetadata, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	jwtgo "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+)
+
+const testIssuer = "https://issuer.example.com"
+
+// rsaKeySet verifies signatures with a single RSA public key.
+type rsaKeySet struct {
+	key *rsa.PublicKey
+}
+
+func (s *rsaKeySet) VerifySignature(ctx context.Context, rawToken string) ([]byte, error) {
+	_, err := jwtgo.Parse(rawToken, func(*jwtgo.Token) (interface{}, error) {
+		return s.key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	return base64.RawURLEncoding.DecodeString(parts[1])
+}
+
+func TestJWTUnaryInterceptor(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier := oidc.NewVerifier(testIssuer, &rsaKeySet{key: &key.PublicKey}, &oidc.Config{ClientID: "dapr"})
+	interceptor := unaryInterceptor(verifier)
+
+	sign := func(k *rsa.PrivateKey, audience string) string {
+		token, err := jwtgo.NewWithClaims(jwtgo.SigningMethodRS256, jwtgo.RegisteredClaims{
+			Issuer:    testIssuer,
+			Audience:  jwtgo.ClaimStrings{audience},
+			ExpiresAt: jwtgo.NewNumericDate(time.Now().Add(time.Hour)),
+		}).SignedString(k)
+		require.NoError(t, err)
+		return token
+	}
+	call := func(authorization string) error {
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = grpcMetadata.NewIncomingContext(ctx, grpcMetadata.Pairs(authorizationKey, authorization))
+		}
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		return err
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		assert.NoError(t, call("Bearer "+sign(key, "dapr")))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(call("")))
+	})
+
+	t.Run("not a bearer token", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(call("Basic Zm9vOmJhcg==")))
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(call("Bearer "+sign(key, "other"))))
+	})
+
+	t.Run("wrong signing key", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(call("Bearer "+sign(otherKey, "dapr"))))
+	})
+}
+
+func TestJWTMetadata(t *testing.T) {
+	m := &Middleware{}
+
+	_, err := m.getNativeMetadata(middleware.Metadata{})
+	assert.Error(t, err)
+
+	meta, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"issuerURL": testIssuer,
+		"jwksURL":   testIssuer + "/keys",
+		"audience":  "dapr",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer, meta.IssuerURL)
+	assert.Equal(t, testIssuer+"/keys", meta.JWKSURL)
+	assert.Equal(t, "dapr", meta.Audience)
+}
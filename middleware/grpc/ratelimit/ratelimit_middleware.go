@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// Metadata is the ratelimit middleware config.
+type rateLimitMiddlewareMetadata struct {
+	MaxRequestsPerSecond float64 `json:"maxRequestsPerSecond"`
+}
+
+const (
+	maxRequestsPerSecondKey = "maxRequestsPerSecond"
+
+	// Defaults.
+	defaultMaxRequestsPerSecond = 100
+)
+
+// NewRateLimitMiddleware returns a new gRPC ratelimit middleware.
+func NewRateLimitMiddleware(_ logger.Logger) middleware.GRPCMiddleware {
+	return &Middleware{}
+}
+
+// Middleware is a gRPC ratelimit middleware.
+// Calls exceeding the limit are rejected with codes.ResourceExhausted.
+type Middleware struct{}
+
+// GetUnaryInterceptor returns the unary server interceptor provided by the middleware.
+func (m *Middleware) GetUnaryInterceptor(metadata middleware.Metadata) (grpc.UnaryServerInterceptor, error) {
+	limiter, err := m.getLimiter(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !limiter.Allow() {
+			return nil, errRateLimited(info.FullMethod)
+		}
+
+		return handler(ctx, req)
+	}, nil
+}
+
+// GetStreamInterceptor returns the stream server interceptor provided by the middleware.
+// The limit applies to the creation of new streams, not to the messages sent on them.
+func (m *Middleware) GetStreamInterceptor(metadata middleware.Metadata) (grpc.StreamServerInterceptor, error) {
+	limiter, err := m.getLimiter(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !limiter.Allow() {
+			return errRateLimited(info.FullMethod)
+		}
+
+		return handler(srv, ss)
+	}, nil
+}
+
+func (m *Middleware) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
+func (m *Middleware) getLimiter(metadata middleware.Metadata) (*rate.Limiter, error) {
+	meta, err := m.getNativeMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Allow bursts of up to one second worth of requests
+	burst := int(math.Ceil(meta.MaxRequestsPerSecond))
+
+	return rate.NewLimiter(rate.Limit(meta.MaxRequestsPerSecond), burst), nil
+}
+
+func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*rateLimitMiddlewareMetadata, error) {
+	var middlewareMetadata rateLimitMiddlewareMetadata
+
+	middlewareMetadata.MaxRequestsPerSecond = defaultMaxRequestsPerSecond
+	if val, ok := metadata.Properties[maxRequestsPerSecondKey]; ok {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ratelimit middleware property %s: %w", maxRequestsPerSecondKey, err)
+		}
+		if f <= 0 {
+			return nil, fmt.Errorf("ratelimit middleware property %s must be a positive value", maxRequestsPerSecondKey)
+		}
+		middlewareMetadata.MaxRequestsPerSecond = f
+	}
+
+	return &middlewareMetadata, nil
+}
+
+func errRateLimited(method string) error {
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s", method)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestRateLimitUnaryInterceptor(t *testing.T) {
+	m := NewRateLimitMiddleware(logger.NewLogger("test"))
+	interceptor, err := m.GetUnaryInterceptor(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		maxRequestsPerSecondKey: "2",
+	}}})
+	require.NoError(t, err)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/dapr.proto.runtime.v1.Dapr/GetState"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := interceptor(context.Background(), nil, info, handler)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", res)
+	}
+
+	_, err = interceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestRateLimitStreamInterceptor(t *testing.T) {
+	m := NewRateLimitMiddleware(logger.NewLogger("test"))
+	interceptor, err := m.GetStreamInterceptor(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		maxRequestsPerSecondKey: "1",
+	}}})
+	require.NoError(t, err)
+
+	info := &grpc.StreamServerInfo{FullMethod: "/dapr.proto.runtime.v1.Dapr/SubscribeConfigurationAlpha1"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}
+
+	assert.NoError(t, interceptor(nil, nil, info, handler))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(interceptor(nil, nil, info, handler)))
+}
+
+func TestRateLimitMetadata(t *testing.T) {
+	m := &Middleware{}
+
+	meta, err := m.getNativeMetadata(middleware.Metadata{})
+	require.NoError(t, err)
+	assert.Equal(t, float64(defaultMaxRequestsPerSecond), meta.MaxRequestsPerSecond)
+
+	_, err = m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		maxRequestsPerSecondKey: "0",
+	}}})
+	assert.Error(t, err)
+
+	_, err = m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		maxRequestsPerSecondKey: "abc",
+	}}})
+	assert.Error(t, err)
+}
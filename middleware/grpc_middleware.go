@@ -0,0 +1,24 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package middleware
+
+import (
+	"google.golang.org/grpc"
+)
+
+// GRPCMiddleware is the interface for a middleware in the gRPC API pipeline.
+type GRPCMiddleware interface {
+	GetUnaryInterceptor(metadata Metadata) (grpc.UnaryServerInterceptor, error)
+	GetStreamInterceptor(metadata Metadata) (grpc.StreamServerInterceptor, error)
+}
@@ -79,6 +79,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Ha
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileNone},
+	}
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*bearerMiddlewareMetadata, error) {
 	var middlewareMetadata bearerMiddlewareMetadata
 	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
@@ -0,0 +1,304 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/dapr/components-contrib/internal/httputils"
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	memoryStore = "memory"
+	redisStore  = "redis"
+
+	// Header added to the responses, with the value hit or miss.
+	cacheStatusHeader = "X-Cache"
+	cacheHit          = "hit"
+	cacheMiss         = "miss"
+
+	// Timeout to store a response, which is independent of the request that got it.
+	storeTimeout = 5 * time.Second
+
+	// Defaults.
+	defaultTTL         = time.Minute
+	defaultMaxEntries  = 1000
+	defaultMaxBodySize = 1 << 20
+	defaultKeyPrefix   = "dapr-http-cache:"
+)
+
+// Metadata is the cache middleware config.
+type Metadata struct {
+	// Store of the responses: memory or redis. The redis store takes the properties of the Redis components,
+	// such as redisHost and redisPassword.
+	Store string `json:"store" mapstructure:"store"`
+	// Time to live of the responses.
+	TTL time.Duration `json:"ttl" mapstructure:"ttl"`
+	// Comma-separated names of the request headers that are part of the cache key, such as Accept,Accept-Language.
+	// Responses are shared between requests that differ only by other headers.
+	VaryHeaders string `json:"varyHeaders" mapstructure:"varyHeaders"`
+	// Maximum number of responses in the memory store.
+	MaxEntries int `json:"maxEntries" mapstructure:"maxEntries"`
+	// Responses with a larger body, in bytes, are not cached.
+	MaxBodySize int `json:"maxBodySize" mapstructure:"maxBodySize"`
+	// Prefix of the keys in the redis store.
+	KeyPrefix string `json:"keyPrefix" mapstructure:"keyPrefix"`
+
+	varyHeaders []string
+}
+
+// NewMiddleware returns a new cache middleware.
+func NewMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{logger: logger}
+}
+
+// Middleware is a cache middleware. It caches the responses of GET requests, and coalesces the concurrent requests
+// with the same key into a single request to the next handler.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// cachedResponse is a response of the next handler.
+type cachedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// GetHandler returns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta, err := m.getNativeMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var store responseStore
+	switch meta.Store {
+	case memoryStore:
+		store, err = newMemoryStore(meta.MaxEntries)
+	case redisStore:
+		store, err = newRedisStore(metadata.Properties, meta.KeyPrefix)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s store of the cache middleware: %w", meta.Store, err)
+	}
+
+	h := &cacheHandler{
+		meta:   meta,
+		store:  store,
+		logger: m.logger,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.serveHTTP(w, r, next)
+		})
+	}, nil
+}
+
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileNone, mdutils.AuthProfilePassword},
+	}
+}
+
+func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*Metadata, error) {
+	middlewareMetadata := Metadata{
+		Store:       memoryStore,
+		TTL:         defaultTTL,
+		MaxEntries:  defaultMaxEntries,
+		MaxBodySize: defaultMaxBodySize,
+		KeyPrefix:   defaultKeyPrefix,
+	}
+	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	middlewareMetadata.Store = strings.ToLower(middlewareMetadata.Store)
+	if middlewareMetadata.Store != memoryStore && middlewareMetadata.Store != redisStore {
+		return nil, fmt.Errorf("cache middleware property store must be %s or %s", memoryStore, redisStore)
+	}
+	if middlewareMetadata.TTL <= 0 {
+		return nil, errors.New("cache middleware property ttl must be a positive value")
+	}
+	if middlewareMetadata.MaxEntries <= 0 {
+		return nil, errors.New("cache middleware property maxEntries must be a positive value")
+	}
+	if middlewareMetadata.MaxBodySize <= 0 {
+		return nil, errors.New("cache middleware property maxBodySize must be a positive value")
+	}
+	for _, h := range strings.Split(middlewareMetadata.VaryHeaders, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" {
+			middlewareMetadata.varyHeaders = append(middlewareMetadata.varyHeaders, http.CanonicalHeaderKey(h))
+		}
+	}
+
+	return &middlewareMetadata, nil
+}
+
+type cacheHandler struct {
+	meta   *Metadata
+	store  responseStore
+	group  singleflight.Group
+	logger logger.Logger
+}
+
+func (h *cacheHandler) serveHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if r.Method != http.MethodGet || bypassCache(r.Header) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	key := h.key(r)
+	res, err := h.store.get(r.Context(), key)
+	if err != nil {
+		h.logger.Warnf("Failed to get response from cache: %v", err)
+	}
+	if res != nil {
+		writeResponse(w, res, cacheHit)
+		return
+	}
+
+	// Concurrent requests with the same key wait for the response of the first one
+	var leader bool
+	v, _, _ := h.group.Do(key, func() (any, error) {
+		leader = true
+		res := record(r, next)
+		if h.cacheable(res) {
+			ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			defer cancel()
+			err := h.store.set(ctx, key, res, h.meta.TTL)
+			if err != nil {
+				h.logger.Warnf("Failed to store response in cache: %v", err)
+			}
+		}
+		return res, nil
+	})
+	res = v.(*cachedResponse)
+
+	// Responses that can't be cached may be specific to the first request, so the others are served by the next handler
+	if !leader && !h.cacheable(res) {
+		next.ServeHTTP(w, r)
+		return
+	}
+	writeResponse(w, res, cacheMiss)
+}
+
+// key returns the cache key of a request: a hash of its URI and of the values of the vary headers.
+func (h *cacheHandler) key(r *http.Request) string {
+	hash := sha256.New()
+	hash.Write([]byte(httputils.RequestURI(r)))
+	for _, name := range h.meta.varyHeaders {
+		hash.Write([]byte{0})
+		hash.Write([]byte(name))
+		for _, v := range r.Header.Values(name) {
+			hash.Write([]byte{0})
+			hash.Write([]byte(v))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// cacheable returns true if a response can be shared between requests.
+func (h *cacheHandler) cacheable(res *cachedResponse) bool {
+	if res.StatusCode != http.StatusOK || len(res.Body) > h.meta.MaxBodySize {
+		return false
+	}
+	if res.Header.Get("Set-Cookie") != "" {
+		return false
+	}
+	return !hasCacheControl(res.Header, "no-store", "no-cache", "private")
+}
+
+// bypassCache returns true if the request asks for a response that isn't cached.
+func bypassCache(header http.Header) bool {
+	return hasCacheControl(header, "no-store", "no-cache")
+}
+
+// hasCacheControl returns true if the Cache-Control header has one of the directives.
+func hasCacheControl(header http.Header, directives ...string) bool {
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			for _, directive := range directives {
+				if strings.EqualFold(d, directive) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// record calls the next handler and returns its response.
+func record(r *http.Request, next http.Handler) *cachedResponse {
+	rec := &responseRecorder{header: http.Header{}}
+	next.ServeHTTP(rec, r)
+	if rec.statusCode == 0 {
+		rec.statusCode = http.StatusOK
+	}
+	return &cachedResponse{
+		StatusCode: rec.statusCode,
+		Header:     rec.header,
+		Body:       rec.body.Bytes(),
+	}
+}
+
+func writeResponse(w http.ResponseWriter, res *cachedResponse, status string) {
+	header := w.Header()
+	for k, v := range res.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set(cacheStatusHeader, status)
+	w.WriteHeader(res.StatusCode)
+	w.Write(res.Body)
+}
+
+// responseRecorder buffers a response of the next handler.
+type responseRecorder struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	return r.body.Write(b)
+}
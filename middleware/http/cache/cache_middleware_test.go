@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// countingHandler responds with the number of requests it served, and the Accept header of the request.
+type countingHandler struct {
+	calls   atomic.Int32
+	release chan struct{}
+	header  http.Header
+	status  int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	if h.release != nil {
+		<-h.release
+	}
+	for k, v := range h.header {
+		w.Header()[k] = v
+	}
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+	fmt.Fprintf(w, "response %d for %s", n, r.Header.Get("Accept"))
+}
+
+func getHandler(t *testing.T, props map[string]string, next http.Handler) http.Handler {
+	t.Helper()
+
+	m := NewMiddleware(logger.NewLogger("cache.test"))
+	handler, err := m.GetHandler(middleware.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	return handler(next)
+}
+
+func serve(h http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMetadata(t *testing.T) {
+	m := &Middleware{}
+
+	meta, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"ttl":         "10s",
+		"varyHeaders": "accept, x-tenant-id",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, memoryStore, meta.Store)
+	assert.Equal(t, 10*time.Second, meta.TTL)
+	assert.Equal(t, defaultMaxEntries, meta.MaxEntries)
+	assert.Equal(t, []string{"Accept", "X-Tenant-Id"}, meta.varyHeaders)
+
+	invalid := []map[string]string{
+		{"store": "disk"},
+		{"ttl": "0"},
+		{"maxEntries": "-1"},
+		{"maxBodySize": "0"},
+	}
+	for _, props := range invalid {
+		_, err = m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: props}})
+		assert.Error(t, err, props)
+	}
+}
+
+func TestCache(t *testing.T) {
+	next := &countingHandler{header: http.Header{"Content-Type": {"text/plain"}}}
+	h := getHandler(t, map[string]string{"varyHeaders": "Accept"}, next)
+
+	w := serve(h, http.MethodGet, "/v1.0/invoke/app/method/items?page=1", http.Header{"Accept": {"text/plain"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "response 1 for text/plain", w.Body.String())
+
+	w = serve(h, http.MethodGet, "/v1.0/invoke/app/method/items?page=1", http.Header{"Accept": {"text/plain"}, "X-Other": {"1"}})
+	assert.Equal(t, cacheHit, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "response 1 for text/plain", w.Body.String())
+
+	// Different query, vary header, or method
+	w = serve(h, http.MethodGet, "/v1.0/invoke/app/method/items?page=2", http.Header{"Accept": {"text/plain"}})
+	assert.Equal(t, "response 2 for text/plain", w.Body.String())
+	w = serve(h, http.MethodGet, "/v1.0/invoke/app/method/items?page=1", http.Header{"Accept": {"application/json"}})
+	assert.Equal(t, "response 3 for application/json", w.Body.String())
+	w = serve(h, http.MethodPost, "/v1.0/invoke/app/method/items?page=1", http.Header{"Accept": {"text/plain"}})
+	assert.Empty(t, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "response 4 for text/plain", w.Body.String())
+
+	// Requests can bypass the cache
+	w = serve(h, http.MethodGet, "/v1.0/invoke/app/method/items?page=1", http.Header{"Accept": {"text/plain"}, "Cache-Control": {"no-cache"}})
+	assert.Equal(t, "response 5 for text/plain", w.Body.String())
+}
+
+func TestNotCacheable(t *testing.T) {
+	tests := map[string]*countingHandler{
+		"error":      {status: http.StatusInternalServerError},
+		"no-store":   {header: http.Header{"Cache-Control": {"max-age=60, no-store"}}},
+		"private":    {header: http.Header{"Cache-Control": {"Private"}}},
+		"set-cookie": {header: http.Header{"Set-Cookie": {"session=1"}}},
+	}
+	for name, next := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := getHandler(t, nil, next)
+			serve(h, http.MethodGet, "/items", nil)
+			w := serve(h, http.MethodGet, "/items", nil)
+			assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+			assert.Equal(t, int32(2), next.calls.Load())
+		})
+	}
+
+	t.Run("max body size", func(t *testing.T) {
+		next := &countingHandler{}
+		h := getHandler(t, map[string]string{"maxBodySize": "5"}, next)
+		serve(h, http.MethodGet, "/items", nil)
+		serve(h, http.MethodGet, "/items", nil)
+		assert.Equal(t, int32(2), next.calls.Load())
+	})
+}
+
+func TestCoalescing(t *testing.T) {
+	next := &countingHandler{release: make(chan struct{})}
+	h := getHandler(t, nil, next)
+
+	const n = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bodies[0] = serve(h, http.MethodGet, "/items", nil).Body.String()
+	}()
+	require.Eventually(t, func() bool { return next.calls.Load() == 1 }, 5*time.Second, time.Millisecond)
+
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = serve(h, http.MethodGet, "/items", nil).Body.String()
+		}(i)
+	}
+	// Give the other requests time to join the first one
+	time.Sleep(50 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), next.calls.Load())
+	for _, b := range bodies {
+		assert.Equal(t, "response 1 for ", b)
+	}
+}
+
+func TestMemoryStoreExpiration(t *testing.T) {
+	store, err := newMemoryStore(2)
+	require.NoError(t, err)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	res := &cachedResponse{StatusCode: http.StatusOK, Body: []byte("a")}
+	require.NoError(t, store.set(ctx, "a", res, time.Minute))
+	got, err := store.get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, res, got)
+
+	now = now.Add(time.Minute)
+	got, err = store.get(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// The least recently used response is evicted
+	require.NoError(t, store.set(ctx, "b", res, time.Minute))
+	require.NoError(t, store.set(ctx, "c", res, time.Minute))
+	require.NoError(t, store.set(ctx, "d", res, time.Minute))
+	got, _ = store.get(ctx, "b")
+	assert.Nil(t, got)
+	got, _ = store.get(ctx, "d")
+	assert.NotNil(t, got)
+}
+
+func TestRedisStore(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	next := &countingHandler{header: http.Header{"Content-Type": {"text/plain"}}}
+	props := map[string]string{
+		"store":     "redis",
+		"redisHost": s.Addr(),
+		"ttl":       "30s",
+		"keyPrefix": "test:",
+	}
+	// Two instances of the app share the responses
+	h1 := getHandler(t, props, next)
+	h2 := getHandler(t, props, next)
+
+	w := serve(h1, http.MethodGet, "/items", nil)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	w = serve(h2, http.MethodGet, "/items", nil)
+	assert.Equal(t, cacheHit, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "response 1 for ", w.Body.String())
+
+	keys := s.Keys()
+	require.Len(t, keys, 1)
+	assert.Regexp(t, "^test:[0-9a-f]{64}$", keys[0])
+	assert.Equal(t, 30*time.Second, s.TTL(keys[0]))
+
+	s.FastForward(30 * time.Second)
+	serve(h2, http.MethodGet, "/items", nil)
+	assert.Equal(t, int32(2), next.calls.Load())
+}
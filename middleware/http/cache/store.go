@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	lru "github.com/hashicorp/golang-lru"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+)
+
+// responseStore stores the cached responses.
+// get returns a nil response when the key isn't in the store, or expired.
+type responseStore interface {
+	get(ctx context.Context, key string) (*cachedResponse, error)
+	set(ctx context.Context, key string, res *cachedResponse, ttl time.Duration) error
+}
+
+// memoryResponseStore is an LRU cache of responses, local to the instance of the app.
+type memoryResponseStore struct {
+	cache *lru.Cache
+	now   func() time.Time
+}
+
+type memoryEntry struct {
+	res       *cachedResponse
+	expiresAt time.Time
+}
+
+func newMemoryStore(maxEntries int) (*memoryResponseStore, error) {
+	cache, err := lru.New(maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryResponseStore{cache: cache, now: time.Now}, nil
+}
+
+func (s *memoryResponseStore) get(_ context.Context, key string) (*cachedResponse, error) {
+	v, ok := s.cache.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	entry := v.(memoryEntry)
+	if !s.now().Before(entry.expiresAt) {
+		s.cache.Remove(key)
+		return nil, nil
+	}
+	return entry.res, nil
+}
+
+func (s *memoryResponseStore) set(_ context.Context, key string, res *cachedResponse, ttl time.Duration) error {
+	s.cache.Add(key, memoryEntry{res: res, expiresAt: s.now().Add(ttl)})
+	return nil
+}
+
+// redisResponseStore stores the responses in Redis, where they are shared between the instances of the app.
+type redisResponseStore struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+func newRedisStore(properties map[string]string, keyPrefix string) (*redisResponseStore, error) {
+	client, _, err := rediscomponent.ParseClientFromProperties(properties, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &redisResponseStore{client: client, keyPrefix: keyPrefix}, nil
+}
+
+func (s *redisResponseStore) get(ctx context.Context, key string) (*cachedResponse, error) {
+	data, err := s.client.Get(ctx, s.keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res cachedResponse
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cached response: %w", err)
+	}
+	return &res, nil
+}
+
+func (s *redisResponseStore) set(ctx context.Context, key string, res *cachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.keyPrefix+key, data, ttl).Err()
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfilter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/dapr/components-contrib/internal/httputils"
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+const defaultForwardedForHeader = "X-Forwarded-For"
+
+// Metadata is the ipfilter middleware config.
+type Metadata struct {
+	// Comma-separated IPs or CIDRs of the allowed clients; all clients are allowed when empty.
+	AllowCIDRs string `json:"allowCIDRs" mapstructure:"allowCIDRs"`
+	// Comma-separated IPs or CIDRs of the denied clients, which take precedence over allowCIDRs.
+	DenyCIDRs string `json:"denyCIDRs" mapstructure:"denyCIDRs"`
+	// Path of a MaxMind GeoIP2 or GeoLite2 Country or City database, required by the country rules.
+	GeoIPDatabase string `json:"geoIPDatabase" mapstructure:"geoIPDatabase"`
+	// Comma-separated ISO codes of the allowed countries; clients whose country is unknown are denied.
+	AllowCountries string `json:"allowCountries" mapstructure:"allowCountries"`
+	// Comma-separated ISO codes of the denied countries.
+	DenyCountries string `json:"denyCountries" mapstructure:"denyCountries"`
+	// Comma-separated IPs or CIDRs of the proxies trusted to set the forwardedForHeader.
+	// The header is ignored when the request doesn't come from a trusted proxy.
+	TrustedProxies string `json:"trustedProxies" mapstructure:"trustedProxies"`
+	// Header with the IPs of the client and of the proxies.
+	ForwardedForHeader string `json:"forwardedForHeader" mapstructure:"forwardedForHeader"`
+}
+
+// NewMiddleware returns a new ipfilter middleware.
+func NewMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{logger: logger}
+}
+
+// Middleware is an ipfilter middleware, which denies the requests of clients by IP or country.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// countryResolver returns the ISO code of the country of an IP, or an empty string if it is unknown.
+type countryResolver interface {
+	country(ip netip.Addr) (string, error)
+}
+
+type filter struct {
+	allow          []netip.Prefix
+	deny           []netip.Prefix
+	trustedProxies []netip.Prefix
+	allowCountries map[string]struct{}
+	denyCountries  map[string]struct{}
+	header         string
+	countries      countryResolver
+	logger         logger.Logger
+}
+
+// GetHandler returns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta, err := m.getNativeMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newFilter(meta)
+	if err != nil {
+		return nil, err
+	}
+	f.logger = m.logger
+	if meta.GeoIPDatabase != "" {
+		reader, err := maxminddb.Open(meta.GeoIPDatabase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open geoIPDatabase: %w", err)
+		}
+		f.countries = &geoIPResolver{reader: reader}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.allowed(r) {
+				httputils.RespondWithErrorAndMessage(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileNone},
+	}
+}
+
+func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*Metadata, error) {
+	middlewareMetadata := Metadata{
+		ForwardedForHeader: defaultForwardedForHeader,
+	}
+	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	hasCountryRules := middlewareMetadata.AllowCountries != "" || middlewareMetadata.DenyCountries != ""
+	if hasCountryRules && middlewareMetadata.GeoIPDatabase == "" {
+		return nil, errors.New("ipfilter middleware property geoIPDatabase is required by allowCountries and denyCountries")
+	}
+	if middlewareMetadata.ForwardedForHeader == "" {
+		middlewareMetadata.ForwardedForHeader = defaultForwardedForHeader
+	}
+
+	return &middlewareMetadata, nil
+}
+
+func newFilter(meta *Metadata) (*filter, error) {
+	var (
+		f   = &filter{header: meta.ForwardedForHeader}
+		err error
+	)
+	f.allow, err = parsePrefixes(meta.AllowCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipfilter middleware property allowCIDRs: %w", err)
+	}
+	f.deny, err = parsePrefixes(meta.DenyCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipfilter middleware property denyCIDRs: %w", err)
+	}
+	f.trustedProxies, err = parsePrefixes(meta.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipfilter middleware property trustedProxies: %w", err)
+	}
+	f.allowCountries = parseCountries(meta.AllowCountries)
+	f.denyCountries = parseCountries(meta.DenyCountries)
+
+	return f, nil
+}
+
+// allowed returns true if the client of a request is allowed.
+func (f *filter) allowed(r *http.Request) bool {
+	ip, ok := f.clientIP(r)
+	if !ok {
+		f.logger.Debugf("Denying request with unknown client address %q", r.RemoteAddr)
+		return false
+	}
+
+	if contains(f.deny, ip) {
+		f.logger.Debugf("Denying request of %s: denied address", ip)
+		return false
+	}
+	if len(f.allow) > 0 && !contains(f.allow, ip) {
+		f.logger.Debugf("Denying request of %s: address not allowed", ip)
+		return false
+	}
+
+	if len(f.allowCountries) == 0 && len(f.denyCountries) == 0 {
+		return true
+	}
+	country, err := f.countries.country(ip)
+	if err != nil {
+		f.logger.Warnf("Failed to look up the country of %s: %v", ip, err)
+	}
+	if _, ok := f.denyCountries[country]; ok {
+		f.logger.Debugf("Denying request of %s: denied country %s", ip, country)
+		return false
+	}
+	if len(f.allowCountries) > 0 {
+		if _, ok := f.allowCountries[country]; !ok {
+			f.logger.Debugf("Denying request of %s: country %q not allowed", ip, country)
+			return false
+		}
+	}
+
+	return true
+}
+
+// clientIP returns the IP of the client of a request.
+// When the request comes from a trusted proxy, the client is the last IP of the forwarded-for header that isn't
+// a trusted proxy: the IPs before it may be set by the client, and can't be trusted.
+func (f *filter) clientIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+
+	if !contains(f.trustedProxies, ip) {
+		return ip, true
+	}
+
+	values := r.Header.Values(f.header)
+	for i := len(values) - 1; i >= 0; i-- {
+		hops := strings.Split(values[i], ",")
+		for j := len(hops) - 1; j >= 0; j-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[j]))
+			if err != nil {
+				// The header is malformed, so the client is unknown
+				return netip.Addr{}, false
+			}
+			ip = hop.Unmap()
+			if !contains(f.trustedProxies, ip) {
+				return ip, true
+			}
+		}
+	}
+	return ip, true
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefixes parses comma-separated IPs or CIDRs.
+func parsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, err
+			}
+			ip = ip.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, err
+		}
+		if p.Addr().Is4In6() {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// parseCountries parses comma-separated ISO codes of countries.
+func parseCountries(s string) map[string]struct{} {
+	countries := map[string]struct{}{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if v != "" {
+			countries[v] = struct{}{}
+		}
+	}
+	return countries
+}
+
+// geoIPResolver looks up countries in a MaxMind database.
+type geoIPResolver struct {
+	reader *maxminddb.Reader
+}
+
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+func (g *geoIPResolver) country(ip netip.Addr) (string, error) {
+	var record geoIPRecord
+	err := g.reader.Lookup(net.IP(ip.AsSlice()), &record)
+	if err != nil {
+		return "", err
+	}
+	if record.Country.ISOCode != "" {
+		return record.Country.ISOCode, nil
+	}
+	// Anycast and some other networks only have the country they are registered in
+	return record.RegisteredCountry.ISOCode, nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// mockedRequestHandler acts like an upstream service returns success status code 200 and a fixed response body.
+func mockedRequestHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("from mock"))
+}
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) country(ip netip.Addr) (string, error) {
+	return f[ip.String()], nil
+}
+
+func newTestFilter(t *testing.T, props map[string]string) *filter {
+	t.Helper()
+
+	m := &Middleware{}
+	meta, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	f, err := newFilter(meta)
+	require.NoError(t, err)
+	f.logger = logger.NewLogger("ipfilter.test")
+	return f
+}
+
+func newRequest(remoteAddr string, forwardedFor ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:3500/v1.0/invoke/app/method/items", nil)
+	r.RemoteAddr = remoteAddr
+	for _, v := range forwardedFor {
+		r.Header.Add("X-Forwarded-For", v)
+	}
+	return r
+}
+
+func TestMetadata(t *testing.T) {
+	m := &Middleware{}
+
+	_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"denyCountries": "RU",
+	}}})
+	assert.Error(t, err)
+
+	invalid := []map[string]string{
+		{"allowCIDRs": "10.0.0.0/33"},
+		{"denyCIDRs": "10.0.0.x"},
+		{"trustedProxies": "proxy.local"},
+	}
+	for _, props := range invalid {
+		meta, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: props}})
+		require.NoError(t, err)
+		_, err = newFilter(meta)
+		assert.Error(t, err, props)
+	}
+
+	_, err = NewMiddleware(logger.NewLogger("ipfilter.test")).GetHandler(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"geoIPDatabase":  "/nonexistent/GeoLite2-Country.mmdb",
+		"allowCountries": "FR",
+	}}})
+	assert.Error(t, err)
+}
+
+func TestCIDRs(t *testing.T) {
+	f := newTestFilter(t, map[string]string{
+		"allowCIDRs": "10.0.0.0/8, 192.168.1.10, 2001:db8::/32",
+		"denyCIDRs":  "10.1.0.0/16",
+	})
+
+	tests := map[string]bool{
+		"10.2.3.4:1234":          true,
+		"192.168.1.10:1234":      true,
+		"[2001:db8::1]:1234":     true,
+		"[::ffff:10.2.3.4]:1234": true,
+		"10.1.2.3:1234":          false,
+		"192.168.1.11:1234":      false,
+		"[2001:db9::1]:1234":     false,
+		"@":                      false,
+	}
+	for remoteAddr, allowed := range tests {
+		assert.Equal(t, allowed, f.allowed(newRequest(remoteAddr)), remoteAddr)
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	f := newTestFilter(t, map[string]string{
+		"denyCIDRs":      "203.0.113.0/24",
+		"trustedProxies": "10.0.0.0/8",
+	})
+
+	// The header is ignored when the request doesn't come from a trusted proxy
+	ip, ok := f.clientIP(newRequest("198.51.100.1:1234", "10.0.0.1"))
+	require.True(t, ok)
+	assert.Equal(t, "198.51.100.1", ip.String())
+
+	// The client is the last address that isn't a trusted proxy, ignoring the addresses set by the client
+	ip, ok = f.clientIP(newRequest("10.0.0.2:1234", "203.0.113.5, 198.51.100.7, 10.0.0.3"))
+	require.True(t, ok)
+	assert.Equal(t, "198.51.100.7", ip.String())
+
+	ip, ok = f.clientIP(newRequest("10.0.0.2:1234", "203.0.113.5", "10.0.0.3"))
+	require.True(t, ok)
+	assert.Equal(t, "203.0.113.5", ip.String())
+
+	// Only trusted proxies
+	ip, ok = f.clientIP(newRequest("10.0.0.2:1234"))
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.2", ip.String())
+
+	_, ok = f.clientIP(newRequest("10.0.0.2:1234", "unknown, 10.0.0.3"))
+	assert.False(t, ok)
+
+	assert.False(t, f.allowed(newRequest("10.0.0.2:1234", "203.0.113.5")))
+	assert.True(t, f.allowed(newRequest("10.0.0.2:1234", "203.0.113.5, 198.51.100.7")))
+}
+
+func TestCountries(t *testing.T) {
+	resolver := fakeResolver{
+		"198.51.100.1": "FR",
+		"198.51.100.2": "DE",
+		"198.51.100.3": "RU",
+	}
+
+	t.Run("allow", func(t *testing.T) {
+		f := newTestFilter(t, map[string]string{
+			"geoIPDatabase":  "GeoLite2-Country.mmdb",
+			"allowCountries": "fr, de",
+		})
+		f.countries = resolver
+		assert.True(t, f.allowed(newRequest("198.51.100.1:1234")))
+		assert.True(t, f.allowed(newRequest("198.51.100.2:1234")))
+		assert.False(t, f.allowed(newRequest("198.51.100.3:1234")))
+		// Unknown country
+		assert.False(t, f.allowed(newRequest("198.51.100.4:1234")))
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		f := newTestFilter(t, map[string]string{
+			"geoIPDatabase": "GeoLite2-Country.mmdb",
+			"denyCountries": "RU",
+			"denyCIDRs":     "198.51.100.2",
+		})
+		f.countries = resolver
+		assert.True(t, f.allowed(newRequest("198.51.100.1:1234")))
+		assert.False(t, f.allowed(newRequest("198.51.100.2:1234")))
+		assert.False(t, f.allowed(newRequest("198.51.100.3:1234")))
+		assert.True(t, f.allowed(newRequest("198.51.100.4:1234")))
+	})
+}
+
+func TestRequestHandler(t *testing.T) {
+	m := NewMiddleware(logger.NewLogger("ipfilter.test"))
+	handler, err := m.GetHandler(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"allowCIDRs": "192.0.2.0/24",
+	}}})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler(http.HandlerFunc(mockedRequestHandler)).ServeHTTP(w, newRequest("192.0.2.1:1234"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(http.HandlerFunc(mockedRequestHandler)).ServeHTTP(w, newRequest("198.51.100.1:1234"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
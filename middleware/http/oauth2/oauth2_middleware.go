@@ -142,6 +142,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Ha
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileOAuth2},
+	}
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*oAuth2MiddlewareMetadata, error) {
 	var middlewareMetadata oAuth2MiddlewareMetadata
 	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
@@ -123,6 +123,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Ha
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileOAuth2},
+	}
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*oAuth2ClientCredentialsMiddlewareMetadata, error) {
 	var middlewareMetadata oAuth2ClientCredentialsMiddlewareMetadata
 	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
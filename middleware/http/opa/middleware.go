@@ -32,6 +32,7 @@ import (
 
 	"github.com/dapr/components-contrib/internal/httputils"
 	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/middleware"
 	"github.com/dapr/kit/logger"
 )
@@ -132,6 +133,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Ha
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 func (m *Middleware) evalRequest(w http.ResponseWriter, r *http.Request, meta *middlewareMetadata, query *rego.PreparedEvalQuery) bool {
 	headers := map[string]string{}
 
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/middleware"
 	"github.com/dapr/kit/logger"
 
@@ -59,6 +60,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) error {
 	m.router = mux.NewRouter()
 	for key, value := range metadata.Properties {
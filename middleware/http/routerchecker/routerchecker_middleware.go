@@ -63,6 +63,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Ha
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileNone},
+	}
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*Metadata, error) {
 	var middlewareMetadata Metadata
 	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
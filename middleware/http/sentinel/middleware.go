@@ -92,6 +92,12 @@ func (m *Middleware) GetHandler(metadata middleware.Metadata) (func(next http.Ha
 	}, nil
 }
 
+func (m *Middleware) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileNone},
+	}
+}
+
 func (m *Middleware) loadSentinelRules(meta *middlewareMetadata) error {
 	if meta.FlowRules != "" {
 		err := loadRules(meta.FlowRules, newFlowRuleDataSource)
@@ -17,6 +17,7 @@ import (
 	"github.com/http-wasm/http-wasm-host-go/api"
 	"github.com/tetratelabs/wazero"
 
+	"github.com/dapr/components-contrib/metadata"
 	dapr "github.com/dapr/components-contrib/middleware"
 	"github.com/dapr/kit/logger"
 )
@@ -55,6 +56,12 @@ func (m *middleware) GetHandler(metadata dapr.Metadata) (func(next http.Handler)
 	return rh.requestHandler, nil
 }
 
+func (m *middleware) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 // getHandler is extracted for unit testing.
 func (m *middleware) getHandler(metadata dapr.Metadata) (*requestHandler, error) {
 	meta, err := m.getMetadata(metadata)
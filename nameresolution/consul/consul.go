@@ -22,6 +22,7 @@ import (
 
 	consul "github.com/hashicorp/consul/api"
 
+	"github.com/dapr/components-contrib/metadata"
 	nr "github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/kit/logger"
 )
@@ -119,6 +120,12 @@ func (r *resolver) Init(metadata nr.Metadata) error {
 	return nil
 }
 
+func (r *resolver) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileToken},
+	}
+}
+
 // ResolveID resolves name to address via consul.
 func (r *resolver) ResolveID(req nr.ResolveRequest) (string, error) {
 	cfg := r.config
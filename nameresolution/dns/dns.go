@@ -16,6 +16,7 @@ package dns
 import (
 	"fmt"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/kit/logger"
 )
@@ -34,6 +35,12 @@ func (k *resolver) Init(metadata nameresolution.Metadata) error {
 	return nil
 }
 
+func (k *resolver) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 // ResolveID resolves name to address in orchestrator.
 func (k *resolver) ResolveID(req nameresolution.ResolveRequest) (string, error) {
 	return fmt.Sprintf("%s-dapr.%s.svc:%d", req.ID, req.Namespace, req.Port), nil
@@ -16,6 +16,7 @@ package kubernetes
 import (
 	"fmt"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/kit/config"
 	"github.com/dapr/kit/logger"
@@ -58,6 +59,12 @@ func (k *resolver) Init(metadata nameresolution.Metadata) error {
 	return nil
 }
 
+func (k *resolver) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileKubernetes},
+	}
+}
+
 // ResolveID resolves name to address in Kubernetes.
 func (k *resolver) ResolveID(req nameresolution.ResolveRequest) (string, error) {
 	// Dapr requires this formatting for Kubernetes services
@@ -28,6 +28,7 @@ import (
 
 	"github.com/grandcat/zeroconf"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/kit/logger"
 )
@@ -311,6 +312,12 @@ func (m *Resolver) Init(metadata nameresolution.Metadata) error {
 	return nil
 }
 
+func (m *Resolver) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
 func (m *Resolver) getZeroconfResolver() (resolver *zeroconf.Resolver, err error) {
 	// Try with IPv4 + IPv6 first, then IPv4-only, then IPv6-only
 	opts := []zeroconf.ClientOption{
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snssqs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const (
+	// SNS accepts at most 10 attributes per message.
+	maxMessageAttributes = 10
+
+	messageAttributeTypeString = "String"
+	messageAttributeTypeNumber = "Number"
+)
+
+// validAttributeName matches the characters allowed by SNS and SQS in message attribute names.
+var validAttributeName = regexp.MustCompile(`^[a-zA-Z0-9_\-.]{1,256}$`)
+
+// Metadata set by Dapr on publish requests, which can't be forwarded as message attributes.
+var reservedMetadataKeys = map[string]struct{}{
+	"rawPayload":   {},
+	"ttlInSeconds": {},
+}
+
+type snsMessageAttribute struct {
+	Type  string
+	Value string
+}
+
+func isValidAttributeName(name string) bool {
+	if !validAttributeName.MatchString(name) {
+		return false
+	}
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "aws.") || strings.HasPrefix(lower, "amazon.") {
+		return false
+	}
+
+	return !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, ".") && !strings.Contains(name, "..")
+}
+
+// metadataToMessageAttributes maps the metadata of a publish request listed in the messageAttributes metadata of the
+// component to SNS message attributes, so they can be used in subscription filter policies. The sanitized topic name is
+// always added, as it identifies the topic of raw messages.
+func (s *snsSqs) metadataToMessageAttributes(md map[string]string, sanitizedTopic string) map[string]*sns.MessageAttributeValue {
+	attributes := map[string]*sns.MessageAttributeValue{
+		awsSnsTopicNameKey: {
+			DataType:    aws.String(messageAttributeTypeString),
+			StringValue: aws.String(sanitizedTopic),
+		},
+	}
+
+	for _, name := range s.metadata.messageAttributes {
+		if v := md[name]; v != "" {
+			attributes[name] = &sns.MessageAttributeValue{
+				DataType:    aws.String(messageAttributeTypeString),
+				StringValue: aws.String(v),
+			}
+		}
+	}
+
+	return attributes
+}
+
+// snsAttributesToMetadata maps the message attributes of an SNS envelope to message metadata.
+func snsAttributesToMetadata(attributes map[string]snsMessageAttribute) map[string]string {
+	md := make(map[string]string, len(attributes))
+	for k, attr := range attributes {
+		if k == awsSnsTopicNameKey {
+			continue
+		}
+		if attr.Type == messageAttributeTypeString || attr.Type == messageAttributeTypeNumber {
+			md[k] = attr.Value
+		}
+	}
+
+	return md
+}
+
+// sqsAttributesToMetadata maps the message attributes of a raw SQS message to message metadata.
+func sqsAttributesToMetadata(attributes map[string]*sqs.MessageAttributeValue) map[string]string {
+	md := make(map[string]string, len(attributes))
+	for k, attr := range attributes {
+		if k == awsSnsTopicNameKey || attr == nil || attr.StringValue == nil || attr.DataType == nil {
+			continue
+		}
+		// Custom types are suffixes of the base type, such as "String.json"
+		dataType := strings.SplitN(*attr.DataType, ".", 2)[0]
+		if dataType == messageAttributeTypeString || dataType == messageAttributeTypeNumber {
+			md[k] = *attr.StringValue
+		}
+	}
+
+	return md
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	mdutils "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
@@ -56,6 +57,11 @@ type snsSqsMetadata struct {
 	accountID string
 	// processing concurrency mode
 	concurrencyMode pubsub.ConcurrencyMode
+	// enables SNS raw message delivery on the subscriptions of the SQS queue, so messages are not wrapped in an SNS envelope.
+	rawMessageDelivery bool
+	// names of the metadata of publish requests that are forwarded as SNS message attributes, such as the attributes of
+	// the filter policies of the subscriptions. Other metadata is not forwarded.
+	messageAttributes []string
 }
 
 func parseInt64(input string, propertyName string) (int64, error) {
@@ -139,6 +145,14 @@ func (s *snsSqs) getSnsSqsMetatdata(metadata pubsub.Metadata) (*snsSqsMetadata,
 		return nil, err
 	}
 
+	if err := md.setRawMessageDelivery(props); err != nil {
+		return nil, err
+	}
+
+	if err := md.setMessageAttributes(props); err != nil {
+		return nil, err
+	}
+
 	s.logger.Debug(md.hideDebugPrintedCredentials())
 
 	return md, nil
@@ -154,6 +168,42 @@ func (md *snsSqsMetadata) setConcurrencyMode(props map[string]string) error {
 	return nil
 }
 
+func (md *snsSqsMetadata) setRawMessageDelivery(props map[string]string) error {
+	if val, ok := props["rawMessageDelivery"]; ok {
+		rawMessageDelivery, err := parseBool(val, "rawMessageDelivery")
+		if err != nil {
+			return err
+		}
+		md.rawMessageDelivery = rawMessageDelivery
+	}
+
+	return nil
+}
+
+func (md *snsSqsMetadata) setMessageAttributes(props map[string]string) error {
+	val, ok := props["messageAttributes"]
+	if !ok {
+		return nil
+	}
+
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, reserved := reservedMetadataKeys[name]; reserved || name == awsSnsTopicNameKey || !isValidAttributeName(name) {
+			return fmt.Errorf("invalid messageAttributes: %s can't be a message attribute", name)
+		}
+		md.messageAttributes = append(md.messageAttributes, name)
+	}
+	// the topic name is always one of the attributes.
+	if len(md.messageAttributes) > maxMessageAttributes-1 {
+		return fmt.Errorf("invalid messageAttributes: at most %d metadata properties can be message attributes", maxMessageAttributes-1)
+	}
+
+	return nil
+}
+
 func (md *snsSqsMetadata) hideDebugPrintedCredentials() string {
 	mdCopy := *md
 	mdCopy.AccessKey = maskLeft(md.AccessKey)
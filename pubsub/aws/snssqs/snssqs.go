@@ -33,6 +33,7 @@ import (
 	gonanoid "github.com/matoous/go-nanoid/v2"
 
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -67,6 +68,7 @@ type snsSqs struct {
 	pollerCancel  context.CancelFunc
 	backOffConfig retry.Config
 	pollerRunning chan struct{}
+	pauseGate     *pubsub.PauseGate
 }
 
 type sqsQueueInfo struct {
@@ -75,8 +77,9 @@ type sqsQueueInfo struct {
 }
 
 type snsMessage struct {
-	Message  string
-	TopicArn string
+	Message           string
+	TopicArn          string
+	MessageAttributes map[string]snsMessageAttribute
 }
 
 func (sn *snsMessage) parseTopicArn() string {
@@ -91,6 +94,11 @@ const (
 	maxAWSNameLength                      = 80
 	assetsManagementDefaultTimeoutSeconds = 5.0
 	awsAccountIDLength                    = 12
+
+	// subscription metadata holding the SNS filter policy and its scope. The policy of a subscription is removed when
+	// the metadata doesn't have one, and it can't be set when entity management is disabled.
+	filterPolicyMetadataKey      = "filterPolicy"
+	filterPolicyScopeMetadataKey = "filterPolicyScope"
 )
 
 // NewSnsSqs - constructor for a new snssqs dapr component.
@@ -105,6 +113,7 @@ func NewSnsSqs(l logger.Logger) pubsub.PubSub {
 		id:            id,
 		topicsLock:    sync.RWMutex{},
 		pollerRunning: make(chan struct{}, 1),
+		pauseGate:     pubsub.NewPauseGate(),
 	}
 }
 
@@ -389,7 +398,6 @@ func (s *snsSqs) getMessageGroupID(req *pubsub.PublishRequest) *string {
 func (s *snsSqs) createSnsSqsSubscription(parentCtx context.Context, queueArn, topicArn string) (string, error) {
 	ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
 	subscribeOutput, err := s.snsClient.SubscribeWithContext(ctx, &sns.SubscribeInput{
-		Attributes:            nil,
 		Endpoint:              aws.String(queueArn), // create SQS queue per subscription.
 		Protocol:              aws.String("sqs"),
 		ReturnSubscriptionArn: nil,
@@ -406,6 +414,23 @@ func (s *snsSqs) createSnsSqsSubscription(parentCtx context.Context, queueArn, t
 	return *subscribeOutput.SubscriptionArn, nil
 }
 
+// setSnsSqsSubscriptionRawMessageDelivery enables or disables the raw message delivery of a subscription, which may
+// exist already with another setting.
+func (s *snsSqs) setSnsSqsSubscriptionRawMessageDelivery(parentCtx context.Context, subscriptionArn string) error {
+	ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
+	_, err := s.snsClient.SetSubscriptionAttributesWithContext(ctx, &sns.SetSubscriptionAttributesInput{
+		AttributeName:   aws.String("RawMessageDelivery"),
+		AttributeValue:  aws.String(strconv.FormatBool(s.metadata.rawMessageDelivery)),
+		SubscriptionArn: aws.String(subscriptionArn),
+	})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("error setting raw message delivery of subscription %s: %w", subscriptionArn, err)
+	}
+
+	return nil
+}
+
 func (s *snsSqs) getSnsSqsSubscriptionArn(parentCtx context.Context, topicArn string) (string, error) {
 	ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
 	listSubscriptionsOutput, err := s.snsClient.ListSubscriptionsByTopicWithContext(ctx, &sns.ListSubscriptionsByTopicInput{TopicArn: aws.String(topicArn)})
@@ -440,6 +465,13 @@ func (s *snsSqs) getOrCreateSnsSqsSubscription(ctx context.Context, queueArn, to
 
 			return "", err
 		}
+
+		// the subscription is created without attributes, so an existing one is returned whatever its raw message delivery.
+		if err = s.setSnsSqsSubscriptionRawMessageDelivery(ctx, subscriptionArn); err != nil {
+			s.logger.Error(err)
+
+			return "", err
+		}
 	} else {
 		subscriptionArn, err = s.getSnsSqsSubscriptionArn(ctx, topicArn)
 		if err != nil {
@@ -455,6 +487,66 @@ func (s *snsSqs) getOrCreateSnsSqsSubscription(ctx context.Context, queueArn, to
 	return subscriptionArn, nil
 }
 
+// getFilterPolicy returns the SNS filter policy and its scope declared in the metadata of a subscription.
+func getFilterPolicy(md map[string]string) (policy string, scope string, err error) {
+	policy = strings.TrimSpace(md[filterPolicyMetadataKey])
+	scope = md[filterPolicyScopeMetadataKey]
+	if policy == "" {
+		if scope != "" {
+			return "", "", fmt.Errorf("%s is set without a %s", filterPolicyScopeMetadataKey, filterPolicyMetadataKey)
+		}
+
+		return "", "", nil
+	}
+
+	var parsed map[string]interface{}
+	if err = json.Unmarshal([]byte(policy), &parsed); err != nil {
+		return "", "", fmt.Errorf("invalid %s, it must be a JSON object: %w", filterPolicyMetadataKey, err)
+	}
+
+	switch scope {
+	case "", "MessageAttributes", "MessageBody":
+	default:
+		return "", "", fmt.Errorf("invalid %s %s, it must be MessageAttributes or MessageBody", filterPolicyScopeMetadataKey, scope)
+	}
+
+	return policy, scope, nil
+}
+
+// setSnsSqsSubscriptionFilterPolicy creates, updates or removes the filter policy of a subscription, so SNS only delivers
+// matching messages to the queue. An empty policy removes it, and an empty scope is the default MessageAttributes scope.
+func (s *snsSqs) setSnsSqsSubscriptionFilterPolicy(parentCtx context.Context, subscriptionArn, policy, scope string) error {
+	// the scope is set first, as SNS validates the policy against the current scope; it can't be set without a policy.
+	if policy != "" {
+		if scope == "" {
+			scope = "MessageAttributes"
+		}
+		ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
+		_, err := s.snsClient.SetSubscriptionAttributesWithContext(ctx, &sns.SetSubscriptionAttributesInput{
+			AttributeName:   aws.String("FilterPolicyScope"),
+			AttributeValue:  aws.String(scope),
+			SubscriptionArn: aws.String(subscriptionArn),
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("error setting filter policy scope of subscription %s: %w", subscriptionArn, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
+	_, err := s.snsClient.SetSubscriptionAttributesWithContext(ctx, &sns.SetSubscriptionAttributesInput{
+		AttributeName:   aws.String("FilterPolicy"),
+		AttributeValue:  aws.String(policy),
+		SubscriptionArn: aws.String(subscriptionArn),
+	})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("error setting filter policy of subscription %s: %w", subscriptionArn, err)
+	}
+
+	return nil
+}
+
 func (s *snsSqs) acknowledgeMessage(parentCtx context.Context, queueURL string, receiptHandle *string) error {
 	ctx, cancelFn := context.WithCancel(parentCtx)
 	_, err := s.sqsClient.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
@@ -540,17 +632,11 @@ func (s *snsSqs) validateMessage(ctx context.Context, message *sqs.Message, queu
 }
 
 func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInfo *sqsQueueInfo) error {
-	// otherwise, try to handle the message.
-	var snsMessagePayload snsMessage
-	err := json.Unmarshal([]byte(*(message.Body)), &snsMessagePayload)
+	data, sanitizedTopic, metadata, err := s.parseMessage(message)
 	if err != nil {
-		return fmt.Errorf("error unmarshalling message: %w", err)
+		return err
 	}
 
-	// snsMessagePayload.TopicArn can only carry a sanitized topic name as we conform to AWS naming standards.
-	// for the user to be able to understand the source of the coming message, we'd use the original,
-	// dirty name to be carried over in the pubsub.NewMessage Topic field.
-	sanitizedTopic := snsMessagePayload.parseTopicArn()
 	s.topicsLock.RLock()
 	handler, ok := s.topicHandlers[sanitizedTopic]
 	s.topicsLock.RUnlock()
@@ -558,11 +644,18 @@ func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInf
 		return fmt.Errorf("handler for topic (sanitized): %s not found", sanitizedTopic)
 	}
 
+	if s.pauseGate.IsPaused(handler.topicName) {
+		// The message isn't acknowledged, so it's received again once its visibility timeout expires
+		s.logger.Debugf("Subscription to topic %s is paused, leaving SNS message id: %s in the queue", handler.topicName, *message.MessageId)
+		return nil
+	}
+
 	s.logger.Debugf("Processing SNS message id: %s of topic: %s", *message.MessageId, sanitizedTopic)
 
 	err = handler.handler(handler.ctx, &pubsub.NewMessage{
-		Data:  []byte(snsMessagePayload.Message),
-		Topic: handler.topicName,
+		Data:     data,
+		Topic:    handler.topicName,
+		Metadata: metadata,
 	})
 	if err != nil {
 		return fmt.Errorf("error handling message: %w", err)
@@ -571,6 +664,30 @@ func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInf
 	return s.acknowledgeMessage(ctx, queueInfo.url, message.ReceiptHandle)
 }
 
+// parseMessage returns the payload, the sanitized topic name and the metadata of a message received from SQS.
+func (s *snsSqs) parseMessage(message *sqs.Message) ([]byte, string, map[string]string, error) {
+	// with raw message delivery the body is the published payload, and the topic is carried in a message attribute.
+	if s.metadata.rawMessageDelivery {
+		topicAttr, ok := message.MessageAttributes[awsSnsTopicNameKey]
+		if !ok || topicAttr == nil || topicAttr.StringValue == nil {
+			return nil, "", nil, fmt.Errorf("raw message is missing the %s attribute", awsSnsTopicNameKey)
+		}
+
+		return []byte(*(message.Body)), *topicAttr.StringValue, sqsAttributesToMetadata(message.MessageAttributes), nil
+	}
+
+	var snsMessagePayload snsMessage
+	err := json.Unmarshal([]byte(*(message.Body)), &snsMessagePayload)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error unmarshalling message: %w", err)
+	}
+
+	// snsMessagePayload.TopicArn can only carry a sanitized topic name as we conform to AWS naming standards.
+	// for the user to be able to understand the source of the coming message, we'd use the original,
+	// dirty name to be carried over in the pubsub.NewMessage Topic field.
+	return []byte(snsMessagePayload.Message), snsMessagePayload.parseTopicArn(), snsAttributesToMetadata(snsMessagePayload.MessageAttributes), nil
+}
+
 func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLettersQueueInfo *sqsQueueInfo) {
 	sqsPullExponentialBackoff := s.backOffConfig.NewBackOffWithContext(ctx)
 
@@ -579,10 +696,12 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 		AttributeNames: []*string{
 			aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount),
 		},
-		MaxNumberOfMessages: aws.Int64(s.metadata.messageMaxNumber),
-		QueueUrl:            aws.String(queueInfo.url),
-		VisibilityTimeout:   aws.Int64(s.metadata.messageVisibilityTimeout),
-		WaitTimeSeconds:     aws.Int64(s.metadata.messageWaitTimeSeconds),
+		// message attributes are mapped to the metadata of raw messages.
+		MessageAttributeNames: []*string{aws.String("All")},
+		MaxNumberOfMessages:   aws.Int64(s.metadata.messageMaxNumber),
+		QueueUrl:              aws.String(queueInfo.url),
+		VisibilityTimeout:     aws.Int64(s.metadata.messageVisibilityTimeout),
+		WaitTimeSeconds:       aws.Int64(s.metadata.messageWaitTimeSeconds),
 	}
 
 	for {
@@ -591,6 +710,11 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 			break
 		}
 
+		// The queue is shared by the topics, so it's only left alone while all of them are paused
+		if err := s.pauseGate.Wait(ctx, s.subscribedTopics()...); err != nil {
+			break
+		}
+
 		// Internally, by default, aws go sdk performs 3 retires with exponential backoff to contact
 		// sqs and try pull messages. Since we are iteratively short polling (based on the defined
 		// s.metadata.messageWaitTimeSeconds) the sdk backoff is not effective as it gets reset per each polling
@@ -748,6 +872,14 @@ func (s *snsSqs) restrictQueuePublishPolicyToOnlySNS(parentCtx context.Context,
 }
 
 func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	filterPolicy, filterPolicyScope, err := getFilterPolicy(req.Metadata)
+	if err != nil {
+		wrappedErr := fmt.Errorf("error subscribing to topic %s: %w", req.Topic, err)
+		s.logger.Error(wrappedErr)
+
+		return wrappedErr
+	}
+
 	// subscribers declare a topic ARN and declare a SQS queue to use
 	// these should be idempotent - queues should not be created if they exist.
 	topicArn, sanitizedName, err := s.getOrCreateTopic(subscribeCtx, req.Topic)
@@ -801,7 +933,7 @@ func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeReq
 	}
 
 	// subscription creation is idempotent. Subscriptions are unique by topic/queue.
-	_, err = s.getOrCreateSnsSqsSubscription(subscribeCtx, queueInfo.arn, topicArn)
+	subscriptionArn, err := s.getOrCreateSnsSqsSubscription(subscribeCtx, queueInfo.arn, topicArn)
 	if err != nil {
 		wrappedErr := fmt.Errorf("error subscribing topic: %s, to queue: %s, with error: %w", topicArn, queueInfo.arn, err)
 		s.logger.Error(wrappedErr)
@@ -809,6 +941,21 @@ func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeReq
 		return wrappedErr
 	}
 
+	// the filter policy is applied on every subscribe, so changes to the subscription metadata update the existing policy,
+	// and removing it from the metadata removes the policy.
+	if s.metadata.disableEntityManagement {
+		if filterPolicy != "" {
+			wrappedErr := fmt.Errorf("error subscribing to topic %s: %s can't be set when entity management is disabled", req.Topic, filterPolicyMetadataKey)
+			s.logger.Error(wrappedErr)
+
+			return wrappedErr
+		}
+	} else if err = s.setSnsSqsSubscriptionFilterPolicy(subscribeCtx, subscriptionArn, filterPolicy, filterPolicyScope); err != nil {
+		s.logger.Error(err)
+
+		return err
+	}
+
 	// Store the handler for this topic
 	s.topicsLock.Lock()
 	defer s.topicsLock.Unlock()
@@ -849,15 +996,18 @@ func (s *snsSqs) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeReq
 }
 
 func (s *snsSqs) Publish(req *pubsub.PublishRequest) error {
-	topicArn, _, err := s.getOrCreateTopic(s.ctx, req.Topic)
+	topicArn, sanitizedName, err := s.getOrCreateTopic(s.ctx, req.Topic)
 	if err != nil {
 		s.logger.Errorf("error getting topic ARN for %s: %v", req.Topic, err)
 	}
 
+	attributes := s.metadataToMessageAttributes(req.Metadata, sanitizedName)
+
 	message := string(req.Data)
 	snsPublishInput := &sns.PublishInput{
-		Message:  aws.String(message),
-		TopicArn: aws.String(topicArn),
+		Message:           aws.String(message),
+		MessageAttributes: attributes,
+		TopicArn:          aws.String(topicArn),
 	}
 	if s.metadata.fifo {
 		snsPublishInput.MessageGroupId = s.getMessageGroupID(req)
@@ -875,6 +1025,33 @@ func (s *snsSqs) Publish(req *pubsub.PublishRequest) error {
 	return nil
 }
 
+// subscribedTopics returns the topics with a handler.
+func (s *snsSqs) subscribedTopics() []string {
+	s.topicsLock.RLock()
+	defer s.topicsLock.RUnlock()
+
+	topics := make([]string, 0, len(s.topicHandlers))
+	for _, h := range s.topicHandlers {
+		topics = append(topics, h.topicName)
+	}
+
+	return topics
+}
+
+// PauseSubscription pauses the subscription to the topic. The queue, which is shared by the topics of the component,
+// isn't polled while all the topics are paused; while some of them aren't, the messages of the paused topics are left
+// in the queue, and received again once their visibility timeout expires, which counts toward messageReceiveLimit.
+func (s *snsSqs) PauseSubscription(topic string) error {
+	s.pauseGate.Pause(topic)
+	return nil
+}
+
+// ResumeSubscription resumes the subscription to the topic.
+func (s *snsSqs) ResumeSubscription(topic string) error {
+	s.pauseGate.Resume(topic)
+	return nil
+}
+
 func (s *snsSqs) Close() error {
 	s.cancel()
 
@@ -884,3 +1061,22 @@ func (s *snsSqs) Close() error {
 func (s *snsSqs) Features() []pubsub.Feature {
 	return nil
 }
+
+func (s *snsSqs) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+	// FIFO topics deliver the messages of a message group in order.
+	if s.metadata != nil && s.metadata.fifo {
+		g.Ordering = pubsub.OrderingPartition
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (s *snsSqs) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileAWSIAM},
+	}
+}
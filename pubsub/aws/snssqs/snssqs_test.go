@@ -14,9 +14,21 @@ limitations under the License.
 package snssqs
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
@@ -61,6 +73,8 @@ func Test_getSnsSqsMetatdata_AllConfiguration(t *testing.T) {
 		"messageWaitTimeSeconds":   "4",
 		"messageMaxNumber":         "5",
 		"messageReceiveLimit":      "6",
+		"rawMessageDelivery":       "true",
+		"messageAttributes":        "eventType, tenant",
 	}}})
 
 	r.NoError(err)
@@ -78,6 +92,8 @@ func Test_getSnsSqsMetatdata_AllConfiguration(t *testing.T) {
 	r.Equal(int64(4), md.messageWaitTimeSeconds)
 	r.Equal(int64(5), md.messageMaxNumber)
 	r.Equal(int64(6), md.messageReceiveLimit)
+	r.True(md.rawMessageDelivery)
+	r.Equal([]string{"eventType", "tenant"}, md.messageAttributes)
 }
 
 func Test_getSnsSqsMetatdata_defaults(t *testing.T) {
@@ -112,6 +128,8 @@ func Test_getSnsSqsMetatdata_defaults(t *testing.T) {
 	r.Equal(false, md.disableEntityManagement)
 	r.Equal(float64(5), md.assetsManagementTimeoutSeconds)
 	r.Equal(false, md.disableDeleteOnRetryLimit)
+	r.Equal(false, md.rawMessageDelivery)
+	r.Empty(md.messageAttributes)
 }
 
 func Test_getSnsSqsMetatdata_legacyaliases(t *testing.T) {
@@ -311,6 +329,194 @@ func Test_getSnsSqsMetatdata_invalidMetadataSetup(t *testing.T) {
 	}
 }
 
+func Test_metadataToMessageAttributes(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+	ps := snsSqs{
+		logger:   logger.NewLogger("SnsSqs unit test"),
+		metadata: &snsSqsMetadata{messageAttributes: []string{"eventType", "tenant"}},
+	}
+
+	attributes := ps.metadataToMessageAttributes(map[string]string{
+		"eventType":    "created",
+		"other":        "x",
+		"rawPayload":   "true",
+		"ttlInSeconds": "10",
+	}, "topic")
+	r.Len(attributes, 2)
+	r.Equal("String", *attributes["eventType"].DataType)
+	r.Equal("created", *attributes["eventType"].StringValue)
+	r.Equal("topic", *attributes[awsSnsTopicNameKey].StringValue)
+
+	ps.metadata.messageAttributes = nil
+	attributes = ps.metadataToMessageAttributes(map[string]string{"eventType": "created"}, "topic")
+	r.Len(attributes, 1)
+}
+
+func Test_setMessageAttributes(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]string, maxMessageAttributes)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("key%d", i)
+	}
+	for _, val := range []string{"rawPayload", "AWS.invalid", "in valid", awsSnsTopicNameKey, strings.Join(tooMany, ",")} {
+		md := &snsSqsMetadata{}
+		require.Error(t, md.setMessageAttributes(map[string]string{"messageAttributes": val}), val)
+	}
+
+	md := &snsSqsMetadata{}
+	require.NoError(t, md.setMessageAttributes(map[string]string{"messageAttributes": strings.Join(tooMany[1:], ",")}))
+	require.Len(t, md.messageAttributes, maxMessageAttributes-1)
+}
+
+// newTestSnsClient returns an SNS client of a server that records the forms of the SetSubscriptionAttributes requests.
+func newTestSnsClient(t *testing.T, forms *[]url.Values) *sns.SNS {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		*forms = append(*forms, r.PostForm)
+		w.Write([]byte(`<SetSubscriptionAttributesResponse></SetSubscriptionAttributesResponse>`))
+	}))
+	t.Cleanup(server.Close)
+
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("a", "s", ""),
+	})
+	require.NoError(t, err)
+	return sns.New(sess)
+}
+
+func Test_setSnsSqsSubscriptionRawMessageDelivery(t *testing.T) {
+	t.Parallel()
+
+	var forms []url.Values
+	ps := snsSqs{
+		snsClient:  newTestSnsClient(t, &forms),
+		metadata:   &snsSqsMetadata{},
+		opsTimeout: time.Second,
+	}
+
+	// an existing subscription may have raw message delivery enabled
+	require.NoError(t, ps.setSnsSqsSubscriptionRawMessageDelivery(context.Background(), "arn"))
+	ps.metadata.rawMessageDelivery = true
+	require.NoError(t, ps.setSnsSqsSubscriptionRawMessageDelivery(context.Background(), "arn"))
+
+	require.Len(t, forms, 2)
+	require.Equal(t, "SetSubscriptionAttributes", forms[0].Get("Action"))
+	require.Equal(t, "RawMessageDelivery", forms[0].Get("AttributeName"))
+	require.Equal(t, "false", forms[0].Get("AttributeValue"))
+	require.Equal(t, "true", forms[1].Get("AttributeValue"))
+}
+
+func Test_parseMessage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sns envelope", func(t *testing.T) {
+		r := require.New(t)
+		ps := snsSqs{metadata: &snsSqsMetadata{}}
+		body := `{"Message":"hello","TopicArn":"arn:aws:sns:us-east-1:000000000000:topic","MessageAttributes":{` +
+			`"eventType":{"Type":"String","Value":"created"},"count":{"Type":"Number","Value":"3"},` +
+			`"bin":{"Type":"Binary","Value":"AQI="},"dapr-topic-name":{"Type":"String","Value":"topic"}}}`
+
+		data, topic, md, err := ps.parseMessage(&sqs.Message{Body: aws.String(body)})
+		r.NoError(err)
+		r.Equal("hello", string(data))
+		r.Equal("topic", topic)
+		r.Equal(map[string]string{"eventType": "created", "count": "3"}, md)
+	})
+
+	t.Run("raw message", func(t *testing.T) {
+		r := require.New(t)
+		ps := snsSqs{metadata: &snsSqsMetadata{rawMessageDelivery: true}}
+		data, topic, md, err := ps.parseMessage(&sqs.Message{
+			Body: aws.String(`{"id":1}`),
+			MessageAttributes: map[string]*sqs.MessageAttributeValue{
+				awsSnsTopicNameKey: {DataType: aws.String("String"), StringValue: aws.String("topic")},
+				"eventType":        {DataType: aws.String("String.custom"), StringValue: aws.String("created")},
+				"bin":              {DataType: aws.String("Binary"), BinaryValue: []byte{1, 2}},
+			},
+		})
+		r.NoError(err)
+		r.Equal(`{"id":1}`, string(data))
+		r.Equal("topic", topic)
+		r.Equal(map[string]string{"eventType": "created"}, md)
+	})
+
+	t.Run("raw message without topic", func(t *testing.T) {
+		ps := snsSqs{metadata: &snsSqsMetadata{rawMessageDelivery: true}}
+		_, _, _, err := ps.parseMessage(&sqs.Message{Body: aws.String("data")})
+		require.Error(t, err)
+	})
+}
+
+func Test_getFilterPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no filter policy", func(t *testing.T) {
+		policy, scope, err := getFilterPolicy(map[string]string{})
+		require.NoError(t, err)
+		require.Empty(t, policy)
+		require.Empty(t, scope)
+	})
+
+	t.Run("filter policy with scope", func(t *testing.T) {
+		policy, scope, err := getFilterPolicy(map[string]string{
+			"filterPolicy":      ` {"eventType":["created"]} `,
+			"filterPolicyScope": "MessageBody",
+		})
+		require.NoError(t, err)
+		require.Equal(t, `{"eventType":["created"]}`, policy)
+		require.Equal(t, "MessageBody", scope)
+	})
+
+	t.Run("invalid filter policy", func(t *testing.T) {
+		_, _, err := getFilterPolicy(map[string]string{"filterPolicy": `["created"]`})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		_, _, err := getFilterPolicy(map[string]string{"filterPolicy": `{"a":["b"]}`, "filterPolicyScope": "Body"})
+		require.Error(t, err)
+	})
+
+	t.Run("scope without filter policy", func(t *testing.T) {
+		_, _, err := getFilterPolicy(map[string]string{"filterPolicyScope": "MessageAttributes"})
+		require.Error(t, err)
+	})
+}
+
+func Test_setSnsSqsSubscriptionFilterPolicy(t *testing.T) {
+	t.Parallel()
+
+	var forms []url.Values
+	ps := snsSqs{
+		snsClient:  newTestSnsClient(t, &forms),
+		metadata:   &snsSqsMetadata{},
+		opsTimeout: time.Second,
+	}
+
+	t.Run("set", func(t *testing.T) {
+		forms = nil
+		require.NoError(t, ps.setSnsSqsSubscriptionFilterPolicy(context.Background(), "arn", `{"a":["b"]}`, ""))
+		require.Len(t, forms, 2)
+		require.Equal(t, "FilterPolicyScope", forms[0].Get("AttributeName"))
+		require.Equal(t, "MessageAttributes", forms[0].Get("AttributeValue"))
+		require.Equal(t, "FilterPolicy", forms[1].Get("AttributeName"))
+		require.Equal(t, `{"a":["b"]}`, forms[1].Get("AttributeValue"))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		forms = nil
+		require.NoError(t, ps.setSnsSqsSubscriptionFilterPolicy(context.Background(), "arn", "", ""))
+		require.Len(t, forms, 1)
+		require.Equal(t, "FilterPolicy", forms[0].Get("AttributeName"))
+		require.True(t, forms[0].Has("AttributeValue"))
+		require.Empty(t, forms[0].Get("AttributeValue"))
+	})
+}
+
 func Test_parseInt64(t *testing.T) {
 	t.Parallel()
 	r := require.New(t)
@@ -45,6 +45,8 @@ const (
 	entityPathKey = "EntityPath"
 	// metadata partitionKey key.
 	partitionKeyMetadataKey = "partitionKey"
+	// metadata partitionID key.
+	partitionIDMetadataKey = "partitionID"
 
 	// errors.
 	hubManagerCreationErrorMsg               = "error: creating eventHub manager client"
@@ -310,13 +312,36 @@ func (aeh *AzureEventHubs) createHubEntity(parentCtx context.Context, hubName st
 	return nil
 }
 
-func (aeh *AzureEventHubs) ensurePublisherClient(ctx context.Context, hubName string) error {
+// publisherKey returns the key of the publisher client for the hub and, optionally, a specific partition.
+func publisherKey(hubName string, partitionID string) string {
+	if partitionID == "" {
+		return hubName
+	}
+	return hubName + "/" + partitionID
+}
+
+// getPublisherClient returns the client publishing to the hub, or to a specific partition of the hub if partitionID is set.
+func (aeh *AzureEventHubs) getPublisherClient(ctx context.Context, hubName string, partitionID string) (*eventhub.Hub, error) {
+	key := publisherKey(hubName, partitionID)
+	if _, ok := aeh.hubClients[key]; !ok {
+		if err := aeh.ensurePublisherClient(ctx, hubName, partitionID); err != nil {
+			return nil, fmt.Errorf("error on establishing hub connection: %s", err)
+		}
+	}
+	return aeh.hubClients[key], nil
+}
+
+func (aeh *AzureEventHubs) ensurePublisherClient(ctx context.Context, hubName string, partitionID string) error {
 	if aeh.metadata.EnableEntityManagement {
 		if err := aeh.ensureEventHub(ctx, hubName); err != nil {
 			return err
 		}
 	}
 	userAgent := "dapr-" + logger.DaprVersion
+	opts := []eventhub.HubOption{eventhub.HubWithUserAgent(userAgent)}
+	if partitionID != "" {
+		opts = append(opts, eventhub.HubWithPartitionedSender(partitionID))
+	}
 	if aeh.metadata.ConnectionString != "" {
 		// Connect with connection string.
 		newConnectionString, err := aeh.constructConnectionStringFromTopic(hubName)
@@ -324,23 +349,22 @@ func (aeh *AzureEventHubs) ensurePublisherClient(ctx context.Context, hubName st
 			return err
 		}
 
-		hub, err := eventhub.NewHubFromConnectionString(newConnectionString,
-			eventhub.HubWithUserAgent(userAgent))
+		hub, err := eventhub.NewHubFromConnectionString(newConnectionString, opts...)
 		if err != nil {
 			aeh.logger.Debugf("unable to connect to azure event hubs: %v", err)
 			return fmt.Errorf("unable to connect to azure event hubs: %v", err)
 		}
-		aeh.hubClients[hubName] = hub
+		aeh.hubClients[publisherKey(hubName, partitionID)] = hub
 	} else {
 		if hubName == "" {
 			return errors.New("error: missing topic/hubName attribute with AAD connection")
 		}
 
-		hub, err := eventhub.NewHub(aeh.metadata.EventHubNamespace, hubName, aeh.tokenProvider, eventhub.HubWithUserAgent(userAgent))
+		hub, err := eventhub.NewHub(aeh.metadata.EventHubNamespace, hubName, aeh.tokenProvider, opts...)
 		if err != nil {
 			return fmt.Errorf("unable to connect to azure event hubs: %v", err)
 		}
-		aeh.hubClients[hubName] = hub
+		aeh.hubClients[publisherKey(hubName, partitionID)] = hub
 	}
 
 	return nil
@@ -546,19 +570,34 @@ func (aeh *AzureEventHubs) Init(metadata pubsub.Metadata) error {
 	return nil
 }
 
+// getPartition returns the partition key and the partition ID set in the metadata of a message.
+// Only one of them can be set, as events sent to a specific partition can't have a partition key.
+func getPartition(md map[string]string) (partitionKey *string, partitionID string, err error) {
+	if val, ok := md[partitionKeyMetadataKey]; ok {
+		partitionKey = &val
+	}
+	partitionID = md[partitionIDMetadataKey]
+	if partitionKey != nil && *partitionKey != "" && partitionID != "" {
+		return nil, "", fmt.Errorf("only one of %s and %s can be set", partitionKeyMetadataKey, partitionIDMetadataKey)
+	}
+	return partitionKey, partitionID, nil
+}
+
 // Publish sends data to Azure Event Hubs.
 func (aeh *AzureEventHubs) Publish(req *pubsub.PublishRequest) error {
-	if _, ok := aeh.hubClients[req.Topic]; !ok {
-		if err := aeh.ensurePublisherClient(aeh.publishCtx, req.Topic); err != nil {
-			return fmt.Errorf("error on establishing hub connection: %s", err)
-		}
+	partitionKey, partitionID, err := getPartition(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("error from publish: %s", err)
 	}
-	event := &eventhub.Event{Data: req.Data}
-	val, ok := req.Metadata[partitionKeyMetadataKey]
-	if ok {
-		event.PartitionKey = &val
+	client, err := aeh.getPublisherClient(aeh.publishCtx, req.Topic, partitionID)
+	if err != nil {
+		return err
 	}
-	err := aeh.hubClients[req.Topic].Send(aeh.publishCtx, event)
+	event := &eventhub.Event{
+		Data:         req.Data,
+		PartitionKey: partitionKey,
+	}
+	err = client.Send(aeh.publishCtx, event)
 	if err != nil {
 		return fmt.Errorf("error from publish: %s", err)
 	}
@@ -567,12 +606,12 @@ func (aeh *AzureEventHubs) Publish(req *pubsub.PublishRequest) error {
 }
 
 // BulkPublish sends data to Azure Event Hubs in bulk.
+// The partition ID, if any, is set in the metadata of the request, as all the events of a batch are sent to the same partition.
 func (aeh *AzureEventHubs) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
-	if _, ok := aeh.hubClients[req.Topic]; !ok {
-		if err := aeh.ensurePublisherClient(ctx, req.Topic); err != nil {
-			err = fmt.Errorf("error on establishing hub connection: %s", err)
-			return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishFailed, err), err
-		}
+	partitionID := req.Metadata[partitionIDMetadataKey]
+	client, err := aeh.getPublisherClient(ctx, req.Topic, partitionID)
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishFailed, err), err
 	}
 
 	// Create a slice of events to send.
@@ -580,6 +619,10 @@ func (aeh *AzureEventHubs) BulkPublish(ctx context.Context, req *pubsub.BulkPubl
 	for i, entry := range req.Entries {
 		events[i] = &eventhub.Event{Data: entry.Event}
 		if val, ok := entry.Metadata[partitionKeyMetadataKey]; ok {
+			if partitionID != "" && val != "" {
+				err = fmt.Errorf("entry %s can't set %s when the events are sent to partition %s", entry.EntryId, partitionKeyMetadataKey, partitionID)
+				return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishFailed, err), err
+			}
 			events[i].PartitionKey = &val
 		}
 	}
@@ -591,7 +634,7 @@ func (aeh *AzureEventHubs) BulkPublish(ctx context.Context, req *pubsub.BulkPubl
 	}
 
 	// Send events.
-	err := aeh.hubClients[req.Topic].SendBatch(ctx, eventhub.NewEventBatchIterator(events...), opts...)
+	err = client.SendBatch(ctx, eventhub.NewEventBatchIterator(events...), opts...)
 	if err != nil {
 		// Partial success is not supported by Azure Event Hubs.
 		// If an error occurs, all events are considered failed.
@@ -708,3 +751,19 @@ func (aeh *AzureEventHubs) Close() (err error) {
 func (aeh *AzureEventHubs) Features() []pubsub.Feature {
 	return nil
 }
+
+func (aeh *AzureEventHubs) GetComponentMetadata() map[string]string {
+	// Events with the same partition key are sent to the same partition, which is read in order.
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingPartition,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (aeh *AzureEventHubs) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileConnectionString, contribMetadata.AuthProfileAzureAD},
+	}
+}
@@ -322,3 +322,30 @@ func TestConstructConnectionStringFromTopic(t *testing.T) {
 		assert.Equal(t, "", c)
 	})
 }
+
+func TestGetPartition(t *testing.T) {
+	t.Run("partition key", func(t *testing.T) {
+		partitionKey, partitionID, err := getPartition(map[string]string{"partitionKey": "key1"})
+		require.NoError(t, err)
+		require.NotNil(t, partitionKey)
+		assert.Equal(t, "key1", *partitionKey)
+		assert.Empty(t, partitionID)
+	})
+
+	t.Run("partition id", func(t *testing.T) {
+		partitionKey, partitionID, err := getPartition(map[string]string{"partitionID": "2"})
+		require.NoError(t, err)
+		assert.Nil(t, partitionKey)
+		assert.Equal(t, "2", partitionID)
+	})
+
+	t.Run("both partition key and partition id", func(t *testing.T) {
+		_, _, err := getPartition(map[string]string{"partitionKey": "key1", "partitionID": "2"})
+		assert.Error(t, err)
+	})
+
+	t.Run("publisher keys", func(t *testing.T) {
+		assert.Equal(t, "hub", publisherKey("hub", ""))
+		assert.Equal(t, "hub/2", publisherKey("hub", "2"))
+	})
+}
@@ -41,13 +41,16 @@ type azureServiceBus struct {
 	features      []pubsub.Feature
 	publishCtx    context.Context
 	publishCancel context.CancelFunc
+	rateLimiter   *pubsub.PublishRateLimiter
+	pauseGate     *pubsub.PauseGate
 }
 
 // NewAzureServiceBusQueues returns a new implementation.
 func NewAzureServiceBusQueues(logger logger.Logger) pubsub.PubSub {
 	return &azureServiceBus{
-		logger:   logger,
-		features: []pubsub.Feature{pubsub.FeatureMessageTTL},
+		logger:    logger,
+		features:  []pubsub.Feature{pubsub.FeatureMessageTTL},
+		pauseGate: pubsub.NewPauseGate(),
 	}
 }
 
@@ -62,6 +65,11 @@ func (a *azureServiceBus) Init(metadata pubsub.Metadata) (err error) {
 		return err
 	}
 
+	a.rateLimiter, err = pubsub.NewPublishRateLimiter(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
 	a.publishCtx, a.publishCancel = context.WithCancel(context.Background())
 
 	return nil
@@ -73,6 +81,11 @@ func (a *azureServiceBus) Publish(req *pubsub.PublishRequest) error {
 		return err
 	}
 
+	err = a.rateLimiter.Wait(a.publishCtx, req.Topic)
+	if err != nil {
+		return err
+	}
+
 	ebo := backoff.NewExponentialBackOff()
 	ebo.InitialInterval = time.Duration(a.metadata.PublishInitialRetryIntervalInMs) * time.Millisecond
 	bo := backoff.WithMaxRetries(ebo, uint64(a.metadata.PublishMaxRetries))
@@ -138,10 +151,15 @@ func (a *azureServiceBus) BulkPublish(ctx context.Context, req *pubsub.BulkPubli
 		return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishSucceeded, nil), nil
 	}
 
+	err := a.rateLimiter.WaitN(ctx, req.Topic, len(req.Entries))
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishFailed, err), err
+	}
+
 	// Ensure the queue exists the first time it is referenced
 	// This does nothing if DisableEntityManagement is true
 	// Note that the parameter is called "Topic" but we're publishing to a queue
-	err := a.client.EnsureQueue(a.publishCtx, req.Topic)
+	err = a.client.EnsureQueue(a.publishCtx, req.Topic)
 	if err != nil {
 		return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishFailed, err), err
 	}
@@ -231,6 +249,8 @@ func (a *azureServiceBus) BulkSubscribe(subscribeCtx context.Context, req pubsub
 func (a *azureServiceBus) doSubscribe(subscribeCtx context.Context,
 	req pubsub.SubscribeRequest, sub *impl.Subscription, receiveAndBlockFn func(func()) error,
 ) error {
+	sub.SetPauseGate(a.pauseGate, req.Topic)
+
 	// Does nothing if DisableEntityManagement is true
 	err := a.client.EnsureQueue(subscribeCtx, req.Topic)
 	if err != nil {
@@ -291,6 +311,18 @@ func (a *azureServiceBus) doSubscribe(subscribeCtx context.Context,
 	return nil
 }
 
+// PauseSubscription stops receiving the messages of the queue, which stay in Service Bus until it's resumed.
+func (a *azureServiceBus) PauseSubscription(topic string) error {
+	a.pauseGate.Pause(topic)
+	return nil
+}
+
+// ResumeSubscription resumes receiving the messages of the queue.
+func (a *azureServiceBus) ResumeSubscription(topic string) error {
+	a.pauseGate.Resume(topic)
+	return nil
+}
+
 func (a *azureServiceBus) Close() (err error) {
 	a.publishCancel()
 	a.client.CloseAllSenders(a.logger)
@@ -300,3 +332,19 @@ func (a *azureServiceBus) Close() (err error) {
 func (a *azureServiceBus) Features() []pubsub.Feature {
 	return a.features
 }
+
+func (a *azureServiceBus) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (a *azureServiceBus) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		Capabilities: pubsub.FeatureCapabilities(a.Features()),
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileConnectionString, contribMetadata.AuthProfileAzureAD},
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bridge
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	transformUnwrapCloudEvent = "unwrapCloudEvent"
+	transformDropMetadata     = "dropMetadata"
+)
+
+// ErrSkip is returned by a TransformFunc to acknowledge a message without republishing it.
+var ErrSkip = errors.New("bridge pubsub: skip message")
+
+var errNotInitialized = errors.New("bridge pubsub: not initialized")
+
+// TransformFunc is a hook modifying the request republishing a message consumed from the source pubsub.
+// Errors other than ErrSkip are returned to the source pubsub, which redelivers the message.
+type TransformFunc func(ctx context.Context, msg *pubsub.NewMessage, req *pubsub.PublishRequest) error
+
+// Bridge is a pubsub consuming the mapped topics of a source pubsub and republishing the messages to a destination pubsub,
+// to migrate between brokers gradually. Applications publishing and subscribing through the bridge use the destination pubsub.
+type Bridge struct {
+	source      pubsub.PubSub
+	destination pubsub.PubSub
+	resolver    pubsub.PubSubResolver
+	topics      map[string]string
+	transforms  []TransformFunc
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	lock   sync.Mutex
+	logger logger.Logger
+}
+
+type bridgeMetadata struct {
+	// Component names of the pubsubs messages are consumed from and republished to.
+	SourcePubsub      string `mapstructure:"sourcePubsub"`
+	DestinationPubsub string `mapstructure:"destinationPubsub"`
+	// Source topics to consume, each optionally mapped to a destination topic with "source:destination".
+	Topics []string `mapstructure:"topics"`
+	// Built-in transforms applied in order before republishing messages.
+	Transforms []string `mapstructure:"transforms"`
+}
+
+// NewBridgePubSub returns a new bridge pubsub.
+func NewBridgePubSub(logger logger.Logger) pubsub.PubSub {
+	return &Bridge{logger: logger}
+}
+
+// SetPubSubResolver sets the resolver of the source and destination pubsubs.
+func (b *Bridge) SetPubSubResolver(resolver pubsub.PubSubResolver) {
+	b.resolver = resolver
+}
+
+// AddTransform adds a hook applied to messages after the built-in transforms.
+// It must be called before Init, as the source topics are consumed as soon as the bridge is initialized.
+func (b *Bridge) AddTransform(fn TransformFunc) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.transforms = append(b.transforms, fn)
+}
+
+// Init parses the metadata, resolves the source and destination pubsubs and subscribes to the source topics.
+func (b *Bridge) Init(meta pubsub.Metadata) error {
+	m := bridgeMetadata{}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.SourcePubsub == "" || m.DestinationPubsub == "" {
+		return errors.New("bridge pubsub: missing sourcePubsub or destinationPubsub")
+	}
+	if m.SourcePubsub == m.DestinationPubsub {
+		return errors.New("bridge pubsub: sourcePubsub and destinationPubsub must be different")
+	}
+
+	b.topics = make(map[string]string, len(m.Topics))
+	for _, t := range m.Topics {
+		src, dst, _ := strings.Cut(strings.TrimSpace(t), ":")
+		src, dst = strings.TrimSpace(src), strings.TrimSpace(dst)
+		if src == "" {
+			continue
+		}
+		if dst == "" {
+			dst = src
+		}
+		if _, ok := b.topics[src]; ok {
+			return fmt.Errorf("bridge pubsub: topic %s is mapped more than once", src)
+		}
+		b.topics[src] = dst
+	}
+	if len(b.topics) == 0 {
+		return errors.New("bridge pubsub: missing topics")
+	}
+
+	builtins := make([]TransformFunc, 0, len(m.Transforms))
+	for _, t := range m.Transforms {
+		switch strings.TrimSpace(t) {
+		case "":
+			continue
+		case transformUnwrapCloudEvent:
+			builtins = append(builtins, unwrapCloudEvent)
+		case transformDropMetadata:
+			builtins = append(builtins, dropMetadata)
+		default:
+			return fmt.Errorf("bridge pubsub: invalid transform %s", t)
+		}
+	}
+
+	if b.resolver == nil {
+		return errors.New("bridge pubsub: no resolver for the source and destination pubsubs")
+	}
+	b.source, err = b.resolver(m.SourcePubsub)
+	if err != nil {
+		return fmt.Errorf("bridge pubsub: %w", err)
+	}
+	b.destination, err = b.resolver(m.DestinationPubsub)
+	if err != nil {
+		return fmt.Errorf("bridge pubsub: %w", err)
+	}
+
+	b.lock.Lock()
+	b.transforms = append(builtins, b.transforms...)
+	b.lock.Unlock()
+
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	for src, dst := range b.topics {
+		err = b.source.Subscribe(b.ctx, pubsub.SubscribeRequest{Topic: src, Metadata: map[string]string{}}, b.handler(dst))
+		if err != nil {
+			b.cancel()
+			return fmt.Errorf("bridge pubsub: error subscribing to source topic %s: %w", src, err)
+		}
+		b.logger.Infof("bridge pubsub: republishing topic %s of %s to topic %s of %s", src, m.SourcePubsub, dst, m.DestinationPubsub)
+	}
+
+	return nil
+}
+
+// handler returns the handler republishing the messages of a source topic to the destination topic.
+func (b *Bridge) handler(topic string) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		req := &pubsub.PublishRequest{
+			Data:        msg.Data,
+			Topic:       topic,
+			Metadata:    make(map[string]string, len(msg.Metadata)),
+			ContentType: msg.ContentType,
+		}
+		for k, v := range msg.Metadata {
+			req.Metadata[k] = v
+		}
+
+		b.lock.Lock()
+		transforms := b.transforms
+		b.lock.Unlock()
+		for _, fn := range transforms {
+			err := fn(ctx, msg, req)
+			if errors.Is(err, ErrSkip) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("bridge pubsub: error transforming message of topic %s: %w", msg.Topic, err)
+			}
+		}
+
+		err := b.destination.Publish(req)
+		if err != nil {
+			return fmt.Errorf("bridge pubsub: error republishing message of topic %s to topic %s: %w", msg.Topic, req.Topic, err)
+		}
+
+		return nil
+	}
+}
+
+// unwrapCloudEvent republishes the data of CloudEvents instead of the whole envelope.
+// Messages that aren't CloudEvents are republished as they are.
+func unwrapCloudEvent(_ context.Context, _ *pubsub.NewMessage, req *pubsub.PublishRequest) error {
+	var ce map[string]json.RawMessage
+	if json.Unmarshal(req.Data, &ce) != nil {
+		return nil
+	}
+	if _, ok := ce[pubsub.SpecVersionField]; !ok {
+		return nil
+	}
+
+	if raw, ok := ce[pubsub.DataBase64Field]; ok {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return fmt.Errorf("invalid %s: %w", pubsub.DataBase64Field, err)
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", pubsub.DataBase64Field, err)
+		}
+		req.Data = data
+	} else if raw, ok := ce[pubsub.DataField]; ok {
+		// String data is republished without the JSON quotes
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			req.Data = []byte(s)
+		} else {
+			req.Data = raw
+		}
+	} else {
+		req.Data = nil
+	}
+
+	var contentType string
+	if json.Unmarshal(ce[pubsub.DataContentTypeField], &contentType) == nil && contentType != "" {
+		req.ContentType = &contentType
+	}
+
+	return nil
+}
+
+// dropMetadata republishes messages without the metadata set by the source pubsub.
+func dropMetadata(_ context.Context, _ *pubsub.NewMessage, req *pubsub.PublishRequest) error {
+	req.Metadata = map[string]string{}
+
+	return nil
+}
+
+// Features returns the features of the destination pubsub, or none before the bridge is initialized.
+func (b *Bridge) Features() []pubsub.Feature {
+	if b.destination == nil {
+		return nil
+	}
+
+	return b.destination.Features()
+}
+
+func (b *Bridge) Publish(req *pubsub.PublishRequest) error {
+	if b.destination == nil {
+		return errNotInitialized
+	}
+
+	return b.destination.Publish(req)
+}
+
+func (b *Bridge) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if b.destination == nil {
+		return errNotInitialized
+	}
+
+	return b.destination.Subscribe(ctx, req, handler)
+}
+
+// GetComponentMetadata returns the weakest guarantees of the source and destination pubsubs.
+// Messages republished after a failure can be duplicated, so the delivery is at least once at best.
+// Before the bridge is initialized, the guarantees of the pubsubs are unknown and the weakest ones are returned.
+func (b *Bridge) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingTopic,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+	for _, ps := range []pubsub.PubSub{b.source, b.destination} {
+		pg := pubsub.Guarantees{Ordering: pubsub.OrderingNone, Delivery: pubsub.DeliveryAtMostOnce}
+		if ps != nil {
+			if psg, err := pubsub.GetGuarantees(ps); err == nil {
+				pg = psg
+			}
+		}
+		if pg.Ordering == pubsub.OrderingNone || (pg.Ordering == pubsub.OrderingPartition && g.Ordering == pubsub.OrderingTopic) {
+			g.Ordering = pg.Ordering
+		}
+		if pg.Delivery == pubsub.DeliveryAtMostOnce {
+			g.Delivery = pg.Delivery
+		}
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (b *Bridge) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
+// Close stops consuming the source topics. The source and destination pubsubs are components of their own, so they aren't closed.
+func (b *Bridge) Close() error {
+	if b.cancel != nil {
+		b.cancel()
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	inmemory "github.com/dapr/components-contrib/pubsub/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func newInMemoryPubSub(t *testing.T) pubsub.PubSub {
+	t.Helper()
+
+	ps := inmemory.New(logger.NewLogger("test"))
+	require.NoError(t, ps.Init(pubsub.Metadata{}))
+
+	return ps
+}
+
+func newBridge(t *testing.T, pubsubs map[string]pubsub.PubSub, props map[string]string, transforms ...TransformFunc) (*Bridge, error) {
+	t.Helper()
+
+	b := NewBridgePubSub(logger.NewLogger("test")).(*Bridge)
+	b.SetPubSubResolver(pubsub.NewMapPubSubResolver(pubsubs))
+	for _, fn := range transforms {
+		b.AddTransform(fn)
+	}
+	err := b.Init(pubsub.Metadata{Base: metadata.Base{Properties: props}})
+	if err == nil {
+		t.Cleanup(func() { b.Close() })
+	}
+
+	return b, err
+}
+
+// subscribe returns a channel receiving the messages of a topic.
+func subscribe(t *testing.T, ps pubsub.PubSub, topic string) <-chan *pubsub.NewMessage {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ch := make(chan *pubsub.NewMessage, 10)
+	require.NoError(t, ps.Subscribe(ctx, pubsub.SubscribeRequest{Topic: topic}, func(_ context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg
+		return nil
+	}))
+
+	return ch
+}
+
+func receive(t *testing.T, ch <-chan *pubsub.NewMessage) *pubsub.NewMessage {
+	t.Helper()
+
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "message not received")
+		return nil
+	}
+}
+
+func TestInit(t *testing.T) {
+	pubsubs := map[string]pubsub.PubSub{"old": newInMemoryPubSub(t), "new": newInMemoryPubSub(t)}
+
+	t.Run("valid metadata", func(t *testing.T) {
+		b, err := newBridge(t, pubsubs, map[string]string{
+			"sourcePubsub": "old", "destinationPubsub": "new", "topics": "orders:orders-v2, payments", "transforms": "dropMetadata",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"orders": "orders-v2", "payments": "payments"}, b.topics)
+		assert.Len(t, b.transforms, 1)
+	})
+
+	t.Run("same source and destination", func(t *testing.T) {
+		_, err := newBridge(t, pubsubs, map[string]string{"sourcePubsub": "old", "destinationPubsub": "old", "topics": "orders"})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing topics", func(t *testing.T) {
+		_, err := newBridge(t, pubsubs, map[string]string{"sourcePubsub": "old", "destinationPubsub": "new"})
+		assert.Error(t, err)
+	})
+
+	t.Run("topic mapped twice", func(t *testing.T) {
+		_, err := newBridge(t, pubsubs, map[string]string{"sourcePubsub": "old", "destinationPubsub": "new", "topics": "orders:a,orders:b"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid transform", func(t *testing.T) {
+		_, err := newBridge(t, pubsubs, map[string]string{"sourcePubsub": "old", "destinationPubsub": "new", "topics": "orders", "transforms": "uppercase"})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown pubsub", func(t *testing.T) {
+		_, err := newBridge(t, pubsubs, map[string]string{"sourcePubsub": "old", "destinationPubsub": "other", "topics": "orders"})
+		assert.Error(t, err)
+	})
+}
+
+func TestBridge(t *testing.T) {
+	source := newInMemoryPubSub(t)
+	destination := newInMemoryPubSub(t)
+
+	b, err := newBridge(t, map[string]pubsub.PubSub{"old": source, "new": destination}, map[string]string{
+		"sourcePubsub": "old", "destinationPubsub": "new", "topics": "orders:orders-v2,payments", "transforms": "unwrapCloudEvent",
+	}, func(_ context.Context, _ *pubsub.NewMessage, req *pubsub.PublishRequest) error {
+		if string(req.Data) == "skip" {
+			return ErrSkip
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	orders := subscribe(t, b, "orders-v2")
+	payments := subscribe(t, destination, "payments")
+
+	t.Run("republishes mapped topics", func(t *testing.T) {
+		require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("order-1")}))
+		msg := receive(t, orders)
+		assert.Equal(t, "order-1", string(msg.Data))
+
+		require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "payments", Data: []byte("payment-1")}))
+		assert.Equal(t, "payment-1", string(receive(t, payments).Data))
+	})
+
+	t.Run("unwraps cloud events", func(t *testing.T) {
+		require.NoError(t, source.Publish(&pubsub.PublishRequest{
+			Topic: "orders",
+			Data:  []byte(`{"specversion":"1.0","id":"1","type":"order","source":"app","datacontenttype":"application/json","data":{"id":2}}`),
+		}))
+		assert.JSONEq(t, `{"id":2}`, string(receive(t, orders).Data))
+	})
+
+	t.Run("skips messages", func(t *testing.T) {
+		require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("skip")}))
+		require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("order-2")}))
+		assert.Equal(t, "order-2", string(receive(t, orders).Data))
+	})
+
+	t.Run("publishes to the destination", func(t *testing.T) {
+		require.NoError(t, b.Publish(&pubsub.PublishRequest{Topic: "payments", Data: []byte("payment-2")}))
+		assert.Equal(t, "payment-2", string(receive(t, payments).Data))
+	})
+}
+
+func TestUnwrapCloudEvent(t *testing.T) {
+	t.Run("string data", func(t *testing.T) {
+		req := &pubsub.PublishRequest{Data: []byte(`{"specversion":"1.0","datacontenttype":"text/plain","data":"hello"}`)}
+		require.NoError(t, unwrapCloudEvent(context.Background(), nil, req))
+		assert.Equal(t, "hello", string(req.Data))
+		require.NotNil(t, req.ContentType)
+		assert.Equal(t, "text/plain", *req.ContentType)
+	})
+
+	t.Run("binary data", func(t *testing.T) {
+		req := &pubsub.PublishRequest{Data: []byte(`{"specversion":"1.0","data_base64":"AQI="}`)}
+		require.NoError(t, unwrapCloudEvent(context.Background(), nil, req))
+		assert.Equal(t, []byte{1, 2}, req.Data)
+	})
+
+	t.Run("not a cloud event", func(t *testing.T) {
+		req := &pubsub.PublishRequest{Data: []byte(`{"id":1}`)}
+		require.NoError(t, unwrapCloudEvent(context.Background(), nil, req))
+		assert.Equal(t, `{"id":1}`, string(req.Data))
+	})
+}
+
+func TestGetComponentMetadata(t *testing.T) {
+	b := &Bridge{source: newInMemoryPubSub(t), destination: newInMemoryPubSub(t)}
+	g, err := pubsub.GetGuarantees(b)
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.Guarantees{Ordering: pubsub.OrderingNone, Delivery: pubsub.DeliveryAtMostOnce}, g)
+}
+
+func TestBeforeInit(t *testing.T) {
+	b := NewBridgePubSub(logger.NewLogger("test"))
+	assert.Empty(t, b.Features())
+	g, err := pubsub.GetGuarantees(b)
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.Guarantees{Ordering: pubsub.OrderingNone, Delivery: pubsub.DeliveryAtMostOnce}, g)
+	assert.Error(t, b.Publish(&pubsub.PublishRequest{Topic: "topic"}))
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import "github.com/dapr/components-contrib/internal/component"
+
+// PubSubResolver returns the initialized pubsub with the given component name.
+type PubSubResolver func(name string) (PubSub, error)
+
+// CompositePubSub is implemented by pubsubs built on top of other pubsubs,
+// which are referenced by component name in their metadata.
+// The runtime calls SetPubSubResolver before Init, and the pubsub resolves the components it references in Init.
+type CompositePubSub interface {
+	SetPubSubResolver(resolver PubSubResolver)
+}
+
+// NewMapPubSubResolver returns a PubSubResolver for a fixed set of pubsubs, keyed by component name.
+func NewMapPubSubResolver(pubsubs map[string]PubSub) PubSubResolver {
+	return component.NewMapResolver("pubsub", pubsubs)
+}
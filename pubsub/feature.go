@@ -13,6 +13,8 @@ limitations under the License.
 
 package pubsub
 
+import "github.com/dapr/components-contrib/metadata"
+
 const (
 	// FeatureMessageTTL is the feature to handle message TTL.
 	FeatureMessageTTL Feature = "MESSAGE_TTL"
@@ -33,3 +35,18 @@ func (f Feature) IsPresent(features []Feature) bool {
 
 	return false
 }
+
+// FeatureCapabilities returns the capabilities declared in the component metadata for the features.
+func FeatureCapabilities(features []Feature) []metadata.Capability {
+	capabilities := make([]metadata.Capability, 0, len(features))
+	for _, f := range features {
+		switch f {
+		case FeatureMessageTTL:
+			capabilities = append(capabilities, metadata.CapabilityTTL)
+		case FeatureSubscribeWildcards:
+			capabilities = append(capabilities, metadata.CapabilitySubscribeWildcards)
+		}
+	}
+
+	return capabilities
+}
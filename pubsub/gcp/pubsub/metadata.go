@@ -31,4 +31,6 @@ type metadata struct {
 	EnableMessageOrdering   bool
 	MaxReconnectionAttempts int
 	ConnectionRecoveryInSec int
+	DeadLetterTopic         string
+	MaxDeliveryAttempts     int
 }
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	gcppubsub "cloud.google.com/go/pubsub"
@@ -25,6 +26,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -49,10 +51,23 @@ const (
 	metadataEnableMessageOrderingKey   = "enableMessageOrdering"
 	metadataMaxReconnectionAttemptsKey = "maxReconnectionAttempts"
 	metadataConnectionRecoveryInSecKey = "connectionRecoveryInSec"
+	metadataDeadLetterTopicKey         = "deadLetterTopic"
+	metadataMaxDeliveryAttemptsKey     = "maxDeliveryAttempts"
+
+	// Metadata of the received messages.
+	metadataDeliveryAttemptKey = "deliveryAttempt"
 
 	// Defaults.
 	defaultMaxReconnectionAttempts = 30
 	defaultConnectionRecoveryInSec = 2
+	defaultMaxDeliveryAttempts     = 5
+
+	// Prefix of the full names of the topics.
+	topicNamePrefix = "projects/"
+
+	// Bounds of the max delivery attempts of a dead letter policy, enforced by GCP.
+	minMaxDeliveryAttempts = 5
+	maxMaxDeliveryAttempts = 100
 )
 
 // GCPPubSub type.
@@ -62,6 +77,7 @@ type GCPPubSub struct {
 	logger        logger.Logger
 	publishCtx    context.Context
 	publishCancel context.CancelFunc
+	rateLimiter   *pubsub.PublishRateLimiter
 }
 
 type GCPAuthJSON struct {
@@ -173,6 +189,22 @@ func createMetadata(pubSubMetadata pubsub.Metadata) (*metadata, error) {
 		}
 	}
 
+	if val, found := pubSubMetadata.Properties[metadataDeadLetterTopicKey]; found && val != "" {
+		result.DeadLetterTopic = val
+	}
+
+	result.MaxDeliveryAttempts = defaultMaxDeliveryAttempts
+	if val, ok := pubSubMetadata.Properties[metadataMaxDeliveryAttemptsKey]; ok && val != "" {
+		var err error
+		result.MaxDeliveryAttempts, err = strconv.Atoi(val)
+		if err != nil {
+			return &result, fmt.Errorf("%s invalid maxDeliveryAttempts %s, %s", errorMessagePrefix, val, err)
+		}
+		if result.MaxDeliveryAttempts < minMaxDeliveryAttempts || result.MaxDeliveryAttempts > maxMaxDeliveryAttempts {
+			return &result, fmt.Errorf("%s invalid maxDeliveryAttempts %s, must be between %d and %d", errorMessagePrefix, val, minMaxDeliveryAttempts, maxMaxDeliveryAttempts)
+		}
+	}
+
 	return &result, nil
 }
 
@@ -183,6 +215,11 @@ func (g *GCPPubSub) Init(meta pubsub.Metadata) error {
 		return err
 	}
 
+	rateLimiter, err := pubsub.NewPublishRateLimiter(meta.Properties)
+	if err != nil {
+		return fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+
 	pubsubClient, err := g.getPubSubClient(context.Background(), metadata)
 	if err != nil {
 		return fmt.Errorf("%s error creating pubsub client: %w", errorMessagePrefix, err)
@@ -190,6 +227,7 @@ func (g *GCPPubSub) Init(meta pubsub.Metadata) error {
 
 	g.client = pubsubClient
 	g.metadata = metadata
+	g.rateLimiter = rateLimiter
 
 	g.publishCtx, g.publishCancel = context.WithCancel(context.Background())
 
@@ -241,9 +279,14 @@ func (g *GCPPubSub) Publish(req *pubsub.PublishRequest) error {
 		}
 	}
 
+	err := g.rateLimiter.Wait(g.publishCtx, req.Topic)
+	if err != nil {
+		return fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+
 	topic := g.getTopic(req.Topic)
 
-	_, err := topic.Publish(g.publishCtx, &gcppubsub.Message{
+	_, err = topic.Publish(g.publishCtx, &gcppubsub.Message{
 		Data: req.Data,
 	}).Get(g.publishCtx)
 
@@ -311,8 +354,9 @@ func (g *GCPPubSub) handleSubscriptionMessages(parentCtx context.Context, topic
 	for {
 		receiveErr = sub.Receive(parentCtx, func(ctx context.Context, m *gcppubsub.Message) {
 			msg := &pubsub.NewMessage{
-				Data:  m.Data,
-				Topic: topic.ID(),
+				Data:     m.Data,
+				Topic:    topic.ID(),
+				Metadata: messageMetadata(m),
 			}
 
 			err := handler(ctx, msg)
@@ -380,15 +424,51 @@ func (g *GCPPubSub) ensureSubscription(parentCtx context.Context, subscription s
 	entity := g.getSubscription(managedSubscription)
 	exists, subErr := entity.Exists(parentCtx)
 	if !exists {
-		_, subErr = g.client.CreateSubscription(parentCtx, managedSubscription, gcppubsub.SubscriptionConfig{
+		config := gcppubsub.SubscriptionConfig{
 			Topic:                 g.getTopic(topic),
 			EnableMessageOrdering: g.metadata.EnableMessageOrdering,
-		})
+		}
+		if g.metadata.DeadLetterTopic != "" {
+			// Topics of other projects are referenced by their full name, and are not provisioned
+			if !strings.HasPrefix(g.metadata.DeadLetterTopic, topicNamePrefix) {
+				err = g.ensureTopic(parentCtx, g.metadata.DeadLetterTopic)
+				if err != nil {
+					return fmt.Errorf("could not get valid dead letter topic %s, %w", g.metadata.DeadLetterTopic, err)
+				}
+			}
+			// The Pub/Sub service account must be allowed to publish to the dead letter topic, and to acknowledge
+			// the messages of the subscription, or the messages are not forwarded
+			config.DeadLetterPolicy = deadLetterPolicy(g.metadata)
+		}
+		_, subErr = g.client.CreateSubscription(parentCtx, managedSubscription, config)
 	}
 
 	return subErr
 }
 
+// deadLetterPolicy returns the dead letter policy of the subscriptions, whose topic is referenced by its full name.
+func deadLetterPolicy(metadata *metadata) *gcppubsub.DeadLetterPolicy {
+	topic := metadata.DeadLetterTopic
+	if !strings.HasPrefix(topic, topicNamePrefix) {
+		topic = fmt.Sprintf("%s%s/topics/%s", topicNamePrefix, metadata.ProjectID, topic)
+	}
+	return &gcppubsub.DeadLetterPolicy{
+		DeadLetterTopic:     topic,
+		MaxDeliveryAttempts: metadata.MaxDeliveryAttempts,
+	}
+}
+
+// messageMetadata returns the metadata of a received message.
+// The delivery attempt is only known for the subscriptions with a dead letter policy.
+func messageMetadata(m *gcppubsub.Message) map[string]string {
+	if m.DeliveryAttempt == nil {
+		return nil
+	}
+	return map[string]string{
+		metadataDeliveryAttemptKey: strconv.Itoa(*m.DeliveryAttempt),
+	}
+}
+
 func (g *GCPPubSub) getSubscription(subscription string) *gcppubsub.Subscription {
 	return g.client.Subscription(subscription)
 }
@@ -401,3 +481,22 @@ func (g *GCPPubSub) Close() error {
 func (g *GCPPubSub) Features() []pubsub.Feature {
 	return nil
 }
+
+func (g *GCPPubSub) GetComponentMetadata() map[string]string {
+	guarantees := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+	// With message ordering, messages with the same ordering key are delivered in order.
+	if g.metadata != nil && g.metadata.EnableMessageOrdering {
+		guarantees.Ordering = pubsub.OrderingPartition
+	}
+
+	return guarantees.ComponentMetadata()
+}
+
+func (g *GCPPubSub) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileGCPServiceAccount},
+	}
+}
@@ -16,6 +16,7 @@ package pubsub
 import (
 	"testing"
 
+	gcppubsub "cloud.google.com/go/pubsub"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/dapr/components-contrib/pubsub"
@@ -131,6 +132,42 @@ func TestInit(t *testing.T) {
 	})
 }
 
+func TestDeadLetter(t *testing.T) {
+	t.Run("metadata", func(t *testing.T) {
+		m := pubsub.Metadata{}
+		m.Properties = map[string]string{
+			"projectId":       "superproject",
+			"deadLetterTopic": "dlq",
+		}
+		pubSubMetadata, err := createMetadata(m)
+		assert.Nil(t, err)
+		assert.Equal(t, "dlq", pubSubMetadata.DeadLetterTopic)
+		assert.Equal(t, 5, pubSubMetadata.MaxDeliveryAttempts)
+
+		for _, val := range []string{invalidNumber, "4", "101"} {
+			m.Properties[metadataMaxDeliveryAttemptsKey] = val
+			_, err = createMetadata(m)
+			assert.Error(t, err)
+			assertValidErrorMessage(t, err)
+		}
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		policy := deadLetterPolicy(&metadata{ProjectID: "superproject", DeadLetterTopic: "dlq", MaxDeliveryAttempts: 10})
+		assert.Equal(t, "projects/superproject/topics/dlq", policy.DeadLetterTopic)
+		assert.Equal(t, 10, policy.MaxDeliveryAttempts)
+
+		policy = deadLetterPolicy(&metadata{ProjectID: "superproject", DeadLetterTopic: "projects/other/topics/dlq"})
+		assert.Equal(t, "projects/other/topics/dlq", policy.DeadLetterTopic)
+	})
+
+	t.Run("delivery attempt", func(t *testing.T) {
+		assert.Nil(t, messageMetadata(&gcppubsub.Message{}))
+		attempt := 3
+		assert.Equal(t, map[string]string{"deliveryAttempt": "3"}, messageMetadata(&gcppubsub.Message{DeliveryAttempt: &attempt}))
+	})
+}
+
 func assertValidErrorMessage(t *testing.T, err error) {
 	assert.Contains(t, err.Error(), errorMessagePrefix)
 }
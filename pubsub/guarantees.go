@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import "fmt"
+
+const (
+	// OrderingGuaranteeKey is the key of the ordering guarantee in the component metadata.
+	OrderingGuaranteeKey = "orderingGuarantee"
+	// DeliveryGuaranteeKey is the key of the delivery guarantee in the component metadata.
+	DeliveryGuaranteeKey = "deliveryGuarantee"
+)
+
+// OrderingGuarantee is the order in which a pubsub delivers messages, relative to the order they were published in.
+type OrderingGuarantee string
+
+const (
+	// OrderingNone means messages can be delivered in any order.
+	OrderingNone OrderingGuarantee = "none"
+	// OrderingPartition means messages published with the same partition or ordering key are delivered in order.
+	OrderingPartition OrderingGuarantee = "partition"
+	// OrderingTopic means all messages of a topic are delivered in order.
+	OrderingTopic OrderingGuarantee = "topic"
+)
+
+// DeliveryGuarantee is the number of times a pubsub delivers each message.
+type DeliveryGuarantee string
+
+const (
+	// DeliveryAtMostOnce means messages can be lost, but are never redelivered.
+	DeliveryAtMostOnce DeliveryGuarantee = "at-most-once"
+	// DeliveryAtLeastOnce means messages are redelivered until they are acknowledged, so they can be delivered more than once.
+	DeliveryAtLeastOnce DeliveryGuarantee = "at-least-once"
+	// DeliveryEffectivelyOnce means the broker deduplicates redeliveries, so messages are processed once.
+	DeliveryEffectivelyOnce DeliveryGuarantee = "effectively-once"
+)
+
+// Guarantees are the ordering and delivery guarantees of a pubsub, as configured.
+type Guarantees struct {
+	Ordering OrderingGuarantee
+	Delivery DeliveryGuarantee
+}
+
+// ComponentMetadata returns the guarantees as component metadata.
+func (g Guarantees) ComponentMetadata() map[string]string {
+	return map[string]string{
+		OrderingGuaranteeKey: string(g.Ordering),
+		DeliveryGuaranteeKey: string(g.Delivery),
+	}
+}
+
+// IsOrdered returns true if the pubsub delivers messages in order, at least within a partition.
+func (g Guarantees) IsOrdered() bool {
+	return g.Ordering == OrderingPartition || g.Ordering == OrderingTopic
+}
+
+// GetGuarantees returns the guarantees declared by a pubsub in its component metadata.
+func GetGuarantees(pubsub PubSub) (Guarantees, error) {
+	md := pubsub.GetComponentMetadata()
+	g := Guarantees{
+		Ordering: OrderingGuarantee(md[OrderingGuaranteeKey]),
+		Delivery: DeliveryGuarantee(md[DeliveryGuaranteeKey]),
+	}
+
+	switch g.Ordering {
+	case OrderingNone, OrderingPartition, OrderingTopic:
+	default:
+		return g, fmt.Errorf("invalid ordering guarantee '%s'", g.Ordering)
+	}
+	switch g.Delivery {
+	case DeliveryAtMostOnce, DeliveryAtLeastOnce, DeliveryEffectivelyOnce:
+	default:
+		return g, fmt.Errorf("invalid delivery guarantee '%s'", g.Delivery)
+	}
+
+	return g, nil
+}
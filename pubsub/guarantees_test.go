@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type guaranteesPubSub struct {
+	md map[string]string
+}
+
+func (p *guaranteesPubSub) Init(metadata Metadata) error {
+	return nil
+}
+
+func (p *guaranteesPubSub) Features() []Feature {
+	return nil
+}
+
+func (p *guaranteesPubSub) Publish(req *PublishRequest) error {
+	return nil
+}
+
+func (p *guaranteesPubSub) Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error {
+	return nil
+}
+
+func (p *guaranteesPubSub) Close() error {
+	return nil
+}
+
+func (p *guaranteesPubSub) GetComponentMetadata() map[string]string {
+	return p.md
+}
+
+func TestGetGuarantees(t *testing.T) {
+	t.Run("declared guarantees", func(t *testing.T) {
+		ps := &guaranteesPubSub{md: Guarantees{Ordering: OrderingPartition, Delivery: DeliveryAtLeastOnce}.ComponentMetadata()}
+		g, err := GetGuarantees(ps)
+		require.NoError(t, err)
+		assert.Equal(t, OrderingPartition, g.Ordering)
+		assert.Equal(t, DeliveryAtLeastOnce, g.Delivery)
+		assert.True(t, g.IsOrdered())
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		g := Guarantees{Ordering: OrderingNone, Delivery: DeliveryAtMostOnce}
+		assert.False(t, g.IsOrdered())
+	})
+
+	t.Run("missing guarantees", func(t *testing.T) {
+		_, err := GetGuarantees(&guaranteesPubSub{md: map[string]string{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid delivery guarantee", func(t *testing.T) {
+		_, err := GetGuarantees(&guaranteesPubSub{md: map[string]string{
+			OrderingGuaranteeKey: "topic",
+			DeliveryGuaranteeKey: "exactly-once",
+		}})
+		assert.Error(t, err)
+	})
+}
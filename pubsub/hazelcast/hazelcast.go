@@ -23,8 +23,8 @@ import (
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/hazelcast/hazelcast-go-client"
-	hazelcastCore "github.com/hazelcast/hazelcast-go-client/core"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -36,7 +36,7 @@ const (
 )
 
 type Hazelcast struct {
-	client   hazelcast.Client
+	client   *hazelcast.Client
 	logger   logger.Logger
 	metadata metadata
 }
@@ -77,9 +77,9 @@ func (p *Hazelcast) Init(metadata pubsub.Metadata) error {
 	hzConfig := hazelcast.NewConfig()
 
 	servers := m.hazelcastServers
-	hzConfig.NetworkConfig().AddAddress(strings.Split(servers, ",")...)
+	hzConfig.Cluster.Network.SetAddresses(strings.Split(servers, ",")...)
 
-	p.client, err = hazelcast.NewClientWithConfig(hzConfig)
+	p.client, err = hazelcast.StartNewClientWithConfig(context.Background(), hzConfig)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to create new client, %v", err)
 	}
@@ -88,12 +88,13 @@ func (p *Hazelcast) Init(metadata pubsub.Metadata) error {
 }
 
 func (p *Hazelcast) Publish(req *pubsub.PublishRequest) error {
-	topic, err := p.client.GetTopic(req.Topic)
+	ctx := context.Background()
+	topic, err := p.client.GetTopic(ctx, req.Topic)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to get topic for %s", req.Topic)
 	}
 
-	if err = topic.Publish(req.Data); err != nil {
+	if err = topic.Publish(ctx, req.Data); err != nil {
 		return fmt.Errorf("hazelcast error: failed to publish data, %v", err)
 	}
 
@@ -101,17 +102,18 @@ func (p *Hazelcast) Publish(req *pubsub.PublishRequest) error {
 }
 
 func (p *Hazelcast) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	topic, err := p.client.GetTopic(req.Topic)
+	topic, err := p.client.GetTopic(subscribeCtx, req.Topic)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to get topic for %s", req.Topic)
 	}
 
-	listenerID, err := topic.AddMessageListener(&hazelcastMessageListener{
+	listener := &hazelcastMessageListener{
 		p:             p,
 		ctx:           subscribeCtx,
 		topicName:     topic.Name(),
 		pubsubHandler: handler,
-	})
+	}
+	listenerID, err := topic.AddMessageListener(subscribeCtx, listener.OnMessage)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to add new listener, %v", err)
 	}
@@ -119,22 +121,37 @@ func (p *Hazelcast) Subscribe(subscribeCtx context.Context, req pubsub.Subscribe
 	// Wait for context cancelation then remove the listener
 	go func() {
 		<-subscribeCtx.Done()
-		topic.RemoveMessageListener(listenerID)
+		// Use the background context as subscribeCtx is already closed
+		topic.RemoveListener(context.Background(), listenerID)
 	}()
 
 	return nil
 }
 
 func (p *Hazelcast) Close() error {
-	p.client.Shutdown()
-
-	return nil
+	return p.client.Shutdown(context.Background())
 }
 
 func (p *Hazelcast) Features() []pubsub.Feature {
 	return nil
 }
 
+func (p *Hazelcast) GetComponentMetadata() map[string]string {
+	// Hazelcast topics don't retain messages for disconnected listeners.
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtMostOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (p *Hazelcast) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone},
+	}
+}
+
 type hazelcastMessageListener struct {
 	p             *Hazelcast
 	ctx           context.Context
@@ -142,19 +159,17 @@ type hazelcastMessageListener struct {
 	pubsubHandler pubsub.Handler
 }
 
-func (l *hazelcastMessageListener) OnMessage(message hazelcastCore.Message) error {
-	msg, ok := message.MessageObject().([]byte)
+func (l *hazelcastMessageListener) OnMessage(message *hazelcast.MessagePublished) {
+	msg, ok := message.Value.([]byte)
 	if !ok {
-		return errors.New("hazelcast error: cannot cast message to byte array")
+		l.p.logger.Error("hazelcast error: cannot cast message to byte array")
+
+		return
 	}
 
 	if err := l.handleMessageObject(msg); err != nil {
 		l.p.logger.Error("Failure processing Hazelcast message")
-
-		return err
 	}
-
-	return nil
 }
 
 func (l *hazelcastMessageListener) handleMessageObject(message []byte) error {
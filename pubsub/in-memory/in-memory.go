@@ -18,13 +18,15 @@ import (
 	"time"
 
 	"github.com/dapr/components-contrib/internal/eventbus"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
 
 type bus struct {
-	bus eventbus.Bus
-	log logger.Logger
+	bus     eventbus.Bus
+	schemas *pubsub.SchemaValidator
+	log     logger.Logger
 }
 
 func New(logger logger.Logger) pubsub.PubSub {
@@ -41,13 +43,37 @@ func (a *bus) Features() []pubsub.Feature {
 	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards}
 }
 
+func (a *bus) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtMostOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (a *bus) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		Capabilities: pubsub.FeatureCapabilities(a.Features()),
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone},
+	}
+}
+
 func (a *bus) Init(metadata pubsub.Metadata) error {
+	schemas, err := pubsub.NewSchemaValidator(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	a.schemas = schemas
 	a.bus = eventbus.New(true)
 
 	return nil
 }
 
 func (a *bus) Publish(req *pubsub.PublishRequest) error {
+	if err := a.schemas.Validate(req); err != nil {
+		return err
+	}
 	a.bus.Publish(req.Topic, req.Data)
 
 	return nil
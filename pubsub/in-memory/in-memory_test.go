@@ -20,6 +20,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -107,3 +108,23 @@ func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 
 	return nil
 }
+
+func TestPublishSchema(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	err := bus.Init(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+		pubsub.PublishSchemaTopicKeyPrefix + "demo": `{"type": "object", "required": ["id"]}`,
+	}}})
+	assert.NoError(t, err)
+
+	ch := make(chan []byte, 1)
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return publish(ch, msg)
+	})
+
+	err = bus.Publish(&pubsub.PublishRequest{Data: []byte(`{"foo":1}`), Topic: "demo"})
+	assert.ErrorIs(t, err, pubsub.ErrSchemaValidation)
+
+	err = bus.Publish(&pubsub.PublishRequest{Data: []byte(`{"id":1}`), Topic: "demo"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(<-ch))
+}
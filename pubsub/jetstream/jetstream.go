@@ -19,6 +19,7 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nkeys"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -90,6 +91,21 @@ func (js *jetstreamPubSub) Features() []pubsub.Feature {
 	return nil
 }
 
+func (js *jetstreamPubSub) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (js *jetstreamPubSub) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfileToken, contribMetadata.AuthProfileJWT, contribMetadata.AuthProfileMTLS},
+	}
+}
+
 func (js *jetstreamPubSub) Publish(req *pubsub.PublishRequest) error {
 	var opts []nats.PubOpt
 	var msgID string
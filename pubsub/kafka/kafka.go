@@ -110,6 +110,18 @@ func (p *PubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest
 	return p.kafka.BulkPublish(ctx, req.Topic, req.Entries, req.Metadata)
 }
 
+// PauseSubscription pauses fetching the partitions of the topic.
+func (p *PubSub) PauseSubscription(topic string) error {
+	p.kafka.PauseTopic(topic)
+	return nil
+}
+
+// ResumeSubscription resumes fetching the partitions of the topic.
+func (p *PubSub) ResumeSubscription(topic string) error {
+	p.kafka.ResumeTopic(topic)
+	return nil
+}
+
 func (p *PubSub) Close() (err error) {
 	p.subscribeCancel()
 	return p.kafka.Close()
@@ -119,6 +131,22 @@ func (p *PubSub) Features() []pubsub.Feature {
 	return nil
 }
 
+func (p *PubSub) GetComponentMetadata() map[string]string {
+	// Messages with the same partition key are sent to the same partition, which is read in order.
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingPartition,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (p *PubSub) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword, metadata.AuthProfileOAuth2, metadata.AuthProfileMTLS, metadata.AuthProfileSPIFFE},
+	}
+}
+
 func adaptHandler(handler pubsub.Handler) kafka.EventHandler {
 	return func(ctx context.Context, event *kafka.NewEvent) error {
 		return handler(ctx, &pubsub.NewMessage{
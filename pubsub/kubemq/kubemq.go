@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -18,6 +19,7 @@ type kubeMQ struct {
 	ctxCancel        context.CancelFunc
 	eventsClient     *kubeMQEvents
 	eventStoreClient *kubeMQEventStore
+	queuesClient     *kubeMQQueues
 }
 
 func NewKubeMQ(logger logger.Logger) pubsub.PubSub {
@@ -34,7 +36,12 @@ func (k *kubeMQ) Init(metadata pubsub.Metadata) error {
 	}
 	k.metadata = meta
 	k.ctx, k.ctxCancel = context.WithCancel(context.Background())
-	if meta.isStore {
+	if meta.isQueue {
+		k.queuesClient = newKubeMQQueues(k.logger)
+		if err := k.queuesClient.Init(meta); err != nil {
+			return err
+		}
+	} else if meta.isStore {
 		k.eventStoreClient = newKubeMQEventsStore(k.logger)
 		_ = k.eventStoreClient.Init(meta)
 	} else {
@@ -48,7 +55,29 @@ func (k *kubeMQ) Features() []pubsub.Feature {
 	return nil
 }
 
+func (k *kubeMQ) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtMostOnce,
+	}
+	// Events are fire-and-forget, while events store and queue messages are persisted until delivered.
+	if k.metadata != nil && (k.metadata.isQueue || k.metadata.isStore) {
+		g.Delivery = pubsub.DeliveryAtLeastOnce
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (k *kubeMQ) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfileToken},
+	}
+}
+
 func (k *kubeMQ) Publish(req *pubsub.PublishRequest) error {
+	if k.metadata.isQueue {
+		return k.queuesClient.Publish(req)
+	}
 	if k.metadata.isStore {
 		return k.eventStoreClient.Publish(req)
 	} else {
@@ -57,6 +86,9 @@ func (k *kubeMQ) Publish(req *pubsub.PublishRequest) error {
 }
 
 func (k *kubeMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if k.metadata.isQueue {
+		return k.queuesClient.Subscribe(ctx, req, handler)
+	}
 	if k.metadata.isStore {
 		return k.eventStoreClient.Subscribe(ctx, req, handler)
 	} else {
@@ -65,6 +97,9 @@ func (k *kubeMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 }
 
 func (k *kubeMQ) Close() error {
+	if k.metadata.isQueue {
+		return k.queuesClient.Close()
+	}
 	if k.metadata.isStore {
 		return k.eventStoreClient.Close()
 	} else {
@@ -0,0 +1,225 @@
+package kubemq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	qs "github.com/kubemq-io/kubemq-go/queues_stream"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultQueuePollMaxItems       = 1
+	defaultQueuePollTimeoutSeconds = 60
+)
+
+// interface used to allow unit testing.
+type kubemqQueuesClient interface {
+	Send(ctx context.Context, messages ...*qs.QueueMessage) (*qs.SendResult, error)
+	Poll(ctx context.Context, request *qs.PollRequest) (*qs.PollResponse, error)
+	Close() error
+}
+
+// kubeMQQueues publishes and subscribes using KubeMQ queue streams.
+// Unlike events, queue messages are persisted until acknowledged, support delayed delivery,
+// and are moved to a dead-letter queue after maxReceiveCount failed deliveries.
+type kubeMQQueues struct {
+	lock          sync.RWMutex
+	client        kubemqQueuesClient
+	metadata      *metadata
+	logger        logger.Logger
+	ctx           context.Context
+	ctxCancel     context.CancelFunc
+	isInitialized bool
+}
+
+func newKubeMQQueues(logger logger.Logger) *kubeMQQueues {
+	return &kubeMQQueues{
+		client:        nil,
+		metadata:      nil,
+		logger:        logger,
+		ctx:           nil,
+		ctxCancel:     nil,
+		isInitialized: false,
+	}
+}
+
+func (k *kubeMQQueues) init() error {
+	k.lock.RLock()
+	isInit := k.isInitialized
+	k.lock.RUnlock()
+	if isInit {
+		return nil
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.ctx, k.ctxCancel = context.WithCancel(context.Background())
+	clientID := k.metadata.clientID
+	if clientID == "" {
+		clientID = getRandomID()
+	}
+	client, err := qs.NewQueuesStreamClient(k.ctx,
+		qs.WithAddress(k.metadata.host, k.metadata.port),
+		qs.WithClientId(clientID),
+		qs.WithCheckConnection(true),
+		qs.WithAuthToken(k.metadata.authToken),
+		qs.WithAutoReconnect(true),
+		qs.WithReconnectInterval(time.Second))
+	if err != nil {
+		k.logger.Errorf("error init kubemq client error: %s", err.Error())
+		return err
+	}
+	k.client = client
+	k.isInitialized = true
+	return nil
+}
+
+func (k *kubeMQQueues) Init(meta *metadata) error {
+	k.metadata = meta
+	return k.init()
+}
+
+func (k *kubeMQQueues) Publish(req *pubsub.PublishRequest) error {
+	if err := k.init(); err != nil {
+		return err
+	}
+	k.logger.Debugf("kubemq pub/sub: publishing message to queue %s", req.Topic)
+	queueMessage := qs.NewQueueMessage().
+		SetChannel(req.Topic).
+		SetBody(req.Data).
+		SetPolicyDelaySeconds(k.getIntPolicy(req.Metadata, "delaySeconds", k.metadata.delaySeconds)).
+		SetPolicyExpirationSeconds(k.getIntPolicy(req.Metadata, "expirationSeconds", k.metadata.expirationSeconds)).
+		SetPolicyMaxReceiveCount(k.getIntPolicy(req.Metadata, "maxReceiveCount", k.metadata.maxReceiveCount)).
+		SetPolicyMaxReceiveQueue(k.getMaxReceiveQueue(req.Metadata))
+	result, err := k.client.Send(k.ctx, queueMessage)
+	if err != nil {
+		k.logger.Errorf("kubemq pub/sub error: publishing to %s failed with error: %s", req.Topic, err.Error())
+		return err
+	}
+	if len(result.Results) > 0 && result.Results[0].IsError {
+		return fmt.Errorf("kubemq pub/sub error: publishing to %s failed with error: %s", req.Topic, result.Results[0].Error)
+	}
+	return nil
+}
+
+func (k *kubeMQQueues) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if err := k.init(); err != nil {
+		return err
+	}
+	k.logger.Debugf("kubemq pub/sub: subscribing to queue %s", req.Topic)
+	go func() {
+		for {
+			err := k.processQueueMessages(ctx, req.Topic, handler)
+			if ctx.Err() != nil || k.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				k.logger.Errorf("kubemq pub/sub error: polling queue '%s' failed, %s", req.Topic, err.Error())
+				time.Sleep(time.Second)
+			}
+		}
+	}()
+	return nil
+}
+
+func (k *kubeMQQueues) processQueueMessages(ctx context.Context, topic string, handler pubsub.Handler) error {
+	pollMaxItems := k.metadata.pollMaxItems
+	if pollMaxItems <= 0 {
+		pollMaxItems = defaultQueuePollMaxItems
+	}
+	pollTimeoutSeconds := k.metadata.pollTimeoutSeconds
+	if pollTimeoutSeconds <= 0 {
+		pollTimeoutSeconds = defaultQueuePollTimeoutSeconds
+	}
+	waitTimeout := time.Duration(pollTimeoutSeconds) * time.Second
+	pr := qs.NewPollRequest().
+		SetChannel(topic).
+		SetMaxItems(pollMaxItems).
+		SetWaitTimeout(int(waitTimeout.Milliseconds())).
+		SetAutoAck(false)
+
+	start := time.Now()
+	pollResp, err := k.client.Poll(ctx, pr)
+	if err != nil {
+		// The client fails the polls the broker didn't answer within the wait timeout with an untyped error,
+		// which only means that the queue was empty
+		if ctx.Err() == nil && time.Since(start) >= waitTimeout {
+			return nil
+		}
+		return err
+	}
+	if !pollResp.HasMessages() {
+		return nil
+	}
+
+	for _, message := range pollResp.Messages {
+		msg := &pubsub.NewMessage{
+			Data:     message.Body,
+			Topic:    topic,
+			Metadata: map[string]string{"receiveCount": strconv.Itoa(int(message.Attributes.GetReceiveCount()))},
+		}
+		if err := k.handleWithVisibility(ctx, msg, handler); err != nil {
+			// A nack makes the message visible again; once maxReceiveCount is reached the broker moves it to the dead-letter queue
+			k.logger.Errorf("kubemq pub/sub error: error handling message from queue '%s', %s", topic, err.Error())
+			if err := message.NAck(); err != nil {
+				k.logger.Errorf("kubemq pub/sub error: error processing nack message, %s", err.Error())
+			}
+			continue
+		}
+		if err := message.Ack(); err != nil {
+			k.logger.Errorf("kubemq pub/sub error: error processing ack message, %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// handleWithVisibility invokes the handler, failing the delivery if it takes longer than the visibility timeout.
+// The context of the handler is canceled when the visibility timeout expires, and the handler is waited for so
+// that the message isn't redelivered while it's still being processed.
+func (k *kubeMQQueues) handleWithVisibility(ctx context.Context, msg *pubsub.NewMessage, handler pubsub.Handler) error {
+	if k.metadata.visibilitySeconds <= 0 {
+		return handler(ctx, msg)
+	}
+
+	handlerCtx, cancel := context.WithTimeout(ctx, time.Duration(k.metadata.visibilitySeconds)*time.Second)
+	defer cancel()
+	err := handler(handlerCtx, msg)
+	if errors.Is(handlerCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("visibility timeout of %d seconds expired", k.metadata.visibilitySeconds)
+	}
+	return err
+}
+
+func (k *kubeMQQueues) getIntPolicy(md map[string]string, key string, defaultValue int) int {
+	if val, found := md[key]; found && val != "" {
+		intVal, err := strconv.Atoi(val)
+		if err == nil && intVal >= 0 {
+			return intVal
+		}
+		k.logger.Warnf("kubemq pub/sub: invalid %s value %s, using %d", key, val, defaultValue)
+	}
+	return defaultValue
+}
+
+func (k *kubeMQQueues) getMaxReceiveQueue(md map[string]string) string {
+	if val, found := md["maxReceiveQueue"]; found && val != "" {
+		return val
+	}
+	return k.metadata.maxReceiveQueue
+}
+
+func (k *kubeMQQueues) Close() error {
+	if k.ctxCancel != nil {
+		k.ctxCancel()
+	}
+	if k.client == nil {
+		return nil
+	}
+	return k.client.Close()
+}
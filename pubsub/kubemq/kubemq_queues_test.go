@@ -0,0 +1,180 @@
+package kubemq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	qs "github.com/kubemq-io/kubemq-go/queues_stream"
+	pb "github.com/kubemq-io/protobuf/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+type kubemqQueuesMock struct {
+	sent      []*qs.QueueMessage
+	sendError error
+	sendIsErr bool
+	polls     []*qs.PollRequest
+	pollError error
+	pollDelay time.Duration
+}
+
+func (k *kubemqQueuesMock) Send(ctx context.Context, messages ...*qs.QueueMessage) (*qs.SendResult, error) {
+	if k.sendError != nil {
+		return nil, k.sendError
+	}
+	k.sent = append(k.sent, messages...)
+	return &qs.SendResult{Results: []*pb.SendQueueMessageResult{{IsError: k.sendIsErr, Error: "some error"}}}, nil
+}
+
+func (k *kubemqQueuesMock) Poll(ctx context.Context, request *qs.PollRequest) (*qs.PollResponse, error) {
+	k.polls = append(k.polls, request)
+	time.Sleep(k.pollDelay)
+	if k.pollError != nil {
+		return nil, k.pollError
+	}
+	return &qs.PollResponse{}, nil
+}
+
+func (k *kubemqQueuesMock) Close() error {
+	return nil
+}
+
+func newTestKubeMQQueues(meta *metadata, client kubemqQueuesClient) *kubeMQQueues {
+	k := newKubeMQQueues(logger.NewLogger("kubemq-test"))
+	k.ctx, k.ctxCancel = context.WithCancel(context.Background())
+	k.metadata = meta
+	k.client = client
+	k.isInitialized = true
+	return k
+}
+
+func Test_kubeMQQueues_Publish(t *testing.T) {
+	meta := &metadata{
+		isQueue:           true,
+		delaySeconds:      5,
+		expirationSeconds: 600,
+		maxReceiveCount:   3,
+		maxReceiveQueue:   "dead-letter",
+	}
+
+	t.Run("publish with component policy", func(t *testing.T) {
+		client := &kubemqQueuesMock{}
+		k := newTestKubeMQQueues(meta, client)
+		defer k.Close()
+
+		err := k.Publish(&pubsub.PublishRequest{Data: []byte("data"), Topic: "some-topic"})
+		assert.NoError(t, err)
+		assert.Len(t, client.sent, 1)
+		assert.Equal(t, "some-topic", client.sent[0].Channel)
+		assert.Equal(t, int32(5), client.sent[0].Policy.DelaySeconds)
+		assert.Equal(t, int32(600), client.sent[0].Policy.ExpirationSeconds)
+		assert.Equal(t, int32(3), client.sent[0].Policy.MaxReceiveCount)
+		assert.Equal(t, "dead-letter", client.sent[0].Policy.MaxReceiveQueue)
+	})
+
+	t.Run("publish with request policy", func(t *testing.T) {
+		client := &kubemqQueuesMock{}
+		k := newTestKubeMQQueues(meta, client)
+		defer k.Close()
+
+		err := k.Publish(&pubsub.PublishRequest{
+			Data:  []byte("data"),
+			Topic: "some-topic",
+			Metadata: map[string]string{
+				"delaySeconds":    "10",
+				"maxReceiveCount": "bad",
+				"maxReceiveQueue": "other-dead-letter",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(10), client.sent[0].Policy.DelaySeconds)
+		assert.Equal(t, int32(3), client.sent[0].Policy.MaxReceiveCount)
+		assert.Equal(t, "other-dead-letter", client.sent[0].Policy.MaxReceiveQueue)
+	})
+
+	t.Run("publish with send error", func(t *testing.T) {
+		k := newTestKubeMQQueues(meta, &kubemqQueuesMock{sendError: errors.New("some error")})
+		defer k.Close()
+
+		err := k.Publish(&pubsub.PublishRequest{Data: []byte("data"), Topic: "some-topic"})
+		assert.Error(t, err)
+	})
+
+	t.Run("publish with result error", func(t *testing.T) {
+		k := newTestKubeMQQueues(meta, &kubemqQueuesMock{sendIsErr: true})
+		defer k.Close()
+
+		err := k.Publish(&pubsub.PublishRequest{Data: []byte("data"), Topic: "some-topic"})
+		assert.Error(t, err)
+	})
+}
+
+func Test_kubeMQQueues_handleWithVisibility(t *testing.T) {
+	msg := &pubsub.NewMessage{Data: []byte("data"), Topic: "some-topic"}
+
+	t.Run("handler completes within visibility", func(t *testing.T) {
+		k := newTestKubeMQQueues(&metadata{visibilitySeconds: 1}, &kubemqQueuesMock{})
+		err := k.handleWithVisibility(context.Background(), msg, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("handler exceeds visibility", func(t *testing.T) {
+		k := newTestKubeMQQueues(&metadata{visibilitySeconds: 1}, &kubemqQueuesMock{})
+		handlerDone := false
+		err := k.handleWithVisibility(context.Background(), msg, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			<-ctx.Done()
+			time.Sleep(100 * time.Millisecond)
+			handlerDone = true
+			return nil
+		})
+		assert.Error(t, err)
+		// The message is only nacked once the handler returned
+		assert.True(t, handlerDone)
+	})
+
+	t.Run("handler error without visibility", func(t *testing.T) {
+		k := newTestKubeMQQueues(&metadata{}, &kubemqQueuesMock{})
+		err := k.handleWithVisibility(context.Background(), msg, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return errors.New("some error")
+		})
+		assert.Error(t, err)
+	})
+}
+
+func Test_kubeMQQueues_processQueueMessages(t *testing.T) {
+	meta := &metadata{pollTimeoutSeconds: 1}
+
+	t.Run("wait timeout in milliseconds", func(t *testing.T) {
+		client := &kubemqQueuesMock{}
+		k := newTestKubeMQQueues(meta, client)
+		defer k.Close()
+
+		err := k.processQueueMessages(context.Background(), "some-topic", nil)
+		assert.NoError(t, err)
+		assert.Len(t, client.polls, 1)
+		assert.Equal(t, 1000, client.polls[0].WaitTimeout)
+	})
+
+	t.Run("poll without response within the wait timeout", func(t *testing.T) {
+		k := newTestKubeMQQueues(meta, &kubemqQueuesMock{pollError: errors.New("some error"), pollDelay: time.Second})
+		defer k.Close()
+
+		err := k.processQueueMessages(context.Background(), "some-topic", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("poll error", func(t *testing.T) {
+		k := newTestKubeMQQueues(meta, &kubemqQueuesMock{pollError: errors.New("some error")})
+		defer k.Close()
+
+		err := k.processQueueMessages(context.Background(), "some-topic", nil)
+		assert.Error(t, err)
+	})
+}
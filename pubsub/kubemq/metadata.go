@@ -16,6 +16,16 @@ type metadata struct {
 	group             string
 	isStore           bool
 	disableReDelivery bool
+
+	// Queue streams options
+	isQueue            bool
+	visibilitySeconds  int
+	delaySeconds       int
+	expirationSeconds  int
+	maxReceiveCount    int
+	maxReceiveQueue    string
+	pollMaxItems       int
+	pollTimeoutSeconds int
 }
 
 func parseAddress(address string) (string, int, error) {
@@ -76,5 +86,47 @@ func createMetadata(pubSubMetadata pubsub.Metadata) (*metadata, error) {
 			result.disableReDelivery = true
 		}
 	}
+	if val, found := pubSubMetadata.Properties["queue"]; found && val != "" {
+		switch val {
+		case "false":
+			result.isQueue = false
+		case "true":
+			result.isQueue = true
+			result.isStore = false
+		default:
+			return nil, fmt.Errorf("invalid kubeMQ queue value, queue can be true or false")
+		}
+	}
+	var err error
+	for key, target := range map[string]*int{
+		"visibilitySeconds":  &result.visibilitySeconds,
+		"delaySeconds":       &result.delaySeconds,
+		"expirationSeconds":  &result.expirationSeconds,
+		"maxReceiveCount":    &result.maxReceiveCount,
+		"pollMaxItems":       &result.pollMaxItems,
+		"pollTimeoutSeconds": &result.pollTimeoutSeconds,
+	} {
+		if *target, err = parseNonNegativeInt(pubSubMetadata.Properties, key); err != nil {
+			return nil, err
+		}
+	}
+	if val, found := pubSubMetadata.Properties["maxReceiveQueue"]; found && val != "" {
+		result.maxReceiveQueue = val
+	}
 	return result, nil
 }
+
+func parseNonNegativeInt(props map[string]string, key string) (int, error) {
+	val, found := props[key]
+	if !found || val == "" {
+		return 0, nil
+	}
+	intVal, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid kubeMQ %s value, %s", key, err.Error())
+	}
+	if intVal < 0 {
+		return 0, fmt.Errorf("invalid kubeMQ %s value, value must be greater or equal to 0", key)
+	}
+	return intVal, nil
+}
@@ -140,6 +140,64 @@ func Test_createMetadata(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "create valid metadata with queue options",
+			meta: pubsub.Metadata{
+				Base: mdata.Base{
+					Properties: map[string]string{
+						"address":            "localhost:50000",
+						"queue":              "true",
+						"visibilitySeconds":  "30",
+						"delaySeconds":       "5",
+						"expirationSeconds":  "600",
+						"maxReceiveCount":    "3",
+						"maxReceiveQueue":    "dead-letter",
+						"pollMaxItems":       "10",
+						"pollTimeoutSeconds": "20",
+					},
+				},
+			},
+			want: &metadata{
+				host:               "localhost",
+				port:               50000,
+				isQueue:            true,
+				visibilitySeconds:  30,
+				delaySeconds:       5,
+				expirationSeconds:  600,
+				maxReceiveCount:    3,
+				maxReceiveQueue:    "dead-letter",
+				pollMaxItems:       10,
+				pollTimeoutSeconds: 20,
+			},
+			wantErr: false,
+		},
+		{
+			name: "create invalid metadata with bad queue info",
+			meta: pubsub.Metadata{
+				Base: mdata.Base{
+					Properties: map[string]string{
+						"address": "localhost:50000",
+						"queue":   "bad",
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "create invalid metadata with negative visibility",
+			meta: pubsub.Metadata{
+				Base: mdata.Base{
+					Properties: map[string]string{
+						"address":           "localhost:50000",
+						"queue":             "true",
+						"visibilitySeconds": "-1",
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "create invalid metadata with bad store info",
 			meta: pubsub.Metadata{
@@ -30,7 +30,11 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"go.uber.org/ratelimit"
 
+	"github.com/dapr/components-contrib/internal/authentication/spiffe"
+	mqttcerts "github.com/dapr/components-contrib/internal/component/mqtt"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
 )
 
@@ -50,6 +54,9 @@ type mqttPubSub struct {
 	subscribingLock   sync.RWMutex
 	ctx               context.Context
 	cancel            context.CancelFunc
+	secretResolver    secretstores.SecretStoreResolver
+	certReloader      *mqttcerts.CertReloader
+	spiffe            *spiffe.Config
 }
 
 type mqttPubSubSubscription struct {
@@ -73,6 +80,11 @@ func isValidPEM(val string) bool {
 	return block != nil
 }
 
+// SetSecretStoreResolver sets the resolver of the secret store holding the TLS certificates.
+func (m *mqttPubSub) SetSecretStoreResolver(resolver secretstores.SecretStoreResolver) {
+	m.secretResolver = resolver
+}
+
 // Init parses metadata and creates a new Pub Sub client.
 func (m *mqttPubSub) Init(metadata pubsub.Metadata) error {
 	mqttMeta, err := parseMQTTMetaData(metadata, m.logger)
@@ -89,6 +101,26 @@ func (m *mqttPubSub) Init(metadata pubsub.Metadata) error {
 
 	m.ctx, m.cancel = context.WithCancel(context.Background())
 
+	m.certReloader, err = mqttcerts.StartCertReloader(m.ctx, metadata.Properties, m.secretResolver, mqttcerts.Certs{
+		CACert:     m.metadata.caCert,
+		ClientCert: m.metadata.clientCert,
+		ClientKey:  m.metadata.clientKey,
+	}, m.logger)
+	if err != nil {
+		m.cancel()
+		return fmt.Errorf("%s %w", errorMsgPrefix, err)
+	}
+
+	m.spiffe, err = spiffe.Parse(metadata.Properties)
+	if err != nil {
+		m.cancel()
+		return fmt.Errorf("%s %w", errorMsgPrefix, err)
+	}
+	if m.spiffe != nil && m.metadata.clientCert != "" {
+		m.cancel()
+		return fmt.Errorf("%s clientCert and spiffeEndpointSocket can't be set together", errorMsgPrefix)
+	}
+
 	// mqtt broker allows only one connection at a given time from a clientID.
 	producerClientID := m.metadata.producerID
 	if producerClientID == "" {
@@ -143,6 +175,61 @@ func (m *mqttPubSub) Publish(req *pubsub.PublishRequest) error {
 	return nil
 }
 
+// BulkPublish sends all the messages before waiting for their acknowledgements, so that the client keeps several
+// messages in flight instead of waiting for the broker after each message.
+func (m *mqttPubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if req.Topic == "" {
+		err := errors.New("topic name is empty")
+		return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishFailed, err), err
+	}
+
+	m.logger.Debugf("mqtt bulk publishing %d messages to topic %s", len(req.Entries), req.Topic)
+
+	tokens := make([]mqtt.Token, len(req.Entries))
+	for i, entry := range req.Entries {
+		tokens[i] = m.producer.Publish(req.Topic, m.metadata.qos, m.metadata.retain, entry.Event)
+	}
+
+	// All the messages share the same timeout, as they are sent together
+	waitCtx, cancel := context.WithTimeout(ctx, defaultWait)
+	defer cancel()
+	res := pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishSucceeded, nil)
+	failed := 0
+	for i, token := range tokens {
+		if err := m.waitForPublish(waitCtx, token); err != nil {
+			res.Statuses[i].Status = pubsub.PublishFailed
+			res.Statuses[i].Error = err
+			failed++
+		}
+	}
+	if failed > 0 {
+		return res, fmt.Errorf("mqtt failed to publish %d of %d messages", failed, len(req.Entries))
+	}
+
+	return res, nil
+}
+
+// waitForPublish waits until the message of the token is published, or the context is done.
+func (m *mqttPubSub) waitForPublish(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		// Operation completed
+	case <-m.ctx.Done():
+		// Context canceled
+		return m.ctx.Err()
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("mqtt timeout while publishing")
+		}
+		return ctx.Err()
+	}
+	if token.Error() != nil {
+		return fmt.Errorf("mqtt error from publish: %v", token.Error())
+	}
+
+	return nil
+}
+
 // Subscribe to the mqtt pub sub topic.
 func (m *mqttPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	if ctxErr := m.ctx.Err(); ctxErr != nil {
@@ -329,7 +416,10 @@ func (m *mqttPubSub) connect(ctx context.Context, clientID string) (mqtt.Client,
 	if err != nil {
 		return nil, err
 	}
-	opts := m.createClientOptions(uri, clientID)
+	opts, err := m.createClientOptions(uri, clientID)
+	if err != nil {
+		return nil, err
+	}
 	// Turn off auto-ack
 	opts.SetAutoAckDisabled(true)
 	client := mqtt.NewClient(opts)
@@ -377,7 +467,7 @@ func (m *mqttPubSub) newTLSConfig() *tls.Config {
 	return tlsConfig
 }
 
-func (m *mqttPubSub) createClientOptions(uri *url.URL, clientID string) *mqtt.ClientOptions {
+func (m *mqttPubSub) createClientOptions(uri *url.URL, clientID string) (*mqtt.ClientOptions, error) {
 	opts := mqtt.NewClientOptions()
 	opts.SetClientID(clientID)
 	opts.SetCleanSession(m.metadata.cleanSession)
@@ -394,9 +484,22 @@ func (m *mqttPubSub) createClientOptions(uri *url.URL, clientID string) *mqtt.Cl
 	password, _ := uri.User.Password()
 	opts.SetPassword(password)
 	// tls config
-	opts.SetTLSConfig(m.newTLSConfig())
+	var tlsConfig *tls.Config
+	if m.certReloader != nil {
+		tlsConfig = m.certReloader.TLSConfig()
+	} else {
+		tlsConfig = m.newTLSConfig()
+	}
+	if m.spiffe != nil {
+		// The client certificate is the X.509 SVID of the workload
+		err := m.spiffe.ConfigureTLS(tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%s %w", errorMsgPrefix, err)
+		}
+	}
+	opts.SetTLSConfig(tlsConfig)
 
-	return opts
+	return opts, nil
 }
 
 func (m *mqttPubSub) Close() error {
@@ -410,13 +513,37 @@ func (m *mqttPubSub) Close() error {
 	}
 	m.producer.Disconnect(5)
 
-	return nil
+	return m.spiffe.Close()
 }
 
 func (m *mqttPubSub) Features() []pubsub.Feature {
 	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards}
 }
 
+func (m *mqttPubSub) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+	if m.metadata != nil {
+		switch m.metadata.qos {
+		case 0:
+			g.Delivery = pubsub.DeliveryAtMostOnce
+		case 2:
+			g.Delivery = pubsub.DeliveryEffectivelyOnce
+		}
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (m *mqttPubSub) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		Capabilities: pubsub.FeatureCapabilities(m.Features()),
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfilePassword, contribMetadata.AuthProfileMTLS, contribMetadata.AuthProfileSPIFFE},
+	}
+}
+
 var sharedSubscriptionMatch = regexp.MustCompile(`^\$share\/(.*?)\/.`)
 
 // Adds a topic to the list of subscriptions.
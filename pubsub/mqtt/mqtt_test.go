@@ -14,12 +14,15 @@ limitations under the License.
 package mqtt
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 
+	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/stretchr/testify/assert"
 
 	mdata "github.com/dapr/components-contrib/metadata"
@@ -456,3 +459,87 @@ func Test_buildRegexForTopic(t *testing.T) {
 		})
 	}
 }
+
+type fakeToken struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *fakeToken) Wait() bool {
+	<-t.done
+	return true
+}
+
+func (t *fakeToken) WaitTimeout(d time.Duration) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+func (t *fakeToken) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *fakeToken) Error() error {
+	return t.err
+}
+
+// fakeClient completes the publishings of the payloads that aren't "pending", failing the ones that are "error".
+type fakeClient struct {
+	mqtt.Client
+	published []string
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	p := string(payload.([]byte))
+	c.published = append(c.published, p)
+	token := &fakeToken{done: make(chan struct{})}
+	switch p {
+	case "pending":
+		return token
+	case "error":
+		token.err = errors.New("not authorized")
+	}
+	close(token.done)
+	return token
+}
+
+func TestBulkPublish(t *testing.T) {
+	client := &fakeClient{}
+	m := &mqttPubSub{
+		producer: client,
+		metadata: &metadata{qos: 1},
+		logger:   logger.NewLogger("test"),
+		ctx:      context.Background(),
+	}
+
+	res, err := m.BulkPublish(context.Background(), &pubsub.BulkPublishRequest{
+		Topic: "topic",
+		Entries: []pubsub.BulkMessageEntry{
+			{EntryId: "1", Event: []byte("a")},
+			{EntryId: "2", Event: []byte("error")},
+			{EntryId: "3", Event: []byte("b")},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"a", "error", "b"}, client.published)
+	assert.Equal(t, pubsub.PublishSucceeded, res.Statuses[0].Status)
+	assert.Equal(t, pubsub.PublishFailed, res.Statuses[1].Status)
+	assert.ErrorContains(t, res.Statuses[1].Error, "not authorized")
+	assert.Equal(t, pubsub.PublishSucceeded, res.Statuses[2].Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	res, err = m.BulkPublish(ctx, &pubsub.BulkPublishRequest{
+		Topic:   "topic",
+		Entries: []pubsub.BulkMessageEntry{{EntryId: "1", Event: []byte("pending")}},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, pubsub.PublishFailed, res.Statuses[0].Status)
+
+	_, err = m.BulkPublish(context.Background(), &pubsub.BulkPublishRequest{})
+	assert.Error(t, err)
+}
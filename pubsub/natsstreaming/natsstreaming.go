@@ -28,6 +28,7 @@ import (
 	stan "github.com/nats-io/stan.go"
 	"github.com/nats-io/stan.go/pb"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -348,3 +349,18 @@ func (n *natsStreamingPubSub) Close() error {
 func (n *natsStreamingPubSub) Features() []pubsub.Feature {
 	return nil
 }
+
+func (n *natsStreamingPubSub) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (n *natsStreamingPubSub) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone},
+	}
+}
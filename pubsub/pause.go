@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscriptionPauser is implemented by the pubsub components that can pause and resume their subscriptions.
+// A paused subscription stops fetching messages from the broker, rather than only holding back their delivery,
+// so the messages stay in the broker while the application applies backpressure or is under maintenance.
+type SubscriptionPauser interface {
+	// PauseSubscription stops fetching the messages of the subscription to the topic.
+	// Messages already fetched may still be delivered.
+	PauseSubscription(topic string) error
+	// ResumeSubscription resumes fetching the messages of the subscription to the topic.
+	ResumeSubscription(topic string) error
+}
+
+// PauseGate holds the paused topics of a component, for its receive loops to wait while their topics are paused.
+// Topics can be paused before they're subscribed to. A nil PauseGate never pauses.
+type PauseGate struct {
+	lock    sync.Mutex
+	paused  map[string]struct{}
+	resumed chan struct{}
+}
+
+// NewPauseGate returns a PauseGate with no paused topic.
+func NewPauseGate() *PauseGate {
+	return &PauseGate{
+		paused:  map[string]struct{}{},
+		resumed: make(chan struct{}),
+	}
+}
+
+// Pause pauses the topic.
+func (g *PauseGate) Pause(topic string) {
+	g.lock.Lock()
+	g.paused[topic] = struct{}{}
+	g.lock.Unlock()
+}
+
+// Resume resumes the topic, waking up the receive loops waiting for it.
+func (g *PauseGate) Resume(topic string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.paused[topic]; !ok {
+		return
+	}
+	delete(g.paused, topic)
+	close(g.resumed)
+	g.resumed = make(chan struct{})
+}
+
+// IsPaused returns true if the topic is paused.
+func (g *PauseGate) IsPaused(topic string) bool {
+	if g == nil {
+		return false
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	_, ok := g.paused[topic]
+
+	return ok
+}
+
+// Wait blocks while all the topics are paused, or until the context is canceled.
+func (g *PauseGate) Wait(ctx context.Context, topics ...string) error {
+	if g == nil || len(topics) == 0 {
+		return nil
+	}
+
+	for {
+		g.lock.Lock()
+		resumed := g.resumed
+		paused := true
+		for _, topic := range topics {
+			if _, ok := g.paused[topic]; !ok {
+				paused = false
+				break
+			}
+		}
+		g.lock.Unlock()
+		if !paused {
+			return nil
+		}
+
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
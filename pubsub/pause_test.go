@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseGate(t *testing.T) {
+	t.Run("nil gate", func(t *testing.T) {
+		var g *PauseGate
+		assert.False(t, g.IsPaused("a"))
+		assert.NoError(t, g.Wait(context.Background(), "a"))
+	})
+
+	t.Run("waits until resumed", func(t *testing.T) {
+		g := NewPauseGate()
+		g.Pause("a")
+		assert.True(t, g.IsPaused("a"))
+		assert.False(t, g.IsPaused("b"))
+
+		done := make(chan error)
+		go func() {
+			done <- g.Wait(context.Background(), "a")
+		}()
+		select {
+		case <-done:
+			t.Fatal("paused topic didn't wait")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		g.Resume("a")
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("resumed topic is still waiting")
+		}
+		assert.False(t, g.IsPaused("a"))
+	})
+
+	t.Run("waits while all topics are paused", func(t *testing.T) {
+		g := NewPauseGate()
+		g.Pause("a")
+		assert.NoError(t, g.Wait(context.Background(), "a", "b"))
+
+		g.Pause("b")
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, g.Wait(ctx, "a", "b"), context.DeadlineExceeded)
+	})
+}
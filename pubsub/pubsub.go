@@ -27,6 +27,8 @@ type PubSub interface {
 	Publish(req *PublishRequest) error
 	Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error
 	Close() error
+	// GetComponentMetadata returns the metadata of the pubsub, including its ordering and delivery guarantees.
+	GetComponentMetadata() map[string]string
 }
 
 // BulkPublisher is the interface that wraps the BulkPublish method.
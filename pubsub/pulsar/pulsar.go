@@ -24,6 +24,7 @@ import (
 	"github.com/apache/pulsar-client-go/pulsar"
 	lru "github.com/hashicorp/golang-lru"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -339,6 +340,22 @@ func (p *Pulsar) Features() []pubsub.Feature {
 	return nil
 }
 
+func (p *Pulsar) GetComponentMetadata() map[string]string {
+	// Messages with the same key are delivered in order to a single consumer.
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingPartition,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (p *Pulsar) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfileToken},
+	}
+}
+
 // formatTopic formats the topic into pulsar's structure with tenant and namespace.
 func (p *Pulsar) formatTopic(topic string) string {
 	persist := persistentStr
@@ -23,6 +23,7 @@ import (
 
 	"github.com/dapr/kit/logger"
 
+	"github.com/dapr/components-contrib/internal/component/rabbitmq"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 )
@@ -46,8 +47,10 @@ type metadata struct {
 	maxLenBytes      int64
 	exchangeKind     string
 	publisherConfirm bool
+	confirmWindow    int
 	concurrency      pubsub.ConcurrencyMode
 	defaultQueueTTL  *time.Duration
+	connectionConfig rabbitmq.ConnectionConfig
 }
 
 const (
@@ -73,6 +76,7 @@ const (
 	metadataMaxLenBytesKey          = "maxLenBytes"
 	metadataExchangeKindKey         = "exchangeKind"
 	metadataPublisherConfirmKey     = "publisherConfirm"
+	metadataPublisherConfirmWindow  = "publisherConfirmWindow"
 
 	defaultReconnectWaitSeconds = 3
 )
@@ -88,6 +92,7 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*metadat
 		reconnectWait:    time.Duration(defaultReconnectWaitSeconds) * time.Second,
 		exchangeKind:     fanoutExchangeKind,
 		publisherConfirm: false,
+		confirmWindow:    rabbitmq.DefaultConfirmWindow,
 	}
 
 	if val, found := pubSubMetadata.Properties[metadataConnectionStringKey]; found && val != "" {
@@ -194,6 +199,14 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*metadat
 		}
 	}
 
+	if val, found := pubSubMetadata.Properties[metadataPublisherConfirmWindow]; found && val != "" {
+		intVal, err := strconv.Atoi(val)
+		if err != nil || intVal <= 0 {
+			return &result, fmt.Errorf("%s invalid RabbitMQ publisher confirm window %s", errorMessagePrefix, val)
+		}
+		result.confirmWindow = intVal
+	}
+
 	ttl, ok, err := contribMetadata.TryGetTTL(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, fmt.Errorf("%s parse RabbitMQ ttl metadata with error: %s", errorMessagePrefix, err)
@@ -203,6 +216,11 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*metadat
 		result.defaultQueueTTL = &ttl
 	}
 
+	result.connectionConfig, err = rabbitmq.ParseConnectionConfig(pubSubMetadata.Properties)
+	if err != nil {
+		return &result, fmt.Errorf("%s %s", errorMessagePrefix, err)
+	}
+
 	c, err := pubsub.Concurrency(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, err
@@ -20,6 +20,7 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/dapr/components-contrib/internal/component/rabbitmq"
 	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
@@ -73,6 +74,7 @@ func TestCreateMetadata(t *testing.T) {
 		assert.Equal(t, true, m.deleteWhenUnused)
 		assert.Equal(t, false, m.enableDeadLetter)
 		assert.Equal(t, false, m.publisherConfirm)
+		assert.Equal(t, rabbitmq.DefaultConfirmWindow, m.confirmWindow)
 		assert.Equal(t, uint8(0), m.deliveryMode)
 		assert.Equal(t, uint8(0), m.prefetchCount)
 		assert.Equal(t, int64(0), m.maxLen)
@@ -173,6 +175,39 @@ func TestCreateMetadata(t *testing.T) {
 		assert.Equal(t, int64(2000000), m.maxLenBytes)
 	})
 
+	t.Run("publisherConfirmWindow is set", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataPublisherConfirmWindow] = "16"
+
+		// act
+		m, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 16, m.confirmWindow)
+	})
+
+	t.Run("publisherConfirmWindow is invalid", func(t *testing.T) {
+		for _, val := range []string{"0", "-1", "abc"} {
+			fakeProperties := getFakeProperties()
+
+			fakeMetaData := pubsub.Metadata{
+				Base: mdata.Base{Properties: fakeProperties},
+			}
+			fakeMetaData.Properties[metadataPublisherConfirmWindow] = val
+
+			// act
+			_, err := createMetadata(fakeMetaData, log)
+
+			// assert
+			assert.Error(t, err, val)
+		}
+	})
+
 	for _, tt := range booleanFlagTests {
 		t.Run(fmt.Sprintf("autoAck value=%s", tt.in), func(t *testing.T) {
 			fakeProperties := getFakeProperties()
@@ -20,12 +20,15 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 
 	"github.com/dapr/kit/logger"
 
+	"github.com/dapr/components-contrib/internal/component/rabbitmq"
+	"github.com/dapr/components-contrib/internal/redact"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 )
@@ -58,12 +61,14 @@ type rabbitMQ struct {
 	connectionCount   int
 	metadata          *metadata
 	declaredExchanges map[string]bool
+	confirmWindow     *rabbitmq.ConfirmWindow
 	ctx               context.Context
 	cancel            context.CancelFunc
 
 	connectionDial func(uri string) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error)
 
-	logger logger.Logger
+	logger   logger.Logger
+	redactor *redact.Redactor
 }
 
 // interface used to allow unit testing.
@@ -91,15 +96,17 @@ type rabbitMQConnectionBroker interface {
 
 // NewRabbitMQ creates a new RabbitMQ pub/sub.
 func NewRabbitMQ(logger logger.Logger) pubsub.PubSub {
-	return &rabbitMQ{
+	r := &rabbitMQ{
 		declaredExchanges: make(map[string]bool),
 		logger:            logger,
-		connectionDial:    dial,
 	}
+	r.connectionDial = r.dial
+
+	return r
 }
 
-func dial(uri string) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
-	conn, err := amqp.Dial(uri)
+func (r *rabbitMQ) dial(uri string) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
+	conn, err := rabbitmq.Dial(uri, r.metadata.connectionConfig)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -115,6 +122,10 @@ func dial(uri string) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
 
 // Init does metadata parsing and connection creation.
 func (r *rabbitMQ) Init(metadata pubsub.Metadata) error {
+	// The connection string and password are quoted by the errors of the AMQP client
+	r.redactor = redact.New(metadata.Properties, nil)
+	r.logger = r.redactor.Logger(r.logger)
+
 	meta, err := createMetadata(metadata, r.logger)
 	if err != nil {
 		return err
@@ -123,6 +134,9 @@ func (r *rabbitMQ) Init(metadata pubsub.Metadata) error {
 	r.ctx, r.cancel = context.WithCancel(context.Background())
 
 	r.metadata = meta
+	if r.metadata.publisherConfirm {
+		r.confirmWindow = rabbitmq.NewConfirmWindow(r.metadata.confirmWindow)
+	}
 
 	r.reconnect(0)
 	// We do not return error on reconnect because it can cause problems if init() happens
@@ -157,7 +171,7 @@ func (r *rabbitMQ) reconnect(connectionCount int) error {
 	if err != nil {
 		r.reset()
 
-		return err
+		return r.redactor.Error(err)
 	}
 
 	if r.metadata.publisherConfirm {
@@ -177,17 +191,40 @@ func (r *rabbitMQ) reconnect(connectionCount int) error {
 }
 
 func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, error) {
+	if r.confirmWindow != nil {
+		if err := r.confirmWindow.Acquire(r.ctx); err != nil {
+			return nil, r.connectionCount, err
+		}
+		defer r.confirmWindow.Release()
+	}
+
+	channel, connectionCount, confirm, err := r.publish(req)
+	// confirm will be nil if are not requesting publish confirmations
+	if err != nil || confirm == nil {
+		return channel, connectionCount, err
+	}
+
+	// The confirm is awaited without holding the channel lock, so that concurrent publishings don't wait for each other
+	err = rabbitmq.WaitConfirm(confirm)
+	if err != nil {
+		r.logger.Errorf("%s publishing to %s failed: %v", logMessagePrefix, req.Topic, err)
+	}
+
+	return channel, connectionCount, err
+}
+
+func (r *rabbitMQ) publish(req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, *amqp.DeferredConfirmation, error) {
 	r.channelMutex.Lock()
 	defer r.channelMutex.Unlock()
 
 	if r.channel == nil {
-		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
+		return r.channel, r.connectionCount, nil, errors.New(errorChannelNotInitialized)
 	}
 
 	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.exchangeKind); err != nil {
 		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
 
-		return r.channel, r.connectionCount, err
+		return r.channel, r.connectionCount, nil, err
 	}
 	routingKey := ""
 	if val, ok := req.Metadata[reqMetadataRoutingKey]; ok && val != "" {
@@ -215,20 +252,10 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 	if err != nil {
 		r.logger.Errorf("%s publishing to %s failed in channel.Publish: %v", logMessagePrefix, req.Topic, err)
 
-		return r.channel, r.connectionCount, err
-	}
-
-	// confirm will be nil if are not requesting publish confirmations
-	if confirm != nil {
-		// Blocks until the server confirms
-		ok := confirm.Wait()
-		if !ok {
-			err = fmt.Errorf("did not receive confirmation of publishing")
-			r.logger.Errorf("%s publishing to %s failed: %v", logMessagePrefix, req.Topic, err)
-		}
+		return r.channel, r.connectionCount, nil, err
 	}
 
-	return r.channel, r.connectionCount, nil
+	return r.channel, r.connectionCount, confirm, nil
 }
 
 func (r *rabbitMQ) Publish(req *pubsub.PublishRequest) error {
@@ -256,6 +283,91 @@ func (r *rabbitMQ) Publish(req *pubsub.PublishRequest) error {
 	}
 }
 
+// BulkPublish publishes the messages one after the other without waiting for their publisher confirms,
+// which are awaited concurrently, so that the messages share the round trips to the broker.
+// Messages are not retried: the statuses of the response report the messages that failed.
+func (r *rabbitMQ) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	r.logger.Debugf("%s bulk publishing %d messages to %s", logMessagePrefix, len(req.Entries), req.Topic)
+
+	res := pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishSucceeded, nil)
+	var (
+		wg       sync.WaitGroup
+		failures atomic.Int32
+	)
+	fail := func(i int, err error) {
+		res.Statuses[i].Status = pubsub.PublishFailed
+		res.Statuses[i].Error = err
+		failures.Add(1)
+	}
+	release := func() {
+		if r.confirmWindow != nil {
+			r.confirmWindow.Release()
+		}
+	}
+
+	for i, entry := range req.Entries {
+		if r.confirmWindow != nil {
+			if err := r.confirmWindow.Acquire(ctx); err != nil {
+				fail(i, err)
+				continue
+			}
+		}
+
+		channel, connectionCount, confirm, err := r.publish(&pubsub.PublishRequest{
+			Data:     entry.Event,
+			Topic:    req.Topic,
+			Metadata: mergeMetadata(req.Metadata, entry.Metadata),
+		})
+		if err != nil {
+			release()
+			fail(i, err)
+			if mustReconnect(channel, err) {
+				r.logger.Warnf("%s publisher is reconnecting in %s ...", logMessagePrefix, r.metadata.reconnectWait.String())
+				time.Sleep(r.metadata.reconnectWait)
+				r.reconnect(connectionCount)
+			}
+			continue
+		}
+		// confirm will be nil if are not requesting publish confirmations
+		if confirm == nil {
+			release()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, confirm *amqp.DeferredConfirmation) {
+			defer wg.Done()
+			defer release()
+			if err := rabbitmq.WaitConfirm(confirm); err != nil {
+				fail(i, err)
+			}
+		}(i, confirm)
+	}
+	wg.Wait()
+
+	if n := failures.Load(); n > 0 {
+		err := fmt.Errorf("%s failed to publish %d of %d messages to %s", logMessagePrefix, n, len(req.Entries), req.Topic)
+		r.logger.Error(err)
+		return res, err
+	}
+	return res, nil
+}
+
+// mergeMetadata returns the metadata of the request, overridden by the metadata of the message.
+func mergeMetadata(reqMetadata map[string]string, entryMetadata map[string]string) map[string]string {
+	if len(entryMetadata) == 0 {
+		return reqMetadata
+	}
+	merged := make(map[string]string, len(reqMetadata)+len(entryMetadata))
+	for k, v := range reqMetadata {
+		merged[k] = v
+	}
+	for k, v := range entryMetadata {
+		merged[k] = v
+	}
+	return merged
+}
+
 func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	if r.metadata.consumerID == "" {
 		return errors.New("consumerID is required for subscriptions")
@@ -565,6 +677,26 @@ func (r *rabbitMQ) Features() []pubsub.Feature {
 	return []pubsub.Feature{pubsub.FeatureMessageTTL}
 }
 
+func (r *rabbitMQ) GetComponentMetadata() map[string]string {
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+	// Auto-acknowledged messages are not redelivered if processing fails.
+	if r.metadata != nil && r.metadata.autoAck {
+		g.Delivery = pubsub.DeliveryAtMostOnce
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (r *rabbitMQ) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		Capabilities: pubsub.FeatureCapabilities(r.Features()),
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfilePassword, contribMetadata.AuthProfileMTLS},
+	}
+}
+
 func mustReconnect(channel rabbitMQChannelBroker, err error) bool {
 	if channel == nil {
 		return true
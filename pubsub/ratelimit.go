@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// PublishRateLimitKey is the key of the maximum number of messages published per second to each topic, in the component metadata.
+	PublishRateLimitKey = "publishRateLimit"
+	// PublishBurstKey is the key of the number of messages that can be published to a topic at once, in the component metadata.
+	// It defaults to the rate limit, rounded up.
+	PublishBurstKey = "publishBurst"
+)
+
+// PublishRateLimiter limits the rate of the messages published to each topic, with a token bucket per topic,
+// so that bursts of messages don't exceed the quotas of the broker.
+// A nil PublishRateLimiter doesn't limit publishing.
+type PublishRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	lock     sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewPublishRateLimiter returns the rate limiter configured in the component metadata,
+// or nil if the rate of messages isn't limited.
+func NewPublishRateLimiter(metadata map[string]string) (*PublishRateLimiter, error) {
+	val, ok := metadata[PublishRateLimitKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+	limit, err := strconv.ParseFloat(val, 64)
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("invalid %s %s: must be a non-negative number of messages per second", PublishRateLimitKey, val)
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+
+	burst := int(math.Ceil(limit))
+	if val, ok := metadata[PublishBurstKey]; ok && val != "" {
+		burst, err = strconv.Atoi(val)
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid %s %s: must be 1 or greater", PublishBurstKey, val)
+		}
+	}
+
+	return &PublishRateLimiter{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		limiters: map[string]*rate.Limiter{},
+	}, nil
+}
+
+// Wait blocks until a message can be published to the topic, or the context is done.
+func (l *PublishRateLimiter) Wait(ctx context.Context, topic string) error {
+	return l.WaitN(ctx, topic, 1)
+}
+
+// WaitN blocks until n messages can be published to the topic, or the context is done.
+// Batches larger than the burst wait for the messages in excess too.
+func (l *PublishRateLimiter) WaitN(ctx context.Context, topic string, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	limiter := l.topicLimiter(topic)
+	for n > 0 {
+		tokens := n
+		if tokens > l.burst {
+			tokens = l.burst
+		}
+		if err := limiter.WaitN(ctx, tokens); err != nil {
+			return fmt.Errorf("publish rate limit of topic %s: %w", topic, err)
+		}
+		n -= tokens
+	}
+
+	return nil
+}
+
+func (l *PublishRateLimiter) topicLimiter(topic string) *rate.Limiter {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	limiter, ok := l.limiters[topic]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[topic] = limiter
+	}
+
+	return limiter
+}
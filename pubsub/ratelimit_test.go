@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNewPublishRateLimiter(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		l, err := NewPublishRateLimiter(map[string]string{})
+		require.NoError(t, err)
+		assert.Nil(t, l)
+
+		l, err = NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "0"})
+		require.NoError(t, err)
+		assert.Nil(t, l)
+	})
+
+	t.Run("default burst", func(t *testing.T) {
+		l, err := NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "2.5"})
+		require.NoError(t, err)
+		assert.Equal(t, rate.Limit(2.5), l.limit)
+		assert.Equal(t, 3, l.burst)
+	})
+
+	t.Run("burst", func(t *testing.T) {
+		l, err := NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "100", PublishBurstKey: "10"})
+		require.NoError(t, err)
+		assert.Equal(t, 10, l.burst)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "-1"})
+		assert.Error(t, err)
+		_, err = NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "fast"})
+		assert.Error(t, err)
+		_, err = NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "1", PublishBurstKey: "0"})
+		assert.Error(t, err)
+	})
+}
+
+func TestPublishRateLimiterWait(t *testing.T) {
+	t.Run("nil limiter", func(t *testing.T) {
+		var l *PublishRateLimiter
+		assert.NoError(t, l.WaitN(context.Background(), "a", 1000))
+	})
+
+	t.Run("limits each topic", func(t *testing.T) {
+		l, err := NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "1", PublishBurstKey: "2"})
+		require.NoError(t, err)
+
+		require.NoError(t, l.WaitN(context.Background(), "a", 2))
+		// The bucket of the other topic is full
+		require.NoError(t, l.WaitN(context.Background(), "b", 2))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		assert.Error(t, l.Wait(ctx, "a"))
+	})
+
+	t.Run("batch larger than the burst", func(t *testing.T) {
+		l, err := NewPublishRateLimiter(map[string]string{PublishRateLimitKey: "100", PublishBurstKey: "5"})
+		require.NoError(t, err)
+
+		start := time.Now()
+		require.NoError(t, l.WaitN(context.Background(), "a", 10))
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// groupLag is the backlog of a consumer group, from `XINFO GROUPS`.
+type groupLag struct {
+	// Number of entries delivered to consumers and not acknowledged yet
+	pending int64
+	// Number of entries not delivered to the group yet, or -1 if Redis can't tell.
+	// Redis reports it from version 7.0, and not after entries were deleted from the stream out of order.
+	lag int64
+}
+
+// reportLagLoop periodically logs the lag of the consumer group on the stream
+// based on the `lagReportInterval` setting.
+func (r *redisStreams) reportLagLoop(ctx context.Context, stream string) {
+	if r.metadata.lagReportInterval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.metadata.lagReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			lag, err := r.groupLag(ctx, stream)
+			if err != nil {
+				if ctx.Err() == nil {
+					r.logger.Errorf("redis streams: error retrieving the lag of stream %s: %s", stream, err)
+				}
+				continue
+			}
+			if lag.lag < 0 {
+				r.logger.Infof("redis streams: consumer group %s on stream %s has %d pending messages, lag unknown", r.metadata.consumerID, stream, lag.pending)
+			} else {
+				r.logger.Infof("redis streams: consumer group %s on stream %s has %d pending messages and a lag of %d messages", r.metadata.consumerID, stream, lag.pending, lag.lag)
+			}
+		}
+	}
+}
+
+// groupLag returns the lag of the consumer group on the stream.
+// The command is sent without a typed client method, as the lag isn't parsed by go-redis v8.
+func (r *redisStreams) groupLag(ctx context.Context, stream string) (groupLag, error) {
+	reply, err := r.client.Do(ctx, "XINFO", "GROUPS", stream).Result()
+	if err != nil {
+		return groupLag{}, err
+	}
+
+	return parseGroupLag(reply, r.metadata.consumerID)
+}
+
+// parseGroupLag finds the group in the reply of `XINFO GROUPS`, a list of field-value lists.
+func parseGroupLag(reply interface{}, group string) (groupLag, error) {
+	groups, ok := reply.([]interface{})
+	if !ok {
+		return groupLag{}, fmt.Errorf("unexpected reply type %T", reply)
+	}
+
+	for _, g := range groups {
+		fields, ok := g.([]interface{})
+		if !ok || len(fields)%2 != 0 {
+			return groupLag{}, fmt.Errorf("unexpected group type %T", g)
+		}
+
+		values := make(map[string]interface{}, len(fields)/2)
+		for i := 0; i < len(fields); i += 2 {
+			name, _ := fields[i].(string)
+			values[name] = fields[i+1]
+		}
+		if values["name"] != group {
+			continue
+		}
+
+		res := groupLag{lag: -1}
+		res.pending, _ = values["pending"].(int64)
+		if lag, ok := values["lag"].(int64); ok {
+			res.lag = lag
+		}
+
+		return res, nil
+	}
+
+	return groupLag{}, fmt.Errorf("consumer group %s not found", group)
+}
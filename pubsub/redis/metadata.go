@@ -14,6 +14,7 @@ limitations under the License.
 package redis
 
 import (
+	"strconv"
 	"time"
 )
 
@@ -31,4 +32,25 @@ type metadata struct {
 
 	// the max len of stream
 	maxLenApprox int64
+	// the exact max len of stream
+	maxLen int64
+	// the age of the entries trimmed from the stream, by ID
+	minIDRetention time.Duration
+	// the interval between reports of the lag of the consumer group (0 disables reporting)
+	lagReportInterval time.Duration
+}
+
+// trimArgs returns the arguments of XADD trimming the stream, if configured.
+// Trimming by age uses the time in the IDs generated by Redis, so it is approximate like maxLenApprox.
+func (m metadata) trimArgs(now time.Time) []interface{} {
+	switch {
+	case m.maxLen > 0:
+		return []interface{}{"MAXLEN", m.maxLen}
+	case m.maxLenApprox > 0:
+		return []interface{}{"MAXLEN", "~", m.maxLenApprox}
+	case m.minIDRetention > 0:
+		return []interface{}{"MINID", "~", strconv.FormatInt(now.Add(-m.minIDRetention).UnixMilli(), 10)}
+	default:
+		return nil
+	}
 }
@@ -23,6 +23,8 @@ import (
 	"github.com/go-redis/redis/v8"
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/internal/redact"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -35,6 +37,9 @@ const (
 	queueDepth        = "queueDepth"
 	concurrency       = "concurrency"
 	maxLenApprox      = "maxLenApprox"
+	maxLen            = "maxLen"
+	minIDRetention    = "minIDRetention"
+	lagReportInterval = "lagReportInterval"
 )
 
 // redisStreams handles consuming from a Redis stream using
@@ -47,6 +52,8 @@ type redisStreams struct {
 	metadata       metadata
 	client         redis.UniversalClient
 	clientSettings *rediscomponent.Settings
+	doer           rediscomponent.Doer
+	schemas        *pubsub.SchemaValidator
 	logger         logger.Logger
 
 	queue chan redisMessageWrapper
@@ -128,19 +135,65 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		m.maxLenApprox = maxLenApprox
 	}
 
+	if val, ok := meta.Properties[maxLen]; ok && val != "" {
+		maxLen, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return m, fmt.Errorf("redis streams error: invalid maxLen %s, %s", val, err)
+		}
+		m.maxLen = maxLen
+	}
+
+	if val, ok := meta.Properties[minIDRetention]; ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return m, fmt.Errorf("redis streams error: invalid minIDRetention %s, %s", val, err)
+		}
+		m.minIDRetention = d
+	}
+
+	trimOptions := 0
+	for _, set := range []bool{m.maxLen > 0, m.maxLenApprox > 0, m.minIDRetention > 0} {
+		if set {
+			trimOptions++
+		}
+	}
+	if trimOptions > 1 {
+		return m, fmt.Errorf("redis streams error: only one of %s, %s and %s can be set", maxLen, maxLenApprox, minIDRetention)
+	}
+
+	if val, ok := meta.Properties[lagReportInterval]; ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return m, fmt.Errorf("redis streams error: can't parse lagReportInterval field: %s", err)
+		}
+		m.lagReportInterval = d
+	}
+
 	return m, nil
 }
 
-func (r *redisStreams) Init(metadata pubsub.Metadata) error {
+func (r *redisStreams) Init(metadata pubsub.Metadata) (err error) {
+	// The password may be quoted by the errors of the Redis client
+	redactor := redact.New(metadata.Properties, nil)
+	r.logger = redactor.Logger(r.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	m, err := parseRedisMetadata(metadata)
 	if err != nil {
 		return err
 	}
 	r.metadata = m
+	r.schemas, err = pubsub.NewSchemaValidator(metadata.Properties)
+	if err != nil {
+		return fmt.Errorf("redis streams: %w", err)
+	}
 	r.client, r.clientSettings, err = rediscomponent.ParseClientFromProperties(metadata.Properties, nil)
 	if err != nil {
 		return err
 	}
+	r.doer = rediscomponent.NewDoer(r.client, r.clientSettings)
 
 	r.ctx, r.cancel = context.WithCancel(context.Background())
 
@@ -157,18 +210,92 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 }
 
 func (r *redisStreams) Publish(req *pubsub.PublishRequest) error {
-	_, err := r.client.XAdd(r.ctx, &redis.XAddArgs{
-		Stream:       req.Topic,
-		MaxLenApprox: r.metadata.maxLenApprox,
-		Values:       map[string]interface{}{"data": req.Data},
-	}).Result()
-	if err != nil {
+	if err := r.schemas.Validate(req); err != nil {
+		return err
+	}
+
+	args := []interface{}{"XADD", req.Topic}
+	args = append(args, r.metadata.trimArgs(time.Now())...)
+	args = append(args, "*", "data", req.Data)
+
+	// The doer is the client itself if the pipeline batching is disabled
+	if err := r.doer.Do(r.ctx, args...).Err(); err != nil {
 		return fmt.Errorf("redis streams: error from publish: %s", err)
 	}
 
 	return nil
 }
 
+// BulkPublish adds the messages to the stream with XADD commands sent in a single pipeline.
+func (r *redisStreams) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if len(req.Entries) == 0 {
+		return pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishSucceeded, nil), nil
+	}
+
+	res := pubsub.NewBulkPublishResponse(req.Entries, pubsub.PublishSucceeded, nil)
+	failed := 0
+
+	trimArgs := r.metadata.trimArgs(time.Now())
+	pipe := r.client.Pipeline()
+	// Commands of the messages, or nil for the messages that failed the schema validation
+	cmds := make([]*redis.Cmd, len(req.Entries))
+	for i, entry := range req.Entries {
+		err := r.schemas.Validate(&pubsub.PublishRequest{
+			Data:        entry.Event,
+			PubsubName:  req.PubsubName,
+			Topic:       req.Topic,
+			Metadata:    mergeMetadata(req.Metadata, entry.Metadata),
+			ContentType: &entry.ContentType,
+		})
+		if err != nil {
+			res.Statuses[i].Status = pubsub.PublishFailed
+			res.Statuses[i].Error = err
+			failed++
+			continue
+		}
+
+		args := []interface{}{"XADD", req.Topic}
+		args = append(args, trimArgs...)
+		args = append(args, "*", "data", entry.Event)
+		cmds[i] = pipe.Do(ctx, args...)
+	}
+	if pipe.Len() > 0 {
+		// The error of Exec is the error of the first failed command, the errors of the messages are in their commands
+		_, _ = pipe.Exec(ctx)
+	}
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if err := cmd.Err(); err != nil {
+			res.Statuses[i].Status = pubsub.PublishFailed
+			res.Statuses[i].Error = fmt.Errorf("redis streams: error from publish: %s", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return res, fmt.Errorf("redis streams: failed to publish %d of %d messages", failed, len(req.Entries))
+	}
+
+	return res, nil
+}
+
+// mergeMetadata returns the metadata of the request overridden by the metadata of an entry.
+func mergeMetadata(reqMetadata map[string]string, entryMetadata map[string]string) map[string]string {
+	if len(entryMetadata) == 0 {
+		return reqMetadata
+	}
+	merged := make(map[string]string, len(reqMetadata)+len(entryMetadata))
+	for k, v := range reqMetadata {
+		merged[k] = v
+	}
+	for k, v := range entryMetadata {
+		merged[k] = v
+	}
+	return merged
+}
+
 func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	err := r.client.XGroupCreateMkStream(ctx, req.Topic, r.metadata.consumerID, "0").Err()
 	// Ignore BUSYGROUP errors
@@ -179,6 +306,7 @@ func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeReques
 
 	go r.pollNewMessagesLoop(ctx, req.Topic, handler)
 	go r.reclaimPendingMessagesLoop(ctx, req.Topic, handler)
+	go r.reportLagLoop(ctx, req.Topic)
 
 	return nil
 }
@@ -425,6 +553,10 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Co
 func (r *redisStreams) Close() error {
 	r.cancel()
 
+	if batcher, ok := r.doer.(*rediscomponent.PipelineBatcher); ok {
+		batcher.Close()
+	}
+
 	return r.client.Close()
 }
 
@@ -432,6 +564,22 @@ func (r *redisStreams) Features() []pubsub.Feature {
 	return nil
 }
 
+func (r *redisStreams) GetComponentMetadata() map[string]string {
+	// Messages are processed concurrently.
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (r *redisStreams) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone, contribMetadata.AuthProfilePassword, contribMetadata.AuthProfileSPIFFE},
+	}
+}
+
 func (r *redisStreams) Ping() error {
 	if _, err := r.client.Ping(context.Background()).Result(); err != nil {
 		return fmt.Errorf("redis pubsub: error connecting to redis at %s: %s", r.clientSettings.Host, err)
@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
@@ -125,3 +128,155 @@ func generateRedisStreamTestData(topicCount, messageCount int, data string) []re
 
 	return xmessageArray
 }
+
+func TestTrimArgs(t *testing.T) {
+	now := time.UnixMilli(1670000000000)
+
+	t.Run("no trimming", func(t *testing.T) {
+		assert.Nil(t, metadata{}.trimArgs(now))
+	})
+
+	t.Run("maxLen", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"MAXLEN", int64(10)}, metadata{maxLen: 10}.trimArgs(now))
+	})
+
+	t.Run("maxLenApprox", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"MAXLEN", "~", int64(10)}, metadata{maxLenApprox: 10}.trimArgs(now))
+	})
+
+	t.Run("minIDRetention", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"MINID", "~", "1669999940000"}, metadata{minIDRetention: time.Minute}.trimArgs(now))
+	})
+}
+
+func TestParseTrimmingMetadata(t *testing.T) {
+	t.Run("minIDRetention", func(t *testing.T) {
+		m, err := parseRedisMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			consumerID: "fakeConsumer", minIDRetention: "1h", lagReportInterval: "30s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, m.minIDRetention)
+		assert.Equal(t, 30*time.Second, m.lagReportInterval)
+	})
+
+	t.Run("multiple trimming options", func(t *testing.T) {
+		_, err := parseRedisMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			consumerID: "fakeConsumer", maxLen: "100", minIDRetention: "1h",
+		}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseGroupLag(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{"name", "other", "consumers", int64(1), "pending", int64(0), "last-delivered-id", "0-0", "entries-read", nil, "lag", int64(5)},
+		[]interface{}{"name", "fakeConsumer", "consumers", int64(2), "pending", int64(3), "last-delivered-id", "1-0", "entries-read", int64(1), "lag", int64(7)},
+	}
+
+	lag, err := parseGroupLag(reply, "fakeConsumer")
+	require.NoError(t, err)
+	assert.Equal(t, groupLag{pending: 3, lag: 7}, lag)
+
+	t.Run("lag unknown", func(t *testing.T) {
+		lag, err := parseGroupLag([]interface{}{
+			[]interface{}{"name", "fakeConsumer", "consumers", int64(1), "pending", int64(2), "last-delivered-id", "1-0"},
+		}, "fakeConsumer")
+		require.NoError(t, err)
+		assert.Equal(t, groupLag{pending: 2, lag: -1}, lag)
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		_, err := parseGroupLag(reply, "missing")
+		assert.Error(t, err)
+	})
+}
+
+func TestPublishTrimming(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	r := NewRedisStreams(logger.NewLogger("test"))
+	err := r.Init(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"redisHost": s.Addr(), consumerID: "fakeConsumer", maxLen: "2",
+	}}})
+	require.NoError(t, err)
+	defer r.Close()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.Publish(&pubsub.PublishRequest{Topic: "topic", Data: []byte(fmt.Sprintf("message %d", i))}))
+	}
+
+	entries, err := s.Stream("topic")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"data", "message 4"}, entries[1].Values)
+}
+
+func TestBulkPublish(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	r := NewRedisStreams(logger.NewLogger("test"))
+	err := r.Init(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"redisHost": s.Addr(), consumerID: "fakeConsumer", maxLen: "3",
+	}}})
+	require.NoError(t, err)
+	defer r.Close()
+
+	entries := make([]pubsub.BulkMessageEntry, 5)
+	for i := range entries {
+		entries[i] = pubsub.BulkMessageEntry{EntryId: fmt.Sprint(i), Event: []byte(fmt.Sprintf("message %d", i))}
+	}
+	res, err := r.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{Topic: "topic", Entries: entries})
+	require.NoError(t, err)
+	require.Len(t, res.Statuses, 5)
+	for i, st := range res.Statuses {
+		assert.Equal(t, fmt.Sprint(i), st.EntryId)
+		assert.Equal(t, pubsub.PublishSucceeded, st.Status)
+	}
+
+	streamEntries, err := s.Stream("topic")
+	require.NoError(t, err)
+	require.Len(t, streamEntries, 3)
+	assert.Equal(t, []string{"data", "message 4"}, streamEntries[2].Values)
+
+	// The key isn't a stream
+	s.Set("string", "value")
+	res, err = r.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{Topic: "string", Entries: entries[:2]})
+	assert.Error(t, err)
+	assert.Equal(t, pubsub.PublishFailed, res.Statuses[0].Status)
+	assert.Error(t, res.Statuses[1].Error)
+}
+
+func TestBulkPublishSchemaValidation(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	r := NewRedisStreams(logger.NewLogger("test"))
+	err := r.Init(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"redisHost": s.Addr(), consumerID: "fakeConsumer",
+		pubsub.PublishSchemaTopicKeyPrefix + "orders": `{"type":"object","required":["id"]}`,
+	}}})
+	require.NoError(t, err)
+	defer r.Close()
+
+	entries := []pubsub.BulkMessageEntry{
+		{EntryId: "0", Event: []byte(`{"id":"1"}`)},
+		{EntryId: "1", Event: []byte(`{"qty":1}`)},
+		{EntryId: "2", Event: []byte(`{"id":"2"}`)},
+	}
+	res, err := r.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{Topic: "orders", Entries: entries})
+	assert.Error(t, err)
+	require.Len(t, res.Statuses, 3)
+	assert.Equal(t, pubsub.PublishSucceeded, res.Statuses[0].Status)
+	assert.Equal(t, pubsub.PublishFailed, res.Statuses[1].Status)
+	assert.ErrorIs(t, res.Statuses[1].Error, pubsub.ErrSchemaValidation)
+	assert.Equal(t, pubsub.PublishSucceeded, res.Statuses[2].Status)
+
+	streamEntries, err := s.Stream("orders")
+	require.NoError(t, err)
+	require.Len(t, streamEntries, 2)
+	assert.Equal(t, []string{"data", `{"id":"2"}`}, streamEntries[1].Values)
+
+	// No message is sent when none matches the schema
+	res, err = r.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{Topic: "orders", Entries: entries[1:2]})
+	assert.Error(t, err)
+	assert.Equal(t, pubsub.PublishFailed, res.Statuses[0].Status)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	// PublishSchemaKey is the metadata key of the JSON schema that the payloads published to any topic must match.
+	// The value is either an inline JSON schema or the path of a file containing it.
+	PublishSchemaKey = "publishSchema"
+	// PublishSchemaTopicKeyPrefix is the prefix of the metadata keys of the JSON schemas for single topics,
+	// for example "publishSchema.orders". A topic schema takes precedence over the schema in PublishSchemaKey.
+	PublishSchemaTopicKeyPrefix = PublishSchemaKey + "."
+)
+
+// ErrSchemaValidation is returned, wrapped, when a payload doesn't match the schema of its topic.
+var ErrSchemaValidation = errors.New("payload does not match the schema")
+
+// SchemaValidator validates the payloads of publish requests against the JSON schemas configured in the component metadata.
+// A nil SchemaValidator accepts every payload.
+type SchemaValidator struct {
+	defaultSchema *gojsonschema.Schema
+	topicSchemas  map[string]*gojsonschema.Schema
+}
+
+// NewSchemaValidator returns a SchemaValidator for the schemas in the component metadata.
+// It returns nil if no schema is configured.
+func NewSchemaValidator(metadata map[string]string) (*SchemaValidator, error) {
+	v := &SchemaValidator{
+		topicSchemas: map[string]*gojsonschema.Schema{},
+	}
+	for k, val := range metadata {
+		if val == "" {
+			continue
+		}
+
+		var topic string
+		switch {
+		case k == PublishSchemaKey:
+		case strings.HasPrefix(k, PublishSchemaTopicKeyPrefix):
+			topic = strings.TrimPrefix(k, PublishSchemaTopicKeyPrefix)
+			if topic == "" {
+				return nil, fmt.Errorf("missing topic name in metadata key %s", k)
+			}
+		default:
+			continue
+		}
+
+		schema, err := loadSchema(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema in metadata key %s: %w", k, err)
+		}
+		if topic == "" {
+			v.defaultSchema = schema
+		} else {
+			v.topicSchemas[topic] = schema
+		}
+	}
+
+	if v.defaultSchema == nil && len(v.topicSchemas) == 0 {
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+func loadSchema(val string) (*gojsonschema.Schema, error) {
+	src := []byte(strings.TrimSpace(val))
+	if len(src) > 0 && src[0] != '{' {
+		var err error
+		src, err = os.ReadFile(val)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return gojsonschema.NewSchema(gojsonschema.NewBytesLoader(src))
+}
+
+// Validate returns an error wrapping ErrSchemaValidation if the payload of the request doesn't match the schema of its topic.
+// When the payload is a CloudEvent envelope, its data is validated rather than the envelope.
+func (v *SchemaValidator) Validate(req *PublishRequest) error {
+	if v == nil {
+		return nil
+	}
+
+	schema, ok := v.topicSchemas[req.Topic]
+	if !ok {
+		schema = v.defaultSchema
+	}
+	if schema == nil {
+		return nil
+	}
+
+	payload, err := schemaPayload(req)
+	if err != nil {
+		return fmt.Errorf("%w for topic %s: %v", ErrSchemaValidation, req.Topic, err)
+	}
+
+	res, err := schema.Validate(gojsonschema.NewBytesLoader(payload))
+	if err != nil {
+		return fmt.Errorf("%w for topic %s: %v", ErrSchemaValidation, req.Topic, err)
+	}
+	if !res.Valid() {
+		errs := make([]string, len(res.Errors()))
+		for i, e := range res.Errors() {
+			errs[i] = e.String()
+		}
+
+		return fmt.Errorf("%w for topic %s: %s", ErrSchemaValidation, req.Topic, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// schemaPayload returns the JSON document to validate for the request.
+func schemaPayload(req *PublishRequest) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(req.Data, &doc); err != nil {
+		if !json.Valid(req.Data) {
+			return nil, errors.New("payload is not valid JSON")
+		}
+
+		// Valid JSON that isn't an object, so it can't be an envelope.
+		return req.Data, nil
+	}
+
+	rawPayload, _ := contribMetadata.IsRawPayload(req.Metadata)
+	if _, isEnvelope := doc[SpecVersionField]; rawPayload || !isEnvelope {
+		return req.Data, nil
+	}
+
+	if data, ok := doc[DataField]; ok {
+		return data, nil
+	}
+	if encoded, ok := doc[DataBase64Field]; ok {
+		var s string
+		if err := json.Unmarshal(encoded, &s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", DataBase64Field, err)
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", DataBase64Field, err)
+		}
+		if !json.Valid(data) {
+			return nil, errors.New("event data is not valid JSON")
+		}
+
+		return data, nil
+	}
+
+	return []byte("null"), nil
+}
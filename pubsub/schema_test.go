@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"properties": {"id": {"type": "string"}, "qty": {"type": "integer", "minimum": 1}},
+	"required": ["id", "qty"]
+}`
+
+func TestNewSchemaValidator(t *testing.T) {
+	t.Run("no schemas", func(t *testing.T) {
+		v, err := NewSchemaValidator(map[string]string{"foo": "bar", PublishSchemaKey: ""})
+		require.NoError(t, err)
+		assert.Nil(t, v)
+		assert.NoError(t, v.Validate(&PublishRequest{Topic: "a", Data: []byte("not json")}))
+	})
+
+	t.Run("schema from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "schema.json")
+		require.NoError(t, os.WriteFile(path, []byte(orderSchema), 0o600))
+
+		v, err := NewSchemaValidator(map[string]string{PublishSchemaKey: path})
+		require.NoError(t, err)
+		assert.NoError(t, v.Validate(&PublishRequest{Topic: "a", Data: []byte(`{"id":"1","qty":2}`)}))
+		assert.ErrorIs(t, v.Validate(&PublishRequest{Topic: "a", Data: []byte(`{"id":"1"}`)}), ErrSchemaValidation)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewSchemaValidator(map[string]string{PublishSchemaKey: filepath.Join(t.TempDir(), "nope.json")})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := NewSchemaValidator(map[string]string{PublishSchemaTopicKeyPrefix + "orders": `{"type": 1}`})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing topic", func(t *testing.T) {
+		_, err := NewSchemaValidator(map[string]string{PublishSchemaTopicKeyPrefix: orderSchema})
+		assert.Error(t, err)
+	})
+}
+
+func TestSchemaValidatorValidate(t *testing.T) {
+	v, err := NewSchemaValidator(map[string]string{
+		PublishSchemaTopicKeyPrefix + "orders": orderSchema,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		req      *PublishRequest
+		expValid bool
+	}{
+		{
+			name:     "valid payload",
+			req:      &PublishRequest{Topic: "orders", Data: []byte(`{"id":"1","qty":2}`)},
+			expValid: true,
+		},
+		{
+			name: "invalid payload",
+			req:  &PublishRequest{Topic: "orders", Data: []byte(`{"id":"1","qty":0}`)},
+		},
+		{
+			name: "not json",
+			req:  &PublishRequest{Topic: "orders", Data: []byte(`hello`)},
+		},
+		{
+			name:     "topic without schema",
+			req:      &PublishRequest{Topic: "other", Data: []byte(`hello`)},
+			expValid: true,
+		},
+		{
+			name:     "valid envelope",
+			req:      &PublishRequest{Topic: "orders", Data: []byte(`{"specversion":"1.0","id":"a","data":{"id":"1","qty":2}}`)},
+			expValid: true,
+		},
+		{
+			name: "invalid envelope",
+			req:  &PublishRequest{Topic: "orders", Data: []byte(`{"specversion":"1.0","id":"a","data":{"qty":2}}`)},
+		},
+		{
+			name:     "valid base64 envelope",
+			req:      &PublishRequest{Topic: "orders", Data: []byte(`{"specversion":"1.0","id":"a","data_base64":"eyJpZCI6IjEiLCJxdHkiOjJ9"}`)},
+			expValid: true,
+		},
+		{
+			name: "envelope without data",
+			req:  &PublishRequest{Topic: "orders", Data: []byte(`{"specversion":"1.0","id":"a"}`)},
+		},
+		{
+			name: "raw payload is not unwrapped",
+			req: &PublishRequest{
+				Topic:    "orders",
+				Data:     []byte(`{"specversion":"1.0","id":"a","data":{"id":"1","qty":2}}`),
+				Metadata: map[string]string{"rawPayload": "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.req)
+			if tt.expValid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrSchemaValidation)
+			}
+		})
+	}
+
+	t.Run("topic schema takes precedence", func(t *testing.T) {
+		v, err := NewSchemaValidator(map[string]string{
+			PublishSchemaKey:                       `{"type": "string"}`,
+			PublishSchemaTopicKeyPrefix + "orders": orderSchema,
+		})
+		require.NoError(t, err)
+		assert.NoError(t, v.Validate(&PublishRequest{Topic: "orders", Data: []byte(`{"id":"1","qty":2}`)}))
+		assert.NoError(t, v.Validate(&PublishRequest{Topic: "other", Data: []byte(`"hello"`)}))
+		assert.Error(t, v.Validate(&PublishRequest{Topic: "other", Data: []byte(`{"id":"1","qty":2}`)}))
+	})
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	defaultTablePrefix       = "pubsub_"
+	defaultPollInterval      = time.Second
+	defaultVisibilityTimeout = time.Minute
+	defaultBatchSize         = 10
+	defaultBusyTimeout       = 2 * time.Second
+	defaultTimeout           = 20 * time.Second
+)
+
+type metadata struct {
+	// Path of the database file, or a "file:" URI.
+	ConnectionString string
+	// Prefix of the names of the tables.
+	TablePrefix string
+	// Consumer group of the subscriptions; each message is delivered once per consumer group.
+	ConsumerID string
+	// Interval between polls of the deliveries when there are no messages to deliver.
+	PollInterval time.Duration
+	// Time after which a delivered message that wasn't acknowledged is delivered again.
+	VisibilityTimeout time.Duration
+	// Maximum number of messages delivered per poll.
+	BatchSize int
+	// Time SQLite waits for a lock held by another connection before failing with SQLITE_BUSY.
+	BusyTimeout time.Duration
+}
+
+func parseMetadata(meta pubsub.Metadata) (metadata, error) {
+	m := metadata{
+		TablePrefix:       defaultTablePrefix,
+		PollInterval:      defaultPollInterval,
+		VisibilityTimeout: defaultVisibilityTimeout,
+		BatchSize:         defaultBatchSize,
+		BusyTimeout:       defaultBusyTimeout,
+	}
+	err := contribMetadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.ConnectionString == "" {
+		return m, errors.New("missing connection string")
+	}
+	if m.ConsumerID == "" {
+		return m, errors.New("missing consumerID")
+	}
+	if m.TablePrefix != "" && !validIdentifier(m.TablePrefix) {
+		return m, fmt.Errorf("table prefix '%s' is not valid", m.TablePrefix)
+	}
+	if m.PollInterval <= 0 {
+		return m, fmt.Errorf("invalid pollInterval: %v", m.PollInterval)
+	}
+	if m.VisibilityTimeout <= 0 {
+		return m, fmt.Errorf("invalid visibilityTimeout: %v", m.VisibilityTimeout)
+	}
+	if m.BatchSize < 1 {
+		return m, fmt.Errorf("invalid batchSize: %d", m.BatchSize)
+	}
+	if m.BusyTimeout < 0 {
+		return m, fmt.Errorf("invalid busyTimeout: %v", m.BusyTimeout)
+	}
+
+	return m, nil
+}
+
+// dsn returns the DSN of the connection to the database.
+func (m metadata) dsn() string {
+	params := url.Values{}
+	// Transactions take the write lock immediately, so they wait on busy_timeout rather than failing when upgrading
+	// from a read lock
+	params.Add("_txlock", "immediate")
+	params.Add("_pragma", "busy_timeout("+strconv.FormatInt(m.BusyTimeout.Milliseconds(), 10)+")")
+	if !m.isInMemory() {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+
+	sep := "?"
+	if strings.Contains(m.ConnectionString, "?") {
+		sep = "&"
+	}
+	return m.ConnectionString + sep + params.Encode()
+}
+
+func (m metadata) isInMemory() bool {
+	return m.ConnectionString == ":memory:" ||
+		strings.HasPrefix(m.ConnectionString, "file::memory:") ||
+		strings.Contains(m.ConnectionString, "mode=memory")
+}
+
+// Validates an identifier, such as a table name.
+func validIdentifier(v string) bool {
+	if v == "" {
+		return false
+	}
+
+	// Loop through the string as byte slice as we only care about ASCII characters
+	b := []byte(v)
+	for i := 0; i < len(b); i++ {
+		if (b[i] >= '0' && b[i] <= '9') ||
+			(b[i] >= 'a' && b[i] <= 'z') ||
+			(b[i] >= 'A' && b[i] <= 'Z') ||
+			b[i] == '_' {
+			continue
+		}
+		return false
+	}
+	return true
+}
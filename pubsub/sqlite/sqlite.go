@@ -0,0 +1,444 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+
+	// Blank import for the underlying SQLite driver.
+	_ "modernc.org/sqlite"
+)
+
+// SQLite is a pubsub backed by a SQLite database, for single-node deployments without a broker.
+//
+// Published messages are written to a messages table, and fanned out in the same transaction to a deliveries table,
+// with one row per consumer group subscribed to the topic. Subscribers poll their deliveries, which are hidden for
+// the visibility timeout once claimed and deleted once the handler succeeds; deliveries that aren't acknowledged in
+// time are delivered again, so messages are delivered at least once.
+type SQLite struct {
+	metadata metadata
+	db       *sql.DB
+	timeout  time.Duration
+	schemas  *pubsub.SchemaValidator
+
+	messagesTable      string
+	deliveriesTable    string
+	subscriptionsTable string
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger logger.Logger
+}
+
+// delivery is a message claimed by a subscriber.
+type delivery struct {
+	messageID   int64
+	data        []byte
+	contentType sql.NullString
+	metadata    sql.NullString
+}
+
+// NewSQLite returns a new SQLite pubsub.
+func NewSQLite(logger logger.Logger) pubsub.PubSub {
+	return &SQLite{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init opens the database and ensures that the tables exist.
+func (s *SQLite) Init(meta pubsub.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	s.metadata = m
+	s.schemas, err = pubsub.NewSchemaValidator(meta.Properties)
+	if err != nil {
+		return err
+	}
+	s.timeout, err = contribMetadata.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	s.messagesTable = m.TablePrefix + "messages"
+	s.deliveriesTable = m.TablePrefix + "deliveries"
+	s.subscriptionsTable = m.TablePrefix + "subscriptions"
+
+	s.db, err = sql.Open("sqlite", m.dsn())
+	if err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}
+	// SQLite allows a single writer, and in-memory databases are per-connection
+	s.db.SetMaxOpenConns(1)
+	s.db.SetConnMaxIdleTime(0)
+	s.db.SetConnMaxLifetime(0)
+
+	return s.ensureTables()
+}
+
+func (s *SQLite) ensureTables() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	// Note that the table names are sanitized
+	//nolint:gosec
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			topic TEXT NOT NULL,
+			data BLOB NOT NULL,
+			content_type TEXT,
+			metadata TEXT,
+			published_at INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS %[2]s (
+			consumer_id TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (consumer_id, topic)
+		);
+		CREATE TABLE IF NOT EXISTS %[3]s (
+			consumer_id TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			message_id INTEGER NOT NULL,
+			visible_at INTEGER NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (consumer_id, message_id)
+		);
+		CREATE INDEX IF NOT EXISTS %[3]s_visible_idx ON %[3]s (consumer_id, topic, visible_at);`,
+		s.messagesTable, s.subscriptionsTable, s.deliveriesTable))
+	if err != nil {
+		return fmt.Errorf("failed to create the tables: %w", err)
+	}
+
+	return nil
+}
+
+// Features returns the features supported by the pubsub.
+func (s *SQLite) Features() []pubsub.Feature {
+	return nil
+}
+
+// GetComponentMetadata returns the guarantees of the pubsub.
+func (s *SQLite) GetComponentMetadata() map[string]string {
+	// Deliveries that aren't acknowledged in time are redelivered after more recent messages
+	g := pubsub.Guarantees{
+		Ordering: pubsub.OrderingNone,
+		Delivery: pubsub.DeliveryAtLeastOnce,
+	}
+
+	return g.ComponentMetadata()
+}
+
+func (s *SQLite) ComponentProfile() contribMetadata.ComponentProfile {
+	return contribMetadata.ComponentProfile{
+		AuthProfiles: []contribMetadata.AuthProfile{contribMetadata.AuthProfileNone},
+	}
+}
+
+// Publish stores a message and fans it out to the consumer groups subscribed to the topic.
+// Messages published to a topic without subscriptions are dropped.
+func (s *SQLite) Publish(req *pubsub.PublishRequest) error {
+	if err := s.schemas.Validate(req); err != nil {
+		return err
+	}
+
+	var md []byte
+	if len(req.Metadata) > 0 {
+		var err error
+		md, err = json.Marshal(req.Metadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		now := time.Now().UnixMilli()
+		//nolint:gosec
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %s (topic, data, content_type, metadata, published_at) VALUES (?, ?, ?, ?, ?)`,
+			s.messagesTable), req.Topic, req.Data, req.ContentType, nullString(md), now)
+		if err != nil {
+			return fmt.Errorf("failed to insert message: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		//nolint:gosec
+		res, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %s (consumer_id, topic, message_id, visible_at) SELECT consumer_id, topic, ?, ? FROM %s WHERE topic = ?`,
+			s.deliveriesTable, s.subscriptionsTable), id, now, req.Topic)
+		if err != nil {
+			return fmt.Errorf("failed to insert deliveries: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			s.logger.Debugf("No subscriptions to topic %s: dropping message", req.Topic)
+			//nolint:gosec
+			_, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.messagesTable), id)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Subscribe registers the consumer group's subscription to the topic and polls its deliveries until ctx is done.
+// The subscription is durable: messages published while the subscriber isn't running are delivered when it restarts.
+func (s *SQLite) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	concurrency, err := pubsub.Concurrency(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	err = s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		//nolint:gosec
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`INSERT OR IGNORE INTO %s (consumer_id, topic, created_at) VALUES (?, ?, ?)`,
+			s.subscriptionsTable), s.metadata.ConsumerID, req.Topic, time.Now().UnixMilli())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to topic %s: %w", req.Topic, err)
+	}
+
+	s.wg.Add(1)
+	go s.poll(ctx, req.Topic, concurrency, handler)
+
+	return nil
+}
+
+// poll delivers the messages of a topic until ctx is done or the pubsub is closed.
+func (s *SQLite) poll(ctx context.Context, topic string, concurrency pubsub.ConcurrencyMode, handler pubsub.Handler) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closeCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		deliveries, err := s.claim(topic)
+		if err != nil {
+			s.logger.Errorf("Failed to poll messages of topic %s: %v", topic, err)
+		} else if len(deliveries) > 0 {
+			s.deliver(ctx, topic, deliveries, concurrency, handler)
+		}
+
+		// Poll again immediately while there are messages left
+		if err == nil && len(deliveries) == s.metadata.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.metadata.PollInterval):
+		}
+	}
+}
+
+// claim returns the visible deliveries of a topic, hiding them for the visibility timeout.
+func (s *SQLite) claim(topic string) ([]delivery, error) {
+	var deliveries []delivery
+	err := s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		now := time.Now().UnixMilli()
+		//nolint:gosec
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+			`UPDATE %[1]s SET visible_at = ?, attempts = attempts + 1
+			WHERE consumer_id = ? AND message_id IN (
+				SELECT message_id FROM %[1]s WHERE consumer_id = ? AND topic = ? AND visible_at <= ? ORDER BY message_id LIMIT ?
+			)
+			RETURNING message_id`,
+			s.deliveriesTable),
+			now+s.metadata.VisibilityTimeout.Milliseconds(), s.metadata.ConsumerID,
+			s.metadata.ConsumerID, topic, now, s.metadata.BatchSize)
+		if err != nil {
+			return err
+		}
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		//nolint:gosec
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(
+			`SELECT data, content_type, metadata FROM %s WHERE id = ?`, s.messagesTable))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, id := range ids {
+			d := delivery{messageID: id}
+			err = stmt.QueryRowContext(ctx, id).Scan(&d.data, &d.contentType, &d.metadata)
+			if err != nil {
+				return fmt.Errorf("failed to read message %d: %w", id, err)
+			}
+			deliveries = append(deliveries, d)
+		}
+
+		return nil
+	})
+
+	return deliveries, err
+}
+
+func (s *SQLite) deliver(ctx context.Context, topic string, deliveries []delivery, concurrency pubsub.ConcurrencyMode, handler pubsub.Handler) {
+	if concurrency == pubsub.Single {
+		for _, d := range deliveries {
+			s.handle(ctx, topic, d, handler)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(deliveries))
+	for _, d := range deliveries {
+		go func(d delivery) {
+			defer wg.Done()
+			s.handle(ctx, topic, d, handler)
+		}(d)
+	}
+	wg.Wait()
+}
+
+// handle invokes the handler for a delivery, and acknowledges it if the handler succeeds.
+// Otherwise the delivery is left to be redelivered once its visibility timeout expires.
+func (s *SQLite) handle(ctx context.Context, topic string, d delivery, handler pubsub.Handler) {
+	msg := &pubsub.NewMessage{
+		Data:  d.data,
+		Topic: topic,
+	}
+	if d.contentType.Valid {
+		msg.ContentType = &d.contentType.String
+	}
+	if d.metadata.Valid {
+		err := json.Unmarshal([]byte(d.metadata.String), &msg.Metadata)
+		if err != nil {
+			s.logger.Warnf("Failed to decode metadata of message %d: %v", d.messageID, err)
+		}
+	}
+
+	err := handler(ctx, msg)
+	if err != nil {
+		s.logger.Errorf("Error processing message %d of topic %s: %v", d.messageID, topic, err)
+		return
+	}
+
+	err = s.ack(d.messageID)
+	if err != nil {
+		s.logger.Errorf("Failed to acknowledge message %d of topic %s: %v", d.messageID, topic, err)
+	}
+}
+
+// ack deletes the delivery of a message, and the message once it's been delivered to all the consumer groups.
+func (s *SQLite) ack(messageID int64) error {
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		//nolint:gosec
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE consumer_id = ? AND message_id = ?`,
+			s.deliveriesTable), s.metadata.ConsumerID, messageID)
+		if err != nil {
+			return err
+		}
+
+		//nolint:gosec
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE id = ? AND NOT EXISTS (SELECT 1 FROM %s WHERE message_id = ?)`,
+			s.messagesTable, s.deliveriesTable), messageID, messageID)
+		return err
+	})
+}
+
+// withTx runs fn in a transaction, committing it if fn succeeds.
+func (s *SQLite) withTx(fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if s.db == nil {
+		return errors.New("pubsub is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction is committed
+		_ = tx.Rollback()
+	}()
+
+	err = fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close stops the subscriptions and closes the database.
+func (s *SQLite) Close() error {
+	if s.db == nil {
+		return nil
+	}
+
+	close(s.closeCh)
+	s.wg.Wait()
+
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+func nullString(b []byte) sql.NullString {
+	if b == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(b), Valid: true}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(newMetadata(map[string]string{
+			"connectionString": "pubsub.db",
+			"consumerID":       "app1",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, defaultTablePrefix, m.TablePrefix)
+		assert.Equal(t, defaultPollInterval, m.PollInterval)
+		assert.Equal(t, defaultVisibilityTimeout, m.VisibilityTimeout)
+		assert.Equal(t, defaultBatchSize, m.BatchSize)
+		assert.Contains(t, m.dsn(), "journal_mode%28WAL%29")
+	})
+
+	invalid := map[string]map[string]string{
+		"missing connection string": {"consumerID": "app1"},
+		"missing consumerID":        {"connectionString": "pubsub.db"},
+		"invalid table prefix":      {"connectionString": "pubsub.db", "consumerID": "app1", "tablePrefix": "a;b"},
+		"invalid batch size":        {"connectionString": "pubsub.db", "consumerID": "app1", "batchSize": "0"},
+		"invalid visibility":        {"connectionString": "pubsub.db", "consumerID": "app1", "visibilityTimeout": "0s"},
+	}
+	for name, props := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(newMetadata(props))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "pubsub.db")
+	app1 := newTestPubSub(t, dbPath, "app1")
+	app2 := newTestPubSub(t, dbPath, "app2")
+
+	received1 := make(chan *pubsub.NewMessage, 10)
+	received2 := make(chan *pubsub.NewMessage, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, app1.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received1 <- msg
+		return nil
+	}))
+	require.NoError(t, app2.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received2 <- msg
+		return nil
+	}))
+
+	contentType := "application/json"
+	err := app1.Publish(&pubsub.PublishRequest{
+		Topic:       "orders",
+		Data:        []byte(`{"id":1}`),
+		ContentType: &contentType,
+		Metadata:    map[string]string{"k": "v"},
+	})
+	require.NoError(t, err)
+
+	// Each consumer group receives the message
+	for _, ch := range []chan *pubsub.NewMessage{received1, received2} {
+		select {
+		case msg := <-ch:
+			assert.Equal(t, "orders", msg.Topic)
+			assert.Equal(t, `{"id":1}`, string(msg.Data))
+			require.NotNil(t, msg.ContentType)
+			assert.Equal(t, contentType, *msg.ContentType)
+			assert.Equal(t, map[string]string{"k": "v"}, msg.Metadata)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+
+	// The message is deleted once acknowledged by both consumer groups
+	assert.Eventually(t, func() bool {
+		return countRows(t, app1, app1.messagesTable) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRedeliveryAfterVisibilityTimeout(t *testing.T) {
+	ps := newTestPubSub(t, filepath.Join(t.TempDir(), "pubsub.db"), "app1")
+
+	var attempts atomic.Int32
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, ps.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		if attempts.Add(1) == 1 {
+			return errors.New("handler failed")
+		}
+		close(done)
+		return nil
+	}))
+	require.NoError(t, ps.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("hello")}))
+
+	select {
+	case <-done:
+		assert.Equal(t, int32(2), attempts.Load())
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+}
+
+func TestDurableSubscription(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "pubsub.db")
+	ps := newTestPubSub(t, dbPath, "app1")
+
+	// Messages published without subscriptions are dropped
+	require.NoError(t, ps.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("dropped")}))
+	assert.Equal(t, 0, countRows(t, ps, ps.messagesTable))
+
+	// Messages published while the subscriber isn't running are kept
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, ps.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}))
+	cancel()
+	require.NoError(t, ps.Close())
+
+	publisher := newTestPubSub(t, dbPath, "publisher")
+	require.NoError(t, publisher.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("kept")}))
+
+	restarted := newTestPubSub(t, dbPath, "app1")
+	received := make(chan []byte, 1)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, restarted.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received <- msg.Data
+		return nil
+	}))
+
+	select {
+	case data := <-received:
+		assert.Equal(t, "kept", string(data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestSingleConcurrency(t *testing.T) {
+	ps := newTestPubSub(t, filepath.Join(t.TempDir(), "pubsub.db"), "app1")
+
+	var (
+		lock     sync.Mutex
+		active   int
+		received int
+	)
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := pubsub.SubscribeRequest{Topic: "orders", Metadata: map[string]string{pubsub.ConcurrencyKey: string(pubsub.Single)}}
+	require.NoError(t, ps.Subscribe(ctx, req, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		lock.Lock()
+		active++
+		assert.Equal(t, 1, active)
+		lock.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		lock.Lock()
+		defer lock.Unlock()
+		active--
+		received++
+		if received == 5 {
+			close(done)
+		}
+		return nil
+	}))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ps.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("msg")}))
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("messages not received")
+	}
+}
+
+func newTestPubSub(t *testing.T, dbPath string, consumerID string) *SQLite {
+	t.Helper()
+
+	ps := NewSQLite(logger.NewLogger("test")).(*SQLite)
+	err := ps.Init(newMetadata(map[string]string{
+		"connectionString":  dbPath,
+		"consumerID":        consumerID,
+		"pollInterval":      "10ms",
+		"visibilityTimeout": "100ms",
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, ps.Close())
+	})
+
+	return ps
+}
+
+func newMetadata(props map[string]string) pubsub.Metadata {
+	return pubsub.Metadata{Base: contribMetadata.Base{Properties: props}}
+}
+
+func countRows(t *testing.T, ps *SQLite, table string) int {
+	t.Helper()
+
+	var n int
+	err := ps.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+	require.NoError(t, err)
+	return n
+}
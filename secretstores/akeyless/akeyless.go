@@ -0,0 +1,357 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package akeyless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultGatewayURL = "https://api.akeyless.io"
+	defaultPath       = "/"
+
+	// Request metadata with the type of a secret, to avoid looking it up before reading it.
+	metadataSecretType = "secretType"
+
+	secretTypeStatic  = "static"
+	secretTypeDynamic = "dynamic"
+
+	itemTypeStatic  = "STATIC_SECRET"
+	itemTypeDynamic = "DYNAMIC_SECRET"
+
+	// Number of static secrets read at once by BulkGetSecret.
+	bulkGetBatchSize = 100
+	// Timeout of the requests of the store.
+	requestTimeout = 30 * time.Second
+)
+
+var _ secretstores.SecretStore = (*akeylessSecretStore)(nil)
+
+// ErrNotFound is returned when a secret doesn't exist.
+var ErrNotFound = errors.New("secret not found")
+
+// AkeylessMetadata is the metadata of the Akeyless secret store.
+type AkeylessMetadata struct {
+	// URL of the Akeyless API or of an Akeyless gateway; defaults to https://api.akeyless.io.
+	GatewayURL string `mapstructure:"gatewayUrl"`
+	// Access ID of the auth method.
+	AccessID string `mapstructure:"accessId"`
+	// Type of the auth method: access_key (default), aws_iam, azure_ad or gcp.
+	AccessType string `mapstructure:"accessType"`
+	// Access key of the access_key auth method.
+	AccessKey string `mapstructure:"accessKey"`
+	// Object ID of the Azure managed identity, when the VM has more than one.
+	AzureObjectID string `mapstructure:"azureObjectId"`
+	// Audience of the GCP identity token; defaults to akeyless.io.
+	GCPAudience string `mapstructure:"gcpAudience"`
+	// Folder of the secrets returned by BulkGetSecret; defaults to the root folder.
+	Path string `mapstructure:"path"`
+}
+
+// akeylessSecretStore is a secret store implementation for Akeyless.
+type akeylessSecretStore struct {
+	client     *http.Client
+	gatewayURL string
+	path       string
+	auth       *authenticator
+
+	logger logger.Logger
+}
+
+// NewAkeylessSecretStore returns a new Akeyless secret store.
+func NewAkeylessSecretStore(logger logger.Logger) secretstores.SecretStore {
+	return &akeylessSecretStore{
+		client: &http.Client{Timeout: requestTimeout},
+		logger: logger,
+	}
+}
+
+// Init validates the metadata and authenticates with Akeyless.
+func (a *akeylessSecretStore) Init(meta secretstores.Metadata) error {
+	m := AkeylessMetadata{
+		GatewayURL: defaultGatewayURL,
+		AccessType: string(accessTypeAccessKey),
+		Path:       defaultPath,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.GatewayURL == "" {
+		m.GatewayURL = defaultGatewayURL
+	}
+	if m.Path == "" {
+		m.Path = defaultPath
+	}
+
+	a.gatewayURL = strings.TrimSuffix(m.GatewayURL, "/")
+	a.path = m.Path
+	a.auth, err = newAuthenticator(&m, a.client)
+	if err != nil {
+		return fmt.Errorf("akeyless init error: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err = a.auth.token(ctx, a.post)
+	if err != nil {
+		return fmt.Errorf("akeyless init error: %w", err)
+	}
+
+	return nil
+}
+
+// GetSecret returns the value of a static secret as a map with the name of the secret as key,
+// or the values of a dynamic secret, which generates new credentials each time it is read.
+func (a *akeylessSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	secretType := req.Metadata[metadataSecretType]
+	if secretType == "" {
+		itemType, err := a.describeItem(ctx, req.Name)
+		if err != nil {
+			return secretstores.GetSecretResponse{Data: nil}, err
+		}
+		switch itemType {
+		case itemTypeStatic:
+			secretType = secretTypeStatic
+		case itemTypeDynamic:
+			secretType = secretTypeDynamic
+		default:
+			return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("secret %s has the unsupported type %s", req.Name, itemType)
+		}
+	}
+
+	var (
+		data map[string]string
+		err  error
+	)
+	switch secretType {
+	case secretTypeStatic:
+		data, err = a.getStaticSecrets(ctx, []string{req.Name})
+		if err == nil && len(data) == 0 {
+			err = fmt.Errorf("getSecret %s failed %w", req.Name, ErrNotFound)
+		}
+	case secretTypeDynamic:
+		data, err = a.getDynamicSecret(ctx, req.Name)
+	default:
+		err = fmt.Errorf("invalid %s %s, accepted values are %s or %s", metadataSecretType, secretType, secretTypeStatic, secretTypeDynamic)
+	}
+	if err != nil {
+		return secretstores.GetSecretResponse{Data: nil}, err
+	}
+
+	return secretstores.GetSecretResponse{Data: data}, nil
+}
+
+// BulkGetSecret returns the static secrets of the folder of the path metadata, and of its subfolders.
+// Dynamic secrets are not returned, as reading them generates new credentials.
+func (a *akeylessSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	names, err := a.listStaticSecrets(ctx)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	resp := secretstores.BulkGetSecretResponse{
+		Data: make(map[string]map[string]string, len(names)),
+	}
+	for start := 0; start < len(names); start += bulkGetBatchSize {
+		end := start + bulkGetBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		values, err := a.getStaticSecrets(ctx, names[start:end])
+		if err != nil {
+			return secretstores.BulkGetSecretResponse{Data: nil}, err
+		}
+		for name, value := range values {
+			resp.Data[name] = map[string]string{name: value}
+		}
+	}
+
+	return resp, nil
+}
+
+type describeItemResponse struct {
+	ItemType string `json:"item_type"`
+}
+
+func (a *akeylessSecretStore) describeItem(ctx context.Context, name string) (string, error) {
+	var res describeItemResponse
+	err := a.call(ctx, "/describe-item", map[string]any{"name": name}, &res)
+	if err != nil {
+		return "", fmt.Errorf("couldn't describe secret %s: %w", name, err)
+	}
+	return res.ItemType, nil
+}
+
+// getStaticSecrets returns the values of static secrets by name.
+func (a *akeylessSecretStore) getStaticSecrets(ctx context.Context, names []string) (map[string]string, error) {
+	res := map[string]string{}
+	err := a.call(ctx, "/get-secret-value", map[string]any{"names": names}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get secret value: %w", err)
+	}
+	return res, nil
+}
+
+// getDynamicSecret returns the values of a dynamic secret. Values that aren't strings are returned as JSON.
+func (a *akeylessSecretStore) getDynamicSecret(ctx context.Context, name string) (map[string]string, error) {
+	var res map[string]json.RawMessage
+	err := a.call(ctx, "/get-dynamic-secret-value", map[string]any{"name": name}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get dynamic secret value %s: %w", name, err)
+	}
+
+	data := make(map[string]string, len(res))
+	for k, v := range res {
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			data[k] = s
+		} else {
+			data[k] = string(v)
+		}
+	}
+	return data, nil
+}
+
+type listItemsResponse struct {
+	Items []struct {
+		ItemName string `json:"item_name"`
+		ItemType string `json:"item_type"`
+	} `json:"items"`
+	NextPage string `json:"next_page"`
+}
+
+// listStaticSecrets returns the names of the static secrets under the path.
+func (a *akeylessSecretStore) listStaticSecrets(ctx context.Context) ([]string, error) {
+	var (
+		names []string
+		page  string
+	)
+	for {
+		body := map[string]any{
+			"path": a.path,
+			"type": []string{"static-secret"},
+		}
+		if page != "" {
+			body["pagination-token"] = page
+		}
+		var res listItemsResponse
+		err := a.call(ctx, "/list-items", body, &res)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't list secrets: %w", err)
+		}
+		for _, item := range res.Items {
+			if item.ItemType == itemTypeStatic {
+				names = append(names, item.ItemName)
+			}
+		}
+		if res.NextPage == "" {
+			return names, nil
+		}
+		page = res.NextPage
+	}
+}
+
+// call calls an operation of the API with a token, and authenticates again if the token has expired.
+func (a *akeylessSecretStore) call(ctx context.Context, operation string, body map[string]any, res any) error {
+	for attempt := 0; ; attempt++ {
+		token, err := a.auth.token(ctx, a.post)
+		if err != nil {
+			return err
+		}
+		body["token"] = token
+		err = a.post(ctx, operation, body, res)
+		var apiErr *apiError
+		if attempt == 0 && errors.As(err, &apiErr) && apiErr.statusCode == http.StatusUnauthorized {
+			a.auth.invalidate(token)
+			continue
+		}
+		return err
+	}
+}
+
+// apiError is the response of a failed request.
+type apiError struct {
+	statusCode int
+	body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("couldn't get successful response, status code %d, body %s", e.statusCode, e.body)
+}
+
+// post sends a request to an operation of the API, and decodes its response.
+func (a *akeylessSecretStore) post(ctx context.Context, operation string, body any, res any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.gatewayURL+operation, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		if httpResp.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		return &apiError{statusCode: httpResp.StatusCode, body: string(respBody)}
+	}
+
+	err = json.NewDecoder(httpResp.Body).Decode(res)
+	if err != nil {
+		return fmt.Errorf("couldn't decode response body: %w", err)
+	}
+	return nil
+}
+
+// Features returns the features available in this secret store.
+func (a *akeylessSecretStore) Features() []secretstores.Feature {
+	return []secretstores.Feature{}
+}
+
+func (a *akeylessSecretStore) GetComponentMetadata() map[string]string {
+	metadataStruct := AkeylessMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+func (a *akeylessSecretStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey, metadata.AuthProfileAWSIAM, metadata.AuthProfileAzureAD, metadata.AuthProfileGCPServiceAccount},
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package akeyless
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeAkeyless is a fake of the operations of the Akeyless API used by the store.
+type fakeAkeyless struct {
+	lock     sync.Mutex
+	auths    []map[string]any
+	tokens   int
+	revoked  map[string]bool
+	static   map[string]string
+	dynamic  map[string]map[string]any
+	pageSize int
+}
+
+func newFakeAkeyless() *fakeAkeyless {
+	return &fakeAkeyless{
+		revoked: map[string]bool{},
+		static: map[string]string{
+			"/app/db-password": "secret1",
+			"/app/api-key":     "secret2",
+			"/app/cert":        "secret3",
+		},
+		dynamic: map[string]map[string]any{
+			"/app/db-creds": {"user": "tmp-user", "password": "tmp-pass", "ttl_in_minutes": 60},
+		},
+		pageSize: 2,
+	}
+}
+
+func (f *fakeAkeyless) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Path == "/auth" {
+		f.auths = append(f.auths, body)
+		if body["access-type"] == "access_key" && body["access-key"] != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid access key"}`)
+			return
+		}
+		f.tokens++
+		json.NewEncoder(w).Encode(map[string]string{"token": fmt.Sprintf("t-%d", f.tokens)})
+		return
+	}
+
+	token, _ := body["token"].(string)
+	if token == "" || f.revoked[token] {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"token expired"}`)
+		return
+	}
+
+	var res any
+	switch r.URL.Path {
+	case "/describe-item":
+		name := body["name"].(string)
+		switch {
+		case f.static[name] != "":
+			res = map[string]string{"item_type": itemTypeStatic}
+		case f.dynamic[name] != nil:
+			res = map[string]string{"item_type": itemTypeDynamic}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	case "/get-secret-value":
+		values := map[string]string{}
+		for _, n := range body["names"].([]any) {
+			if v, ok := f.static[n.(string)]; ok {
+				values[n.(string)] = v
+			}
+		}
+		res = values
+	case "/get-dynamic-secret-value":
+		res = f.dynamic[body["name"].(string)]
+	case "/list-items":
+		names := []string{"/app/api-key", "/app/cert", "/app/db-password"}
+		start := 0
+		if p, ok := body["pagination-token"].(string); ok {
+			fmt.Sscan(p, &start)
+		}
+		end := start + f.pageSize
+		next := fmt.Sprint(end)
+		if end >= len(names) {
+			end = len(names)
+			next = ""
+		}
+		items := []map[string]string{}
+		for _, n := range names[start:end] {
+			items = append(items, map[string]string{"item_name": n, "item_type": itemTypeStatic})
+		}
+		res = map[string]any{"items": items, "next_page": next}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func newTestStore(t *testing.T, fake *fakeAkeyless, props map[string]string) (*akeylessSecretStore, error) {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	m := secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"gatewayUrl": server.URL,
+		"accessId":   "p-123",
+		"accessKey":  "key",
+	}}}
+	for k, v := range props {
+		m.Properties[k] = v
+	}
+	s := NewAkeylessSecretStore(logger.NewLogger("test")).(*akeylessSecretStore)
+	return s, s.Init(m)
+}
+
+func TestInit(t *testing.T) {
+	t.Run("access key", func(t *testing.T) {
+		fake := newFakeAkeyless()
+		_, err := newTestStore(t, fake, nil)
+		require.NoError(t, err)
+		require.Len(t, fake.auths, 1)
+		assert.Equal(t, "p-123", fake.auths[0]["access-id"])
+		assert.Equal(t, "access_key", fake.auths[0]["access-type"])
+		assert.Equal(t, "key", fake.auths[0]["access-key"])
+	})
+
+	t.Run("invalid access key", func(t *testing.T) {
+		_, err := newTestStore(t, newFakeAkeyless(), map[string]string{"accessKey": "wrong"})
+		assert.ErrorContains(t, err, "invalid access key")
+	})
+
+	t.Run("missing access id", func(t *testing.T) {
+		_, err := newTestStore(t, newFakeAkeyless(), map[string]string{"accessId": ""})
+		assert.ErrorContains(t, err, "accessId is required")
+	})
+
+	t.Run("missing access key", func(t *testing.T) {
+		_, err := newTestStore(t, newFakeAkeyless(), map[string]string{"accessKey": ""})
+		assert.ErrorContains(t, err, "accessKey is required")
+	})
+
+	t.Run("invalid access type", func(t *testing.T) {
+		_, err := newTestStore(t, newFakeAkeyless(), map[string]string{"accessType": "ldap"})
+		assert.ErrorContains(t, err, "invalid accessType ldap")
+	})
+
+	t.Run("azure ad", func(t *testing.T) {
+		imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "true", r.Header.Get("Metadata"))
+			assert.Equal(t, "obj-1", r.URL.Query().Get("object_id"))
+			fmt.Fprint(w, `{"access_token":"azure-token"}`)
+		}))
+		defer imds.Close()
+		defer func(u string) { azureIMDSURL = u }(azureIMDSURL)
+		azureIMDSURL = imds.URL
+
+		fake := newFakeAkeyless()
+		_, err := newTestStore(t, fake, map[string]string{"accessType": "azure_ad", "accessKey": "", "azureObjectId": "obj-1"})
+		require.NoError(t, err)
+		require.Len(t, fake.auths, 1)
+		assert.Equal(t, "azure_ad", fake.auths[0]["access-type"])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("azure-token")), fake.auths[0]["cloud-id"])
+		assert.NotContains(t, fake.auths[0], "access-key")
+	})
+
+	t.Run("gcp", func(t *testing.T) {
+		mds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			assert.Equal(t, "akeyless.io", r.URL.Query().Get("audience"))
+			fmt.Fprint(w, "gcp-jwt\n")
+		}))
+		defer mds.Close()
+		defer func(u string) { gcpIdentityURL = u }(gcpIdentityURL)
+		gcpIdentityURL = mds.URL
+
+		fake := newFakeAkeyless()
+		_, err := newTestStore(t, fake, map[string]string{"accessType": "gcp"})
+		require.NoError(t, err)
+		require.Len(t, fake.auths, 1)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("gcp-jwt")), fake.auths[0]["cloud-id"])
+		assert.Equal(t, "akeyless.io", fake.auths[0]["gcp-audience"])
+	})
+}
+
+func TestGetSecret(t *testing.T) {
+	fake := newFakeAkeyless()
+	s, err := newTestStore(t, fake, nil)
+	require.NoError(t, err)
+
+	t.Run("static", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/db-password"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/app/db-password": "secret1"}, res.Data)
+	})
+
+	t.Run("static with secret type", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "/app/api-key",
+			Metadata: map[string]string{"secretType": "static"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/app/api-key": "secret2"}, res.Data)
+	})
+
+	t.Run("dynamic", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/db-creds"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"user": "tmp-user", "password": "tmp-pass", "ttl_in_minutes": "60"}, res.Data)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "/app/missing",
+			Metadata: map[string]string{"secretType": "static"},
+		})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid secret type", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "/app/api-key",
+			Metadata: map[string]string{"secretType": "rotated"},
+		})
+		assert.ErrorContains(t, err, "invalid secretType rotated")
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		fake.lock.Lock()
+		fake.revoked[s.auth.value] = true
+		auths := len(fake.auths)
+		fake.lock.Unlock()
+
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/db-password"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/app/db-password": "secret1"}, res.Data)
+		assert.Len(t, fake.auths, auths+1)
+	})
+}
+
+func TestBulkGetSecret(t *testing.T) {
+	fake := newFakeAkeyless()
+	s, err := newTestStore(t, fake, map[string]string{"path": "/app"})
+	require.NoError(t, err)
+
+	res, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"/app/api-key":     {"/app/api-key": "secret2"},
+		"/app/cert":        {"/app/cert": "secret3"},
+		"/app/db-password": {"/app/db-password": "secret1"},
+	}, res.Data)
+}
+
+func TestGetComponentMetadata(t *testing.T) {
+	s := NewAkeylessSecretStore(logger.NewLogger("test"))
+	m := s.GetComponentMetadata()
+	assert.Contains(t, m, "accessId")
+	assert.Contains(t, m, "gatewayUrl")
+}
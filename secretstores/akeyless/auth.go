@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package akeyless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+type accessType string
+
+const (
+	accessTypeAccessKey accessType = "access_key"
+	accessTypeAWSIAM    accessType = "aws_iam"
+	accessTypeAzureAD   accessType = "azure_ad"
+	accessTypeGCP       accessType = "gcp"
+
+	defaultGCPAudience = "akeyless.io"
+
+	// Tokens are valid for 60 minutes by default; they are renewed well before.
+	tokenTTL = 30 * time.Minute
+
+	awsSTSURL     = "https://sts.amazonaws.com/"
+	awsSTSBody    = "Action=GetCallerIdentity&Version=2011-06-15"
+	awsSTSRegion  = "us-east-1"
+	awsSTSService = "sts"
+)
+
+// Endpoints of the cloud metadata services; variables so that tests can replace them.
+var (
+	azureIMDSURL      = "http://169.254.169.254/metadata/identity/oauth2/token"
+	gcpIdentityURL    = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"
+	azureIMDSResource = "https://management.azure.com/"
+)
+
+// postFn sends a request to an operation of the Akeyless API.
+type postFn func(ctx context.Context, operation string, body any, res any) error
+
+// authenticator gets the tokens of the Akeyless API, and caches them until they expire.
+type authenticator struct {
+	accessID   string
+	accessType accessType
+	accessKey  string
+	// cloudID returns the cloud identity of the cloud auth methods.
+	cloudID func(ctx context.Context) (string, error)
+	// Only for gcp.
+	gcpAudience string
+
+	lock    sync.Mutex
+	value   string
+	expires time.Time
+}
+
+func newAuthenticator(m *AkeylessMetadata, client *http.Client) (*authenticator, error) {
+	if m.AccessID == "" {
+		return nil, errors.New("accessId is required")
+	}
+
+	a := &authenticator{
+		accessID:   m.AccessID,
+		accessType: accessType(m.AccessType),
+	}
+	switch a.accessType {
+	case "", accessTypeAccessKey:
+		if m.AccessKey == "" {
+			return nil, errors.New("accessKey is required with the access_key access type")
+		}
+		a.accessType = accessTypeAccessKey
+		a.accessKey = m.AccessKey
+	case accessTypeAWSIAM:
+		a.cloudID = awsCloudID
+	case accessTypeAzureAD:
+		objectID := m.AzureObjectID
+		a.cloudID = func(ctx context.Context) (string, error) {
+			return azureCloudID(ctx, client, objectID)
+		}
+	case accessTypeGCP:
+		a.gcpAudience = m.GCPAudience
+		if a.gcpAudience == "" {
+			a.gcpAudience = defaultGCPAudience
+		}
+		a.cloudID = func(ctx context.Context) (string, error) {
+			return gcpCloudID(ctx, client, a.gcpAudience)
+		}
+	default:
+		return nil, fmt.Errorf("invalid accessType %s, accepted values are %s, %s, %s or %s", m.AccessType, accessTypeAccessKey, accessTypeAWSIAM, accessTypeAzureAD, accessTypeGCP)
+	}
+
+	return a, nil
+}
+
+type authRequest struct {
+	AccessID    string `json:"access-id"`
+	AccessType  string `json:"access-type"`
+	AccessKey   string `json:"access-key,omitempty"`
+	CloudID     string `json:"cloud-id,omitempty"`
+	GCPAudience string `json:"gcp-audience,omitempty"`
+}
+
+type authResponse struct {
+	Token string `json:"token"`
+}
+
+// token returns the cached token, or authenticates when it has expired.
+func (a *authenticator) token(ctx context.Context, post postFn) (string, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.value != "" && time.Now().Before(a.expires) {
+		return a.value, nil
+	}
+
+	req := authRequest{
+		AccessID:    a.accessID,
+		AccessType:  string(a.accessType),
+		AccessKey:   a.accessKey,
+		GCPAudience: a.gcpAudience,
+	}
+	if a.cloudID != nil {
+		cloudID, err := a.cloudID(ctx)
+		if err != nil {
+			return "", fmt.Errorf("couldn't get the cloud identity of %s: %w", a.accessType, err)
+		}
+		req.CloudID = cloudID
+	}
+
+	var res authResponse
+	err := post(ctx, "/auth", req, &res)
+	if err != nil {
+		return "", fmt.Errorf("couldn't authenticate: %w", err)
+	}
+	if res.Token == "" {
+		return "", errors.New("couldn't authenticate: empty token")
+	}
+
+	a.value = res.Token
+	a.expires = time.Now().Add(tokenTTL)
+	return a.value, nil
+}
+
+// invalidate removes the token from the cache, unless it has already been replaced.
+func (a *authenticator) invalidate(token string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.value == token {
+		a.value = ""
+	}
+}
+
+// awsCloudID returns a GetCallerIdentity request of STS signed with the AWS credentials of the environment,
+// which Akeyless sends to STS to verify the identity.
+func awsCloudID(ctx context.Context) (string, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, awsSTSURL, strings.NewReader(awsSTSBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	_, err = v4.NewSigner(sess.Config.Credentials).Sign(req, strings.NewReader(awsSTSBody), awsSTSService, awsSTSRegion, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	headers, err := json.Marshal(req.Header)
+	if err != nil {
+		return "", err
+	}
+	identity, err := json.Marshal(map[string]string{
+		"sts_request_method":  http.MethodPost,
+		"sts_request_url":     base64.StdEncoding.EncodeToString([]byte(awsSTSURL)),
+		"sts_request_body":    base64.StdEncoding.EncodeToString([]byte(awsSTSBody)),
+		"sts_request_headers": base64.StdEncoding.EncodeToString(headers),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(identity), nil
+}
+
+// azureCloudID returns an access token of the managed identity of the VM.
+func azureCloudID(ctx context.Context, client *http.Client, objectID string) (string, error) {
+	q := url.Values{}
+	q.Set("api-version", "2018-02-01")
+	q.Set("resource", azureIMDSResource)
+	if objectID != "" {
+		q.Set("object_id", objectID)
+	}
+	b, err := getMetadata(ctx, client, azureIMDSURL+"?"+q.Encode(), "Metadata", "true")
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return "", fmt.Errorf("couldn't decode the Azure access token: %w", err)
+	}
+	if res.AccessToken == "" {
+		return "", errors.New("empty Azure access token")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(res.AccessToken)), nil
+}
+
+// gcpCloudID returns an identity token of the service account of the instance.
+func gcpCloudID(ctx context.Context, client *http.Client, audience string) (string, error) {
+	q := url.Values{}
+	q.Set("audience", audience)
+	q.Set("format", "full")
+	b, err := getMetadata(ctx, client, gcpIdentityURL+"?"+q.Encode(), "Metadata-Flavor", "Google")
+	if err != nil {
+		return "", err
+	}
+	token := string(bytes.TrimSpace(b))
+	if token == "" {
+		return "", errors.New("empty GCP identity token")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(token)), nil
+}
+
+// getMetadata reads an endpoint of a cloud metadata service.
+func getMetadata(ctx context.Context, client *http.Client, u string, header string, value string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(header, value)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata service returned status code %d, body %s", res.StatusCode, string(b))
+	}
+	return b, nil
+}
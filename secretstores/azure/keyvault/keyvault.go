@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -35,6 +36,11 @@ import (
 const (
 	VersionID          = "version_id"
 	secretItemIDPrefix = "/secrets/"
+
+	// Metadata property for BulkGetSecret to only return secrets having all the given tags ("key1=value1,key2=value2").
+	bulkGetTagsKey = "tags"
+
+	defaultBulkGetConcurrency = 10
 )
 
 var _ secretstores.SecretStore = (*keyvaultSecretStore)(nil)
@@ -44,11 +50,14 @@ type keyvaultSecretStore struct {
 	vaultClient    *azsecrets.Client
 	vaultDNSSuffix string
 
+	bulkGetConcurrency int
+
 	logger logger.Logger
 }
 
 type KeyvaultMetadata struct {
-	VaultName string
+	VaultName          string
+	BulkGetConcurrency int
 }
 
 // NewAzureKeyvaultSecretStore returns a new Azure Key Vault secret store.
@@ -95,6 +104,10 @@ func (k *keyvaultSecretStore) Init(meta secretstores.Metadata) error {
 	}
 
 	k.vaultName = m.VaultName
+	k.bulkGetConcurrency = m.BulkGetConcurrency
+	if k.bulkGetConcurrency <= 0 {
+		k.bulkGetConcurrency = defaultBulkGetConcurrency
+	}
 	k.vaultDNSSuffix = settings.AzureEnvironment.KeyVaultDNSSuffix
 
 	cred, err := settings.GetTokenCredential()
@@ -138,21 +151,23 @@ func (k *keyvaultSecretStore) GetSecret(ctx context.Context, req secretstores.Ge
 }
 
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
+// Only enabled secrets are returned; they can be further filtered by tags with the "tags" metadata property.
 func (k *keyvaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
 	maxResults, err := k.getMaxResultsFromMetadata(req.Metadata)
 	if err != nil {
 		return secretstores.BulkGetSecretResponse{}, err
 	}
 
-	resp := secretstores.BulkGetSecretResponse{
-		Data: map[string]map[string]string{},
+	tags, err := parseTagsFilter(req.Metadata[bulkGetTagsKey])
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
 	}
 
 	secretIDPrefix := k.getVaultURI() + secretItemIDPrefix
 
 	pager := k.vaultClient.NewListSecretsPager(nil)
 
-out:
+	secretNames := []string{}
 	for pager.More() {
 		pr, err := pager.NextPage(ctx)
 		if err != nil {
@@ -163,27 +178,115 @@ out:
 			if secret.Attributes == nil || secret.Attributes.Enabled == nil || !*secret.Attributes.Enabled {
 				continue
 			}
+			if !matchTags(secret.Tags, tags) {
+				continue
+			}
+
+			secretNames = append(secretNames, strings.TrimPrefix(secret.ID.Name(), secretIDPrefix))
+		}
+
+		if maxResults != nil && *maxResults > 0 && len(secretNames) >= int(*maxResults) {
+			break
+		}
+	}
+
+	return k.getSecrets(ctx, secretNames)
+}
+
+// getSecrets fetches the latest version of the given secrets using a bounded number of concurrent requests.
+func (k *keyvaultSecretStore) getSecrets(ctx context.Context, secretNames []string) (secretstores.BulkGetSecretResponse, error) {
+	resp := secretstores.BulkGetSecretResponse{
+		Data: make(map[string]map[string]string, len(secretNames)),
+	}
 
-			secretName := strings.TrimPrefix(secret.ID.Name(), secretIDPrefix)
-			secretResp, err := k.vaultClient.GetSecret(ctx, secretName, "", nil) // empty string means latest version
-			if err != nil {
-				return secretstores.BulkGetSecretResponse{}, err
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	namesCh := make(chan string)
+	go func() {
+		defer close(namesCh)
+		for _, name := range secretNames {
+			select {
+			case namesCh <- name:
+			case <-ctx.Done():
+				return
 			}
+		}
+	}()
+
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	workers := k.bulkGetConcurrency
+	if workers > len(secretNames) {
+		workers = len(secretNames)
+	}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for secretName := range namesCh {
+				secretResp, err := k.vaultClient.GetSecret(ctx, secretName, "", nil) // empty string means latest version
+
+				lock.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					lock.Unlock()
+					continue
+				}
 
-			secretValue := ""
-			if secretResp.Value != nil {
-				secretValue = *secretResp.Value
+				secretValue := ""
+				if secretResp.Value != nil {
+					secretValue = *secretResp.Value
+				}
+				resp.Data[secretName] = map[string]string{secretName: secretValue}
+				lock.Unlock()
 			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return secretstores.BulkGetSecretResponse{}, firstErr
+	}
+
+	return resp, nil
+}
 
-			resp.Data[secretName] = map[string]string{secretName: secretValue}
+// parseTagsFilter parses a "key1=value1,key2=value2" tags filter.
+func parseTagsFilter(val string) (map[string]string, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	tags := map[string]string{}
+	for _, pair := range strings.Split(val, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid tag filter '%s': expected format key=value", pair)
 		}
+		tags[k] = strings.TrimSpace(v)
+	}
+
+	return tags, nil
+}
 
-		if maxResults != nil && *maxResults > 0 && len(resp.Data) >= int(*maxResults) {
-			break out
+// matchTags returns true if the secret has all the tags in the filter.
+func matchTags(secretTags map[string]*string, filter map[string]string) bool {
+	for k, v := range filter {
+		tag, ok := secretTags[k]
+		if !ok || tag == nil || *tag != v {
+			return false
 		}
 	}
 
-	return resp, nil
+	return true
 }
 
 // getVaultURI returns Azure Key Vault URI.
@@ -216,3 +319,9 @@ func (k *keyvaultSecretStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (k *keyvaultSecretStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAzureAD},
+	}
+}
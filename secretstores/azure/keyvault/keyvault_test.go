@@ -39,8 +39,23 @@ func TestInit(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, kv.vaultName, "foo")
 		assert.Equal(t, kv.vaultDNSSuffix, "vault.azure.net")
+		assert.Equal(t, defaultBulkGetConcurrency, kv.bulkGetConcurrency)
 		assert.NotNil(t, kv.vaultClient)
 	})
+	t.Run("Init with bulk get concurrency", func(t *testing.T) {
+		m.Properties = map[string]string{
+			"vaultName":          "foo",
+			"azureTenantId":      "00000000-0000-0000-0000-000000000000",
+			"azureClientId":      "00000000-0000-0000-0000-000000000000",
+			"azureClientSecret":  "passw0rd",
+			"bulkGetConcurrency": "4",
+		}
+		err := s.Init(m)
+		assert.Nil(t, err)
+		kv, ok := s.(*keyvaultSecretStore)
+		assert.True(t, ok)
+		assert.Equal(t, 4, kv.bulkGetConcurrency)
+	})
 	t.Run("Init with valid metadata and Azure environment", func(t *testing.T) {
 		m.Properties = map[string]string{
 			"vaultName":         "foo",
@@ -97,3 +112,28 @@ func TestGetFeatures(t *testing.T) {
 		assert.Empty(t, f)
 	})
 }
+
+func TestTagsFilter(t *testing.T) {
+	t.Run("empty filter", func(t *testing.T) {
+		tags, err := parseTagsFilter("")
+		assert.Nil(t, err)
+		assert.Nil(t, tags)
+		assert.True(t, matchTags(nil, tags))
+	})
+
+	t.Run("valid filter", func(t *testing.T) {
+		tags, err := parseTagsFilter("env=prod, team = payments")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"env": "prod", "team": "payments"}, tags)
+
+		prod, payments, dev := "prod", "payments", "dev"
+		assert.True(t, matchTags(map[string]*string{"env": &prod, "team": &payments, "other": &dev}, tags))
+		assert.False(t, matchTags(map[string]*string{"env": &dev, "team": &payments}, tags))
+		assert.False(t, matchTags(map[string]*string{"env": &prod}, tags))
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := parseTagsFilter("env")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doppler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultAPIURL = "https://api.doppler.com"
+
+	// Request metadata with the project and the config to read the secrets from, which override the component metadata.
+	metadataProject = "project"
+	metadataConfig  = "config"
+
+	// Timeout of the requests of the store.
+	requestTimeout = 30 * time.Second
+)
+
+var _ secretstores.SecretStore = (*dopplerSecretStore)(nil)
+
+// ErrNotFound is returned when a secret doesn't exist.
+var ErrNotFound = errors.New("secret not found")
+
+// DopplerMetadata is the metadata of the Doppler secret store.
+type DopplerMetadata struct {
+	// Token to authenticate with: a service token, which is scoped to a config,
+	// or a personal or service account token, which requires the project and the config.
+	Token string `mapstructure:"token"`
+	// Project of the secrets.
+	Project string `mapstructure:"project"`
+	// Config of the project, such as dev or prd.
+	Config string `mapstructure:"config"`
+	// URL of the Doppler API; defaults to https://api.doppler.com.
+	APIURL string `mapstructure:"apiUrl"`
+}
+
+// dopplerSecretStore is a secret store implementation for Doppler.
+type dopplerSecretStore struct {
+	client  *http.Client
+	apiURL  string
+	token   string
+	project string
+	config  string
+
+	logger logger.Logger
+}
+
+// NewDopplerSecretStore returns a new Doppler secret store.
+func NewDopplerSecretStore(logger logger.Logger) secretstores.SecretStore {
+	return &dopplerSecretStore{
+		client: &http.Client{Timeout: requestTimeout},
+		logger: logger,
+	}
+}
+
+// Init validates the metadata.
+func (d *dopplerSecretStore) Init(meta secretstores.Metadata) error {
+	m := DopplerMetadata{
+		APIURL: defaultAPIURL,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.Token == "" {
+		return errors.New("doppler init error: token is required")
+	}
+	if (m.Project == "") != (m.Config == "") {
+		return errors.New("doppler init error: project and config must be set together")
+	}
+	if m.APIURL == "" {
+		m.APIURL = defaultAPIURL
+	}
+
+	d.apiURL = strings.TrimSuffix(m.APIURL, "/")
+	d.token = m.Token
+	d.project = m.Project
+	d.config = m.Config
+
+	return nil
+}
+
+type secretValue struct {
+	Raw      *string `json:"raw"`
+	Computed *string `json:"computed"`
+}
+
+// value returns the computed value of the secret, in which the references to other secrets are resolved.
+func (v secretValue) value() string {
+	if v.Computed != nil {
+		return *v.Computed
+	}
+	if v.Raw != nil {
+		return *v.Raw
+	}
+	return ""
+}
+
+type getSecretResponse struct {
+	Name  string      `json:"name"`
+	Value secretValue `json:"value"`
+}
+
+// GetSecret returns the value of a secret of the config as a map with the name of the secret as key.
+func (d *dopplerSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	query, err := d.scope(req.Metadata)
+	if err != nil {
+		return secretstores.GetSecretResponse{Data: nil}, err
+	}
+	query.Set("name", req.Name)
+
+	var res getSecretResponse
+	err = d.get(ctx, "/v3/configs/config/secret", query, &res)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("getSecret %s failed %w", req.Name, ErrNotFound)
+		}
+		return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("couldn't get secret %s: %w", req.Name, err)
+	}
+
+	return secretstores.GetSecretResponse{
+		Data: map[string]string{req.Name: res.Value.value()},
+	}, nil
+}
+
+// BulkGetSecret returns all the secrets of the config, which are downloaded at once, so they are a consistent snapshot of the config.
+func (d *dopplerSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	query, err := d.scope(req.Metadata)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, err
+	}
+	query.Set("format", "json")
+
+	var res map[string]string
+	err = d.get(ctx, "/v3/configs/config/secrets/download", query, &res)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("couldn't download secrets: %w", err)
+	}
+
+	resp := secretstores.BulkGetSecretResponse{
+		Data: make(map[string]map[string]string, len(res)),
+	}
+	for name, value := range res {
+		resp.Data[name] = map[string]string{name: value}
+	}
+
+	return resp, nil
+}
+
+// scope returns the query parameters with the project and the config of a request.
+// They can be omitted only with service tokens, which are scoped to a config.
+func (d *dopplerSecretStore) scope(reqMetadata map[string]string) (url.Values, error) {
+	project, config := d.project, d.config
+	if v := reqMetadata[metadataProject]; v != "" {
+		project = v
+	}
+	if v := reqMetadata[metadataConfig]; v != "" {
+		config = v
+	}
+	if (project == "") != (config == "") {
+		return nil, errors.New("project and config must be set together")
+	}
+
+	query := url.Values{}
+	if project != "" {
+		query.Set("project", project)
+		query.Set("config", config)
+	}
+	return query, nil
+}
+
+type errorResponse struct {
+	Messages []string `json:"messages"`
+}
+
+// get sends a request to an endpoint of the API, and decodes its response.
+func (d *dopplerSecretStore) get(ctx context.Context, path string, query url.Values, res any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, d.apiURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+d.token)
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := d.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		if httpResp.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		var errRes errorResponse
+		if json.Unmarshal(respBody, &errRes) == nil && len(errRes.Messages) > 0 {
+			return fmt.Errorf("status code %d: %s", httpResp.StatusCode, strings.Join(errRes.Messages, "; "))
+		}
+		return fmt.Errorf("status code %d, body %s", httpResp.StatusCode, string(respBody))
+	}
+
+	err = json.NewDecoder(httpResp.Body).Decode(res)
+	if err != nil {
+		return fmt.Errorf("couldn't decode response body: %w", err)
+	}
+	return nil
+}
+
+// Features returns the features available in this secret store.
+func (d *dopplerSecretStore) Features() []secretstores.Feature {
+	return []secretstores.Feature{}
+}
+
+func (d *dopplerSecretStore) GetComponentMetadata() map[string]string {
+	metadataStruct := DopplerMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+func (d *dopplerSecretStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doppler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const testToken = "dp.pt.test"
+
+// newFakeDoppler returns a server with the secrets of the configs by project and config.
+func newFakeDoppler(t *testing.T, configs map[string]map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]any{"messages": []string{"Invalid auth token"}, "success": false})
+			return
+		}
+		q := r.URL.Query()
+		secrets, ok := configs[q.Get("project")+"/"+q.Get("config")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v3/configs/config/secret":
+			v, ok := secrets[q.Get("name")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"name":  q.Get("name"),
+				"value": map[string]string{"raw": "raw-" + v, "computed": v},
+			})
+		case "/v3/configs/config/secrets/download":
+			assert.Equal(t, "json", q.Get("format"))
+			json.NewEncoder(w).Encode(secrets)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestStore(t *testing.T, serverURL string, props map[string]string) (*dopplerSecretStore, error) {
+	t.Helper()
+	m := secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"token":  testToken,
+		"apiUrl": serverURL,
+	}}}
+	for k, v := range props {
+		m.Properties[k] = v
+	}
+	s := NewDopplerSecretStore(logger.NewLogger("test")).(*dopplerSecretStore)
+	return s, s.Init(m)
+}
+
+func TestInit(t *testing.T) {
+	t.Run("missing token", func(t *testing.T) {
+		s := NewDopplerSecretStore(logger.NewLogger("test"))
+		err := s.Init(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		assert.ErrorContains(t, err, "token is required")
+	})
+
+	t.Run("project without config", func(t *testing.T) {
+		_, err := newTestStore(t, "", map[string]string{"project": "app"})
+		assert.ErrorContains(t, err, "project and config must be set together")
+	})
+
+	t.Run("default api url", func(t *testing.T) {
+		s, err := newTestStore(t, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, defaultAPIURL, s.apiURL)
+	})
+}
+
+func TestGetSecret(t *testing.T) {
+	server := newFakeDoppler(t, map[string]map[string]string{
+		"app/dev": {"DB_PASSWORD": "dev-pass"},
+		"app/prd": {"DB_PASSWORD": "prd-pass"},
+		"/":       {"DB_PASSWORD": "scoped-pass"},
+	})
+	defer server.Close()
+
+	s, err := newTestStore(t, server.URL, map[string]string{"project": "app", "config": "dev"})
+	require.NoError(t, err)
+
+	t.Run("computed value", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "DB_PASSWORD"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DB_PASSWORD": "dev-pass"}, res.Data)
+	})
+
+	t.Run("config from request metadata", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "DB_PASSWORD",
+			Metadata: map[string]string{"config": "prd"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DB_PASSWORD": "prd-pass"}, res.Data)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "MISSING"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("service token scoped to a config", func(t *testing.T) {
+		s, err := newTestStore(t, server.URL, nil)
+		require.NoError(t, err)
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "DB_PASSWORD"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DB_PASSWORD": "scoped-pass"}, res.Data)
+
+		_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "DB_PASSWORD",
+			Metadata: map[string]string{"project": "app"},
+		})
+		assert.ErrorContains(t, err, "project and config must be set together")
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		s, err := newTestStore(t, server.URL, map[string]string{"token": "invalid"})
+		require.NoError(t, err)
+		_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "DB_PASSWORD"})
+		assert.ErrorContains(t, err, "Invalid auth token")
+	})
+}
+
+func TestBulkGetSecret(t *testing.T) {
+	server := newFakeDoppler(t, map[string]map[string]string{
+		"app/dev": {"DB_PASSWORD": "dev-pass", "API_KEY": "dev-key"},
+	})
+	defer server.Close()
+
+	s, err := newTestStore(t, server.URL, map[string]string{"project": "app", "config": "dev"})
+	require.NoError(t, err)
+
+	res, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"DB_PASSWORD": {"DB_PASSWORD": "dev-pass"},
+		"API_KEY":     {"API_KEY": "dev-key"},
+	}, res.Data)
+
+	_, err = s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+		Metadata: map[string]string{"config": "stg"},
+	})
+	assert.Error(t, err)
+}
+
+func TestGetComponentMetadata(t *testing.T) {
+	s := NewDopplerSecretStore(logger.NewLogger("test"))
+	m := s.GetComponentMetadata()
+	assert.Contains(t, m, "token")
+	assert.Contains(t, m, "project")
+	assert.Contains(t, m, "config")
+}
@@ -13,6 +13,8 @@ limitations under the License.
 
 package secretstores
 
+import "github.com/dapr/components-contrib/metadata"
+
 // Feature names a feature that can be implemented by Secret Store components.
 type Feature string
 
@@ -31,3 +33,15 @@ func (f Feature) IsPresent(features []Feature) bool {
 
 	return false
 }
+
+// FeatureCapabilities returns the capabilities declared in the component metadata for the features.
+func FeatureCapabilities(features []Feature) []metadata.Capability {
+	capabilities := make([]metadata.Capability, 0, len(features))
+	for _, f := range features {
+		if f == FeatureMultipleKeyValuesPerSecret {
+			capabilities = append(capabilities, metadata.CapabilityMultipleKeyValuesPerSecret)
+		}
+	}
+
+	return capabilities
+}
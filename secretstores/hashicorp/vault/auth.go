@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+type authMethod string
+
+const (
+	authMethodToken      authMethod = "token"
+	authMethodAppRole    authMethod = "approle"
+	authMethodKubernetes authMethod = "kubernetes"
+
+	defaultKubernetesTokenPath string = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// Timeout for login and renewal requests.
+	authRequestTimeout = 30 * time.Second
+	// Delay before retrying after a failed renewal or login.
+	authRetryInterval = 10 * time.Second
+)
+
+// vaultAuthResponse is the response data from Vault login and token renewal endpoints.
+type vaultAuthResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+func (r *vaultAuthResponse) leaseDuration() time.Duration {
+	return time.Duration(r.Auth.LeaseDuration) * time.Second
+}
+
+// initAuth validates the configuration of the selected auth method and, for methods other than token, logs in to Vault
+// and starts renewing the token in background.
+func (v *vaultSecretStore) initAuth(m *VaultMetadata) error {
+	v.authMethod = authMethodToken
+	if m.VaultAuthMethod != "" {
+		v.authMethod = authMethod(m.VaultAuthMethod)
+	}
+
+	v.authMountPath = m.VaultAuthMountPath
+	switch v.authMethod {
+	case authMethodToken:
+		v.vaultToken = m.VaultToken
+		v.vaultTokenMountPath = m.VaultTokenMountPath
+		return v.initVaultToken()
+	case authMethodAppRole:
+		if m.VaultRoleID == "" || m.VaultSecretID == "" {
+			return fmt.Errorf("vaultRoleID and vaultSecretID are required for auth method %s", v.authMethod)
+		}
+		v.roleID = m.VaultRoleID
+		v.secretID = m.VaultSecretID
+	case authMethodKubernetes:
+		if m.VaultKubernetesRole == "" {
+			return fmt.Errorf("vaultKubernetesRole is required for auth method %s", v.authMethod)
+		}
+		v.kubernetesRole = m.VaultKubernetesRole
+		v.kubernetesTokenPath = m.VaultKubernetesTokenPath
+		if v.kubernetesTokenPath == "" {
+			v.kubernetesTokenPath = defaultKubernetesTokenPath
+		}
+	default:
+		return fmt.Errorf("vault init error, invalid auth method %s, accepted values are token, approle or kubernetes", m.VaultAuthMethod)
+	}
+	if v.authMountPath == "" {
+		v.authMountPath = string(v.authMethod)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authRequestTimeout)
+	auth, err := v.login(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	v.setToken(auth.Auth.ClientToken)
+
+	v.wg.Add(1)
+	go v.renewToken(auth.leaseDuration(), auth.Auth.Renewable)
+
+	return nil
+}
+
+// login authenticates with Vault using the configured auth method and returns the new client token.
+func (v *vaultSecretStore) login(ctx context.Context) (*vaultAuthResponse, error) {
+	body := map[string]string{}
+	switch v.authMethod {
+	case authMethodAppRole:
+		body["role_id"] = v.roleID
+		body["secret_id"] = v.secretID
+	case authMethodKubernetes:
+		jwt, err := os.ReadFile(v.kubernetesTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read service account token from %s: %w", v.kubernetesTokenPath, err)
+		}
+		body["role"] = v.kubernetesRole
+		body["jwt"] = string(bytes.TrimSpace(jwt))
+		v.redactor.Add(body["jwt"])
+	}
+
+	loginAddr := fmt.Sprintf("%s/v1/auth/%s/login", v.vaultAddress, v.authMountPath)
+
+	return v.doAuthRequest(ctx, loginAddr, "", body)
+}
+
+// renewSelf extends the lease of the current token.
+func (v *vaultSecretStore) renewSelf(ctx context.Context) (*vaultAuthResponse, error) {
+	renewAddr := fmt.Sprintf("%s/v1/auth/token/renew-self", v.vaultAddress)
+
+	return v.doAuthRequest(ctx, renewAddr, v.getToken(), map[string]string{})
+}
+
+func (v *vaultSecretStore) doAuthRequest(ctx context.Context, addr string, token string, body map[string]string) (*vaultAuthResponse, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, addr, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate request: %w", err)
+	}
+	if token != "" {
+		httpReq.Header.Set(vaultHTTPHeader, token)
+	}
+	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't authenticate: %w", err)
+	}
+
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		var b bytes.Buffer
+		io.Copy(&b, httpresp.Body)
+		return nil, fmt.Errorf("couldn't authenticate with auth method %s, status code %d, body %s",
+			v.authMethod, httpresp.StatusCode, b.String())
+	}
+
+	var d vaultAuthResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("couldn't decode response body: %w", err)
+	}
+	if d.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("no client token returned by auth method %s", v.authMethod)
+	}
+
+	return &d, nil
+}
+
+// renewToken keeps the client token valid, renewing it when two thirds of its lease have elapsed.
+// Tokens that are not renewable, or whose renewal fails, are replaced by logging in again.
+func (v *vaultSecretStore) renewToken(leaseDuration time.Duration, renewable bool) {
+	defer v.wg.Done()
+
+	// Tokens without a lease never expire
+	if leaseDuration <= 0 {
+		return
+	}
+
+	wait := leaseDuration * 2 / 3
+	for {
+		select {
+		case <-v.closeCh:
+			return
+		case <-time.After(wait):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), authRequestTimeout)
+		var (
+			auth *vaultAuthResponse
+			err  error
+		)
+		if renewable {
+			auth, err = v.renewSelf(ctx)
+			if err != nil {
+				v.logger.Warnf("failed to renew vault token, logging in again: %v", err)
+			}
+		}
+		if auth == nil {
+			auth, err = v.login(ctx)
+		}
+		cancel()
+
+		if err != nil {
+			v.logger.Errorf("failed to refresh vault token, retrying in %v: %v", authRetryInterval, err)
+			wait = authRetryInterval
+			continue
+		}
+
+		v.setToken(auth.Auth.ClientToken)
+		renewable = auth.Auth.Renewable
+		if auth.leaseDuration() <= 0 {
+			return
+		}
+		wait = auth.leaseDuration() * 2 / 3
+	}
+}
+
+func (v *vaultSecretStore) getToken() string {
+	v.tokenLock.RLock()
+	defer v.tokenLock.RUnlock()
+
+	return v.vaultToken
+}
+
+func (v *vaultSecretStore) setToken(token string) {
+	v.redactor.Add(token)
+	v.tokenLock.Lock()
+	v.vaultToken = token
+	v.tokenLock.Unlock()
+}
@@ -22,15 +22,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/net/http2"
 
+	"github.com/dapr/components-contrib/internal/httputils"
+	"github.com/dapr/components-contrib/internal/redact"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -84,9 +88,21 @@ type vaultSecretStore struct {
 	vaultEnginePath     string
 	vaultValueType      valueType
 
+	authMethod          authMethod
+	authMountPath       string
+	roleID              string
+	secretID            string
+	kubernetesRole      string
+	kubernetesTokenPath string
+	tokenLock           sync.RWMutex
+	closeCh             chan struct{}
+	closeOnce           sync.Once
+	wg                  sync.WaitGroup
+
 	json jsoniter.API
 
-	logger logger.Logger
+	logger   logger.Logger
+	redactor *redact.Redactor
 }
 
 type VaultMetadata struct {
@@ -98,10 +114,17 @@ type VaultMetadata struct {
 	VaultAddr           string
 	VaultKVPrefix       string
 	VaultKVUsePrefix    bool
-	VaultToken          string
+	VaultToken          string `sensitive:"true"`
 	VaultTokenMountPath string
 	EnginePath          string
 	VaultValueType      string
+	// Auth method used to obtain the token: token (default), approle or kubernetes.
+	VaultAuthMethod          string
+	VaultAuthMountPath       string
+	VaultRoleID              string
+	VaultSecretID            string `sensitive:"true"`
+	VaultKubernetesRole      string
+	VaultKubernetesTokenPath string
 }
 
 // tlsConfig is TLS configuration to interact with HashiCorp Vault.
@@ -130,9 +153,10 @@ type vaultListKVResponse struct {
 // NewHashiCorpVaultSecretStore returns a new HashiCorp Vault secret store.
 func NewHashiCorpVaultSecretStore(logger logger.Logger) secretstores.SecretStore {
 	return &vaultSecretStore{
-		client: &http.Client{},
-		logger: logger,
-		json:   jsoniter.ConfigFastest,
+		client:  &http.Client{},
+		closeCh: make(chan struct{}),
+		logger:  logger,
+		json:    jsoniter.ConfigFastest,
 	}
 }
 
@@ -146,6 +170,10 @@ func (v *vaultSecretStore) Init(meta secretstores.Metadata) error {
 		return err
 	}
 
+	// The tokens obtained from Vault are added to the redactor as well
+	v.redactor = redact.New(meta.Properties, &m)
+	v.logger = v.redactor.Logger(v.logger)
+
 	// Get Vault address
 	address := m.VaultAddr
 	if address == "" {
@@ -170,13 +198,6 @@ func (v *vaultSecretStore) Init(meta secretstores.Metadata) error {
 		}
 	}
 
-	v.vaultToken = m.VaultToken
-	v.vaultTokenMountPath = m.VaultTokenMountPath
-	initErr := v.initVaultToken()
-	if initErr != nil {
-		return initErr
-	}
-
 	vaultKVPrefix := m.VaultKVPrefix
 	if !m.VaultKVUsePrefix {
 		vaultKVPrefix = ""
@@ -188,14 +209,14 @@ func (v *vaultSecretStore) Init(meta secretstores.Metadata) error {
 	// Generate TLS config
 	tlsConf := metadataToTLSConfig(&m)
 
-	client, err := v.createHTTPClient(tlsConf)
+	client, err := v.createHTTPClient(tlsConf, meta.Properties)
 	if err != nil {
 		return fmt.Errorf("couldn't create client using config: %w", err)
 	}
 
 	v.client = client
 
-	return nil
+	return v.initAuth(&m)
 }
 
 func metadataToTLSConfig(meta *VaultMetadata) *tlsConfig {
@@ -226,7 +247,7 @@ func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string
 		return nil, fmt.Errorf("couldn't generate request: %w", err)
 	}
 	// Set vault token.
-	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
+	httpReq.Header.Set(vaultHTTPHeader, v.getToken())
 	// Set X-Vault-Request header
 	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
 
@@ -345,7 +366,7 @@ func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) (
 		return nil, fmt.Errorf("couldn't generate request: %s", err)
 	}
 	// Set vault token.
-	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
+	httpReq.Header.Set(vaultHTTPHeader, v.getToken())
 	// Set X-Vault-Request header
 	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
 	httpresp, err := v.client.Do(httpReq)
@@ -411,11 +432,12 @@ func (v *vaultSecretStore) initVaultToken() error {
 		return fmt.Errorf("couldn't read vault token from mount path %s err: %s", v.vaultTokenMountPath, err)
 	}
 	v.vaultToken = string(bytes.TrimSpace(data))
+	v.redactor.Add(v.vaultToken)
 
 	return nil
 }
 
-func (v *vaultSecretStore) createHTTPClient(config *tlsConfig) (*http.Client, error) {
+func (v *vaultSecretStore) createHTTPClient(config *tlsConfig, properties map[string]string) (*http.Client, error) {
 	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
 
 	tlsClientConfig.InsecureSkipVerify = config.vaultSkipVerify
@@ -436,9 +458,13 @@ func (v *vaultSecretStore) createHTTPClient(config *tlsConfig) (*http.Client, er
 	transport := &http.Transport{
 		TLSClientConfig: tlsClientConfig,
 	}
+	err := httputils.ConfigureTransport(transport, &net.Dialer{}, properties)
+	if err != nil {
+		return nil, err
+	}
 
 	// Configure http2 client
-	err := http2.ConfigureTransport(transport)
+	err = http2.ConfigureTransport(transport)
 	if err != nil {
 		return nil, errors.New("failed to configure http2")
 	}
@@ -517,6 +543,18 @@ func readCertificateFolder(certPool *x509.CertPool, path string) error {
 	return nil
 }
 
+// Close stops the background token renewal.
+func (v *vaultSecretStore) Close() error {
+	v.closeOnce.Do(func() {
+		if v.closeCh != nil {
+			close(v.closeCh)
+		}
+	})
+	v.wg.Wait()
+
+	return nil
+}
+
 // Features returns the features available in this secret store.
 func (v *vaultSecretStore) Features() []secretstores.Feature {
 	if v.vaultValueType == valueTypeText {
@@ -532,3 +570,10 @@ func (v *vaultSecretStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (v *vaultSecretStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: secretstores.FeatureCapabilities(v.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken, metadata.AuthProfileAppRole, metadata.AuthProfileKubernetes},
+	}
+}
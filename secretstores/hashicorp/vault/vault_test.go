@@ -14,12 +14,18 @@ limitations under the License.
 package vault
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
@@ -454,3 +460,113 @@ func TestGetFeatures(t *testing.T) {
 		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
 	})
 }
+
+func TestVaultAuthMethods(t *testing.T) {
+	var loginBody map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login", "/v1/auth/k8s/login":
+			loginBody = nil
+			json.NewDecoder(r.Body).Decode(&loginBody)
+			w.Write([]byte(`{"auth":{"client_token":"s.logged-in","lease_duration":3600,"renewable":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("approle", func(t *testing.T) {
+		m := secretstores.Metadata{
+			Base: metadata.Base{Properties: map[string]string{
+				componentVaultAddress: server.URL,
+				"vaultAuthMethod":     "approle",
+				"vaultRoleID":         "my-role",
+				"vaultSecretID":       "my-secret",
+			}},
+		}
+
+		target := NewHashiCorpVaultSecretStore(logger.NewLogger("test")).(*vaultSecretStore)
+		defer target.Close()
+		err := target.Init(m)
+		assert.NoError(t, err)
+		assert.Equal(t, "s.logged-in", target.getToken())
+		assert.Equal(t, map[string]string{"role_id": "my-role", "secret_id": "my-secret"}, loginBody)
+	})
+
+	t.Run("kubernetes with custom mount path", func(t *testing.T) {
+		jwtPath, cleanUpFunc := createTempFileWithContent(t, "service-account-jwt\n")
+		defer cleanUpFunc()
+
+		m := secretstores.Metadata{
+			Base: metadata.Base{Properties: map[string]string{
+				componentVaultAddress:      server.URL,
+				"vaultAuthMethod":          "kubernetes",
+				"vaultAuthMountPath":       "k8s",
+				"vaultKubernetesRole":      "dapr",
+				"vaultKubernetesTokenPath": jwtPath,
+			}},
+		}
+
+		target := NewHashiCorpVaultSecretStore(logger.NewLogger("test")).(*vaultSecretStore)
+		defer target.Close()
+		err := target.Init(m)
+		assert.NoError(t, err)
+		assert.Equal(t, "s.logged-in", target.getToken())
+		assert.Equal(t, map[string]string{"role": "dapr", "jwt": "service-account-jwt"}, loginBody)
+	})
+
+	t.Run("approle without secret id", func(t *testing.T) {
+		m := secretstores.Metadata{
+			Base: metadata.Base{Properties: map[string]string{
+				componentVaultAddress: server.URL,
+				"vaultAuthMethod":     "approle",
+				"vaultRoleID":         "my-role",
+			}},
+		}
+
+		target := NewHashiCorpVaultSecretStore(logger.NewLogger("test"))
+		err := target.Init(m)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid auth method", func(t *testing.T) {
+		m := secretstores.Metadata{
+			Base: metadata.Base{Properties: map[string]string{
+				componentVaultAddress: server.URL,
+				"vaultAuthMethod":     "ldap",
+			}},
+		}
+
+		target := NewHashiCorpVaultSecretStore(logger.NewLogger("test"))
+		err := target.Init(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestVaultRedactsTokenInLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+	}))
+	defer server.Close()
+
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger("test")
+	log.SetOutput(buf)
+	log.SetOutputLevel(logger.DebugLevel)
+
+	m := secretstores.Metadata{
+		Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress: server.URL,
+			componentVaultToken:   expectedTok,
+		}},
+	}
+	target := NewHashiCorpVaultSecretStore(log)
+	err := target.Init(m)
+	require.NoError(t, err)
+
+	_, err = target.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "couldn't get successful response")
+	assert.NotContains(t, buf.String(), expectedTok)
+}
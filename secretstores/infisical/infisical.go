@@ -0,0 +1,332 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infisical
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultSiteURL    = "https://app.infisical.com"
+	defaultSecretPath = "/"
+
+	// Request metadata that override the environment and the folder of the component.
+	metadataEnvironment = "environment"
+	metadataSecretPath  = "secretPath"
+	// VersionID is the request metadata with the version of the secret to read; the latest version by default.
+	VersionID = "version_id"
+
+	// Timeout of the requests of the store.
+	requestTimeout = 30 * time.Second
+	// Access tokens are renewed this long before they expire.
+	tokenExpiryMargin = 30 * time.Second
+)
+
+var _ secretstores.SecretStore = (*infisicalSecretStore)(nil)
+
+// ErrNotFound is returned when a secret doesn't exist.
+var ErrNotFound = errors.New("secret not found")
+
+// InfisicalMetadata is the metadata of the Infisical secret store.
+type InfisicalMetadata struct {
+	// URL of Infisical; defaults to https://app.infisical.com.
+	SiteURL string `mapstructure:"siteUrl"`
+	// Client ID and client secret of the universal auth of the machine identity.
+	ClientID     string `mapstructure:"clientId"`
+	ClientSecret string `mapstructure:"clientSecret"`
+	// ID of the project of the secrets.
+	ProjectID string `mapstructure:"projectId"`
+	// Slug of the environment of the secrets, such as dev or prod.
+	Environment string `mapstructure:"environment"`
+	// Folder of the secrets; defaults to the root folder.
+	SecretPath string `mapstructure:"secretPath"`
+}
+
+// infisicalSecretStore is a secret store implementation for Infisical.
+type infisicalSecretStore struct {
+	client       *http.Client
+	siteURL      string
+	clientID     string
+	clientSecret string
+	projectID    string
+	environment  string
+	secretPath   string
+
+	tokenLock    sync.Mutex
+	token        string
+	tokenExpires time.Time
+
+	logger logger.Logger
+}
+
+// NewInfisicalSecretStore returns a new Infisical secret store.
+func NewInfisicalSecretStore(logger logger.Logger) secretstores.SecretStore {
+	return &infisicalSecretStore{
+		client: &http.Client{Timeout: requestTimeout},
+		logger: logger,
+	}
+}
+
+// Init validates the metadata and logs in with the machine identity.
+func (s *infisicalSecretStore) Init(meta secretstores.Metadata) error {
+	m := InfisicalMetadata{}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case m.ClientID == "":
+		return errors.New("clientId is required")
+	case m.ClientSecret == "":
+		return errors.New("clientSecret is required")
+	case m.ProjectID == "":
+		return errors.New("projectId is required")
+	case m.Environment == "":
+		return errors.New("environment is required")
+	}
+	if m.SiteURL == "" {
+		m.SiteURL = defaultSiteURL
+	}
+	if m.SecretPath == "" {
+		m.SecretPath = defaultSecretPath
+	}
+
+	s.siteURL = strings.TrimSuffix(m.SiteURL, "/")
+	s.clientID = m.ClientID
+	s.clientSecret = m.ClientSecret
+	s.projectID = m.ProjectID
+	s.environment = m.Environment
+	s.secretPath = m.SecretPath
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err = s.accessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("infisical init error: %w", err)
+	}
+
+	return nil
+}
+
+type secret struct {
+	SecretKey   string `json:"secretKey"`
+	SecretValue string `json:"secretValue"`
+	Version     int    `json:"version"`
+}
+
+// GetSecret returns the value of a secret as a map with the name of the secret as key.
+// The environment, secretPath and version_id request metadata select the environment, folder and version of the secret.
+func (s *infisicalSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	q := s.scope(req.Metadata)
+	if v := req.Metadata[VersionID]; v != "" {
+		if _, err := strconv.Atoi(v); err != nil {
+			return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("invalid %s %s: must be a number", VersionID, v)
+		}
+		q.Set("version", v)
+	}
+
+	var res struct {
+		Secret secret `json:"secret"`
+	}
+	err := s.get(ctx, "/api/v3/secrets/raw/"+url.PathEscape(req.Name), q, &res)
+	if err != nil {
+		return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("couldn't get secret %s: %w", req.Name, err)
+	}
+
+	return secretstores.GetSecretResponse{
+		Data: map[string]string{req.Name: res.Secret.SecretValue},
+	}, nil
+}
+
+// BulkGetSecret returns the latest version of the secrets of the folder, without its subfolders.
+// The environment and secretPath request metadata select the environment and folder of the secrets.
+func (s *infisicalSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	var res struct {
+		Secrets []secret `json:"secrets"`
+	}
+	err := s.get(ctx, "/api/v3/secrets/raw", s.scope(req.Metadata), &res)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("couldn't list secrets: %w", err)
+	}
+
+	data := make(map[string]map[string]string, len(res.Secrets))
+	for _, sec := range res.Secrets {
+		data[sec.SecretKey] = map[string]string{sec.SecretKey: sec.SecretValue}
+	}
+	return secretstores.BulkGetSecretResponse{Data: data}, nil
+}
+
+// scope returns the query parameters of the project, environment and folder of a request.
+func (s *infisicalSecretStore) scope(meta map[string]string) url.Values {
+	environment, secretPath := s.environment, s.secretPath
+	if v := meta[metadataEnvironment]; v != "" {
+		environment = v
+	}
+	if v := meta[metadataSecretPath]; v != "" {
+		secretPath = v
+	}
+
+	q := url.Values{}
+	q.Set("workspaceId", s.projectID)
+	q.Set("environment", environment)
+	q.Set("secretPath", secretPath)
+	return q
+}
+
+// get sends a request to the API with an access token, and logs in again if the token has been revoked.
+func (s *infisicalSecretStore) get(ctx context.Context, path string, q url.Values, res any) error {
+	for attempt := 0; ; attempt++ {
+		token, err := s.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+		err = s.do(ctx, http.MethodGet, path+"?"+q.Encode(), token, nil, res)
+		var apiErr *apiError
+		if attempt == 0 && errors.As(err, &apiErr) && apiErr.statusCode == http.StatusUnauthorized {
+			s.invalidateToken(token)
+			continue
+		}
+		return err
+	}
+}
+
+type loginResponse struct {
+	AccessToken string `json:"accessToken"`
+	// Validity of the token in seconds.
+	ExpiresIn int `json:"expiresIn"`
+}
+
+// accessToken returns the cached access token, or logs in when it has expired.
+func (s *infisicalSecretStore) accessToken(ctx context.Context) (string, error) {
+	s.tokenLock.Lock()
+	defer s.tokenLock.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpires) {
+		return s.token, nil
+	}
+
+	body := map[string]string{
+		"clientId":     s.clientID,
+		"clientSecret": s.clientSecret,
+	}
+	var res loginResponse
+	err := s.do(ctx, http.MethodPost, "/api/v1/auth/universal-auth/login", "", body, &res)
+	if err != nil {
+		return "", fmt.Errorf("couldn't log in with the machine identity: %w", err)
+	}
+	if res.AccessToken == "" {
+		return "", errors.New("couldn't log in with the machine identity: empty access token")
+	}
+
+	s.token = res.AccessToken
+	s.tokenExpires = time.Now().Add(time.Duration(res.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return s.token, nil
+}
+
+// invalidateToken removes the token from the cache, unless it has already been replaced.
+func (s *infisicalSecretStore) invalidateToken(token string) {
+	s.tokenLock.Lock()
+	defer s.tokenLock.Unlock()
+
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// apiError is the response of a failed request.
+type apiError struct {
+	statusCode int
+	body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("couldn't get successful response, status code %d, body %s", e.statusCode, e.body)
+}
+
+// do sends a request to the API, and decodes its response.
+func (s *infisicalSecretStore) do(ctx context.Context, method string, path string, token string, body any, res any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, s.siteURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		if httpResp.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return &apiError{statusCode: httpResp.StatusCode, body: string(respBody)}
+	}
+
+	err = json.NewDecoder(httpResp.Body).Decode(res)
+	if err != nil {
+		return fmt.Errorf("couldn't decode response body: %w", err)
+	}
+	return nil
+}
+
+// Features returns the features available in this secret store.
+func (s *infisicalSecretStore) Features() []secretstores.Feature {
+	return []secretstores.Feature{}
+}
+
+func (s *infisicalSecretStore) GetComponentMetadata() map[string]string {
+	metadataStruct := InfisicalMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+func (s *infisicalSecretStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileOAuth2},
+	}
+}
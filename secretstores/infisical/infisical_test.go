@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infisical
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeInfisical is a fake of the operations of the Infisical API used by the store.
+// Secrets are keyed by environment, path and name, and have their versions in order.
+type fakeInfisical struct {
+	lock    sync.Mutex
+	logins  int
+	revoked map[string]bool
+	secrets map[string][]string
+}
+
+func newFakeInfisical() *fakeInfisical {
+	return &fakeInfisical{
+		revoked: map[string]bool{},
+		secrets: map[string][]string{
+			"dev|/|db-password":     {"v1", "v2"},
+			"dev|/|api-key":         {"key1"},
+			"prod|/|db-password":    {"prod1"},
+			"dev|/payments|api-key": {"pay1"},
+		},
+	}
+}
+
+func (f *fakeInfisical) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.URL.Path == "/api/v1/auth/universal-auth/login" {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["clientId"] != "id" || body["clientSecret"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"invalid credentials"}`)
+			return
+		}
+		f.logins++
+		json.NewEncoder(w).Encode(map[string]any{"accessToken": fmt.Sprintf("t-%d", f.logins), "expiresIn": 7200})
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || f.revoked[token] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("workspaceId") != "proj" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	scope := q.Get("environment") + "|" + q.Get("secretPath") + "|"
+
+	if r.URL.Path == "/api/v3/secrets/raw" {
+		secrets := []map[string]any{}
+		for k, versions := range f.secrets {
+			if strings.HasPrefix(k, scope) {
+				secrets = append(secrets, map[string]any{"secretKey": k[len(scope):], "secretValue": versions[len(versions)-1], "version": len(versions)})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"secrets": secrets})
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/v3/secrets/raw/")
+	versions, ok := f.secrets[scope+name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	version := len(versions)
+	if v := q.Get("version"); v != "" {
+		fmt.Sscan(v, &version)
+		if version < 1 || version > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"secret": map[string]any{"secretKey": name, "secretValue": versions[version-1], "version": version}})
+}
+
+func newTestStore(t *testing.T, fake *fakeInfisical, props map[string]string) (*infisicalSecretStore, error) {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	m := secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"siteUrl":      server.URL,
+		"clientId":     "id",
+		"clientSecret": "secret",
+		"projectId":    "proj",
+		"environment":  "dev",
+	}}}
+	for k, v := range props {
+		m.Properties[k] = v
+	}
+	s := NewInfisicalSecretStore(logger.NewLogger("test")).(*infisicalSecretStore)
+	return s, s.Init(m)
+}
+
+func TestInit(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		fake := newFakeInfisical()
+		s, err := newTestStore(t, fake, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fake.logins)
+		assert.Equal(t, "/", s.secretPath)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		_, err := newTestStore(t, newFakeInfisical(), map[string]string{"clientSecret": "wrong"})
+		assert.ErrorContains(t, err, "invalid credentials")
+	})
+
+	for _, name := range []string{"clientId", "clientSecret", "projectId", "environment"} {
+		t.Run("missing "+name, func(t *testing.T) {
+			_, err := newTestStore(t, newFakeInfisical(), map[string]string{name: ""})
+			assert.ErrorContains(t, err, name+" is required")
+		})
+	}
+}
+
+func TestGetSecret(t *testing.T) {
+	fake := newFakeInfisical()
+	s, err := newTestStore(t, fake, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		secret   string
+		metadata map[string]string
+		expected string
+	}{
+		{name: "latest version", secret: "db-password", expected: "v2"},
+		{name: "version", secret: "db-password", metadata: map[string]string{"version_id": "1"}, expected: "v1"},
+		{name: "environment", secret: "db-password", metadata: map[string]string{"environment": "prod"}, expected: "prod1"},
+		{name: "secret path", secret: "api-key", metadata: map[string]string{"secretPath": "/payments"}, expected: "pay1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: tt.secret, Metadata: tt.metadata})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{tt.secret: tt.expected}, res.Data)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db-password", Metadata: map[string]string{"version_id": "latest"}})
+		assert.ErrorContains(t, err, "invalid version_id latest")
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		fake.lock.Lock()
+		fake.revoked[s.token] = true
+		fake.lock.Unlock()
+
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "api-key"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"api-key": "key1"}, res.Data)
+		assert.Equal(t, 2, fake.logins)
+	})
+}
+
+func TestBulkGetSecret(t *testing.T) {
+	s, err := newTestStore(t, newFakeInfisical(), nil)
+	require.NoError(t, err)
+
+	res, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"db-password": {"db-password": "v2"},
+		"api-key":     {"api-key": "key1"},
+	}, res.Data)
+
+	res, err = s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{Metadata: map[string]string{"secretPath": "/payments"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"api-key": {"api-key": "pay1"}}, res.Data)
+}
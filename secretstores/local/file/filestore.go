@@ -283,3 +283,10 @@ func (j *localSecretStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (j *localSecretStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: secretstores.FeatureCapabilities(j.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
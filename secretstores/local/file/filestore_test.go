@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
 )
@@ -202,6 +203,7 @@ func TestMultiValuedSecrets(t *testing.T) {
 
 	t.Run("MultiValued stores support MULTIPLE_KEY_VALUES_PER_SECRET", func(t *testing.T) {
 		assert.True(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(s.Features()))
+		assert.Equal(t, []metadata.Capability{metadata.CapabilityMultipleKeyValuesPerSecret}, s.ComponentProfile().Capabilities)
 	})
 
 	t.Run("successfully retrieve a single multi-valued secret", func(t *testing.T) {
@@ -0,0 +1,31 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import "github.com/dapr/components-contrib/internal/component"
+
+// SecretStoreResolver returns the initialized secret store with the given component name.
+type SecretStoreResolver func(name string) (SecretStore, error)
+
+// SecretStoreConsumer is implemented by components reading secrets from a secret store after they're initialized,
+// for example to pick up rotated credentials. The secret store is referenced by component name in their metadata.
+// The runtime calls SetSecretStoreResolver before Init, and the component resolves the secret store in Init.
+type SecretStoreConsumer interface {
+	SetSecretStoreResolver(resolver SecretStoreResolver)
+}
+
+// NewMapSecretStoreResolver returns a SecretStoreResolver for a fixed set of secret stores, keyed by component name.
+func NewMapSecretStoreResolver(stores map[string]SecretStore) SecretStoreResolver {
+	return component.NewMapResolver("secret store", stores)
+}
@@ -49,6 +49,7 @@ type Aerospike struct {
 	set       string // optional
 	client    *as.Client
 	json      jsoniter.API
+	keyCodec  *state.KeyCodec
 
 	features []state.Feature
 	logger   logger.Logger
@@ -95,6 +96,9 @@ func (aspike *Aerospike) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if aspike.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	hostsMeta := m.Hosts
 	hostPorts, _ := parseHosts(hostsMeta)
@@ -121,7 +125,7 @@ func (aspike *Aerospike) Set(req *state.SetRequest) error {
 	if err != nil {
 		return err
 	}
-	asKey, err := as.NewKey(aspike.namespace, aspike.set, req.Key)
+	asKey, err := as.NewKey(aspike.namespace, aspike.set, aspike.keyCodec.Encode(req.Key))
 	if err != nil {
 		return err
 	}
@@ -169,7 +173,7 @@ func (aspike *Aerospike) Set(req *state.SetRequest) error {
 
 // Get retrieves state from Aerospike with a key.
 func (aspike *Aerospike) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	asKey, err := as.NewKey(aspike.namespace, aspike.set, req.Key)
+	asKey, err := as.NewKey(aspike.namespace, aspike.set, aspike.keyCodec.Encode(req.Key))
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +231,7 @@ func (aspike *Aerospike) Delete(req *state.DeleteRequest) error {
 		writePolicy.CommitLevel = as.COMMIT_MASTER //nolint:nosnakecase
 	}
 
-	asKey, err := as.NewKey(aspike.namespace, aspike.set, req.Key)
+	asKey, err := as.NewKey(aspike.namespace, aspike.set, aspike.keyCodec.Encode(req.Key))
 	if err != nil {
 		return err
 	}
@@ -276,3 +280,10 @@ func (aspike *Aerospike) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (aspike *Aerospike) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(aspike.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
@@ -35,6 +35,7 @@ type AliCloudTableStore struct {
 	logger   logger.Logger
 	client   tablestore.TableStoreApi
 	metadata tablestoreMetadata
+	keyCodec *state.KeyCodec
 	features []state.Feature
 }
 
@@ -59,6 +60,11 @@ func (s *AliCloudTableStore) Init(metadata state.Metadata) error {
 		return err
 	}
 
+	s.keyCodec, err = state.NewKeyCodec(metadata)
+	if err != nil {
+		return err
+	}
+
 	s.metadata = *m
 	s.client = tablestore.NewClient(m.Endpoint, m.InstanceName, m.AccessKeyID, m.AccessKey)
 
@@ -129,11 +135,12 @@ func (s *AliCloudTableStore) BulkGet(reqs []state.GetRequest) (bool, []state.Bul
 
 	for _, row := range batchGetResp.TableToRowsResult[mqCriteria.TableName] {
 		resp := s.getResp(row.Columns)
+		key, _ := s.keyCodec.Decode(row.PrimaryKey.PrimaryKeys[0].Value.(string))
 
 		responseList = append(responseList, state.BulkGetResponse{
 			Data: resp.Data,
 			ETag: resp.ETag,
-			Key:  row.PrimaryKey.PrimaryKeys[0].Value.(string),
+			Key:  key,
 		})
 	}
 
@@ -243,7 +250,7 @@ func (s *AliCloudTableStore) parse(meta state.Metadata) (*tablestoreMetadata, er
 
 func (s *AliCloudTableStore) primaryKey(key string) *tablestore.PrimaryKey {
 	pk := &tablestore.PrimaryKey{}
-	pk.AddPrimaryKeyColumn(stateKey, key)
+	pk.AddPrimaryKeyColumn(stateKey, s.keyCodec.Encode(key))
 
 	return pk
 }
@@ -254,3 +261,10 @@ func (s *AliCloudTableStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (s *AliCloudTableStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey},
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -33,11 +34,24 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
+const (
+	// ttlAttributeNameKey is the request metadata that overrides the TTL attribute of the component for a request.
+	ttlAttributeNameKey = "ttlAttributeName"
+)
+
 // StateStore is a DynamoDB state store.
 type StateStore struct {
 	client           dynamodbiface.DynamoDBAPI
 	table            string
 	ttlAttributeName string
+	queryableValues  bool
+	keyCodec         *state.KeyCodec
+	// includeExpired disables filtering out the items that expired but haven't been deleted by DynamoDB yet,
+	// which can take up to a few days.
+	includeExpired bool
+
+	indexesLock sync.Mutex
+	indexes     map[string]indexKeys
 }
 
 type dynamoDBMetadata struct {
@@ -48,6 +62,10 @@ type dynamoDBMetadata struct {
 	SessionToken     string `json:"sessionToken"`
 	Table            string `json:"table"`
 	TTLAttributeName string `json:"ttlAttributeName"`
+	// FilterExpiredItems controls whether Get filters out the items that expired but haven't been deleted yet.
+	FilterExpiredItems bool `json:"filterExpiredItems"`
+	// Store the top-level fields of JSON object values as attributes of the items, so that they can be queried.
+	QueryableValues bool `json:"queryableValues"`
 }
 
 // NewDynamoDBStateStore returns a new dynamoDB state store.
@@ -61,6 +79,9 @@ func (d *StateStore) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if d.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	client, err := d.getClient(meta)
 	if err != nil {
@@ -70,13 +91,18 @@ func (d *StateStore) Init(metadata state.Metadata) error {
 	d.client = client
 	d.table = meta.Table
 	d.ttlAttributeName = meta.TTLAttributeName
+	d.includeExpired = !meta.FilterExpiredItems
+	d.queryableValues = meta.QueryableValues
 
 	return nil
 }
 
 // Features returns the features available in this state store.
 func (d *StateStore) Features() []state.Feature {
-	return []state.Feature{state.FeatureETag}
+	if d.queryableValues {
+		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI}
+	}
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
 }
 
 // Get retrieves a dynamoDB item.
@@ -86,7 +112,7 @@ func (d *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		TableName:      aws.String(d.table),
 		Key: map[string]*dynamodb.AttributeValue{
 			"key": {
-				S: aws.String(req.Key),
+				S: aws.String(d.keyCodec.Encode(req.Key)),
 			},
 		},
 	}
@@ -106,8 +132,8 @@ func (d *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	}
 
 	var ttl int64
-	if d.ttlAttributeName != "" {
-		if val, ok := result.Item[d.ttlAttributeName]; ok {
+	if ttlAttributeName := d.getTTLAttributeName(req.Metadata); ttlAttributeName != "" && !d.includeExpired {
+		if val, ok := result.Item[ttlAttributeName]; ok {
 			if err = dynamodbattribute.Unmarshal(val, &ttl); err != nil {
 				return nil, err
 			}
@@ -223,7 +249,7 @@ func (d *StateStore) Delete(req *state.DeleteRequest) error {
 	input := &dynamodb.DeleteItemInput{
 		Key: map[string]*dynamodb.AttributeValue{
 			"key": {
-				S: aws.String(req.Key),
+				S: aws.String(d.keyCodec.Encode(req.Key)),
 			},
 		},
 		TableName: aws.String(d.table),
@@ -267,7 +293,7 @@ func (d *StateStore) BulkDelete(req []state.DeleteRequest) error {
 			DeleteRequest: &dynamodb.DeleteRequest{
 				Key: map[string]*dynamodb.AttributeValue{
 					"key": {
-						S: aws.String(r.Key),
+						S: aws.String(d.keyCodec.Encode(r.Key)),
 					},
 				},
 			},
@@ -292,8 +318,17 @@ func (d *StateStore) GetComponentMetadata() map[string]string {
 	return metadataInfo
 }
 
+func (d *StateStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(d.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAWSIAM},
+	}
+}
+
 func (d *StateStore) getDynamoDBMetadata(meta state.Metadata) (*dynamoDBMetadata, error) {
-	var m dynamoDBMetadata
+	m := dynamoDBMetadata{
+		FilterExpiredItems: true,
+	}
 	err := metadata.DecodeMetadata(meta.Properties, &m)
 	if m.Table == "" {
 		return nil, fmt.Errorf("missing dynamodb table name")
@@ -301,6 +336,14 @@ func (d *StateStore) getDynamoDBMetadata(meta state.Metadata) (*dynamoDBMetadata
 	return &m, err
 }
 
+// getTTLAttributeName returns the TTL attribute of the request, which defaults to the TTL attribute of the component.
+func (d *StateStore) getTTLAttributeName(reqMetadata map[string]string) string {
+	if val := reqMetadata[ttlAttributeNameKey]; val != "" {
+		return val
+	}
+	return d.ttlAttributeName
+}
+
 func (d *StateStore) getClient(metadata *dynamoDBMetadata) (*dynamodb.DynamoDB, error) {
 	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
 	if err != nil {
@@ -318,6 +361,11 @@ func (d *StateStore) getItemFromReq(req *state.SetRequest) (map[string]*dynamodb
 		return nil, fmt.Errorf("dynamodb error: failed to set key %s: %s", req.Key, err)
 	}
 
+	ttlAttributeName := d.getTTLAttributeName(req.Metadata)
+	switch ttlAttributeName {
+	case "key", "value", "etag":
+		return nil, fmt.Errorf("dynamodb error: invalid %s %s: the attribute is reserved", ttlAttributeNameKey, ttlAttributeName)
+	}
 	ttl, err := d.parseTTL(req)
 	if err != nil {
 		return nil, fmt.Errorf("dynamodb error: failed to parse ttlInSeconds: %s", err)
@@ -329,7 +377,7 @@ func (d *StateStore) getItemFromReq(req *state.SetRequest) (map[string]*dynamodb
 	}
 	item := map[string]*dynamodb.AttributeValue{
 		"key": {
-			S: aws.String(req.Key),
+			S: aws.String(d.keyCodec.Encode(req.Key)),
 		},
 		"value": {
 			S: aws.String(value),
@@ -340,14 +388,53 @@ func (d *StateStore) getItemFromReq(req *state.SetRequest) (map[string]*dynamodb
 	}
 
 	if ttl != nil {
-		item[d.ttlAttributeName] = &dynamodb.AttributeValue{
+		item[ttlAttributeName] = &dynamodb.AttributeValue{
 			N: aws.String(strconv.FormatInt(*ttl, 10)),
 		}
 	}
 
+	if d.queryableValues {
+		err = d.addValueAttributes(item, value)
+		if err != nil {
+			return nil, fmt.Errorf("dynamodb error: failed to set key %s: %s", req.Key, err)
+		}
+	}
+
 	return item, nil
 }
 
+// addValueAttributes adds the top-level fields of a JSON object value to the attributes of its item.
+// Other values, and the fields named as the attributes of the store, aren't added.
+func (d *StateStore) addValueAttributes(item map[string]*dynamodb.AttributeValue, value string) error {
+	var fields map[string]interface{}
+	if jsoniterator.ConfigFastest.UnmarshalFromString(value, &fields) != nil {
+		return nil
+	}
+
+	for name, v := range fields {
+		if d.isReservedAttribute(name) {
+			continue
+		}
+		attr, err := dynamodbattribute.Marshal(v)
+		if err != nil {
+			return err
+		}
+		item[name] = attr
+	}
+
+	return nil
+}
+
+// isReservedAttribute returns true if an attribute of the items is used by the store.
+func (d *StateStore) isReservedAttribute(name string) bool {
+	switch name {
+	case "key", "value", "etag":
+		return true
+	default:
+		return d.ttlAttributeName != "" && name == d.ttlAttributeName
+	}
+}
+
 func getRand64() (uint64, error) {
 	randBuf := make([]byte, 8)
 	_, err := rand.Read(randBuf)
@@ -368,8 +455,8 @@ func (d *StateStore) marshalToString(v interface{}) (string, error) {
 
 // Parse and process ttlInSeconds.
 func (d *StateStore) parseTTL(req *state.SetRequest) (*int64, error) {
-	// Only attempt to parse the value when TTL has been specified in component metadata.
-	if d.ttlAttributeName != "" {
+	// Only attempt to parse the value when a TTL attribute has been specified in the component or request metadata.
+	if d.getTTLAttributeName(req.Metadata) != "" {
 		if val, ok := req.Metadata["ttlInSeconds"]; ok && val != "" {
 			parsedVal, err := strconv.ParseInt(val, 10, 0)
 			if err != nil {
@@ -0,0 +1,420 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// Request metadata with the name of a global secondary index to query instead of scanning the table.
+// The filter must have an EQ on the partition key of the index, at its top level.
+const metadataIndexName = "indexName"
+
+// indexKeys are the names of the key attributes of a global secondary index.
+type indexKeys struct {
+	partitionKey string
+	sortKey      string
+}
+
+type dynamoDBQuery struct {
+	indexName        string
+	keyCondition     *expression.KeyConditionBuilder
+	filter           *expression.ConditionBuilder
+	scanIndexForward *bool
+	limit            int
+	startKey         map[string]*dynamodb.AttributeValue
+}
+
+// Query returns the items whose value attributes match the filter of the query.
+// It scans the table, or queries a global secondary index if the indexName request metadata is set.
+// Results can only be sorted by the sort key of the queried index.
+func (d *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	if !d.queryableValues {
+		return nil, errors.New("dynamodb error: query requires the queryableValues metadata")
+	}
+
+	q, err := d.buildQuery(req)
+	if err != nil {
+		return nil, fmt.Errorf("dynamodb error: invalid query: %w", err)
+	}
+
+	results, token, err := d.executeQuery(q)
+	if err != nil {
+		return nil, fmt.Errorf("dynamodb error: failed to query: %w", err)
+	}
+
+	return &state.QueryResponse{
+		Results: results,
+		Token:   token,
+	}, nil
+}
+
+func (d *StateStore) buildQuery(req *state.QueryRequest) (*dynamoDBQuery, error) {
+	q := &dynamoDBQuery{
+		indexName: req.Metadata[metadataIndexName],
+		limit:     req.Query.Page.Limit,
+	}
+
+	filter := req.Query.Filter
+	var keys indexKeys
+	if q.indexName != "" {
+		var err error
+		keys, err = d.getIndexKeys(q.indexName)
+		if err != nil {
+			return nil, err
+		}
+		var partition *query.EQ
+		partition, filter = splitPartitionFilter(filter, keys.partitionKey)
+		if partition == nil {
+			return nil, fmt.Errorf("query on index %s requires an EQ filter on its partition key %s", q.indexName, keys.partitionKey)
+		}
+		keyCondition := expression.Key(partition.Key).Equal(expression.Value(partition.Val))
+		q.keyCondition = &keyCondition
+	}
+
+	if filter != nil {
+		condition, err := d.condition(filter)
+		if err != nil {
+			return nil, err
+		}
+		q.filter = &condition
+	}
+
+	if len(req.Query.Sort) > 0 {
+		if keys.sortKey == "" || len(req.Query.Sort) > 1 || req.Query.Sort[0].Key != keys.sortKey {
+			return nil, errors.New("sorting is only supported on the sort key of the queried index")
+		}
+		q.scanIndexForward = aws.Bool(req.Query.Sort[0].Order != query.DESC)
+	}
+
+	if req.Query.Page.Token != "" {
+		startKey, err := decodeQueryToken(req.Query.Page.Token)
+		if err != nil {
+			return nil, err
+		}
+		q.startKey = startKey
+	}
+
+	return q, nil
+}
+
+// splitPartitionFilter returns the EQ filter on the partition key of an index, and the rest of the filter.
+func splitPartitionFilter(filter query.Filter, partitionKey string) (*query.EQ, query.Filter) {
+	switch f := filter.(type) {
+	case *query.EQ:
+		if f.Key == partitionKey {
+			return f, nil
+		}
+	case *query.AND:
+		for i, child := range f.Filters {
+			eq, ok := child.(*query.EQ)
+			if !ok || eq.Key != partitionKey {
+				continue
+			}
+			rest := make([]query.Filter, 0, len(f.Filters)-1)
+			rest = append(rest, f.Filters[:i]...)
+			rest = append(rest, f.Filters[i+1:]...)
+			if len(rest) == 1 {
+				return eq, rest[0]
+			}
+			return eq, &query.AND{Filters: rest}
+		}
+	}
+
+	return nil, filter
+}
+
+// condition translates a filter into a condition on the value attributes of the items.
+func (d *StateStore) condition(filter query.Filter) (expression.ConditionBuilder, error) {
+	switch f := filter.(type) {
+	case *query.EQ:
+		if err := d.checkQueryKey(f.Key); err != nil {
+			return expression.ConditionBuilder{}, err
+		}
+		return expression.Name(f.Key).Equal(expression.Value(f.Val)), nil
+	case *query.IN:
+		if err := d.checkQueryKey(f.Key); err != nil {
+			return expression.ConditionBuilder{}, err
+		}
+		if len(f.Vals) == 0 {
+			return expression.ConditionBuilder{}, fmt.Errorf("empty IN operator for key %q", f.Key)
+		}
+		vals := make([]expression.OperandBuilder, len(f.Vals)-1)
+		for i, v := range f.Vals[1:] {
+			vals[i] = expression.Value(v)
+		}
+		return expression.Name(f.Key).In(expression.Value(f.Vals[0]), vals...), nil
+	case *query.AND:
+		conditions, err := d.conditions(f.Filters)
+		if err != nil || len(conditions) == 1 {
+			return first(conditions), err
+		}
+		return expression.And(conditions[0], conditions[1], conditions[2:]...), nil
+	case *query.OR:
+		conditions, err := d.conditions(f.Filters)
+		if err != nil || len(conditions) == 1 {
+			return first(conditions), err
+		}
+		return expression.Or(conditions[0], conditions[1], conditions[2:]...), nil
+	default:
+		return expression.ConditionBuilder{}, fmt.Errorf("unsupported filter type %#v", filter)
+	}
+}
+
+func (d *StateStore) conditions(filters []query.Filter) ([]expression.ConditionBuilder, error) {
+	if len(filters) == 0 {
+		return nil, errors.New("empty AND or OR operator")
+	}
+	conditions := make([]expression.ConditionBuilder, len(filters))
+	for i, f := range filters {
+		var err error
+		conditions[i], err = d.condition(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conditions, nil
+}
+
+func first(conditions []expression.ConditionBuilder) expression.ConditionBuilder {
+	if len(conditions) == 0 {
+		return expression.ConditionBuilder{}
+	}
+	return conditions[0]
+}
+
+// checkQueryKey returns an error if a key of the filter refers to an attribute of the store instead of the value.
+func (d *StateStore) checkQueryKey(key string) error {
+	attribute, _, _ := strings.Cut(key, ".")
+	attribute, _, _ = strings.Cut(attribute, "[")
+	if d.isReservedAttribute(attribute) {
+		return fmt.Errorf("key %q isn't a value attribute", key)
+	}
+	return nil
+}
+
+func (d *StateStore) executeQuery(q *dynamoDBQuery) ([]state.QueryItem, string, error) {
+	expr, hasExpr, err := q.expression()
+	if err != nil {
+		return nil, "", err
+	}
+
+	results := []state.QueryItem{}
+	startKey := q.startKey
+	for {
+		// DynamoDB limits the number of evaluated items, so the pages are read until enough items matched
+		var limit *int64
+		if q.limit > 0 {
+			limit = aws.Int64(int64(q.limit - len(results)))
+		}
+
+		var items []map[string]*dynamodb.AttributeValue
+		if q.indexName != "" {
+			input := &dynamodb.QueryInput{
+				TableName:                 aws.String(d.table),
+				IndexName:                 aws.String(q.indexName),
+				KeyConditionExpression:    expr.KeyCondition(),
+				FilterExpression:          expr.Filter(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+				ScanIndexForward:          q.scanIndexForward,
+				ExclusiveStartKey:         startKey,
+				Limit:                     limit,
+			}
+			var output *dynamodb.QueryOutput
+			output, err = d.client.Query(input)
+			if err != nil {
+				return nil, "", err
+			}
+			items, startKey = output.Items, output.LastEvaluatedKey
+		} else {
+			input := &dynamodb.ScanInput{
+				TableName:         aws.String(d.table),
+				ExclusiveStartKey: startKey,
+				Limit:             limit,
+			}
+			if hasExpr {
+				input.FilterExpression = expr.Filter()
+				input.ExpressionAttributeNames = expr.Names()
+				input.ExpressionAttributeValues = expr.Values()
+			}
+			var output *dynamodb.ScanOutput
+			output, err = d.client.Scan(input)
+			if err != nil {
+				return nil, "", err
+			}
+			items, startKey = output.Items, output.LastEvaluatedKey
+		}
+
+		for _, item := range items {
+			result, ok, err := d.queryItem(item)
+			if err != nil {
+				return nil, "", err
+			}
+			if ok {
+				results = append(results, result)
+			}
+		}
+
+		if len(startKey) == 0 || (q.limit > 0 && len(results) >= q.limit) {
+			break
+		}
+	}
+
+	var token string
+	if len(startKey) > 0 {
+		token, err = encodeQueryToken(startKey)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return results, token, nil
+}
+
+// expression builds the expressions of the query, and returns false if it has none.
+func (q *dynamoDBQuery) expression() (expression.Expression, bool, error) {
+	if q.keyCondition == nil && q.filter == nil {
+		return expression.Expression{}, false, nil
+	}
+
+	builder := expression.NewBuilder()
+	if q.keyCondition != nil {
+		builder = builder.WithKeyCondition(*q.keyCondition)
+	}
+	if q.filter != nil {
+		builder = builder.WithFilter(*q.filter)
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return expression.Expression{}, false, err
+	}
+
+	return expr, true, nil
+}
+
+// queryItem converts an item into a query result, and returns false if it has expired or if its key doesn't follow the
+// keyTemplate metadata.
+func (d *StateStore) queryItem(item map[string]*dynamodb.AttributeValue) (state.QueryItem, bool, error) {
+	if d.ttlAttributeName != "" && !d.includeExpired {
+		if val, ok := item[d.ttlAttributeName]; ok {
+			var ttl int64
+			if err := dynamodbattribute.Unmarshal(val, &ttl); err != nil {
+				return state.QueryItem{}, false, err
+			}
+			if ttl <= time.Now().Unix() {
+				// Item has expired but DynamoDB didn't delete it yet.
+				return state.QueryItem{}, false, nil
+			}
+		}
+	}
+
+	var key, value string
+	if err := dynamodbattribute.Unmarshal(item["key"], &key); err != nil {
+		return state.QueryItem{}, false, err
+	}
+	key, ok := d.keyCodec.Decode(key)
+	if !ok {
+		return state.QueryItem{}, false, nil
+	}
+	if err := dynamodbattribute.Unmarshal(item["value"], &value); err != nil {
+		return state.QueryItem{}, false, err
+	}
+	result := state.QueryItem{
+		Key:  key,
+		Data: []byte(value),
+	}
+	if etagVal, ok := item["etag"]; ok {
+		var etag string
+		if err := dynamodbattribute.Unmarshal(etagVal, &etag); err != nil {
+			return state.QueryItem{}, false, err
+		}
+		result.ETag = &etag
+	}
+
+	return result, true, nil
+}
+
+// getIndexKeys returns the key attributes of a global secondary index of the table.
+func (d *StateStore) getIndexKeys(indexName string) (indexKeys, error) {
+	d.indexesLock.Lock()
+	defer d.indexesLock.Unlock()
+
+	if keys, ok := d.indexes[indexName]; ok {
+		return keys, nil
+	}
+
+	output, err := d.client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(d.table),
+	})
+	if err != nil {
+		return indexKeys{}, fmt.Errorf("failed to describe table: %w", err)
+	}
+	for _, index := range output.Table.GlobalSecondaryIndexes {
+		if aws.StringValue(index.IndexName) != indexName {
+			continue
+		}
+		var keys indexKeys
+		for _, k := range index.KeySchema {
+			switch aws.StringValue(k.KeyType) {
+			case dynamodb.KeyTypeHash:
+				keys.partitionKey = aws.StringValue(k.AttributeName)
+			case dynamodb.KeyTypeRange:
+				keys.sortKey = aws.StringValue(k.AttributeName)
+			}
+		}
+		if d.indexes == nil {
+			d.indexes = map[string]indexKeys{}
+		}
+		d.indexes[indexName] = keys
+		return keys, nil
+	}
+
+	return indexKeys{}, fmt.Errorf("table %s has no global secondary index %s", d.table, indexName)
+}
+
+// The token of a query is the last evaluated key of its page.
+func encodeQueryToken(key map[string]*dynamodb.AttributeValue) (string, error) {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeQueryToken(token string) (map[string]*dynamodb.AttributeValue, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token %s", token)
+	}
+	var key map[string]*dynamodb.AttributeValue
+	err = json.Unmarshal(b, &key)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid token %s", token)
+	}
+	return key, nil
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func testItem(key string, value string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"key":   {S: aws.String(key)},
+		"value": {S: aws.String(value)},
+		"etag":  {S: aws.String("etag-" + key)},
+	}
+}
+
+func parseQuery(t *testing.T, q string) query.Query {
+	t.Helper()
+	var res query.Query
+	require.NoError(t, json.Unmarshal([]byte(q), &res))
+	return res
+}
+
+func TestSetValueAttributes(t *testing.T) {
+	ss := StateStore{queryableValues: true, ttlAttributeName: "expiresAt"}
+
+	item, err := ss.getItemFromReq(&state.SetRequest{
+		Key:   "order-1",
+		Value: map[string]interface{}{"status": "open", "total": 10, "customer": map[string]interface{}{"id": "c1"}, "etag": "x", "expiresAt": 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "open", *item["status"].S)
+	assert.Equal(t, "10", *item["total"].N)
+	assert.Equal(t, "c1", *item["customer"].M["id"].S)
+	assert.NotEqual(t, "x", *item["etag"].S)
+	assert.NotContains(t, item, "expiresAt")
+
+	item, err = ss.getItemFromReq(&state.SetRequest{Key: "text", Value: []byte("not json")})
+	require.NoError(t, err)
+	assert.Len(t, item, 3)
+}
+
+func TestQuery(t *testing.T) {
+	t.Run("requires queryable values", func(t *testing.T) {
+		ss := StateStore{}
+		_, err := ss.Query(&state.QueryRequest{})
+		assert.ErrorContains(t, err, "queryableValues")
+		assert.Equal(t, []state.Feature{state.FeatureETag, state.FeatureTransactional}, ss.Features())
+	})
+
+	t.Run("scan with filter and pages", func(t *testing.T) {
+		var inputs []*dynamodb.ScanInput
+		ss := StateStore{
+			table:           "table",
+			queryableValues: true,
+			client: &mockedDynamoDB{
+				ScanFn: func(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+					inputs = append(inputs, input)
+					if input.ExclusiveStartKey == nil {
+						return &dynamodb.ScanOutput{
+							Items:            []map[string]*dynamodb.AttributeValue{testItem("order-1", `{"status":"open"}`)},
+							LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"key": {S: aws.String("order-2")}},
+						}, nil
+					}
+					return &dynamodb.ScanOutput{
+						Items:            []map[string]*dynamodb.AttributeValue{testItem("order-3", `{"status":"closed"}`)},
+						LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"key": {S: aws.String("order-3")}},
+					}, nil
+				},
+			},
+		}
+		assert.Contains(t, ss.Features(), state.FeatureQueryAPI)
+
+		res, err := ss.Query(&state.QueryRequest{
+			Query: parseQuery(t, `{"filter":{"AND":[{"IN":{"status":["open","closed"]}},{"EQ":{"customer.id":"c1"}}]},"page":{"limit":2}}`),
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 2)
+		assert.Equal(t, "order-1", res.Results[0].Key)
+		assert.Equal(t, []byte(`{"status":"open"}`), res.Results[0].Data)
+		assert.Equal(t, "etag-order-1", *res.Results[0].ETag)
+		assert.Equal(t, "order-3", res.Results[1].Key)
+
+		require.Len(t, inputs, 2)
+		assert.Equal(t, int64(2), *inputs[0].Limit)
+		assert.Equal(t, int64(1), *inputs[1].Limit)
+		assert.Equal(t, "table", *inputs[0].TableName)
+		assert.NotEmpty(t, *inputs[0].FilterExpression)
+		assert.Len(t, inputs[0].ExpressionAttributeValues, 3)
+
+		startKey, err := decodeQueryToken(res.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "order-3", *startKey["key"].S)
+
+		_, err = ss.Query(&state.QueryRequest{Query: parseQuery(t, `{"page":{"limit":2,"token":"`+res.Token+`"}}`)})
+		require.NoError(t, err)
+		assert.Equal(t, "order-3", *inputs[2].ExclusiveStartKey["key"].S)
+		assert.Nil(t, inputs[2].FilterExpression)
+	})
+
+	t.Run("query index", func(t *testing.T) {
+		var input *dynamodb.QueryInput
+		describes := 0
+		ss := StateStore{
+			table:           "table",
+			queryableValues: true,
+			client: &mockedDynamoDB{
+				DescribeTableFn: func(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+					describes++
+					return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+						GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+							IndexName: aws.String("status-index"),
+							KeySchema: []*dynamodb.KeySchemaElement{
+								{AttributeName: aws.String("status"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+								{AttributeName: aws.String("createdAt"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+							},
+						}},
+					}}, nil
+				},
+				QueryFn: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+					input = in
+					return &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{testItem("order-1", `{"status":"open"}`)}}, nil
+				},
+			},
+		}
+
+		res, err := ss.Query(&state.QueryRequest{
+			Query:    parseQuery(t, `{"filter":{"AND":[{"EQ":{"status":"open"}},{"EQ":{"total":10}}]},"sort":[{"key":"createdAt","order":"DESC"}]}`),
+			Metadata: map[string]string{"indexName": "status-index"},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		assert.Empty(t, res.Token)
+		assert.Equal(t, "status-index", *input.IndexName)
+		assert.NotEmpty(t, *input.KeyConditionExpression)
+		assert.NotEmpty(t, *input.FilterExpression)
+		assert.False(t, *input.ScanIndexForward)
+		assert.Nil(t, input.Limit)
+
+		_, err = ss.Query(&state.QueryRequest{
+			Query:    parseQuery(t, `{"filter":{"EQ":{"total":10}}}`),
+			Metadata: map[string]string{"indexName": "status-index"},
+		})
+		assert.ErrorContains(t, err, "requires an EQ filter on its partition key status")
+		assert.Equal(t, 1, describes)
+
+		_, err = ss.Query(&state.QueryRequest{
+			Query:    parseQuery(t, `{"filter":{"EQ":{"status":"open"}}}`),
+			Metadata: map[string]string{"indexName": "missing"},
+		})
+		assert.ErrorContains(t, err, "has no global secondary index missing")
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		ss := StateStore{queryableValues: true, client: &mockedDynamoDB{}}
+		for _, q := range []string{
+			`{"sort":[{"key":"status"}]}`,
+			`{"filter":{"EQ":{"etag":"x"}}}`,
+			`{"filter":{"EQ":{"value.status":"x"}}}`,
+			`{"page":{"limit":1,"token":"invalid"}}`,
+		} {
+			_, err := ss.Query(&state.QueryRequest{Query: parseQuery(t, q)})
+			assert.ErrorContains(t, err, "invalid query", q)
+		}
+	})
+}
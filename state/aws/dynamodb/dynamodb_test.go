@@ -31,10 +31,14 @@ import (
 )
 
 type mockedDynamoDB struct {
-	GetItemFn        func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
-	PutItemFn        func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
-	DeleteItemFn     func(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
-	BatchWriteItemFn func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	GetItemFn            func(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	PutItemFn            func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	DeleteItemFn         func(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	BatchWriteItemFn     func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	QueryFn              func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	ScanFn               func(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	DescribeTableFn      func(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error)
+	TransactWriteItemsFn func(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
 	dynamodbiface.DynamoDBAPI
 }
 
@@ -60,6 +64,22 @@ func (m *mockedDynamoDB) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*d
 	return m.BatchWriteItemFn(input)
 }
 
+func (m *mockedDynamoDB) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return m.QueryFn(input)
+}
+
+func (m *mockedDynamoDB) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	return m.ScanFn(input)
+}
+
+func (m *mockedDynamoDB) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return m.DescribeTableFn(input)
+}
+
+func (m *mockedDynamoDB) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	return m.TransactWriteItemsFn(input)
+}
+
 func TestInit(t *testing.T) {
 	m := state.Metadata{}
 	s := NewDynamoDBStateStore(logger.NewLogger("test")).(*StateStore)
@@ -74,6 +94,18 @@ func TestInit(t *testing.T) {
 		}
 		err := s.Init(m)
 		assert.Nil(t, err)
+		assert.False(t, s.includeExpired)
+	})
+
+	t.Run("Init without filtering expired items", func(t *testing.T) {
+		m.Properties = map[string]string{
+			"Table":              "a",
+			"Region":             "eu-west-1",
+			"filterExpiredItems": "false",
+		}
+		err := s.Init(m)
+		assert.Nil(t, err)
+		assert.True(t, s.includeExpired)
 	})
 
 	t.Run("Init with missing table", func(t *testing.T) {
@@ -200,6 +232,59 @@ func TestGet(t *testing.T) {
 		assert.Nil(t, out.Data)
 		assert.Nil(t, out.ETag)
 	})
+	t.Run("Successfully retrieve item (with expired ttl, not filtered)", func(t *testing.T) {
+		ss := StateStore{
+			client: &mockedDynamoDB{
+				GetItemFn: func(input *dynamodb.GetItemInput) (output *dynamodb.GetItemOutput, err error) {
+					return &dynamodb.GetItemOutput{
+						Item: map[string]*dynamodb.AttributeValue{
+							"key": {
+								S: aws.String("someKey"),
+							},
+							"value": {
+								S: aws.String("some value"),
+							},
+							"testAttributeName": {
+								N: aws.String("35489251"),
+							},
+						},
+					}, nil
+				},
+			},
+			ttlAttributeName: "testAttributeName",
+			includeExpired:   true,
+		}
+		out, err := ss.Get(&state.GetRequest{Key: "someKey"})
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("some value"), out.Data)
+	})
+	t.Run("Successfully retrieve item (with expired ttl in request attribute)", func(t *testing.T) {
+		ss := StateStore{
+			client: &mockedDynamoDB{
+				GetItemFn: func(input *dynamodb.GetItemInput) (output *dynamodb.GetItemOutput, err error) {
+					return &dynamodb.GetItemOutput{
+						Item: map[string]*dynamodb.AttributeValue{
+							"key": {
+								S: aws.String("someKey"),
+							},
+							"value": {
+								S: aws.String("some value"),
+							},
+							"expiresAt": {
+								N: aws.String("35489251"),
+							},
+						},
+					}, nil
+				},
+			},
+		}
+		out, err := ss.Get(&state.GetRequest{
+			Key:      "someKey",
+			Metadata: map[string]string{"ttlAttributeName": "expiresAt"},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, out.Data)
+	})
 	t.Run("Unsuccessfully get item", func(t *testing.T) {
 		ss := StateStore{
 			client: &mockedDynamoDB{
@@ -617,6 +702,52 @@ func TestSet(t *testing.T) {
 		assert.NotNil(t, err)
 		assert.Equal(t, "dynamodb error: failed to parse ttlInSeconds: strconv.ParseInt: parsing \"invalidvalue\": invalid syntax", err.Error())
 	})
+	t.Run("Successfully set item with ttl in request attribute", func(t *testing.T) {
+		ss := StateStore{
+			client: &mockedDynamoDB{
+				PutItemFn: func(input *dynamodb.PutItemInput) (output *dynamodb.PutItemOutput, err error) {
+					assert.Equal(t, len(input.Item), 4)
+					assert.Nil(t, input.Item["testAttributeName"])
+					var expiresAt int64
+					dynamodbattribute.Unmarshal(input.Item["expiresAt"], &expiresAt)
+					assert.Greater(t, expiresAt, time.Now().Unix()+180-1)
+					assert.Less(t, expiresAt, time.Now().Unix()+180+1)
+
+					return &dynamodb.PutItemOutput{}, nil
+				},
+			},
+			ttlAttributeName: "testAttributeName",
+		}
+		req := &state.SetRequest{
+			Key: "someKey",
+			Value: value{
+				Value: "someValue",
+			},
+			Metadata: map[string]string{
+				"ttlInSeconds":     "180",
+				"ttlAttributeName": "expiresAt",
+			},
+		}
+		err := ss.Set(req)
+		assert.Nil(t, err)
+	})
+	t.Run("Unsuccessfully set item with reserved ttl attribute", func(t *testing.T) {
+		ss := StateStore{
+			client: &mockedDynamoDB{},
+		}
+		req := &state.SetRequest{
+			Key: "someKey",
+			Value: value{
+				Value: "someValue",
+			},
+			Metadata: map[string]string{
+				"ttlInSeconds":     "180",
+				"ttlAttributeName": "value",
+			},
+		}
+		err := ss.Set(req)
+		assert.NotNil(t, err)
+	})
 }
 
 func TestBulkSet(t *testing.T) {
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+const (
+	// maxTransactionOperations is the maximum number of operations of a DynamoDB transaction.
+	maxTransactionOperations = 100
+
+	conditionalCheckFailedReason = "ConditionalCheckFailed"
+)
+
+// Multi performs the operations in a DynamoDB transaction, with TransactWriteItems.
+// It succeeds only if all operations succeed, and fails if one or more operations fail.
+func (d *StateStore) Multi(request *state.TransactionalStateRequest) error {
+	if len(request.Operations) == 0 {
+		return nil
+	}
+	if len(request.Operations) > maxTransactionOperations {
+		return fmt.Errorf("dynamodb error: transactions are limited to %d operations, got %d", maxTransactionOperations, len(request.Operations))
+	}
+
+	items := make([]*dynamodb.TransactWriteItem, len(request.Operations))
+	// Operations with an ETag, whose failed condition is an ETag mismatch
+	withETag := make([]bool, len(request.Operations))
+	keys := make(map[string]struct{}, len(request.Operations))
+	for i, o := range request.Operations {
+		var key string
+		switch o.Operation {
+		case state.Upsert:
+			req := o.Request.(state.SetRequest)
+			key = req.Key
+			item, err := d.getItemFromReq(&req)
+			if err != nil {
+				return err
+			}
+			put := &dynamodb.Put{
+				Item:      item,
+				TableName: aws.String(d.table),
+			}
+			withETag[i] = req.ETag != nil && *req.ETag != ""
+			put.ConditionExpression, put.ExpressionAttributeValues = etagCondition(req.ETag, req.Options.Concurrency == state.FirstWrite)
+			items[i] = &dynamodb.TransactWriteItem{Put: put}
+		case state.Delete:
+			req := o.Request.(state.DeleteRequest)
+			key = req.Key
+			del := &dynamodb.Delete{
+				Key: map[string]*dynamodb.AttributeValue{
+					"key": {
+						S: aws.String(d.keyCodec.Encode(req.Key)),
+					},
+				},
+				TableName: aws.String(d.table),
+			}
+			withETag[i] = req.ETag != nil && *req.ETag != ""
+			del.ConditionExpression, del.ExpressionAttributeValues = etagCondition(req.ETag, false)
+			items[i] = &dynamodb.TransactWriteItem{Delete: del}
+		default:
+			return fmt.Errorf("dynamodb error: unsupported operation %s", o.Operation)
+		}
+
+		// DynamoDB rejects transactions with several operations on the same item
+		if _, ok := keys[key]; ok {
+			return fmt.Errorf("dynamodb error: transaction has more than one operation on key %s", key)
+		}
+		keys[key] = struct{}{}
+	}
+
+	_, err := d.client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		var cErr *dynamodb.TransactionCanceledException
+		if errors.As(err, &cErr) {
+			for i, reason := range cErr.CancellationReasons {
+				if i < len(withETag) && withETag[i] && reason != nil && aws.StringValue(reason.Code) == conditionalCheckFailedReason {
+					return state.NewETagError(state.ETagMismatch, cErr)
+				}
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// etagCondition returns the condition expression of a write with the ETag, or of the first write of an item.
+func etagCondition(etag *string, firstWrite bool) (*string, map[string]*dynamodb.AttributeValue) {
+	if etag != nil && *etag != "" {
+		return aws.String("etag = :etag"), map[string]*dynamodb.AttributeValue{
+			":etag": {
+				S: etag,
+			},
+		}
+	}
+	if firstWrite {
+		return aws.String("attribute_not_exists(etag)"), nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+func TestMulti(t *testing.T) {
+	etag := "1bdead4badc0ffee"
+
+	t.Run("writes the operations in a transaction", func(t *testing.T) {
+		var input *dynamodb.TransactWriteItemsInput
+		ss := StateStore{
+			table: "table",
+			client: &mockedDynamoDB{
+				TransactWriteItemsFn: func(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+					input = in
+					return &dynamodb.TransactWriteItemsOutput{}, nil
+				},
+			},
+		}
+
+		err := ss.Multi(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				{Operation: state.Upsert, Request: state.SetRequest{Key: "a", Value: "v", ETag: &etag}},
+				{Operation: state.Upsert, Request: state.SetRequest{Key: "b", Value: "v", Options: state.SetStateOption{Concurrency: state.FirstWrite}}},
+				{Operation: state.Upsert, Request: state.SetRequest{Key: "c", Value: "v"}},
+				{Operation: state.Delete, Request: state.DeleteRequest{Key: "d", ETag: &etag}},
+				{Operation: state.Delete, Request: state.DeleteRequest{Key: "e"}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, input.TransactItems, 5)
+
+		put := input.TransactItems[0].Put
+		assert.Equal(t, "table", *put.TableName)
+		assert.Equal(t, "a", *put.Item["key"].S)
+		assert.Equal(t, "etag = :etag", *put.ConditionExpression)
+		assert.Equal(t, etag, *put.ExpressionAttributeValues[":etag"].S)
+
+		assert.Equal(t, "attribute_not_exists(etag)", *input.TransactItems[1].Put.ConditionExpression)
+		assert.Nil(t, input.TransactItems[2].Put.ConditionExpression)
+
+		del := input.TransactItems[3].Delete
+		assert.Equal(t, "d", *del.Key["key"].S)
+		assert.Equal(t, "etag = :etag", *del.ConditionExpression)
+		assert.Nil(t, input.TransactItems[4].Delete.ConditionExpression)
+	})
+
+	t.Run("ETag mismatch", func(t *testing.T) {
+		ss := StateStore{
+			client: &mockedDynamoDB{
+				TransactWriteItemsFn: func(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+					return nil, &dynamodb.TransactionCanceledException{
+						CancellationReasons: []*dynamodb.CancellationReason{
+							{Code: aws.String("None")},
+							{Code: aws.String(conditionalCheckFailedReason)},
+						},
+					}
+				},
+			},
+		}
+
+		err := ss.Multi(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				{Operation: state.Upsert, Request: state.SetRequest{Key: "a", Value: "v"}},
+				{Operation: state.Delete, Request: state.DeleteRequest{Key: "b", ETag: &etag}},
+			},
+		})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+
+	t.Run("transaction error", func(t *testing.T) {
+		ss := StateStore{
+			client: &mockedDynamoDB{
+				TransactWriteItemsFn: func(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+					return nil, errors.New("throttled")
+				},
+			},
+		}
+
+		err := ss.Multi(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				{Operation: state.Delete, Request: state.DeleteRequest{Key: "a", ETag: &etag}},
+			},
+		})
+		var etagErr *state.ETagError
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, &etagErr))
+	})
+
+	t.Run("invalid transactions", func(t *testing.T) {
+		ss := StateStore{client: &mockedDynamoDB{}}
+
+		err := ss.Multi(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				{Operation: state.Upsert, Request: state.SetRequest{Key: "a", Value: "v"}},
+				{Operation: state.Delete, Request: state.DeleteRequest{Key: "a"}},
+			},
+		})
+		assert.ErrorContains(t, err, "more than one operation")
+
+		ops := make([]state.TransactionalStateOperation, maxTransactionOperations+1)
+		for i := range ops {
+			ops[i] = state.TransactionalStateOperation{Operation: state.Delete, Request: state.DeleteRequest{Key: strconv.Itoa(i)}}
+		}
+		err = ss.Multi(&state.TransactionalStateRequest{Operations: ops})
+		assert.ErrorContains(t, err, "limited to 100 operations")
+
+		assert.NoError(t, ss.Multi(&state.TransactionalStateRequest{}))
+	})
+}
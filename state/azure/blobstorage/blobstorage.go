@@ -30,26 +30,104 @@ Sample configuration in yaml:
 	  - name: containerName
 		value: <container Name>
 
+Set requests store the MD5 hash of the values in the ContentMD5 property of the blobs, and Get requests return a
+ContentMD5MismatchError when the content of a blob doesn't match it, e.g. when the download was truncated.
+Values that aren't bytes are serialized to JSON, with the application/json content type unless the request has one.
+
 Concurrency is supported with ETags according to https://docs.microsoft.com/en-us/azure/storage/common/storage-concurrency#managing-concurrency-in-blob-storage
+
+Transactions are applied one operation at a time, as blob storage has no transactions: if an operation fails,
+the blobs changed by the previous operations are restored to their content before the transaction.
+
+Values are written with an expiration time when the ttlInSeconds request metadata is set, in the expirytime metadata of the blob,
+and expired values are filtered out on reads; -1 means that the value never expires. Blobs don't expire on their own, so with
+ttlIndexTag set to true the expiration time is also written to the daprExpiryTime blob index tag, which a lifecycle management
+rule or a blob index query can use to purge expired blobs.
+
+With encryptionKey, a base64-encoded AES key of 16, 24 or 32 bytes, values are encrypted with AES-GCM before they
+are uploaded and decrypted when they are read; values written before the key was set are read as they are.
+With encryptionScope, blobs are written with the Azure encryption scope, which encrypts them on the service side.
+
+With enableSnapshots set to true, a snapshot of the blob is created after every write, so previous values can be recovered:
+Get reads a snapshot with the snapshot request metadata, or the latest snapshot taken at or before a time with the
+snapshotAsOf request metadata. Deleting a key also deletes its snapshots.
+
+When the storage account has soft delete enabled, Get restores a soft-deleted blob before reading it with the
+undelete request metadata set to true. With softDeleteAware set to true, deleting a key with the ETag of a version
+that was soft-deleted succeeds, as that version is already deleted, and deleting a key that doesn't exist with any
+other ETag fails with an ETag mismatch, rather than being a no-op.
+
+Bulk operations run the requests of the keys in parallel, with at most maxConcurrency requests at once (10 by default).
+
+The query API filters the blobs by name (key and keyPrefix), blob index tags (tags.<name>) and metadata (metadata.<name>),
+as the content of the blobs can't be filtered. Results are sorted by key and paginated with a continuation token.
+
+Set requests attach blob index tags to the blobs with the tags.<name> metadata. Queries that only filter by equality of
+tags find the blobs with the blob index, without listing the container; the index is updated asynchronously, so
+the results of these queries may not include the latest changes of the tags.
+
+By default, the names of the blobs are the keys without the app ID. The keyPrefixStrategy metadata prefixes them, so
+that several apps or components can share a container:
+  - none: <key> (default)
+  - appid: <app id>/<key>
+  - storename: <component name>/<key>
+  - custom: the keyPrefixTemplate metadata followed by the key; the {appid} and {storename} placeholders of the
+    template are replaced by the app ID and the name of the component, e.g. "shared/{storename}/{appid}-".
+The keyTemplate metadata, shared with other state stores, sets the whole name of the blobs with a template instead,
+e.g. "{namespace}/{appid}/{key}"; it can't be used with keyPrefixStrategy.
+Queries and key listings only return the blobs of the prefix, with their keys without the prefix. They are not
+supported when the prefix contains the app ID, nor when the keyTemplate has a suffix after {key}.
+
+The keys of the store are listed by pages, optionally filtered by a prefix, with Keys.
+
+With partitionFolders set to true, the partitionKey request metadata puts the keys in a folder of their partition, after
+the key prefix: <prefix><partition>/<key>. Without it, which is the default, the partitionKey metadata is stored as
+metadata of the blobs, and doesn't change their names. Requests of a key in a partition must all have the partitionKey metadata. Queries and key
+listings with the partitionKey metadata are limited to the partition, so that the keys of a partition can be listed,
+cleaned up, or moved to another container on their own.
+
+With concurrencyMode set to timestamp, rather than etag (the default), concurrent writers resolve conflicts with
+last-write-wins: Set and Delete requests have the time of the write in the timestamp request metadata, in RFC 3339
+format, or the current time if it's not set. The time is stored in the daprtimestamp metadata of the blob, and
+a request whose timestamp isn't later than the one of the stored value fails with an ETag mismatch. This is useful for
+writers that replicate the same changes, which don't share ETags. Deleted keys are replaced by empty blobs with the
+daprdeleted metadata, tombstones which keep the timestamp of the delete so that an earlier write can't recreate the key;
+they're skipped by reads, queries and key listings.
+
+Values are uploaded with a single request and downloaded with a single read. With streamingThresholdBytes set, values
+larger than the threshold are uploaded in blocks of streamingBlockSizeBytes (4 MiB by default), staged in parallel and then
+committed, and downloaded block by block, resuming a failed read from where it stopped. This splits the transfers of large
+values into smaller requests, but doesn't bound the memory used: state requests and responses hold the whole values, so
+they're still read and written in memory at once.
 */
 
 package blobstorage
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/base64"
 	"fmt"
-	"io"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/hashicorp/go-multierror"
 	jsoniter "github.com/json-iterator/go"
 
 	storageinternal "github.com/dapr/components-contrib/internal/component/azure/blobstorage"
+	"github.com/dapr/components-contrib/internal/redact"
+	"github.com/dapr/components-contrib/internal/utils"
 	mdutils "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
@@ -57,27 +135,119 @@ import (
 )
 
 const (
-	keyDelimiter = "||"
+	defaultMaxConcurrency = 10
+	contentTypeJSON       = "application/json"
+
+	// defaultTimeout is the timeout of the requests without the operationTimeout metadata, long enough to transfer large values.
+	defaultTimeout = 5 * time.Minute
+
+	// expiryTimeMetadataKey is the name of the blob metadata with the expiration time of the value.
+	expiryTimeMetadataKey = "expirytime"
+	// expiryTimeTagName is the name of the blob index tag with the expiration time of the value, when ttlIndexTag is enabled.
+	expiryTimeTagName = "daprExpiryTime"
 )
 
 // StateStore Type.
 type StateStore struct {
-	state.DefaultBulkStore
+	serviceClient   *service.Client
 	containerClient *container.Client
+	containerName   string
 	json            jsoniter.API
+	timeout         time.Duration
+	maxConcurrency  int
+	ttlIndexTag     bool
+	cipher          *valueCipher
+	encryptionScope string
+	enableSnapshots bool
+	softDeleteAware bool
+	prefixer        *keyPrefixer
+	concurrencyMode string
+
+	streamingThreshold int64
+	streamingBlockSize int64
 
 	features []state.Feature
 	logger   logger.Logger
 }
 
 // Init the connection to blob storage, optionally creates a blob container if it doesn't exist.
-func (r *StateStore) Init(metadata state.Metadata) error {
-	var err error
-	r.containerClient, _, err = storageinternal.CreateContainerStorageClient(r.logger, metadata.Properties)
+func (r *StateStore) Init(metadata state.Metadata) (err error) {
+	// The account key or the connection string may be quoted by the errors of the blob clients
+	redactor := redact.New(metadata.Properties, nil)
+	r.logger = redactor.Logger(r.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
+	var m *storageinternal.BlobStorageMetadata
+	r.serviceClient, r.containerClient, m, err = storageinternal.CreateStorageClients(r.logger, metadata.Properties)
 	if err != nil {
 		return err
 	}
-	return nil
+	r.containerName = m.ContainerName
+	r.timeout, err = mdutils.GetOperationTimeout(metadata.Properties, defaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	meta := blobStateMetadata{
+		MaxConcurrency:          defaultMaxConcurrency,
+		StreamingBlockSizeBytes: defaultStreamingBlockSize,
+	}
+	err = mdutils.DecodeMetadata(metadata.Properties, &meta)
+	if err != nil {
+		return err
+	}
+	if meta.MaxConcurrency <= 0 {
+		return fmt.Errorf("invalid maxConcurrency %d: must be greater than 0", meta.MaxConcurrency)
+	}
+	r.maxConcurrency = meta.MaxConcurrency
+	if meta.StreamingThresholdBytes < 0 {
+		return fmt.Errorf("invalid streamingThresholdBytes %d: must be 0 or greater", meta.StreamingThresholdBytes)
+	}
+	if meta.StreamingBlockSizeBytes <= 0 {
+		return fmt.Errorf("invalid streamingBlockSizeBytes %d: must be greater than 0", meta.StreamingBlockSizeBytes)
+	}
+	r.streamingThreshold = meta.StreamingThresholdBytes
+	r.streamingBlockSize = meta.StreamingBlockSizeBytes
+	r.ttlIndexTag = meta.TTLIndexTag
+	r.encryptionScope = meta.EncryptionScope
+	r.enableSnapshots = meta.EnableSnapshots
+	r.softDeleteAware = meta.SoftDeleteAware
+	switch meta.ConcurrencyMode {
+	case "", concurrencyModeETag:
+		r.concurrencyMode = concurrencyModeETag
+	case concurrencyModeTimestamp:
+		r.concurrencyMode = concurrencyModeTimestamp
+	default:
+		return fmt.Errorf("invalid concurrencyMode %s: must be %s or %s", meta.ConcurrencyMode, concurrencyModeETag, concurrencyModeTimestamp)
+	}
+	if meta.EncryptionKey != "" {
+		r.cipher, err = newValueCipher(meta.EncryptionKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.prefixer, err = newKeyPrefixer(meta.KeyPrefixStrategy, meta.KeyPrefixTemplate, meta.PartitionFolders, metadata)
+	return err
+}
+
+// blobStateMetadata is the metadata of the state store that isn't shared with the blob storage binding.
+type blobStateMetadata struct {
+	MaxConcurrency    int    `mapstructure:"maxConcurrency"`
+	TTLIndexTag       bool   `mapstructure:"ttlIndexTag"`
+	EncryptionKey     string `mapstructure:"encryptionKey"`
+	EncryptionScope   string `mapstructure:"encryptionScope"`
+	EnableSnapshots   bool   `mapstructure:"enableSnapshots"`
+	SoftDeleteAware   bool   `mapstructure:"softDeleteAware"`
+	KeyPrefixStrategy string `mapstructure:"keyPrefixStrategy"`
+	KeyPrefixTemplate string `mapstructure:"keyPrefixTemplate"`
+	PartitionFolders  bool   `mapstructure:"partitionFolders"`
+	ConcurrencyMode   string `mapstructure:"concurrencyMode"`
+
+	StreamingThresholdBytes int64 `mapstructure:"streamingThresholdBytes"`
+	StreamingBlockSizeBytes int64 `mapstructure:"streamingBlockSizeBytes"`
 }
 
 // Features returns the features available in this state store.
@@ -87,21 +257,147 @@ func (r *StateStore) Features() []state.Feature {
 
 // Delete the state.
 func (r *StateStore) Delete(req *state.DeleteRequest) error {
-	return r.deleteFile(context.Background(), req)
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.deleteFile(ctx, req)
 }
 
 // Get the state.
 func (r *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	return r.readFile(context.Background(), req)
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.readFile(ctx, req)
 }
 
 // Set the state.
 func (r *StateStore) Set(req *state.SetRequest) error {
-	return r.writeFile(context.Background(), req)
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.writeFile(ctx, req)
+}
+
+// BulkGet reads the blobs in parallel. The errors of the keys are returned in the responses.
+func (r *StateStore) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	res := make([]state.BulkGetResponse, len(req))
+	r.parallel(len(req), func(i int) error {
+		ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+		defer cancel()
+		res[i] = state.BulkGetResponse{Key: req[i].Key}
+		item, err := r.readFile(ctx, &req[i])
+		if err != nil {
+			res[i].Error = err.Error()
+			return nil
+		}
+		res[i].Data = item.Data
+		res[i].ETag = item.ETag
+		res[i].ContentType = item.ContentType
+		return nil
+	})
+
+	return true, res, nil
+}
+
+// BulkSet writes the blobs in parallel. It returns the errors of all the keys that failed, as BulkStoreError.
+func (r *StateStore) BulkSet(req []state.SetRequest) error {
+	return r.parallel(len(req), func(i int) error {
+		ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+		defer cancel()
+		if err := r.writeFile(ctx, &req[i]); err != nil {
+			return state.NewBulkStoreError(req[i].Key, err)
+		}
+		return nil
+	})
+}
+
+// BulkDelete deletes the blobs in parallel. It returns the errors of all the keys that failed, as BulkStoreError.
+func (r *StateStore) BulkDelete(req []state.DeleteRequest) error {
+	return r.parallel(len(req), func(i int) error {
+		ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+		defer cancel()
+		if err := r.deleteFile(ctx, &req[i]); err != nil {
+			return state.NewBulkStoreError(req[i].Key, err)
+		}
+		return nil
+	})
+}
+
+// parallel runs fn for the indexes from 0 to n-1, with at most maxConcurrency calls at once.
+// It returns the errors of the calls in the order of the indexes.
+func (r *StateStore) parallel(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, r.maxConcurrency)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	var err error
+	for _, e := range errs {
+		if e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+	return err
+}
+
+// Query lists the blobs that match the filters of the query, or finds them with the blob index when the query only
+// filters by tags. Only the blobs with the key prefix of the store are returned.
+func (r *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	q, err := newBlobQuery(&req.Query)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+	prefix, err := r.prefixer.queryPrefix(req.Metadata)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	var (
+		data  []state.QueryItem
+		token string
+	)
+	if q.where != "" {
+		data, token, err = q.executeIndex(ctx, prefixedFindBlobs(r.findBlobs, prefix), prefixedReadBlob(r.readBlob, prefix))
+	} else {
+		data, token, err = q.execute(ctx, prefixedListPage(r.listPage, prefix), prefixedReadBlob(r.readBlob, prefix))
+	}
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+// Keys lists a page of the keys of the store, sorted, with the key prefix of the store removed.
+func (r *StateStore) Keys(req *state.KeysRequest) (*state.KeysResponse, error) {
+	prefix, err := r.prefixer.queryPrefix(req.Metadata)
+	if err != nil {
+		return &state.KeysResponse{}, err
+	}
+
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return listKeys(ctx, prefixedListPage(r.listPage, prefix), req)
 }
 
 func (r *StateStore) Ping() error {
-	if _, err := r.containerClient.GetProperties(context.Background(), nil); err != nil {
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	if _, err := r.containerClient.GetProperties(ctx, nil); err != nil {
 		return fmt.Errorf("blob storage: error connecting to Blob storage at %s: %s", r.containerClient.URL(), err)
 	}
 
@@ -115,20 +411,50 @@ func (r *StateStore) GetComponentMetadata() map[string]string {
 	return metadataInfo
 }
 
+func (r *StateStore) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(r.Features()), mdutils.CapabilityTTL),
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileAccessKey, mdutils.AuthProfileAzureAD},
+	}
+}
+
 // NewAzureBlobStorageStore instance.
 func NewAzureBlobStorageStore(logger logger.Logger) state.Store {
 	s := &StateStore{
-		json:     jsoniter.ConfigFastest,
-		features: []state.Feature{state.FeatureETag},
-		logger:   logger,
+		json:           jsoniter.ConfigFastest,
+		maxConcurrency: defaultMaxConcurrency,
+		features:       []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureKeysList},
+		logger:         logger,
 	}
-	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
 
 	return s
 }
 
 func (r *StateStore) readFile(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	blockBlobClient := r.containerClient.NewBlockBlobClient(getFileName(req.Key))
+	name := r.prefixer.blobName(req.Key, req.Metadata)
+	if utils.IsTruthy(req.Metadata[undeleteMetadataKey]) {
+		if err := r.undelete(ctx, name); err != nil {
+			return &state.GetResponse{}, err
+		}
+	}
+	blockBlobClient, isSnapshot, err := r.getReadClient(ctx, name, req.Metadata)
+	if err != nil {
+		return &state.GetResponse{}, err
+	}
+	if blockBlobClient == nil {
+		return &state.GetResponse{}, nil
+	}
+
+	return r.download(ctx, name, blockBlobClient, isSnapshot)
+}
+
+// readBlob reads a blob by name.
+func (r *StateStore) readBlob(ctx context.Context, name string) (*state.GetResponse, error) {
+	return r.download(ctx, name, r.containerClient.NewBlockBlobClient(name), false)
+}
+
+// download reads the blob, or its snapshot, with the client.
+func (r *StateStore) download(ctx context.Context, name string, blockBlobClient *blockblob.Client, isSnapshot bool) (*state.GetResponse, error) {
 	blobDownloadResponse, err := blockBlobClient.DownloadStream(ctx, nil)
 	if err != nil {
 		if isNotFoundError(err) {
@@ -138,12 +464,35 @@ func (r *StateStore) readFile(ctx context.Context, req *state.GetRequest) (*stat
 		return &state.GetResponse{}, err
 	}
 
-	reader := blobDownloadResponse.Body
-	defer reader.Close()
-	blobData, err := io.ReadAll(reader)
+	defer blobDownloadResponse.Body.Close()
+	blobData, err := r.readBody(ctx, &blobDownloadResponse)
 	if err != nil {
 		return &state.GetResponse{}, fmt.Errorf("error reading az blob: %w", err)
 	}
+	err = checkContentMD5(name, blobData, blobDownloadResponse.ContentMD5)
+	if err != nil {
+		return &state.GetResponse{}, err
+	}
+
+	if isTombstone(blobDownloadResponse.Metadata) {
+		return &state.GetResponse{}, nil
+	}
+
+	// Snapshots are read for recovery, so they're returned even if the value they contain has expired since
+	if !isSnapshot {
+		expired, err := isExpired(blobDownloadResponse.Metadata, time.Now())
+		if err != nil {
+			return &state.GetResponse{}, err
+		}
+		if expired {
+			return &state.GetResponse{}, nil
+		}
+	}
+
+	blobData, err = r.decryptBlob(name, blobDownloadResponse.Metadata, blobData)
+	if err != nil {
+		return &state.GetResponse{}, err
+	}
 
 	contentType := blobDownloadResponse.ContentType
 
@@ -154,7 +503,72 @@ func (r *StateStore) readFile(ctx context.Context, req *state.GetRequest) (*stat
 	}, nil
 }
 
+// listPage lists a page of blobs with their tags and metadata, which are used by the query filters.
+func (r *StateStore) listPage(ctx context.Context, prefix string, marker string, pageSize int32) ([]*container.BlobItem, string, error) {
+	opts := &container.ListBlobsFlatOptions{
+		Include:    container.ListBlobsInclude{Metadata: true, Tags: true},
+		MaxResults: ptr.Of(pageSize),
+	}
+	if prefix != "" {
+		opts.Prefix = ptr.Of(prefix)
+	}
+	if marker != "" {
+		opts.Marker = ptr.Of(marker)
+	}
+
+	page, err := r.containerClient.NewListBlobsFlatPager(opts).NextPage(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("error listing az blobs: %w", err)
+	}
+
+	var items []*container.BlobItem
+	if page.Segment != nil {
+		items = page.Segment.BlobItems
+	}
+	var next string
+	if page.NextMarker != nil {
+		next = *page.NextMarker
+	}
+	return items, next, nil
+}
+
+// findBlobs finds a page of the blobs of the container with the blob index.
+func (r *StateStore) findBlobs(ctx context.Context, where string, marker string, maxResults int32) ([]string, string, error) {
+	opts := &service.FilterBlobsOptions{
+		Where:      ptr.Of("@container='" + r.containerName + "' AND " + where),
+		MaxResults: ptr.Of(maxResults),
+	}
+	if marker != "" {
+		opts.Marker = ptr.Of(marker)
+	}
+
+	res, err := r.serviceClient.FilterBlobs(ctx, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("error finding az blobs by tags: %w", err)
+	}
+
+	names := make([]string, 0, len(res.Blobs))
+	for _, b := range res.Blobs {
+		if b != nil && b.Name != nil {
+			names = append(names, *b.Name)
+		}
+	}
+	var next string
+	if res.NextMarker != nil {
+		next = *res.NextMarker
+	}
+	return names, next, nil
+}
+
+// writeFile writes the blob, after comparing the timestamps of the request and of the stored value in the timestamp concurrency mode.
 func (r *StateStore) writeFile(ctx context.Context, req *state.SetRequest) error {
+	if r.concurrencyMode == concurrencyModeTimestamp {
+		return r.writeFileWithTimestamp(ctx, req)
+	}
+	return r.uploadFile(ctx, req)
+}
+
+func (r *StateStore) uploadFile(ctx context.Context, req *state.SetRequest) error {
 	modifiedAccessConditions := blob.ModifiedAccessConditions{}
 
 	if req.ETag != nil && *req.ETag != "" {
@@ -168,19 +582,66 @@ func (r *StateStore) writeFile(ctx context.Context, req *state.SetRequest) error
 		ModifiedAccessConditions: &modifiedAccessConditions,
 	}
 
-	blobHTTPHeaders, err := storageinternal.CreateBlobHTTPHeadersFromRequest(req.Metadata, req.ContentType, r.logger)
+	ttl, err := parseTTL(req.Metadata)
 	if err != nil {
 		return err
 	}
+	// The request metadata is copied, as it's consumed to build the headers and metadata of the blob
+	meta := make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		if k != mdutils.TTLMetadataKey {
+			meta[k] = v
+		}
+	}
+
+	blobHTTPHeaders, err := storageinternal.CreateBlobHTTPHeadersFromRequest(meta, req.ContentType, r.logger)
+	if err != nil {
+		return err
+	}
+
+	tags, metadata, err := splitTags(meta)
+	if err != nil {
+		return err
+	}
+	if r.prefixer.hasPartitionFolders() {
+		// The partition is the folder of the blob
+		delete(metadata, metadataPartitionKey)
+	}
 
 	uploadOptions := azblob.UploadBufferOptions{
 		AccessConditions: &accessConditions,
-		Metadata:         storageinternal.SanitizeMetadata(r.logger, req.Metadata),
+		Metadata:         storageinternal.SanitizeMetadata(r.logger, metadata),
 		HTTPHeaders:      &blobHTTPHeaders,
+		Tags:             tags,
+	}
+	if ttl != nil && *ttl >= 0 {
+		expiryTime := time.Now().UTC().Add(time.Duration(*ttl) * time.Second).Format(time.RFC3339)
+		uploadOptions.Metadata[expiryTimeMetadataKey] = expiryTime
+		if r.ttlIndexTag {
+			if uploadOptions.Tags == nil {
+				uploadOptions.Tags = map[string]string{}
+			}
+			uploadOptions.Tags[expiryTimeTagName] = expiryTime
+		}
+	}
+
+	if r.encryptionScope != "" {
+		uploadOptions.CpkScopeInfo = &blob.CpkScopeInfo{EncryptionScope: ptr.Of(r.encryptionScope)}
 	}
 
-	blockBlobClient := r.containerClient.NewBlockBlobClient(getFileName(req.Key))
-	_, err = blockBlobClient.UploadBuffer(ctx, r.marshal(req), &uploadOptions)
+	name := r.prefixer.blobName(req.Key, req.Metadata)
+	data := r.marshal(req)
+	if r.cipher != nil {
+		data, err = r.cipher.encrypt(name, data)
+		if err != nil {
+			return err
+		}
+		uploadOptions.Metadata[encryptionMetadataKey] = encryptionAlgorithm
+	}
+	setContentHeaders(&blobHTTPHeaders, req, data)
+
+	blockBlobClient := r.containerClient.NewBlockBlobClient(name)
+	etag, err := r.upload(ctx, blockBlobClient, data, &uploadOptions)
 
 	if err != nil {
 		// Check if the error is due to ETag conflict
@@ -191,11 +652,24 @@ func (r *StateStore) writeFile(ctx context.Context, req *state.SetRequest) error
 		return fmt.Errorf("error uploading az blob: %w", err)
 	}
 
+	if r.enableSnapshots {
+		return r.createSnapshot(ctx, blockBlobClient, etag)
+	}
+
 	return nil
 }
 
+// deleteFile deletes the blob, after comparing the timestamps of the request and of the stored value in the timestamp concurrency mode.
 func (r *StateStore) deleteFile(ctx context.Context, req *state.DeleteRequest) error {
-	blockBlobClient := r.containerClient.NewBlockBlobClient(getFileName(req.Key))
+	if r.concurrencyMode == concurrencyModeTimestamp {
+		return r.deleteFileWithTimestamp(ctx, req)
+	}
+	return r.removeFile(ctx, req)
+}
+
+func (r *StateStore) removeFile(ctx context.Context, req *state.DeleteRequest) error {
+	name := r.prefixer.blobName(req.Key, req.Metadata)
+	blockBlobClient := r.containerClient.NewBlockBlobClient(name)
 
 	modifiedAccessConditions := blob.ModifiedAccessConditions{}
 	if req.ETag != nil && *req.ETag != "" {
@@ -203,17 +677,24 @@ func (r *StateStore) deleteFile(ctx context.Context, req *state.DeleteRequest) e
 	}
 
 	deleteOptions := blob.DeleteOptions{
-		DeleteSnapshots: nil,
 		AccessConditions: &blob.AccessConditions{
 			ModifiedAccessConditions: &modifiedAccessConditions,
 		},
 	}
 
+	if r.enableSnapshots {
+		// A blob can't be deleted without its snapshots
+		deleteOptions.DeleteSnapshots = ptr.Of(blob.DeleteSnapshotsOptionTypeInclude)
+	}
+
 	_, err := blockBlobClient.Delete(ctx, &deleteOptions)
 	if err != nil {
 		if req.ETag != nil && isETagConflictError(err) {
 			return state.NewETagError(state.ETagMismatch, err)
 		} else if isNotFoundError(err) {
+			if r.softDeleteAware && req.ETag != nil && *req.ETag != "" {
+				return r.checkDeletedETag(ctx, name, *req.ETag)
+			}
 			// deleting an item that doesn't exist without specifying an ETAG is a noop
 			return nil
 		}
@@ -224,13 +705,102 @@ func (r *StateStore) deleteFile(ctx context.Context, req *state.DeleteRequest) e
 	return nil
 }
 
-func getFileName(key string) string {
-	pr := strings.Split(key, keyDelimiter)
-	if len(pr) != 2 {
-		return pr[0]
+// splitTags returns the blob index tags of the tags.<name> metadata, and the other metadata.
+func splitTags(metadata map[string]string) (map[string]string, map[string]string, error) {
+	var tags map[string]string
+	others := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, queryTagsPrefix) {
+			others[k] = v
+			continue
+		}
+		name := k[len(queryTagsPrefix):]
+		if err := validateTag(name, v); err != nil {
+			return nil, nil, err
+		}
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		tags[name] = v
+	}
+	if len(tags) > maxTags {
+		return nil, nil, fmt.Errorf("a blob can have at most %d blob index tags", maxTags)
+	}
+	return tags, others, nil
+}
+
+// parseTTL returns the ttlInSeconds of the request metadata; -1 means that the value never expires.
+func parseTTL(requestMetadata map[string]string) (*int, error) {
+	val, ok := requestMetadata[mdutils.TTLMetadataKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	ttl, err := strconv.Atoi(val)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s metadata: %w", mdutils.TTLMetadataKey, err)
+	}
+	if ttl < -1 {
+		return nil, fmt.Errorf("%s must be -1 or higher: actual is %d", mdutils.TTLMetadataKey, ttl)
+	}
+
+	return &ttl, nil
+}
+
+// isExpired returns true if the blob metadata has an expiration time before now.
+// The names of blob metadata are case-insensitive.
+func isExpired(blobMetadata map[string]string, now time.Time) (bool, error) {
+	v, ok := getBlobMetadata(blobMetadata, expiryTimeMetadataKey)
+	if !ok {
+		return false, nil
 	}
 
-	return pr[1]
+	expiryTime, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s metadata of az blob: %w", expiryTimeMetadataKey, err)
+	}
+
+	return !now.Before(expiryTime), nil
+}
+
+// setContentHeaders sets the ContentMD5 of the blob, so that downloads can be validated, unless the request has one,
+// and the content type of values that are serialized to JSON, so that it's returned by Get.
+func setContentHeaders(headers *blob.HTTPHeaders, req *state.SetRequest, data []byte) {
+	if headers.BlobContentMD5 == nil {
+		sum := md5.Sum(data) //nolint:gosec
+		headers.BlobContentMD5 = sum[:]
+	}
+	if headers.BlobContentType == nil {
+		if _, ok := req.Value.([]byte); !ok {
+			headers.BlobContentType = ptr.Of(contentTypeJSON)
+		}
+	}
+}
+
+// ContentMD5MismatchError is returned when the content of a blob doesn't match its ContentMD5 property,
+// such as when the download was truncated.
+type ContentMD5MismatchError struct {
+	Blob     string
+	Expected []byte
+	Actual   []byte
+}
+
+func (e *ContentMD5MismatchError) Error() string {
+	return fmt.Sprintf("content of az blob %s is corrupted: its MD5 hash %s doesn't match its ContentMD5 %s",
+		e.Blob, base64.StdEncoding.EncodeToString(e.Actual), base64.StdEncoding.EncodeToString(e.Expected))
+}
+
+// checkContentMD5 returns a ContentMD5MismatchError if the data doesn't match the ContentMD5 of the blob.
+// Blobs without ContentMD5 aren't validated.
+func checkContentMD5(name string, data []byte, contentMD5 []byte) error {
+	if len(contentMD5) == 0 {
+		return nil
+	}
+	sum := md5.Sum(data) //nolint:gosec
+	if !bytes.Equal(sum[:], contentMD5) {
+		return &ContentMD5MismatchError{Blob: name, Expected: contentMD5, Actual: sum[:]}
+	}
+	return nil
 }
 
 func (r *StateStore) marshal(req *state.SetRequest) []byte {
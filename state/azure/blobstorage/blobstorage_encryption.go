@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// encryptionMetadataKey is the name of the blob metadata that marks the values encrypted by the state store.
+	encryptionMetadataKey = "daprencryption"
+	// encryptionAlgorithm is the value of the encryption metadata of the blobs encrypted with AES-GCM.
+	encryptionAlgorithm = "AES-GCM"
+)
+
+// valueCipher encrypts the values with AES-GCM before they are uploaded, and decrypts them when they are read.
+// The name of the blob is authenticated with the value, so an encrypted value can't be copied to another key.
+type valueCipher struct {
+	aead cipher.AEAD
+}
+
+// newValueCipher returns a valueCipher for a base64-encoded AES key of 16, 24 or 32 bytes.
+func newValueCipher(encodedKey string) (*valueCipher, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryptionKey: must be base64-encoded: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryptionKey: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &valueCipher{aead: aead}, nil
+}
+
+// encrypt returns the nonce followed by the encrypted value.
+func (c *valueCipher) encrypt(name string, value []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(value)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, value, []byte(name)), nil
+}
+
+func (c *valueCipher) decrypt(name string, data []byte) ([]byte, error) {
+	if len(data) < c.aead.NonceSize() {
+		return nil, errors.New("encrypted value is too short")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+
+	return c.aead.Open(nil, nonce, ciphertext, []byte(name))
+}
+
+// decryptBlob decrypts the data of a blob if its metadata marks it as encrypted, and returns it as-is otherwise,
+// so values written before the encryption was enabled can still be read.
+func (r *StateStore) decryptBlob(name string, blobMetadata map[string]string, data []byte) ([]byte, error) {
+	algorithm, ok := getBlobMetadata(blobMetadata, encryptionMetadataKey)
+	if !ok {
+		return data, nil
+	}
+	if algorithm != encryptionAlgorithm {
+		return nil, fmt.Errorf("az blob %s is encrypted with unsupported algorithm %s", name, algorithm)
+	}
+	if r.cipher == nil {
+		return nil, fmt.Errorf("az blob %s is encrypted, but the state store has no encryptionKey", name)
+	}
+
+	plaintext, err := r.cipher.decrypt(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting az blob %s: %w", name, err)
+	}
+
+	return plaintext, nil
+}
+
+// getBlobMetadata returns the value of a metadata of a blob; the names of metadata are case-insensitive.
+func getBlobMetadata(blobMetadata map[string]string, name string) (string, bool) {
+	for k, v := range blobMetadata {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
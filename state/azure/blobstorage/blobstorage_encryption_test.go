@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEncryptionKey is a base64-encoded 32 bytes key.
+const testEncryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestNewValueCipher(t *testing.T) {
+	_, err := newValueCipher(testEncryptionKey)
+	assert.NoError(t, err)
+
+	_, err = newValueCipher("not base64!")
+	assert.Error(t, err)
+
+	// 5 bytes
+	_, err = newValueCipher("aGVsbG8=")
+	assert.Error(t, err)
+}
+
+func TestValueCipher(t *testing.T) {
+	c, err := newValueCipher(testEncryptionKey)
+	require.NoError(t, err)
+
+	encrypted, err := c.encrypt("key1", []byte("hello"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "hello")
+
+	decrypted, err := c.decrypt("key1", encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(decrypted))
+
+	_, err = c.decrypt("key2", encrypted)
+	assert.Error(t, err, "value copied to another key")
+
+	_, err = c.decrypt("key1", encrypted[:4])
+	assert.Error(t, err)
+}
+
+func TestDecryptBlob(t *testing.T) {
+	c, err := newValueCipher(testEncryptionKey)
+	require.NoError(t, err)
+	encrypted, err := c.encrypt("key1", []byte("hello"))
+	require.NoError(t, err)
+
+	t.Run("encrypted", func(t *testing.T) {
+		r := &StateStore{cipher: c}
+		data, err := r.decryptBlob("key1", map[string]string{"Daprencryption": encryptionAlgorithm}, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		r := &StateStore{cipher: c}
+		data, err := r.decryptBlob("key1", map[string]string{}, []byte("plain"))
+		require.NoError(t, err)
+		assert.Equal(t, "plain", string(data))
+	})
+
+	t.Run("encrypted without key", func(t *testing.T) {
+		r := &StateStore{}
+		_, err := r.decryptBlob("key1", map[string]string{encryptionMetadataKey: encryptionAlgorithm}, encrypted)
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		r := &StateStore{cipher: c}
+		_, err := r.decryptBlob("key1", map[string]string{encryptionMetadataKey: "ROT13"}, encrypted)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+// Strategies of the keyPrefixStrategy metadata, which sets the prefix of the names of the blobs when the component has
+// no keyTemplate metadata.
+const (
+	// The name of the blob is the key, without the app ID. This is the default.
+	keyPrefixNone = "none"
+	// The name of the blob is the key in a folder with the name of the app ID: <app id>/<key>.
+	keyPrefixAppID = "appid"
+	// The name of the blob is the key in a folder with the name of the component: <store name>/<key>.
+	keyPrefixStoreName = "storename"
+	// The prefix of the name of the blob is the keyPrefixTemplate metadata.
+	keyPrefixCustom = "custom"
+
+	// Request metadata with the partition of the keys; with the partitionFolders metadata of the component, each
+	// partition is a folder after the key prefix.
+	metadataPartitionKey = "partitionKey"
+)
+
+// keyPrefixer adds a prefix to the names of the blobs, so that several apps or components can share a container.
+type keyPrefixer struct {
+	// Nil when the names of the blobs have no prefix.
+	codec *state.KeyCodec
+	// Whether the keys are in the folders of their partitions.
+	partitionFolders bool
+}
+
+// newKeyPrefixer returns the prefixer of the keyTemplate metadata of the component, or else of the keyPrefixStrategy,
+// which is nil when the names of the blobs aren't prefixed nor in the folders of their partitions.
+func newKeyPrefixer(strategy string, template string, partitionFolders bool, meta state.Metadata) (*keyPrefixer, error) {
+	codec, err := state.NewKeyCodec(meta)
+	if err != nil {
+		return nil, err
+	}
+	if codec != nil {
+		if strategy != "" || template != "" {
+			return nil, errors.New("keyTemplate can't be used with keyPrefixStrategy or keyPrefixTemplate")
+		}
+		return &keyPrefixer{codec: codec, partitionFolders: partitionFolders}, nil
+	}
+
+	switch strings.ToLower(strategy) {
+	case "", keyPrefixNone:
+		if partitionFolders {
+			return &keyPrefixer{partitionFolders: true}, nil
+		}
+		return nil, nil
+	case keyPrefixAppID:
+		template = state.KeyPlaceholderAppID + "/"
+	case keyPrefixStoreName:
+		template = state.KeyPlaceholderStoreName + "/"
+	case keyPrefixCustom:
+		if template == "" {
+			return nil, errors.New("keyPrefixTemplate is required with the custom keyPrefixStrategy")
+		}
+	default:
+		return nil, fmt.Errorf("invalid keyPrefixStrategy %s, accepted values are %s, %s, %s or %s", strategy, keyPrefixNone, keyPrefixAppID, keyPrefixStoreName, keyPrefixCustom)
+	}
+
+	codec, err = state.ParseKeyTemplate(template+state.KeyPlaceholderKey, meta.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &keyPrefixer{codec: codec, partitionFolders: partitionFolders}, nil
+}
+
+// blobName returns the name of the blob of a key, which may be prefixed with the app ID as app-id||key,
+// in the partition of the request metadata with partition folders.
+func (p *keyPrefixer) blobName(key string, meta map[string]string) string {
+	appID, k := state.SplitKey(key)
+	if p == nil {
+		return k
+	}
+	if p.partitionFolders {
+		k = partitionFolder(meta) + k
+	}
+	if p.codec == nil {
+		return k
+	}
+	return p.codec.Format(appID, k)
+}
+
+// queryPrefix returns the prefix of the blobs that queries and key listings are limited to.
+// They are not supported when the prefix depends on the app ID, which is not part of their requests.
+// With partition folders, when the request metadata has a partition, they are limited to the blobs of the partition.
+func (p *keyPrefixer) queryPrefix(meta map[string]string) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	var prefix string
+	if p.codec != nil {
+		var err error
+		prefix, err = p.codec.Prefix()
+		if err != nil {
+			return "", err
+		}
+	}
+	if p.partitionFolders {
+		prefix += partitionFolder(meta)
+	}
+	return prefix, nil
+}
+
+// hasPartitionFolders returns true if the keys are in the folders of their partitions.
+func (p *keyPrefixer) hasPartitionFolders() bool {
+	return p != nil && p.partitionFolders
+}
+
+// partitionFolder returns the folder of the partition of the request metadata, or an empty string without partition.
+func partitionFolder(meta map[string]string) string {
+	partition := strings.Trim(meta[metadataPartitionKey], "/")
+	if partition == "" {
+		return ""
+	}
+	return partition + "/"
+}
+
+// prefixedListPage lists the blobs of the prefix, and returns them with their names without the prefix.
+func prefixedListPage(listPage listPageFn, prefix string) listPageFn {
+	if prefix == "" {
+		return listPage
+	}
+	return func(ctx context.Context, p string, marker string, pageSize int32) ([]*container.BlobItem, string, error) {
+		items, next, err := listPage(ctx, prefix+p, marker, pageSize)
+		for _, item := range items {
+			if item != nil && item.Name != nil {
+				item.Name = ptr.Of(strings.TrimPrefix(*item.Name, prefix))
+			}
+		}
+		return items, next, err
+	}
+}
+
+// prefixedFindBlobs finds the blobs of the prefix, and returns their names without the prefix.
+func prefixedFindBlobs(findBlobs findBlobsFn, prefix string) findBlobsFn {
+	if prefix == "" {
+		return findBlobs
+	}
+	return func(ctx context.Context, where string, marker string, maxResults int32) ([]string, string, error) {
+		names, next, err := findBlobs(ctx, where, marker, maxResults)
+		res := make([]string, 0, len(names))
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				res = append(res, name[len(prefix):])
+			}
+		}
+		return res, next, err
+	}
+}
+
+// prefixedReadBlob reads the blobs by their names without the prefix.
+func prefixedReadBlob(readBlob readBlobFn, prefix string) readBlobFn {
+	if prefix == "" {
+		return readBlob
+	}
+	return func(ctx context.Context, name string) (*state.GetResponse, error) {
+		return readBlob(ctx, prefix+name)
+	}
+}
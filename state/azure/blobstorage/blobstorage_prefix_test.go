@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestKeyPrefixer(t *testing.T) {
+	tests := []struct {
+		strategy    string
+		template    string
+		blobName    string
+		noAppID     string
+		queryPrefix string
+		queryErr    bool
+	}{
+		{strategy: "", blobName: "key", noAppID: "key"},
+		{strategy: "none", blobName: "key", noAppID: "key"},
+		{strategy: "appid", blobName: "app1/key", noAppID: "/key", queryErr: true},
+		{strategy: "storename", blobName: "store1/key", noAppID: "store1/key", queryPrefix: "store1/"},
+		{strategy: "StoreName", blobName: "store1/key", noAppID: "store1/key", queryPrefix: "store1/"},
+		{strategy: "custom", template: "shared/{storename}/", blobName: "shared/store1/key", noAppID: "shared/store1/key", queryPrefix: "shared/store1/"},
+		{strategy: "custom", template: "{storename}-{appid}-", blobName: "store1-app1-key", noAppID: "store1--key", queryErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy+" "+tt.template, func(t *testing.T) {
+			p, err := newKeyPrefixer(tt.strategy, tt.template, false, componentMetadata("store1", nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.blobName, p.blobName("app1||key", nil))
+			assert.Equal(t, tt.noAppID, p.blobName("key", nil))
+
+			prefix, err := p.queryPrefix(nil)
+			if tt.queryErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.queryPrefix, prefix)
+			}
+		})
+	}
+
+	t.Run("without prefix", func(t *testing.T) {
+		p, err := newKeyPrefixer("", "", false, componentMetadata("store1", nil))
+		require.NoError(t, err)
+		assert.Nil(t, p)
+		assert.Equal(t, "key", p.blobName("app_id||key", nil))
+		assert.Equal(t, "key||suffix", p.blobName("app_id||key||suffix", nil))
+		assert.Equal(t, "key", p.blobName("key", nil))
+	})
+
+	t.Run("partition folders", func(t *testing.T) {
+		meta := map[string]string{"partitionKey": "tenant-1"}
+		p, err := newKeyPrefixer("", "", true, componentMetadata("store1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1/key", p.blobName("app1||key", meta))
+		prefix, err := p.queryPrefix(meta)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1/", prefix)
+
+		p, err = newKeyPrefixer("storename", "", true, componentMetadata("store1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "store1/tenant-1/key", p.blobName("app1||key", meta))
+		assert.Equal(t, "store1/tenant-1/key", p.blobName("key", map[string]string{"partitionKey": "/tenant-1/"}))
+		assert.Equal(t, "store1/key", p.blobName("key", map[string]string{"partitionKey": ""}))
+		prefix, err = p.queryPrefix(meta)
+		require.NoError(t, err)
+		assert.Equal(t, "store1/tenant-1/", prefix)
+
+		p, err = newKeyPrefixer("appid", "", true, componentMetadata("store1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "app1/tenant-1/key", p.blobName("app1||key", meta))
+
+		p, err = newKeyPrefixer("", "", true, componentMetadata("store1", map[string]string{"keyTemplate": "{storename}/{appid}/{key}"}))
+		require.NoError(t, err)
+		assert.Equal(t, "store1/app1/tenant-1/key", p.blobName("app1||key", meta))
+	})
+
+	t.Run("partition without partition folders", func(t *testing.T) {
+		meta := map[string]string{"partitionKey": "tenant-1"}
+		var none *keyPrefixer
+		assert.Equal(t, "key", none.blobName("app1||key", meta))
+		prefix, err := none.queryPrefix(meta)
+		require.NoError(t, err)
+		assert.Empty(t, prefix)
+
+		p, err := newKeyPrefixer("storename", "", false, componentMetadata("store1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "store1/key", p.blobName("app1||key", meta))
+		prefix, err = p.queryPrefix(meta)
+		require.NoError(t, err)
+		assert.Equal(t, "store1/", prefix)
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, err := newKeyPrefixer("namespace", "", false, componentMetadata("store1", nil))
+		assert.ErrorContains(t, err, "invalid keyPrefixStrategy namespace")
+	})
+
+	t.Run("custom without template", func(t *testing.T) {
+		_, err := newKeyPrefixer("custom", "", false, componentMetadata("store1", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("store name without component name", func(t *testing.T) {
+		_, err := newKeyPrefixer("storename", "", false, componentMetadata("", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("keyTemplate", func(t *testing.T) {
+		p, err := newKeyPrefixer("", "", false, componentMetadata("store1", map[string]string{"keyTemplate": "{storename}/{appid}/{key}"}))
+		require.NoError(t, err)
+		assert.Equal(t, "store1/app1/key", p.blobName("app1||key", nil))
+		_, err = p.queryPrefix(nil)
+		assert.Error(t, err)
+
+		p, err = newKeyPrefixer("", "", false, componentMetadata("store1", map[string]string{"keyTemplate": "shared/{storename}/{key}", "keyPrefix": "name"}))
+		require.NoError(t, err)
+		assert.Equal(t, "shared/store1/key", p.blobName("app1||key", nil))
+		prefix, err := p.queryPrefix(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "shared/store1/", prefix)
+	})
+
+	t.Run("runtime keyPrefix strategy", func(t *testing.T) {
+		p, err := newKeyPrefixer("storename", "", false, componentMetadata("store1", map[string]string{"keyPrefix": "name"}))
+		require.NoError(t, err)
+		assert.Equal(t, "store1/key", p.blobName("key", nil))
+	})
+
+	t.Run("keyTemplate with keyPrefixStrategy", func(t *testing.T) {
+		_, err := newKeyPrefixer("appid", "", false, componentMetadata("store1", map[string]string{"keyTemplate": "{appid}/{key}"}))
+		assert.Error(t, err)
+	})
+}
+
+func componentMetadata(name string, props map[string]string) state.Metadata {
+	return state.Metadata{Base: mdutils.Base{Name: name, Properties: props}}
+}
+
+func TestPrefixedQuery(t *testing.T) {
+	blobs := map[string]string{
+		"store1/order-1": "a",
+		"store1/order-2": "b",
+		"store1/user-1":  "c",
+		"store2/order-1": "d",
+	}
+	listPage := func(ctx context.Context, prefix string, marker string, pageSize int32) ([]*container.BlobItem, string, error) {
+		var items []*container.BlobItem
+		for _, name := range []string{"store1/order-1", "store1/order-2", "store1/user-1", "store2/order-1"} {
+			if len(name) >= len(prefix) && name[:len(prefix)] == prefix {
+				items = append(items, &container.BlobItem{Name: ptr.Of(name)})
+			}
+		}
+		return items, "", nil
+	}
+	findBlobs := func(ctx context.Context, where string, marker string, maxResults int32) ([]string, string, error) {
+		return []string{"store1/order-1", "store2/order-1"}, "", nil
+	}
+	readBlob := func(ctx context.Context, name string) (*state.GetResponse, error) {
+		return &state.GetResponse{Data: []byte(blobs[name]), ETag: ptr.Of(name)}, nil
+	}
+
+	q := &blobQuery{prefix: "order-", match: all(nil), token: queryToken{PageSize: maxPageSize}}
+	res, _, err := q.execute(context.Background(), prefixedListPage(listPage, "store1/"), prefixedReadBlob(readBlob, "store1/"))
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "order-1", res[0].Key)
+	assert.Equal(t, []byte("a"), res[0].Data)
+	assert.Equal(t, "order-2", res[1].Key)
+	assert.Equal(t, []byte("b"), res[1].Data)
+
+	q = &blobQuery{where: `"status"='open'`, token: queryToken{PageSize: maxPageSize}}
+	res, _, err = q.executeIndex(context.Background(), prefixedFindBlobs(findBlobs, "store1/"), prefixedReadBlob(readBlob, "store1/"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "order-1", res[0].Key)
+	assert.Equal(t, []byte("a"), res[0].Data)
+}
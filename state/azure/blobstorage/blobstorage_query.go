@@ -0,0 +1,407 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// Keys of the query filters. Blobs can't be filtered by their content, so the filters apply to the name of the blobs
+// and to the blob index tags and metadata.
+const (
+	queryKeyName    = "key"
+	queryKeyPrefix  = "keyPrefix"
+	queryTagsPrefix = "tags."
+	queryMetaPrefix = "metadata."
+
+	// Maximum number of blobs of a listing page.
+	maxPageSize = 5000
+
+	// Limits of the blob index tags.
+	maxTags           = 10
+	maxTagNameLength  = 128
+	maxTagValueLength = 256
+)
+
+// blobMatcher returns true if a blob matches a filter.
+type blobMatcher func(item *container.BlobItem) bool
+
+// blobQuery is a query translated to a listing of the blobs with a prefix, filtered by a matcher.
+// Queries that only filter by blob index tags are run with the blob index, with their tags as where expression.
+type blobQuery struct {
+	prefix string
+	match  blobMatcher
+	where  string
+	limit  int
+	token  queryToken
+}
+
+// queryToken is the position of the next blob to return: the marker of the listing page that has it,
+// and the number of blobs of the page to skip. The size of the pages is kept, so the pages are the same
+// when the listing resumes.
+type queryToken struct {
+	Marker   string `json:"m,omitempty"`
+	Skip     int    `json:"s,omitempty"`
+	PageSize int32  `json:"n"`
+}
+
+// listPageFn lists a page of blobs with a prefix, starting at a marker.
+type listPageFn func(ctx context.Context, prefix string, marker string, pageSize int32) (items []*container.BlobItem, nextMarker string, err error)
+
+// findBlobsFn finds a page of the names of the blobs whose tags match a where expression, starting at a marker.
+type findBlobsFn func(ctx context.Context, where string, marker string, maxResults int32) (names []string, nextMarker string, err error)
+
+// readBlobFn reads a blob.
+type readBlobFn func(ctx context.Context, name string) (*state.GetResponse, error)
+
+func newBlobQuery(q *query.Query) (*blobQuery, error) {
+	if len(q.Sort) > 0 {
+		return nil, errors.New("sorting is not supported: the results are sorted by key")
+	}
+	if q.Page.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit: %d", q.Page.Limit)
+	}
+
+	bq := &blobQuery{limit: q.Page.Limit}
+	if q.Page.Token != "" {
+		b, err := base64.RawURLEncoding.DecodeString(q.Page.Token)
+		if err == nil {
+			err = json.Unmarshal(b, &bq.token)
+		}
+		if err != nil || bq.token.Skip < 0 || bq.token.PageSize <= 0 || bq.token.PageSize > maxPageSize {
+			return nil, errors.New("invalid pagination token")
+		}
+	} else {
+		// Pages of the size of the limit avoid listing more blobs than needed when the filters match most blobs
+		bq.token.PageSize = maxPageSize
+		if bq.limit > 0 && bq.limit < maxPageSize {
+			bq.token.PageSize = int32(bq.limit)
+		}
+	}
+
+	// A keyPrefix filter is only supported at the top level, or in the top-level AND, as the prefix of the listing
+	filters := []query.Filter{q.Filter}
+	if and, ok := q.Filter.(*query.AND); ok {
+		filters = and.Filters
+	}
+	var matchers []blobMatcher
+	for _, f := range filters {
+		if f == nil {
+			continue
+		}
+		if eq, ok := f.(*query.EQ); ok && eq.Key == queryKeyPrefix {
+			if bq.prefix != "" {
+				return nil, fmt.Errorf("only one %s filter is supported", queryKeyPrefix)
+			}
+			bq.prefix = fmt.Sprint(eq.Val)
+			continue
+		}
+		m, err := compileFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	bq.match = all(matchers)
+	if bq.prefix == "" {
+		bq.where = tagsExpression(filters)
+	}
+
+	return bq, nil
+}
+
+// tagsExpression returns the where expression of the blob index that is equivalent to the filters,
+// or an empty string if some filters aren't equalities of blob index tags.
+func tagsExpression(filters []query.Filter) string {
+	var conditions []string
+	for _, f := range filters {
+		eq, ok := f.(*query.EQ)
+		if !ok || !strings.HasPrefix(eq.Key, queryTagsPrefix) {
+			return ""
+		}
+		name, value := eq.Key[len(queryTagsPrefix):], fmt.Sprint(eq.Val)
+		if validateTag(name, value) != nil {
+			return ""
+		}
+		conditions = append(conditions, `"`+name+`"='`+value+`'`)
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// validateTag returns an error if a tag isn't a valid blob index tag.
+// See https://learn.microsoft.com/azure/storage/blobs/storage-manage-find-blobs#setting-blob-index-tags
+func validateTag(name string, value string) error {
+	if len(name) == 0 || len(name) > maxTagNameLength {
+		return fmt.Errorf("the name of the blob index tag %q must have between 1 and %d characters", name, maxTagNameLength)
+	}
+	if len(value) > maxTagValueLength {
+		return fmt.Errorf("the value of the blob index tag %s must have at most %d characters", name, maxTagValueLength)
+	}
+	if !isTagString(name) || !isTagString(value) {
+		return fmt.Errorf("the blob index tag %s can only contain letters, digits, spaces and the characters +-./:=_", name)
+	}
+	return nil
+}
+
+func isTagString(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune(" +-./:=_", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func compileFilter(filter query.Filter) (blobMatcher, error) {
+	switch f := filter.(type) {
+	case *query.EQ:
+		return compileIn(f.Key, []any{f.Val})
+	case *query.IN:
+		return compileIn(f.Key, f.Vals)
+	case *query.AND:
+		matchers, err := compileFilters(f.Filters)
+		if err != nil {
+			return nil, err
+		}
+		return all(matchers), nil
+	case *query.OR:
+		matchers, err := compileFilters(f.Filters)
+		if err != nil {
+			return nil, err
+		}
+		return func(item *container.BlobItem) bool {
+			for _, m := range matchers {
+				if m(item) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported filter type %#v", filter)
+	}
+}
+
+func compileFilters(filters []query.Filter) ([]blobMatcher, error) {
+	matchers := make([]blobMatcher, len(filters))
+	for i, f := range filters {
+		m, err := compileFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = m
+	}
+	return matchers, nil
+}
+
+// compileIn returns a matcher of the blobs whose name, tag, or metadata is one of the values.
+func compileIn(key string, vals []any) (blobMatcher, error) {
+	values := make(map[string]struct{}, len(vals))
+	for _, v := range vals {
+		values[fmt.Sprint(v)] = struct{}{}
+	}
+	in := func(v string, ok bool) bool {
+		if !ok {
+			return false
+		}
+		_, found := values[v]
+		return found
+	}
+
+	switch {
+	case key == queryKeyName:
+		return func(item *container.BlobItem) bool {
+			return item.Name != nil && in(*item.Name, true)
+		}, nil
+	case strings.HasPrefix(key, queryTagsPrefix) && len(key) > len(queryTagsPrefix):
+		tag := key[len(queryTagsPrefix):]
+		return func(item *container.BlobItem) bool {
+			return in(blobTag(item, tag))
+		}, nil
+	case strings.HasPrefix(key, queryMetaPrefix) && len(key) > len(queryMetaPrefix):
+		name := key[len(queryMetaPrefix):]
+		return func(item *container.BlobItem) bool {
+			return in(blobMetadata(item, name))
+		}, nil
+	case key == queryKeyPrefix:
+		return nil, fmt.Errorf("the %s filter is only supported at the top level of the query, or in a top-level AND", queryKeyPrefix)
+	default:
+		return nil, fmt.Errorf("unsupported filter key %q: expected %s, %s, %s<tag> or %s<name>", key, queryKeyName, queryKeyPrefix, queryTagsPrefix, queryMetaPrefix)
+	}
+}
+
+func all(matchers []blobMatcher) blobMatcher {
+	return func(item *container.BlobItem) bool {
+		for _, m := range matchers {
+			if !m(item) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func blobTag(item *container.BlobItem, name string) (string, bool) {
+	if item.BlobTags == nil {
+		return "", false
+	}
+	for _, t := range item.BlobTags.BlobTagSet {
+		if t != nil && t.Key != nil && *t.Key == name && t.Value != nil {
+			return *t.Value, true
+		}
+	}
+	return "", false
+}
+
+// blobMetadata returns the metadata of a blob; the names of metadata are case-insensitive.
+func blobMetadata(item *container.BlobItem, name string) (string, bool) {
+	for k, v := range item.Metadata {
+		if strings.EqualFold(k, name) && v != nil {
+			return *v, true
+		}
+	}
+	return "", false
+}
+
+// execute lists the blobs from the position of the token, and reads the ones that match until the limit is reached.
+// It returns the token of the next blob when the listing isn't complete.
+func (q *blobQuery) execute(ctx context.Context, listPage listPageFn, readBlob readBlobFn) ([]state.QueryItem, string, error) {
+	results := []state.QueryItem{}
+	marker, skip, pageSize := q.token.Marker, q.token.Skip, q.token.PageSize
+	for {
+		items, next, err := listPage(ctx, q.prefix, marker, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i := skip; i < len(items); i++ {
+			if q.limit > 0 && len(results) == q.limit {
+				return results, encodeToken(queryToken{Marker: marker, Skip: i, PageSize: pageSize}), nil
+			}
+
+			item := items[i]
+			if item.Name == nil || !q.match(item) {
+				continue
+			}
+			res, err := readBlob(ctx, *item.Name)
+			if err != nil {
+				results = append(results, state.QueryItem{Key: *item.Name, Error: err.Error()})
+				continue
+			}
+			if res.Data == nil && res.ETag == nil {
+				// Deleted or expired since it was listed
+				continue
+			}
+			results = append(results, state.QueryItem{
+				Key:         *item.Name,
+				Data:        res.Data,
+				ETag:        res.ETag,
+				ContentType: res.ContentType,
+			})
+		}
+
+		if next == "" {
+			return results, "", nil
+		}
+		marker, skip = next, 0
+		if q.limit > 0 && len(results) == q.limit {
+			return results, encodeToken(queryToken{Marker: marker, PageSize: pageSize}), nil
+		}
+	}
+}
+
+// executeIndex finds the blobs with the blob index, and reads them.
+// The blob index is updated asynchronously, so the blobs whose tags just changed may be missing from the results.
+func (q *blobQuery) executeIndex(ctx context.Context, findBlobs findBlobsFn, readBlob readBlobFn) ([]state.QueryItem, string, error) {
+	results := []state.QueryItem{}
+	marker, pageSize := q.token.Marker, q.token.PageSize
+	for {
+		names, next, err := findBlobs(ctx, q.where, marker, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, name := range names {
+			res, err := readBlob(ctx, name)
+			if err != nil {
+				results = append(results, state.QueryItem{Key: name, Error: err.Error()})
+				continue
+			}
+			if res.Data == nil && res.ETag == nil {
+				// Deleted since it was indexed
+				continue
+			}
+			results = append(results, state.QueryItem{
+				Key:         name,
+				Data:        res.Data,
+				ETag:        res.ETag,
+				ContentType: res.ContentType,
+			})
+		}
+
+		if next == "" {
+			return results, "", nil
+		}
+		marker = next
+		// The pages have at most pageSize blobs, which is the limit of the query when it has one
+		if q.limit > 0 {
+			return results, encodeToken(queryToken{Marker: marker, PageSize: pageSize}), nil
+		}
+	}
+}
+
+// listKeys lists a page of the names of the blobs with the prefix of the request.
+// The token of the next page is the marker of the listing.
+func listKeys(ctx context.Context, listPage listPageFn, req *state.KeysRequest) (*state.KeysResponse, error) {
+	if req.Limit < 0 {
+		return &state.KeysResponse{}, fmt.Errorf("invalid limit: %d", req.Limit)
+	}
+	pageSize := int32(maxPageSize)
+	if req.Limit > 0 && req.Limit < maxPageSize {
+		pageSize = int32(req.Limit)
+	}
+
+	items, next, err := listPage(ctx, req.Prefix, req.Token, pageSize)
+	if err != nil {
+		return &state.KeysResponse{}, err
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		if item == nil || item.Name == nil {
+			continue
+		}
+		if deleted, _ := blobMetadata(item, deletedBlobMetadataKey); deleted == "true" {
+			// Tombstone of the timestamp concurrency mode
+			continue
+		}
+		keys = append(keys, *item.Name)
+	}
+	return &state.KeysResponse{Keys: keys, Token: next}, nil
+}
+
+func encodeToken(t queryToken) string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/kit/ptr"
+)
+
+// fakeContainer lists blobs by pages, with the index of the next blob as marker.
+type fakeContainer struct {
+	blobs     []*container.BlobItem
+	pageSizes []int32
+}
+
+// newBlob returns a blob as the listing returns it; the types of the tags are internal to the SDK.
+func newBlob(t *testing.T, name string, tags map[string]string, metadata map[string]string) *container.BlobItem {
+	t.Helper()
+
+	var b strings.Builder
+	b.WriteString("<Blob><Name>" + name + "</Name><Metadata>")
+	for k, v := range metadata {
+		b.WriteString("<" + k + ">" + v + "</" + k + ">")
+	}
+	b.WriteString("</Metadata>")
+	if len(tags) > 0 {
+		b.WriteString("<Tags><TagSet>")
+		for k, v := range tags {
+			b.WriteString("<Tag><Key>" + k + "</Key><Value>" + v + "</Value></Tag>")
+		}
+		b.WriteString("</TagSet></Tags>")
+	}
+	b.WriteString("</Blob>")
+
+	var item container.BlobItem
+	require.NoError(t, xml.Unmarshal([]byte(b.String()), &item))
+	return &item
+}
+
+func (c *fakeContainer) listPage(_ context.Context, prefix string, marker string, pageSize int32) ([]*container.BlobItem, string, error) {
+	c.pageSizes = append(c.pageSizes, pageSize)
+
+	var blobs []*container.BlobItem
+	for _, b := range c.blobs {
+		if strings.HasPrefix(*b.Name, prefix) {
+			blobs = append(blobs, b)
+		}
+	}
+	start := 0
+	if marker != "" {
+		var err error
+		start, err = strconv.Atoi(marker)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	end := start + int(pageSize)
+	if end >= len(blobs) {
+		return blobs[start:], "", nil
+	}
+	return blobs[start:end], strconv.Itoa(end), nil
+}
+
+func (c *fakeContainer) readBlob(_ context.Context, name string) (*state.GetResponse, error) {
+	if name == "deleted" {
+		return &state.GetResponse{}, nil
+	}
+	if name == "broken" {
+		return nil, fmt.Errorf("read error")
+	}
+	return &state.GetResponse{Data: []byte(`"` + name + `"`), ETag: ptr.Of("etag-" + name)}, nil
+}
+
+func parseQuery(t *testing.T, s string) *query.Query {
+	t.Helper()
+
+	var q query.Query
+	require.NoError(t, json.Unmarshal([]byte(s), &q))
+	return &q
+}
+
+func keys(items []state.QueryItem) []string {
+	res := make([]string, len(items))
+	for i, item := range items {
+		res[i] = item.Key
+	}
+	return res
+}
+
+func TestQueryFilters(t *testing.T) {
+	c := &fakeContainer{blobs: []*container.BlobItem{
+		newBlob(t, "order-1", map[string]string{"status": "open"}, map[string]string{"Region": "eu"}),
+		newBlob(t, "order-2", map[string]string{"status": "closed"}, map[string]string{"region": "us"}),
+		newBlob(t, "order-3", map[string]string{"status": "open"}, map[string]string{"region": "us"}),
+		newBlob(t, "user-1", map[string]string{"status": "open"}, nil),
+	}}
+
+	tests := map[string]struct {
+		query    string
+		expected []string
+	}{
+		"all": {
+			query:    `{}`,
+			expected: []string{"order-1", "order-2", "order-3", "user-1"},
+		},
+		"prefix": {
+			query:    `{"filter":{"EQ":{"keyPrefix":"order-"}}}`,
+			expected: []string{"order-1", "order-2", "order-3"},
+		},
+		"prefix and tag": {
+			query:    `{"filter":{"AND":[{"EQ":{"keyPrefix":"order-"}},{"EQ":{"tags.status":"open"}}]}}`,
+			expected: []string{"order-1", "order-3"},
+		},
+		"metadata is case-insensitive": {
+			query:    `{"filter":{"EQ":{"metadata.region":"eu"}}}`,
+			expected: []string{"order-1"},
+		},
+		"or": {
+			query:    `{"filter":{"OR":[{"EQ":{"key":"user-1"}},{"AND":[{"EQ":{"tags.status":"closed"}},{"IN":{"metadata.region":["us","eu"]}}]}]}}`,
+			expected: []string{"order-2", "user-1"},
+		},
+		"in": {
+			query:    `{"filter":{"IN":{"key":["order-3","order-1","missing"]}}}`,
+			expected: []string{"order-1", "order-3"},
+		},
+		"missing tag": {
+			query:    `{"filter":{"EQ":{"tags.owner":"me"}}}`,
+			expected: []string{},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			q, err := newBlobQuery(parseQuery(t, tt.query))
+			require.NoError(t, err)
+			items, token, err := q.execute(context.Background(), c.listPage, c.readBlob)
+			require.NoError(t, err)
+			assert.Empty(t, token)
+			assert.Equal(t, tt.expected, keys(items))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []string{
+			`{"filter":{"EQ":{"person.org":"A"}}}`,
+			`{"filter":{"EQ":{"tags.":"A"}}}`,
+			`{"filter":{"OR":[{"EQ":{"keyPrefix":"order-"}},{"EQ":{"key":"user-1"}}]}}`,
+			`{"filter":{"AND":[{"EQ":{"keyPrefix":"order-"}},{"EQ":{"keyPrefix":"user-"}}]}}`,
+			`{"sort":[{"key":"key"}]}`,
+			`{"page":{"token":"invalid"}}`,
+		}
+		for _, s := range invalid {
+			_, err := newBlobQuery(parseQuery(t, s))
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestQueryResults(t *testing.T) {
+	c := &fakeContainer{blobs: []*container.BlobItem{
+		newBlob(t, "a", nil, nil),
+		newBlob(t, "broken", nil, nil),
+		newBlob(t, "deleted", nil, nil),
+	}}
+	q, err := newBlobQuery(parseQuery(t, `{}`))
+	require.NoError(t, err)
+	items, _, err := q.execute(context.Background(), c.listPage, c.readBlob)
+	require.NoError(t, err)
+
+	require.Len(t, items, 2)
+	assert.Equal(t, "a", items[0].Key)
+	assert.Equal(t, []byte(`"a"`), items[0].Data)
+	assert.Equal(t, "etag-a", *items[0].ETag)
+	assert.Equal(t, "broken", items[1].Key)
+	assert.Equal(t, "read error", items[1].Error)
+}
+
+func TestQueryPagination(t *testing.T) {
+	c := &fakeContainer{}
+	for i := 0; i < 10; i++ {
+		status := "closed"
+		if i%3 == 0 {
+			status = "open"
+		}
+		c.blobs = append(c.blobs, newBlob(t, fmt.Sprintf("order-%d", i), map[string]string{"status": status}, nil))
+	}
+
+	t.Run("without filter", func(t *testing.T) {
+		var all []string
+		token := ""
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 10)
+			q, err := newBlobQuery(parseQuery(t, `{"page":{"limit":4,"token":"`+token+`"}}`))
+			require.NoError(t, err)
+			var items []state.QueryItem
+			items, token, err = q.execute(context.Background(), c.listPage, c.readBlob)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(items), 4)
+			all = append(all, keys(items)...)
+			if token == "" {
+				break
+			}
+		}
+		assert.Len(t, all, 10)
+		assert.Equal(t, "order-0", all[0])
+		assert.Equal(t, "order-9", all[9])
+	})
+
+	t.Run("with filter", func(t *testing.T) {
+		c.pageSizes = nil
+		var all []string
+		token := ""
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 10)
+			// The size of the listing pages is in the token, so it doesn't change with the limit
+			limit := 2 + pages
+			q, err := newBlobQuery(parseQuery(t, fmt.Sprintf(`{"filter":{"EQ":{"tags.status":"open"}},"page":{"limit":%d,"token":"%s"}}`, limit, token)))
+			require.NoError(t, err)
+			var items []state.QueryItem
+			items, token, err = q.execute(context.Background(), c.listPage, c.readBlob)
+			require.NoError(t, err)
+			all = append(all, keys(items)...)
+			if token == "" {
+				break
+			}
+		}
+		assert.Equal(t, []string{"order-0", "order-3", "order-6", "order-9"}, all)
+		for _, size := range c.pageSizes {
+			assert.Equal(t, int32(2), size)
+		}
+	})
+}
+
+// findBlobs finds the blobs with the tags of a where expression of equalities, with the index of the next blob as marker.
+func (c *fakeContainer) findBlobs(_ context.Context, where string, marker string, maxResults int32) ([]string, string, error) {
+	c.pageSizes = append(c.pageSizes, maxResults)
+
+	var names []string
+	for _, b := range c.blobs {
+		match := true
+		for _, cond := range strings.Split(where, " AND ") {
+			name, value, _ := strings.Cut(cond, "=")
+			tag, ok := blobTag(b, strings.Trim(name, `"`))
+			match = match && ok && tag == strings.Trim(value, "'")
+		}
+		if match {
+			names = append(names, *b.Name)
+		}
+	}
+	start := 0
+	if marker != "" {
+		start, _ = strconv.Atoi(marker)
+	}
+	end := start + int(maxResults)
+	if end >= len(names) {
+		return names[start:], "", nil
+	}
+	return names[start:end], strconv.Itoa(end), nil
+}
+
+func TestQueryIndex(t *testing.T) {
+	tests := map[string]string{
+		`{}`: "",
+		`{"filter":{"EQ":{"tags.status":"open"}}}`:                                            `"status"='open'`,
+		`{"filter":{"AND":[{"EQ":{"tags.status":"open"}},{"EQ":{"tags.region":"eu-west"}}]}}`: `"status"='open' AND "region"='eu-west'`,
+		`{"filter":{"AND":[{"EQ":{"keyPrefix":"order-"}},{"EQ":{"tags.status":"open"}}]}}`:    "",
+		`{"filter":{"AND":[{"EQ":{"tags.status":"open"}},{"EQ":{"metadata.region":"eu"}}]}}`:  "",
+		`{"filter":{"IN":{"tags.status":["open","closed"]}}}`:                                 "",
+		`{"filter":{"EQ":{"tags.status":"it's open"}}}`:                                       "",
+	}
+	for s, where := range tests {
+		q, err := newBlobQuery(parseQuery(t, s))
+		require.NoError(t, err)
+		assert.Equal(t, where, q.where, s)
+	}
+
+	c := &fakeContainer{}
+	for i := 0; i < 10; i++ {
+		status := "closed"
+		if i%3 == 0 {
+			status = "open"
+		}
+		c.blobs = append(c.blobs, newBlob(t, fmt.Sprintf("order-%d", i), map[string]string{"status": status}, nil))
+	}
+
+	q, err := newBlobQuery(parseQuery(t, `{"filter":{"EQ":{"tags.status":"open"}}}`))
+	require.NoError(t, err)
+	items, token, err := q.executeIndex(context.Background(), c.findBlobs, c.readBlob)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+	assert.Equal(t, []string{"order-0", "order-3", "order-6", "order-9"}, keys(items))
+	assert.Equal(t, "etag-order-3", *items[1].ETag)
+
+	var all []string
+	token = ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		q, err = newBlobQuery(parseQuery(t, `{"filter":{"EQ":{"tags.status":"open"}},"page":{"limit":3,"token":"`+token+`"}}`))
+		require.NoError(t, err)
+		items, token, err = q.executeIndex(context.Background(), c.findBlobs, c.readBlob)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(items), 3)
+		all = append(all, keys(items)...)
+		if token == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"order-0", "order-3", "order-6", "order-9"}, all)
+}
+
+func TestListKeys(t *testing.T) {
+	c := &fakeContainer{}
+	for _, name := range []string{"order-1", "order-2", "order-3", "user-1"} {
+		c.blobs = append(c.blobs, newBlob(t, name, nil, nil))
+	}
+	// Tombstone of a deleted key
+	c.blobs = append(c.blobs, newBlob(t, "user-2", nil, map[string]string{"Daprdeleted": "true"}))
+
+	res, err := listKeys(context.Background(), c.listPage, &state.KeysRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order-1", "order-2", "order-3", "user-1"}, res.Keys)
+	assert.Empty(t, res.Token)
+	assert.Equal(t, int32(maxPageSize), c.pageSizes[0])
+
+	var all []string
+	req := &state.KeysRequest{Prefix: "order-", Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		res, err = listKeys(context.Background(), c.listPage, req)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(res.Keys), 2)
+		all = append(all, res.Keys...)
+		if res.Token == "" {
+			break
+		}
+		req.Token = res.Token
+	}
+	assert.Equal(t, []string{"order-1", "order-2", "order-3"}, all)
+
+	res, err = listKeys(context.Background(), prefixedListPage(c.listPage, "order-"), &state.KeysRequest{Prefix: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, res.Keys)
+
+	_, err = listKeys(context.Background(), c.listPage, &state.KeysRequest{Limit: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	// snapshotMetadataKey is the request metadata of Get with the snapshot of the blob to read.
+	snapshotMetadataKey = "snapshot"
+	// snapshotAsOfMetadataKey is the request metadata of Get with a time in RFC 3339 format:
+	// the value is read from the latest snapshot of the blob taken at or before that time.
+	snapshotAsOfMetadataKey = "snapshotAsOf"
+)
+
+// createSnapshot creates a snapshot of the blob, if it's still at the ETag that was written.
+func (r *StateStore) createSnapshot(ctx context.Context, client *blockblob.Client, etag *azcore.ETag) error {
+	opts := &blob.CreateSnapshotOptions{}
+	if etag != nil {
+		opts.AccessConditions = &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfMatch: etag},
+		}
+	}
+	if r.encryptionScope != "" {
+		opts.CpkScopeInfo = &blob.CpkScopeInfo{EncryptionScope: ptr.Of(r.encryptionScope)}
+	}
+
+	_, err := client.CreateSnapshot(ctx, opts)
+	if err != nil {
+		if isETagConflictError(err) {
+			// The blob was written again since, and that write creates its own snapshot
+			return nil
+		}
+		return fmt.Errorf("error creating snapshot of az blob: %w", err)
+	}
+
+	return nil
+}
+
+// getReadClient returns the client to read the blob with, which reads the snapshot requested in the request metadata, if any.
+// It returns nil if a snapshot was requested by time, but the blob has no snapshot taken before then.
+func (r *StateStore) getReadClient(ctx context.Context, name string, reqMetadata map[string]string) (client *blockblob.Client, isSnapshot bool, err error) {
+	client = r.containerClient.NewBlockBlobClient(name)
+
+	snapshot := reqMetadata[snapshotMetadataKey]
+	if asOf := reqMetadata[snapshotAsOfMetadataKey]; asOf != "" {
+		if snapshot != "" {
+			return nil, false, fmt.Errorf("only one of the %s and %s metadata can be set", snapshotMetadataKey, snapshotAsOfMetadataKey)
+		}
+		t, err := time.Parse(time.RFC3339Nano, asOf)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s metadata: %w", snapshotAsOfMetadataKey, err)
+		}
+		snapshots, err := r.listSnapshots(ctx, name)
+		if err != nil {
+			return nil, false, err
+		}
+		snapshot = latestSnapshot(snapshots, t)
+		if snapshot == "" {
+			return nil, true, nil
+		}
+	}
+	if snapshot == "" {
+		return client, false, nil
+	}
+
+	client, err = client.WithSnapshot(snapshot)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s metadata: %w", snapshotMetadataKey, err)
+	}
+	return client, true, nil
+}
+
+// listSnapshots returns the snapshots of the blob.
+func (r *StateStore) listSnapshots(ctx context.Context, name string) ([]string, error) {
+	pager := r.containerClient.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Include: container.ListBlobsInclude{Snapshots: true},
+		Prefix:  ptr.Of(name),
+	})
+
+	var snapshots []string
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing snapshots of az blob %s: %w", name, err)
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, item := range page.Segment.BlobItems {
+			// The prefix also matches the blobs whose name starts with the name of this blob
+			if item.Name != nil && *item.Name == name && item.Snapshot != nil {
+				snapshots = append(snapshots, *item.Snapshot)
+			}
+		}
+	}
+
+	return snapshots, nil
+}
+
+// latestSnapshot returns the latest of the snapshots taken at or before the time, or an empty string if there's none.
+// Snapshots are identified by the time they were taken at.
+func latestSnapshot(snapshots []string, asOf time.Time) string {
+	var (
+		latest     string
+		latestTime time.Time
+	)
+	for _, s := range snapshots {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil || t.After(asOf) {
+			continue
+		}
+		if latest == "" || t.After(latestTime) {
+			latest, latestTime = s, t
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestLatestSnapshot(t *testing.T) {
+	snapshots := []string{
+		"2022-10-01T12:00:00.0000000Z",
+		"2022-10-03T12:00:00.0000000Z",
+		"2022-10-02T12:00:00.5000000Z",
+		"invalid",
+	}
+
+	assert.Equal(t, "", latestSnapshot(snapshots, time.Date(2022, 9, 30, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2022-10-01T12:00:00.0000000Z", latestSnapshot(snapshots, time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2022-10-02T12:00:00.5000000Z", latestSnapshot(snapshots, time.Date(2022, 10, 3, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2022-10-03T12:00:00.0000000Z", latestSnapshot(snapshots, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "", latestSnapshot(nil, time.Now()))
+}
+
+func TestGetReadClient(t *testing.T) {
+	s := NewAzureBlobStorageStore(logger.NewLogger("logger")).(*StateStore)
+	require.NoError(t, s.Init(state.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"accountName":   "acc",
+		"accountKey":    "e+Dnvl8EOxYxV94nurVaRQ==",
+		"containerName": "dapr",
+	}}}))
+
+	t.Run("current value", func(t *testing.T) {
+		client, isSnapshot, err := s.getReadClient(context.Background(), "key", nil)
+		require.NoError(t, err)
+		assert.False(t, isSnapshot)
+		assert.Equal(t, "https://acc.blob.core.windows.net/dapr/key", client.URL())
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		client, isSnapshot, err := s.getReadClient(context.Background(), "key", map[string]string{
+			"snapshot": "2022-10-01T12:00:00.0000000Z",
+		})
+		require.NoError(t, err)
+		assert.True(t, isSnapshot)
+		assert.Contains(t, client.URL(), "?snapshot=2022-10-01T12:00:00.0000000Z")
+	})
+
+	t.Run("invalid snapshotAsOf", func(t *testing.T) {
+		_, _, err := s.getReadClient(context.Background(), "key", map[string]string{
+			"snapshotAsOf": "yesterday",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("snapshot and snapshotAsOf", func(t *testing.T) {
+		_, _, err := s.getReadClient(context.Background(), "key", map[string]string{
+			"snapshot":     "2022-10-01T12:00:00.0000000Z",
+			"snapshotAsOf": "2022-10-01T12:00:00Z",
+		})
+		assert.Error(t, err)
+	})
+}
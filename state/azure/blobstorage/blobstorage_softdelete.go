@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+// undeleteMetadataKey is the request metadata of Get that restores the blob, if it's soft-deleted, before reading it.
+const undeleteMetadataKey = "undelete"
+
+// undelete restores the blob and its snapshots if they're soft-deleted.
+// It's a no-op if the blob isn't soft-deleted.
+func (r *StateStore) undelete(ctx context.Context, name string) error {
+	_, err := r.containerClient.NewBlobClient(name).Undelete(ctx, nil)
+	if err != nil && !isNotFoundError(err) {
+		return fmt.Errorf("error undeleting az blob %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// softDeletedETags returns the ETags of the soft-deleted versions of the blob.
+func (r *StateStore) softDeletedETags(ctx context.Context, name string) ([]string, error) {
+	pager := r.containerClient.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Include: container.ListBlobsInclude{Deleted: true},
+		Prefix:  ptr.Of(name),
+	})
+
+	var etags []string
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing soft-deleted az blob %s: %w", name, err)
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, item := range page.Segment.BlobItems {
+			// The prefix also matches the blobs whose name starts with the name of this blob
+			if item.Name == nil || *item.Name != name || item.Deleted == nil || !*item.Deleted {
+				continue
+			}
+			if item.Properties != nil && item.Properties.ETag != nil {
+				etags = append(etags, string(*item.Properties.ETag))
+			}
+		}
+	}
+
+	return etags, nil
+}
+
+// checkDeletedETag is called when the blob to delete with an ETag doesn't exist. The delete succeeds if the blob was
+// soft-deleted at that ETag, as it was already deleted, and fails with an ETag mismatch otherwise.
+func (r *StateStore) checkDeletedETag(ctx context.Context, name string, etag string) error {
+	etags, err := r.softDeletedETags(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, e := range etags {
+		if e == etag {
+			return nil
+		}
+	}
+
+	return state.NewETagError(state.ETagMismatch, fmt.Errorf("az blob %s doesn't exist", name))
+}
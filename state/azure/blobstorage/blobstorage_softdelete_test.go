@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+const deletedBlobsList = `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ServiceEndpoint="http://127.0.0.1/acc" ContainerName="dapr">
+  <Blobs>
+    <Blob>
+      <Name>key</Name>
+      <Deleted>true</Deleted>
+      <Properties><Etag>0x1</Etag></Properties>
+    </Blob>
+    <Blob>
+      <Name>key2</Name>
+      <Deleted>true</Deleted>
+      <Properties><Etag>0x2</Etag></Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker />
+</EnumerationResults>`
+
+// newSoftDeleteTestStore returns a state store backed by a server where the blob "key" doesn't exist,
+// but was soft-deleted at ETag 0x1.
+func newSoftDeleteTestStore(t *testing.T) (*StateStore, *[]string) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.Query().Get("comp"))
+		switch {
+		case r.Method == http.MethodPut && r.URL.Query().Get("restype") == "container":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Query().Get("comp") == "undelete":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(deletedBlobsList))
+		default:
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	s := NewAzureBlobStorageStore(logger.NewLogger("test")).(*StateStore)
+	require.NoError(t, s.Init(state.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"accountName":     "acc",
+		"accountKey":      "e+Dnvl8EOxYxV94nurVaRQ==",
+		"containerName":   "dapr",
+		"endpoint":        server.URL,
+		"softDeleteAware": "true",
+	}}}))
+	requests = nil
+
+	return s, &requests
+}
+
+func TestSoftDeleteAwareDelete(t *testing.T) {
+	s, _ := newSoftDeleteTestStore(t)
+
+	t.Run("ETag of the soft-deleted blob", func(t *testing.T) {
+		err := s.Delete(&state.DeleteRequest{Key: "app||key", ETag: ptr.Of("0x1")})
+		assert.NoError(t, err)
+	})
+
+	t.Run("other ETag", func(t *testing.T) {
+		err := s.Delete(&state.DeleteRequest{Key: "app||key", ETag: ptr.Of("0x2")})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+
+	t.Run("without ETag", func(t *testing.T) {
+		err := s.Delete(&state.DeleteRequest{Key: "app||key"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("not soft-delete aware", func(t *testing.T) {
+		s.softDeleteAware = false
+		defer func() { s.softDeleteAware = true }()
+		err := s.Delete(&state.DeleteRequest{Key: "app||key", ETag: ptr.Of("0x2")})
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetUndelete(t *testing.T) {
+	s, requests := newSoftDeleteTestStore(t)
+
+	res, err := s.Get(&state.GetRequest{Key: "app||key", Metadata: map[string]string{"undelete": "true"}})
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+	assert.Equal(t, []string{"PUT /acc/dapr/key?undelete", "GET /acc/dapr/key?"}, *requests)
+}
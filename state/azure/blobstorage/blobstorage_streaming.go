@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+)
+
+const (
+	// defaultStreamingBlockSize is the size of the blocks that large values are uploaded and downloaded in.
+	defaultStreamingBlockSize = 4 << 20
+	// streamingUploadConcurrency is the number of blocks of a value uploaded at once, each with its own buffer.
+	streamingUploadConcurrency = 4
+	// streamingReadRetries is the number of times the download of a large value is resumed after a failed read.
+	streamingReadRetries = 3
+)
+
+// upload uploads the value of a blob. Values larger than streamingThresholdBytes are staged block by block, with
+// streamingUploadConcurrency blocks at once, and then committed; the others are uploaded with a single request.
+func (r *StateStore) upload(ctx context.Context, client *blockblob.Client, data []byte, opts *azblob.UploadBufferOptions) (*azcore.ETag, error) {
+	if !r.isStreamed(int64(len(data))) {
+		res, err := client.UploadBuffer(ctx, data, opts)
+		if err != nil {
+			return nil, err
+		}
+		return res.ETag, nil
+	}
+
+	res, err := client.UploadStream(ctx, bytes.NewReader(data), &blockblob.UploadStreamOptions{
+		BlockSize:        int(r.streamingBlockSize),
+		Concurrency:      streamingUploadConcurrency,
+		HTTPHeaders:      opts.HTTPHeaders,
+		Metadata:         opts.Metadata,
+		AccessConditions: opts.AccessConditions,
+		Tags:             opts.Tags,
+		CpkScopeInfo:     opts.CpkScopeInfo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.ETag, nil
+}
+
+// readBody reads the body of a downloaded blob. Values larger than streamingThresholdBytes are read block by block into a
+// buffer of the size of the blob, and a failed read is resumed from where it stopped, as long as the blob hasn't changed.
+func (r *StateStore) readBody(ctx context.Context, res *blob.DownloadStreamResponse) ([]byte, error) {
+	if res.ContentLength == nil || !r.isStreamed(*res.ContentLength) {
+		return io.ReadAll(res.Body)
+	}
+
+	reader := res.NewRetryReader(ctx, &blob.RetryReaderOptions{MaxRetries: streamingReadRetries})
+	defer reader.Close()
+	return readChunked(reader, *res.ContentLength, r.streamingBlockSize)
+}
+
+// isStreamed returns true if a value of the size is uploaded and downloaded in blocks.
+func (r *StateStore) isStreamed(size int64) bool {
+	return r.streamingThreshold > 0 && size > r.streamingThreshold
+}
+
+// readChunked reads size bytes from the reader, at most blockSize bytes at a time.
+func readChunked(reader io.Reader, size int64, blockSize int64) ([]byte, error) {
+	data := make([]byte, size)
+	for offset := int64(0); offset < size; offset += blockSize {
+		end := offset + blockSize
+		if end > size {
+			end = size
+		}
+		if _, err := io.ReadFull(reader, data[offset:end]); err != nil {
+			return nil, fmt.Errorf("error reading az blob at offset %d: %w", offset, err)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestReadChunked(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 10))
+
+	t.Run("exact blocks", func(t *testing.T) {
+		res, err := readChunked(bytes.NewReader(data), 100, 10)
+		require.NoError(t, err)
+		assert.Equal(t, data, res)
+	})
+
+	t.Run("last block shorter", func(t *testing.T) {
+		res, err := readChunked(iotest.OneByteReader(bytes.NewReader(data)), 100, 30)
+		require.NoError(t, err)
+		assert.Equal(t, data, res)
+	})
+
+	t.Run("body shorter than size", func(t *testing.T) {
+		_, err := readChunked(bytes.NewReader(data[:50]), 100, 30)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
+
+func TestStreamingSet(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		methods []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		lock.Lock()
+		methods = append(methods, r.Method+" "+r.URL.Query().Get("comp"))
+		lock.Unlock()
+		switch {
+		case r.Method == http.MethodPut && r.URL.Query().Get("restype") == "container":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut:
+			w.Header().Set("ETag", "0x1")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	s := NewAzureBlobStorageStore(logger.NewLogger("test")).(*StateStore)
+	require.NoError(t, s.Init(state.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"accountName":             "acc",
+		"accountKey":              "e+Dnvl8EOxYxV94nurVaRQ==",
+		"containerName":           "dapr",
+		"endpoint":                server.URL,
+		"streamingThresholdBytes": "1024",
+		"streamingBlockSizeBytes": "1048576",
+	}}}))
+
+	t.Run("small value", func(t *testing.T) {
+		methods = nil
+		require.NoError(t, s.Set(&state.SetRequest{Key: "key", Value: []byte("small")}))
+		assert.Equal(t, []string{"PUT "}, methods)
+	})
+
+	t.Run("large value", func(t *testing.T) {
+		methods = nil
+		require.NoError(t, s.Set(&state.SetRequest{Key: "key", Value: bytes.Repeat([]byte("a"), 3<<20)}))
+		assert.Equal(t, []string{"PUT block", "PUT block", "PUT block", "PUT blocklist"}, methods)
+	})
+}
+
+func TestStreamingMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	props := map[string]string{
+		"accountName":   "acc",
+		"accountKey":    "e+Dnvl8EOxYxV94nurVaRQ==",
+		"containerName": "dapr",
+		"endpoint":      server.URL,
+	}
+
+	t.Run("negative threshold", func(t *testing.T) {
+		s := NewAzureBlobStorageStore(logger.NewLogger("test")).(*StateStore)
+		p := map[string]string{"streamingThresholdBytes": "-1"}
+		for k, v := range props {
+			p[k] = v
+		}
+		err := s.Init(state.Metadata{Base: mdata.Base{Properties: p}})
+		assert.ErrorContains(t, err, "invalid streamingThresholdBytes")
+	})
+
+	t.Run("zero block size", func(t *testing.T) {
+		s := NewAzureBlobStorageStore(logger.NewLogger("test")).(*StateStore)
+		p := map[string]string{"streamingBlockSizeBytes": "0"}
+		for k, v := range props {
+			p[k] = v
+		}
+		err := s.Init(state.Metadata{Base: mdata.Base{Properties: p}})
+		assert.ErrorContains(t, err, "invalid streamingBlockSizeBytes")
+	})
+}
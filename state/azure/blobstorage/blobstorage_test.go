@@ -14,13 +14,21 @@ limitations under the License.
 package blobstorage
 
 import (
+	"crypto/md5" //nolint:gosec
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
 func TestInit(t *testing.T) {
@@ -58,14 +66,168 @@ func TestInit(t *testing.T) {
 	})
 }
 
-func TestFileName(t *testing.T) {
-	t.Run("Valid composite key", func(t *testing.T) {
-		key := getFileName("app_id||key")
-		assert.Equal(t, "key", key)
+func TestParseTTL(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		ttl, err := parseTTL(map[string]string{})
+		assert.NoError(t, err)
+		assert.Nil(t, ttl)
 	})
 
-	t.Run("No delimiter present", func(t *testing.T) {
-		key := getFileName("key")
-		assert.Equal(t, "key", key)
+	t.Run("valid", func(t *testing.T) {
+		ttl, err := parseTTL(map[string]string{"ttlInSeconds": "60"})
+		assert.NoError(t, err)
+		assert.Equal(t, 60, *ttl)
 	})
+
+	t.Run("never expire", func(t *testing.T) {
+		ttl, err := parseTTL(map[string]string{"ttlInSeconds": "-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, -1, *ttl)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseTTL(map[string]string{"ttlInSeconds": "a"})
+		assert.Error(t, err)
+		_, err = parseTTL(map[string]string{"ttlInSeconds": "-2"})
+		assert.Error(t, err)
+	})
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("no expiry time", func(t *testing.T) {
+		expired, err := isExpired(map[string]string{"foo": "bar"}, now)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+	})
+
+	t.Run("not expired", func(t *testing.T) {
+		expired, err := isExpired(map[string]string{"Expirytime": "2022-10-01T12:00:01Z"}, now)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired, err := isExpired(map[string]string{"expirytime": "2022-10-01T12:00:00Z"}, now)
+		assert.NoError(t, err)
+		assert.True(t, expired)
+	})
+
+	t.Run("invalid expiry time", func(t *testing.T) {
+		_, err := isExpired(map[string]string{"expirytime": "tomorrow"}, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestSplitTags(t *testing.T) {
+	tags, metadata, err := splitTags(map[string]string{
+		"tags.status": "open",
+		"tags.owner":  "team-a/eu",
+		"contentType": "application/json",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": "open", "owner": "team-a/eu"}, tags)
+	assert.Equal(t, map[string]string{"contentType": "application/json"}, metadata)
+
+	tags, _, err = splitTags(map[string]string{"region": "eu"})
+	assert.NoError(t, err)
+	assert.Nil(t, tags)
+
+	invalid := []map[string]string{
+		{"tags.": "a"},
+		{"tags.status": "it's open"},
+	}
+	tooMany := map[string]string{}
+	for i := 0; i <= maxTags; i++ {
+		tooMany[fmt.Sprintf("tags.t%d", i)] = "v"
+	}
+	invalid = append(invalid, tooMany)
+	for _, m := range invalid {
+		_, _, err = splitTags(m)
+		assert.Error(t, err, m)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	s := &StateStore{maxConcurrency: 3}
+
+	var running, maxRunning atomic.Int32
+	err := s.parallel(20, func(i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		switch i {
+		case 4:
+			return state.NewBulkStoreError("key-4", state.NewETagError(state.ETagMismatch, nil))
+		case 2:
+			return state.NewBulkStoreError("key-2", errors.New("failed"))
+		}
+		return nil
+	})
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+
+	var merr *multierror.Error
+	require.ErrorAs(t, err, &merr)
+	require.Len(t, merr.Errors, 2)
+	var bulkErr *state.BulkStoreError
+	require.ErrorAs(t, merr.Errors[0], &bulkErr)
+	assert.Equal(t, "key-2", bulkErr.Key())
+	var etagErr *state.ETagError
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	assert.NoError(t, s.parallel(0, func(i int) error { return errors.New("unexpected") }))
+}
+
+func TestContentHeaders(t *testing.T) {
+	t.Run("JSON value", func(t *testing.T) {
+		req := &state.SetRequest{Key: "key", Value: map[string]string{"a": "b"}}
+		s := &StateStore{}
+		data := s.marshal(req)
+		headers := blob.HTTPHeaders{}
+		setContentHeaders(&headers, req, data)
+
+		sum := md5.Sum(data) //nolint:gosec
+		assert.Equal(t, sum[:], headers.BlobContentMD5)
+		assert.Equal(t, "application/json", *headers.BlobContentType)
+		assert.NoError(t, checkContentMD5("key", data, headers.BlobContentMD5))
+	})
+
+	t.Run("bytes keep their content type", func(t *testing.T) {
+		req := &state.SetRequest{Key: "key", Value: []byte("text"), ContentType: ptr.Of("text/plain")}
+		headers := blob.HTTPHeaders{BlobContentType: req.ContentType}
+		setContentHeaders(&headers, req, []byte("text"))
+		assert.Equal(t, "text/plain", *headers.BlobContentType)
+
+		headers = blob.HTTPHeaders{}
+		setContentHeaders(&headers, &state.SetRequest{Key: "key", Value: []byte("text")}, []byte("text"))
+		assert.Nil(t, headers.BlobContentType)
+	})
+
+	t.Run("ContentMD5 of the request", func(t *testing.T) {
+		headers := blob.HTTPHeaders{BlobContentMD5: []byte("0123456789abcdef")}
+		setContentHeaders(&headers, &state.SetRequest{Key: "key", Value: []byte("text")}, []byte("text"))
+		assert.Equal(t, []byte("0123456789abcdef"), headers.BlobContentMD5)
+	})
+}
+
+func TestCheckContentMD5(t *testing.T) {
+	sum := md5.Sum([]byte("complete value")) //nolint:gosec
+
+	assert.NoError(t, checkContentMD5("key", []byte("complete value"), sum[:]))
+	assert.NoError(t, checkContentMD5("key", []byte("complete value"), nil))
+
+	err := checkContentMD5("key", []byte("complete"), sum[:])
+	var mismatch *ContentMD5MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "key", mismatch.Blob)
+	assert.Equal(t, sum[:], mismatch.Expected)
+	assert.Contains(t, err.Error(), "is corrupted")
 }
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	concurrencyModeETag      = "etag"
+	concurrencyModeTimestamp = "timestamp"
+
+	// timestampMetadataKey is the request metadata of Set and Delete with the time of the write, in RFC 3339 format.
+	timestampMetadataKey = "timestamp"
+	// timestampBlobMetadataKey is the name of the blob metadata with the time of the last write.
+	timestampBlobMetadataKey = "daprtimestamp"
+	// deletedBlobMetadataKey is the name of the blob metadata of the tombstones of the deleted keys.
+	deletedBlobMetadataKey = "daprdeleted"
+
+	// maxTimestampAttempts is the number of times a write is attempted when the blob changes between reading its timestamp and writing it.
+	maxTimestampAttempts = 3
+)
+
+// errStaleWrite is returned when the timestamp of a write isn't later than the timestamp of the stored value.
+var errStaleWrite = errors.New("the timestamp of the request isn't later than the timestamp of the stored value")
+
+// writeFileWithTimestamp writes the blob if the timestamp of the request is later than the timestamp of the stored value.
+// The write is conditional on the ETag of the blob whose timestamp was compared, and is retried if the blob changed since.
+func (r *StateStore) writeFileWithTimestamp(ctx context.Context, req *state.SetRequest) error {
+	ts, err := parseTimestamp(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		etag, err := r.checkTimestamp(ctx, r.prefixer.blobName(req.Key, req.Metadata), req.ETag, ts)
+		if err != nil {
+			return err
+		}
+
+		// The request is copied, as its ETag and metadata are replaced
+		tsReq := *req
+		tsReq.Metadata = make(map[string]string, len(req.Metadata)+1)
+		for k, v := range req.Metadata {
+			if k != timestampMetadataKey {
+				tsReq.Metadata[k] = v
+			}
+		}
+		tsReq.Metadata[timestampBlobMetadataKey] = ts.UTC().Format(time.RFC3339Nano)
+		if etag != nil {
+			tsReq.ETag = ptr.Of(string(*etag))
+		} else {
+			tsReq.ETag = nil
+			tsReq.Options.Concurrency = state.FirstWrite
+		}
+
+		err = r.uploadFile(ctx, &tsReq)
+		if err == nil || !isTimestampRetriable(err, req.ETag) || attempt == maxTimestampAttempts {
+			return err
+		}
+	}
+}
+
+// deleteFileWithTimestamp replaces the blob with a tombstone if the timestamp of the request is later than the timestamp
+// of the stored value. The tombstone keeps the timestamp of the delete, so that a write with an earlier timestamp that
+// arrives late doesn't recreate the key; it's written even if the blob doesn't exist.
+func (r *StateStore) deleteFileWithTimestamp(ctx context.Context, req *state.DeleteRequest) error {
+	ts, err := parseTimestamp(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	name := r.prefixer.blobName(req.Key, req.Metadata)
+	for attempt := 1; ; attempt++ {
+		etag, err := r.checkTimestamp(ctx, name, req.ETag, ts)
+		if err != nil {
+			return err
+		}
+
+		err = r.writeTombstone(ctx, name, etag, ts)
+		if err == nil || !isTimestampRetriable(err, req.ETag) || attempt == maxTimestampAttempts {
+			return err
+		}
+	}
+}
+
+// writeTombstone writes an empty blob with the timestamp of a delete, on the condition that the blob is at the ETag,
+// or doesn't exist if the ETag is nil. The snapshots of the deleted value are deleted.
+func (r *StateStore) writeTombstone(ctx context.Context, name string, etag *azcore.ETag, ts time.Time) error {
+	modifiedAccessConditions := blob.ModifiedAccessConditions{}
+	if etag != nil {
+		modifiedAccessConditions.IfMatch = etag
+	} else {
+		modifiedAccessConditions.IfNoneMatch = ptr.Of(azcore.ETagAny)
+	}
+
+	uploadOptions := azblob.UploadBufferOptions{
+		AccessConditions: &blob.AccessConditions{ModifiedAccessConditions: &modifiedAccessConditions},
+		Metadata: map[string]string{
+			timestampBlobMetadataKey: ts.UTC().Format(time.RFC3339Nano),
+			deletedBlobMetadataKey:   "true",
+		},
+	}
+	if r.encryptionScope != "" {
+		uploadOptions.CpkScopeInfo = &blob.CpkScopeInfo{EncryptionScope: ptr.Of(r.encryptionScope)}
+	}
+
+	blockBlobClient := r.containerClient.NewBlockBlobClient(name)
+	if _, err := r.upload(ctx, blockBlobClient, []byte{}, &uploadOptions); err != nil {
+		return fmt.Errorf("error uploading tombstone of az blob: %w", err)
+	}
+
+	if r.enableSnapshots {
+		_, err := blockBlobClient.Delete(ctx, &blob.DeleteOptions{DeleteSnapshots: ptr.Of(blob.DeleteSnapshotsOptionTypeOnly)})
+		if err != nil && !isNotFoundError(err) {
+			return fmt.Errorf("error deleting snapshots of az blob: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkTimestamp returns the ETag of the blob, or nil if it doesn't exist, if its value is older than the timestamp.
+// If the request has an ETag, the blob must be at that ETag.
+func (r *StateStore) checkTimestamp(ctx context.Context, name string, reqETag *string, ts time.Time) (*azcore.ETag, error) {
+	props, err := r.containerClient.NewBlobClient(name).GetProperties(ctx, nil)
+	if err != nil {
+		if isNotFoundError(err) {
+			if reqETag != nil && *reqETag != "" {
+				return nil, state.NewETagError(state.ETagMismatch, err)
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading properties of az blob %s: %w", name, err)
+	}
+
+	if reqETag != nil && *reqETag != "" && (props.ETag == nil || string(*props.ETag) != *reqETag) {
+		return nil, state.NewETagError(state.ETagMismatch, nil)
+	}
+
+	stale, err := isStale(props.Metadata, ts)
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		return nil, state.NewETagError(state.ETagMismatch, errStaleWrite)
+	}
+
+	return props.ETag, nil
+}
+
+// isTombstone returns true if the blob metadata is the one of the tombstone of a deleted key.
+func isTombstone(blobMetadata map[string]string) bool {
+	v, ok := getBlobMetadata(blobMetadata, deletedBlobMetadataKey)
+	return ok && v == "true"
+}
+
+// parseTimestamp returns the timestamp of the request metadata, or the current time if it's not set.
+func parseTimestamp(requestMetadata map[string]string) (time.Time, error) {
+	val, ok := requestMetadata[timestampMetadataKey]
+	if !ok || val == "" {
+		return time.Now(), nil
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing %s metadata: %w", timestampMetadataKey, err)
+	}
+
+	return ts, nil
+}
+
+// isStale returns true if the blob metadata has a timestamp that isn't earlier than the timestamp of a write.
+// Blobs written without a timestamp are never stale.
+func isStale(blobMetadata map[string]string, ts time.Time) (bool, error) {
+	v, ok := getBlobMetadata(blobMetadata, timestampBlobMetadataKey)
+	if !ok {
+		return false, nil
+	}
+
+	stored, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s metadata of az blob: %w", timestampBlobMetadataKey, err)
+	}
+
+	return !ts.After(stored), nil
+}
+
+// isTimestampRetriable returns true if a write failed because the blob changed after its timestamp was compared,
+// unless the write was conditional on the ETag of the request.
+func isTimestampRetriable(err error, reqETag *string) bool {
+	if reqETag != nil && *reqETag != "" {
+		return false
+	}
+	var etagErr *state.ETagError
+	return errors.As(err, &etagErr) || bloberror.HasCode(err, bloberror.BlobAlreadyExists, bloberror.ConditionNotMet)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	ts, err := parseTimestamp(map[string]string{timestampMetadataKey: "2022-10-01T10:00:00.5Z"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 10, 1, 10, 0, 0, 5e8, time.UTC), ts)
+
+	ts, err = parseTimestamp(map[string]string{})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), ts, time.Minute)
+
+	_, err = parseTimestamp(map[string]string{timestampMetadataKey: "1664618400"})
+	assert.Error(t, err)
+}
+
+func TestIsStale(t *testing.T) {
+	stored := map[string]string{"Daprtimestamp": "2022-10-01T10:00:00Z"}
+
+	stale, err := isStale(stored, time.Date(2022, 10, 1, 10, 0, 1, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, stale)
+
+	stale, err = isStale(stored, time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, stale, "same timestamp")
+
+	stale, err = isStale(stored, time.Date(2022, 10, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, stale)
+
+	stale, err = isStale(map[string]string{}, time.Time{})
+	require.NoError(t, err)
+	assert.False(t, stale, "blob without timestamp")
+
+	_, err = isStale(map[string]string{timestampBlobMetadataKey: "yesterday"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestTimestampConcurrency(t *testing.T) {
+	var uploads []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Query().Get("restype") == "container":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodHead && r.URL.Path == "/acc/dapr/key":
+			w.Header().Set("ETag", "0x1")
+			w.Header().Set("x-ms-meta-daprtimestamp", "2022-10-01T10:00:00Z")
+			w.WriteHeader(http.StatusOK)
+		case (r.Method == http.MethodHead || r.Method == http.MethodGet) && r.URL.Path == "/acc/dapr/deleted":
+			w.Header().Set("ETag", "0x3")
+			w.Header().Set("x-ms-meta-daprtimestamp", "2022-10-01T12:00:00Z")
+			w.Header().Set("x-ms-meta-daprdeleted", "true")
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPut:
+			uploads = append(uploads, r.Header)
+			w.Header().Set("ETag", "0x2")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s := NewAzureBlobStorageStore(logger.NewLogger("test")).(*StateStore)
+	require.NoError(t, s.Init(state.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"accountName":     "acc",
+		"accountKey":      "e+Dnvl8EOxYxV94nurVaRQ==",
+		"containerName":   "dapr",
+		"endpoint":        server.URL,
+		"concurrencyMode": "timestamp",
+	}}}))
+
+	t.Run("later timestamp", func(t *testing.T) {
+		uploads = nil
+		err := s.Set(&state.SetRequest{Key: "key", Value: "v", Metadata: map[string]string{"timestamp": "2022-10-01T11:00:00Z"}})
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, "0x1", uploads[0].Get("If-Match"))
+		assert.Equal(t, "2022-10-01T11:00:00Z", uploads[0].Get("x-ms-meta-daprtimestamp"))
+		assert.Empty(t, uploads[0].Get("x-ms-meta-timestamp"))
+	})
+
+	t.Run("stale timestamp", func(t *testing.T) {
+		uploads = nil
+		err := s.Set(&state.SetRequest{Key: "key", Value: "v", Metadata: map[string]string{"timestamp": "2022-10-01T09:00:00Z"}})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+		assert.Empty(t, uploads)
+
+		err = s.Delete(&state.DeleteRequest{Key: "key", Metadata: map[string]string{"timestamp": "2022-10-01T09:00:00Z"}})
+		assert.True(t, errors.As(err, &etagErr))
+	})
+
+	t.Run("new blob", func(t *testing.T) {
+		uploads = nil
+		err := s.Set(&state.SetRequest{Key: "other", Value: "v", Metadata: map[string]string{"timestamp": "2022-10-01T09:00:00Z"}})
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, "*", uploads[0].Get("If-None-Match"))
+	})
+
+	t.Run("delete writes a tombstone", func(t *testing.T) {
+		uploads = nil
+		err := s.Delete(&state.DeleteRequest{Key: "key", Metadata: map[string]string{"timestamp": "2022-10-01T11:00:00Z"}})
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, "0x1", uploads[0].Get("If-Match"))
+		assert.Equal(t, "2022-10-01T11:00:00Z", uploads[0].Get("x-ms-meta-daprtimestamp"))
+		assert.Equal(t, "true", uploads[0].Get("x-ms-meta-daprdeleted"))
+
+		uploads = nil
+		err = s.Delete(&state.DeleteRequest{Key: "other", Metadata: map[string]string{"timestamp": "2022-10-01T11:00:00Z"}})
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, "*", uploads[0].Get("If-None-Match"))
+	})
+
+	t.Run("write older than the tombstone", func(t *testing.T) {
+		uploads = nil
+		err := s.Set(&state.SetRequest{Key: "deleted", Value: "v", Metadata: map[string]string{"timestamp": "2022-10-01T11:00:00Z"}})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Empty(t, uploads)
+
+		err = s.Set(&state.SetRequest{Key: "deleted", Value: "v", Metadata: map[string]string{"timestamp": "2022-10-01T13:00:00Z"}})
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+		assert.Equal(t, "0x3", uploads[0].Get("If-Match"))
+	})
+
+	t.Run("tombstone isn't read", func(t *testing.T) {
+		res, err := s.Get(&state.GetRequest{Key: "deleted"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		assert.Nil(t, res.ETag)
+	})
+
+	t.Run("ETag of another version", func(t *testing.T) {
+		uploads = nil
+		etag := "0x0"
+		err := s.Set(&state.SetRequest{Key: "key", ETag: &etag, Value: "v", Metadata: map[string]string{"timestamp": "2022-10-01T11:00:00Z"}})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Empty(t, uploads)
+	})
+}
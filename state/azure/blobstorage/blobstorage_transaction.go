@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+// blobSnapshot is the content of a blob before an operation of a transaction, which is restored if the transaction is rolled back.
+type blobSnapshot struct {
+	name     string
+	exists   bool
+	etag     azcore.ETag
+	data     []byte
+	headers  blob.HTTPHeaders
+	metadata map[string]string
+	tags     map[string]string
+}
+
+// transactionStore is the storage of the blobs that transactions are applied to.
+type transactionStore interface {
+	snapshot(ctx context.Context, name string) (*blobSnapshot, error)
+	apply(ctx context.Context, op state.TransactionalStateOperation, snap *blobSnapshot) error
+	restore(ctx context.Context, snap *blobSnapshot) error
+}
+
+// Multi applies the operations of the transaction in order.
+// Blob storage has no transactions, so the blobs changed by the operations are restored if an operation fails.
+// Each operation is conditional on the blob not changing since its snapshot was taken, but the restore is best-effort:
+// the changes are visible to other clients until the transaction completes, and the restore itself can fail.
+func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
+	ctx, cancel := mdutils.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return executeTransaction(ctx, r, r.prefixer, request.Operations)
+}
+
+func executeTransaction(ctx context.Context, store transactionStore, prefixer *keyPrefixer, operations []state.TransactionalStateOperation) error {
+	names := make([]string, len(operations))
+	for i, o := range operations {
+		switch req := o.Request.(type) {
+		case state.SetRequest:
+			if o.Operation != state.Upsert {
+				return fmt.Errorf("operation %s doesn't match request for key %s", o.Operation, req.Key)
+			}
+			names[i] = prefixer.blobName(req.Key, req.Metadata)
+		case state.DeleteRequest:
+			if o.Operation != state.Delete {
+				return fmt.Errorf("operation %s doesn't match request for key %s", o.Operation, req.Key)
+			}
+			names[i] = prefixer.blobName(req.Key, req.Metadata)
+		default:
+			return fmt.Errorf("unsupported operation %s", o.Operation)
+		}
+	}
+
+	applied := make([]*blobSnapshot, 0, len(operations))
+	for i, o := range operations {
+		snap, err := store.snapshot(ctx, names[i])
+		if err == nil {
+			err = store.apply(ctx, o, snap)
+		}
+		if err != nil {
+			for j := len(applied) - 1; j >= 0; j-- {
+				if rbErr := store.restore(ctx, applied[j]); rbErr != nil {
+					return fmt.Errorf("error during transaction: %w; error restoring az blob %s: %v", err, applied[j].name, rbErr)
+				}
+			}
+			return fmt.Errorf("error during transaction, the changes were rolled back: %w", err)
+		}
+		applied = append(applied, snap)
+	}
+
+	return nil
+}
+
+func (r *StateStore) snapshot(ctx context.Context, name string) (*blobSnapshot, error) {
+	blobClient := r.containerClient.NewBlobClient(name)
+	res, err := blobClient.DownloadStream(ctx, nil)
+	if err != nil {
+		if isNotFoundError(err) {
+			return &blobSnapshot{name: name}, nil
+		}
+		return nil, fmt.Errorf("error reading az blob %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	snap := &blobSnapshot{
+		name:   name,
+		exists: true,
+		headers: blob.HTTPHeaders{
+			BlobContentType:        res.ContentType,
+			BlobContentEncoding:    res.ContentEncoding,
+			BlobContentLanguage:    res.ContentLanguage,
+			BlobContentDisposition: res.ContentDisposition,
+			BlobCacheControl:       res.CacheControl,
+			BlobContentMD5:         res.ContentMD5,
+		},
+		metadata: res.Metadata,
+	}
+	if res.ETag != nil {
+		snap.etag = *res.ETag
+	}
+	snap.data, err = r.readBody(ctx, &res)
+	if err != nil {
+		return nil, fmt.Errorf("error reading az blob %s: %w", name, err)
+	}
+
+	tagsRes, err := blobClient.GetTags(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tags of az blob %s: %w", name, err)
+	}
+	for _, t := range tagsRes.BlobTagSet {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		if snap.tags == nil {
+			snap.tags = map[string]string{}
+		}
+		snap.tags[*t.Key] = *t.Value
+	}
+
+	return snap, nil
+}
+
+// apply applies the operation, on the condition that the blob hasn't changed since the snapshot.
+func (r *StateStore) apply(ctx context.Context, op state.TransactionalStateOperation, snap *blobSnapshot) error {
+	switch req := op.Request.(type) {
+	case state.SetRequest:
+		if req.ETag == nil || *req.ETag == "" {
+			if snap.exists {
+				req.ETag = ptr.Of(string(snap.etag))
+			} else {
+				req.Options.Concurrency = state.FirstWrite
+			}
+		}
+		return r.writeFile(ctx, &req)
+	case state.DeleteRequest:
+		if req.ETag == nil || *req.ETag == "" {
+			if !snap.exists {
+				return nil
+			}
+			req.ETag = ptr.Of(string(snap.etag))
+		}
+		return r.deleteFile(ctx, &req)
+	default:
+		return fmt.Errorf("unsupported operation %s", op.Operation)
+	}
+}
+
+// restore writes the blob back as it was in the snapshot, or deletes it if it didn't exist.
+func (r *StateStore) restore(ctx context.Context, snap *blobSnapshot) error {
+	blockBlobClient := r.containerClient.NewBlockBlobClient(snap.name)
+	if !snap.exists {
+		// Deletes the snapshots created by the transaction too
+		_, err := blockBlobClient.Delete(ctx, &blob.DeleteOptions{
+			DeleteSnapshots: ptr.Of(blob.DeleteSnapshotsOptionTypeInclude),
+		})
+		if err != nil && !isNotFoundError(err) {
+			return err
+		}
+		return nil
+	}
+
+	uploadOptions := &azblob.UploadBufferOptions{
+		HTTPHeaders: &snap.headers,
+		Metadata:    snap.metadata,
+		Tags:        snap.tags,
+	}
+	if r.encryptionScope != "" {
+		uploadOptions.CpkScopeInfo = &blob.CpkScopeInfo{EncryptionScope: ptr.Of(r.encryptionScope)}
+	}
+
+	_, err := r.upload(ctx, blockBlobClient, snap.data, uploadOptions)
+	return err
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobstorage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeTransactionStore stores blobs in a map, and fails the operations on the blobs in failOn.
+type fakeTransactionStore struct {
+	blobs      map[string]string
+	failOn     map[string]bool
+	restoreErr error
+}
+
+func (f *fakeTransactionStore) snapshot(_ context.Context, name string) (*blobSnapshot, error) {
+	v, ok := f.blobs[name]
+	return &blobSnapshot{name: name, exists: ok, data: []byte(v)}, nil
+}
+
+func (f *fakeTransactionStore) apply(_ context.Context, op state.TransactionalStateOperation, snap *blobSnapshot) error {
+	if f.failOn[snap.name] {
+		return errors.New("failed")
+	}
+	switch req := op.Request.(type) {
+	case state.SetRequest:
+		f.blobs[snap.name] = req.Value.(string)
+	case state.DeleteRequest:
+		delete(f.blobs, snap.name)
+	}
+	return nil
+}
+
+func (f *fakeTransactionStore) restore(_ context.Context, snap *blobSnapshot) error {
+	if f.restoreErr != nil {
+		return f.restoreErr
+	}
+	if snap.exists {
+		f.blobs[snap.name] = string(snap.data)
+	} else {
+		delete(f.blobs, snap.name)
+	}
+	return nil
+}
+
+func TestExecuteTransaction(t *testing.T) {
+	ops := []state.TransactionalStateOperation{
+		{Operation: state.Upsert, Request: state.SetRequest{Key: "app||a", Value: "1"}},
+		{Operation: state.Delete, Request: state.DeleteRequest{Key: "app||b"}},
+		{Operation: state.Upsert, Request: state.SetRequest{Key: "app||c", Value: "3"}},
+		{Operation: state.Upsert, Request: state.SetRequest{Key: "app||a", Value: "4"}},
+	}
+
+	t.Run("all operations succeed", func(t *testing.T) {
+		store := &fakeTransactionStore{blobs: map[string]string{"a": "0", "b": "0"}}
+		err := executeTransaction(context.Background(), store, nil, ops)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"a": "4", "c": "3"}, store.blobs)
+	})
+
+	t.Run("failed operation rolls back", func(t *testing.T) {
+		store := &fakeTransactionStore{
+			blobs:  map[string]string{"a": "0", "b": "0"},
+			failOn: map[string]bool{"c": true},
+		}
+		err := executeTransaction(context.Background(), store, nil, ops)
+		assert.ErrorContains(t, err, "rolled back")
+		assert.Equal(t, map[string]string{"a": "0", "b": "0"}, store.blobs)
+	})
+
+	t.Run("failed restore", func(t *testing.T) {
+		store := &fakeTransactionStore{
+			blobs:      map[string]string{"a": "0", "b": "0"},
+			failOn:     map[string]bool{"c": true},
+			restoreErr: errors.New("restore failed"),
+		}
+		err := executeTransaction(context.Background(), store, nil, ops)
+		assert.ErrorContains(t, err, "error restoring az blob b")
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		store := &fakeTransactionStore{blobs: map[string]string{}}
+		err := executeTransaction(context.Background(), store, nil, []state.TransactionalStateOperation{
+			{Operation: state.Upsert, Request: state.SetRequest{Key: "a", Value: "1"}},
+			{Operation: state.Delete, Request: state.SetRequest{Key: "b"}},
+		})
+		assert.Error(t, err)
+		assert.Empty(t, store.blobs)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	var uploads []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Query().Get("restype") == "" {
+			uploads = append(uploads, r.Header)
+		}
+		w.Header().Set("ETag", "0x1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	s := NewAzureBlobStorageStore(logger.NewLogger("test")).(*StateStore)
+	require.NoError(t, s.Init(state.Metadata{Base: mdata.Base{Properties: map[string]string{
+		"accountName":     "acc",
+		"accountKey":      "e+Dnvl8EOxYxV94nurVaRQ==",
+		"containerName":   "dapr",
+		"endpoint":        server.URL,
+		"encryptionScope": "scope",
+	}}}))
+
+	err := s.restore(context.Background(), &blobSnapshot{name: "key", exists: true, data: []byte("v"), metadata: map[string]string{"m": "1"}})
+	require.NoError(t, err)
+	require.Len(t, uploads, 1)
+	assert.Equal(t, "scope", uploads[0].Get("x-ms-encryption-scope"))
+	assert.Equal(t, "1", uploads[0].Get("x-ms-meta-m"))
+}
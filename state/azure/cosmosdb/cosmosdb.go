@@ -46,6 +46,8 @@ type StateStore struct {
 	client      *azcosmos.ContainerClient
 	metadata    metadata
 	contentType string
+	timeout     time.Duration
+	keyCodec    *state.KeyCodec
 	logger      logger.Logger
 }
 
@@ -55,6 +57,8 @@ type metadata struct {
 	Database    string `json:"database"`
 	Collection  string `json:"collection"`
 	ContentType string `json:"contentType"`
+	// Maximum number of partition keys written at once by the bulk operations
+	MaxConcurrency int `json:"maxConcurrency"`
 }
 
 type cosmosOperationType string
@@ -80,6 +84,11 @@ const (
 	metadataTTLKey       = "ttlInSeconds"
 	defaultTimeout       = 20 * time.Second
 	statusNotFound       = "NotFound"
+
+	// Number of attempts of a partial update without ETag when the item is concurrently modified.
+	maxPartialUpdateAttempts = 5
+	// Max number of operations of a CosmosDB partial document update.
+	maxPatchOperations = 10
 )
 
 // policy that tracks the number of times it was invoked
@@ -113,12 +122,20 @@ func (c *StateStore) GetComponentMetadata() map[string]string {
 	return metadataInfo
 }
 
+func (c *StateStore) ComponentProfile() contribmeta.ComponentProfile {
+	return contribmeta.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(c.Features()), contribmeta.CapabilityTTL),
+		AuthProfiles: []contribmeta.AuthProfile{contribmeta.AuthProfileAccessKey, contribmeta.AuthProfileAzureAD},
+	}
+}
+
 // Init does metadata and connection parsing.
 func (c *StateStore) Init(meta state.Metadata) error {
 	c.logger.Debugf("CosmosDB init start")
 
 	m := metadata{
-		ContentType: "application/json",
+		ContentType:    "application/json",
+		MaxConcurrency: defaultMaxConcurrency,
 	}
 	errDecode := contribmeta.DecodeMetadata(meta.Properties, &m)
 	if errDecode != nil {
@@ -137,6 +154,16 @@ func (c *StateStore) Init(meta state.Metadata) error {
 	if m.ContentType == "" {
 		return errors.New("contentType is required")
 	}
+	if m.MaxConcurrency <= 0 {
+		return errors.New("maxConcurrency must be greater than 0")
+	}
+	timeout, err := contribmeta.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	if c.keyCodec, err = state.NewKeyCodec(meta); err != nil {
+		return err
+	}
 
 	// Internal query policy was created due to lack of cross partition query capability in the current Go sdk
 	queryPolicy := &crossPartitionQueryPolicy{}
@@ -191,8 +218,9 @@ func (c *StateStore) Init(meta state.Metadata) error {
 
 	c.metadata = m
 	c.contentType = m.ContentType
+	c.timeout = timeout
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	_, err = c.client.Read(ctx, nil)
 	cancel()
 	return err
@@ -204,11 +232,13 @@ func (c *StateStore) Features() []state.Feature {
 		state.FeatureETag,
 		state.FeatureTransactional,
 		state.FeatureQueryAPI,
+		state.FeaturePartialUpdate,
 	}
 }
 
 // Get retrieves a CosmosDB item.
 func (c *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	req = c.keyCodec.EncodeGet(req)
 	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
 
 	options := azcosmos.ItemOptions{}
@@ -218,7 +248,7 @@ func (c *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	readItem, err := c.client.ReadItem(ctx, azcosmos.NewPartitionKeyString(partitionKey), req.Key, &options)
 	cancel()
 	if err != nil {
@@ -274,6 +304,7 @@ func (c *StateStore) Set(req *state.SetRequest) error {
 	if err != nil {
 		return err
 	}
+	req = c.keyCodec.EncodeSet(req)
 
 	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
 	options := azcosmos.ItemOptions{}
@@ -307,7 +338,7 @@ func (c *StateStore) Set(req *state.SetRequest) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	pk := azcosmos.NewPartitionKeyString(partitionKey)
 	_, err = c.client.UpsertItem(ctx, pk, marsh, &options)
 	cancel()
@@ -323,6 +354,7 @@ func (c *StateStore) Delete(req *state.DeleteRequest) error {
 	if err != nil {
 		return err
 	}
+	req = c.keyCodec.EncodeDelete(req)
 	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
 	options := azcosmos.ItemOptions{}
 
@@ -336,7 +368,7 @@ func (c *StateStore) Delete(req *state.DeleteRequest) error {
 		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	pk := azcosmos.NewPartitionKeyString(partitionKey)
 	_, err = c.client.DeleteItem(ctx, pk, req.Key, &options)
 	cancel()
@@ -351,6 +383,188 @@ func (c *StateStore) Delete(req *state.DeleteRequest) error {
 	return nil
 }
 
+// PartialUpdate patches the value of a CosmosDB item. Implements state.PartialUpdater.
+// JSON Patch add, replace and remove operations are applied by CosmosDB with a partial document update, while merge
+// patches and the other JSON Patch operations, which it can't express, replace the item only if its ETag didn't
+// change since it was read; without an ETag in the request, that update is retried when the item is concurrently
+// modified.
+func (c *StateStore) PartialUpdate(req *state.PartialUpdateRequest) error {
+	patchType, err := req.GetPatchType()
+	if err != nil {
+		return err
+	}
+	req = c.keyCodec.EncodePartialUpdate(req)
+
+	if patchType == state.JSONPatch {
+		ops, err := state.ParseJSONPatch(req.Patch)
+		if err != nil {
+			return err
+		}
+		if patch, ok := nativePatchOperations(ops); ok {
+			return c.nativePartialUpdate(req, patch)
+		}
+	}
+
+	return c.replacePatchedItem(req)
+}
+
+// nativePartialUpdate applies a partial document update to a CosmosDB item.
+func (c *StateStore) nativePartialUpdate(req *state.PartialUpdateRequest, patch azcosmos.PatchOperations) error {
+	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
+	hasETag := req.ETag != nil && *req.ETag != ""
+
+	options := azcosmos.ItemOptions{}
+	if hasETag {
+		etag := azcore.ETag(*req.ETag)
+		options.IfMatchEtag = &etag
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	_, err := c.client.PatchItem(ctx, azcosmos.NewPartitionKeyString(partitionKey), req.Key, patch, &options)
+	cancel()
+	if err != nil {
+		if isNotFoundError(err) {
+			if hasETag {
+				return state.NewETagError(state.ETagMismatch, err)
+			}
+			return fmt.Errorf("%w: %s", state.ErrKeyNotFound, req.Key)
+		}
+		var responseErr *azcore.ResponseError
+		if errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusPreconditionFailed {
+			if hasETag {
+				return state.NewETagError(state.ETagMismatch, err)
+			}
+			// The condition of the patch failed
+			return fmt.Errorf("binary value of key %s can't be partially updated", req.Key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// nativePatchOperations translates JSON Patch operations to the operations of a CosmosDB partial document update of
+// the item value. It returns false if CosmosDB can't apply them.
+func nativePatchOperations(ops []state.PatchOperation) (azcosmos.PatchOperations, bool) {
+	var patch azcosmos.PatchOperations
+	if len(ops) == 0 || len(ops) > maxPatchOperations {
+		return patch, false
+	}
+
+	// Binary values are stored base64-encoded, so they can't be patched
+	patch.SetCondition("FROM c WHERE NOT c.isBinary")
+	for _, op := range ops {
+		// CosmosDB paths don't support the escaped characters of JSON pointers
+		if strings.Contains(op.Path, "~") {
+			return patch, false
+		}
+		path := "/value" + op.Path
+
+		switch op.Op {
+		case state.PatchOpAdd:
+			if op.Path == "" {
+				patch.AppendSet(path, op.Value)
+			} else {
+				patch.AppendAdd(path, op.Value)
+			}
+		case state.PatchOpReplace:
+			patch.AppendReplace(path, op.Value)
+		case state.PatchOpRemove:
+			if op.Path == "" {
+				return patch, false
+			}
+			patch.AppendRemove(path)
+		default:
+			return patch, false
+		}
+	}
+
+	return patch, true
+}
+
+// replacePatchedItem applies the patch to the value of a CosmosDB item that it reads, and replaces the item if it
+// didn't change in the meantime.
+func (c *StateStore) replacePatchedItem(req *state.PartialUpdateRequest) error {
+	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
+	pk := azcosmos.NewPartitionKeyString(partitionKey)
+	hasETag := req.ETag != nil && *req.ETag != ""
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		readItem, err := c.client.ReadItem(ctx, pk, req.Key, nil)
+		cancel()
+		if err != nil {
+			if isNotFoundError(err) {
+				if hasETag {
+					return state.NewETagError(state.ETagMismatch, err)
+				}
+				return fmt.Errorf("%w: %s", state.ErrKeyNotFound, req.Key)
+			}
+			return err
+		}
+
+		etag := readItem.ETag
+		if hasETag && string(etag) != *req.ETag {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+
+		doc, err := patchItem(readItem.Value, req)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), c.timeout)
+		_, err = c.client.ReplaceItem(ctx, pk, req.Key, doc, &azcosmos.ItemOptions{IfMatchEtag: &etag})
+		cancel()
+		if err != nil {
+			var responseErr *azcore.ResponseError
+			if errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusPreconditionFailed {
+				if hasETag {
+					return state.NewETagError(state.ETagMismatch, err)
+				}
+				if attempt < maxPartialUpdateAttempts {
+					c.logger.Debugf("CosmosDB item %s was modified during partial update, retrying", req.Key)
+					continue
+				}
+			}
+			return err
+		}
+
+		return nil
+	}
+}
+
+// patchItem applies the patch of the request to the value of a CosmosDB document, preserving its other properties.
+func patchItem(item []byte, req *state.PartialUpdateRequest) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	err := json.Unmarshal(item, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var isBinary bool
+	if raw, ok := doc["isBinary"]; ok {
+		if err = json.Unmarshal(raw, &isBinary); err != nil {
+			return nil, err
+		}
+	}
+	if isBinary {
+		return nil, fmt.Errorf("binary value of key %s can't be partially updated", req.Key)
+	}
+
+	value, ok := doc["value"]
+	if !ok {
+		return nil, fmt.Errorf("item %s has no value", req.Key)
+	}
+	patched, err := state.ApplyPatch(value, req)
+	if err != nil {
+		return nil, err
+	}
+	doc["value"] = patched
+
+	return json.Marshal(doc)
+}
+
 // Multi performs a transactional operation. succeeds only if all operations succeed, and fails if one or more operations fail.
 func (c *StateStore) Multi(request *state.TransactionalStateRequest) (err error) {
 	if len(request.Operations) == 0 {
@@ -370,6 +584,7 @@ func (c *StateStore) Multi(request *state.TransactionalStateRequest) (err error)
 
 		if o.Operation == state.Upsert {
 			req := o.Request.(state.SetRequest)
+			req.Key = c.keyCodec.Encode(req.Key)
 			var doc CosmosItem
 			doc, err = createUpsertItem(c.contentType, req, partitionKey)
 			if err != nil {
@@ -398,6 +613,7 @@ func (c *StateStore) Multi(request *state.TransactionalStateRequest) (err error)
 			numOperations++
 		} else if o.Operation == state.Delete {
 			req := o.Request.(state.DeleteRequest)
+			req.Key = c.keyCodec.Encode(req.Key)
 
 			if req.ETag != nil && *req.ETag != "" {
 				etag := azcore.ETag(*req.ETag)
@@ -419,7 +635,7 @@ func (c *StateStore) Multi(request *state.TransactionalStateRequest) (err error)
 
 	c.logger.Debugf("#operations=%d,partitionkey=%s", numOperations, partitionKey)
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	batchResponse, err := c.client.ExecuteTransactionalBatch(ctx, batch, nil)
 	cancel()
 	if err != nil {
@@ -447,26 +663,26 @@ func (c *StateStore) Multi(request *state.TransactionalStateRequest) (err error)
 }
 
 func (c *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
-	q := &Query{}
+	q := &Query{keyPattern: c.keyCodec.Pattern()}
 
 	qbuilder := query.NewQueryBuilder(q)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
 
-	data, token, err := q.execute(c.client)
+	data, token, err := q.execute(c.client, c.timeout)
 	if err != nil {
 		return nil, err
 	}
 
 	return &state.QueryResponse{
-		Results: data,
+		Results: c.keyCodec.DecodeItems(data),
 		Token:   token,
 	}, nil
 }
 
 func (c *StateStore) Ping() error {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	_, err := c.client.Read(ctx, nil)
 	cancel()
 	if err != nil {
@@ -0,0 +1,263 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosmosdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	// maxBatchOperations is the maximum number of operations of a Cosmos DB transactional batch.
+	maxBatchOperations = 100
+
+	defaultMaxConcurrency = 10
+)
+
+// errBatchFailed is the error of the operations that weren't applied because another operation on their partition key failed.
+var errBatchFailed = errors.New("not applied because another operation on the partition key failed")
+
+// bulkOperation is an upsert or delete of a bulk request, executed in the transactional batch of its partition key.
+type bulkOperation struct {
+	// The key of the request, and the id of the item, which is its stored key
+	key          string
+	id           string
+	partitionKey string
+	// The document of an upsert, or nil for a delete
+	doc  []byte
+	etag *azcore.ETag
+	// Whether the ETag is the one of the request, rather than a random one for a first write
+	hasETag bool
+}
+
+// executeBatchFunc executes the operations in a transactional batch of the partition key.
+type executeBatchFunc func(ctx context.Context, partitionKey string, ops []bulkOperation) (*azcosmos.TransactionalBatchResponse, error)
+
+// BulkSet upserts the items in transactional batches of up to 100 items with the same partition key, executed in
+// parallel. The items of a batch are written together, so an item failing fails the other items of its batch.
+// It returns the errors of all the keys that failed, as BulkStoreError.
+func (c *StateStore) BulkSet(req []state.SetRequest) error {
+	ops := make([]bulkOperation, len(req))
+	for i := range req {
+		err := state.CheckRequestOptions(req[i].Options)
+		if err != nil {
+			return err
+		}
+
+		stored := c.keyCodec.EncodeSet(&req[i])
+		partitionKey := populatePartitionMetadata(stored.Key, stored.Metadata)
+		doc, err := createUpsertItem(c.contentType, *stored, partitionKey)
+		if err != nil {
+			return state.NewBulkStoreError(req[i].Key, err)
+		}
+		ops[i].doc, err = json.Marshal(doc)
+		if err != nil {
+			return state.NewBulkStoreError(req[i].Key, err)
+		}
+		ops[i].key = req[i].Key
+		ops[i].id = stored.Key
+		ops[i].partitionKey = partitionKey
+		ops[i].etag, ops[i].hasETag, err = batchETag(req[i].ETag, req[i].Options.Concurrency == state.FirstWrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.bulk(ops, c.executeBatch)
+}
+
+// BulkDelete deletes the items in transactional batches of up to 100 items with the same partition key, executed in
+// parallel. Items that don't exist are ignored. It returns the errors of all the keys that failed, as BulkStoreError.
+func (c *StateStore) BulkDelete(req []state.DeleteRequest) error {
+	ops := make([]bulkOperation, len(req))
+	for i := range req {
+		err := state.CheckRequestOptions(req[i].Options)
+		if err != nil {
+			return err
+		}
+
+		stored := c.keyCodec.EncodeDelete(&req[i])
+		ops[i].key = req[i].Key
+		ops[i].id = stored.Key
+		ops[i].partitionKey = populatePartitionMetadata(stored.Key, stored.Metadata)
+		ops[i].etag, ops[i].hasETag, err = batchETag(req[i].ETag, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.bulk(ops, c.executeBatch)
+}
+
+// batchETag returns the ETag condition of an operation: the ETag of the request, or a random one for a first write,
+// which only matches an item that doesn't exist.
+func batchETag(etag *string, firstWrite bool) (*azcore.ETag, bool, error) {
+	if etag != nil && *etag != "" {
+		return ptr.Of(azcore.ETag(*etag)), true, nil
+	}
+	if firstWrite {
+		u, err := uuid.NewRandom()
+		if err != nil {
+			return nil, false, err
+		}
+		return ptr.Of(azcore.ETag(u.String())), false, nil
+	}
+
+	return nil, false, nil
+}
+
+// bulk executes the operations of each partition key in parallel, with at most maxConcurrency partition keys at once.
+func (c *StateStore) bulk(ops []bulkOperation, execute executeBatchFunc) error {
+	batches := groupByPartitionKey(ops)
+	errs := make([]error, len(batches))
+	sem := make(chan struct{}, c.metadata.MaxConcurrency)
+	var wg sync.WaitGroup
+	wg.Add(len(batches))
+	for i := range batches {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = c.runBatch(batches[i], execute)
+		}(i)
+	}
+	wg.Wait()
+
+	var err error
+	for _, e := range errs {
+		if e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+	return err
+}
+
+// groupByPartitionKey groups the operations by partition key, in the order of the requests.
+func groupByPartitionKey(ops []bulkOperation) [][]bulkOperation {
+	batches := [][]bulkOperation{}
+	index := map[string]int{}
+	for _, op := range ops {
+		i, ok := index[op.partitionKey]
+		if !ok {
+			i = len(batches)
+			index[op.partitionKey] = i
+			batches = append(batches, nil)
+		}
+		batches[i] = append(batches[i], op)
+	}
+	return batches
+}
+
+// runBatch executes the operations of a partition key, in sequential batches of up to maxBatchOperations operations
+// with no key more than once. Deletes of items that don't exist are removed from their batch, which is executed again.
+func (c *StateStore) runBatch(ops []bulkOperation, execute executeBatchFunc) error {
+	for len(ops) > 0 {
+		n := 0
+		keys := map[string]struct{}{}
+		for n < len(ops) && n < maxBatchOperations {
+			if _, ok := keys[ops[n].id]; ok {
+				break
+			}
+			keys[ops[n].id] = struct{}{}
+			n++
+		}
+		batch := ops[:n]
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		res, err := execute(ctx, ops[0].partitionKey, batch)
+		cancel()
+		if err != nil {
+			return batchError(ops, -1, err)
+		}
+		if !res.Success {
+			failed := -1
+			var status int32
+			for i, r := range res.OperationResults {
+				if r.StatusCode != http.StatusFailedDependency {
+					failed, status = i, r.StatusCode
+					break
+				}
+			}
+			if failed >= 0 && failed < n && batch[failed].doc == nil && status == http.StatusNotFound && !batch[failed].hasETag {
+				// Deleting an item that doesn't exist isn't an error
+				ops = append(ops[:failed:failed], ops[failed+1:]...)
+				continue
+			}
+			return batchError(ops, failed, operationError(batch, failed, status))
+		}
+
+		ops = ops[n:]
+	}
+
+	return nil
+}
+
+// operationError returns the error of the operation that failed its batch.
+func operationError(batch []bulkOperation, failed int, status int32) error {
+	err := fmt.Errorf("operation failed with status code %d", status)
+	if failed >= 0 && failed < len(batch) && batch[failed].etag != nil &&
+		(status == http.StatusPreconditionFailed || (status == http.StatusNotFound && batch[failed].hasETag)) {
+		return state.NewETagError(state.ETagMismatch, err)
+	}
+	return err
+}
+
+// batchError returns the errors of the operations that weren't applied: the error of the failed operation, if any,
+// and errBatchFailed for the others.
+func batchError(ops []bulkOperation, failed int, err error) error {
+	var errs error
+	for i, op := range ops {
+		opErr := errBatchFailed
+		if failed < 0 || i == failed {
+			opErr = err
+		}
+		errs = multierror.Append(errs, state.NewBulkStoreError(op.key, opErr))
+	}
+	return errs
+}
+
+// executeBatch executes the operations in a transactional batch with Cosmos DB.
+func (c *StateStore) executeBatch(ctx context.Context, partitionKey string, ops []bulkOperation) (*azcosmos.TransactionalBatchResponse, error) {
+	batch := c.client.NewTransactionalBatch(azcosmos.NewPartitionKeyString(partitionKey))
+	for _, op := range ops {
+		options := &azcosmos.TransactionalBatchItemOptions{
+			IfMatchETag: op.etag,
+		}
+		if op.doc == nil {
+			batch.DeleteItem(op.id, options)
+		} else {
+			batch.UpsertItem(op.doc, options)
+		}
+	}
+
+	res, err := c.client.ExecuteTransactionalBatch(ctx, batch, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
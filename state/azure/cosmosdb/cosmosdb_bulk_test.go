@@ -0,0 +1,240 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosmosdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribmeta "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+// fakeBatches records the executed batches, and fails the operations of the keys with the status code.
+type fakeBatches struct {
+	lock     sync.Mutex
+	batches  [][]string
+	statuses map[string]int32
+}
+
+func (f *fakeBatches) execute(_ context.Context, _ string, ops []bulkOperation) (*azcosmos.TransactionalBatchResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	keys := make([]string, len(ops))
+	res := &azcosmos.TransactionalBatchResponse{Success: true, OperationResults: make([]azcosmos.TransactionalBatchResult, len(ops))}
+	for i, op := range ops {
+		keys[i] = op.key
+		res.OperationResults[i].StatusCode = http.StatusOK
+		if status, ok := f.statuses[op.key]; ok && res.Success {
+			res.Success = false
+			res.OperationResults[i].StatusCode = status
+		}
+	}
+	if !res.Success {
+		for i := range res.OperationResults {
+			if res.OperationResults[i].StatusCode == http.StatusOK {
+				res.OperationResults[i].StatusCode = http.StatusFailedDependency
+			}
+		}
+	}
+	f.batches = append(f.batches, keys)
+
+	return res, nil
+}
+
+func newBulkTestStore() *StateStore {
+	return &StateStore{
+		timeout:  time.Second,
+		metadata: metadata{MaxConcurrency: 2},
+	}
+}
+
+func TestGroupByPartitionKey(t *testing.T) {
+	batches := groupByPartitionKey([]bulkOperation{
+		{key: "a", partitionKey: "1"},
+		{key: "b", partitionKey: "2"},
+		{key: "c", partitionKey: "1"},
+	})
+	require.Len(t, batches, 2)
+	assert.Equal(t, []bulkOperation{{key: "a", partitionKey: "1"}, {key: "c", partitionKey: "1"}}, batches[0])
+	assert.Equal(t, []bulkOperation{{key: "b", partitionKey: "2"}}, batches[1])
+}
+
+func TestRunBatch(t *testing.T) {
+	t.Run("splits the batches", func(t *testing.T) {
+		ops := make([]bulkOperation, maxBatchOperations+2)
+		for i := range ops {
+			ops[i] = bulkOperation{key: strconv.Itoa(i), id: strconv.Itoa(i), doc: []byte("{}")}
+		}
+		// An item is only once in a batch
+		ops[maxBatchOperations+1].id = ops[maxBatchOperations].id
+
+		f := &fakeBatches{}
+		require.NoError(t, newBulkTestStore().runBatch(ops, f.execute))
+		require.Len(t, f.batches, 3)
+		assert.Len(t, f.batches[0], maxBatchOperations)
+		assert.Len(t, f.batches[1], 1)
+		assert.Len(t, f.batches[2], 1)
+	})
+
+	t.Run("ignores deletes of missing items", func(t *testing.T) {
+		f := &fakeBatches{statuses: map[string]int32{"b": http.StatusNotFound}}
+		err := newBulkTestStore().runBatch([]bulkOperation{{key: "a", id: "a"}, {key: "b", id: "b"}, {key: "c", id: "c"}}, f.execute)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"a", "b", "c"}, {"a", "c"}}, f.batches)
+	})
+
+	t.Run("ETag mismatch", func(t *testing.T) {
+		f := &fakeBatches{statuses: map[string]int32{"b": http.StatusPreconditionFailed}}
+		err := newBulkTestStore().runBatch([]bulkOperation{
+			{key: "a", id: "a", doc: []byte("{}")},
+			{key: "b", id: "b", doc: []byte("{}"), etag: ptr.Of(azcore.ETag("1")), hasETag: true},
+		}, f.execute)
+
+		var bulkErr *state.BulkStoreError
+		require.True(t, errors.As(err, &bulkErr))
+		assert.Equal(t, "a", bulkErr.Key())
+		assert.ErrorIs(t, err, errBatchFailed)
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+}
+
+func TestBulk(t *testing.T) {
+	f := &fakeBatches{statuses: map[string]int32{"c": http.StatusConflict}}
+	err := newBulkTestStore().bulk([]bulkOperation{
+		{key: "a", id: "a", partitionKey: "1", doc: []byte("{}")},
+		{key: "b", id: "b", partitionKey: "2", doc: []byte("{}")},
+		{key: "c", id: "c", partitionKey: "3", doc: []byte("{}")},
+	}, f.execute)
+	require.Error(t, err)
+	assert.Len(t, f.batches, 3)
+	assert.Contains(t, err.Error(), "failed operation on key c")
+	assert.NotContains(t, err.Error(), "key a")
+}
+
+// fakeCosmosDB serves the items of a container from memory, for the requests of the reads, deletes and transactional
+// batches of the client.
+type fakeCosmosDB struct {
+	lock  sync.Mutex
+	items map[string]json.RawMessage
+}
+
+func (f *fakeCosmosDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	const docsPath = "/dbs/db/colls/coll/docs"
+	isItem := strings.HasPrefix(r.URL.Path, docsPath+"/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/dbs/db/colls/coll":
+		w.Write([]byte(`{"id":"coll"}`))
+	case r.Method == http.MethodPost && r.URL.Path == docsPath && r.Header.Get("x-ms-cosmos-is-batch-request") != "":
+		var ops []struct {
+			OperationType string          `json:"operationType"`
+			ID            string          `json:"id"`
+			ResourceBody  json.RawMessage `json:"resourceBody"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&ops)
+		results := make([]map[string]any, len(ops))
+		for i, op := range ops {
+			results[i] = map[string]any{"statusCode": http.StatusOK, "eTag": "etag"}
+			switch op.OperationType {
+			case "Upsert":
+				var doc struct {
+					ID string `json:"id"`
+				}
+				_ = json.Unmarshal(op.ResourceBody, &doc)
+				f.items[doc.ID] = op.ResourceBody
+			case "Delete":
+				if _, ok := f.items[op.ID]; !ok {
+					results[i]["statusCode"] = http.StatusNotFound
+					w.WriteHeader(http.StatusMultiStatus)
+					json.NewEncoder(w).Encode(results[:i+1])
+					return
+				}
+				delete(f.items, op.ID)
+			}
+		}
+		json.NewEncoder(w).Encode(results)
+	case r.Method == http.MethodGet && isItem:
+		item, ok := f.items[strings.TrimPrefix(r.URL.Path, docsPath+"/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound"}`))
+			return
+		}
+		w.Header().Set("etag", "etag")
+		w.Write(item)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestBulkWithKeyTemplate(t *testing.T) {
+	fake := &fakeCosmosDB{items: map[string]json.RawMessage{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := NewCosmosDBStateStore(logger.NewLogger("test"))
+	err := store.Init(state.Metadata{Base: contribmeta.Base{
+		Name: "statestore",
+		Properties: map[string]string{
+			"url":                        server.URL,
+			"masterKey":                  base64.StdEncoding.EncodeToString([]byte("key")),
+			"database":                   "db",
+			"collection":                 "coll",
+			state.KeyTemplateMetadataKey: "{storename}/{appid}/{key}",
+		},
+	}})
+	require.NoError(t, err)
+
+	err = store.BulkSet([]state.SetRequest{
+		{Key: "app||a", Value: []byte(`"va"`)},
+		{Key: "app||b", Value: []byte(`"vb"`)},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, fake.items, "statestore/app/a")
+	assert.Contains(t, fake.items, "statestore/app/b")
+
+	res, err := store.Get(&state.GetRequest{Key: "app||a"})
+	require.NoError(t, err)
+	assert.Equal(t, `"va"`, string(res.Data))
+
+	err = store.BulkDelete([]state.DeleteRequest{{Key: "app||a"}, {Key: "app||b"}})
+	require.NoError(t, err)
+	assert.Empty(t, fake.items)
+
+	res, err = store.Get(&state.GetRequest{Key: "app||a"})
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+}
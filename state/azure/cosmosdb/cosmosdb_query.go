@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	jsoniter "github.com/json-iterator/go"
@@ -37,6 +38,8 @@ type Query struct {
 	query InternalQuery
 	limit int
 	token string
+	// Pattern of the ids for RegexMatch, from the keyTemplate metadata
+	keyPattern string
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -115,6 +118,14 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 
 func (q *Query) Finalize(filters string, qq *query.Query) error {
 	var filter, orderBy string
+	if q.keyPattern != "" {
+		keyFilter := "RegexMatch(c.id, " + q.setNextParameter(q.keyPattern) + ")"
+		if len(filters) != 0 {
+			filters = keyFilter + " AND " + filters
+		} else {
+			filters = keyFilter
+		}
+	}
 	if len(filters) != 0 {
 		filter = " WHERE " + filters
 	}
@@ -144,7 +155,7 @@ func (q *Query) setNextParameter(val string) string {
 	return pname
 }
 
-func (q *Query) execute(client *azcosmos.ContainerClient) ([]state.QueryItem, string, error) {
+func (q *Query) execute(client *azcosmos.ContainerClient, timeout time.Duration) ([]state.QueryItem, string, error) {
 	opts := &azcosmos.QueryOptions{}
 
 	resultLimit := q.limit
@@ -160,7 +171,7 @@ func (q *Query) execute(client *azcosmos.ContainerClient) ([]state.QueryItem, st
 
 	token := ""
 	for queryPager.More() {
-		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		queryResponse, innerErr := queryPager.NextPage(ctx)
 		cancel()
 		if innerErr != nil {
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state/query"
 )
@@ -140,3 +141,18 @@ func TestCosmosDbQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestCosmosDbQueryKeyPattern(t *testing.T) {
+	q := &Query{keyPattern: "^statestore/.*$"}
+	err := query.NewQueryBuilder(q).BuildQuery(&query.Query{
+		Filter: &query.EQ{Key: "state", Val: "CA"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, InternalQuery{
+		query: "SELECT * FROM c WHERE RegexMatch(c.id, @__param__1__) AND c['value']['state'] = @__param__0__",
+		parameters: []azcosmos.QueryParameter{
+			{Name: "@__param__0__", Value: "CA"},
+			{Name: "@__param__1__", Value: "^statestore/.*$"},
+		},
+	}, q.query)
+}
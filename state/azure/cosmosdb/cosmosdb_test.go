@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
 )
@@ -355,3 +356,73 @@ func TestCreateCosmosItemWithTTL(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestPatchItem(t *testing.T) {
+	t.Run("patches the value", func(t *testing.T) {
+		item := []byte(`{"id":"key1","value":{"color":"white","size":1},"isBinary":false,"partitionKey":"key1","_rid":"abc"}`)
+		doc, err := patchItem(item, &state.PartialUpdateRequest{
+			Key:   "key1",
+			Patch: []byte(`[{"op":"replace","path":"/color","value":"red"},{"op":"remove","path":"/size"}]`),
+		})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":"key1","value":{"color":"red"},"isBinary":false,"partitionKey":"key1","_rid":"abc"}`, string(doc))
+	})
+
+	t.Run("merge patch", func(t *testing.T) {
+		item := []byte(`{"id":"key1","value":{"color":"white"},"isBinary":false,"partitionKey":"key1"}`)
+		doc, err := patchItem(item, &state.PartialUpdateRequest{
+			Key:       "key1",
+			Patch:     []byte(`{"size":2}`),
+			PatchType: state.MergePatch,
+		})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":"key1","value":{"color":"white","size":2},"isBinary":false,"partitionKey":"key1"}`, string(doc))
+	})
+
+	t.Run("binary values are rejected", func(t *testing.T) {
+		item := []byte(`{"id":"key1","value":"aGVsbG8=","isBinary":true,"partitionKey":"key1"}`)
+		_, err := patchItem(item, &state.PartialUpdateRequest{
+			Key:   "key1",
+			Patch: []byte(`[{"op":"remove","path":"/color"}]`),
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestNativePatchOperations(t *testing.T) {
+	parse := func(t *testing.T, patch string) []state.PatchOperation {
+		ops, err := state.ParseJSONPatch([]byte(patch))
+		require.NoError(t, err)
+		return ops
+	}
+
+	t.Run("add, replace and remove are translated", func(t *testing.T) {
+		patch, ok := nativePatchOperations(parse(t, `[{"op":"add","path":"/tags/-","value":"new"},{"op":"replace","path":"/color","value":"red"},{"op":"remove","path":"/size"}]`))
+		require.True(t, ok)
+		res, err := json.Marshal(patch)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"condition":"FROM c WHERE NOT c.isBinary","operations":[{"op":"add","path":"/value/tags/-","value":"new"},{"op":"replace","path":"/value/color","value":"red"},{"op":"remove","path":"/value/size"}]}`, string(res))
+	})
+
+	t.Run("adding the whole value sets it", func(t *testing.T) {
+		patch, ok := nativePatchOperations(parse(t, `[{"op":"add","path":"","value":{"color":"red"}}]`))
+		require.True(t, ok)
+		res, err := json.Marshal(patch)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"condition":"FROM c WHERE NOT c.isBinary","operations":[{"op":"set","path":"/value","value":{"color":"red"}}]}`, string(res))
+	})
+
+	t.Run("unsupported operations", func(t *testing.T) {
+		for _, patch := range []string{
+			`[]`,
+			`[{"op":"move","from":"/a","path":"/b"}]`,
+			`[{"op":"test","path":"/a","value":1}]`,
+			`[{"op":"remove","path":""}]`,
+			`[{"op":"remove","path":"/a~1b"}]`,
+			`[{"op":"remove","path":"/1"},{"op":"remove","path":"/2"},{"op":"remove","path":"/3"},{"op":"remove","path":"/4"},{"op":"remove","path":"/5"},{"op":"remove","path":"/6"},{"op":"remove","path":"/7"},{"op":"remove","path":"/8"},{"op":"remove","path":"/9"},{"op":"remove","path":"/10"},{"op":"remove","path":"/11"}]`,
+		} {
+			_, ok := nativePatchOperations(parse(t, patch))
+			assert.False(t, ok, patch)
+		}
+	})
+}
@@ -51,17 +51,17 @@ import (
 	"github.com/pkg/errors"
 
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/internal/redact"
 	mdutils "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
 const (
-	keyDelimiter        = "||"
 	valueEntityProperty = "Value"
 
 	cosmosDBModeKey = "cosmosDbMode"
-	timeout         = 15 * time.Second
+	defaultTimeout  = 15 * time.Second
 )
 
 type StateStore struct {
@@ -69,6 +69,8 @@ type StateStore struct {
 	client       *aztables.Client
 	json         jsoniter.API
 	cosmosDBMode bool
+	timeout      time.Duration
+	keyCodec     *state.KeyCodec
 
 	features []state.Feature
 	logger   logger.Logger
@@ -84,11 +86,26 @@ type tablesMetadata struct {
 }
 
 // Init Initialises connection to table storage, optionally creates a table if it doesn't exist.
-func (r *StateStore) Init(metadata state.Metadata) error {
+func (r *StateStore) Init(metadata state.Metadata) (err error) {
+	// The errors of the shared key credential may quote the account key
+	redactor := redact.New(metadata.Properties, nil)
+	r.logger = redactor.Logger(r.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	meta, err := getTablesMetadata(metadata.Properties)
 	if err != nil {
 		return err
 	}
+	r.timeout, err = mdutils.GetOperationTimeout(metadata.Properties, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	r.keyCodec, err = state.NewKeyCodec(metadata)
+	if err != nil {
+		return err
+	}
 
 	var client *aztables.ServiceClient
 
@@ -146,7 +163,7 @@ func (r *StateStore) Init(metadata state.Metadata) error {
 	}
 
 	if !meta.SkipCreateTable {
-		createContext, cancel := context.WithTimeout(context.Background(), timeout)
+		createContext, cancel := context.WithTimeout(context.Background(), r.timeout)
 		defer cancel()
 		_, innerErr := client.CreateTable(createContext, meta.TableName, nil)
 		if innerErr != nil {
@@ -188,8 +205,8 @@ func (r *StateStore) Delete(req *state.DeleteRequest) error {
 
 func (r *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	r.logger.Debugf("fetching %s", req.Key)
-	pk, rk := getPartitionAndRowKey(req.Key, r.cosmosDBMode)
-	getContext, cancel := context.WithTimeout(context.Background(), timeout)
+	pk, rk := getPartitionAndRowKey(r.keyCodec.Encode(req.Key), r.cosmosDBMode)
+	getContext, cancel := context.WithTimeout(context.Background(), r.timeout)
 	defer cancel()
 	resp, err := r.client.GetEntity(getContext, pk, rk, nil)
 	if err != nil {
@@ -222,6 +239,13 @@ func (r *StateStore) GetComponentMetadata() map[string]string {
 	return metadataInfo
 }
 
+func (r *StateStore) ComponentProfile() mdutils.ComponentProfile {
+	return mdutils.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(r.Features()),
+		AuthProfiles: []mdutils.AuthProfile{mdutils.AuthProfileAccessKey, mdutils.AuthProfileAzureAD},
+	}
+}
+
 func NewAzureTablesStateStore(logger logger.Logger) state.Store {
 	s := &StateStore{
 		json:     jsoniter.ConfigFastest,
@@ -261,7 +285,7 @@ func (r *StateStore) writeRow(req *state.SetRequest) error {
 		return err
 	}
 
-	writeContext, cancel := context.WithTimeout(context.Background(), timeout)
+	writeContext, cancel := context.WithTimeout(context.Background(), r.timeout)
 	defer cancel()
 	// InsertOrReplace does not support ETag concurrency, therefore we will use Insert to check for key existence
 	// and then use Update to update the key if it exists with the specified ETag
@@ -270,7 +294,7 @@ func (r *StateStore) writeRow(req *state.SetRequest) error {
 	if err != nil {
 		// If Insert failed because item already exists, try to Update instead per Upsert semantics
 		if isEntityAlreadyExistsError(err) {
-			updateContext, cancel := context.WithTimeout(context.Background(), timeout)
+			updateContext, cancel := context.WithTimeout(context.Background(), r.timeout)
 			defer cancel()
 			// Always Update using the etag when provided even if Concurrency != FirstWrite.
 			// Today the presence of etag takes precedence over Concurrency.
@@ -338,9 +362,9 @@ func isTableAlreadyExistsError(err error) bool {
 }
 
 func (r *StateStore) deleteRow(req *state.DeleteRequest) error {
-	pk, rk := getPartitionAndRowKey(req.Key, r.cosmosDBMode)
+	pk, rk := getPartitionAndRowKey(r.keyCodec.Encode(req.Key), r.cosmosDBMode)
 
-	deleteContext, cancel := context.WithTimeout(context.Background(), timeout)
+	deleteContext, cancel := context.WithTimeout(context.Background(), r.timeout)
 	defer cancel()
 
 	if req.ETag != nil {
@@ -354,7 +378,7 @@ func (r *StateStore) deleteRow(req *state.DeleteRequest) error {
 }
 
 func getPartitionAndRowKey(key string, cosmosDBmode bool) (string, string) {
-	pr := strings.Split(key, keyDelimiter)
+	pr := strings.Split(key, state.KeyDelimiter)
 	if len(pr) != 2 {
 		if cosmosDBmode {
 			return pr[0], "_dapr_empty_row_key_value_"
@@ -375,7 +399,7 @@ func (r *StateStore) marshal(req *state.SetRequest) ([]byte, error) {
 		value, _ = jsoniter.MarshalToString(req.Value)
 	}
 
-	pk, rk := getPartitionAndRowKey(req.Key, r.cosmosDBMode)
+	pk, rk := getPartitionAndRowKey(r.keyCodec.Encode(req.Key), r.cosmosDBMode)
 
 	entity := aztables.EDMEntity{
 		Entity: aztables.Entity{
@@ -68,4 +68,20 @@ func TestPartitionAndRowKey(t *testing.T) {
 		assert.Equal(t, "pk_rk", pk)
 		assert.Equal(t, "", rk)
 	})
+
+	t.Run("Empty partition key", func(t *testing.T) {
+		pk, rk := getPartitionAndRowKey("||rk", false)
+		assert.Equal(t, "", pk)
+		assert.Equal(t, "rk", rk)
+	})
+
+	t.Run("Multiple delimiters present", func(t *testing.T) {
+		pk, rk := getPartitionAndRowKey("pk||rk||suffix", false)
+		assert.Equal(t, "pk", pk)
+		assert.Equal(t, "", rk)
+
+		pk, rk = getPartitionAndRowKey("pk||rk||suffix", true)
+		assert.Equal(t, "pk", pk)
+		assert.Equal(t, "_dapr_empty_row_key_value_", rk)
+	})
 }
@@ -0,0 +1,361 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cached
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultCacheTTL         = time.Minute
+	defaultFlushInterval    = time.Second
+	defaultMaxPendingWrites = 1000
+
+	writeModeWriteThrough = "writeThrough"
+	writeModeWriteBehind  = "writeBehind"
+
+	consistencyEventual = "eventual"
+	consistencyStrong   = "strong"
+)
+
+// Store is a state store layering a fast cache store in front of a durable store.
+// Reads are served from the cache, which is populated from the durable store on misses; writes go to the durable
+// store and invalidate the cached keys. Cached entries expire after the cache TTL, which bounds how stale they can be
+// when the durable store is also written by other clients.
+// In write-behind mode, writes are buffered and flushed to the durable store in the background.
+type Store struct {
+	state.DefaultBulkStore
+
+	cache    state.Store
+	durable  state.Store
+	resolver state.StoreResolver
+	metadata cachedMetadata
+	features []state.Feature
+	logger   logger.Logger
+
+	// Writes not flushed to the durable store yet, in write-behind mode, and those being flushed.
+	pending     map[string]*pendingWrite
+	flushing    map[string]*pendingWrite
+	pendingLock sync.Mutex
+	flushLock   sync.Mutex
+	closeCh     chan struct{}
+	wg          sync.WaitGroup
+}
+
+type cachedMetadata struct {
+	// Component name of the cache store, which must support the ttlInSeconds metadata.
+	CacheStore string `mapstructure:"cacheStore"`
+	// Component name of the durable store.
+	DurableStore string `mapstructure:"durableStore"`
+	// Time to live of the cached entries.
+	CacheTTL time.Duration `mapstructure:"cacheTTL"`
+	// Prefix of the keys in the cache store, when it's shared with other applications.
+	CacheKeyPrefix string `mapstructure:"cacheKeyPrefix"`
+	// writeThrough (default) writes to the durable store before returning; writeBehind buffers the writes and
+	// flushes them in the background, losing them if the process stops before they're flushed.
+	WriteMode string `mapstructure:"writeMode"`
+	// Interval between the flushes of the buffered writes, in write-behind mode.
+	FlushInterval time.Duration `mapstructure:"flushInterval"`
+	// Number of buffered writes after which writes wait for a flush, in write-behind mode.
+	MaxPendingWrites int `mapstructure:"maxPendingWrites"`
+	// Consistency of the requests that don't set one: eventual (default) reads from the cache and, in write-behind
+	// mode, writes in the background; strong reads from and writes to the durable store.
+	DefaultConsistency string `mapstructure:"defaultConsistency"`
+}
+
+// cachedItem is the value stored in the cache, preserving the ETag of the durable store.
+type cachedItem struct {
+	Data        []byte            `json:"data"`
+	ETag        *string           `json:"etag,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ContentType *string           `json:"contentType,omitempty"`
+}
+
+// NewCachedStateStore returns a new cached state store.
+func NewCachedStateStore(logger logger.Logger) state.Store {
+	s := &Store{
+		logger:   logger,
+		pending:  map[string]*pendingWrite{},
+		flushing: map[string]*pendingWrite{},
+		closeCh:  make(chan struct{}),
+	}
+	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
+
+	return s
+}
+
+// SetStoreResolver sets the resolver of the cache and durable stores.
+func (s *Store) SetStoreResolver(resolver state.StoreResolver) {
+	s.resolver = resolver
+}
+
+// Init parses the metadata and resolves the cache and durable stores.
+func (s *Store) Init(meta state.Metadata) error {
+	m := cachedMetadata{
+		CacheTTL:           defaultCacheTTL,
+		WriteMode:          writeModeWriteThrough,
+		FlushInterval:      defaultFlushInterval,
+		MaxPendingWrites:   defaultMaxPendingWrites,
+		DefaultConsistency: consistencyEventual,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.CacheStore == "" || m.DurableStore == "" {
+		return errors.New("cached state store: cacheStore and durableStore are required")
+	}
+	if m.CacheTTL < time.Second {
+		return errors.New("cached state store: cacheTTL must be at least 1s")
+	}
+	switch m.WriteMode {
+	case writeModeWriteThrough:
+	case writeModeWriteBehind:
+		if m.FlushInterval <= 0 {
+			return errors.New("cached state store: flushInterval must be greater than 0")
+		}
+		if m.MaxPendingWrites < 1 {
+			return errors.New("cached state store: maxPendingWrites must be at least 1")
+		}
+	default:
+		return fmt.Errorf("cached state store: invalid writeMode %s, accepted values are %s or %s", m.WriteMode, writeModeWriteThrough, writeModeWriteBehind)
+	}
+	m.DefaultConsistency = strings.ToLower(m.DefaultConsistency)
+	if m.DefaultConsistency != consistencyEventual && m.DefaultConsistency != consistencyStrong {
+		return fmt.Errorf("cached state store: invalid defaultConsistency %s, accepted values are %s or %s", m.DefaultConsistency, consistencyEventual, consistencyStrong)
+	}
+	if s.resolver == nil {
+		return errors.New("cached state store: no resolver for the referenced stores")
+	}
+
+	s.cache, err = s.resolver(m.CacheStore)
+	if err != nil {
+		return fmt.Errorf("cached state store: %w", err)
+	}
+	s.durable, err = s.resolver(m.DurableStore)
+	if err != nil {
+		return fmt.Errorf("cached state store: %w", err)
+	}
+	s.metadata = m
+
+	// Only the features implemented by this store are passed through
+	s.features = make([]state.Feature, 0, 2)
+	for _, f := range s.durable.Features() {
+		if f == state.FeatureETag || f == state.FeatureTransactional {
+			s.features = append(s.features, f)
+		}
+	}
+
+	if m.WriteMode == writeModeWriteBehind {
+		s.wg.Add(1)
+		go s.flushLoop()
+	}
+
+	return nil
+}
+
+func (s *Store) Features() []state.Feature {
+	return s.features
+}
+
+func (s *Store) cacheKey(key string) string {
+	return s.metadata.CacheKeyPrefix + key
+}
+
+// strong returns true if a request with the given consistency must bypass the cache and the write buffer.
+func (s *Store) strong(consistency string) bool {
+	if consistency == "" {
+		return s.metadata.DefaultConsistency == consistencyStrong
+	}
+	return consistency == state.Strong
+}
+
+// Get reads the key from the write buffer or the cache, falling back to the durable store.
+// Requests with strong consistency always read from the durable store.
+func (s *Store) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	if s.strong(req.Options.Consistency) {
+		// The buffered write of the key must be visible in the durable store
+		err := s.flush(req.Key)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if res, ok := s.getPending(req.Key); ok {
+			return res, nil
+		}
+
+		res, err := s.cache.Get(&state.GetRequest{Key: s.cacheKey(req.Key)})
+		if err != nil {
+			s.logger.Warnf("cached state store: failed to read key %s from the cache: %v", req.Key, err)
+		} else if res != nil && len(res.Data) > 0 {
+			var item cachedItem
+			if err = json.Unmarshal(res.Data, &item); err == nil {
+				return &state.GetResponse{
+					Data:        item.Data,
+					ETag:        item.ETag,
+					Metadata:    item.Metadata,
+					ContentType: item.ContentType,
+				}, nil
+			}
+			s.logger.Warnf("cached state store: invalid cached value for key %s: %v", req.Key, err)
+		}
+	}
+
+	res, err := s.durable.Get(req)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil && len(res.Data) > 0 {
+		s.populate(req.Key, res)
+	}
+
+	return res, nil
+}
+
+// populate caches a response of the durable store. Failures only affect performance, so they're logged.
+func (s *Store) populate(key string, res *state.GetResponse) {
+	b, err := json.Marshal(cachedItem{
+		Data:        res.Data,
+		ETag:        res.ETag,
+		Metadata:    res.Metadata,
+		ContentType: res.ContentType,
+	})
+	if err != nil {
+		s.logger.Warnf("cached state store: failed to encode key %s for the cache: %v", key, err)
+		return
+	}
+
+	err = s.cache.Set(&state.SetRequest{
+		Key:   s.cacheKey(key),
+		Value: b,
+		Metadata: map[string]string{
+			"ttlInSeconds": strconv.FormatInt(int64(s.metadata.CacheTTL/time.Second), 10),
+		},
+	})
+	if err != nil {
+		s.logger.Warnf("cached state store: failed to cache key %s: %v", key, err)
+	}
+}
+
+// invalidate removes keys from the cache after they were written to the durable store.
+// If the removal fails, the cached value is stale until it expires.
+func (s *Store) invalidate(keys ...string) {
+	for _, key := range keys {
+		if err := s.cache.Delete(&state.DeleteRequest{Key: s.cacheKey(key)}); err != nil {
+			s.logger.Warnf("cached state store: failed to invalidate key %s in the cache: %v", key, err)
+		}
+	}
+}
+
+func (s *Store) Set(req *state.SetRequest) error {
+	if s.writeBehind(req.ETag, req.Options.Concurrency, req.Options.Consistency) {
+		return s.enqueue(req.Key, &pendingWrite{set: req})
+	}
+
+	err := s.flush(req.Key)
+	if err != nil {
+		return err
+	}
+	err = s.durable.Set(req)
+	// Even failed writes invalidate the cache, as they could have been partially applied
+	s.invalidate(req.Key)
+
+	return err
+}
+
+func (s *Store) Delete(req *state.DeleteRequest) error {
+	if s.writeBehind(req.ETag, req.Options.Concurrency, req.Options.Consistency) {
+		return s.enqueue(req.Key, &pendingWrite{delete: req})
+	}
+
+	err := s.flush(req.Key)
+	if err != nil {
+		return err
+	}
+	err = s.durable.Delete(req)
+	s.invalidate(req.Key)
+
+	return err
+}
+
+// Multi is only called by the runtime if the durable store is transactional, as features are passed through.
+// Transactions are always written to the durable store before returning.
+func (s *Store) Multi(request *state.TransactionalStateRequest) error {
+	tx, ok := s.durable.(state.TransactionalStore)
+	if !ok {
+		return errors.New("cached state store: the durable store doesn't support transactions")
+	}
+
+	err := s.flush()
+	if err != nil {
+		return err
+	}
+	err = tx.Multi(request)
+
+	keys := make([]string, 0, len(request.Operations))
+	for _, op := range request.Operations {
+		switch r := op.Request.(type) {
+		case state.SetRequest:
+			keys = append(keys, r.Key)
+		case state.DeleteRequest:
+			keys = append(keys, r.Key)
+		}
+	}
+	s.invalidate(keys...)
+
+	return err
+}
+
+func (s *Store) Ping() error {
+	return state.Ping(s.durable)
+}
+
+// Close flushes the buffered writes. It doesn't close the referenced stores, which are components of their own.
+func (s *Store) Close() error {
+	select {
+	case <-s.closeCh:
+		return nil
+	default:
+		close(s.closeCh)
+	}
+	s.wg.Wait()
+
+	return s.flush()
+}
+
+func (s *Store) GetComponentMetadata() map[string]string {
+	metadataStruct := cachedMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+// ComponentProfile declares the capabilities passed through from the durable store, once initialized.
+func (s *Store) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
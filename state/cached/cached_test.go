@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cached
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func newCachedStore(t *testing.T, props map[string]string) (*Store, state.Store, state.Store, error) {
+	t.Helper()
+
+	log := logger.NewLogger("test")
+	cache := inmemory.NewInMemoryStateStore(log)
+	require.NoError(t, cache.Init(state.Metadata{}))
+	durable := inmemory.NewInMemoryStateStore(log)
+	require.NoError(t, durable.Init(state.Metadata{}))
+
+	s := NewCachedStateStore(log).(*Store)
+	s.SetStoreResolver(state.NewMapStoreResolver(map[string]state.Store{"cache": cache, "durable": durable}))
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: props}})
+
+	return s, cache, durable, err
+}
+
+func TestInit(t *testing.T) {
+	t.Run("valid metadata", func(t *testing.T) {
+		s, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "cacheTTL": "30s", "cacheKeyPrefix": "app||"})
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, s.metadata.CacheTTL)
+		assert.Equal(t, "app||k", s.cacheKey("k"))
+		assert.Equal(t, []state.Feature{state.FeatureETag, state.FeatureTransactional}, s.Features())
+		assert.Equal(t, []metadata.Capability{metadata.CapabilityETag, metadata.CapabilityTransactions}, s.ComponentProfile().Capabilities)
+	})
+
+	t.Run("default TTL", func(t *testing.T) {
+		s, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable"})
+		require.NoError(t, err)
+		assert.Equal(t, defaultCacheTTL, s.metadata.CacheTTL)
+	})
+
+	t.Run("missing store", func(t *testing.T) {
+		_, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache"})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown store", func(t *testing.T) {
+		_, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "other"})
+		assert.Error(t, err)
+	})
+
+	t.Run("TTL too short", func(t *testing.T) {
+		_, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "cacheTTL": "100ms"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid write mode", func(t *testing.T) {
+		_, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "writeMode": "writeAround"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid consistency", func(t *testing.T) {
+		_, _, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "defaultConsistency": "causal"})
+		assert.Error(t, err)
+	})
+}
+
+func TestReadThroughWriteInvalidate(t *testing.T) {
+	s, cache, durable, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set(&state.SetRequest{Key: "k", Value: "v1"}))
+
+	// The first read populates the cache
+	res, err := s.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+	require.NotNil(t, res.ETag)
+	etag := *res.ETag
+
+	cached, err := cache.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, cached.Data)
+
+	// Writes made behind the cache aren't visible until it's invalidated
+	require.NoError(t, durable.Set(&state.SetRequest{Key: "k", Value: "v2"}))
+	res, err = s.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+	assert.Equal(t, etag, *res.ETag)
+
+	// Strong consistency reads bypass the cache
+	res, err = s.Get(&state.GetRequest{Key: "k", Options: state.GetStateOption{Consistency: state.Strong}})
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, string(res.Data))
+
+	// Writes invalidate the cache
+	require.NoError(t, s.Set(&state.SetRequest{Key: "k", Value: "v3"}))
+	cached, err = cache.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Empty(t, cached.Data)
+	res, err = s.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v3"`, string(res.Data))
+
+	// Transactions invalidate the keys they write
+	require.NoError(t, s.Multi(&state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{{Operation: state.Delete, Request: state.DeleteRequest{Key: "k"}}},
+	}))
+	res, err = s.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Data)
+}
+
+func TestMissesAreNotCached(t *testing.T) {
+	s, cache, _, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable"})
+	require.NoError(t, err)
+
+	res, err := s.Get(&state.GetRequest{Key: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Data)
+
+	cached, err := cache.Get(&state.GetRequest{Key: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, cached.Data)
+}
+
+func TestWriteBehind(t *testing.T) {
+	s, _, durable, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "writeMode": "writeBehind", "flushInterval": "1h", "maxPendingWrites": "3"})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Set(&state.SetRequest{Key: "k1", Value: "v1"}))
+	require.NoError(t, s.Delete(&state.DeleteRequest{Key: "k2"}))
+
+	// Buffered writes are visible through the store but not in the durable store
+	res, err := s.Get(&state.GetRequest{Key: "k1"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+	res, err = durable.Get(&state.GetRequest{Key: "k1"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Data)
+
+	// Strong consistency reads flush the key
+	res, err = s.Get(&state.GetRequest{Key: "k1", Options: state.GetStateOption{Consistency: state.Strong}})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+	require.NotNil(t, res.ETag)
+	etag := *res.ETag
+
+	// Writes with an ETag are synchronous
+	require.NoError(t, s.Set(&state.SetRequest{Key: "k1", Value: "v2", ETag: &etag}))
+	res, err = durable.Get(&state.GetRequest{Key: "k1"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, string(res.Data))
+	err = s.Set(&state.SetRequest{Key: "k1", Value: "v3", ETag: &etag})
+	assert.Error(t, err)
+
+	// Reaching the maximum number of buffered writes flushes them
+	for i := 3; i <= 6; i++ {
+		require.NoError(t, s.Set(&state.SetRequest{Key: fmt.Sprintf("k%d", i), Value: i}))
+	}
+	res, err = durable.Get(&state.GetRequest{Key: "k4"})
+	require.NoError(t, err)
+	assert.Equal(t, "4", string(res.Data))
+	res, err = durable.Get(&state.GetRequest{Key: "k6"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Data)
+
+	// Closing flushes the remaining writes
+	require.NoError(t, s.Close())
+	res, err = durable.Get(&state.GetRequest{Key: "k6"})
+	require.NoError(t, err)
+	assert.Equal(t, "6", string(res.Data))
+}
+
+func TestWriteBehindFlushInterval(t *testing.T) {
+	s, _, durable, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "writeMode": "writeBehind", "flushInterval": "10ms"})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Set(&state.SetRequest{Key: "k", Value: "v"}))
+	assert.Eventually(t, func() bool {
+		res, err := durable.Get(&state.GetRequest{Key: "k"})
+		return err == nil && string(res.Data) == `"v"`
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStrongDefaultConsistency(t *testing.T) {
+	s, cache, durable, err := newCachedStore(t, map[string]string{"cacheStore": "cache", "durableStore": "durable", "writeMode": "writeBehind", "defaultConsistency": "strong"})
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Writes are synchronous and reads bypass the cache, unless requests ask for eventual consistency
+	require.NoError(t, s.Set(&state.SetRequest{Key: "k", Value: "v1"}))
+	res, err := durable.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+
+	_, err = s.Get(&state.GetRequest{Key: "k", Options: state.GetStateOption{Consistency: state.Eventual}})
+	require.NoError(t, err)
+	require.NoError(t, durable.Set(&state.SetRequest{Key: "k", Value: "v2"}))
+
+	cached, err := cache.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, cached.Data)
+	res, err = s.Get(&state.GetRequest{Key: "k", Options: state.GetStateOption{Consistency: state.Eventual}})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+	res, err = s.Get(&state.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, string(res.Data))
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cached
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
+)
+
+// Number of times a buffered write is flushed before it's dropped.
+const maxFlushAttempts = 5
+
+// pendingWrite is the last buffered write of a key, either a set or a delete.
+type pendingWrite struct {
+	set      *state.SetRequest
+	delete   *state.DeleteRequest
+	attempts int
+}
+
+// writeBehind returns true if a write can be buffered.
+// Writes with an ETag or first-write concurrency must be checked by the durable store, so they're synchronous.
+func (s *Store) writeBehind(etag *string, concurrency string, consistency string) bool {
+	return s.metadata.WriteMode == writeModeWriteBehind &&
+		(etag == nil || *etag == "") &&
+		concurrency != state.FirstWrite &&
+		!s.strong(consistency)
+}
+
+// enqueue buffers a write, replacing the previous buffered write of the key.
+func (s *Store) enqueue(key string, w *pendingWrite) error {
+	if w.set != nil {
+		// Fail now for values that can't be read back from the buffer
+		if _, err := utils.Marshal(w.set.Value, json.Marshal); err != nil {
+			return err
+		}
+	}
+
+	s.pendingLock.Lock()
+	full := len(s.pending) >= s.metadata.MaxPendingWrites
+	s.pendingLock.Unlock()
+	if full {
+		// Writes wait for the flush rather than growing the buffer without bound
+		err := s.flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.pendingLock.Lock()
+	s.pending[key] = w
+	s.pendingLock.Unlock()
+
+	// Cached values are older than the buffered write, which is returned by reads until it's flushed
+	s.invalidate(key)
+
+	return nil
+}
+
+// getPending returns the buffered write of a key, if any, as the response of a read.
+// Buffered values don't have an ETag until they're flushed.
+func (s *Store) getPending(key string) (*state.GetResponse, bool) {
+	s.pendingLock.Lock()
+	w, ok := s.pending[key]
+	if !ok {
+		w, ok = s.flushing[key]
+	}
+	s.pendingLock.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	if w.delete != nil {
+		return &state.GetResponse{}, true
+	}
+	data, err := utils.Marshal(w.set.Value, json.Marshal)
+	if err != nil {
+		// Values are checked when they're buffered
+		return nil, false
+	}
+	return &state.GetResponse{Data: data, Metadata: w.set.Metadata}, true
+}
+
+// flush writes the buffered writes of the keys to the durable store, or all of them if no key is given.
+// Writes that fail are buffered again, unless the key was written since.
+func (s *Store) flush(keys ...string) error {
+	if s.metadata.WriteMode != writeModeWriteBehind {
+		return nil
+	}
+
+	s.flushLock.Lock()
+	defer s.flushLock.Unlock()
+
+	s.pendingLock.Lock()
+	if len(keys) == 0 {
+		s.flushing = s.pending
+		s.pending = make(map[string]*pendingWrite, len(s.flushing))
+	} else {
+		for _, key := range keys {
+			if w, ok := s.pending[key]; ok {
+				s.flushing[key] = w
+				delete(s.pending, key)
+			}
+		}
+	}
+	batch := s.flushing
+	s.pendingLock.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	sets := make([]state.SetRequest, 0, len(batch))
+	deletes := make([]state.DeleteRequest, 0, len(batch))
+	setKeys := make([]string, 0, len(batch))
+	deleteKeys := make([]string, 0, len(batch))
+	for key, w := range batch {
+		if w.set != nil {
+			sets = append(sets, *w.set)
+			setKeys = append(setKeys, key)
+		} else {
+			deletes = append(deletes, *w.delete)
+			deleteKeys = append(deleteKeys, key)
+		}
+	}
+
+	var err, failed error
+	var retry []string
+	if len(sets) > 0 {
+		if err = s.durable.BulkSet(sets); err != nil {
+			failed = multierror.Append(failed, err)
+			retry = append(retry, setKeys...)
+		}
+	}
+	if len(deletes) > 0 {
+		if err = s.durable.BulkDelete(deletes); err != nil {
+			failed = multierror.Append(failed, err)
+			retry = append(retry, deleteKeys...)
+		}
+	}
+
+	s.pendingLock.Lock()
+	for _, key := range retry {
+		w := batch[key]
+		w.attempts++
+		if w.attempts >= maxFlushAttempts {
+			// Writes that keep failing, such as invalid requests, would otherwise block the writes of the key
+			s.logger.Errorf("cached state store: dropping the write of key %s after %d failed attempts", key, w.attempts)
+			continue
+		}
+		if _, ok := s.pending[key]; !ok {
+			s.pending[key] = w
+		}
+	}
+	s.flushing = map[string]*pendingWrite{}
+	s.pendingLock.Unlock()
+
+	// Values cached while the writes were flushed may be stale
+	s.invalidate(append(setKeys, deleteKeys...)...)
+
+	return failed
+}
+
+// flushLoop flushes the buffered writes periodically until the store is closed.
+func (s *Store) flushLoop() {
+	defer s.wg.Done()
+
+	t := time.NewTicker(s.metadata.FlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			err := s.flush()
+			if err != nil {
+				s.logger.Errorf("cached state store: failed to flush writes to the durable store, retrying: %v", err)
+			}
+		case <-s.closeCh:
+			return
+		}
+	}
+}
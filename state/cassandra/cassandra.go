@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/gocql/gocql"
 	jsoniter "github.com/json-iterator/go"
@@ -36,6 +37,8 @@ const (
 	table                    = "table"
 	keyspace                 = "keyspace"
 	replicationFactor        = "replicationFactor"
+	compactionStrategy       = "compactionStrategy"
+	defaultTTLInSeconds      = "defaultTTLInSeconds"
 	defaultProtoVersion      = 4
 	defaultReplicationFactor = 1
 	defaultConsistency       = gocql.All
@@ -43,14 +46,25 @@ const (
 	defaultKeyspace          = "dapr"
 	defaultPort              = 9042
 	metadataTTLKey           = "ttlInSeconds"
+
+	// Maximum TTL accepted by Cassandra, 20 years.
+	maxTTLInSeconds = 630720000
 )
 
+// Compaction strategies that can be set on the table when it's created.
+var compactionStrategies = []string{
+	"SizeTieredCompactionStrategy",
+	"LeveledCompactionStrategy",
+	"TimeWindowCompactionStrategy",
+}
+
 // Cassandra is a state store implementation for Apache Cassandra.
 type Cassandra struct {
 	state.DefaultBulkStore
-	session *gocql.Session
-	cluster *gocql.ClusterConfig
-	table   string
+	session  *gocql.Session
+	cluster  *gocql.ClusterConfig
+	table    string
+	keyCodec *state.KeyCodec
 
 	logger logger.Logger
 }
@@ -65,6 +79,10 @@ type cassandraMetadata struct {
 	Consistency       string
 	Table             string
 	Keyspace          string
+	// Compaction strategy of the table, used only when the table is created.
+	CompactionStrategy string
+	// Default TTL of the table, used only when the table is created. 0 means no expiration.
+	DefaultTTLInSeconds int
 }
 
 // NewCassandraStateStore returns a new cassandra state store.
@@ -81,6 +99,9 @@ func (c *Cassandra) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if c.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	cluster, err := c.createClusterConfig(meta)
 	if err != nil {
@@ -99,7 +120,7 @@ func (c *Cassandra) Init(metadata state.Metadata) error {
 		return fmt.Errorf("error creating keyspace %s: %s", meta.Keyspace, err)
 	}
 
-	err = c.tryCreateTable(meta.Table, meta.Keyspace)
+	err = c.tryCreateTable(meta)
 	if err != nil {
 		return fmt.Errorf("error creating table %s: %s", meta.Table, err)
 	}
@@ -118,8 +139,27 @@ func (c *Cassandra) tryCreateKeyspace(keyspace string, replicationFactor int) er
 	return c.session.Query(fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH REPLICATION = {'class' : 'SimpleStrategy', 'replication_factor' : %s};", keyspace, fmt.Sprintf("%v", replicationFactor))).Exec()
 }
 
-func (c *Cassandra) tryCreateTable(table, keyspace string) error {
-	return c.session.Query(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (key text, value blob, PRIMARY KEY (key));", keyspace, table)).Exec()
+func (c *Cassandra) tryCreateTable(meta *cassandraMetadata) error {
+	return c.session.Query(createTableQuery(meta)).Exec()
+}
+
+// createTableQuery returns the statement creating the table, with the options of the metadata.
+// The options aren't applied to existing tables.
+func createTableQuery(meta *cassandraMetadata) string {
+	var options []string
+	if meta.CompactionStrategy != "" {
+		options = append(options, fmt.Sprintf("compaction = {'class' : '%s'}", meta.CompactionStrategy))
+	}
+	if meta.DefaultTTLInSeconds > 0 {
+		options = append(options, fmt.Sprintf("default_time_to_live = %d", meta.DefaultTTLInSeconds))
+	}
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (key text, value blob, PRIMARY KEY (key))", meta.Keyspace, meta.Table)
+	if len(options) > 0 {
+		query += " WITH " + strings.Join(options, " AND ")
+	}
+
+	return query + ";"
 }
 
 func (c *Cassandra) createClusterConfig(metadata *cassandraMetadata) (*gocql.ClusterConfig, error) {
@@ -208,12 +248,34 @@ func getCassandraMetadata(meta state.Metadata) (*cassandraMetadata, error) {
 		m.ReplicationFactor = int(r)
 	}
 
+	if m.ReplicationFactor < 1 {
+		return nil, fmt.Errorf("invalid replicationFactor %d, must be at least 1", m.ReplicationFactor)
+	}
+
+	if m.CompactionStrategy != "" {
+		found := false
+		for _, s := range compactionStrategies {
+			if strings.EqualFold(m.CompactionStrategy, s) {
+				m.CompactionStrategy = s
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid %s %s, accepted values are %s", compactionStrategy, m.CompactionStrategy, strings.Join(compactionStrategies, ", "))
+		}
+	}
+
+	if m.DefaultTTLInSeconds < 0 || m.DefaultTTLInSeconds > maxTTLInSeconds {
+		return nil, fmt.Errorf("invalid %s %d, must be between 0 and %d", defaultTTLInSeconds, m.DefaultTTLInSeconds, maxTTLInSeconds)
+	}
+
 	return &m, nil
 }
 
 // Delete performs a delete operation.
 func (c *Cassandra) Delete(req *state.DeleteRequest) error {
-	return c.session.Query(fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table), req.Key).Exec()
+	return c.session.Query(fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table), c.keyCodec.Encode(req.Key)).Exec()
 }
 
 // Get retrieves state from cassandra with a key.
@@ -236,7 +298,7 @@ func (c *Cassandra) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		session = sess
 	}
 
-	results, err := session.Query(fmt.Sprintf("SELECT value FROM %s WHERE key = ?", c.table), req.Key).Iter().SliceMap()
+	results, err := session.Query(fmt.Sprintf("SELECT value FROM %s WHERE key = ?", c.table), c.keyCodec.Encode(req.Key)).Iter().SliceMap()
 	if err != nil {
 		return nil, err
 	}
@@ -283,11 +345,12 @@ func (c *Cassandra) Set(req *state.SetRequest) error {
 		return fmt.Errorf("error parsing TTL from Metadata: %s", err)
 	}
 
+	// A TTL set on the request overrides the default TTL of the table
 	if ttl != nil {
-		return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), req.Key, bt, *ttl).Exec()
+		return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), c.keyCodec.Encode(req.Key), bt, *ttl).Exec()
 	}
 
-	return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), req.Key, bt).Exec()
+	return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), c.keyCodec.Encode(req.Key), bt).Exec()
 }
 
 func (c *Cassandra) createSession(consistency gocql.Consistency) (*gocql.Session, error) {
@@ -301,13 +364,21 @@ func (c *Cassandra) createSession(consistency gocql.Consistency) (*gocql.Session
 	return session, nil
 }
 
+// parseTTL returns the TTL of a request, if any.
+// A TTL of -1 means that the value never expires, which is a TTL of 0 for Cassandra.
 func parseTTL(requestMetadata map[string]string) (*int, error) {
 	if val, found := requestMetadata[metadataTTLKey]; found && val != "" {
 		parsedVal, err := strconv.ParseInt(val, 10, 0)
 		if err != nil {
 			return nil, err
 		}
+		if parsedVal < -1 || parsedVal > maxTTLInSeconds {
+			return nil, fmt.Errorf("invalid %s %d, must be -1 or between 0 and %d", metadataTTLKey, parsedVal, maxTTLInSeconds)
+		}
 		parsedInt := int(parsedVal)
+		if parsedInt == -1 {
+			parsedInt = 0
+		}
 
 		return &parsedInt, nil
 	}
@@ -321,3 +392,10 @@ func (c *Cassandra) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (c *Cassandra) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(c.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
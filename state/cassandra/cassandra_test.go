@@ -42,6 +42,8 @@ func TestGetCassandraMetadata(t *testing.T) {
 		assert.Equal(t, defaultReplicationFactor, metadata.ReplicationFactor)
 		assert.Equal(t, defaultTable, metadata.Table)
 		assert.Equal(t, defaultPort, metadata.Port)
+		assert.Empty(t, metadata.CompactionStrategy)
+		assert.Equal(t, 0, metadata.DefaultTTLInSeconds)
 	})
 
 	t.Run("With custom values", func(t *testing.T) {
@@ -93,6 +95,38 @@ func TestGetCassandraMetadata(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("With table options", func(t *testing.T) {
+		properties := map[string]string{
+			hosts:               "127.0.0.1",
+			compactionStrategy:  "leveledcompactionstrategy",
+			defaultTTLInSeconds: "3600",
+		}
+		m := state.Metadata{
+			Base: metadata.Base{Properties: properties},
+		}
+
+		metadata, err := getCassandraMetadata(m)
+		assert.Nil(t, err)
+		assert.Equal(t, "LeveledCompactionStrategy", metadata.CompactionStrategy)
+		assert.Equal(t, 3600, metadata.DefaultTTLInSeconds)
+	})
+
+	t.Run("Invalid table options", func(t *testing.T) {
+		for name, properties := range map[string]map[string]string{
+			"compaction strategy":  {hosts: "127.0.0.1", compactionStrategy: "UnknownStrategy"},
+			"negative default TTL": {hosts: "127.0.0.1", defaultTTLInSeconds: "-1"},
+			"default TTL too long": {hosts: "127.0.0.1", defaultTTLInSeconds: "630720001"},
+			"replication factor":   {hosts: "127.0.0.1", replicationFactor: "0"},
+		} {
+			m := state.Metadata{
+				Base: metadata.Base{Properties: properties},
+			}
+
+			_, err := getCassandraMetadata(m)
+			assert.Error(t, err, name)
+		}
+	})
+
 	t.Run("Missing hosts", func(t *testing.T) {
 		properties := map[string]string{
 			consistency:       "Quorum",
@@ -137,9 +171,42 @@ func TestParseTTL(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, *ttl, ttlInSeconds)
 	})
+	t.Run("TTL never expires", func(t *testing.T) {
+		ttl, err := parseTTL(map[string]string{
+			"ttlInSeconds": "-1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, *ttl)
+	})
+	t.Run("TTL out of range", func(t *testing.T) {
+		for _, ttlInSeconds := range []string{"-2", "630720001"} {
+			ttl, err := parseTTL(map[string]string{
+				"ttlInSeconds": ttlInSeconds,
+			})
+			assert.Error(t, err)
+			assert.Nil(t, ttl)
+		}
+	})
 	t.Run("TTL not set", func(t *testing.T) {
 		ttl, err := parseTTL(map[string]string{})
 		assert.NoError(t, err)
 		assert.Nil(t, ttl)
 	})
 }
+
+func TestCreateTableQuery(t *testing.T) {
+	t.Run("Without options", func(t *testing.T) {
+		query := createTableQuery(&cassandraMetadata{Keyspace: "dapr", Table: "items"})
+		assert.Equal(t, "CREATE TABLE IF NOT EXISTS dapr.items (key text, value blob, PRIMARY KEY (key));", query)
+	})
+
+	t.Run("With options", func(t *testing.T) {
+		query := createTableQuery(&cassandraMetadata{
+			Keyspace:            "dapr",
+			Table:               "items",
+			CompactionStrategy:  "TimeWindowCompactionStrategy",
+			DefaultTTLInSeconds: 86400,
+		})
+		assert.Equal(t, "CREATE TABLE IF NOT EXISTS dapr.items (key text, value blob, PRIMARY KEY (key)) WITH compaction = {'class' : 'TimeWindowCompactionStrategy'} AND default_time_to_live = 86400;", query)
+	})
+}
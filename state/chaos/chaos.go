@@ -0,0 +1,283 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	opGet        = "get"
+	opSet        = "set"
+	opDelete     = "delete"
+	opBulkGet    = "bulkget"
+	opBulkSet    = "bulkset"
+	opBulkDelete = "bulkdelete"
+	opMulti      = "multi"
+	opQuery      = "query"
+)
+
+// ErrInjected is returned by the operations failed on purpose.
+var ErrInjected = errors.New("chaos state store: injected failure")
+
+// Store is a state store that wraps another store, injecting latency, errors and ETag conflicts in its operations.
+// It's meant to test the resiliency policies of applications without breaking the real store.
+type Store struct {
+	inner    state.Store
+	resolver state.StoreResolver
+	metadata chaosMetadata
+	ops      map[string]struct{}
+
+	rand     *rand.Rand
+	randLock sync.Mutex
+	logger   logger.Logger
+
+	// Canceled on close, to interrupt the injected latency.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type chaosMetadata struct {
+	// Component name of the wrapped store.
+	Store string `mapstructure:"store"`
+	// Latency added to every operation, plus a random duration up to LatencyJitter.
+	Latency       time.Duration `mapstructure:"latency"`
+	LatencyJitter time.Duration `mapstructure:"latencyJitter"`
+	// Probability between 0 and 1 for an operation to fail with ErrInjected.
+	ErrorRate float64 `mapstructure:"errorRate"`
+	// Probability between 0 and 1 for a write operation to fail with an ETag mismatch.
+	ETagConflictRate float64 `mapstructure:"etagConflictRate"`
+	// Operations affected by the faults, all by default.
+	Operations []string `mapstructure:"operations"`
+	// Seed of the random generator, for reproducible runs. Zero picks a random seed.
+	Seed int64 `mapstructure:"seed"`
+}
+
+// NewChaosStateStore returns a new chaos state store.
+func NewChaosStateStore(logger logger.Logger) state.Store {
+	return &Store{logger: logger}
+}
+
+// SetStoreResolver sets the resolver of the wrapped store.
+func (s *Store) SetStoreResolver(resolver state.StoreResolver) {
+	s.resolver = resolver
+}
+
+// Init parses the metadata and resolves the wrapped store.
+func (s *Store) Init(meta state.Metadata) error {
+	m := chaosMetadata{}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.Store == "" {
+		return errors.New("chaos state store: missing store")
+	}
+	if m.ErrorRate < 0 || m.ErrorRate > 1 || m.ETagConflictRate < 0 || m.ETagConflictRate > 1 {
+		return errors.New("chaos state store: errorRate and etagConflictRate must be between 0 and 1")
+	}
+	if m.Latency < 0 || m.LatencyJitter < 0 {
+		return errors.New("chaos state store: latency and latencyJitter can't be negative")
+	}
+
+	s.ops = make(map[string]struct{}, len(m.Operations))
+	for _, op := range m.Operations {
+		op = strings.ToLower(strings.TrimSpace(op))
+		switch op {
+		case "":
+			continue
+		case opGet, opSet, opDelete, opBulkGet, opBulkSet, opBulkDelete, opMulti, opQuery:
+			s.ops[op] = struct{}{}
+		default:
+			return fmt.Errorf("chaos state store: invalid operation %s", op)
+		}
+	}
+
+	if s.resolver == nil {
+		return errors.New("chaos state store: no resolver for the wrapped store")
+	}
+	s.inner, err = s.resolver(m.Store)
+	if err != nil {
+		return fmt.Errorf("chaos state store: %w", err)
+	}
+
+	seed := m.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s.rand = rand.New(rand.NewSource(seed)) //nolint:gosec
+	s.metadata = m
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	return nil
+}
+
+// inject applies the configured faults to an operation.
+func (s *Store) inject(op string, write bool) error {
+	if len(s.ops) > 0 {
+		if _, ok := s.ops[op]; !ok {
+			return nil
+		}
+	}
+
+	s.randLock.Lock()
+	delay := s.metadata.Latency
+	if s.metadata.LatencyJitter > 0 {
+		delay += time.Duration(s.rand.Int63n(int64(s.metadata.LatencyJitter)))
+	}
+	failure := s.rand.Float64() < s.metadata.ErrorRate
+	conflict := write && s.rand.Float64() < s.metadata.ETagConflictRate
+	s.randLock.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			return fmt.Errorf("chaos state store: %w", s.ctx.Err())
+		}
+	}
+	if failure {
+		s.logger.Debugf("chaos state store: injecting failure in %s", op)
+		return ErrInjected
+	}
+	if conflict {
+		s.logger.Debugf("chaos state store: injecting ETag conflict in %s", op)
+		return state.NewETagError(state.ETagMismatch, ErrInjected)
+	}
+
+	return nil
+}
+
+func (s *Store) Features() []state.Feature {
+	if s.inner == nil {
+		return nil
+	}
+
+	return s.inner.Features()
+}
+
+func (s *Store) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	if err := s.inject(opGet, false); err != nil {
+		return nil, err
+	}
+
+	return s.inner.Get(req)
+}
+
+func (s *Store) Set(req *state.SetRequest) error {
+	if err := s.inject(opSet, true); err != nil {
+		return err
+	}
+
+	return s.inner.Set(req)
+}
+
+func (s *Store) Delete(req *state.DeleteRequest) error {
+	if err := s.inject(opDelete, true); err != nil {
+		return err
+	}
+
+	return s.inner.Delete(req)
+}
+
+func (s *Store) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	if err := s.inject(opBulkGet, false); err != nil {
+		return false, nil, err
+	}
+
+	return s.inner.BulkGet(req)
+}
+
+func (s *Store) BulkSet(req []state.SetRequest) error {
+	if err := s.inject(opBulkSet, true); err != nil {
+		return err
+	}
+
+	return s.inner.BulkSet(req)
+}
+
+func (s *Store) BulkDelete(req []state.DeleteRequest) error {
+	if err := s.inject(opBulkDelete, true); err != nil {
+		return err
+	}
+
+	return s.inner.BulkDelete(req)
+}
+
+// Multi is only called by the runtime if the wrapped store is transactional, as features are passed through.
+func (s *Store) Multi(request *state.TransactionalStateRequest) error {
+	tx, ok := s.inner.(state.TransactionalStore)
+	if !ok {
+		return errors.New("chaos state store: the wrapped store doesn't support transactions")
+	}
+	if err := s.inject(opMulti, true); err != nil {
+		return err
+	}
+
+	return tx.Multi(request)
+}
+
+// Query returns an error if the wrapped store doesn't support queries, as the runtime calls it whatever the features.
+func (s *Store) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	q, ok := s.inner.(state.Querier)
+	if !ok {
+		return nil, errors.New("chaos state store: the wrapped store doesn't support queries")
+	}
+	if err := s.inject(opQuery, false); err != nil {
+		return nil, err
+	}
+
+	return q.Query(req)
+}
+
+func (s *Store) Ping() error {
+	return state.Ping(s.inner)
+}
+
+// Close interrupts the injected latency. It doesn't close the wrapped store, which is a component of its own.
+func (s *Store) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	return nil
+}
+
+func (s *Store) GetComponentMetadata() map[string]string {
+	metadataStruct := chaosMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+// ComponentProfile declares the capabilities of the wrapped store, which are only known once the store is initialized.
+func (s *Store) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
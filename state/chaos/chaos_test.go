@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func newChaosStore(t *testing.T, props map[string]string) (*Store, error) {
+	t.Helper()
+
+	log := logger.NewLogger("test")
+	inner := inmemory.NewInMemoryStateStore(log)
+	require.NoError(t, inner.Init(state.Metadata{}))
+
+	s := NewChaosStateStore(log).(*Store)
+	s.SetStoreResolver(state.NewMapStoreResolver(map[string]state.Store{"inner": inner}))
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: props}})
+
+	return s, err
+}
+
+func TestInit(t *testing.T) {
+	t.Run("valid metadata", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{
+			"store": "inner", "latency": "10ms", "latencyJitter": "5ms", "errorRate": "0.5", "etagConflictRate": "0.1", "operations": "get,Set",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Millisecond, s.metadata.Latency)
+		assert.Equal(t, 5*time.Millisecond, s.metadata.LatencyJitter)
+		assert.Equal(t, 0.5, s.metadata.ErrorRate)
+		assert.Equal(t, 0.1, s.metadata.ETagConflictRate)
+		assert.Len(t, s.ops, 2)
+		assert.Equal(t, []state.Feature{state.FeatureETag, state.FeatureTransactional}, s.Features())
+		assert.Equal(t, []metadata.Capability{metadata.CapabilityETag, metadata.CapabilityTransactions}, s.ComponentProfile().Capabilities)
+	})
+
+	t.Run("capabilities before init", func(t *testing.T) {
+		s := NewChaosStateStore(logger.NewLogger("test")).(*Store)
+		assert.Empty(t, s.ComponentProfile().Capabilities)
+	})
+
+	t.Run("missing store", func(t *testing.T) {
+		_, err := newChaosStore(t, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown store", func(t *testing.T) {
+		_, err := newChaosStore(t, map[string]string{"store": "other"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid rate", func(t *testing.T) {
+		_, err := newChaosStore(t, map[string]string{"store": "inner", "errorRate": "1.5"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		_, err := newChaosStore(t, map[string]string{"store": "inner", "operations": "scan"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFaults(t *testing.T) {
+	t.Run("no faults", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner"})
+		require.NoError(t, err)
+
+		require.NoError(t, s.Set(&state.SetRequest{Key: "k", Value: "v"}))
+		res, err := s.Get(&state.GetRequest{Key: "k"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v"`, string(res.Data))
+		require.NoError(t, s.Multi(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{{Operation: state.Delete, Request: state.DeleteRequest{Key: "k"}}},
+		}))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner", "errorRate": "1"})
+		require.NoError(t, err)
+
+		_, err = s.Get(&state.GetRequest{Key: "k"})
+		assert.ErrorIs(t, err, ErrInjected)
+		assert.ErrorIs(t, s.Set(&state.SetRequest{Key: "k", Value: "v"}), ErrInjected)
+	})
+
+	t.Run("ETag conflicts only on writes", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner", "etagConflictRate": "1"})
+		require.NoError(t, err)
+
+		err = s.Set(&state.SetRequest{Key: "k", Value: "v"})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+		_, err = s.Get(&state.GetRequest{Key: "k"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("faults limited to operations", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner", "errorRate": "1", "operations": "delete"})
+		require.NoError(t, err)
+
+		assert.NoError(t, s.Set(&state.SetRequest{Key: "k", Value: "v"}))
+		assert.ErrorIs(t, s.Delete(&state.DeleteRequest{Key: "k"}), ErrInjected)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner", "latency": "20ms"})
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = s.Get(&state.GetRequest{Key: "k"})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("latency interrupted by close", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner", "latency": "1h"})
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			s.Close()
+		}()
+		_, err = s.Get(&state.GetRequest{Key: "k"})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// querierStore is a store that supports queries.
+type querierStore struct {
+	state.Store
+}
+
+func (s *querierStore) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	return &state.QueryResponse{Results: []state.QueryItem{{Key: "k"}}}, nil
+}
+
+func TestQuery(t *testing.T) {
+	t.Run("wrapped store without queries", func(t *testing.T) {
+		s, err := newChaosStore(t, map[string]string{"store": "inner"})
+		require.NoError(t, err)
+
+		_, err = s.Query(&state.QueryRequest{})
+		assert.Error(t, err)
+	})
+
+	t.Run("wrapped store with queries", func(t *testing.T) {
+		log := logger.NewLogger("test")
+		s := NewChaosStateStore(log).(*Store)
+		s.SetStoreResolver(state.NewMapStoreResolver(map[string]state.Store{"inner": &querierStore{Store: inmemory.NewInMemoryStateStore(log)}}))
+		require.NoError(t, s.Init(state.Metadata{Base: metadata.Base{Properties: map[string]string{"store": "inner"}}}))
+
+		res, err := s.Query(&state.QueryRequest{})
+		require.NoError(t, err)
+		assert.Len(t, res.Results, 1)
+
+		s.metadata.ErrorRate = 1
+		_, err = s.Query(&state.QueryRequest{})
+		assert.ErrorIs(t, err, ErrInjected)
+	})
+}
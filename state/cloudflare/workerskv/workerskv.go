@@ -0,0 +1,387 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workerskv implements a state store backed by Cloudflare Workers KV, storing the values too large for KV in
+// an R2 bucket, with a pointer to the object in KV.
+package workerskv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/internal/component/cloudflare"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultAPIURL  = "https://api.cloudflare.com/client/v4"
+	defaultTimeout = 20 * time.Second
+
+	// Maximum size of a value in KV.
+	maxValueSize = 25 << 20
+	// Minimum TTL of a key in KV.
+	minTTL = 60
+
+	// Values stored in R2 are replaced in KV with this prefix followed by the key of the object.
+	overflowPointerPrefix = "\x00dapr-r2:"
+
+	// Bodies of the responses are truncated in errors.
+	maxErrorBodySize = 1024
+)
+
+var errKeyNotFound = errors.New("key not found")
+
+// WorkersKV is a state store backed by a namespace of Cloudflare Workers KV, using the REST API of Cloudflare.
+// KV is eventually consistent: a value may be read from other locations up to 60 seconds after it was written.
+type WorkersKV struct {
+	state.DefaultBulkStore
+	metadata workersKVMetadata
+	keyCodec *state.KeyCodec
+	// URL of the Cloudflare API, which tests replace.
+	apiURL string
+	client *http.Client
+	// Bucket storing the values bigger than the overflow threshold; nil if values aren't stored in R2.
+	overflow objectStore
+	logger   logger.Logger
+}
+
+// objectStore stores the values that overflow to R2.
+type objectStore interface {
+	Put(ctx context.Context, key string, data []byte) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type workersKVMetadata struct {
+	// Identifiers of the account and of the namespace, and API token with the Workers KV Storage edit permission.
+	AccountID   string        `mapstructure:"accountID"`
+	NamespaceID string        `mapstructure:"namespaceID"`
+	APIToken    string        `mapstructure:"apiToken"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	// R2 bucket storing the values bigger than OverflowThreshold, with the access key of an R2 API token.
+	R2Bucket          string `mapstructure:"r2Bucket"`
+	R2AccessKeyID     string `mapstructure:"r2AccessKeyID"`
+	R2SecretAccessKey string `mapstructure:"r2SecretAccessKey"`
+	// Endpoint of the S3-compatible API of R2, which defaults to the endpoint of the account.
+	R2Endpoint string `mapstructure:"r2Endpoint"`
+	// Size in bytes above which values are stored in R2.
+	OverflowThreshold int `mapstructure:"overflowThreshold"`
+}
+
+// apiResponse is the envelope of the responses of the REST API.
+type apiResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// NewWorkersKV returns a new Cloudflare Workers KV state store.
+func NewWorkersKV(logger logger.Logger) state.Store {
+	s := &WorkersKV{
+		apiURL: defaultAPIURL,
+		logger: logger,
+	}
+	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
+
+	return s
+}
+
+// Init performs metadata parsing and creates the client of the R2 bucket, if values overflow to R2.
+func (s *WorkersKV) Init(meta state.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	s.metadata = m
+	s.keyCodec, err = state.NewKeyCodec(meta)
+	if err != nil {
+		return err
+	}
+	s.client = &http.Client{Timeout: m.Timeout}
+
+	if m.R2Bucket != "" {
+		s.overflow, err = cloudflare.NewR2(m.R2Endpoint, m.R2Bucket, m.R2AccessKeyID, m.R2SecretAccessKey)
+		if err != nil {
+			return fmt.Errorf("cloudflare workers kv error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func parseMetadata(meta state.Metadata) (workersKVMetadata, error) {
+	m := workersKVMetadata{
+		Timeout:           defaultTimeout,
+		OverflowThreshold: maxValueSize,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.AccountID == "" || m.NamespaceID == "" || m.APIToken == "" {
+		return m, errors.New("cloudflare workers kv error: accountID, namespaceID and apiToken are required")
+	}
+	if m.Timeout <= 0 {
+		return m, errors.New("cloudflare workers kv error: timeout must be greater than 0")
+	}
+	if m.OverflowThreshold <= 0 || m.OverflowThreshold > maxValueSize {
+		return m, fmt.Errorf("cloudflare workers kv error: overflowThreshold must be between 1 and %d bytes", maxValueSize)
+	}
+	if m.R2Bucket != "" {
+		if m.R2AccessKeyID == "" || m.R2SecretAccessKey == "" {
+			return m, errors.New("cloudflare workers kv error: r2AccessKeyID and r2SecretAccessKey are required with r2Bucket")
+		}
+		if m.R2Endpoint == "" {
+			m.R2Endpoint = cloudflare.R2Endpoint(m.AccountID)
+		}
+	}
+
+	return m, nil
+}
+
+// Features returns the features available in this state store.
+func (s *WorkersKV) Features() []state.Feature {
+	return nil
+}
+
+// Get returns the value of a key, reading it from R2 if it overflowed.
+func (s *WorkersKV) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.metadata.Timeout)
+	defer cancel()
+
+	key := s.keyCodec.Encode(req.Key)
+	data, err := s.getValue(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return &state.GetResponse{}, nil
+	}
+
+	if bytes.HasPrefix(data, []byte(overflowPointerPrefix)) {
+		if s.overflow == nil {
+			return nil, fmt.Errorf("value of key %s is stored in R2, but r2Bucket isn't set", req.Key)
+		}
+		data, err = s.overflow.Get(ctx, string(data[len(overflowPointerPrefix):]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the value of key %s from R2: %w", req.Key, err)
+		}
+	}
+
+	return &state.GetResponse{
+		Data: data,
+	}, nil
+}
+
+// Set stores a value in KV, or in R2 with a pointer in KV if it's bigger than the overflow threshold.
+func (s *WorkersKV) Set(req *state.SetRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+	ttl, err := parseTTL(req.Metadata)
+	if err != nil {
+		return err
+	}
+	data, err := utils.Marshal(req.Value, json.Marshal)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.metadata.Timeout)
+	defer cancel()
+
+	key := s.keyCodec.Encode(req.Key)
+	if s.overflow == nil {
+		if len(data) > maxValueSize {
+			return fmt.Errorf("value of key %s is bigger than the %d bytes limit of KV; set r2Bucket to store it in R2", req.Key, maxValueSize)
+		}
+		return s.putValue(ctx, key, data, ttl)
+	}
+
+	// Values that look like a pointer are stored in R2 too, so they're never mistaken for one
+	if len(data) > s.metadata.OverflowThreshold || bytes.HasPrefix(data, []byte(overflowPointerPrefix)) {
+		// The object is written first, so the pointer never references a missing object
+		_, err = s.overflow.Put(ctx, key, data)
+		if err != nil {
+			return fmt.Errorf("failed to write the value of key %s to R2: %w", req.Key, err)
+		}
+		return s.putValue(ctx, key, []byte(overflowPointerPrefix+key), ttl)
+	}
+
+	err = s.putValue(ctx, key, data, ttl)
+	if err != nil {
+		return err
+	}
+	// Delete the object of a previous value that overflowed
+	err = s.overflow.Delete(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete the previous value of key %s from R2: %w", req.Key, err)
+	}
+	return nil
+}
+
+// Delete deletes a key from KV, and its value from R2 if it overflowed.
+func (s *WorkersKV) Delete(req *state.DeleteRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.metadata.Timeout)
+	defer cancel()
+
+	key := s.keyCodec.Encode(req.Key)
+	_, err = s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", req.Key, err)
+	}
+	if s.overflow != nil {
+		err = s.overflow.Delete(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to delete the value of key %s from R2: %w", req.Key, err)
+		}
+	}
+	return nil
+}
+
+// getValue returns the value of a key in KV, or nil if the key doesn't exist.
+func (s *WorkersKV) getValue(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if errors.Is(err, errKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+func (s *WorkersKV) putValue(ctx context.Context, key string, data []byte, ttl int) error {
+	var query url.Values
+	if ttl > 0 {
+		query = url.Values{"expiration_ttl": []string{strconv.Itoa(ttl)}}
+	}
+	_, err := s.do(ctx, http.MethodPut, key, query, data)
+	if err != nil {
+		return fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return nil
+}
+
+// do sends a request for a key to the REST API and returns the body of the response of a read.
+func (s *WorkersKV) do(ctx context.Context, method string, key string, query url.Values, body []byte) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/accounts/%s/storage/kv/namespaces/%s/values/%s",
+		s.apiURL, url.PathEscape(s.metadata.AccountID), url.PathEscape(s.metadata.NamespaceID), url.PathEscape(key))
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+s.metadata.APIToken)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	httpRes, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+	resBody, err := io.ReadAll(io.LimitReader(httpRes.Body, maxValueSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if httpRes.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return nil, errKeyNotFound
+	}
+	if httpRes.StatusCode >= http.StatusBadRequest {
+		return nil, parseAPIError(httpRes.StatusCode, resBody)
+	}
+	if method == http.MethodGet {
+		// Values are returned as they are, rather than in the envelope of the API
+		return resBody, nil
+	}
+	return nil, nil
+}
+
+func parseAPIError(status int, body []byte) error {
+	var res apiResponse
+	err := json.Unmarshal(body, &res)
+	if err != nil || len(res.Errors) == 0 {
+		if len(body) > maxErrorBodySize {
+			body = append(body[:maxErrorBodySize:maxErrorBodySize], "..."...)
+		}
+		return fmt.Errorf("request failed with status %d: %s", status, body)
+	}
+
+	msgs := make([]string, len(res.Errors))
+	for i, e := range res.Errors {
+		msgs[i] = fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+	}
+	return fmt.Errorf("request failed with status %d: %s", status, strings.Join(msgs, "; "))
+}
+
+// parseTTL returns the TTL of the request in seconds, or 0 if the key doesn't expire.
+func parseTTL(md map[string]string) (int, error) {
+	ttl, ok, err := metadata.TryGetTTL(md)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, nil
+	}
+	if ttl < minTTL*time.Second {
+		return 0, fmt.Errorf("%s must be at least %d seconds, the minimum TTL of KV", metadata.TTLMetadataKey, minTTL)
+	}
+	return int(ttl.Seconds()), nil
+}
+
+func (s *WorkersKV) GetComponentMetadata() map[string]string {
+	metadataStruct := workersKVMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+func (s *WorkersKV) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(s.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileToken},
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workerskv
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/internal/component/cloudflare"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accountID":   "account",
+			"namespaceID": "namespace",
+			"apiToken":    "token",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, maxValueSize, m.OverflowThreshold)
+		assert.Empty(t, m.R2Endpoint)
+	})
+
+	t.Run("overflow to R2", func(t *testing.T) {
+		m, err := parseMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accountID":         "account",
+			"namespaceID":       "namespace",
+			"apiToken":          "token",
+			"r2Bucket":          "bucket",
+			"r2AccessKeyID":     "key",
+			"r2SecretAccessKey": "secret",
+			"overflowThreshold": "1048576",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, 1<<20, m.OverflowThreshold)
+		assert.Equal(t, "https://account.r2.cloudflarestorage.com", m.R2Endpoint)
+	})
+
+	invalid := map[string]map[string]string{
+		"missing token":         {"accountID": "account", "namespaceID": "namespace"},
+		"missing R2 secret":     {"accountID": "account", "namespaceID": "namespace", "apiToken": "token", "r2Bucket": "bucket", "r2AccessKeyID": "key"},
+		"threshold above limit": {"accountID": "account", "namespaceID": "namespace", "apiToken": "token", "overflowThreshold": "26214401"},
+		"invalid timeout":       {"accountID": "account", "namespaceID": "namespace", "apiToken": "token", "timeout": "0s"},
+	}
+	for name, props := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWorkersKV(t *testing.T) {
+	kv := newFakeKV(t)
+	srv := httptest.NewServer(kv)
+	defer srv.Close()
+
+	s := NewWorkersKV(logger.NewLogger("test")).(*WorkersKV)
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"accountID":   "account",
+		"namespaceID": "namespace",
+		"apiToken":    "token",
+	}}})
+	require.NoError(t, err)
+	s.apiURL = srv.URL
+
+	t.Run("set and get", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "app||k1", Value: map[string]string{"a": "b"}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"b"}`, kv.value("app||k1"))
+
+		res, err := s.Get(&state.GetRequest{Key: "app||k1"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"b"}`, string(res.Data))
+
+		res, err = s.Get(&state.GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "k2", Value: []byte("v"), Metadata: map[string]string{"ttlInSeconds": "120"}})
+		require.NoError(t, err)
+		assert.Equal(t, "120", kv.ttl("k2"))
+
+		err = s.Set(&state.SetRequest{Key: "k2", Value: []byte("v"), Metadata: map[string]string{"ttlInSeconds": "10"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := s.Delete(&state.DeleteRequest{Key: "app||k1"})
+		require.NoError(t, err)
+		_, ok := kv.values["app||k1"]
+		assert.False(t, ok)
+	})
+
+	t.Run("value too large without R2", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "big", Value: make([]byte, maxValueSize+1)})
+		assert.ErrorContains(t, err, "r2Bucket")
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		s := NewWorkersKV(logger.NewLogger("test")).(*WorkersKV)
+		require.NoError(t, s.Init(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accountID":   "account",
+			"namespaceID": "namespace",
+			"apiToken":    "wrong",
+		}}}))
+		s.apiURL = srv.URL
+
+		_, err := s.Get(&state.GetRequest{Key: "k2"})
+		assert.ErrorContains(t, err, "Authentication error (code 10000)")
+	})
+}
+
+func TestOverflow(t *testing.T) {
+	kv := newFakeKV(t)
+	srv := httptest.NewServer(kv)
+	defer srv.Close()
+
+	s := NewWorkersKV(logger.NewLogger("test")).(*WorkersKV)
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"accountID":         "account",
+		"namespaceID":       "namespace",
+		"apiToken":          "token",
+		"overflowThreshold": "10",
+	}}})
+	require.NoError(t, err)
+	s.apiURL = srv.URL
+	bucket := &fakeBucket{objects: map[string][]byte{}}
+	s.overflow = bucket
+
+	t.Run("small value is stored in KV", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "k1", Value: []byte("small")})
+		require.NoError(t, err)
+		assert.Equal(t, "small", kv.value("k1"))
+		assert.Empty(t, bucket.objects)
+	})
+
+	t.Run("large value is stored in R2", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "k1", Value: []byte("a large value")})
+		require.NoError(t, err)
+		assert.Equal(t, overflowPointerPrefix+"k1", kv.value("k1"))
+		assert.Equal(t, "a large value", string(bucket.objects["k1"]))
+
+		res, err := s.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Equal(t, "a large value", string(res.Data))
+	})
+
+	t.Run("overwriting with a small value deletes the object", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "k1", Value: []byte("small")})
+		require.NoError(t, err)
+		assert.Equal(t, "small", kv.value("k1"))
+		assert.Empty(t, bucket.objects)
+	})
+
+	t.Run("value that looks like a pointer is stored in R2", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "k2", Value: []byte(overflowPointerPrefix)})
+		require.NoError(t, err)
+		assert.Equal(t, overflowPointerPrefix+"k2", kv.value("k2"))
+
+		res, err := s.Get(&state.GetRequest{Key: "k2"})
+		require.NoError(t, err)
+		assert.Equal(t, overflowPointerPrefix, string(res.Data))
+	})
+
+	t.Run("delete removes the object", func(t *testing.T) {
+		err := s.Delete(&state.DeleteRequest{Key: "k2"})
+		require.NoError(t, err)
+		_, ok := kv.values["k2"]
+		assert.False(t, ok)
+		assert.Empty(t, bucket.objects)
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		kv.values["k3"] = overflowPointerPrefix + "k3"
+		_, err := s.Get(&state.GetRequest{Key: "k3"})
+		assert.ErrorIs(t, err, cloudflare.ErrR2ObjectNotFound)
+	})
+
+	t.Run("pointer without R2", func(t *testing.T) {
+		s.overflow = nil
+		defer func() { s.overflow = bucket }()
+
+		_, err := s.Get(&state.GetRequest{Key: "k3"})
+		assert.ErrorContains(t, err, "r2Bucket")
+	})
+}
+
+// fakeKV serves the requests of the REST API of Workers KV used by the store.
+type fakeKV struct {
+	t      *testing.T
+	lock   sync.Mutex
+	values map[string]string
+	ttls   map[string]string
+}
+
+func newFakeKV(t *testing.T) *fakeKV {
+	return &fakeKV{t: t, values: map[string]string{}, ttls: map[string]string{}}
+}
+
+func (kv *fakeKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"success":false,"errors":[{"code":10000,"message":"Authentication error"}]}`))
+		return
+	}
+	const prefix = "/accounts/account/storage/kv/namespaces/namespace/values/"
+	require.True(kv.t, strings.HasPrefix(r.URL.Path, prefix), r.URL.Path)
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		require.NoError(kv.t, err)
+		kv.values[key] = string(data)
+		kv.ttls[key] = r.URL.Query().Get("expiration_ttl")
+	case http.MethodGet:
+		v, ok := kv.values[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"code":10009,"message":"get: 'key not found'"}]}`))
+			return
+		}
+		w.Write([]byte(v))
+		return
+	case http.MethodDelete:
+		delete(kv.values, key)
+	}
+	w.Write([]byte(`{"success":true,"errors":[],"result":null}`))
+}
+
+func (kv *fakeKV) value(key string) string {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+	return kv.values[key]
+}
+
+func (kv *fakeKV) ttl(key string) string {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+	return kv.ttls[key]
+}
+
+type fakeBucket struct {
+	objects map[string][]byte
+}
+
+func (b *fakeBucket) Put(_ context.Context, key string, data []byte) (string, error) {
+	b.objects[key] = data
+	return time.Now().String(), nil
+}
+
+func (b *fakeBucket) Get(_ context.Context, key string) ([]byte, error) {
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, cloudflare.ErrR2ObjectNotFound
+	}
+	return data, nil
+}
+
+func (b *fakeBucket) Delete(_ context.Context, key string) error {
+	delete(b.objects, key)
+	return nil
+}
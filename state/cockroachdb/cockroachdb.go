@@ -15,12 +15,16 @@ package cockroachdb
 
 import (
 	"reflect"
+	"strconv"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
+// Key of the version of the schema of the state table in the metadata of the component.
+const schemaVersionKey = "schemaVersion"
+
 // CockroachDB state store.
 type CockroachDB struct {
 	features []state.Feature
@@ -110,9 +114,21 @@ func (c *CockroachDB) Close() error {
 	return nil
 }
 
+// GetComponentMetadata returns the metadata of the component, and the version of the schema of the state table once
+// initialized.
 func (c *CockroachDB) GetComponentMetadata() map[string]string {
 	metadataStruct := cockroachDBMetadata{}
 	metadataInfo := map[string]string{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	if version := c.dbaccess.SchemaVersion(); version > 0 {
+		metadataInfo[schemaVersionKey] = strconv.Itoa(version)
+	}
 	return metadataInfo
 }
+
+func (c *CockroachDB) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(c.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString},
+	}
+}
@@ -14,6 +14,7 @@ limitations under the License.
 package cockroachdb
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/base64"
@@ -23,6 +24,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/dapr/components-contrib/internal/component/postgresql/migrations"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
@@ -48,11 +50,14 @@ type cockroachDBAccess struct {
 	metadata         cockroachDBMetadata
 	db               *sql.DB
 	connectionString string
+	schemaVersion    int
+	keyCodec         *state.KeyCodec
 }
 
 type cockroachDBMetadata struct {
 	ConnectionString      string
 	TableName             string
+	MetadataTableName     string
 	MaxConnectionAttempts *int
 }
 
@@ -69,12 +74,17 @@ func newCockroachDBAccess(logger logger.Logger) *cockroachDBAccess {
 }
 
 func parseMetadata(meta state.Metadata) (*cockroachDBMetadata, error) {
-	m := cockroachDBMetadata{}
+	m := cockroachDBMetadata{
+		MetadataTableName: migrations.DefaultMetadataTableName,
+	}
 	metadata.DecodeMetadata(meta.Properties, &m)
 
 	if m.ConnectionString == "" {
 		return nil, errors.New(errMissingConnectionString)
 	}
+	if !migrations.ValidTableName(m.MetadataTableName) {
+		return nil, fmt.Errorf("invalid metadataTableName: %s", m.MetadataTableName)
+	}
 
 	return &m, nil
 }
@@ -88,6 +98,9 @@ func (p *cockroachDBAccess) Init(metadata state.Metadata) error {
 		return err
 	}
 	p.metadata = *meta
+	if p.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	if p.metadata.ConnectionString == "" {
 		p.logger.Error("Missing CockroachDB connection string")
@@ -110,7 +123,7 @@ func (p *cockroachDBAccess) Init(metadata state.Metadata) error {
 		return err
 	}
 
-	if err = p.ensureStateTable(tableName); err != nil {
+	if err = p.migrateSchema(tableName); err != nil {
 		return err
 	}
 
@@ -131,6 +144,7 @@ func (p *cockroachDBAccess) Set(req *state.SetRequest) error {
 	if err != nil {
 		return err
 	}
+	req = p.keyCodec.EncodeSet(req)
 
 	var result sql.Result
 
@@ -203,6 +217,7 @@ func (p *cockroachDBAccess) Get(req *state.GetRequest) (*state.GetResponse, erro
 	if req.Key == "" {
 		return nil, fmt.Errorf("missing key in get operation")
 	}
+	req = p.keyCodec.EncodeGet(req)
 
 	var value string
 	var isBinary bool
@@ -252,6 +267,7 @@ func (p *cockroachDBAccess) Delete(req *state.DeleteRequest) error {
 	if req.Key == "" {
 		return fmt.Errorf("missing key in delete operation")
 	}
+	req = p.keyCodec.EncodeDelete(req)
 
 	var result sql.Result
 	var err error
@@ -365,10 +381,11 @@ func (p *cockroachDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse
 	p.logger.Debug("Getting query value from CockroachDB")
 
 	stateQuery := &Query{
-		query:  "",
-		params: []interface{}{},
-		limit:  0,
-		skip:   ptr.Of[int64](0),
+		query:      "",
+		params:     []interface{}{},
+		limit:      0,
+		skip:       ptr.Of[int64](0),
+		keyPattern: p.keyCodec.Pattern(),
 	}
 	qbuilder := query.NewQueryBuilder(stateQuery)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
@@ -391,7 +408,7 @@ func (p *cockroachDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse
 	}
 
 	return &state.QueryResponse{
-		Results:  data,
+		Results:  p.keyCodec.DecodeItems(data),
 		Token:    token,
 		Metadata: map[string]string{},
 	}, nil
@@ -431,30 +448,47 @@ func (p *cockroachDBAccess) Close() error {
 	return nil
 }
 
-func (p *cockroachDBAccess) ensureStateTable(stateTableName string) error {
-	exists, err := tableExists(p.db, stateTableName)
-	if err != nil {
-		return err
-	}
+// SchemaVersion returns the version of the schema of the state table, once migrated by Init.
+func (p *cockroachDBAccess) SchemaVersion() int {
+	return p.schemaVersion
+}
 
-	if !exists {
-		p.logger.Info("Creating CockroachDB state table")
-		createTable := fmt.Sprintf(`CREATE TABLE %s (
+// migrateSchema creates or upgrades the state table.
+// Tables created before the schema was versioned are at version 0, and are upgraded like new tables.
+func (p *cockroachDBAccess) migrateSchema(stateTableName string) error {
+	m := migrations.Migrations{
+		DB:                p.db,
+		Logger:            p.logger,
+		MetadataTableName: p.metadata.MetadataTableName,
+		MetadataKey:       migrationsKey(stateTableName),
+	}
+	version, err := m.Perform(context.Background(), []migrations.MigrationFn{
+		// Migration 1: create the state table
+		func(ctx context.Context, tx *sql.Tx) error {
+			p.logger.Info("Creating CockroachDB state table")
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
 									key text NOT NULL PRIMARY KEY,
 									value jsonb NOT NULL,
 									isbinary boolean NOT NULL,
 									etag INT,
 									insertdate TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName)
-		_, err = p.db.Exec(createTable)
-		if err != nil {
+									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName))
 			return err
-		}
+		},
+	})
+	if err != nil {
+		return err
 	}
+	p.schemaVersion = version
 
 	return nil
 }
 
+// migrationsKey returns the key of the schema version of a state table in the metadata table.
+func migrationsKey(stateTableName string) string {
+	return "migrations-" + stateTableName
+}
+
 func tableExists(db *sql.DB, tableName string) (bool, error) {
 	exists := false
 	err := db.QueryRow("SELECT EXISTS (SELECT * FROM pg_tables where tablename = $1)", tableName).Scan(&exists)
@@ -441,6 +441,30 @@ func createDeleteRequest() state.DeleteRequest {
 	}
 }
 
+func TestMigrateSchema(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.roachDba.metadata.MetadataTableName = "dapr_metadata"
+
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("INSERT INTO dapr_metadata").WithArgs("migrations-state").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value FROM dapr_metadata").WithArgs("migrations-state").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("0"))
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS state").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("UPDATE dapr_metadata").WithArgs("1", "migrations-state").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectCommit()
+
+	// Act
+	err := m.roachDba.migrateSchema("state")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, m.roachDba.SchemaVersion())
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
 func mockDatabase(t *testing.T) (*mocks, error) {
 	logger := logger.NewLogger("test")
 
@@ -30,6 +30,8 @@ type Query struct {
 	params []interface{}
 	limit  int
 	skip   *int64
+	// Regular expression the keys are matched with by the ~ operator, from the keyTemplate metadata
+	keyPattern string
 }
 
 func (q *Query) VisitEQ(filter *query.EQ) (string, error) {
@@ -97,6 +99,14 @@ func (q *Query) VisitOR(filter *query.OR) (string, error) {
 func (q *Query) Finalize(filters string, storeQuery *query.Query) error {
 	q.query = fmt.Sprintf("SELECT key, value, etag FROM %s", tableName)
 
+	if q.keyPattern != "" {
+		keyFilter := fmt.Sprintf("key ~ $%d", q.addParamValueAndReturnPosition(q.keyPattern))
+		if filters != "" {
+			filters = keyFilter + " AND " + filters
+		} else {
+			filters = keyFilter
+		}
+	}
 	if filters != "" {
 		q.query += fmt.Sprintf(" WHERE %s", filters)
 	}
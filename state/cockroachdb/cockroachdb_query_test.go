@@ -75,3 +75,18 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 		assert.Equal(t, test.query, stateQuery.query)
 	}
 }
+
+func TestCockroachDBQueryKeyPattern(t *testing.T) {
+	t.Parallel()
+
+	stateQuery := &Query{
+		skip:       ptr.Of[int64](0),
+		keyPattern: "^statestore/.*$",
+	}
+	err := query.NewQueryBuilder(stateQuery).BuildQuery(&query.Query{
+		Filter: &query.EQ{Key: "state", Val: "CA"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT key, value, etag FROM state WHERE key ~ $2 AND value->>'state'=$1", stateQuery.query)
+	assert.Equal(t, []interface{}{"CA", "^statestore/.*$"}, stateQuery.params)
+}
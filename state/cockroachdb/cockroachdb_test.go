@@ -76,6 +76,10 @@ func (m *fakeDBaccess) Query(req *state.QueryRequest) (*state.QueryResponse, err
 	return nil, nil
 }
 
+func (m *fakeDBaccess) SchemaVersion() int {
+	return 1
+}
+
 func (m *fakeDBaccess) Close() error {
 	return nil
 }
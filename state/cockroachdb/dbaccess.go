@@ -25,6 +25,7 @@ type dbAccess interface {
 	BulkDelete(req []state.DeleteRequest) error
 	ExecuteMulti(req *state.TransactionalStateRequest) error
 	Query(req *state.QueryRequest) (*state.QueryResponse, error)
+	SchemaVersion() int
 	Ping() error
 	Close() error
 }
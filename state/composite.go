@@ -0,0 +1,31 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import "github.com/dapr/components-contrib/internal/component"
+
+// StoreResolver returns the initialized state store with the given component name.
+type StoreResolver func(name string) (Store, error)
+
+// CompositeStore is implemented by state stores built on top of other state stores,
+// which are referenced by component name in their metadata.
+// The runtime calls SetStoreResolver before Init, and the store resolves the components it references in Init.
+type CompositeStore interface {
+	SetStoreResolver(resolver StoreResolver)
+}
+
+// NewMapStoreResolver returns a StoreResolver for a fixed set of stores, keyed by component name.
+func NewMapStoreResolver(stores map[string]Store) StoreResolver {
+	return component.NewMapResolver("state store", stores)
+}
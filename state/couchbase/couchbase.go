@@ -47,6 +47,7 @@ type Couchbase struct {
 	numReplicasDurableReplication uint
 	numReplicasDurablePersistence uint
 	json                          jsoniter.API
+	keyCodec                      *state.KeyCodec
 
 	features []state.Feature
 	logger   logger.Logger
@@ -123,6 +124,9 @@ func (cbs *Couchbase) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if cbs.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 	cbs.bucketName = meta.BucketName
 	c, err := gocb.Connect(meta.CouchbaseURL)
 	if err != nil {
@@ -179,16 +183,16 @@ func (cbs *Couchbase) Set(req *state.SetRequest) error {
 			return err
 		}
 		if req.Options.Consistency == state.Strong {
-			_, err = cbs.bucket.ReplaceDura(req.Key, value, cas, 0, cbs.numReplicasDurableReplication, cbs.numReplicasDurablePersistence)
+			_, err = cbs.bucket.ReplaceDura(cbs.keyCodec.Encode(req.Key), value, cas, 0, cbs.numReplicasDurableReplication, cbs.numReplicasDurablePersistence)
 		} else {
-			_, err = cbs.bucket.Replace(req.Key, value, cas, 0)
+			_, err = cbs.bucket.Replace(cbs.keyCodec.Encode(req.Key), value, cas, 0)
 		}
 	} else {
 		// key does not exist: replace or insert (with Upsert)
 		if req.Options.Consistency == state.Strong {
-			_, err = cbs.bucket.UpsertDura(req.Key, value, 0, cbs.numReplicasDurableReplication, cbs.numReplicasDurablePersistence)
+			_, err = cbs.bucket.UpsertDura(cbs.keyCodec.Encode(req.Key), value, 0, cbs.numReplicasDurableReplication, cbs.numReplicasDurablePersistence)
 		} else {
-			_, err = cbs.bucket.Upsert(req.Key, value, 0)
+			_, err = cbs.bucket.Upsert(cbs.keyCodec.Encode(req.Key), value, 0)
 		}
 	}
 
@@ -206,7 +210,7 @@ func (cbs *Couchbase) Set(req *state.SetRequest) error {
 // Get retrieves state from couchbase with a key.
 func (cbs *Couchbase) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	var data interface{}
-	cas, err := cbs.bucket.Get(req.Key, &data)
+	cas, err := cbs.bucket.Get(cbs.keyCodec.Encode(req.Key), &data)
 	if err != nil {
 		if gocb.IsKeyNotFoundError(err) {
 			return &state.GetResponse{}, nil
@@ -237,9 +241,9 @@ func (cbs *Couchbase) Delete(req *state.DeleteRequest) error {
 		}
 	}
 	if req.Options.Consistency == state.Strong {
-		_, err = cbs.bucket.RemoveDura(req.Key, cas, cbs.numReplicasDurableReplication, cbs.numReplicasDurablePersistence)
+		_, err = cbs.bucket.RemoveDura(cbs.keyCodec.Encode(req.Key), cas, cbs.numReplicasDurableReplication, cbs.numReplicasDurablePersistence)
 	} else {
-		_, err = cbs.bucket.Remove(req.Key, cas)
+		_, err = cbs.bucket.Remove(cbs.keyCodec.Encode(req.Key), cas)
 	}
 	if err != nil {
 		if req.ETag != nil {
@@ -271,3 +275,10 @@ func (cbs *Couchbase) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (cbs *Couchbase) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(cbs.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfilePassword},
+	}
+}
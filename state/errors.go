@@ -80,3 +80,31 @@ func NewBulkDeleteRowMismatchError(expected, affected uint64) *BulkDeleteRowMism
 		affected: affected,
 	}
 }
+
+// BulkStoreError is the error of a key of a bulk operation.
+type BulkStoreError struct {
+	key string
+	err error
+}
+
+// NewBulkStoreError returns a BulkStoreError wrapping the error of a key.
+func NewBulkStoreError(key string, err error) *BulkStoreError {
+	return &BulkStoreError{
+		key: key,
+		err: err,
+	}
+}
+
+// Key returns the key of the failed operation.
+func (e *BulkStoreError) Key() string {
+	return e.key
+}
+
+func (e *BulkStoreError) Error() string {
+	return fmt.Sprintf("failed operation on key %s: %s", e.key, e.err)
+}
+
+// Unwrap returns the error of the key, so that it can be checked with errors.Is and errors.As.
+func (e *BulkStoreError) Unwrap() error {
+	return e.err
+}
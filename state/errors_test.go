@@ -53,3 +53,12 @@ func TestETagError(t *testing.T) {
 		assert.IsType(t, ETagMismatch, err.kind)
 	})
 }
+
+func TestBulkStoreError(t *testing.T) {
+	err := NewBulkStoreError("key1", NewETagError(ETagMismatch, nil))
+
+	assert.Equal(t, "key1", err.Key())
+	assert.Equal(t, "failed operation on key key1: "+mismatchPrefix, err.Error())
+	var etagErr *ETagError
+	assert.ErrorAs(t, err, &etagErr)
+}
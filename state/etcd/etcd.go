@@ -0,0 +1,510 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	defaultDialTimeout      = 5 * time.Second
+	defaultOperationTimeout = 10 * time.Second
+
+	// A TTL of -1 means that the value never expires.
+	noTTL = -1
+)
+
+var (
+	errMissingEndpoints = errors.New("etcd state store: endpoints are required")
+	errMissingTLSPair   = errors.New("etcd state store: cert and key must be set together")
+)
+
+type etcdMetadata struct {
+	// Comma-separated list of the endpoints of the cluster.
+	Endpoints string `mapstructure:"endpoints"`
+	// Prefix of the keys, when the cluster is shared with other applications.
+	KeyPrefixPath string        `mapstructure:"keyPrefixPath"`
+	Username      string        `mapstructure:"username"`
+	Password      string        `mapstructure:"password"`
+	DialTimeout   time.Duration `mapstructure:"dialTimeout"`
+	TLSEnable     bool          `mapstructure:"tlsEnable"`
+	// PEM-encoded certificate of the CA of the cluster; the system roots are used if it's empty.
+	CA string `mapstructure:"ca"`
+	// PEM-encoded client certificate and key, for clusters that authenticate clients with certificates.
+	Cert string `mapstructure:"cert"`
+	Key  string `mapstructure:"key"`
+}
+
+// Etcd is a state store implementation for etcd.
+// ETags are the revisions of the last modification of the keys, and TTLs are implemented with leases.
+type Etcd struct {
+	state.DefaultBulkStore
+	client           *clientv3.Client
+	keyPrefixPath    string
+	keyCodec         *state.KeyCodec
+	operationTimeout time.Duration
+	features         []state.Feature
+
+	wg     sync.WaitGroup
+	logger logger.Logger
+}
+
+// ChangeHandler is called with the keys that are set or deleted, including by other clients and when their TTL expires.
+type ChangeHandler func(key string, deleted bool)
+
+var _ state.TransactionalStore = (*Etcd)(nil)
+
+// NewEtcdStateStore returns a new etcd state store.
+func NewEtcdStateStore(logger logger.Logger) state.Store {
+	s := &Etcd{
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional},
+		logger:   logger,
+	}
+	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
+
+	return s
+}
+
+// Init parses the metadata and connects to the cluster.
+func (e *Etcd) Init(meta state.Metadata) error {
+	m, err := getEtcdMetadata(meta)
+	if err != nil {
+		return err
+	}
+	e.operationTimeout, err = metadata.GetOperationTimeout(meta.Properties, defaultOperationTimeout)
+	if err != nil {
+		return fmt.Errorf("etcd state store: %w", err)
+	}
+
+	config, err := clientConfig(m)
+	if err != nil {
+		return err
+	}
+	e.client, err = clientv3.New(config)
+	if err != nil {
+		return fmt.Errorf("etcd state store: error creating client: %w", err)
+	}
+	e.keyPrefixPath = m.KeyPrefixPath
+	e.keyCodec, err = state.NewKeyCodec(meta)
+	if err != nil {
+		e.client.Close()
+		return fmt.Errorf("etcd state store: %w", err)
+	}
+
+	// The client connects lazily, so a read checks that the cluster is reachable with the credentials
+	ctx, cancel := context.WithTimeout(context.Background(), e.operationTimeout)
+	defer cancel()
+	_, err = e.client.Get(ctx, e.prefixedKey(""), clientv3.WithCountOnly())
+	if err != nil {
+		e.client.Close()
+		return fmt.Errorf("etcd state store: error connecting to %s: %w", m.Endpoints, err)
+	}
+
+	return nil
+}
+
+func getEtcdMetadata(meta state.Metadata) (*etcdMetadata, error) {
+	m := etcdMetadata{
+		DialTimeout: defaultDialTimeout,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(m.Endpoints) == "" {
+		return nil, errMissingEndpoints
+	}
+	if (m.Cert == "") != (m.Key == "") {
+		return nil, errMissingTLSPair
+	}
+	if m.DialTimeout <= 0 {
+		return nil, errors.New("etcd state store: dialTimeout must be greater than 0")
+	}
+	m.KeyPrefixPath = strings.TrimSuffix(m.KeyPrefixPath, "/")
+
+	return &m, nil
+}
+
+func clientConfig(m *etcdMetadata) (clientv3.Config, error) {
+	config := clientv3.Config{
+		DialTimeout: m.DialTimeout,
+		Username:    m.Username,
+		Password:    m.Password,
+	}
+	for _, endpoint := range strings.Split(m.Endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			config.Endpoints = append(config.Endpoints, endpoint)
+		}
+	}
+
+	if m.TLSEnable || m.CA != "" || m.Cert != "" {
+		tlsConfig := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		if m.CA != "" {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(m.CA)) {
+				return config, errors.New("etcd state store: invalid ca")
+			}
+		}
+		if m.Cert != "" {
+			cert, err := tls.X509KeyPair([]byte(m.Cert), []byte(m.Key))
+			if err != nil {
+				return config, fmt.Errorf("etcd state store: invalid cert or key: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		config.TLS = tlsConfig
+	}
+
+	return config, nil
+}
+
+// Features returns the features available in this state store.
+func (e *Etcd) Features() []state.Feature {
+	return e.features
+}
+
+// Get retrieves state from etcd with a key.
+// Reads with eventual consistency are served by any member of the cluster, without going through the leader.
+func (e *Etcd) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []clientv3.OpOption
+	if req.Options.Consistency == state.Eventual {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.operationTimeout)
+	defer cancel()
+	res, err := e.client.Get(ctx, e.prefixedKey(e.keyCodec.Encode(req.Key)), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Kvs) == 0 {
+		return &state.GetResponse{}, nil
+	}
+
+	return &state.GetResponse{
+		Data: res.Kvs[0].Value,
+		ETag: ptr.Of(formatETag(res.Kvs[0].ModRevision)),
+	}, nil
+}
+
+// Set saves state into etcd.
+func (e *Etcd) Set(req *state.SetRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.operationTimeout)
+	defer cancel()
+
+	t := &txn{}
+	err := e.addSet(ctx, t, req)
+	if err != nil {
+		return err
+	}
+
+	return e.commit(ctx, t)
+}
+
+// Delete performs a delete operation.
+func (e *Etcd) Delete(req *state.DeleteRequest) error {
+	t := &txn{}
+	err := e.addDelete(t, req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.operationTimeout)
+	defer cancel()
+
+	return e.commit(ctx, t)
+}
+
+// Multi performs the operations of a transaction in a single etcd transaction.
+// The transaction fails without changes if the ETag of any operation doesn't match.
+func (e *Etcd) Multi(request *state.TransactionalStateRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.operationTimeout)
+	defer cancel()
+
+	t := &txn{}
+	for _, o := range request.Operations {
+		var err error
+		switch o.Operation {
+		case state.Upsert:
+			req, ok := o.Request.(state.SetRequest)
+			if !ok {
+				err = fmt.Errorf("expecting set request")
+			} else {
+				err = e.addSet(ctx, t, &req)
+			}
+		case state.Delete:
+			req, ok := o.Request.(state.DeleteRequest)
+			if !ok {
+				err = fmt.Errorf("expecting delete request")
+			} else {
+				err = e.addDelete(t, &req)
+			}
+		default:
+			err = fmt.Errorf("unsupported operation: %s", o.Operation)
+		}
+		if err != nil {
+			e.revokeLeases(t)
+			return err
+		}
+	}
+
+	return e.commit(ctx, t)
+}
+
+// Watch calls handler for the changes of the keys of the store until ctx is done, so that caches of the state can be
+// invalidated. The keys are passed to the handler without the key prefix, and decoded with the keyTemplate metadata.
+func (e *Etcd) Watch(ctx context.Context, handler ChangeHandler) error {
+	if e.client == nil {
+		return errors.New("etcd state store: not initialized")
+	}
+
+	// Without a leader the watch would silently stop receiving events
+	ch := e.client.Watch(clientv3.WithRequireLeader(ctx), e.prefixedKey(""), clientv3.WithPrefix())
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for res := range ch {
+			err := res.Err()
+			if err != nil {
+				if res.CompactRevision != 0 {
+					// The events that happened before are lost
+					e.logger.Warnf("etcd state store: changes of the keys were compacted before they were watched: %v", err)
+				} else {
+					e.logger.Errorf("etcd state store: error watching the keys: %v", err)
+				}
+				continue
+			}
+			for _, ev := range res.Events {
+				key, ok := e.keyCodec.Decode(e.unprefixedKey(string(ev.Kv.Key)))
+				if !ok {
+					continue
+				}
+				handler(key, ev.Type == clientv3.EventTypeDelete)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close closes the connection to the cluster, which stops the watches.
+func (e *Etcd) Close() error {
+	if e.client == nil {
+		return nil
+	}
+	err := e.client.Close()
+	e.wg.Wait()
+
+	return err
+}
+
+func (e *Etcd) GetComponentMetadata() map[string]string {
+	metadataStruct := etcdMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+func (e *Etcd) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(e.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword, metadata.AuthProfileMTLS},
+	}
+}
+
+// txn accumulates the conditions and operations of an etcd transaction, and the leases granted for it.
+type txn struct {
+	cmps   []clientv3.Cmp
+	ops    []clientv3.Op
+	leases []clientv3.LeaseID
+}
+
+func (e *Etcd) addSet(ctx context.Context, t *txn, req *state.SetRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+	key := e.prefixedKey(e.keyCodec.Encode(req.Key))
+	cmp, err := concurrencyCmp(key, req.ETag, req.Options.Concurrency)
+	if err != nil {
+		return err
+	}
+	ttl, err := parseTTL(req.Metadata)
+	if err != nil {
+		return err
+	}
+	data, err := marshalData(req.Value)
+	if err != nil {
+		return err
+	}
+
+	var opts []clientv3.OpOption
+	if ttl != noTTL {
+		// Each key has its own lease, which deletes it when the TTL expires.
+		// Leases of overwritten values expire without deleting anything.
+		lease, err := e.client.Grant(ctx, ttl)
+		if err != nil {
+			return fmt.Errorf("etcd state store: error granting lease for key %s: %w", req.Key, err)
+		}
+		t.leases = append(t.leases, lease.ID)
+		opts = append(opts, clientv3.WithLease(lease.ID))
+	}
+
+	if cmp != nil {
+		t.cmps = append(t.cmps, *cmp)
+	}
+	t.ops = append(t.ops, clientv3.OpPut(key, string(data), opts...))
+
+	return nil
+}
+
+func (e *Etcd) addDelete(t *txn, req *state.DeleteRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+	key := e.prefixedKey(e.keyCodec.Encode(req.Key))
+	cmp, err := concurrencyCmp(key, req.ETag, req.Options.Concurrency)
+	if err != nil {
+		return err
+	}
+
+	if cmp != nil {
+		t.cmps = append(t.cmps, *cmp)
+	}
+	t.ops = append(t.ops, clientv3.OpDelete(key))
+
+	return nil
+}
+
+// commit executes the transaction, failing with an ETag mismatch if any of its conditions isn't met.
+func (e *Etcd) commit(ctx context.Context, t *txn) error {
+	res, err := e.client.Txn(ctx).If(t.cmps...).Then(t.ops...).Commit()
+	if err != nil {
+		e.revokeLeases(t)
+		return err
+	}
+	if !res.Succeeded {
+		e.revokeLeases(t)
+		return state.NewETagError(state.ETagMismatch, nil)
+	}
+
+	return nil
+}
+
+// revokeLeases revokes the leases granted for a transaction that isn't committed, which would otherwise linger until
+// they expire.
+func (e *Etcd) revokeLeases(t *txn) {
+	if len(t.leases) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.operationTimeout)
+	defer cancel()
+	for _, id := range t.leases {
+		_, err := e.client.Revoke(ctx, id)
+		if err != nil {
+			e.logger.Debugf("etcd state store: error revoking lease %x: %v", id, err)
+		}
+	}
+}
+
+func (e *Etcd) prefixedKey(key string) string {
+	if e.keyPrefixPath == "" {
+		return key
+	}
+
+	return e.keyPrefixPath + "/" + key
+}
+
+func (e *Etcd) unprefixedKey(key string) string {
+	if e.keyPrefixPath == "" {
+		return key
+	}
+
+	return strings.TrimPrefix(key, e.keyPrefixPath+"/")
+}
+
+// concurrencyCmp returns the condition of an operation on a key: with an ETag, the key must not have been modified
+// since that revision; with first-write concurrency and no ETag, the key must not exist.
+func concurrencyCmp(key string, etag *string, concurrency string) (*clientv3.Cmp, error) {
+	if etag != nil && *etag != "" {
+		rev, err := strconv.ParseInt(*etag, 10, 64)
+		if err != nil {
+			return nil, state.NewETagError(state.ETagInvalid, err)
+		}
+		cmp := clientv3.Compare(clientv3.ModRevision(key), "=", rev)
+		return &cmp, nil
+	}
+	if concurrency == state.FirstWrite {
+		cmp := clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		return &cmp, nil
+	}
+
+	return nil, nil
+}
+
+func formatETag(rev int64) string {
+	return strconv.FormatInt(rev, 10)
+}
+
+// parseTTL returns the TTL of a request in seconds, or noTTL if it doesn't expire.
+func parseTTL(requestMetadata map[string]string) (int64, error) {
+	val := requestMetadata[metadata.TTLMetadataKey]
+	if val == "" {
+		return noTTL, nil
+	}
+	ttl, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("etcd state store: invalid %s %s: %w", metadata.TTLMetadataKey, val, err)
+	}
+	if ttl <= 0 && ttl != noTTL {
+		return 0, fmt.Errorf("etcd state store: invalid %s %d, must be -1 or greater than 0", metadata.TTLMetadataKey, ttl)
+	}
+
+	return ttl, nil
+}
+
+func marshalData(v interface{}) ([]byte, error) {
+	if buf, ok := v.([]byte); ok {
+		return buf, nil
+	}
+
+	return jsoniter.ConfigFastest.Marshal(v)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestGetEtcdMetadata(t *testing.T) {
+	t.Run("With defaults", func(t *testing.T) {
+		m, err := getEtcdMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:2379", m.Endpoints)
+		assert.Equal(t, defaultDialTimeout, m.DialTimeout)
+		assert.Empty(t, m.KeyPrefixPath)
+		assert.False(t, m.TLSEnable)
+	})
+
+	t.Run("With custom values", func(t *testing.T) {
+		m, err := getEtcdMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints":     "etcd-0:2379, etcd-1:2379",
+			"keyPrefixPath": "dapr/",
+			"username":      "user",
+			"password":      "pass",
+			"dialTimeout":   "2s",
+			"tlsEnable":     "true",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "dapr", m.KeyPrefixPath)
+		assert.Equal(t, 2*time.Second, m.DialTimeout)
+		assert.True(t, m.TLSEnable)
+
+		config, err := clientConfig(m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"etcd-0:2379", "etcd-1:2379"}, config.Endpoints)
+		assert.Equal(t, "user", config.Username)
+		assert.Equal(t, "pass", config.Password)
+		assert.NotNil(t, config.TLS)
+	})
+
+	t.Run("Missing endpoints", func(t *testing.T) {
+		_, err := getEtcdMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{}}})
+		assert.ErrorIs(t, err, errMissingEndpoints)
+	})
+
+	t.Run("Cert without key", func(t *testing.T) {
+		_, err := getEtcdMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"cert":      "cert",
+		}}})
+		assert.ErrorIs(t, err, errMissingTLSPair)
+	})
+
+	t.Run("Invalid CA", func(t *testing.T) {
+		m, err := getEtcdMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"ca":        "not a certificate",
+		}}})
+		require.NoError(t, err)
+		_, err = clientConfig(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestConcurrencyCmp(t *testing.T) {
+	t.Run("With ETag", func(t *testing.T) {
+		cmp, err := concurrencyCmp("key", ptr.Of("42"), state.LastWrite)
+		require.NoError(t, err)
+		assert.Equal(t, clientv3.Compare(clientv3.ModRevision("key"), "=", 42), *cmp)
+	})
+
+	t.Run("With invalid ETag", func(t *testing.T) {
+		_, err := concurrencyCmp("key", ptr.Of("not a revision"), state.FirstWrite)
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagInvalid, etagErr.Kind())
+	})
+
+	t.Run("First write without ETag", func(t *testing.T) {
+		cmp, err := concurrencyCmp("key", ptr.Of(""), state.FirstWrite)
+		require.NoError(t, err)
+		assert.Equal(t, clientv3.Compare(clientv3.CreateRevision("key"), "=", 0), *cmp)
+	})
+
+	t.Run("Last write without ETag", func(t *testing.T) {
+		cmp, err := concurrencyCmp("key", nil, state.LastWrite)
+		require.NoError(t, err)
+		assert.Nil(t, cmp)
+	})
+}
+
+func TestParseTTL(t *testing.T) {
+	ttl, err := parseTTL(map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(noTTL), ttl)
+
+	ttl, err = parseTTL(map[string]string{"ttlInSeconds": "-1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(noTTL), ttl)
+
+	ttl, err = parseTTL(map[string]string{"ttlInSeconds": "60"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), ttl)
+
+	for _, val := range []string{"0", "-2", "not a number"} {
+		_, err = parseTTL(map[string]string{"ttlInSeconds": val})
+		assert.Error(t, err, val)
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	e := &Etcd{}
+	assert.Equal(t, "key", e.prefixedKey("key"))
+	assert.Equal(t, "key", e.unprefixedKey("key"))
+
+	e.keyPrefixPath = "dapr"
+	assert.Equal(t, "dapr/key", e.prefixedKey("key"))
+	assert.Equal(t, "key", e.unprefixedKey("dapr/key"))
+	assert.Equal(t, "dapr/", e.prefixedKey(""))
+}
@@ -13,6 +13,8 @@ limitations under the License.
 
 package state
 
+import "github.com/dapr/components-contrib/metadata"
+
 const (
 	// FeatureETag is the feature to etag metadata in state store.
 	FeatureETag Feature = "ETAG"
@@ -20,6 +22,10 @@ const (
 	FeatureTransactional Feature = "TRANSACTIONAL"
 	// FeatureQueryAPI is the feature that performs query operations.
 	FeatureQueryAPI Feature = "QUERY_API"
+	// FeaturePartialUpdate is the feature that performs partial updates of JSON documents.
+	FeaturePartialUpdate Feature = "PARTIAL_UPDATE"
+	// FeatureKeysList is the feature that lists the keys of the state store.
+	FeatureKeysList Feature = "KEYS_LIST"
 )
 
 // Feature names a feature that can be implemented by PubSub components.
@@ -35,3 +41,24 @@ func (f Feature) IsPresent(features []Feature) bool {
 
 	return false
 }
+
+// FeatureCapabilities returns the capabilities declared in the component metadata for the features.
+func FeatureCapabilities(features []Feature) []metadata.Capability {
+	capabilities := make([]metadata.Capability, 0, len(features))
+	for _, f := range features {
+		switch f {
+		case FeatureETag:
+			capabilities = append(capabilities, metadata.CapabilityETag)
+		case FeatureTransactional:
+			capabilities = append(capabilities, metadata.CapabilityTransactions)
+		case FeatureQueryAPI:
+			capabilities = append(capabilities, metadata.CapabilityQuery)
+		case FeaturePartialUpdate:
+			capabilities = append(capabilities, metadata.CapabilityPartialUpdate)
+		case FeatureKeysList:
+			capabilities = append(capabilities, metadata.CapabilityKeysList)
+		}
+	}
+
+	return capabilities
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"cloud.google.com/go/datastore"
 	jsoniter "github.com/json-iterator/go"
@@ -29,13 +30,19 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
-const defaultEntityKind = "DaprState"
+const (
+	defaultEntityKind = "DaprState"
+	// defaultTimeout is the timeout of the requests without the operationTimeout metadata.
+	defaultTimeout = 20 * time.Second
+)
 
 // Firestore State Store.
 type Firestore struct {
 	state.DefaultBulkStore
 	client     *datastore.Client
 	entityKind string
+	timeout    time.Duration
+	keyCodec   *state.KeyCodec
 
 	logger logger.Logger
 }
@@ -52,6 +59,8 @@ type firestoreMetadata struct {
 	AuthProviderCertURL string `json:"auth_provider_x509_cert_url" mapstructure:"auth_provider_x509_cert_url"`
 	ClientCertURL       string `json:"client_x509_cert_url" mapstructure:"client_x509_cert_url"`
 	EntityKind          string `json:"entity_kind" mapstructure:"entity_kind"`
+
+	OperationTimeout time.Duration `json:"-" mapstructure:"-"`
 }
 
 type StateEntity struct {
@@ -71,6 +80,10 @@ func (f *Firestore) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	f.keyCodec, err = state.NewKeyCodec(metadata)
+	if err != nil {
+		return err
+	}
 	b, err := json.Marshal(meta)
 	if err != nil {
 		return err
@@ -85,6 +98,7 @@ func (f *Firestore) Init(metadata state.Metadata) error {
 
 	f.client = client
 	f.entityKind = meta.EntityKind
+	f.timeout = meta.OperationTimeout
 
 	return nil
 }
@@ -96,11 +110,13 @@ func (f *Firestore) Features() []state.Feature {
 
 // Get retrieves state from Firestore with a key (Always strong consistency).
 func (f *Firestore) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	key := req.Key
+	key := f.keyCodec.Encode(req.Key)
 
 	entityKey := datastore.NameKey(f.entityKind, key, nil)
 	var entity StateEntity
-	err := f.client.Get(context.Background(), entityKey, &entity)
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), f.timeout)
+	defer cancel()
+	err := f.client.Get(ctx, entityKey, &entity)
 
 	if err != nil && !errors.Is(err, datastore.ErrNoSuchEntity) {
 		return nil, err
@@ -131,8 +147,9 @@ func (f *Firestore) Set(req *state.SetRequest) error {
 	entity := &StateEntity{
 		Value: v,
 	}
-	ctx := context.Background()
-	key := datastore.NameKey(f.entityKind, req.Key, nil)
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), f.timeout)
+	defer cancel()
+	key := datastore.NameKey(f.entityKind, f.keyCodec.Encode(req.Key), nil)
 
 	_, err = f.client.Put(ctx, key, entity)
 
@@ -145,8 +162,9 @@ func (f *Firestore) Set(req *state.SetRequest) error {
 
 // Delete performs a delete operation.
 func (f *Firestore) Delete(req *state.DeleteRequest) error {
-	ctx := context.Background()
-	key := datastore.NameKey(f.entityKind, req.Key, nil)
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), f.timeout)
+	defer cancel()
+	key := datastore.NameKey(f.entityKind, f.keyCodec.Encode(req.Key), nil)
 
 	err := f.client.Delete(ctx, key)
 	if err != nil {
@@ -165,6 +183,10 @@ func getFirestoreMetadata(meta state.Metadata) (*firestoreMetadata, error) {
 	if err != nil {
 		return nil, err
 	}
+	m.OperationTimeout, err = metadata.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
 
 	requiredMetaProperties := []string{
 		"type", "project_id", "private_key_id", "private_key", "client_email", "client_id",
@@ -196,3 +218,9 @@ func (f *Firestore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (f *Firestore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileGCPServiceAccount},
+	}
+}
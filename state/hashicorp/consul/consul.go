@@ -32,6 +32,7 @@ type Consul struct {
 	state.DefaultBulkStore
 	client        *api.Client
 	keyPrefixPath string
+	keyCodec      *state.KeyCodec
 	logger        logger.Logger
 }
 
@@ -78,6 +79,10 @@ func (c *Consul) Init(metadata state.Metadata) error {
 
 	c.client = client
 	c.keyPrefixPath = keyPrefixPath
+	c.keyCodec, err = state.NewKeyCodec(metadata)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -101,7 +106,7 @@ func (c *Consul) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		queryOpts.RequireConsistent = true
 	}
 
-	resp, queryMeta, err := c.client.KV().Get(fmt.Sprintf("%s/%s", c.keyPrefixPath, req.Key), queryOpts)
+	resp, queryMeta, err := c.client.KV().Get(fmt.Sprintf("%s/%s", c.keyPrefixPath, c.keyCodec.Encode(req.Key)), queryOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -126,7 +131,7 @@ func (c *Consul) Set(req *state.SetRequest) error {
 		reqValByte, _ = json.Marshal(req.Value)
 	}
 
-	keyWithPath := fmt.Sprintf("%s/%s", c.keyPrefixPath, req.Key)
+	keyWithPath := fmt.Sprintf("%s/%s", c.keyPrefixPath, c.keyCodec.Encode(req.Key))
 
 	_, err := c.client.KV().Put(&api.KVPair{
 		Key:   keyWithPath,
@@ -141,7 +146,7 @@ func (c *Consul) Set(req *state.SetRequest) error {
 
 // Delete performes a Consul KV delete operation.
 func (c *Consul) Delete(req *state.DeleteRequest) error {
-	keyWithPath := fmt.Sprintf("%s/%s", c.keyPrefixPath, req.Key)
+	keyWithPath := fmt.Sprintf("%s/%s", c.keyPrefixPath, c.keyCodec.Encode(req.Key))
 	_, err := c.client.KV().Delete(keyWithPath, nil)
 	if err != nil {
 		return fmt.Errorf("couldn't delete key %s: %s", keyWithPath, err)
@@ -156,3 +161,9 @@ func (c *Consul) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (c *Consul) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileToken},
+	}
+}
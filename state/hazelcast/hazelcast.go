@@ -14,12 +14,17 @@ limitations under the License.
 package hazelcast
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hazelcast/hazelcast-go-client"
-	"github.com/hazelcast/hazelcast-go-client/core"
+	"github.com/hazelcast/hazelcast-go-client/nearcache"
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/dapr/components-contrib/metadata"
@@ -27,17 +32,56 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
+// etagCounterSuffix is appended to the name of the map to name the CP atomic long generating the ETags.
+const etagCounterSuffix = "-etag"
+
+// etagSize is the size of the ETag prefixed to the values.
+const etagSize = 8
+
+// defaultTimeout is the timeout of the operations on the map without the operationTimeout metadata.
+const defaultTimeout = 20 * time.Second
+
+// hzMap is the subset of *hazelcast.Map used by the store.
+type hzMap interface {
+	Get(ctx context.Context, key interface{}) (interface{}, error)
+	Set(ctx context.Context, key interface{}, value interface{}) error
+	SetWithTTL(ctx context.Context, key interface{}, value interface{}, ttl time.Duration) error
+	SetTTL(ctx context.Context, key interface{}, ttl time.Duration) error
+	PutIfAbsent(ctx context.Context, key interface{}, value interface{}) (interface{}, error)
+	PutIfAbsentWithTTL(ctx context.Context, key interface{}, value interface{}, ttl time.Duration) (interface{}, error)
+	ReplaceIfSame(ctx context.Context, key interface{}, oldValue interface{}, newValue interface{}) (bool, error)
+	RemoveIfSame(ctx context.Context, key interface{}, value interface{}) (bool, error)
+	Delete(ctx context.Context, key interface{}) error
+}
+
+// etagGenerator is the subset of *hazelcast.AtomicLong used by the store.
+type etagGenerator interface {
+	AddAndGet(ctx context.Context, delta int64) (int64, error)
+}
+
 // Hazelcast state store.
 type Hazelcast struct {
 	state.DefaultBulkStore
-	hzMap  core.Map
-	json   jsoniter.API
-	logger logger.Logger
+	client   *hazelcast.Client
+	hzMap    hzMap
+	etags    etagGenerator
+	timeout  time.Duration
+	json     jsoniter.API
+	keyCodec *state.KeyCodec
+	logger   logger.Logger
 }
 
 type hazelcastMetadata struct {
 	HazelcastServers string
 	HazelcastMap     string
+	// Enables a near cache of the map in the client, invalidated when entries change in the cluster.
+	NearCacheEnabled bool
+	// Maximum number of entries in the near cache.
+	NearCacheMaxSize int
+	// Time after which entries are evicted from the near cache.
+	NearCacheTTL time.Duration
+	// Timeout of the operations on the map.
+	OperationTimeout time.Duration
 }
 
 // NewHazelcastStore returns a new hazelcast backed state store.
@@ -63,68 +107,189 @@ func validateAndParseMetadata(meta state.Metadata) (*hazelcastMetadata, error) {
 	if m.HazelcastMap == "" {
 		return nil, fmt.Errorf("hazelcast error: missing hazelcast map name")
 	}
+	if m.NearCacheMaxSize < 0 {
+		return nil, fmt.Errorf("hazelcast error: invalid near cache max size %d", m.NearCacheMaxSize)
+	}
+	if m.NearCacheTTL < 0 {
+		return nil, fmt.Errorf("hazelcast error: invalid near cache TTL %s", m.NearCacheTTL)
+	}
+	m.OperationTimeout, err = metadata.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("hazelcast error: %v", err)
+	}
 
 	return m, nil
 }
 
+// newConfig returns the client configuration of the metadata.
+func newConfig(meta *hazelcastMetadata) hazelcast.Config {
+	hzConfig := hazelcast.NewConfig()
+	hzConfig.Cluster.Network.SetAddresses(strings.Split(meta.HazelcastServers, ",")...)
+
+	if meta.NearCacheEnabled {
+		nc := nearcache.Config{
+			Name: meta.HazelcastMap,
+			// Values are byte slices, which don't need to be serialized again
+			InMemoryFormat:    nearcache.InMemoryFormatObject,
+			TimeToLiveSeconds: int(meta.NearCacheTTL / time.Second),
+		}
+		nc.SetInvalidateOnChange(true)
+		if meta.NearCacheMaxSize > 0 {
+			nc.Eviction.SetSize(meta.NearCacheMaxSize)
+		}
+		hzConfig.AddNearCache(nc)
+	}
+
+	return hzConfig
+}
+
 // Init does metadata and connection parsing.
 func (store *Hazelcast) Init(metadata state.Metadata) error {
 	meta, err := validateAndParseMetadata(metadata)
 	if err != nil {
 		return err
 	}
-	servers := meta.HazelcastServers
-
-	hzConfig := hazelcast.NewConfig()
-	hzConfig.NetworkConfig().AddAddress(strings.Split(servers, ",")...)
+	if store.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
-	client, err := hazelcast.NewClientWithConfig(hzConfig)
+	ctx := context.Background()
+	store.timeout = meta.OperationTimeout
+	store.client, err = hazelcast.StartNewClientWithConfig(ctx, newConfig(meta))
 	if err != nil {
 		return fmt.Errorf("hazelcast error: %v", err)
 	}
-	store.hzMap, err = client.GetMap(meta.HazelcastMap)
-
+	store.hzMap, err = store.client.GetMap(ctx, meta.HazelcastMap)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: %v", err)
 	}
 
+	// ETags are fencing tokens issued by a linearizable counter of the CP subsystem,
+	// so they are unique across clients even if members of the cluster fail
+	store.etags, err = store.client.CPSubsystem().GetAtomicLong(ctx, meta.HazelcastMap+etagCounterSuffix)
+	if err != nil {
+		return fmt.Errorf("hazelcast error: failed to get the ETag counter: %v", err)
+	}
+
 	return nil
 }
 
 // Features returns the features available in this state store.
 func (store *Hazelcast) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureETag}
 }
 
 // Set stores value for a key to Hazelcast.
+// Values are stored with a new ETag. With an ETag in the request, the value is only replaced if the ETag matches.
+// With the first-write concurrency and no ETag, the value is only stored if the key doesn't exist.
 func (store *Hazelcast) Set(req *state.SetRequest) error {
-	err := state.CheckRequestOptions(req)
+	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
 		return err
 	}
+	req = store.keyCodec.EncodeSet(req)
+
+	ttl, hasTTL, err := metadata.TryGetTTL(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("hazelcast error: failed to parse ttl for key %s: %s", req.Key, err)
+	}
 
-	var value string
-	b, ok := req.Value.([]byte)
-	if ok {
-		value = string(b)
-	} else {
-		value, err = store.json.MarshalToString(req.Value)
+	data, ok := req.Value.([]byte)
+	if !ok {
+		data, err = store.json.Marshal(req.Value)
 		if err != nil {
 			return fmt.Errorf("hazelcast error: failed to set key %s: %s", req.Key, err)
 		}
 	}
-	_, err = store.hzMap.Put(req.Key, value)
 
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), store.timeout)
+	defer cancel()
+	etag, err := store.etags.AddAndGet(ctx, 1)
 	if err != nil {
+		return fmt.Errorf("hazelcast error: failed to generate ETag for key %s: %s", req.Key, err)
+	}
+	value := encodeValue(etag, data)
+
+	switch {
+	case req.ETag != nil && *req.ETag != "":
+		err = store.replace(ctx, req.Key, *req.ETag, value)
+		if err == nil && hasTTL {
+			err = store.hzMap.SetTTL(ctx, req.Key, ttl)
+		}
+	case req.Options.Concurrency == state.FirstWrite:
+		var previous interface{}
+		if hasTTL {
+			previous, err = store.hzMap.PutIfAbsentWithTTL(ctx, req.Key, value, ttl)
+		} else {
+			previous, err = store.hzMap.PutIfAbsent(ctx, req.Key, value)
+		}
+		if err == nil && previous != nil {
+			return state.NewETagError(state.ETagMismatch, errors.New("item already exists and no etag was passed"))
+		}
+	case hasTTL:
+		err = store.hzMap.SetWithTTL(ctx, req.Key, value, ttl)
+	default:
+		err = store.hzMap.Set(ctx, req.Key, value)
+	}
+
+	if err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return err
+		}
 		return fmt.Errorf("hazelcast error: failed to set key %s: %s", req.Key, err)
 	}
 
 	return nil
 }
 
+// replace replaces the value of the key if its ETag matches.
+func (store *Hazelcast) replace(ctx context.Context, key string, etag string, value []byte) error {
+	current, err := store.getIfMatch(ctx, key, etag)
+	if err != nil {
+		return err
+	}
+
+	// The value is compared by the cluster, so it wasn't replaced since it was read
+	replaced, err := store.hzMap.ReplaceIfSame(ctx, key, current, value)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		return state.NewETagError(state.ETagMismatch, fmt.Errorf("etag of key %s changed", key))
+	}
+
+	return nil
+}
+
+// getIfMatch returns the value of the key if its ETag matches.
+func (store *Hazelcast) getIfMatch(ctx context.Context, key string, etag string) (interface{}, error) {
+	expected, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil {
+		return nil, state.NewETagError(state.ETagInvalid, err)
+	}
+
+	current, err := store.hzMap.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, state.NewETagError(state.ETagMismatch, fmt.Errorf("key %s does not exist", key))
+	}
+	currentETag, _, ok := decodeValue(current)
+	if !ok || currentETag != expected {
+		return nil, state.NewETagError(state.ETagMismatch, fmt.Errorf("etag of key %s does not match", key))
+	}
+
+	return current, nil
+}
+
 // Get retrieves state from Hazelcast with a key.
 func (store *Hazelcast) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	resp, err := store.hzMap.Get(req.Key)
+	req = store.keyCodec.EncodeGet(req)
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), store.timeout)
+	defer cancel()
+	resp, err := store.hzMap.Get(ctx, req.Key)
 	if err != nil {
 		return nil, fmt.Errorf("hazelcast error: failed to get value for %s: %s", req.Key, err)
 	}
@@ -133,23 +298,58 @@ func (store *Hazelcast) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	if resp == nil {
 		return &state.GetResponse{}, nil
 	}
-	value, err := store.json.Marshal(&resp)
-	if err != nil {
-		return nil, fmt.Errorf("hazelcast error: %v", err)
+
+	etag, data, ok := decodeValue(resp)
+	if !ok {
+		// Values stored before ETags were introduced are strings
+		s, isString := resp.(string)
+		if !isString {
+			return nil, fmt.Errorf("hazelcast error: unexpected value type %T for %s", resp, req.Key)
+		}
+		return &state.GetResponse{
+			Data: []byte(s),
+		}, nil
 	}
 
+	etagString := strconv.FormatInt(etag, 10)
 	return &state.GetResponse{
-		Data: value,
+		Data: data,
+		ETag: &etagString,
 	}, nil
 }
 
 // Delete performs a delete operation.
+// With an ETag in the request, the value is only deleted if the ETag matches.
 func (store *Hazelcast) Delete(req *state.DeleteRequest) error {
 	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
 		return err
 	}
-	err = store.hzMap.Delete(req.Key)
+	req = store.keyCodec.EncodeDelete(req)
+
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), store.timeout)
+	defer cancel()
+	if req.ETag != nil && *req.ETag != "" {
+		current, err := store.getIfMatch(ctx, req.Key, *req.ETag)
+		if err != nil {
+			var etagErr *state.ETagError
+			if errors.As(err, &etagErr) {
+				return err
+			}
+			return fmt.Errorf("hazelcast error: failed to delete key - %s", req.Key)
+		}
+		removed, err := store.hzMap.RemoveIfSame(ctx, req.Key, current)
+		if err != nil {
+			return fmt.Errorf("hazelcast error: failed to delete key - %s", req.Key)
+		}
+		if !removed {
+			return state.NewETagError(state.ETagMismatch, fmt.Errorf("etag of key %s changed", req.Key))
+		}
+
+		return nil
+	}
+
+	err = store.hzMap.Delete(ctx, req.Key)
 	if err != nil {
 		return fmt.Errorf("hazelcast error: failed to delete key - %s", req.Key)
 	}
@@ -157,9 +357,43 @@ func (store *Hazelcast) Delete(req *state.DeleteRequest) error {
 	return nil
 }
 
+func (store *Hazelcast) Close() error {
+	if store.client == nil {
+		return nil
+	}
+
+	return store.client.Shutdown(context.Background())
+}
+
 func (store *Hazelcast) GetComponentMetadata() map[string]string {
 	metadataStruct := hazelcastMetadata{}
 	metadataInfo := map[string]string{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (store *Hazelcast) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(store.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
+
+// encodeValue prefixes the data with the ETag.
+func encodeValue(etag int64, data []byte) []byte {
+	value := make([]byte, etagSize+len(data))
+	binary.BigEndian.PutUint64(value, uint64(etag))
+	copy(value[etagSize:], data)
+
+	return value
+}
+
+// decodeValue splits a value stored by encodeValue.
+func decodeValue(value interface{}) (int64, []byte, bool) {
+	b, ok := value.([]byte)
+	if !ok || len(b) < etagSize {
+		return 0, nil, false
+	}
+
+	return int64(binary.BigEndian.Uint64(b)), b[etagSize:], true
+}
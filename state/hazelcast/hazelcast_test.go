@@ -14,12 +14,17 @@ limitations under the License.
 package hazelcast
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
 )
 
 func TestValidateMetadata(t *testing.T) {
@@ -65,5 +70,181 @@ func TestValidateMetadata(t *testing.T) {
 		meta, err := validateAndParseMetadata(m)
 		assert.Nil(t, err)
 		assert.Equal(t, properties["hazelcastServers"], meta.HazelcastServers)
+		assert.Equal(t, defaultTimeout, meta.OperationTimeout)
 	})
 }
+
+type fakeMap struct {
+	entries map[interface{}]interface{}
+	ttls    map[interface{}]time.Duration
+}
+
+func newFakeMap() *fakeMap {
+	return &fakeMap{
+		entries: map[interface{}]interface{}{},
+		ttls:    map[interface{}]time.Duration{},
+	}
+}
+
+func (f *fakeMap) Get(_ context.Context, key interface{}) (interface{}, error) {
+	return f.entries[key], nil
+}
+
+func (f *fakeMap) Set(_ context.Context, key interface{}, value interface{}) error {
+	f.entries[key] = value
+	return nil
+}
+
+func (f *fakeMap) SetWithTTL(ctx context.Context, key interface{}, value interface{}, ttl time.Duration) error {
+	f.ttls[key] = ttl
+	return f.Set(ctx, key, value)
+}
+
+func (f *fakeMap) SetTTL(_ context.Context, key interface{}, ttl time.Duration) error {
+	f.ttls[key] = ttl
+	return nil
+}
+
+func (f *fakeMap) PutIfAbsent(_ context.Context, key interface{}, value interface{}) (interface{}, error) {
+	if previous, ok := f.entries[key]; ok {
+		return previous, nil
+	}
+	f.entries[key] = value
+	return nil, nil
+}
+
+func (f *fakeMap) PutIfAbsentWithTTL(ctx context.Context, key interface{}, value interface{}, ttl time.Duration) (interface{}, error) {
+	previous, err := f.PutIfAbsent(ctx, key, value)
+	if previous == nil {
+		f.ttls[key] = ttl
+	}
+	return previous, err
+}
+
+func (f *fakeMap) ReplaceIfSame(_ context.Context, key interface{}, oldValue interface{}, newValue interface{}) (bool, error) {
+	if !reflect.DeepEqual(f.entries[key], oldValue) {
+		return false, nil
+	}
+	f.entries[key] = newValue
+	return true, nil
+}
+
+func (f *fakeMap) RemoveIfSame(_ context.Context, key interface{}, value interface{}) (bool, error) {
+	if !reflect.DeepEqual(f.entries[key], value) {
+		return false, nil
+	}
+	delete(f.entries, key)
+	return true, nil
+}
+
+func (f *fakeMap) Delete(_ context.Context, key interface{}) error {
+	delete(f.entries, key)
+	return nil
+}
+
+type fakeCounter struct {
+	value int64
+}
+
+func (f *fakeCounter) AddAndGet(_ context.Context, delta int64) (int64, error) {
+	f.value += delta
+	return f.value, nil
+}
+
+func newTestStore() (*Hazelcast, *fakeMap) {
+	m := newFakeMap()
+	store := NewHazelcastStore(logger.NewLogger("test")).(*Hazelcast)
+	store.hzMap = m
+	store.etags = &fakeCounter{}
+
+	return store, m
+}
+
+func TestETag(t *testing.T) {
+	store, _ := newTestStore()
+	assert.True(t, state.FeatureETag.IsPresent(store.Features()))
+
+	var etagErr *state.ETagError
+
+	require.NoError(t, store.Set(&state.SetRequest{Key: "key", Value: []byte("v1")}))
+	resp, err := store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(resp.Data))
+	require.NotNil(t, resp.ETag)
+	etag := *resp.ETag
+
+	// Writes with the current ETag succeed and change it
+	require.NoError(t, store.Set(&state.SetRequest{Key: "key", Value: map[string]string{"a": "b"}, ETag: &etag}))
+	err = store.Set(&state.SetRequest{Key: "key", Value: []byte("v3"), ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	err = store.Delete(&state.DeleteRequest{Key: "key", ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	invalid := "invalid"
+	err = store.Set(&state.SetRequest{Key: "key", Value: []byte("v3"), ETag: &invalid})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagInvalid, etagErr.Kind())
+
+	resp, err = store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":"b"}`, string(resp.Data))
+	assert.NotEqual(t, etag, *resp.ETag)
+	require.NoError(t, store.Delete(&state.DeleteRequest{Key: "key", ETag: resp.ETag}))
+
+	resp, err = store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Data)
+}
+
+func TestFirstWrite(t *testing.T) {
+	store, _ := newTestStore()
+	firstWrite := state.SetStateOption{Concurrency: state.FirstWrite}
+
+	require.NoError(t, store.Set(&state.SetRequest{Key: "key", Value: []byte("v1"), Options: firstWrite}))
+	err := store.Set(&state.SetRequest{Key: "key", Value: []byte("v2"), Options: firstWrite})
+	var etagErr *state.ETagError
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+}
+
+func TestTTL(t *testing.T) {
+	store, m := newTestStore()
+
+	require.NoError(t, store.Set(&state.SetRequest{Key: "key", Value: []byte("v1"), Metadata: map[string]string{"ttlInSeconds": "10"}}))
+	assert.Equal(t, 10*time.Second, m.ttls["key"])
+
+	err := store.Set(&state.SetRequest{Key: "key", Value: []byte("v1"), Metadata: map[string]string{"ttlInSeconds": "abc"}})
+	assert.Error(t, err)
+}
+
+func TestLegacyValues(t *testing.T) {
+	store, m := newTestStore()
+	m.entries["key"] = `{"a":"b"}`
+
+	resp, err := store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":"b"}`, string(resp.Data))
+	assert.Nil(t, resp.ETag)
+}
+
+func TestNearCacheConfig(t *testing.T) {
+	meta, err := validateAndParseMetadata(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"hazelcastServers": "hz1:5701,hz2:5701",
+		"hazelcastMap":     "foo-map",
+		"nearCacheEnabled": "true",
+		"nearCacheMaxSize": "100",
+		"nearCacheTTL":     "1m",
+	}}})
+	require.NoError(t, err)
+
+	config := newConfig(meta)
+	assert.Equal(t, []string{"hz1:5701", "hz2:5701"}, config.Cluster.Network.Addresses)
+	nc, ok, err := config.GetNearCache("foo-map")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 60, nc.TimeToLiveSeconds)
+	assert.Equal(t, 100, nc.Eviction.Size())
+	assert.True(t, nc.InvalidateOnChange())
+}
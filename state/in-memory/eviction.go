@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	evictionPolicyLRU = "lru"
+	evictionPolicyLFU = "lfu"
+)
+
+type inMemoryMetadata struct {
+	// Maximum number of items; 0 means unbounded.
+	MaxItems int `mapstructure:"maxItems"`
+	// Maximum size of the keys and values of the items, in bytes; 0 means unbounded.
+	MaxMemoryBytes int64 `mapstructure:"maxMemoryBytes"`
+	// Policy choosing the items evicted when the store is full: lru (least recently used, default) or lfu (least
+	// frequently used).
+	EvictionPolicy string `mapstructure:"evictionPolicy"`
+}
+
+func parseMetadata(props map[string]string) (inMemoryMetadata, error) {
+	m := inMemoryMetadata{
+		EvictionPolicy: evictionPolicyLRU,
+	}
+	err := metadata.DecodeMetadata(props, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.MaxItems < 0 {
+		return m, fmt.Errorf("invalid maxItems: %d", m.MaxItems)
+	}
+	if m.MaxMemoryBytes < 0 {
+		return m, fmt.Errorf("invalid maxMemoryBytes: %d", m.MaxMemoryBytes)
+	}
+	m.EvictionPolicy = strings.ToLower(m.EvictionPolicy)
+	if m.EvictionPolicy != evictionPolicyLRU && m.EvictionPolicy != evictionPolicyLFU {
+		return m, fmt.Errorf("invalid evictionPolicy: %s", m.EvictionPolicy)
+	}
+
+	return m, nil
+}
+
+// bounded returns true if items are evicted when the store is full.
+func (m inMemoryMetadata) bounded() bool {
+	return m.MaxItems > 0 || m.MaxMemoryBytes > 0
+}
+
+// evictionQueue is a min-heap of the items, the first being the next to evict.
+type evictionQueue struct {
+	items []*inMemStateStoreItem
+	lfu   bool
+}
+
+var _ heap.Interface = (*evictionQueue)(nil)
+
+func (q *evictionQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *evictionQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	// Ties of LFU are broken by recency
+	if q.lfu && a.hits != b.hits {
+		return a.hits < b.hits
+	}
+	return a.lastAccess < b.lastAccess
+}
+
+func (q *evictionQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *evictionQueue) Push(x any) {
+	item := x.(*inMemStateStoreItem)
+	item.index = len(q.items)
+	q.items = append(q.items, item)
+}
+
+func (q *evictionQueue) Pop() any {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items[n-1] = nil
+	q.items = q.items[:n-1]
+	item.index = -1
+	return item
+}
+
+// touch records an access to an item, for the eviction policy.
+// It must be called with the write lock.
+func (store *inMemoryStore) touch(item *inMemStateStoreItem) {
+	if store.queue == nil {
+		return
+	}
+	store.clock++
+	item.lastAccess = store.clock
+	item.hits++
+	if item.index >= 0 {
+		heap.Fix(store.queue, item.index)
+	}
+}
+
+// full returns true if the store holds more items or bytes than allowed.
+func (store *inMemoryStore) full() bool {
+	return (store.metadata.MaxItems > 0 && len(store.items) > store.metadata.MaxItems) ||
+		(store.metadata.MaxMemoryBytes > 0 && store.memoryBytes > store.metadata.MaxMemoryBytes)
+}
+
+// evict removes items until the store isn't full, following the eviction policy.
+// The keys just written aren't evicted, so the store may stay full when a single request writes more than allowed.
+// It must be called with the write lock.
+func (store *inMemoryStore) evict(written ...string) {
+	if store.queue == nil || !store.full() {
+		return
+	}
+
+	protected := make(map[string]struct{}, len(written))
+	for _, key := range written {
+		protected[key] = struct{}{}
+	}
+	var skipped []*inMemStateStoreItem
+	for store.full() && store.queue.Len() > 0 {
+		item := heap.Pop(store.queue).(*inMemStateStoreItem)
+		if _, ok := protected[item.key]; ok {
+			skipped = append(skipped, item)
+			continue
+		}
+		delete(store.items, item.key)
+		store.memoryBytes -= item.size
+		store.evictions++
+	}
+	for _, item := range skipped {
+		heap.Push(store.queue, item)
+	}
+}
+
+// checkSize returns an error if an item can't fit in the store.
+func (store *inMemoryStore) checkSize(key string, data []byte) error {
+	if store.metadata.MaxMemoryBytes > 0 && itemSize(key, data) > store.metadata.MaxMemoryBytes {
+		return fmt.Errorf("item with key %s is larger than maxMemoryBytes", key)
+	}
+	return nil
+}
+
+func itemSize(key string, data []byte) int64 {
+	return int64(len(key) + len(data))
+}
@@ -14,11 +14,14 @@ limitations under the License.
 package inmemory
 
 import (
+	"container/heap"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -29,6 +32,7 @@ import (
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
 )
@@ -38,12 +42,26 @@ type inMemStateStoreItem struct {
 	etag     *string
 	expire   *int64
 	isBinary bool
+
+	// Bookkeeping of the eviction of the items, when the store is bounded.
+	key        string
+	size       int64
+	lastAccess uint64
+	hits       uint64
+	index      int
 }
 
 type inMemoryStore struct {
-	items map[string]*inMemStateStoreItem
-	lock  *sync.RWMutex
-	log   logger.Logger
+	items    map[string]*inMemStateStoreItem
+	lock     *sync.RWMutex
+	log      logger.Logger
+	metadata inMemoryMetadata
+
+	// Items in the order of eviction, only when the store is bounded.
+	queue       *evictionQueue
+	clock       uint64
+	memoryBytes int64
+	evictions   uint64
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -58,6 +76,15 @@ func NewInMemoryStateStore(logger logger.Logger) state.Store {
 }
 
 func (store *inMemoryStore) Init(metadata state.Metadata) error {
+	m, err := parseMetadata(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	store.metadata = m
+	if m.bounded() {
+		store.queue = &evictionQueue{lfu: m.EvictionPolicy == evictionPolicyLFU}
+	}
+
 	store.ctx, store.cancel = context.WithCancel(context.Background())
 	// start a background go routine to clean expired item
 	go store.startCleanThread()
@@ -75,6 +102,10 @@ func (store *inMemoryStore) Close() error {
 	for k := range store.items {
 		delete(store.items, k)
 	}
+	store.memoryBytes = 0
+	if store.queue != nil {
+		store.queue.items = nil
+	}
 
 	return nil
 }
@@ -132,7 +163,15 @@ func (store *inMemoryStore) doValidateEtag(key string, etag *string, concurrency
 }
 
 func (store *inMemoryStore) doDelete(key string) {
+	item := store.items[key]
+	if item == nil {
+		return
+	}
 	delete(store.items, key)
+	store.memoryBytes -= item.size
+	if store.queue != nil && item.index >= 0 {
+		heap.Remove(store.queue, item.index)
+	}
 }
 
 func (store *inMemoryStore) BulkDelete(req []state.DeleteRequest) error {
@@ -167,33 +206,40 @@ func (store *inMemoryStore) BulkDelete(req []state.DeleteRequest) error {
 }
 
 func (store *inMemoryStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	item := store.doGetWithReadLock(req.Key)
-	if item != nil && isExpired(item) {
+	var item *inMemStateStoreItem
+	if store.queue != nil {
+		// Reads are recorded for the eviction policy
 		item = store.doGetWithWriteLock(req.Key)
+	} else {
+		item = store.doGetWithReadLock(req.Key)
+		if item != nil && isExpired(item) {
+			item = store.doGetWithWriteLock(req.Key)
+		}
 	}
 
 	if item == nil {
 		return &state.GetResponse{Data: nil, ETag: nil}, nil
 	}
 
-	data := item.data
-	if item.isBinary {
-		var (
-			s   string
-			err error
-		)
+	data, err := item.value()
+	if err != nil {
+		return nil, err
+	}
 
-		if err = jsoniter.Unmarshal(data, &s); err != nil {
-			return nil, err
-		}
+	return &state.GetResponse{Data: data, ETag: item.etag}, nil
+}
 
-		data, err = base64.StdEncoding.DecodeString(s)
-		if err != nil {
-			return nil, err
-		}
+// value returns the data of the item as it was set, decoding the binary data.
+func (item *inMemStateStoreItem) value() ([]byte, error) {
+	if !item.isBinary {
+		return item.data, nil
 	}
 
-	return &state.GetResponse{Data: data, ETag: item.etag}, nil
+	var s string
+	if err := jsoniter.Unmarshal(item.data, &s); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(s)
 }
 
 func (store *inMemoryStore) doGetWithReadLock(key string) *inMemStateStoreItem {
@@ -215,6 +261,7 @@ func (store *inMemoryStore) doGetWithWriteLock(key string) *inMemStateStoreItem
 		store.doDelete(key)
 		return nil
 	}
+	store.touch(item)
 	return item
 }
 
@@ -263,9 +310,14 @@ func (store *inMemoryStore) Set(req *state.SetRequest) error {
 	if err != nil {
 		return err
 	}
+	err = store.checkSize(req.Key, bt)
+	if err != nil {
+		return err
+	}
 
 	// this operation won't fail
 	store.doSet(req.Key, bt, ttlInSeconds, isBinary)
+	store.evict(req.Key)
 	return nil
 }
 
@@ -307,12 +359,25 @@ func (store *inMemoryStore) doSet(key string, data []byte, ttlInSeconds int, isB
 		data:     data,
 		etag:     &etag,
 		isBinary: isBinary,
+		key:      key,
+		size:     itemSize(key, data),
+		index:    -1,
 	}
 	if ttlInSeconds > 0 {
 		el.expire = ptr.Of(time.Now().UnixMilli() + int64(ttlInSeconds)*1000)
 	}
 
+	if old := store.items[key]; old != nil {
+		// The frequency of use is kept across updates
+		el.hits = old.hits
+		store.doDelete(key)
+	}
 	store.items[key] = el
+	store.memoryBytes += el.size
+	if store.queue != nil {
+		heap.Push(store.queue, el)
+		store.touch(el)
+	}
 }
 
 // innerSetRequest is only used to pass ttlInSeconds and data with SetRequest.
@@ -340,6 +405,10 @@ func (store *inMemoryStore) BulkSet(req []state.SetRequest) error {
 		if err != nil {
 			return err
 		}
+		err = store.checkSize(req[i].Key, bt)
+		if err != nil {
+			return err
+		}
 		innerSetRequest := &innerSetRequest{
 			req:      req[i],
 			ttl:      ttlInSeconds,
@@ -363,9 +432,12 @@ func (store *inMemoryStore) BulkSet(req []state.SetRequest) error {
 
 	// step3: do really set
 	// these operations won't fail
-	for _, innerSetRequest := range innerSetRequestList {
+	keys := make([]string, len(innerSetRequestList))
+	for i, innerSetRequest := range innerSetRequestList {
 		store.doSet(innerSetRequest.req.Key, innerSetRequest.data, innerSetRequest.ttl, innerSetRequest.isBinary)
+		keys[i] = innerSetRequest.req.Key
 	}
+	store.evict(keys...)
 	return nil
 }
 
@@ -386,6 +458,10 @@ func (store *inMemoryStore) Multi(request *state.TransactionalStateRequest) erro
 			if err != nil {
 				return err
 			}
+			err = store.checkSize(s.Key, bt)
+			if err != nil {
+				return err
+			}
 			innerSetRequest := &innerSetRequest{
 				req:      s,
 				ttl:      ttlInSeconds,
@@ -426,15 +502,18 @@ func (store *inMemoryStore) Multi(request *state.TransactionalStateRequest) erro
 
 	// step3: do really set
 	// these operations won't fail
+	var keys []string
 	for _, o := range request.Operations {
 		if o.Operation == state.Upsert {
 			s := o.Request.(*innerSetRequest)
 			store.doSet(s.req.Key, s.data, s.ttl, s.isBinary)
+			keys = append(keys, s.req.Key)
 		} else if o.Operation == state.Delete {
 			d := o.Request.(state.DeleteRequest)
 			store.doDelete(d.Key)
 		}
 	}
+	store.evict(keys...)
 	return nil
 }
 
@@ -460,7 +539,98 @@ func (store *inMemoryStore) doCleanExpiredItems() {
 	}
 }
 
+// Export returns the items of the store sorted by key, with the last key of the page as the token of the next page.
+func (store *inMemoryStore) Export(req *state.ExportRequest) (*state.ExportResponse, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	keys := make([]string, 0, len(store.items))
+	for key, item := range store.items {
+		if key > req.Token && !isExpired(item) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	res := &state.ExportResponse{Items: []state.ExportItem{}}
+	if req.Limit > 0 && len(keys) > req.Limit {
+		keys = keys[:req.Limit]
+		res.Token = keys[req.Limit-1]
+	}
+	now := time.Now().UnixMilli()
+	for _, key := range keys {
+		item := store.items[key]
+		data, err := item.value()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export key %s: %w", key, err)
+		}
+		exported := state.ExportItem{Key: key, Data: data, ETag: item.etag}
+		if item.expire != nil {
+			// Round up, so the item doesn't expire before it's imported
+			ttl := (*item.expire - now + 999) / 1000
+			exported.Metadata = map[string]string{"ttlInSeconds": strconv.FormatInt(ttl, 10)}
+		}
+		res.Items = append(res.Items, exported)
+	}
+	return res, nil
+}
+
+// Import sets the items, keeping their ETags.
+func (store *inMemoryStore) Import(items []state.ExportItem) error {
+	imported := make([]*innerSetRequest, len(items))
+	for i, item := range items {
+		ttlInSeconds, err := doParseTTLInSeconds(item.Metadata)
+		if err != nil {
+			return err
+		}
+		bt, isBinary, err := store.marshal(item.Data)
+		if err != nil {
+			return err
+		}
+		err = store.checkSize(item.Key, bt)
+		if err != nil {
+			return err
+		}
+		imported[i] = &innerSetRequest{
+			req:      state.SetRequest{Key: item.Key, ETag: item.ETag},
+			ttl:      ttlInSeconds,
+			data:     bt,
+			isBinary: isBinary,
+		}
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	keys := make([]string, len(imported))
+	for i, s := range imported {
+		store.doSet(s.req.Key, s.data, s.ttl, s.isBinary)
+		if s.req.ETag != nil {
+			store.items[s.req.Key].etag = s.req.ETag
+		}
+		keys[i] = s.req.Key
+	}
+	store.evict(keys...)
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the store, and its current usage for debugging: the number of items,
+// their size and the number of items evicted.
 func (store *inMemoryStore) GetComponentMetadata() map[string]string {
-	// no metadata, hence no metadata struct to convert here
-	return map[string]string{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(inMemoryMetadata{}), &metadataInfo)
+
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	metadataInfo["itemCount"] = strconv.Itoa(len(store.items))
+	metadataInfo["memoryBytes"] = strconv.FormatInt(store.memoryBytes, 10)
+	metadataInfo["evictedItems"] = strconv.FormatUint(store.evictions, 10)
+	return metadataInfo
+}
+
+func (store *inMemoryStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(store.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
 }
@@ -14,14 +14,18 @@ limitations under the License.
 package inmemory
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/kit/logger"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 )
 
@@ -126,3 +130,129 @@ func TestReadAndWrite(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestExportImport(t *testing.T) {
+	src := NewInMemoryStateStore(logger.NewLogger("test"))
+	src.Init(state.Metadata{})
+	defer src.(*inMemoryStore).Close()
+	dst := NewInMemoryStateStore(logger.NewLogger("test"))
+	dst.Init(state.Metadata{})
+	defer dst.(*inMemoryStore).Close()
+
+	for i := 0; i < 5; i++ {
+		err := src.Set(&state.SetRequest{Key: fmt.Sprintf("key-%d", i), Value: i})
+		require.NoError(t, err)
+	}
+	err := src.Set(&state.SetRequest{Key: "binary", Value: []byte{0, 1, 2}})
+	require.NoError(t, err)
+	err = src.Set(&state.SetRequest{Key: "ttl", Value: "v", Metadata: map[string]string{"ttlInSeconds": "100"}})
+	require.NoError(t, err)
+
+	page, err := src.(state.Exporter).Export(&state.ExportRequest{Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 3)
+	assert.Equal(t, "binary", page.Items[0].Key)
+	assert.Equal(t, []byte{0, 1, 2}, page.Items[0].Data)
+	assert.Equal(t, "key-1", page.Token)
+
+	res, err := state.Migrate(context.Background(), src.(state.Exporter), dst, state.MigrateOptions{BatchSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 7, res.Migrated)
+
+	for _, key := range []string{"key-0", "key-4", "binary", "ttl"} {
+		expected, err := src.Get(&state.GetRequest{Key: key})
+		require.NoError(t, err)
+		actual, err := dst.Get(&state.GetRequest{Key: key})
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, key)
+	}
+	item := dst.(*inMemoryStore).items["ttl"]
+	require.NotNil(t, item.expire)
+	assert.InDelta(t, time.Now().UnixMilli()+100*1000, *item.expire, 2000)
+}
+
+func TestEviction(t *testing.T) {
+	newStore := func(t *testing.T, props map[string]string) *inMemoryStore {
+		store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+		require.NoError(t, store.Init(state.Metadata{Base: metadata.Base{Properties: props}}))
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+	exists := func(t *testing.T, store *inMemoryStore, key string) bool {
+		res, err := store.Get(&state.GetRequest{Key: key})
+		require.NoError(t, err)
+		return res.Data != nil
+	}
+
+	t.Run("least recently used", func(t *testing.T) {
+		store := newStore(t, map[string]string{"maxItems": "2"})
+		require.NoError(t, store.Set(&state.SetRequest{Key: "a", Value: "1"}))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "b", Value: "2"}))
+		assert.True(t, exists(t, store, "a"))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "c", Value: "3"}))
+
+		assert.False(t, exists(t, store, "b"))
+		assert.True(t, exists(t, store, "a"))
+		assert.True(t, exists(t, store, "c"))
+		assert.Equal(t, "1", store.GetComponentMetadata()["evictedItems"])
+		assert.Equal(t, "2", store.GetComponentMetadata()["itemCount"])
+	})
+
+	t.Run("least frequently used", func(t *testing.T) {
+		store := newStore(t, map[string]string{"maxItems": "2", "evictionPolicy": "LFU"})
+		require.NoError(t, store.Set(&state.SetRequest{Key: "a", Value: "1"}))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "b", Value: "2"}))
+		for i := 0; i < 3; i++ {
+			assert.True(t, exists(t, store, "a"))
+		}
+		assert.True(t, exists(t, store, "b"))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "c", Value: "3"}))
+
+		assert.False(t, exists(t, store, "b"))
+		assert.True(t, exists(t, store, "a"))
+		assert.True(t, exists(t, store, "c"))
+	})
+
+	t.Run("memory bound", func(t *testing.T) {
+		store := newStore(t, map[string]string{"maxMemoryBytes": "20"})
+		// Each item takes 1 byte of key and 8 of JSON value
+		require.NoError(t, store.Set(&state.SetRequest{Key: "a", Value: "123456"}))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "b", Value: "123456"}))
+		assert.Equal(t, "18", store.GetComponentMetadata()["memoryBytes"])
+		require.NoError(t, store.BulkSet([]state.SetRequest{{Key: "c", Value: "1"}, {Key: "d", Value: "1"}}))
+
+		// The new items take 4 bytes each, so evicting the oldest item is enough
+		assert.False(t, exists(t, store, "a"))
+		assert.True(t, exists(t, store, "b"))
+		assert.True(t, exists(t, store, "c"))
+		assert.True(t, exists(t, store, "d"))
+		assert.Equal(t, "17", store.GetComponentMetadata()["memoryBytes"])
+
+		err := store.Set(&state.SetRequest{Key: "e", Value: "larger than the maximum size"})
+		assert.Error(t, err)
+	})
+
+	t.Run("deletes and expirations free space", func(t *testing.T) {
+		store := newStore(t, map[string]string{"maxItems": "2"})
+		require.NoError(t, store.Set(&state.SetRequest{Key: "a", Value: "1"}))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "b", Value: "2"}))
+		require.NoError(t, store.Delete(&state.DeleteRequest{Key: "a"}))
+		require.NoError(t, store.Set(&state.SetRequest{Key: "c", Value: "3"}))
+
+		assert.True(t, exists(t, store, "b"))
+		assert.True(t, exists(t, store, "c"))
+		assert.Equal(t, "0", store.GetComponentMetadata()["evictedItems"])
+		assert.Len(t, store.queue.items, 2)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{"maxItems": "-1"},
+			{"maxMemoryBytes": "-1"},
+			{"evictionPolicy": "fifo"},
+		} {
+			store := NewInMemoryStateStore(logger.NewLogger("test"))
+			assert.Error(t, store.Init(state.Metadata{Base: metadata.Base{Properties: props}}))
+		}
+	})
+}
@@ -14,19 +14,17 @@ limitations under the License.
 package jetstream
 
 import (
-	"fmt"
 	"reflect"
 	"strings"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/nats-io/nats.go"
 
+	"github.com/dapr/components-contrib/internal/component/jetstream"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
-
-	"github.com/nats-io/nats.go"
-	"github.com/nats-io/nkeys"
 )
 
 // StateStore is a nats jetstream KV state store.
@@ -36,15 +34,11 @@ type StateStore struct {
 	json   jsoniter.API
 	bucket nats.KeyValue
 	logger logger.Logger
+	// Codec of the keyTemplate metadata of the component, if any.
+	keyCodec *state.KeyCodec
 }
 
-type jetstreamMetadata struct {
-	Name    string
-	NatsURL string
-	Jwt     string
-	SeedKey string
-	Bucket  string
-}
+type jetstreamMetadata = jetstream.KVMetadata
 
 // NewJetstreamStateStore returns a new nats jetstream KV state store.
 func NewJetstreamStateStore(logger logger.Logger) state.Store {
@@ -63,111 +57,107 @@ func (js *StateStore) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
-
-	var opts []nats.Option
-	opts = append(opts, nats.Name(meta.Name))
-
-	// Set nats.UserJWT options when jwt and seed key is provided.
-	if meta.Jwt != "" && meta.SeedKey != "" {
-		opts = append(opts, nats.UserJWT(func() (string, error) {
-			return meta.Jwt, nil
-		}, func(nonce []byte) ([]byte, error) {
-			return sigHandler(meta.SeedKey, nonce)
-		}))
-	}
-
-	js.nc, err = nats.Connect(meta.NatsURL, opts...)
-	if err != nil {
-		return err
-	}
-
-	jsc, err := js.nc.JetStream()
-	if err != nil {
-		return err
-	}
-
-	js.bucket, err = jsc.KeyValue(meta.Bucket)
+	js.keyCodec, err = state.NewKeyCodec(metadata)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	js.nc, js.bucket, err = jetstream.ConnectKV(meta)
+	return err
 }
 
 func (js *StateStore) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureETag}
 }
 
 // Get retrieves state with a key.
+// The revision of the key is returned as the ETag.
 func (js *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	entry, err := js.bucket.Get(escape(req.Key))
+	entry, err := js.bucket.Get(js.storedKey(req.Key))
 	if err != nil {
 		return nil, err
 	}
 
+	etag := jetstream.FormatRevision(entry.Revision())
 	return &state.GetResponse{
 		Data: entry.Value(),
+		ETag: &etag,
 	}, nil
 }
 
 // Set stores value for a key.
+// With an ETag, the key is only updated if its revision matches. With the first-write concurrency and no ETag,
+// the key is only created if it doesn't exist.
 func (js *StateStore) Set(req *state.SetRequest) error {
 	bt, _ := utils.Marshal(req.Value, js.json.Marshal)
-	_, err := js.bucket.Put(escape(req.Key), bt)
-	return err
+	key := js.storedKey(req.Key)
+
+	var err error
+	switch {
+	case req.ETag != nil && *req.ETag != "":
+		var revision uint64
+		revision, err = parseETag(*req.ETag)
+		if err != nil {
+			return err
+		}
+		_, err = js.bucket.Update(key, bt, revision)
+	case req.Options.Concurrency == state.FirstWrite:
+		_, err = js.bucket.Create(key, bt)
+	default:
+		_, err = js.bucket.Put(key, bt)
+	}
+
+	return etagError(err)
 }
 
 // Delete performs a delete operation.
+// With an ETag, the key is only deleted if its revision matches.
 func (js *StateStore) Delete(req *state.DeleteRequest) error {
-	return js.bucket.Delete(escape(req.Key))
-}
-
-func (js *StateStore) getMetadata(meta state.Metadata) (jetstreamMetadata, error) {
-	var m jetstreamMetadata
-	err := metadata.DecodeMetadata(meta.Properties, &m)
-	if err != nil {
-		return jetstreamMetadata{}, err
+	var opts []nats.DeleteOpt
+	if req.ETag != nil && *req.ETag != "" {
+		revision, err := parseETag(*req.ETag)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, nats.LastRevision(revision))
 	}
 
-	if m.NatsURL == "" {
-		return jetstreamMetadata{}, fmt.Errorf("missing nats URL")
-	}
+	return etagError(js.bucket.Delete(js.storedKey(req.Key), opts...))
+}
 
-	if m.Jwt != "" && m.SeedKey == "" {
-		return jetstreamMetadata{}, fmt.Errorf("missing seed key")
-	}
+func (js *StateStore) getMetadata(meta state.Metadata) (jetstreamMetadata, error) {
+	return jetstream.ParseKVMetadata(meta.Properties, "dapr.io - statestore.jetstream")
+}
 
-	if m.Jwt == "" && m.SeedKey != "" {
-		return jetstreamMetadata{}, fmt.Errorf("missing jwt")
+func parseETag(etag string) (uint64, error) {
+	revision, err := jetstream.ParseRevision(etag)
+	if err != nil {
+		return 0, state.NewETagError(state.ETagInvalid, err)
 	}
 
-	if m.Name == "" {
-		m.Name = "dapr.io - statestore.jetstream"
-	}
+	return revision, nil
+}
 
-	if m.Bucket == "" {
-		return jetstreamMetadata{}, fmt.Errorf("missing bucket")
+// etagError converts the errors of writes expecting a revision to ETag mismatch errors.
+func etagError(err error) error {
+	if jetstream.IsRevisionMismatch(err) {
+		return state.NewETagError(state.ETagMismatch, err)
 	}
 
-	return m, nil
+	return err
 }
 
-// Handle nats signature request for challenge response authentication.
-func sigHandler(seedKey string, nonce []byte) ([]byte, error) {
-	kp, err := nkeys.FromSeed([]byte(seedKey))
-	if err != nil {
-		return nil, err
+// storedKey returns the key of the bucket of a key, following the keyTemplate metadata of the component if any.
+func (js *StateStore) storedKey(key string) string {
+	if js.keyCodec != nil {
+		key = js.keyCodec.Encode(key)
 	}
-	// Wipe our key on exit.
-	defer kp.Wipe()
-
-	sig, _ := kp.Sign(nonce)
-	return sig, nil
+	return escape(key)
 }
 
 // Escape dapr keys, because || is forbidden.
 func escape(key string) string {
-	return strings.ReplaceAll(key, "||", ".")
+	return strings.ReplaceAll(key, state.KeyDelimiter, ".")
 }
 
 func (js *StateStore) GetComponentMetadata() map[string]string {
@@ -176,3 +166,10 @@ func (js *StateStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (js *StateStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(js.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfileJWT},
+	}
+}
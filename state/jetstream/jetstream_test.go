@@ -22,6 +22,8 @@ import (
 	"github.com/nats-io/nats-server/v2/server"
 	natsserver "github.com/nats-io/nats-server/v2/test"
 	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
@@ -150,3 +152,67 @@ func TestSetGetAndDelete(t *testing.T) {
 		return
 	}
 }
+
+func TestETag(t *testing.T) {
+	s := runDefaultServer()
+	defer s.Shutdown()
+
+	_, nc := connectAndCreateBucket(t)
+	nc.Close()
+
+	store := NewJetstreamStateStore(nil)
+	err := store.Init(state.Metadata{
+		Base: metadata.Base{Properties: map[string]string{
+			"natsURL": nats.DefaultURL,
+			"bucket":  "test",
+		}},
+	})
+	require.NoError(t, err)
+	assert.True(t, state.FeatureETag.IsPresent(store.Features()))
+
+	var etagErr *state.ETagError
+
+	// First write only creates missing keys
+	firstWrite := state.SetStateOption{Concurrency: state.FirstWrite}
+	require.NoError(t, store.Set(&state.SetRequest{Key: "key", Value: "v1", Options: firstWrite}))
+	err = store.Set(&state.SetRequest{Key: "key", Value: "v2", Options: firstWrite})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	resp, err := store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	require.NotNil(t, resp.ETag)
+	etag := *resp.ETag
+
+	// Writes with the current revision succeed and change it
+	require.NoError(t, store.Set(&state.SetRequest{Key: "key", Value: "v2", ETag: &etag}))
+	err = store.Set(&state.SetRequest{Key: "key", Value: "v3", ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	err = store.Delete(&state.DeleteRequest{Key: "key", ETag: &etag})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+	invalid := "invalid"
+	err = store.Set(&state.SetRequest{Key: "key", Value: "v3", ETag: &invalid})
+	require.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagInvalid, etagErr.Kind())
+
+	resp, err = store.Get(&state.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, string(resp.Data))
+	require.NoError(t, store.Delete(&state.DeleteRequest{Key: "key", ETag: resp.ETag}))
+}
+
+func TestStoredKey(t *testing.T) {
+	js := &StateStore{}
+	assert.Equal(t, "app.key", js.storedKey("app||key"))
+
+	var err error
+	js.keyCodec, err = state.NewKeyCodec(state.Metadata{Base: metadata.Base{
+		Name:       "statestore",
+		Properties: map[string]string{"keyTemplate": "{storename}/{appid}/{key}"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "statestore/app/key", js.storedKey("app||key"))
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// KeyDelimiter separates the app ID from the key in the keys of the requests: <app id>||<key>.
+	KeyDelimiter = "||"
+
+	// KeyTemplateMetadataKey is the metadata of the component with the template of the stored keys.
+	// It's separate from the keyPrefix metadata, which sets the strategy of the Dapr runtime to prefix the keys.
+	KeyTemplateMetadataKey = "keyTemplate"
+
+	// Placeholders of the keyTemplate template.
+	KeyPlaceholderNamespace = "{namespace}"
+	KeyPlaceholderAppID     = "{appid}"
+	KeyPlaceholderStoreName = "{storename}"
+	KeyPlaceholderKey       = "{key}"
+
+	// Metadata of the component with the strategy of the Dapr runtime to prefix the keys.
+	keyPrefixMetadataKey = "keyPrefix"
+	// Strategies of the keyPrefix metadata that don't prefix the keys with the app ID.
+	keyPrefixNone = "none"
+	keyPrefixName = "name"
+
+	// Environment variable with the namespace of the app, set by the Dapr runtime in Kubernetes.
+	namespaceEnvVar = "NAMESPACE"
+)
+
+// SplitKey returns the app ID and the key of the key of a request, which is prefixed with the app ID as <app id>||<key>
+// unless the keyPrefix of the runtime is none. The app ID is empty if the key isn't prefixed.
+func SplitKey(key string) (appID string, k string) {
+	appID, k, found := strings.Cut(key, KeyDelimiter)
+	if !found {
+		return "", key
+	}
+	return appID, k
+}
+
+// KeyCodec builds the stored keys from the keys of the requests, following the keyTemplate metadata of the component,
+// e.g. "{namespace}/{appid}/{key}", so that several namespaces, apps or components can share a store.
+// The {namespace}, {storename} and {appid} placeholders are replaced by the namespace of the app, the name of the
+// component and the app ID of the key; the {key} placeholder, which is required, by the key without the app ID.
+// A template without the {appid} placeholder drops the app ID that the Dapr runtime prefixes the keys with, so that
+// all apps share the same keys; it's only accepted when the keyPrefix metadata of the component is none or name, so
+// that the keys aren't prefixed with the app ID in the first place.
+type KeyCodec struct {
+	// template is the keyTemplate metadata, with the namespace and the store name already replaced.
+	template string
+	// decoder matches the stored keys, with the app ID and the key as submatches.
+	decoder *regexp.Regexp
+}
+
+// NewKeyCodec returns the codec of the keyTemplate metadata of the component, or nil if the component has no template.
+// The keys are then stored as they are sent by the Dapr runtime, following its keyPrefix strategy.
+// A nil codec is valid and leaves the keys unchanged.
+func NewKeyCodec(meta Metadata) (*KeyCodec, error) {
+	template := meta.Properties[KeyTemplateMetadataKey]
+	if template == "" {
+		return nil, nil
+	}
+	if !strings.Contains(template, KeyPlaceholderAppID) {
+		switch strings.ToLower(meta.Properties[keyPrefixMetadataKey]) {
+		case keyPrefixNone, keyPrefixName:
+		default:
+			return nil, fmt.Errorf("invalid %s %s: without the %s placeholder, apps would share the same keys, which requires the %s metadata to be %s or %s",
+				KeyTemplateMetadataKey, template, KeyPlaceholderAppID, keyPrefixMetadataKey, keyPrefixNone, keyPrefixName)
+		}
+	}
+
+	return ParseKeyTemplate(template, meta.Name)
+}
+
+// ParseKeyTemplate returns the codec of a key template, for components that build the template from other metadata.
+// Unlike NewKeyCodec, it accepts templates without the {appid} placeholder regardless of the keyPrefix metadata.
+func ParseKeyTemplate(template string, storeName string) (*KeyCodec, error) {
+	original := template
+	if strings.Count(template, KeyPlaceholderKey) != 1 {
+		return nil, fmt.Errorf("invalid %s %s: it must contain the %s placeholder once", KeyTemplateMetadataKey, template, KeyPlaceholderKey)
+	}
+
+	if strings.Contains(template, KeyPlaceholderNamespace) {
+		namespace := os.Getenv(namespaceEnvVar)
+		if namespace == "" {
+			return nil, fmt.Errorf("the %s placeholder of the %s requires the %s environment variable", KeyPlaceholderNamespace, KeyTemplateMetadataKey, namespaceEnvVar)
+		}
+		template = strings.ReplaceAll(template, KeyPlaceholderNamespace, namespace)
+	}
+	if strings.Contains(template, KeyPlaceholderStoreName) {
+		if storeName == "" {
+			return nil, fmt.Errorf("the %s placeholder of the %s requires the name of the component", KeyPlaceholderStoreName, KeyTemplateMetadataKey)
+		}
+		template = strings.ReplaceAll(template, KeyPlaceholderStoreName, storeName)
+	}
+	rest := strings.ReplaceAll(strings.ReplaceAll(template, KeyPlaceholderKey, ""), KeyPlaceholderAppID, "")
+	if strings.Contains(rest, "{") {
+		return nil, fmt.Errorf("invalid %s %s: the accepted placeholders are %s, %s, %s and %s", KeyTemplateMetadataKey, original,
+			KeyPlaceholderNamespace, KeyPlaceholderAppID, KeyPlaceholderStoreName, KeyPlaceholderKey)
+	}
+
+	return &KeyCodec{template: template, decoder: keyDecoder(template)}, nil
+}
+
+// keyDecoder returns the regular expression of the stored keys of the template, with a group for each placeholder.
+// The app ID can't contain the delimiter of the app ID.
+func keyDecoder(template string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i, part := range strings.Split(template, KeyPlaceholderKey) {
+		if i > 0 {
+			expr.WriteString("(?P<key>.*)")
+		}
+		for j, literal := range strings.Split(part, KeyPlaceholderAppID) {
+			if j > 0 {
+				expr.WriteString("(?P<appid>[^|]*?)")
+			}
+			expr.WriteString(regexp.QuoteMeta(literal))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// Encode returns the stored key of the key of a request. It returns the key unchanged if the codec is nil.
+func (c *KeyCodec) Encode(key string) string {
+	if c == nil {
+		return key
+	}
+	appID, k := SplitKey(key)
+	return c.Format(appID, k)
+}
+
+// EncodeGet returns a copy of the request with the stored key of its key, or the request itself if the codec is nil.
+func (c *KeyCodec) EncodeGet(req *GetRequest) *GetRequest {
+	if c == nil {
+		return req
+	}
+	encoded := *req
+	encoded.Key = c.Encode(req.Key)
+	return &encoded
+}
+
+// EncodeSet returns a copy of the request with the stored key of its key, or the request itself if the codec is nil.
+func (c *KeyCodec) EncodeSet(req *SetRequest) *SetRequest {
+	if c == nil {
+		return req
+	}
+	encoded := *req
+	encoded.Key = c.Encode(req.Key)
+	return &encoded
+}
+
+// EncodeDelete returns a copy of the request with the stored key of its key, or the request itself if the codec is nil.
+func (c *KeyCodec) EncodeDelete(req *DeleteRequest) *DeleteRequest {
+	if c == nil {
+		return req
+	}
+	encoded := *req
+	encoded.Key = c.Encode(req.Key)
+	return &encoded
+}
+
+// EncodePartialUpdate returns a copy of the request with the stored key of its key, or the request itself if the codec
+// is nil.
+func (c *KeyCodec) EncodePartialUpdate(req *PartialUpdateRequest) *PartialUpdateRequest {
+	if c == nil {
+		return req
+	}
+	encoded := *req
+	encoded.Key = c.Encode(req.Key)
+	return &encoded
+}
+
+// Decode returns the key of a request of a stored key, prefixed with the app ID as <app id>||<key> if the template
+// contains it. It returns false if the stored key doesn't follow the template: the keys of other namespaces, apps or
+// components sharing the store are skipped this way. It returns the key unchanged if the codec is nil.
+func (c *KeyCodec) Decode(stored string) (string, bool) {
+	if c == nil {
+		return stored, true
+	}
+	match := c.decoder.FindStringSubmatch(stored)
+	if match == nil {
+		return "", false
+	}
+
+	// Each {appid} placeholder is the same app ID
+	var appID, key string
+	appIDFound := false
+	for i, name := range c.decoder.SubexpNames() {
+		switch name {
+		case "appid":
+			if !appIDFound {
+				appID = match[i]
+				appIDFound = true
+			} else if match[i] != appID {
+				return "", false
+			}
+		case "key":
+			key = match[i]
+		}
+	}
+	if appID == "" {
+		return key, true
+	}
+	return appID + KeyDelimiter + key, true
+}
+
+// DecodeItems decodes the keys of the items of a query in place, and removes the items whose stored key doesn't follow
+// the template.
+func (c *KeyCodec) DecodeItems(items []QueryItem) []QueryItem {
+	if c == nil {
+		return items
+	}
+	decoded := items[:0]
+	for _, item := range items {
+		if key, ok := c.Decode(item.Key); ok {
+			item.Key = key
+			decoded = append(decoded, item)
+		}
+	}
+	return decoded
+}
+
+// Format returns the stored key of an app ID and of a key without the app ID.
+func (c *KeyCodec) Format(appID string, key string) string {
+	before, after, _ := strings.Cut(c.template, KeyPlaceholderKey)
+	before = strings.ReplaceAll(before, KeyPlaceholderAppID, appID)
+	after = strings.ReplaceAll(after, KeyPlaceholderAppID, appID)
+	return before + key + after
+}
+
+// Pattern returns a regular expression matching the stored keys, for queries to only return the items of the template
+// from the backend, so that pages aren't shortened by DecodeItems. It only uses the syntax common to POSIX extended
+// regular expressions and PCRE. It can't check that the {appid} placeholders are the same app ID, so the keys of the
+// items must still be decoded. It returns an empty string if the codec is nil.
+func (c *KeyCodec) Pattern() string {
+	if c == nil {
+		return ""
+	}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i, part := range strings.Split(c.template, KeyPlaceholderKey) {
+		if i > 0 {
+			expr.WriteString(".*")
+		}
+		for j, literal := range strings.Split(part, KeyPlaceholderAppID) {
+			if j > 0 {
+				expr.WriteString("[^|]*")
+			}
+			expr.WriteString(regexp.QuoteMeta(literal))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+// FixedPrefix returns the part of the template before its first placeholder, which all the stored keys start with, for
+// the backends which can only limit queries to a prefix of the keys. It returns an empty string if the codec is nil.
+func (c *KeyCodec) FixedPrefix() string {
+	if c == nil {
+		return ""
+	}
+	prefix, _, _ := strings.Cut(c.template, KeyPlaceholderKey)
+	prefix, _, _ = strings.Cut(prefix, KeyPlaceholderAppID)
+	return prefix
+}
+
+// Prefix returns the prefix of all the stored keys, which listings of the keys are limited to.
+// It fails if the stored keys don't all have the same prefix followed by the key, as listings can't be decoded.
+func (c *KeyCodec) Prefix() (string, error) {
+	if strings.Contains(c.template, KeyPlaceholderAppID) {
+		return "", errors.New("listing the keys is not supported when the keys are prefixed with the app ID")
+	}
+	if !strings.HasSuffix(c.template, KeyPlaceholderKey) {
+		return "", fmt.Errorf("listing the keys requires a %s ending with %s", KeyTemplateMetadataKey, KeyPlaceholderKey)
+	}
+	return strings.TrimSuffix(c.template, KeyPlaceholderKey), nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+func TestSplitKey(t *testing.T) {
+	appID, key := SplitKey("app||key")
+	assert.Equal(t, "app", appID)
+	assert.Equal(t, "key", key)
+
+	appID, key = SplitKey("key")
+	assert.Empty(t, appID)
+	assert.Equal(t, "key", key)
+
+	appID, key = SplitKey("app||key||suffix")
+	assert.Equal(t, "app", appID)
+	assert.Equal(t, "key||suffix", key)
+}
+
+func TestKeyCodec(t *testing.T) {
+	newCodec := func(template string) (*KeyCodec, error) {
+		return NewKeyCodec(Metadata{Base: metadata.Base{
+			Name:       "statestore",
+			Properties: map[string]string{KeyTemplateMetadataKey: template, "keyPrefix": "none"},
+		}})
+	}
+
+	t.Run("templates", func(t *testing.T) {
+		t.Setenv("NAMESPACE", "prod")
+
+		tests := []struct {
+			template  string
+			encoded   string
+			noAppID   string
+			prefix    string
+			prefixErr bool
+		}{
+			{template: "{namespace}/{appid}/{key}", encoded: "prod/app/key", noAppID: "prod//key", prefixErr: true},
+			{template: "{storename}:{key}", encoded: "statestore:key", noAppID: "statestore:key", prefix: "statestore:"},
+			{template: "{key}", encoded: "key", noAppID: "key"},
+			{template: "{namespace}/{key}.json", encoded: "prod/key.json", noAppID: "prod/key.json", prefixErr: true},
+			{template: "{appid}-{key}.json", encoded: "app-key.json", noAppID: "-key.json", prefixErr: true},
+		}
+		for _, tt := range tests {
+			c, err := newCodec(tt.template)
+			require.NoError(t, err, tt.template)
+			assert.Equal(t, tt.encoded, c.Encode("app||key"), tt.template)
+			assert.Equal(t, tt.noAppID, c.Encode("key"), tt.template)
+
+			key, ok := c.Decode(tt.encoded)
+			require.True(t, ok, tt.template)
+			if strings.Contains(tt.template, KeyPlaceholderAppID) {
+				assert.Equal(t, "app||key", key, tt.template)
+			} else {
+				assert.Equal(t, "key", key, tt.template)
+			}
+
+			prefix, err := c.Prefix()
+			if tt.prefixErr {
+				assert.Error(t, err, tt.template)
+			} else {
+				require.NoError(t, err, tt.template)
+				assert.Equal(t, tt.prefix, prefix, tt.template)
+			}
+		}
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		c, err := newCodec("{appid}/{key}.{appid}")
+		require.NoError(t, err)
+		assert.Equal(t, `^[^|]*/.*\.[^|]*$`, c.Pattern())
+		assert.Empty(t, c.FixedPrefix())
+		key, ok := c.Decode("app/a/b.app")
+		require.True(t, ok)
+		assert.Equal(t, "app||a/b", key)
+
+		_, ok = c.Decode("app/key.other")
+		assert.False(t, ok)
+		_, ok = c.Decode("key")
+		assert.False(t, ok)
+	})
+
+	t.Run("requests and items", func(t *testing.T) {
+		c, err := newCodec("{storename}/{key}")
+		require.NoError(t, err)
+
+		get := &GetRequest{Key: "key"}
+		assert.Equal(t, "statestore/key", c.EncodeGet(get).Key)
+		assert.Equal(t, "statestore/key", c.EncodeSet(&SetRequest{Key: "key"}).Key)
+		assert.Equal(t, "statestore/key", c.EncodeDelete(&DeleteRequest{Key: "key"}).Key)
+		assert.Equal(t, "statestore/key", c.EncodePartialUpdate(&PartialUpdateRequest{Key: "key"}).Key)
+		// The request isn't modified
+		assert.Equal(t, "key", get.Key)
+
+		items := c.DecodeItems([]QueryItem{{Key: "statestore/a"}, {Key: "other/b"}, {Key: "statestore/c"}})
+		assert.Equal(t, []QueryItem{{Key: "a"}, {Key: "c"}}, items)
+
+		assert.Equal(t, "statestore/", c.FixedPrefix())
+		pattern := regexp.MustCompile(c.Pattern())
+		assert.True(t, pattern.MatchString("statestore/a"))
+		assert.False(t, pattern.MatchString("other/b"))
+
+		var nilCodec *KeyCodec
+		assert.Empty(t, nilCodec.Pattern())
+		assert.Same(t, get, nilCodec.EncodeGet(get))
+		assert.Equal(t, []QueryItem{{Key: "other/b"}}, nilCodec.DecodeItems([]QueryItem{{Key: "other/b"}}))
+	})
+
+	t.Run("without template", func(t *testing.T) {
+		c, err := newCodec("")
+		require.NoError(t, err)
+		assert.Nil(t, c)
+		assert.Equal(t, "app||key", c.Encode("app||key"))
+		key, ok := c.Decode("app||key")
+		require.True(t, ok)
+		assert.Equal(t, "app||key", key)
+	})
+
+	t.Run("keyPrefix strategy of the runtime", func(t *testing.T) {
+		c, err := NewKeyCodec(Metadata{Base: metadata.Base{
+			Name:       "statestore",
+			Properties: map[string]string{"keyPrefix": "name"},
+		}})
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("template without app ID", func(t *testing.T) {
+		newCodecWithPrefix := func(keyPrefix string) (*KeyCodec, error) {
+			return NewKeyCodec(Metadata{Base: metadata.Base{
+				Name:       "statestore",
+				Properties: map[string]string{KeyTemplateMetadataKey: "{storename}/{key}", "keyPrefix": keyPrefix},
+			}})
+		}
+
+		for _, keyPrefix := range []string{"", "appid", "namespace", "custom"} {
+			_, err := newCodecWithPrefix(keyPrefix)
+			assert.ErrorContains(t, err, KeyPlaceholderAppID, keyPrefix)
+		}
+		for _, keyPrefix := range []string{"none", "name", "NAME"} {
+			c, err := newCodecWithPrefix(keyPrefix)
+			require.NoError(t, err, keyPrefix)
+			assert.Equal(t, "statestore/key", c.Encode("key"), keyPrefix)
+		}
+
+		c, err := ParseKeyTemplate("{storename}/{key}", "statestore")
+		require.NoError(t, err)
+		assert.Equal(t, "statestore/key", c.Encode("app||key"))
+	})
+
+	t.Run("invalid templates", func(t *testing.T) {
+		for _, template := range []string{"{appid}/", "{key}/{key}", "{tenant}/{key}", "appid"} {
+			_, err := newCodec(template)
+			assert.Error(t, err, template)
+		}
+	})
+
+	t.Run("namespace without environment variable", func(t *testing.T) {
+		t.Setenv("NAMESPACE", "")
+		_, err := newCodec("{namespace}/{key}")
+		assert.ErrorContains(t, err, "NAMESPACE")
+	})
+
+	t.Run("store name without component name", func(t *testing.T) {
+		_, err := NewKeyCodec(Metadata{Base: metadata.Base{
+			Properties: map[string]string{KeyTemplateMetadataKey: "{storename}/{key}", "keyPrefix": "none"},
+		}})
+		assert.Error(t, err)
+	})
+}
@@ -40,9 +40,10 @@ const (
 
 type Memcached struct {
 	state.DefaultBulkStore
-	client *memcache.Client
-	json   jsoniter.API
-	logger logger.Logger
+	client   *memcache.Client
+	json     jsoniter.API
+	keyCodec *state.KeyCodec
+	logger   logger.Logger
 }
 
 type memcachedMetadata struct {
@@ -66,6 +67,9 @@ func (m *Memcached) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if m.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	client := memcache.New(meta.Hosts...)
 	if meta.Timeout < 0 {
@@ -155,9 +159,9 @@ func (m *Memcached) Set(req *state.SetRequest) error {
 
 	bt, _ = utils.Marshal(req.Value, m.json.Marshal)
 	if ttl != nil {
-		err = m.client.Set(&memcache.Item{Key: req.Key, Value: bt, Expiration: *ttl})
+		err = m.client.Set(&memcache.Item{Key: m.keyCodec.Encode(req.Key), Value: bt, Expiration: *ttl})
 	} else {
-		err = m.client.Set(&memcache.Item{Key: req.Key, Value: bt})
+		err = m.client.Set(&memcache.Item{Key: m.keyCodec.Encode(req.Key), Value: bt})
 	}
 	if err != nil {
 		return fmt.Errorf("failed to set key %s: %s", req.Key, err)
@@ -167,7 +171,7 @@ func (m *Memcached) Set(req *state.SetRequest) error {
 }
 
 func (m *Memcached) Delete(req *state.DeleteRequest) error {
-	err := m.client.Delete(req.Key)
+	err := m.client.Delete(m.keyCodec.Encode(req.Key))
 	if err != nil {
 		if err == memcache.ErrCacheMiss {
 			return nil
@@ -179,7 +183,7 @@ func (m *Memcached) Delete(req *state.DeleteRequest) error {
 }
 
 func (m *Memcached) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	item, err := m.client.Get(req.Key)
+	item, err := m.client.Get(m.keyCodec.Encode(req.Key))
 	if err != nil {
 		// Return nil for status 204
 		if errors.Is(err, memcache.ErrCacheMiss) {
@@ -200,3 +204,10 @@ func (m *Memcached) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (m *Memcached) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(m.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
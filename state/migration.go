@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const defaultMigrationBatchSize = 100
+
+// Exporter is implemented by state stores that can list all their keys, to migrate them to another state store.
+type Exporter interface {
+	// Export returns a page of the items of the store, starting at the position of the token.
+	// The items may be returned more than once when the store changes during the export.
+	Export(req *ExportRequest) (*ExportResponse, error)
+}
+
+// Importer is implemented by state stores that can write exported items as they are, keeping their ETags.
+type Importer interface {
+	Import(items []ExportItem) error
+}
+
+// ExportRequest is the request of a page of exported items.
+type ExportRequest struct {
+	// Token of the page, empty for the first page.
+	Token string `json:"token,omitempty"`
+	// Maximum number of items of the page; stores may return fewer or, when they can't split their pages, more items.
+	Limit int `json:"limit,omitempty"`
+}
+
+// ExportResponse is a page of exported items.
+type ExportResponse struct {
+	Items []ExportItem `json:"items"`
+	// Token of the next page, empty when the export is complete.
+	Token string `json:"token,omitempty"`
+}
+
+// ExportItem is an item of a state store, with the raw value as it's returned by Get.
+type ExportItem struct {
+	Key         string            `json:"key"`
+	Data        []byte            `json:"data"`
+	ETag        *string           `json:"etag,omitempty"`
+	ContentType *string           `json:"contentType,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Checkpoint is the progress of a migration.
+type Checkpoint struct {
+	// Token of the next page to export.
+	Token string `json:"token,omitempty"`
+	// Done is true when all the items have been migrated.
+	Done bool `json:"done,omitempty"`
+	// Number of items migrated so far.
+	Migrated int `json:"migrated"`
+}
+
+// Checkpointer persists the checkpoints of a migration, so that it can resume where it stopped.
+type Checkpointer interface {
+	// Load returns the last checkpoint, or nil if there is none.
+	Load() (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+}
+
+// FileCheckpointer saves the checkpoints of a migration to a JSON file.
+type FileCheckpointer struct {
+	Path string
+}
+
+// Load returns the checkpoint of the file, or nil if the file doesn't exist.
+func (f FileCheckpointer) Load() (*Checkpoint, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c Checkpoint
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", f.Path, err)
+	}
+	return &c, nil
+}
+
+// Save replaces the file with the checkpoint. The file is replaced atomically, so it is never left half-written.
+func (f FileCheckpointer) Save(checkpoint *Checkpoint) error {
+	b, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// MigrateOptions are the options of Migrate.
+type MigrateOptions struct {
+	// Number of items exported at once; defaults to 100.
+	BatchSize int
+	// Checkpointer saves the progress after each batch. Without it, the migration can't be resumed.
+	Checkpointer Checkpointer
+}
+
+// MigrateResult is the result of Migrate.
+type MigrateResult struct {
+	// Number of items migrated, including the ones of the previous runs.
+	Migrated int
+	// Resumed is true when the migration resumed from a checkpoint.
+	Resumed bool
+}
+
+// Migrate copies all the items of a state store to another one, by batches.
+// The items are written with Import when the destination is an Importer, which keeps the ETags;
+// otherwise they are written with BulkSet, and the destination generates new ETags.
+// After each batch, the progress is saved with the checkpointer, and Migrate resumes from the last checkpoint.
+// The items are written without concurrency checks: items that already exist in the destination are overwritten.
+func Migrate(ctx context.Context, src Exporter, dst Store, opts MigrateOptions) (MigrateResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultMigrationBatchSize
+	}
+
+	var (
+		res        MigrateResult
+		checkpoint = &Checkpoint{}
+	)
+	if opts.Checkpointer != nil {
+		c, err := opts.Checkpointer.Load()
+		if err != nil {
+			return res, fmt.Errorf("failed to load the migration checkpoint: %w", err)
+		}
+		if c != nil {
+			checkpoint = c
+			res.Resumed = true
+		}
+	}
+	res.Migrated = checkpoint.Migrated
+
+	importer, _ := dst.(Importer)
+	for !checkpoint.Done {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		page, err := src.Export(&ExportRequest{Token: checkpoint.Token, Limit: opts.BatchSize})
+		if err != nil {
+			return res, fmt.Errorf("failed to export state: %w", err)
+		}
+		if len(page.Items) > 0 {
+			if importer != nil {
+				err = importer.Import(page.Items)
+			} else {
+				err = dst.BulkSet(toSetRequests(page.Items))
+			}
+			if err != nil {
+				return res, fmt.Errorf("failed to import state: %w", err)
+			}
+		}
+
+		checkpoint = &Checkpoint{
+			Token:    page.Token,
+			Done:     page.Token == "",
+			Migrated: checkpoint.Migrated + len(page.Items),
+		}
+		res.Migrated = checkpoint.Migrated
+		if opts.Checkpointer != nil {
+			err = opts.Checkpointer.Save(checkpoint)
+			if err != nil {
+				return res, fmt.Errorf("failed to save the migration checkpoint: %w", err)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func toSetRequests(items []ExportItem) []SetRequest {
+	reqs := make([]SetRequest, len(items))
+	for i, item := range items {
+		reqs[i] = SetRequest{
+			Key:         item.Key,
+			Value:       item.Data,
+			Metadata:    item.Metadata,
+			ContentType: item.ContentType,
+		}
+	}
+	return reqs
+}
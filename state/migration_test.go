@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExporter exports numbered items, with the index of the next item as token.
+type fakeExporter struct {
+	items  []ExportItem
+	failAt int
+}
+
+func (f *fakeExporter) Export(req *ExportRequest) (*ExportResponse, error) {
+	start := 0
+	if req.Token != "" {
+		start, _ = strconv.Atoi(req.Token)
+	}
+	if f.failAt > 0 && start >= f.failAt {
+		return nil, errors.New("export failed")
+	}
+	end := start + req.Limit
+	if end >= len(f.items) {
+		return &ExportResponse{Items: f.items[start:]}, nil
+	}
+	return &ExportResponse{Items: f.items[start:end], Token: strconv.Itoa(end)}, nil
+}
+
+// fakeStore records the requests of BulkSet; fakeImporter also records the items of Import.
+type fakeStore struct {
+	Store
+	set      []SetRequest
+	imported []ExportItem
+}
+
+func (f *fakeStore) BulkSet(req []SetRequest) error {
+	f.set = append(f.set, req...)
+	return nil
+}
+
+type fakeImporter struct {
+	fakeStore
+}
+
+func (f *fakeImporter) Import(items []ExportItem) error {
+	f.imported = append(f.imported, items...)
+	return nil
+}
+
+func newExporter(n int) *fakeExporter {
+	f := &fakeExporter{}
+	for i := 0; i < n; i++ {
+		etag := strconv.Itoa(i)
+		f.items = append(f.items, ExportItem{Key: fmt.Sprintf("key-%d", i), Data: []byte(etag), ETag: &etag})
+	}
+	return f
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("with BulkSet", func(t *testing.T) {
+		dst := &fakeStore{}
+		res, err := Migrate(context.Background(), newExporter(5), dst, MigrateOptions{BatchSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, MigrateResult{Migrated: 5}, res)
+		require.Len(t, dst.set, 5)
+		assert.Equal(t, "key-4", dst.set[4].Key)
+		assert.Equal(t, []byte("4"), dst.set[4].Value)
+		assert.Nil(t, dst.set[4].ETag)
+	})
+
+	t.Run("with Import", func(t *testing.T) {
+		dst := &fakeImporter{}
+		res, err := Migrate(context.Background(), newExporter(5), dst, MigrateOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 5, res.Migrated)
+		require.Len(t, dst.imported, 5)
+		assert.Equal(t, "4", *dst.imported[4].ETag)
+		assert.Empty(t, dst.set)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := Migrate(ctx, newExporter(5), &fakeStore{}, MigrateOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestMigrateCheckpoints(t *testing.T) {
+	checkpointer := FileCheckpointer{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+	c, err := checkpointer.Load()
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	src := newExporter(7)
+	src.failAt = 4
+	dst := &fakeStore{}
+	res, err := Migrate(context.Background(), src, dst, MigrateOptions{BatchSize: 2, Checkpointer: checkpointer})
+	require.Error(t, err)
+	assert.Equal(t, 4, res.Migrated)
+
+	c, err = checkpointer.Load()
+	require.NoError(t, err)
+	assert.Equal(t, &Checkpoint{Token: "4", Migrated: 4}, c)
+
+	// The migration resumes after the last batch
+	src.failAt = 0
+	res, err = Migrate(context.Background(), src, dst, MigrateOptions{BatchSize: 2, Checkpointer: checkpointer})
+	require.NoError(t, err)
+	assert.Equal(t, MigrateResult{Migrated: 7, Resumed: true}, res)
+	require.Len(t, dst.set, 7)
+	assert.Equal(t, "key-4", dst.set[4].Key)
+
+	// A complete migration isn't run again
+	dst.set = nil
+	res, err = Migrate(context.Background(), src, dst, MigrateOptions{BatchSize: 2, Checkpointer: checkpointer})
+	require.NoError(t, err)
+	assert.Equal(t, 7, res.Migrated)
+	assert.Empty(t, dst.set)
+}
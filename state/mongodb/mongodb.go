@@ -32,6 +32,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 
+	"github.com/dapr/components-contrib/internal/redact"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
@@ -76,6 +77,7 @@ type MongoDB struct {
 	collection       *mongo.Collection
 	operationTimeout time.Duration
 	metadata         mongoDBMetadata
+	keyCodec         *state.KeyCodec
 
 	features []state.Feature
 	logger   logger.Logger
@@ -113,13 +115,23 @@ func NewMongoDB(logger logger.Logger) state.Store {
 }
 
 // Init establishes connection to the store based on the metadata.
-func (m *MongoDB) Init(metadata state.Metadata) error {
+func (m *MongoDB) Init(metadata state.Metadata) (err error) {
+	// The errors of the driver may quote the connection string and its password
+	redactor := redact.New(metadata.Properties, nil)
+	m.logger = redactor.Logger(m.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	meta, err := getMongoDBMetaData(metadata)
 	if err != nil {
 		return err
 	}
 
 	m.operationTimeout = meta.OperationTimeout
+	if m.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	client, err := getMongoDBClient(meta)
 	if err != nil {
@@ -191,14 +203,15 @@ func (m *MongoDB) setInternal(ctx context.Context, req *state.SetRequest) error
 	}
 
 	// create a document based on request key and value
-	filter := bson.M{id: req.Key}
+	key := m.keyCodec.Encode(req.Key)
+	filter := bson.M{id: key}
 	if req.ETag != nil {
 		filter[etag] = *req.ETag
 	} else if req.Options.Concurrency == state.FirstWrite {
 		filter[etag] = uuid.NewString()
 	}
 
-	update := bson.M{"$set": bson.M{id: req.Key, value: v, etag: uuid.NewString()}}
+	update := bson.M{"$set": bson.M{id: key, value: v, etag: uuid.NewString()}}
 	_, err := m.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 
 	return err
@@ -211,7 +224,7 @@ func (m *MongoDB) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.operationTimeout)
 	defer cancel()
 
-	filter := bson.M{id: req.Key}
+	filter := bson.M{id: m.keyCodec.Encode(req.Key)}
 	err := m.collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -223,8 +236,20 @@ func (m *MongoDB) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		return &state.GetResponse{}, err
 	}
 
-	var data []byte
-	switch obj := result.Value.(type) {
+	data, err := getDataFromValue(result.Value)
+	if err != nil {
+		return &state.GetResponse{}, err
+	}
+
+	return &state.GetResponse{
+		Data: data,
+		ETag: ptr.Of(result.Etag),
+	}, nil
+}
+
+// getDataFromValue returns the JSON data of the value of a document.
+func getDataFromValue(v interface{}) (data []byte, err error) {
+	switch obj := v.(type) {
 	case string:
 		data = []byte(obj)
 	case primitive.D:
@@ -236,31 +261,28 @@ func (m *MongoDB) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		// A decimal value stored as BSON will be returned as {"d": 5.5} if canonical is set to false instead of
 		// {"d": {"$numberDouble": 5.5}} when canonical JSON is returned.
 		if data, err = bson.MarshalExtJSON(obj, false, true); err != nil {
-			return &state.GetResponse{}, err
+			return nil, err
 		}
 	case primitive.A:
 		newobj := bson.D{{Key: value, Value: obj}}
 
 		if data, err = bson.MarshalExtJSON(newobj, false, true); err != nil {
-			return &state.GetResponse{}, err
+			return nil, err
 		}
 		var input interface{}
 		json.Unmarshal(data, &input)
 		value := input.(map[string]interface{})[value]
 		if data, err = json.Marshal(value); err != nil {
-			return &state.GetResponse{}, err
+			return nil, err
 		}
 
 	default:
-		if data, err = json.Marshal(result.Value); err != nil {
-			return &state.GetResponse{}, err
+		if data, err = json.Marshal(obj); err != nil {
+			return nil, err
 		}
 	}
 
-	return &state.GetResponse{
-		Data: data,
-		ETag: ptr.Of(result.Etag),
-	}, nil
+	return data, nil
 }
 
 // Delete performs a delete operation.
@@ -277,7 +299,7 @@ func (m *MongoDB) Delete(req *state.DeleteRequest) error {
 }
 
 func (m *MongoDB) deleteInternal(ctx context.Context, req *state.DeleteRequest) error {
-	filter := bson.M{id: req.Key}
+	filter := bson.M{id: m.keyCodec.Encode(req.Key)}
 	if req.ETag != nil {
 		filter[etag] = *req.ETag
 	}
@@ -340,7 +362,7 @@ func (m *MongoDB) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.operationTimeout)
 	defer cancel()
 
-	q := &Query{}
+	q := &Query{keyPattern: m.keyCodec.Pattern()}
 	qbuilder := query.NewQueryBuilder(q)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
@@ -351,7 +373,7 @@ func (m *MongoDB) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	}
 
 	return &state.QueryResponse{
-		Results: data,
+		Results: m.keyCodec.DecodeItems(data),
 		Token:   token,
 	}, nil
 }
@@ -417,6 +439,8 @@ func getMongoDBMetaData(meta state.Metadata) (*mongoDBMetadata, error) {
 		return nil, errors.New("'host' or 'server' fields are mutually exclusive")
 	}
 
+	// operationTimeout was a metadata of MongoDB before it was shared with other components, and accepts any duration
+	// as it did then, rather than only the positive durations of metadata.GetOperationTimeout.
 	var err error
 	if val, ok := meta.Properties[operationTimeout]; ok && val != "" {
 		m.OperationTimeout, err = time.ParseDuration(val)
@@ -471,3 +495,10 @@ func (m *MongoDB) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (m *MongoDB) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(m.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+// ConfigurationStore is a configuration store for the documents of a MongoDB state store, so the same collection
+// serves both the state and the configuration of an application. The subscriptions use MongoDB change streams,
+// which require a replica set or a sharded cluster.
+type ConfigurationStore struct {
+	store *MongoDB
+	// Cancel functions of the subscriptions, by ID
+	subscriptions sync.Map
+
+	logger logger.Logger
+}
+
+// changeEvent is the event of a change stream of the collection.
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		Key string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *Item `bson:"fullDocument"`
+}
+
+// NewMongoDBConfigurationStore returns a new MongoDB configuration store.
+func NewMongoDBConfigurationStore(logger logger.Logger) configuration.Store {
+	return &ConfigurationStore{
+		store:  NewMongoDB(logger).(*MongoDB),
+		logger: logger,
+	}
+}
+
+// Init establishes connection to the store based on the metadata, which is the same as the state store's.
+func (c *ConfigurationStore) Init(metadata configuration.Metadata) error {
+	return c.store.Init(state.Metadata{Base: metadata.Base})
+}
+
+// Get returns the items of the keys, or all the items if no key is requested.
+func (c *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	filter := bson.M{}
+	if len(req.Keys) > 0 {
+		filter[id] = bson.M{"$in": req.Keys}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.store.operationTimeout)
+	defer cancel()
+
+	cursor, err := c.store.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb configuration store: error finding items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := map[string]*configuration.Item{}
+	for cursor.Next(ctx) {
+		var doc Item
+		if err = cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("mongodb configuration store: error decoding item: %w", err)
+		}
+		item, err := toConfigurationItem(&doc)
+		if err != nil {
+			return nil, fmt.Errorf("mongodb configuration store: error reading item %s: %w", doc.Key, err)
+		}
+		items[doc.Key] = item
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, fmt.Errorf("mongodb configuration store: error finding items: %w", err)
+	}
+
+	return &configuration.GetResponse{
+		Items: items,
+	}, nil
+}
+
+// Subscribe opens a change stream of the keys, or of all the keys if none is requested, and calls the handler with
+// the items of each change. The items of the deleted keys have an empty value and version.
+func (c *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	stream, err := c.store.collection.Watch(ctx, changeStreamPipeline(req.Keys), opts)
+	if err != nil {
+		return "", fmt.Errorf("mongodb configuration store: error opening change stream: %w", err)
+	}
+
+	subscribeID := uuid.New().String()
+	ctx, cancel := context.WithCancel(ctx)
+	c.subscriptions.Store(subscribeID, cancel)
+	go c.doSubscribe(ctx, stream, handler, subscribeID)
+
+	return subscribeID, nil
+}
+
+// Unsubscribe closes the change stream of the subscription.
+func (c *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	if cancel, ok := c.subscriptions.LoadAndDelete(req.ID); ok {
+		cancel.(context.CancelFunc)()
+		return nil
+	}
+	return fmt.Errorf("subscription with id %s does not exist", req.ID)
+}
+
+func (c *ConfigurationStore) doSubscribe(ctx context.Context, stream *mongo.ChangeStream, handler configuration.UpdateHandler, id string) {
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			c.logger.Errorf("mongodb configuration store: error decoding change event: %s", err)
+			continue
+		}
+		item, err := toConfigurationItem(event.FullDocument)
+		if err != nil {
+			c.logger.Errorf("mongodb configuration store: error reading item %s: %s", event.DocumentKey.Key, err)
+			continue
+		}
+		err = handler(ctx, &configuration.UpdateEvent{
+			ID:    id,
+			Items: map[string]*configuration.Item{event.DocumentKey.Key: item},
+		})
+		if err != nil {
+			c.logger.Errorf("fail to call handler to notify event for configuration update subscribe: %s", err)
+		}
+	}
+	if ctx.Err() == nil {
+		c.logger.Errorf("mongodb configuration store: change stream of subscription %s closed: %s", id, stream.Err())
+		c.subscriptions.Delete(id)
+	}
+}
+
+// changeStreamPipeline returns the pipeline of a change stream of the changes of the keys, or of all the keys.
+func changeStreamPipeline(keys []string) mongo.Pipeline {
+	match := bson.D{
+		{Key: "operationType", Value: bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}},
+	}
+	if len(keys) > 0 {
+		match = append(match, bson.E{Key: "documentKey._id", Value: bson.M{"$in": keys}})
+	}
+
+	return mongo.Pipeline{{{Key: "$match", Value: match}}}
+}
+
+// toConfigurationItem returns the configuration item of a document, which is an empty item if the document is nil
+// because it was deleted. String values are returned without the quotes the state store saves them with.
+func toConfigurationItem(doc *Item) (*configuration.Item, error) {
+	item := &configuration.Item{
+		Metadata: map[string]string{},
+	}
+	if doc == nil {
+		return item, nil
+	}
+
+	data, err := getDataFromValue(doc.Value)
+	if err != nil {
+		return nil, err
+	}
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		item.Value = s
+	} else {
+		item.Value = string(data)
+	}
+	item.Version = doc.Etag
+
+	return item, nil
+}
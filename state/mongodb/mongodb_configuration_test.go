@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/kit/logger"
+)
+
+func TestChangeStreamPipeline(t *testing.T) {
+	operations := bson.E{Key: "operationType", Value: bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}}
+
+	assert.Equal(t, mongo.Pipeline{{{Key: "$match", Value: bson.D{operations}}}}, changeStreamPipeline(nil))
+	assert.Equal(t, mongo.Pipeline{{{Key: "$match", Value: bson.D{
+		operations,
+		{Key: "documentKey._id", Value: bson.M{"$in": []string{"a", "b"}}},
+	}}}}, changeStreamPipeline([]string{"a", "b"}))
+}
+
+func TestToConfigurationItem(t *testing.T) {
+	t.Run("string value", func(t *testing.T) {
+		item, err := toConfigurationItem(&Item{Key: "a", Value: `"blue"`, Etag: "1"})
+		require.NoError(t, err)
+		assert.Equal(t, "blue", item.Value)
+		assert.Equal(t, "1", item.Version)
+	})
+
+	t.Run("document value", func(t *testing.T) {
+		item, err := toConfigurationItem(&Item{Key: "a", Value: bson.D{{Key: "n", Value: int32(1)}}, Etag: "2"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"n":1}`, item.Value)
+		assert.Equal(t, "2", item.Version)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		item, err := toConfigurationItem(nil)
+		require.NoError(t, err)
+		assert.Empty(t, item.Value)
+		assert.Empty(t, item.Version)
+	})
+}
+
+func TestUnsubscribeUnknownID(t *testing.T) {
+	s := NewMongoDBConfigurationStore(logger.NewLogger("test"))
+	err := s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: "unknown"})
+	assert.Error(t, err)
+}
@@ -33,6 +33,8 @@ type Query struct {
 	query  string
 	filter interface{}
 	opts   *options.FindOptions
+	// $regex of the _id of the documents, from the keyTemplate metadata
+	keyPattern string
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -121,6 +123,12 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	} else if err := bson.UnmarshalExtJSON([]byte(filters), false, &q.filter); err != nil {
 		return err
 	}
+	if q.keyPattern != "" {
+		q.filter = bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: id, Value: bson.D{{Key: "$regex", Value: q.keyPattern}}}},
+			q.filter,
+		}}}
+	}
 	q.opts = options.Find()
 
 	// sorting
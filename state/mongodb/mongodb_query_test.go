@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/dapr/components-contrib/state/query"
 )
@@ -63,3 +65,13 @@ func TestMongoQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestMongoQueryKeyPattern(t *testing.T) {
+	q := &Query{keyPattern: "^statestore/.*$"}
+	err := query.NewQueryBuilder(q).BuildQuery(&query.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$and", Value: bson.A{
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$regex", Value: "^statestore/.*$"}}}},
+		bson.D{},
+	}}}, q.filter)
+}
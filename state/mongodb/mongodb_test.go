@@ -15,6 +15,7 @@ package mongodb
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -59,6 +60,33 @@ func TestGetMongoDBMetadata(t *testing.T) {
 		assert.Equal(t, properties[password], metadata.Password)
 	})
 
+	t.Run("With operation timeout", func(t *testing.T) {
+		properties := map[string]string{
+			host:             "127.0.0.2",
+			operationTimeout: "30s",
+		}
+		m := state.Metadata{
+			Base: metadata.Base{Properties: properties},
+		}
+
+		metadata, err := getMongoDBMetaData(m)
+		assert.Nil(t, err)
+		assert.Equal(t, 30*time.Second, metadata.OperationTimeout)
+	})
+
+	t.Run("Invalid operation timeout", func(t *testing.T) {
+		properties := map[string]string{
+			host:             "127.0.0.2",
+			operationTimeout: "30",
+		}
+		m := state.Metadata{
+			Base: metadata.Base{Properties: properties},
+		}
+
+		_, err := getMongoDBMetaData(m)
+		assert.NotNil(t, err)
+	})
+
 	t.Run("Missing hosts", func(t *testing.T) {
 		properties := map[string]string{
 			username: "username",
@@ -27,8 +27,10 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/dapr/components-contrib/internal/redact"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
 	"github.com/dapr/kit/logger"
 )
 
@@ -58,6 +60,21 @@ const (
 	// "%s:%s@tcp(%s:3306)/%s?allowNativePasswords=true&tls=custom",'myadmin@mydemoserver', 'yourpassword', 'mydemoserver.mysql.database.azure.com', 'targetdb'.
 	keyPemPath = "pemPath"
 
+	// The key name in the metadata for the storage mode of the values: "default"
+	// or "json". In JSON mode, byte values that are JSON documents are stored as
+	// documents in the JSON column rather than as base64 strings, so queries
+	// don't need to decode them and can use the JSON indexes.
+	keyStorageMode = "storageMode"
+
+	// The key name in the metadata for the JSON fields to index in JSON mode.
+	// This is a comma-separated list of paths such as "person.org,person.id:INT";
+	// each field is extracted to a generated column with an index on it.
+	keyJSONIndexes = "jsonIndexes"
+
+	// Storage modes of the values.
+	storageModeDefault = "default"
+	storageModeJSON    = "json"
+
 	// Used if the user does not configure a table name in the metadata.
 	defaultTableName = "state"
 
@@ -77,6 +94,9 @@ type MySQL struct {
 	schemaName       string
 	connectionString string
 	timeout          time.Duration
+	storageMode      string
+	jsonIndexes      []jsonIndex
+	keyCodec         *state.KeyCodec
 
 	// Instance of the database to issue commands to
 	db *sql.DB
@@ -93,6 +113,8 @@ type mySQLMetadata struct {
 	ConnectionString string
 	Timeout          int
 	PemPath          string
+	StorageMode      string
+	JSONIndexes      string
 }
 
 // NewMySQLStateStore creates a new instance of MySQL state store.
@@ -121,13 +143,23 @@ func newMySQLStateStore(logger logger.Logger, factory iMySQLFactory) *MySQL {
 // TransactionalStore
 // Populate the rest of the MySQL object by reading the metadata and opening
 // a connection to the server.
-func (m *MySQL) Init(metadata state.Metadata) error {
+func (m *MySQL) Init(metadata state.Metadata) (err error) {
+	// The errors of the MySQL driver may quote the DSN
+	redactor := redact.New(metadata.Properties, nil)
+	m.logger = redactor.Logger(m.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	m.logger.Debug("Initializing MySql state store")
 
-	err := m.parseMetadata(metadata.Properties)
+	err = m.parseMetadata(metadata.Properties)
 	if err != nil {
 		return err
 	}
+	if m.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	db, err := m.factory.Open(m.connectionString)
 	if err != nil {
@@ -190,12 +222,34 @@ func (m *MySQL) parseMetadata(md map[string]string) error {
 		m.timeout = time.Duration(defaultTimeoutInSeconds) * time.Second
 	}
 
+	// The standard operationTimeout takes precedence over timeoutInSeconds
+	m.timeout, err = metadata.GetOperationTimeout(md, m.timeout)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(meta.StorageMode) {
+	case "", storageModeDefault:
+		m.storageMode = storageModeDefault
+		if meta.JSONIndexes != "" {
+			return fmt.Errorf("%s requires the %s storage mode", keyJSONIndexes, storageModeJSON)
+		}
+	case storageModeJSON:
+		m.storageMode = storageModeJSON
+		m.jsonIndexes, err = parseJSONIndexes(meta.JSONIndexes)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("storage mode '%s' is not valid", meta.StorageMode)
+	}
+
 	return nil
 }
 
 // Features returns the features available in this state store.
 func (m *MySQL) Features() []state.Feature {
-	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI}
 }
 
 // Ping the database.
@@ -225,8 +279,14 @@ func (m *MySQL) finishInit(db *sql.DB) error {
 		return err
 	}
 
+	err = m.ensureStateTable(m.tableName)
+	if err != nil {
+		m.logger.Error(err)
+		return err
+	}
+
 	// will be nil if everything is good or an err that needs to be returned
-	return m.ensureStateTable(m.tableName)
+	return m.ensureJSONIndexes(m.tableName)
 }
 
 func (m *MySQL) ensureStateSchema() error {
@@ -304,6 +364,39 @@ func (m *MySQL) ensureStateTable(stateTableName string) error {
 	return nil
 }
 
+// ensureJSONIndexes adds the generated columns, and their indexes, of the JSON
+// indexes that don't exist yet.
+func (m *MySQL) ensureJSONIndexes(stateTableName string) error {
+	for _, idx := range m.jsonIndexes {
+		exists, err := columnExists(m.db, stateTableName, idx.column, m.timeout)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		m.logger.Infof("Creating MySql JSON index on '%s' in state table '%s'", idx.path, stateTableName)
+
+		// The generated column is virtual, so only the index is stored
+		// Note that stateTableName and the index are sanitized
+		//nolint:gosec
+		alterTable := fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN %s %s GENERATED ALWAYS AS (JSON_UNQUOTE(JSON_EXTRACT(value, %s))) VIRTUAL,
+			ADD INDEX %s_idx (%s);`,
+			stateTableName, idx.column, idx.columnType, jsonPathExpr(idx.path), idx.column, idx.column)
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		_, err = m.db.ExecContext(ctx, alterTable)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func schemaExists(db *sql.DB, schemaName string, timeout time.Duration) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -330,6 +423,19 @@ func tableExists(db *sql.DB, tableName string, timeout time.Duration) (bool, err
 	return exists == 1, err
 }
 
+func columnExists(db *sql.DB, tableName string, columnName string, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Returns 1 or 0 if the column exists or not
+	var exists int
+	query := `SELECT EXISTS (
+		SELECT COLUMN_NAME FROM information_schema.columns WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	) AS 'exists'`
+	err := db.QueryRowContext(ctx, query, tableName, columnName).Scan(&exists)
+	return exists == 1, err
+}
+
 // Delete removes an entity from the store
 // Store Interface.
 func (m *MySQL) Delete(req *state.DeleteRequest) error {
@@ -342,6 +448,7 @@ func (m *MySQL) deleteValue(querier querier, req *state.DeleteRequest) error {
 	if req.Key == "" {
 		return fmt.Errorf("missing key in delete operation")
 	}
+	req = m.keyCodec.EncodeDelete(req)
 
 	var (
 		err    error
@@ -413,6 +520,7 @@ func (m *MySQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	if req.Key == "" {
 		return nil, fmt.Errorf("missing key in get operation")
 	}
+	req = m.keyCodec.EncodeGet(req)
 
 	var (
 		eTag     string
@@ -438,17 +546,7 @@ func (m *MySQL) Get(req *state.GetRequest) (*state.GetResponse, error) {
 	}
 
 	if isBinary {
-		var (
-			s    string
-			data []byte
-		)
-
-		err = json.Unmarshal(value, &s)
-		if err != nil {
-			return nil, err
-		}
-
-		data, err = base64.StdEncoding.DecodeString(s)
+		data, err := decodeBinaryValue(value)
 		if err != nil {
 			return nil, err
 		}
@@ -484,6 +582,7 @@ func (m *MySQL) setValue(querier querier, req *state.SetRequest) error {
 	if req.Key == "" {
 		return errors.New("missing key in set operation")
 	}
+	req = m.keyCodec.EncodeSet(req)
 
 	var v any
 	isBinary := false
@@ -494,8 +593,13 @@ func (m *MySQL) setValue(querier querier, req *state.SetRequest) error {
 		}
 		v = x
 	case []uint8:
-		isBinary = true
-		v = base64.StdEncoding.EncodeToString(x)
+		if m.storageMode == storageModeJSON && json.Valid(x) {
+			// Store JSON documents as is, so they can be queried
+			v = json.RawMessage(x)
+		} else {
+			isBinary = true
+			v = base64.StdEncoding.EncodeToString(x)
+		}
 	default:
 		v = x
 	}
@@ -688,6 +792,31 @@ func (m *MySQL) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse,
 	return false, nil, nil
 }
 
+// Query executes a query against the store.
+// Querier Interface.
+func (m *MySQL) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
+	m.logger.Debug("Getting query value from MySql")
+
+	q := newQuery(m.tableName, m.storageMode, m.jsonIndexes)
+	q.keyPattern = m.keyCodec.Pattern()
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	data, token, err := q.execute(ctx, m)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: m.keyCodec.DecodeItems(data),
+		Token:   token,
+	}, nil
+}
+
 // Close implements io.Closer.
 func (m *MySQL) Close() error {
 	if m.db == nil {
@@ -720,6 +849,17 @@ func validIdentifier(v string) bool {
 	return true
 }
 
+// Decodes a binary value, which is stored as a base64 JSON string.
+func decodeBinaryValue(value []byte) ([]byte, error) {
+	var s string
+	err := json.Unmarshal(value, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(s)
+}
+
 // Interface for both sql.DB and sql.Tx
 type querier interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
@@ -733,3 +873,10 @@ func (m *MySQL) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (m *MySQL) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(m.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString},
+	}
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+const (
+	// Prefix of the generated columns that index JSON fields.
+	jsonIndexColumnPrefix = "jidx_"
+
+	// Type of the generated columns when the index doesn't specify one.
+	defaultJSONIndexType = "VARCHAR(255)"
+
+	// Maximum length of MySQL identifiers.
+	maxIdentifierLength = 64
+
+	// LIMIT value used when a query has an offset but no limit, as MySQL doesn't support OFFSET without LIMIT.
+	maxLimit = "18446744073709551615"
+
+	// In the default storage mode, byte values are stored as base64 strings, so queries read the values from a derived
+	// table where the binary values that decode to JSON documents are converted back to documents, and the other
+	// binary values to NULL.
+	defaultModeDocuments = "(SELECT id, value, eTag, isbinary, " +
+		"IF(isbinary, IF(JSON_VALID(CONVERT(FROM_BASE64(JSON_UNQUOTE(value)) USING utf8mb4)), " +
+		"CAST(CONVERT(FROM_BASE64(JSON_UNQUOTE(value)) USING utf8mb4) AS JSON), NULL), value) AS doc " +
+		"FROM %s) AS docs"
+)
+
+// Allowed types of the generated columns, such as INT, BIGINT UNSIGNED or VARCHAR(64).
+var jsonIndexTypeRegex = regexp.MustCompile(`^[A-Z]+( UNSIGNED)?(\([0-9]+\))?$`)
+
+// jsonIndex is a generated column that extracts a field of the JSON values, with an index on it.
+type jsonIndex struct {
+	path       string
+	column     string
+	columnType string
+}
+
+// parseJSONIndexes parses the jsonIndexes metadata property.
+// This is a comma-separated list of paths of JSON fields (such as "person.org"), each optionally followed by the
+// type of the generated column (such as "person.id:INT"); the default type is VARCHAR(255).
+func parseJSONIndexes(val string) ([]jsonIndex, error) {
+	var (
+		indexes []jsonIndex
+		columns = map[string]struct{}{}
+	)
+	for _, s := range strings.Split(val, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		path, columnType, found := strings.Cut(s, ":")
+		path = strings.TrimSpace(path)
+		columnType = strings.ToUpper(strings.TrimSpace(columnType))
+		if !found || columnType == "" {
+			columnType = defaultJSONIndexType
+		}
+		if !validJSONPath(path) {
+			return nil, fmt.Errorf("JSON index path '%s' is not valid", path)
+		}
+		if !jsonIndexTypeRegex.MatchString(columnType) {
+			return nil, fmt.Errorf("type '%s' of the JSON index on '%s' is not valid", columnType, path)
+		}
+
+		column := jsonIndexColumnPrefix + strings.ReplaceAll(path, ".", "_")
+		if len(column) > maxIdentifierLength {
+			return nil, fmt.Errorf("JSON index path '%s' is too long", path)
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("JSON index path '%s' is a duplicate", path)
+		}
+		columns[column] = struct{}{}
+
+		indexes = append(indexes, jsonIndex{
+			path:       path,
+			column:     column,
+			columnType: columnType,
+		})
+	}
+
+	return indexes, nil
+}
+
+// validJSONPath returns true if the path is made of valid identifiers separated by dots.
+func validJSONPath(path string) bool {
+	for _, p := range strings.Split(path, ".") {
+		if !validIdentifier(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonPathExpr returns the MySQL JSON path of a field of the values.
+// The path must be validated with validJSONPath.
+func jsonPathExpr(path string) string {
+	return "'$." + path + "'"
+}
+
+// Query is the builder of the state queries for MySQL, which filters the values of the JSON column with JSON_EXTRACT,
+// or with the generated columns of the fields that have a JSON index.
+type Query struct {
+	query   string
+	params  []any
+	limit   int
+	skip    *int64
+	indexes map[string]string
+	// Table or derived table the rows are read from, and column of the JSON documents in it.
+	from     string
+	docField string
+	// Regular expression of the ids matched with REGEXP, from the keyTemplate metadata
+	keyPattern string
+}
+
+func newQuery(tableName string, storageMode string, indexes []jsonIndex) *Query {
+	q := &Query{
+		from:     tableName,
+		docField: "value",
+		indexes:  make(map[string]string, len(indexes)),
+	}
+	if storageMode != storageModeJSON {
+		q.from = fmt.Sprintf(defaultModeDocuments, tableName)
+		q.docField = "doc"
+	}
+	for _, idx := range indexes {
+		q.indexes[idx.path] = idx.column
+	}
+	return q
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	return q.whereFieldEqual(f.Key, f.Val)
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	conds := make([]string, len(f.Vals))
+	for i, v := range f.Vals {
+		cond, err := q.whereFieldEqual(f.Key, v)
+		if err != nil {
+			return "", err
+		}
+		conds[i] = cond
+	}
+
+	return "(" + strings.Join(conds, " OR ") + ")", nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	var (
+		arr []string
+		str string
+		err error
+	)
+
+	for _, fil := range filters {
+		switch f := fil.(type) {
+		case *query.EQ:
+			if str, err = q.VisitEQ(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.IN:
+			if str, err = q.VisitIN(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.OR:
+			if str, err = q.VisitOR(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.AND:
+			if str, err = q.VisitAND(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		default:
+			return "", fmt.Errorf("unsupported filter type %#v", f)
+		}
+	}
+
+	return "(" + strings.Join(arr, " "+op+" ") + ")", nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	q.query = "SELECT id, value, eTag, isbinary FROM " + q.from
+
+	if q.keyPattern != "" {
+		// The parameters are positional, so the key filter comes after the other filters
+		q.params = append(q.params, q.keyPattern)
+		if filters != "" {
+			filters += " AND id REGEXP ?"
+		} else {
+			filters = "id REGEXP ?"
+		}
+	}
+	if filters != "" {
+		q.query += " WHERE " + filters
+	}
+
+	if len(qq.Sort) > 0 {
+		order := make([]string, len(qq.Sort))
+		for i, item := range qq.Sort {
+			field, err := q.field(item.Key)
+			if err != nil {
+				return err
+			}
+			if item.Order == query.DESC {
+				order[i] = field + " DESC"
+			} else {
+				order[i] = field + " ASC"
+			}
+		}
+		q.query += " ORDER BY " + strings.Join(order, ", ")
+	}
+
+	if qq.Page.Limit > 0 {
+		q.query += " LIMIT " + strconv.Itoa(qq.Page.Limit)
+		q.limit = qq.Page.Limit
+	}
+
+	if len(qq.Page.Token) != 0 {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil || skip < 0 {
+			return fmt.Errorf("invalid pagination token: %s", qq.Page.Token)
+		}
+		if q.limit == 0 {
+			q.query += " LIMIT " + maxLimit
+		}
+		q.query += " OFFSET " + strconv.FormatInt(skip, 10)
+		q.skip = &skip
+	}
+
+	return nil
+}
+
+func (q *Query) execute(ctx context.Context, m *MySQL) ([]state.QueryItem, string, error) {
+	rows, err := m.db.QueryContext(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ret := []state.QueryItem{}
+	for rows.Next() {
+		var (
+			key      string
+			data     []byte
+			eTag     string
+			isBinary bool
+		)
+		if err = rows.Scan(&key, &data, &eTag, &isBinary); err != nil {
+			return nil, "", err
+		}
+		if isBinary {
+			data, err = decodeBinaryValue(data)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+		ret = append(ret, state.QueryItem{
+			Key:  key,
+			Data: data,
+			ETag: &eTag,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var token string
+	if q.limit != 0 {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	}
+
+	return ret, token, nil
+}
+
+// field returns the expression of a field of the values: the generated column if the field has a JSON index, or
+// JSON_EXTRACT otherwise.
+func (q *Query) field(key string) (string, error) {
+	if !validJSONPath(key) {
+		return "", fmt.Errorf("invalid query key %q", key)
+	}
+	if column, ok := q.indexes[key]; ok {
+		return column, nil
+	}
+	return "JSON_EXTRACT(" + q.docField + ", " + jsonPathExpr(key) + ")", nil
+}
+
+func (q *Query) whereFieldEqual(key string, value any) (string, error) {
+	field, err := q.field(key)
+	if err != nil {
+		return "", err
+	}
+
+	b, isBool := value.(bool)
+	if isBool {
+		// The driver sends booleans as integers, which aren't equal to the JSON booleans
+		value = strconv.FormatBool(b)
+	}
+	q.params = append(q.params, value)
+
+	if isBool && !strings.HasPrefix(field, jsonIndexColumnPrefix) {
+		return field + " = CAST(? AS JSON)", nil
+	}
+	return field + " = ?", nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func TestMySQLQueryBuildQuery(t *testing.T) {
+	indexes, err := parseJSONIndexes("person.org,person.id:INT")
+	require.NoError(t, err)
+
+	tests := []struct {
+		input  string
+		query  string
+		params []any
+	}{
+		{
+			input: "../../tests/state/query/q1.json",
+			query: "SELECT id, value, eTag, isbinary FROM state LIMIT 2",
+		},
+		{
+			input:  "../../tests/state/query/q2.json",
+			query:  "SELECT id, value, eTag, isbinary FROM state WHERE JSON_EXTRACT(value, '$.state') = ? LIMIT 2",
+			params: []any{"CA"},
+		},
+		{
+			input:  "../../tests/state/query/q2-token.json",
+			query:  "SELECT id, value, eTag, isbinary FROM state WHERE JSON_EXTRACT(value, '$.state') = ? LIMIT 2 OFFSET 2",
+			params: []any{"CA"},
+		},
+		{
+			input:  "../../tests/state/query/q3.json",
+			query:  "SELECT id, value, eTag, isbinary FROM state WHERE (jidx_person_org = ? AND (JSON_EXTRACT(value, '$.state') = ? OR JSON_EXTRACT(value, '$.state') = ?)) ORDER BY JSON_EXTRACT(value, '$.state') DESC, JSON_EXTRACT(value, '$.person.name') ASC",
+			params: []any{"A", "CA", "WA"},
+		},
+		{
+			input:  "../../tests/state/query/q6.json",
+			query:  "SELECT id, value, eTag, isbinary FROM state WHERE (jidx_person_id = ? OR (jidx_person_org = ? AND (jidx_person_id = ? OR jidx_person_id = ?))) ORDER BY jidx_person_id ASC LIMIT 2",
+			params: []any{float64(123), "B", float64(567), float64(890)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+			var qq query.Query
+			err = json.Unmarshal(data, &qq)
+			require.NoError(t, err)
+
+			q := newQuery(defaultTableName, storageModeJSON, indexes)
+			err = query.NewQueryBuilder(q).BuildQuery(&qq)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, q.query)
+			assert.Equal(t, test.params, q.params)
+		})
+	}
+
+	t.Run("boolean values", func(t *testing.T) {
+		q := newQuery(defaultTableName, storageModeJSON, nil)
+		err := query.NewQueryBuilder(q).BuildQuery(&query.Query{
+			Filter: &query.EQ{Key: "active", Val: true},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT id, value, eTag, isbinary FROM state WHERE JSON_EXTRACT(value, '$.active') = CAST(? AS JSON)", q.query)
+		assert.Equal(t, []any{"true"}, q.params)
+	})
+
+	t.Run("default storage mode", func(t *testing.T) {
+		q := newQuery(defaultTableName, storageModeDefault, nil)
+		err := query.NewQueryBuilder(q).BuildQuery(&query.Query{
+			Filter: &query.EQ{Key: "person.org", Val: "A"},
+			QueryFields: query.QueryFields{
+				Sort: []query.Sorting{{Key: "person.id"}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT id, value, eTag, isbinary FROM (SELECT id, value, eTag, isbinary, "+
+			"IF(isbinary, IF(JSON_VALID(CONVERT(FROM_BASE64(JSON_UNQUOTE(value)) USING utf8mb4)), "+
+			"CAST(CONVERT(FROM_BASE64(JSON_UNQUOTE(value)) USING utf8mb4) AS JSON), NULL), value) AS doc "+
+			"FROM state) AS docs WHERE JSON_EXTRACT(doc, '$.person.org') = ? ORDER BY JSON_EXTRACT(doc, '$.person.id') ASC", q.query)
+		assert.Equal(t, []any{"A"}, q.params)
+	})
+
+	t.Run("key pattern", func(t *testing.T) {
+		q := newQuery(defaultTableName, storageModeJSON, nil)
+		q.keyPattern = "^statestore/.*$"
+		err := query.NewQueryBuilder(q).BuildQuery(&query.Query{
+			Filter: &query.EQ{Key: "state", Val: "CA"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT id, value, eTag, isbinary FROM state WHERE JSON_EXTRACT(value, '$.state') = ? AND id REGEXP ?", q.query)
+		assert.Equal(t, []any{"CA", "^statestore/.*$"}, q.params)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		q := newQuery(defaultTableName, storageModeJSON, nil)
+		err := query.NewQueryBuilder(q).BuildQuery(&query.Query{
+			Filter: &query.EQ{Key: "person.name') = 1 OR ('", Val: "x"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseJSONIndexes(t *testing.T) {
+	t.Run("valid indexes", func(t *testing.T) {
+		indexes, err := parseJSONIndexes(" person.org , person.id:int, score:DECIMAL(10)")
+		require.NoError(t, err)
+		assert.Equal(t, []jsonIndex{
+			{path: "person.org", column: "jidx_person_org", columnType: "VARCHAR(255)"},
+			{path: "person.id", column: "jidx_person_id", columnType: "INT"},
+			{path: "score", column: "jidx_score", columnType: "DECIMAL(10)"},
+		}, indexes)
+	})
+
+	invalid := []string{
+		"person..org",
+		"person.org:VARCHAR(255) NOT NULL",
+		"person.org;DROP TABLE state",
+		"person.org,person.org:INT",
+	}
+	for _, val := range invalid {
+		t.Run(val, func(t *testing.T) {
+			_, err := parseJSONIndexes(val)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseMetadataStorageMode(t *testing.T) {
+	t.Run("json mode", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		err := m.mySQL.parseMetadata(map[string]string{
+			keyConnectionString: fakeConnectionString,
+			keyStorageMode:      "json",
+			keyJSONIndexes:      "person.org",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, storageModeJSON, m.mySQL.storageMode)
+		assert.Len(t, m.mySQL.jsonIndexes, 1)
+	})
+
+	t.Run("default mode", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		err := m.mySQL.parseMetadata(map[string]string{
+			keyConnectionString: fakeConnectionString,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, storageModeDefault, m.mySQL.storageMode)
+		assert.Contains(t, m.mySQL.Features(), state.FeatureQueryAPI)
+	})
+
+	t.Run("indexes require json mode", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		err := m.mySQL.parseMetadata(map[string]string{
+			keyConnectionString: fakeConnectionString,
+			keyJSONIndexes:      "person.org",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		m, _ := mockDatabase(t)
+		defer m.mySQL.Close()
+
+		err := m.mySQL.parseMetadata(map[string]string{
+			keyConnectionString: fakeConnectionString,
+			keyStorageMode:      "xml",
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestEnsureJSONIndexes(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	var err error
+	m.mySQL.jsonIndexes, err = parseJSONIndexes("person.org,person.id:INT")
+	require.NoError(t, err)
+
+	m.mock1.ExpectQuery("SELECT EXISTS").WithArgs("state", "jidx_person_org").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	m.mock1.ExpectQuery("SELECT EXISTS").WithArgs("state", "jidx_person_id").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(0))
+	m.mock1.ExpectExec(`ALTER TABLE state\s+ADD COLUMN jidx_person_id INT GENERATED ALWAYS AS \(JSON_UNQUOTE\(JSON_EXTRACT\(value, '\$\.person\.id'\)\)\) VIRTUAL,\s+ADD INDEX jidx_person_id_idx \(jidx_person_id\);`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = m.mySQL.ensureJSONIndexes("state")
+	require.NoError(t, err)
+	assert.NoError(t, m.mock1.ExpectationsWereMet())
+}
+
+func TestSetJSONDocumentInJSONMode(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+	m.mySQL.storageMode = storageModeJSON
+
+	m.mock1.ExpectExec("INSERT INTO state").
+		WithArgs(`{"person":{"org":"A"}}`, "k1", sqlmock.AnyArg(), false, `{"person":{"org":"A"}}`, sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock1.ExpectExec("INSERT INTO state").
+		WithArgs(`"bm90IGpzb24="`, "k2", sqlmock.AnyArg(), true, `"bm90IGpzb24="`, sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := m.mySQL.Set(&state.SetRequest{Key: "k1", Value: []byte(`{"person": {"org": "A"}}`)})
+	require.NoError(t, err)
+	err = m.mySQL.Set(&state.SetRequest{Key: "k2", Value: []byte("not json")})
+	require.NoError(t, err)
+	assert.NoError(t, m.mock1.ExpectationsWereMet())
+}
+
+func TestQuery(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	t.Run("default mode decodes the binary values", func(t *testing.T) {
+		m.mySQL.storageMode = storageModeDefault
+		rows := sqlmock.NewRows([]string{"id", "value", "eTag", "isbinary"}).
+			AddRow("k1", []byte(`"eyJzdGF0ZSI6IkNBIn0="`), "etag1", true)
+		m.mock1.ExpectQuery(`SELECT id, value, eTag, isbinary FROM \(SELECT .* FROM state\) AS docs WHERE JSON_EXTRACT\(doc, '\$\.state'\) = \? LIMIT 2`).
+			WithArgs("CA").
+			WillReturnRows(rows)
+
+		res, err := m.mySQL.Query(&state.QueryRequest{
+			Query: query.Query{
+				QueryFields: query.QueryFields{Page: query.Pagination{Limit: 2}},
+				Filter:      &query.EQ{Key: "state", Val: "CA"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		assert.Equal(t, `{"state":"CA"}`, string(res.Results[0].Data))
+		assert.Equal(t, "1", res.Token)
+	})
+
+	t.Run("json mode", func(t *testing.T) {
+		m.mySQL.storageMode = storageModeJSON
+		rows := sqlmock.NewRows([]string{"id", "value", "eTag", "isbinary"}).
+			AddRow("k1", []byte(`{"state":"CA"}`), "etag1", false).
+			AddRow("k2", []byte(`"aGVsbG8="`), "etag2", true)
+		m.mock1.ExpectQuery(`SELECT id, value, eTag, isbinary FROM state WHERE JSON_EXTRACT\(value, '\$\.state'\) = \? LIMIT 2`).
+			WithArgs("CA").
+			WillReturnRows(rows)
+
+		res, err := m.mySQL.Query(&state.QueryRequest{
+			Query: query.Query{
+				QueryFields: query.QueryFields{Page: query.Pagination{Limit: 2}},
+				Filter:      &query.EQ{Key: "state", Val: "CA"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 2)
+		assert.Equal(t, "k1", res.Results[0].Key)
+		assert.Equal(t, `{"state":"CA"}`, string(res.Results[0].Data))
+		assert.Equal(t, "etag1", *res.Results[0].ETag)
+		assert.Equal(t, "hello", string(res.Results[1].Data))
+		assert.Equal(t, "2", res.Token)
+	})
+}
@@ -19,40 +19,38 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
 
-	"github.com/oracle/oci-go-sdk/v54/common"
-	"github.com/oracle/oci-go-sdk/v54/common/auth"
 	"github.com/oracle/oci-go-sdk/v54/objectstorage"
 
+	"github.com/dapr/components-contrib/internal/authentication/oci"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
 const (
-	keyDelimiter                       = "||"
-	instancePrincipalAuthenticationKey = "instancePrincipalAuthentication"
-	configFileAuthenticationKey        = "configFileAuthentication"
-	configFilePathKey                  = "configFilePath"
-	tenancyKey                         = "tenancyOCID"
+	instancePrincipalAuthenticationKey = oci.InstancePrincipalAuthenticationKey
+	configFileAuthenticationKey        = oci.ConfigFileAuthenticationKey
+	configFilePathKey                  = oci.ConfigFilePathKey
+	tenancyKey                         = oci.TenancyKey
 	compartmentKey                     = "compartmentOCID"
-	regionKey                          = "region"
-	fingerPrintKey                     = "fingerPrint"
-	privateKeyKey                      = "privateKey"
-	userKey                            = "userOCID"
+	regionKey                          = oci.RegionKey
+	fingerPrintKey                     = oci.FingerPrintKey
+	privateKeyKey                      = oci.PrivateKeyKey
+	userKey                            = oci.UserKey
 	bucketNameKey                      = "bucketName"
 	metadataTTLKey                     = "ttlInSeconds"
 	daprStateStoreMetaLabel            = "dapr-state-store"
 	expiryTimeMetaLabel                = "expiry-time-from-ttl"
 	isoDateTimeFormat                  = "2006-01-02T15:04:05"
+	// defaultTimeout is the timeout of the calls without the operationTimeout metadata, long enough to transfer large objects.
+	defaultTimeout = 5 * time.Minute
 )
 
 type StateStore struct {
@@ -62,21 +60,16 @@ type StateStore struct {
 	features []state.Feature
 	logger   logger.Logger
 	client   objectStoreClient
+	timeout  time.Duration
+	keyCodec *state.KeyCodec
 }
 
 type objectStoreMetadata struct {
-	UserOCID                        string
-	BucketName                      string
-	Region                          string
-	TenancyOCID                     string
-	FingerPrint                     string
-	PrivateKey                      string
-	CompartmentOCID                 string
-	Namespace                       string
-	ConfigFilePath                  string
-	ConfigFileProfile               string
-	InstancePrincipalAuthentication bool
-	ConfigFileAuthentication        bool
+	oci.Settings     `mapstructure:",squash"`
+	BucketName       string
+	CompartmentOCID  string
+	Namespace        string
+	OperationTimeout time.Duration
 
 	OCIObjectStorageClient *objectstorage.ObjectStorageClient
 }
@@ -107,6 +100,11 @@ func (r *StateStore) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	r.timeout = meta.OperationTimeout
+	r.keyCodec, err = state.NewKeyCodec(metadata)
+	if err != nil {
+		return err
+	}
 	r.client = &ociObjectStorageClient{
 		objectStorageClient: objectStorageClient{
 			objectStorageMetadata: meta,
@@ -180,22 +178,6 @@ func NewOCIObjectStorageStore(logger logger.Logger) state.Store {
 
 /************** private helper functions. */
 
-func getConfigFilePath(configFilePath string) (value string, err error) {
-	value = configFilePath
-	if strings.HasPrefix(value, "~/") {
-		return "", fmt.Errorf("%s is set to %s which starts with ~/; this is not supported - please provide absolute path to configuration file", configFilePathKey, value)
-	}
-	if value != "" {
-		if _, err = os.Stat(value); err != nil {
-			if os.IsNotExist(err) {
-				return "", fmt.Errorf("oci configuration file %s does not exist %w", value, err)
-			}
-			return "", fmt.Errorf("error %w with reading oci configuration file %s", err, value)
-		}
-	}
-	return value, nil
-}
-
 func getObjectStorageMetadata(meta map[string]string) (*objectStoreMetadata, error) {
 	m := objectStoreMetadata{}
 	errDecode := metadata.DecodeMetadata(meta, &m)
@@ -203,46 +185,20 @@ func getObjectStorageMetadata(meta map[string]string) (*objectStoreMetadata, err
 		return nil, errDecode
 	}
 
-	var err error
-
-	if m.ConfigFileAuthentication {
-		if m.ConfigFilePath, err = getConfigFilePath(m.ConfigFilePath); err != nil {
-			return nil, err
-		}
-	}
 	if m.BucketName == "" {
 		return nil, fmt.Errorf("missing or empty %s field from metadata", bucketNameKey)
 	}
 	if m.CompartmentOCID == "" {
 		return nil, fmt.Errorf("missing or empty %s field from metadata", compartmentKey)
 	}
-	externalAuthentication := m.InstancePrincipalAuthentication || m.ConfigFileAuthentication
-	if !externalAuthentication {
-		err = getIdentityAuthenticationDetails(m)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return &m, nil
-}
-
-func getIdentityAuthenticationDetails(meta objectStoreMetadata) (err error) {
-	if meta.Region == "" {
-		return fmt.Errorf("missing or empty %s field from metadata", regionKey)
-	}
-	if meta.UserOCID == "" {
-		return fmt.Errorf("missing or empty %s field from metadata", userKey)
-	}
-	if meta.FingerPrint == "" {
-		return fmt.Errorf("missing or empty %s field from metadata", fingerPrintKey)
+	if err := m.Settings.Validate(); err != nil {
+		return nil, err
 	}
-	if meta.PrivateKey == "" {
-		return fmt.Errorf("missing or empty %s field from metadata", privateKeyKey)
-	}
-	if meta.TenancyOCID == "" {
-		return fmt.Errorf("missing or empty %s field from metadata", tenancyKey)
+	var err error
+	if m.OperationTimeout, err = metadata.GetOperationTimeout(meta, defaultTimeout); err != nil {
+		return nil, err
 	}
-	return nil
+	return &m, nil
 }
 
 // functions that bridge from the Dapr State API to the OCI ObjectStorage Client.
@@ -254,6 +210,8 @@ func (r *StateStore) writeDocument(req *state.SetRequest) error {
 		r.logger.Debugf("when FirstWrite is to be enforced, a value must be provided for the ETag")
 		return fmt.Errorf("when FirstWrite is to be enforced, a value must be provided for the ETag")
 	}
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
 	metadata := (map[string]string{"category": daprStateStoreMetaLabel})
 
 	err := r.convertTTLtoExpiryTime(req, metadata)
@@ -262,10 +220,9 @@ func (r *StateStore) writeDocument(req *state.SetRequest) error {
 	}
 
 	r.logger.Debugf("Save state in OCI Object Storage Bucket under key %s ", req.Key)
-	objectName := getFileName(req.Key)
+	objectName := r.objectName(req.Key)
 	content := r.marshal(req)
 	objectLength := int64(len(content))
-	ctx := context.Background()
 	etag := req.ETag
 	if req.Options.Concurrency != state.FirstWrite {
 		etag = nil
@@ -298,8 +255,9 @@ func (r *StateStore) readDocument(req *state.GetRequest) ([]byte, *string, error
 	if len(req.Key) == 0 || req.Key == "" {
 		return nil, nil, fmt.Errorf("key for value to get was missing from request")
 	}
-	objectName := getFileName(req.Key)
-	ctx := context.Background()
+	objectName := r.objectName(req.Key)
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
 	content, etag, meta, err := r.client.getObject(ctx, objectName)
 	if err != nil {
 		r.logger.Debugf("download file %s, err %s", req.Key, err)
@@ -332,8 +290,9 @@ func (r *StateStore) deleteDocument(req *state.DeleteRequest) error {
 		return fmt.Errorf("key for value to delete was missing from request")
 	}
 
-	objectName := getFileName(req.Key)
-	ctx := context.Background()
+	objectName := r.objectName(req.Key)
+	ctx, cancel := metadata.ContextWithOperationTimeout(context.Background(), r.timeout)
+	defer cancel()
 	etag := req.ETag
 	if req.Options.Concurrency != state.FirstWrite {
 		etag = nil
@@ -361,13 +320,23 @@ func (r *StateStore) marshal(req *state.SetRequest) []byte {
 	return []byte(v)
 }
 
+// objectName returns the name of the object of a key, following the keyTemplate metadata of the component if any.
+func (r *StateStore) objectName(key string) string {
+	if r.keyCodec != nil {
+		return r.keyCodec.Encode(key)
+	}
+	return getFileName(key)
+}
+
+// getFileName returns the name of the object of a key without keyTemplate metadata: the key in a folder with the name of
+// the app ID.
 func getFileName(key string) string {
-	pr := strings.Split(key, keyDelimiter)
-	if len(pr) != 2 {
-		return pr[0]
+	appID, k := state.SplitKey(key)
+	if appID == "" {
+		return k
 	}
 
-	return path.Join(pr[0], pr[1])
+	return path.Join(appID, k)
 }
 
 func parseTTL(requestMetadata map[string]string) (*int, error) {
@@ -498,22 +467,17 @@ func (c *ociObjectStorageClient) initStorageBucket() error {
 }
 
 func (c *ociObjectStorageClient) initOCIObjectStorageClient() (*objectstorage.ObjectStorageClient, error) {
-	var configurationProvider common.ConfigurationProvider
-	if c.objectStorageMetadata.InstancePrincipalAuthentication {
+	switch {
+	case c.objectStorageMetadata.InstancePrincipalAuthentication:
 		c.logger.Debugf("instance principal authentication is used. ")
-		var err error
-		configurationProvider, err = auth.InstancePrincipalConfigurationProvider()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get oci configurationprovider based on instance principal authentication : %w", err)
-		}
-	} else {
-		if c.objectStorageMetadata.ConfigFileAuthentication {
-			c.logger.Debugf("configuration file based authentication is used with configuration file path %s and configuration profile %s. ", c.objectStorageMetadata.ConfigFilePath, c.objectStorageMetadata.ConfigFileProfile)
-			configurationProvider = common.CustomProfileConfigProvider(c.objectStorageMetadata.ConfigFilePath, c.objectStorageMetadata.ConfigFileProfile)
-		} else {
-			c.logger.Debugf("identity authentication is used with configuration provided through Dapr component configuration ")
-			configurationProvider = common.NewRawConfigurationProvider(c.objectStorageMetadata.TenancyOCID, c.objectStorageMetadata.UserOCID, c.objectStorageMetadata.Region, c.objectStorageMetadata.FingerPrint, c.objectStorageMetadata.PrivateKey, nil)
-		}
+	case c.objectStorageMetadata.ConfigFileAuthentication:
+		c.logger.Debugf("configuration file based authentication is used with configuration file path %s and configuration profile %s. ", c.objectStorageMetadata.ConfigFilePath, c.objectStorageMetadata.ConfigFileProfile)
+	default:
+		c.logger.Debugf("identity authentication is used with configuration provided through Dapr component configuration ")
+	}
+	configurationProvider, err := c.objectStorageMetadata.Settings.ConfigurationProvider()
+	if err != nil {
+		return nil, err
 	}
 
 	objectStorageClient, cerr := objectstorage.NewObjectStorageClientWithConfigurationProvider(configurationProvider)
@@ -546,3 +510,10 @@ func (r *StateStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (r *StateStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(r.Features()), metadata.CapabilityTTL),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileAccessKey, metadata.AuthProfileInstancePrincipal},
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
@@ -393,6 +394,17 @@ func TestGetFilename(t *testing.T) {
 		filename := getFileName("app-id-key")
 		assert.Equal(t, "app-id-key", filename)
 	})
+
+	t.Run("With keyTemplate", func(t *testing.T) {
+		s := StateStore{}
+		var err error
+		s.keyCodec, err = state.NewKeyCodec(state.Metadata{Base: metadata.Base{
+			Name:       "statestore",
+			Properties: map[string]string{"keyTemplate": "{storename}/{appid}/{key}.json"},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, "statestore/app-id/key.json", s.objectName("app-id||key"))
+	})
 }
 
 func TestParseTTL(t *testing.T) {
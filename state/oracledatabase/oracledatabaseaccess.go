@@ -46,6 +46,7 @@ type oracleDatabaseAccess struct {
 	metadata         oracleDatabaseMetadata
 	db               *sql.DB
 	connectionString string
+	keyCodec         *state.KeyCodec
 	tx               *sql.Tx
 }
 
@@ -84,6 +85,9 @@ func (o *oracleDatabaseAccess) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if o.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 	if o.metadata.ConnectionString != "" {
 		o.connectionString = meta.ConnectionString
 	} else {
@@ -139,6 +143,7 @@ func (o *oracleDatabaseAccess) Set(req *state.SetRequest) error {
 	if req.Key == "" {
 		return fmt.Errorf("missing key in set operation")
 	}
+	req = o.keyCodec.EncodeSet(req)
 
 	if v, ok := req.Value.(string); ok && v == "" {
 		return fmt.Errorf("empty string is not allowed in set operation")
@@ -234,6 +239,7 @@ func (o *oracleDatabaseAccess) Get(req *state.GetRequest) (*state.GetResponse, e
 	if req.Key == "" {
 		return nil, fmt.Errorf("missing key in get operation")
 	}
+	req = o.keyCodec.EncodeGet(req)
 	var value string
 	var binaryYN string
 	var etag string
@@ -273,6 +279,7 @@ func (o *oracleDatabaseAccess) Delete(req *state.DeleteRequest) error {
 	if req.Key == "" {
 		return fmt.Errorf("missing key in delete operation")
 	}
+	req = o.keyCodec.EncodeDelete(req)
 	if req.Options.Concurrency == state.FirstWrite && (req.ETag == nil || len(*req.ETag) == 0) {
 		o.logger.Debugf("when FirstWrite is to be enforced, a value must be provided for the ETag")
 		return fmt.Errorf("when FirstWrite is to be enforced, a value must be provided for the ETag")
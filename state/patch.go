@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// PatchType is the format of the patch document in a PartialUpdateRequest.
+type PatchType string
+
+const (
+	// JSONPatch is a JSON Patch document (RFC 6902). It's the default patch type.
+	JSONPatch PatchType = "application/json-patch+json"
+	// MergePatch is a JSON Merge Patch document (RFC 7396).
+	MergePatch PatchType = "application/merge-patch+json"
+)
+
+// PatchOp is the operation of a JSON Patch entry.
+type PatchOp string
+
+const (
+	PatchOpAdd     PatchOp = "add"
+	PatchOpRemove  PatchOp = "remove"
+	PatchOpReplace PatchOp = "replace"
+	PatchOpMove    PatchOp = "move"
+	PatchOpCopy    PatchOp = "copy"
+	PatchOpTest    PatchOp = "test"
+)
+
+// PatchOperation is a single entry of a JSON Patch document.
+type PatchOperation struct {
+	Op    PatchOp         `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ErrKeyNotFound is returned when the document targeted by a partial update doesn't exist.
+var ErrKeyNotFound = errors.New("key not found")
+
+// GetPatchType returns the patch type of the request, defaulting to JSONPatch.
+func (r PartialUpdateRequest) GetPatchType() (PatchType, error) {
+	switch r.PatchType {
+	case "", JSONPatch:
+		return JSONPatch, nil
+	case MergePatch:
+		return MergePatch, nil
+	default:
+		return "", fmt.Errorf("unsupported patch type %s", r.PatchType)
+	}
+}
+
+// ParseJSONPatch decodes and validates a JSON Patch document.
+func ParseJSONPatch(patch []byte) ([]PatchOperation, error) {
+	var ops []PatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %w", err)
+	}
+
+	for i, op := range ops {
+		switch op.Op {
+		case PatchOpAdd, PatchOpReplace, PatchOpTest:
+			if len(op.Value) == 0 {
+				return nil, fmt.Errorf("invalid JSON patch: operation %d (%s) requires a value", i, op.Op)
+			}
+		case PatchOpMove, PatchOpCopy:
+			if _, err := SplitJSONPointer(op.From); err != nil {
+				return nil, fmt.Errorf("invalid JSON patch: operation %d (%s): %w", i, op.Op, err)
+			}
+		case PatchOpRemove:
+		default:
+			return nil, fmt.Errorf("invalid JSON patch: operation %d has unsupported op '%s'", i, op.Op)
+		}
+		if _, err := SplitJSONPointer(op.Path); err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+
+	return ops, nil
+}
+
+// SplitJSONPointer returns the unescaped reference tokens of a JSON Pointer (RFC 6901).
+// The empty pointer, which references the whole document, returns no tokens.
+func SplitJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer '%s' must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// ApplyPatch applies the patch of the request to a JSON document and returns the patched document.
+// It's used by stores that can't apply patches natively, while guarding the update with an ETag.
+func ApplyPatch(doc []byte, req *PartialUpdateRequest) ([]byte, error) {
+	patchType, err := req.GetPatchType()
+	if err != nil {
+		return nil, err
+	}
+
+	if patchType == MergePatch {
+		res, err := jsonpatch.MergePatch(doc, req.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply merge patch: %w", err)
+		}
+
+		return res, nil
+	}
+
+	if _, err = ParseJSONPatch(req.Patch); err != nil {
+		return nil, err
+	}
+	patch, err := jsonpatch.DecodePatch(req.Patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %w", err)
+	}
+	res, err := patch.Apply(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply JSON patch: %w", err)
+	}
+
+	return res, nil
+}
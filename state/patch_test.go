@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitJSONPointer(t *testing.T) {
+	tokens, err := SplitJSONPointer("")
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	tokens, err = SplitJSONPointer("/a/b~1c/d~0e/0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b/c", "d~e", "0"}, tokens)
+
+	_, err = SplitJSONPointer("a/b")
+	assert.Error(t, err)
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	t.Run("valid patch", func(t *testing.T) {
+		ops, err := ParseJSONPatch([]byte(`[{"op":"add","path":"/a","value":1},{"op":"remove","path":"/b"},{"op":"move","from":"/c","path":"/d"}]`))
+		require.NoError(t, err)
+		require.Len(t, ops, 3)
+		assert.Equal(t, PatchOpAdd, ops[0].Op)
+		assert.Equal(t, "1", string(ops[0].Value))
+		assert.Equal(t, PatchOpRemove, ops[1].Op)
+		assert.Equal(t, "/c", ops[2].From)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		_, err := ParseJSONPatch([]byte(`[{"op":"replace","path":"/a"}]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported op", func(t *testing.T) {
+		_, err := ParseJSONPatch([]byte(`[{"op":"increment","path":"/a","value":1}]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		_, err := ParseJSONPatch([]byte(`[{"op":"remove","path":"a"}]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		_, err := ParseJSONPatch([]byte(`{"a":1}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := []byte(`{"name":"dapr","tags":["a"],"nested":{"x":1,"y":2}}`)
+
+	t.Run("json patch", func(t *testing.T) {
+		res, err := ApplyPatch(doc, &PartialUpdateRequest{
+			Patch: []byte(`[{"op":"replace","path":"/name","value":"components"},{"op":"add","path":"/tags/-","value":"b"},{"op":"remove","path":"/nested/y"}]`),
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"components","tags":["a","b"],"nested":{"x":1}}`, string(res))
+	})
+
+	t.Run("merge patch", func(t *testing.T) {
+		res, err := ApplyPatch(doc, &PartialUpdateRequest{
+			Patch:     []byte(`{"name":null,"nested":{"x":5,"z":3}}`),
+			PatchType: MergePatch,
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"tags":["a"],"nested":{"x":5,"y":2,"z":3}}`, string(res))
+	})
+
+	t.Run("failed test operation", func(t *testing.T) {
+		_, err := ApplyPatch(doc, &PartialUpdateRequest{
+			Patch: []byte(`[{"op":"test","path":"/name","value":"other"}]`),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported patch type", func(t *testing.T) {
+		_, err := ApplyPatch(doc, &PartialUpdateRequest{
+			Patch:     []byte(`[]`),
+			PatchType: "application/xml",
+		})
+		assert.Error(t, err)
+	})
+}
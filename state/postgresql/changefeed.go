@@ -0,0 +1,302 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	defaultChangeFeedSlot         = "dapr_state_changefeed"
+	defaultChangeFeedPollInterval = time.Second
+
+	// changeFeedBatchSize is the number of changes read from the replication slot at once.
+	// Transactions are always read whole, so a batch can have more changes.
+	changeFeedBatchSize = 100
+
+	changeOperationUpsert = "upsert"
+	changeOperationDelete = "delete"
+)
+
+// stateChangeEvent is the event published to the change feed topic for each change of the state table.
+type stateChangeEvent struct {
+	Key       string          `json:"key"`
+	Operation string          `json:"operation"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	IsBinary  bool            `json:"isBinary,omitempty"`
+}
+
+// wal2jsonChange is a change of the state table, in the format version 2 of the wal2json output plugin.
+type wal2jsonChange struct {
+	Action   string           `json:"action"`
+	Columns  []wal2jsonColumn `json:"columns"`
+	Identity []wal2jsonColumn `json:"identity"`
+}
+
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// changeFeed publishes the changes of the state table to a pubsub topic, including the changes made by other clients
+// of the database. It reads the changes from a logical replication slot using the wal2json output plugin, and only
+// consumes them from the slot once they're published, so changes are published at least once.
+type changeFeed struct {
+	db         *sql.DB
+	logger     logger.Logger
+	slot       string
+	tables     string
+	pubsub     pubsub.PubSub
+	pubsubName string
+	topic      string
+	interval   time.Duration
+	// Codec of the keyTemplate metadata of the state store, if any.
+	keyCodec *state.KeyCodec
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newChangeFeed(db *sql.DB, m postgresMetadataStruct, resolver pubsub.PubSubResolver, logger logger.Logger) (*changeFeed, error) {
+	if m.ChangeFeedTopic == "" {
+		return nil, errors.New("changeFeedTopic is required with changeFeedPubsub")
+	}
+	if resolver == nil {
+		return nil, errors.New("changeFeedPubsub requires a runtime that resolves the pubsub components")
+	}
+	ps, err := resolver(m.ChangeFeedPubsub)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving changeFeedPubsub %s: %w", m.ChangeFeedPubsub, err)
+	}
+	if m.ChangeFeedPollInterval <= 0 {
+		return nil, errors.New("changeFeedPollInterval must be greater than 0")
+	}
+
+	// wal2json requires a schema, so tables without a schema are matched in any schema
+	tables := m.TableName
+	if !strings.Contains(tables, ".") {
+		tables = "*." + tables
+	}
+
+	return &changeFeed{
+		db:         db,
+		logger:     logger,
+		slot:       m.ChangeFeedSlot,
+		tables:     tables,
+		pubsub:     ps,
+		pubsubName: m.ChangeFeedPubsub,
+		topic:      m.ChangeFeedTopic,
+		interval:   m.ChangeFeedPollInterval,
+		closeCh:    make(chan struct{}),
+	}, nil
+}
+
+// start creates the replication slot if it doesn't exist, and starts publishing the changes.
+func (f *changeFeed) start() error {
+	var exists bool
+	err := f.db.QueryRow("SELECT EXISTS (SELECT FROM pg_replication_slots WHERE slot_name = $1)", f.slot).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error reading replication slot %s: %w", f.slot, err)
+	}
+	if !exists {
+		f.logger.Infof("Creating PostgreSQL replication slot %s for the change feed", f.slot)
+		_, err = f.db.Exec("SELECT pg_create_logical_replication_slot($1, 'wal2json')", f.slot)
+		if err != nil {
+			return fmt.Errorf("error creating replication slot %s: %w", f.slot, err)
+		}
+	}
+
+	f.wg.Add(1)
+	go f.pollLoop()
+
+	return nil
+}
+
+// close stops publishing the changes. The replication slot is kept, so the changes made until the change feed
+// starts again are published then.
+func (f *changeFeed) close() {
+	close(f.closeCh)
+	f.wg.Wait()
+}
+
+func (f *changeFeed) pollLoop() {
+	defer f.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-f.closeCh
+		cancel()
+	}()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep reading while the slot has more changes than a batch
+			for {
+				n, err := f.poll(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						f.logger.Errorf("Error publishing the changes of the PostgreSQL state table: %v", err)
+					}
+					break
+				}
+				if n < changeFeedBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// poll publishes a batch of changes, and consumes them from the replication slot up to the last transaction whose
+// changes were all published. It returns the number of changes read.
+func (f *changeFeed) poll(ctx context.Context) (int, error) {
+	rows, err := f.db.QueryContext(ctx,
+		"SELECT lsn::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2, 'format-version', '2', 'add-tables', $3)",
+		f.slot, changeFeedBatchSize, f.tables)
+	if err != nil {
+		return 0, err
+	}
+	type walChange struct {
+		lsn  string
+		data string
+	}
+	var changes []walChange
+	for rows.Next() {
+		var c walChange
+		if err = rows.Scan(&c.lsn, &c.data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		changes = append(changes, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var committed string
+	for _, c := range changes {
+		var change wal2jsonChange
+		err = json.Unmarshal([]byte(c.data), &change)
+		var event *stateChangeEvent
+		if err == nil {
+			event, err = toStateChangeEvent(change)
+		}
+		if err == nil && event != nil {
+			var ok bool
+			if event.Key, ok = f.keyCodec.Decode(event.Key); ok {
+				err = f.publish(event)
+			}
+		}
+		if err != nil {
+			// The changes of the transaction are published again on the next poll
+			f.advance(ctx, committed)
+			return len(changes), fmt.Errorf("error publishing change at %s: %w", c.lsn, err)
+		}
+		// The LSN of a commit is the end of the transaction
+		if change.Action == "C" {
+			committed = c.lsn
+		}
+	}
+	f.advance(ctx, committed)
+
+	return len(changes), nil
+}
+
+// advance consumes the changes from the replication slot, up to the LSN.
+func (f *changeFeed) advance(ctx context.Context, lsn string) {
+	if lsn == "" {
+		return
+	}
+	_, err := f.db.ExecContext(ctx, "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", f.slot, lsn)
+	if err != nil {
+		f.logger.Warnf("Error advancing replication slot %s to %s, changes will be published again: %v", f.slot, lsn, err)
+	}
+}
+
+func (f *changeFeed) publish(event *stateChangeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return f.pubsub.Publish(&pubsub.PublishRequest{
+		Data:        data,
+		PubsubName:  f.pubsubName,
+		Topic:       f.topic,
+		ContentType: ptr.Of("application/json"),
+	})
+}
+
+// toStateChangeEvent returns the event of an insert, update or delete of a row of the state table,
+// or nil for the other changes, such as the beginning and end of transactions.
+func toStateChangeEvent(change wal2jsonChange) (*stateChangeEvent, error) {
+	var (
+		event   stateChangeEvent
+		columns []wal2jsonColumn
+	)
+	switch change.Action {
+	case "I", "U":
+		event.Operation = changeOperationUpsert
+		columns = change.Columns
+	case "D":
+		event.Operation = changeOperationDelete
+		columns = change.Identity
+	default:
+		return nil, nil
+	}
+
+	for _, col := range columns {
+		var err error
+		switch col.Name {
+		case "key":
+			err = json.Unmarshal(col.Value, &event.Key)
+		case "value":
+			// wal2json outputs jsonb values as strings
+			var value string
+			err = json.Unmarshal(col.Value, &value)
+			if err == nil && !json.Valid([]byte(value)) {
+				err = errors.New("invalid JSON")
+			}
+			event.Value = json.RawMessage(value)
+		case "isbinary":
+			err = json.Unmarshal(col.Value, &event.IsBinary)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s column: %w", col.Name, err)
+		}
+	}
+	if event.Key == "" {
+		return nil, errors.New("missing key column")
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+// fakePubSub records the published messages, and fails to publish after failAfter messages if it's set.
+type fakePubSub struct {
+	pubsub.PubSub
+	published []string
+	failAfter int
+}
+
+func (f *fakePubSub) Publish(req *pubsub.PublishRequest) error {
+	if f.failAfter > 0 && len(f.published) == f.failAfter {
+		return errors.New("broker unavailable")
+	}
+	f.published = append(f.published, string(req.Data))
+	return nil
+}
+
+func newTestChangeFeed(t *testing.T, ps *fakePubSub) (*changeFeed, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	f, err := newChangeFeed(db, postgresMetadataStruct{
+		TableName:              "state",
+		ChangeFeedPubsub:       "events",
+		ChangeFeedTopic:        "changes",
+		ChangeFeedSlot:         defaultChangeFeedSlot,
+		ChangeFeedPollInterval: time.Second,
+	}, pubsub.NewMapPubSubResolver(map[string]pubsub.PubSub{"events": ps}), logger.NewLogger("test"))
+	require.NoError(t, err)
+
+	return f, mock
+}
+
+func walRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"lsn", "data"}).
+		AddRow("0/1", `{"action":"B"}`).
+		AddRow("0/2", `{"action":"I","schema":"public","table":"state","columns":[{"name":"key","type":"text","value":"app||a"},{"name":"value","type":"jsonb","value":"{\"n\": 1}"},{"name":"isbinary","type":"boolean","value":false}]}`).
+		AddRow("0/3", `{"action":"C"}`).
+		AddRow("0/4", `{"action":"B"}`).
+		AddRow("0/5", `{"action":"D","schema":"public","table":"state","identity":[{"name":"key","type":"text","value":"app||b"}]}`).
+		AddRow("0/6", `{"action":"C"}`)
+}
+
+func TestChangeFeedPoll(t *testing.T) {
+	t.Run("publishes the changes and consumes them", func(t *testing.T) {
+		ps := &fakePubSub{}
+		f, mock := newTestChangeFeed(t, ps)
+		mock.ExpectQuery("pg_logical_slot_peek_changes").
+			WithArgs(defaultChangeFeedSlot, changeFeedBatchSize, "*.state").
+			WillReturnRows(walRows())
+		mock.ExpectExec("pg_replication_slot_advance").
+			WithArgs(defaultChangeFeedSlot, "0/6").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		n, err := f.poll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 6, n)
+		assert.Equal(t, []string{
+			`{"key":"app||a","operation":"upsert","value":{"n":1}}`,
+			`{"key":"app||b","operation":"delete"}`,
+		}, ps.published)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("consumes the transactions published before an error", func(t *testing.T) {
+		ps := &fakePubSub{failAfter: 1}
+		f, mock := newTestChangeFeed(t, ps)
+		mock.ExpectQuery("pg_logical_slot_peek_changes").WillReturnRows(walRows())
+		mock.ExpectExec("pg_replication_slot_advance").
+			WithArgs(defaultChangeFeedSlot, "0/3").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := f.poll(context.Background())
+		assert.ErrorContains(t, err, "0/5")
+		assert.Len(t, ps.published, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToStateChangeEvent(t *testing.T) {
+	event, err := toStateChangeEvent(wal2jsonChange{Action: "T"})
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	_, err = toStateChangeEvent(wal2jsonChange{Action: "U", Columns: []wal2jsonColumn{
+		{Name: "key", Value: []byte(`"a"`)},
+		{Name: "value", Value: []byte(`"{not json"`)},
+	}})
+	assert.Error(t, err)
+
+	_, err = toStateChangeEvent(wal2jsonChange{Action: "D"})
+	assert.ErrorContains(t, err, "missing key")
+}
+
+func TestNewChangeFeed(t *testing.T) {
+	m := postgresMetadataStruct{
+		TableName:              "myschema.state",
+		ChangeFeedPubsub:       "events",
+		ChangeFeedTopic:        "changes",
+		ChangeFeedPollInterval: time.Second,
+	}
+	resolver := pubsub.NewMapPubSubResolver(map[string]pubsub.PubSub{"events": &fakePubSub{}})
+
+	f, err := newChangeFeed(nil, m, resolver, logger.NewLogger("test"))
+	require.NoError(t, err)
+	assert.Equal(t, "myschema.state", f.tables)
+
+	_, err = newChangeFeed(nil, m, nil, logger.NewLogger("test"))
+	assert.Error(t, err)
+
+	m.ChangeFeedPubsub = "other"
+	_, err = newChangeFeed(nil, m, resolver, logger.NewLogger("test"))
+	assert.Error(t, err)
+
+	m.ChangeFeedPubsub = "events"
+	m.ChangeFeedTopic = ""
+	_, err = newChangeFeed(nil, m, resolver, logger.NewLogger("test"))
+	assert.Error(t, err)
+}
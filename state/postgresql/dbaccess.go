@@ -26,6 +26,8 @@ type dbAccess interface {
 	Delete(req *state.DeleteRequest) error
 	BulkDelete(req []state.DeleteRequest) error
 	ExecuteMulti(req *state.TransactionalStateRequest) error
+	PartialUpdate(req *state.PartialUpdateRequest) error
 	Query(req *state.QueryRequest) (*state.QueryResponse, error)
+	SchemaVersion() int
 	Close() error // io.Closer
 }
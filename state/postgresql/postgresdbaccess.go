@@ -14,23 +14,29 @@ limitations under the License.
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/dapr/components-contrib/internal/authentication/spiffe"
+	"github.com/dapr/components-contrib/internal/component/postgresql/migrations"
+	"github.com/dapr/components-contrib/internal/redact"
 	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
 	"github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
-
-	// Blank import for the underlying PostgreSQL driver.
-	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 const (
@@ -46,6 +52,11 @@ type postgresDBAccess struct {
 	db               *sql.DB
 	connectionString string
 	tableName        string
+	schemaVersion    int
+	keyCodec         *state.KeyCodec
+	pubsubResolver   pubsub.PubSubResolver
+	changeFeed       *changeFeed
+	spiffe           *spiffe.Config
 }
 
 // newPostgresDBAccess creates a new instance of postgresAccess.
@@ -60,19 +71,53 @@ func newPostgresDBAccess(logger logger.Logger) *postgresDBAccess {
 type postgresMetadataStruct struct {
 	ConnectionString      string
 	ConnectionMaxIdleTime time.Duration
-	TableName             string
+	ConnectionMaxLifetime time.Duration
+	MaxOpenConnections    int
+	MaxIdleConnections    int
+	// Executes the queries with the simple protocol and without prepared statements, for connection poolers in
+	// transaction pooling mode such as PgBouncer, where a session's prepared statements aren't available to the next transaction.
+	PgbouncerMode bool
+	// How the queries are executed, as the default_query_exec_mode of pgx: cache_statement (the default),
+	// cache_describe, describe_exec, exec or simple_protocol.
+	QueryExecMode     string
+	TableName         string
+	MetadataTableName string
+	// Name of the pubsub component and topic that the changes of the state table are published to, if set.
+	// The change feed reads the changes from a logical replication slot with the wal2json output plugin.
+	ChangeFeedPubsub       string
+	ChangeFeedTopic        string
+	ChangeFeedSlot         string
+	ChangeFeedPollInterval time.Duration
+}
+
+// SetPubSubResolver sets the resolver of the pubsub component of the change feed. Implements pubsub.CompositePubSub.
+func (p *postgresDBAccess) SetPubSubResolver(resolver pubsub.PubSubResolver) {
+	p.pubsubResolver = resolver
 }
 
 // Init sets up PostgreSQL connection and ensures that the state table exists.
-func (p *postgresDBAccess) Init(meta state.Metadata) error {
+func (p *postgresDBAccess) Init(meta state.Metadata) (err error) {
+	// pgx quotes the connection string when it can't parse it, and some of its errors keep the password
+	redactor := redact.New(meta.Properties, nil)
+	p.logger = redactor.Logger(p.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	p.logger.Debug("Initializing PostgreSQL state store")
 	m := postgresMetadataStruct{
-		TableName: defaultTableName,
+		TableName:              defaultTableName,
+		MetadataTableName:      migrations.DefaultMetadataTableName,
+		ChangeFeedSlot:         defaultChangeFeedSlot,
+		ChangeFeedPollInterval: defaultChangeFeedPollInterval,
 	}
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+	err = metadata.DecodeMetadata(meta.Properties, &m)
 	if err != nil {
 		return err
 	}
+	if !migrations.ValidTableName(m.MetadataTableName) {
+		return fmt.Errorf("invalid metadataTableName: %s", m.MetadataTableName)
+	}
 	p.metadata = m
 
 	if m.ConnectionString == "" {
@@ -81,8 +126,19 @@ func (p *postgresDBAccess) Init(meta state.Metadata) error {
 		return errors.New(errMissingConnectionString)
 	}
 	p.connectionString = m.ConnectionString
+	p.keyCodec, err = state.NewKeyCodec(meta)
+	if err != nil {
+		return err
+	}
 
-	db, err := sql.Open("pgx", p.connectionString)
+	config, err := parseConnConfig(m)
+	if err != nil {
+		p.logger.Error(err)
+
+		return err
+	}
+
+	db, spiffeConfig, err := openDB(config, meta.Properties)
 	if err != nil {
 		p.logger.Error(err)
 
@@ -90,6 +146,7 @@ func (p *postgresDBAccess) Init(meta state.Metadata) error {
 	}
 
 	p.db = db
+	p.spiffe = spiffeConfig
 
 	pingErr := db.Ping()
 	if pingErr != nil {
@@ -97,19 +154,104 @@ func (p *postgresDBAccess) Init(meta state.Metadata) error {
 	}
 
 	p.db.SetConnMaxIdleTime(m.ConnectionMaxIdleTime)
-	if err != nil {
-		return err
+	p.db.SetConnMaxLifetime(m.ConnectionMaxLifetime)
+	p.db.SetMaxOpenConns(m.MaxOpenConnections)
+	if m.MaxIdleConnections != 0 {
+		p.db.SetMaxIdleConns(m.MaxIdleConnections)
 	}
 
-	err = p.ensureStateTable(m.TableName)
+	err = p.migrateSchema(m.TableName)
 	if err != nil {
 		return err
 	}
 	p.tableName = m.TableName
 
+	if m.ChangeFeedPubsub != "" {
+		p.changeFeed, err = newChangeFeed(p.db, m, p.pubsubResolver, p.logger)
+		if err != nil {
+			return err
+		}
+		p.changeFeed.keyCodec = p.keyCodec
+		err = p.changeFeed.start()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// parseConnConfig parses the connection string, and applies the query execution mode of the metadata.
+func parseConnConfig(m postgresMetadataStruct) (*pgx.ConnConfig, error) {
+	config, err := pgx.ParseConfig(m.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.PgbouncerMode {
+		if m.QueryExecMode != "" && m.QueryExecMode != "simple_protocol" && m.QueryExecMode != "exec" {
+			return nil, fmt.Errorf("queryExecMode %s uses prepared statements, which aren't supported with pgbouncerMode", m.QueryExecMode)
+		}
+		config.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	}
+	switch m.QueryExecMode {
+	case "":
+	case "cache_statement":
+		config.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	case "cache_describe":
+		config.DefaultQueryExecMode = pgx.QueryExecModeCacheDescribe
+	case "describe_exec":
+		config.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+	case "exec":
+		config.DefaultQueryExecMode = pgx.QueryExecModeExec
+	case "simple_protocol":
+		config.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	default:
+		return nil, fmt.Errorf("invalid queryExecMode: %s", m.QueryExecMode)
+	}
+	if config.DefaultQueryExecMode == pgx.QueryExecModeExec || config.DefaultQueryExecMode == pgx.QueryExecModeSimpleProtocol {
+		// Don't cache prepared statements or their descriptions on the connection
+		config.StatementCacheCapacity = 0
+		config.DescriptionCacheCapacity = 0
+	}
+
+	return config, nil
+}
+
+// openDB opens the database, presenting the X.509 SVID of the workload as client certificate if the SPIFFE Workload API is configured.
+// The returned SPIFFE configuration, if any, must be closed with the database.
+func openDB(config *pgx.ConnConfig, properties map[string]string) (*sql.DB, *spiffe.Config, error) {
+	spiffeConfig, err := spiffe.Parse(properties)
+	if err != nil {
+		return nil, nil, err
+	}
+	if spiffeConfig == nil {
+		return stdlib.OpenDB(*config), nil, nil
+	}
+
+	if config.TLSConfig == nil {
+		return nil, nil, errors.New("spiffeEndpointSocket requires a connection string with an sslmode using TLS")
+	}
+	err = spiffeConfig.ConfigureTLS(config.TLSConfig)
+	if err == nil {
+		for _, fallback := range config.Fallbacks {
+			if fallback.TLSConfig != nil {
+				err = spiffeConfig.ConfigureTLS(fallback.TLSConfig)
+				if err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		spiffeConfig.Close()
+
+		return nil, nil, err
+	}
+
+	return stdlib.OpenDB(*config), spiffeConfig, nil
+}
+
 // Set makes an insert or update to the database.
 func (p *postgresDBAccess) Set(req *state.SetRequest) error {
 	p.logger.Debug("Setting state value in PostgreSQL")
@@ -122,6 +264,7 @@ func (p *postgresDBAccess) Set(req *state.SetRequest) error {
 	if req.Key == "" {
 		return errors.New("missing key in set operation")
 	}
+	req = p.keyCodec.EncodeSet(req)
 
 	if v, ok := req.Value.(string); ok && v == "" {
 		return errors.New("empty string is not allowed in set operation")
@@ -216,6 +359,7 @@ func (p *postgresDBAccess) Get(req *state.GetRequest) (*state.GetResponse, error
 	if req.Key == "" {
 		return nil, errors.New("missing key in get operation")
 	}
+	req = p.keyCodec.EncodeGet(req)
 
 	var (
 		value    []byte
@@ -265,6 +409,7 @@ func (p *postgresDBAccess) Delete(req *state.DeleteRequest) (err error) {
 	if req.Key == "" {
 		return errors.New("missing key in delete operation")
 	}
+	req = p.keyCodec.EncodeDelete(req)
 
 	var result sql.Result
 
@@ -371,13 +516,195 @@ func (p *postgresDBAccess) ExecuteMulti(request *state.TransactionalStateRequest
 	return err
 }
 
+// PartialUpdate patches a JSON document in place.
+// JSON Patch add, replace and remove operations are translated to jsonb_set, jsonb_insert and #- expressions,
+// while merge patches and the other JSON Patch operations are applied to the document read in the same transaction.
+func (p *postgresDBAccess) PartialUpdate(req *state.PartialUpdateRequest) (err error) {
+	p.logger.Debug("Partially updating state value in PostgreSQL")
+	if req.Key == "" {
+		return errors.New("missing key in partial update operation")
+	}
+	req = p.keyCodec.EncodePartialUpdate(req)
+
+	patchType, err := req.GetPatchType()
+	if err != nil {
+		return err
+	}
+	var ops []state.PatchOperation
+	if patchType == state.JSONPatch {
+		ops, err = state.ParseJSONPatch(req.Patch)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// The row is locked until the transaction completes, so concurrent updates can't interleave
+	var (
+		value    []byte
+		isBinary bool
+	)
+	if req.ETag == nil || *req.ETag == "" {
+		err = tx.QueryRow(fmt.Sprintf(
+			`SELECT value, isbinary FROM %s WHERE key = $1 FOR UPDATE`,
+			p.tableName), req.Key).Scan(&value, &isBinary)
+	} else {
+		// Convert req.ETag to uint32 for postgres XID compatibility
+		var etag64 uint64
+		etag64, err = strconv.ParseUint(*req.ETag, 10, 32)
+		if err != nil {
+			return state.NewETagError(state.ETagInvalid, err)
+		}
+
+		err = tx.QueryRow(fmt.Sprintf(
+			`SELECT value, isbinary FROM %s WHERE key = $1 AND xmin = $2 FOR UPDATE`,
+			p.tableName), req.Key, uint32(etag64)).Scan(&value, &isBinary)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if req.ETag != nil && *req.ETag != "" {
+				return state.NewETagError(state.ETagMismatch, nil)
+			}
+			return fmt.Errorf("%w: %s", state.ErrKeyNotFound, req.Key)
+		}
+		return err
+	}
+
+	if isBinary {
+		return fmt.Errorf("binary value of key %s can't be partially updated", req.Key)
+	}
+
+	if patchType == state.JSONPatch && isNativePatch(ops) {
+		for _, op := range ops {
+			err = p.execPatchOperation(tx, req.Key, op)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		var patched []byte
+		patched, err = state.ApplyPatch(value, req)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(fmt.Sprintf(
+			`UPDATE %s SET value = $1, updatedate = NOW() WHERE key = $2`,
+			p.tableName), string(patched), req.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// isNativePatch returns true if all the JSON Patch operations can be translated to jsonb functions.
+func isNativePatch(ops []state.PatchOperation) bool {
+	for _, op := range ops {
+		if op.Op != state.PatchOpAdd && op.Op != state.PatchOpReplace && op.Op != state.PatchOpRemove {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *postgresDBAccess) execPatchOperation(tx *sql.Tx, key string, op state.PatchOperation) error {
+	tokens, err := state.SplitJSONPointer(op.Path)
+	if err != nil {
+		return err
+	}
+
+	var (
+		query string
+		args  []interface{}
+	)
+	path := pgTextArray(tokens)
+	switch {
+	case len(tokens) == 0 && op.Op == state.PatchOpRemove:
+		return errors.New("failed to apply JSON patch: the whole document can't be removed")
+	case len(tokens) == 0:
+		query = `UPDATE %s SET value = $2, updatedate = NOW() WHERE key = $1`
+		args = []interface{}{key, string(op.Value)}
+	case op.Op == state.PatchOpAdd && tokens[len(tokens)-1] == "-":
+		// Append to the array
+		parent := pgTextArray(tokens[:len(tokens)-1])
+		last := pgTextArray(append(tokens[:len(tokens)-1:len(tokens)-1], "-1"))
+		query = `UPDATE %s SET value = jsonb_insert(value, $2::text::text[], $3::jsonb, true), updatedate = NOW()
+			WHERE key = $1 AND jsonb_typeof(value #> $4::text::text[]) = 'array'`
+		args = []interface{}{key, last, string(op.Value), parent}
+	case op.Op == state.PatchOpAdd:
+		// Insert into arrays, set the member of objects
+		parent := pgTextArray(tokens[:len(tokens)-1])
+		query = `UPDATE %s SET value = CASE WHEN jsonb_typeof(value #> $4::text::text[]) = 'array'
+				THEN jsonb_insert(value, $2::text::text[], $3::jsonb)
+				ELSE jsonb_set(value, $2::text::text[], $3::jsonb, true) END,
+			updatedate = NOW()
+			WHERE key = $1 AND jsonb_typeof(value #> $4::text::text[]) IN ('object', 'array')`
+		args = []interface{}{key, path, string(op.Value), parent}
+	case op.Op == state.PatchOpReplace:
+		query = `UPDATE %s SET value = jsonb_set(value, $2::text::text[], $3::jsonb, false), updatedate = NOW()
+			WHERE key = $1 AND value #> $2::text::text[] IS NOT NULL`
+		args = []interface{}{key, path, string(op.Value)}
+	case op.Op == state.PatchOpRemove:
+		query = `UPDATE %s SET value = value #- $2::text::text[], updatedate = NOW()
+			WHERE key = $1 AND value #> $2::text::text[] IS NOT NULL`
+		args = []interface{}{key, path}
+	default:
+		return fmt.Errorf("failed to apply JSON patch: unsupported op '%s'", op.Op)
+	}
+
+	result, err := tx.Exec(fmt.Sprintf(query, p.tableName), args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return fmt.Errorf("failed to apply JSON patch: path '%s' of %s operation doesn't exist", op.Path, op.Op)
+	}
+
+	return nil
+}
+
+// pgTextArray formats the strings as a PostgreSQL text array literal.
+func pgTextArray(values []string) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, v := range values {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`"`)
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(v, `\`, `\\`), `"`, `\"`))
+		b.WriteString(`"`)
+	}
+	b.WriteString("}")
+
+	return b.String()
+}
+
 // Query executes a query against store.
 func (p *postgresDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	p.logger.Debug("Getting query value from PostgreSQL")
 	q := &Query{
-		query:     "",
-		params:    []interface{}{},
-		tableName: p.tableName,
+		query:      "",
+		params:     []interface{}{},
+		tableName:  p.tableName,
+		keyPattern: p.keyCodec.Pattern(),
 	}
 	qbuilder := query.NewQueryBuilder(q)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
@@ -389,43 +716,67 @@ func (p *postgresDBAccess) Query(req *state.QueryRequest) (*state.QueryResponse,
 	}
 
 	return &state.QueryResponse{
-		Results: data,
+		Results: p.keyCodec.DecodeItems(data),
 		Token:   token,
 	}, nil
 }
 
 // Close implements io.Close.
 func (p *postgresDBAccess) Close() error {
+	if p.changeFeed != nil {
+		p.changeFeed.close()
+	}
+	var err error
 	if p.db != nil {
-		return p.db.Close()
+		err = p.db.Close()
+	}
+	if spiffeErr := p.spiffe.Close(); err == nil {
+		err = spiffeErr
 	}
 
-	return nil
+	return err
 }
 
-func (p *postgresDBAccess) ensureStateTable(stateTableName string) error {
-	exists, err := tableExists(p.db, stateTableName)
-	if err != nil {
-		return err
-	}
+// SchemaVersion returns the version of the schema of the state table, once migrated by Init.
+func (p *postgresDBAccess) SchemaVersion() int {
+	return p.schemaVersion
+}
 
-	if !exists {
-		p.logger.Info("Creating PostgreSQL state table")
-		createTable := fmt.Sprintf(`CREATE TABLE %s (
+// migrateSchema creates or upgrades the state table.
+// Tables created before the schema was versioned are at version 0, and are upgraded like new tables.
+func (p *postgresDBAccess) migrateSchema(stateTableName string) error {
+	m := migrations.Migrations{
+		DB:                p.db,
+		Logger:            p.logger,
+		MetadataTableName: p.metadata.MetadataTableName,
+		MetadataKey:       migrationsKey(stateTableName),
+	}
+	version, err := m.Perform(context.Background(), []migrations.MigrationFn{
+		// Migration 1: create the state table
+		func(ctx context.Context, tx *sql.Tx) error {
+			p.logger.Info("Creating PostgreSQL state table")
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
 									key text NOT NULL PRIMARY KEY,
 									value jsonb NOT NULL,
 									isbinary boolean NOT NULL,
 									insertdate TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName)
-		_, err = p.db.Exec(createTable)
-		if err != nil {
+									updatedate TIMESTAMP WITH TIME ZONE NULL);`, stateTableName))
 			return err
-		}
+		},
+	})
+	if err != nil {
+		return err
 	}
+	p.schemaVersion = version
 
 	return nil
 }
 
+// migrationsKey returns the key of the schema version of a state table in the metadata table.
+func migrationsKey(stateTableName string) string {
+	return "migrations-" + stateTableName
+}
+
 func tableExists(db *sql.DB, tableName string) (bool, error) {
 	exists := false
 	err := db.QueryRow("SELECT EXISTS (SELECT FROM pg_tables where tablename = $1)", tableName).Scan(&exists)
@@ -19,10 +19,13 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
 type mocks struct {
@@ -429,6 +432,132 @@ func TestValidBulkDelete(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestPartialUpdateJSONPatch(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.tableName = "state"
+
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value, isbinary FROM state WHERE key = \\$1 FOR UPDATE").
+		WithArgs("key1").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "isbinary"}).AddRow([]byte(`{"a":1,"b":[1]}`), false))
+	m.mock.ExpectExec("jsonb_set\\(value, \\$2::text::text\\[\\], \\$3::jsonb, false\\)").
+		WithArgs("key1", `{"a"}`, "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectExec("jsonb_insert\\(value, \\$2::text::text\\[\\], \\$3::jsonb, true\\)").
+		WithArgs("key1", `{"b","-1"}`, "2", `{"b"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectExec("value #- \\$2::text::text\\[\\]").
+		WithArgs("key1", `{"c","d\"e"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectCommit()
+
+	// Act
+	err := m.pgDba.PartialUpdate(&state.PartialUpdateRequest{
+		Key:   "key1",
+		Patch: []byte(`[{"op":"replace","path":"/a","value":2},{"op":"add","path":"/b/-","value":2},{"op":"remove","path":"/c/d\"e"}]`),
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestPartialUpdateMissingPath(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.tableName = "state"
+
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value, isbinary FROM state").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "isbinary"}).AddRow([]byte(`{}`), false))
+	m.mock.ExpectExec("UPDATE state").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectRollback()
+
+	// Act
+	err := m.pgDba.PartialUpdate(&state.PartialUpdateRequest{
+		Key:   "key1",
+		Patch: []byte(`[{"op":"remove","path":"/a"}]`),
+	})
+
+	// Assert
+	assert.NotNil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestPartialUpdateMergePatch(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.tableName = "state"
+
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value, isbinary FROM state WHERE key = \\$1 AND xmin = \\$2 FOR UPDATE").
+		WithArgs("key1", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "isbinary"}).AddRow([]byte(`{"a":1,"b":{"c":2}}`), false))
+	m.mock.ExpectExec("UPDATE state SET value = \\$1").
+		WithArgs(`{"a":1,"b":{"c":3}}`, "key1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectCommit()
+
+	// Act
+	err := m.pgDba.PartialUpdate(&state.PartialUpdateRequest{
+		Key:       "key1",
+		Patch:     []byte(`{"b":{"c":3}}`),
+		PatchType: state.MergePatch,
+		ETag:      ptr.Of("42"),
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
+
+func TestPartialUpdateETagMismatch(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.tableName = "state"
+
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value, isbinary FROM state").WillReturnError(sql.ErrNoRows)
+	m.mock.ExpectRollback()
+
+	// Act
+	err := m.pgDba.PartialUpdate(&state.PartialUpdateRequest{
+		Key:   "key1",
+		Patch: []byte(`[{"op":"remove","path":"/a"}]`),
+		ETag:  ptr.Of("42"),
+	})
+
+	// Assert
+	var etagErr *state.ETagError
+	assert.ErrorAs(t, err, &etagErr)
+	assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+}
+
+func TestPartialUpdateKeyNotFound(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.tableName = "state"
+
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value, isbinary FROM state").WillReturnError(sql.ErrNoRows)
+	m.mock.ExpectRollback()
+
+	// Act
+	err := m.pgDba.PartialUpdate(&state.PartialUpdateRequest{
+		Key:   "key1",
+		Patch: []byte(`[{"op":"remove","path":"/a"}]`),
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, state.ErrKeyNotFound)
+}
+
 func createSetRequest() state.SetRequest {
 	return state.SetRequest{
 		Key:   randomKey(),
@@ -461,3 +590,78 @@ func mockDatabase(t *testing.T) (*mocks, error) {
 		pgDba: dba,
 	}, err
 }
+
+func TestOpenDBWithSpiffe(t *testing.T) {
+	config, err := parseConnConfig(postgresMetadataStruct{ConnectionString: "host=localhost sslmode=disable"})
+	require.NoError(t, err)
+
+	_, _, err = openDB(config, map[string]string{"spiffeEndpointSocket": "unix:///run/spire/sockets/agent.sock"})
+	assert.ErrorContains(t, err, "sslmode using TLS")
+
+	_, _, err = openDB(config, map[string]string{"spiffeEndpointSocket": "http://localhost"})
+	assert.ErrorContains(t, err, "invalid spiffeEndpointSocket")
+}
+
+func TestParseConnConfig(t *testing.T) {
+	const connectionString = "host=localhost"
+
+	t.Run("default", func(t *testing.T) {
+		config, err := parseConnConfig(postgresMetadataStruct{ConnectionString: connectionString})
+		require.NoError(t, err)
+		assert.Equal(t, pgx.QueryExecModeCacheStatement, config.DefaultQueryExecMode)
+		assert.Equal(t, 512, config.StatementCacheCapacity)
+	})
+
+	t.Run("pgbouncer mode", func(t *testing.T) {
+		config, err := parseConnConfig(postgresMetadataStruct{ConnectionString: connectionString, PgbouncerMode: true})
+		require.NoError(t, err)
+		assert.Equal(t, pgx.QueryExecModeSimpleProtocol, config.DefaultQueryExecMode)
+		assert.Equal(t, 0, config.StatementCacheCapacity)
+		assert.Equal(t, 0, config.DescriptionCacheCapacity)
+	})
+
+	t.Run("pgbouncer mode with exec", func(t *testing.T) {
+		config, err := parseConnConfig(postgresMetadataStruct{ConnectionString: connectionString, PgbouncerMode: true, QueryExecMode: "exec"})
+		require.NoError(t, err)
+		assert.Equal(t, pgx.QueryExecModeExec, config.DefaultQueryExecMode)
+		assert.Equal(t, 0, config.StatementCacheCapacity)
+	})
+
+	t.Run("query exec mode", func(t *testing.T) {
+		config, err := parseConnConfig(postgresMetadataStruct{ConnectionString: connectionString, QueryExecMode: "describe_exec"})
+		require.NoError(t, err)
+		assert.Equal(t, pgx.QueryExecModeDescribeExec, config.DefaultQueryExecMode)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseConnConfig(postgresMetadataStruct{ConnectionString: connectionString, QueryExecMode: "foo"})
+		assert.ErrorContains(t, err, "invalid queryExecMode")
+
+		_, err = parseConnConfig(postgresMetadataStruct{ConnectionString: connectionString, PgbouncerMode: true, QueryExecMode: "cache_statement"})
+		assert.ErrorContains(t, err, "aren't supported with pgbouncerMode")
+	})
+}
+
+func TestMigrateSchema(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.metadata.MetadataTableName = "dapr_metadata"
+
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS dapr_metadata").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("INSERT INTO dapr_metadata").WithArgs("migrations-state").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectBegin()
+	m.mock.ExpectQuery("SELECT value FROM dapr_metadata").WithArgs("migrations-state").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("0"))
+	m.mock.ExpectExec("CREATE TABLE IF NOT EXISTS state").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.mock.ExpectExec("UPDATE dapr_metadata").WithArgs("1", "migrations-state").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.mock.ExpectCommit()
+
+	// Act
+	err := m.pgDba.migrateSchema("state")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, m.pgDba.SchemaVersion())
+	assert.Nil(t, m.mock.ExpectationsWereMet())
+}
@@ -15,12 +15,17 @@ package postgresql
 
 import (
 	"reflect"
+	"strconv"
 
 	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 )
 
+// Key of the version of the schema of the state table in the metadata of the component.
+const schemaVersionKey = "schemaVersion"
+
 // PostgreSQL state store.
 type PostgreSQL struct {
 	features []state.Feature
@@ -39,7 +44,7 @@ func NewPostgreSQLStateStore(logger logger.Logger) state.Store {
 // This unexported constructor allows injecting a dbAccess instance for unit testing.
 func newPostgreSQLStateStore(logger logger.Logger, dba dbAccess) *PostgreSQL {
 	return &PostgreSQL{
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeaturePartialUpdate},
 		logger:   logger,
 		dbaccess: dba,
 	}
@@ -50,6 +55,14 @@ func (p *PostgreSQL) Init(metadata state.Metadata) error {
 	return p.dbaccess.Init(metadata)
 }
 
+// SetPubSubResolver sets the resolver of the pubsub component that the changes of the state table are published to,
+// with the changeFeedPubsub metadata. Implements pubsub.CompositePubSub.
+func (p *PostgreSQL) SetPubSubResolver(resolver pubsub.PubSubResolver) {
+	if c, ok := p.dbaccess.(pubsub.CompositePubSub); ok {
+		c.SetPubSubResolver(resolver)
+	}
+}
+
 // Features returns the features available in this state store.
 func (p *PostgreSQL) Features() []state.Feature {
 	return p.features
@@ -91,6 +104,11 @@ func (p *PostgreSQL) Multi(request *state.TransactionalStateRequest) error {
 	return p.dbaccess.ExecuteMulti(request)
 }
 
+// PartialUpdate patches a JSON document on store. Implements PartialUpdater.
+func (p *PostgreSQL) PartialUpdate(req *state.PartialUpdateRequest) error {
+	return p.dbaccess.PartialUpdate(req)
+}
+
 // Query executes a query against store.
 func (p *PostgreSQL) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	return p.dbaccess.Query(req)
@@ -105,9 +123,21 @@ func (p *PostgreSQL) Close() error {
 	return nil
 }
 
+// GetComponentMetadata returns the metadata of the component, and the version of the schema of the state table once
+// initialized.
 func (p *PostgreSQL) GetComponentMetadata() map[string]string {
 	metadataStruct := postgresMetadataStruct{}
 	metadataInfo := map[string]string{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	if version := p.dbaccess.SchemaVersion(); version > 0 {
+		metadataInfo[schemaVersionKey] = strconv.Itoa(version)
+	}
 	return metadataInfo
 }
+
+func (p *PostgreSQL) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(p.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString, metadata.AuthProfileSPIFFE},
+	}
+}
@@ -170,9 +170,12 @@ func testCreateTable(t *testing.T, dba *postgresDBAccess) {
 	if exists {
 		dropTable(t, dba.db, tableName)
 	}
+	// Reset the schema version of the table, which is kept when the table is dropped
+	_, err = dba.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE key = $1", dba.metadata.MetadataTableName), migrationsKey(tableName))
+	assert.Nil(t, err)
 
 	// Create the state table and test for its existence
-	err = dba.ensureStateTable(tableName)
+	err = dba.migrateSchema(tableName)
 	assert.Nil(t, err)
 	exists, err = tableExists(dba.db, tableName)
 	assert.Nil(t, err)
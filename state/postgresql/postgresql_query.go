@@ -32,6 +32,8 @@ type Query struct {
 	limit     int
 	skip      *int64
 	tableName string
+	// Regular expression the keys are matched with by the ~ operator, from the keyTemplate metadata
+	keyPattern string
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -104,6 +106,14 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 func (q *Query) Finalize(filters string, qq *query.Query) error {
 	q.query = "SELECT key, value, xmin as etag FROM " + q.tableName
 
+	if q.keyPattern != "" {
+		keyFilter := "key ~ $" + strconv.Itoa(q.addParamValueAndReturnPosition(q.keyPattern))
+		if filters != "" {
+			filters = keyFilter + " AND " + filters
+		} else {
+			filters = keyFilter
+		}
+	}
 	if filters != "" {
 		q.query += " WHERE " + filters
 	}
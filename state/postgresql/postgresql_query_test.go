@@ -69,3 +69,24 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestPostgresqlQueryKeyPattern(t *testing.T) {
+	q := &Query{
+		tableName:  defaultTableName,
+		keyPattern: "^statestore/.*$",
+	}
+	err := query.NewQueryBuilder(q).BuildQuery(&query.Query{
+		Filter: &query.EQ{Key: "state", Val: "CA"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT key, value, xmin as etag FROM state WHERE key ~ $2 AND value->>'state'=$1", q.query)
+	assert.Equal(t, []interface{}{"CA", "^statestore/.*$"}, q.params)
+
+	q = &Query{
+		tableName:  defaultTableName,
+		keyPattern: "^statestore/.*$",
+	}
+	err = query.NewQueryBuilder(q).BuildQuery(&query.Query{})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT key, value, xmin as etag FROM state WHERE key ~ $1", q.query)
+}
@@ -73,10 +73,18 @@ func (m *fakeDBaccess) ExecuteMulti(req *state.TransactionalStateRequest) error
 	return nil
 }
 
+func (m *fakeDBaccess) PartialUpdate(req *state.PartialUpdateRequest) error {
+	return nil
+}
+
 func (m *fakeDBaccess) Query(req *state.QueryRequest) (*state.QueryResponse, error) {
 	return nil, nil
 }
 
+func (m *fakeDBaccess) SchemaVersion() int {
+	return 1
+}
+
 func (m *fakeDBaccess) Close() error {
 	return nil
 }
@@ -15,6 +15,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/dapr/components-contrib/contenttype"
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/internal/redact"
 	daprmetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
@@ -87,6 +89,9 @@ const (
 	defaultBase              = 10
 	defaultBitSize           = 0
 	defaultDB                = 0
+
+	// Number of attempts of a partial update without ETag when the key is concurrently modified.
+	maxPartialUpdateAttempts = 5
 )
 
 // StateStore is a Redis state store.
@@ -94,10 +99,12 @@ type StateStore struct {
 	state.DefaultBulkStore
 	client         redis.UniversalClient
 	clientSettings *rediscomponent.Settings
+	doer           rediscomponent.Doer
 	json           jsoniter.API
 	metadata       rediscomponent.Metadata
 	replicas       int
 	querySchemas   querySchemas
+	keyCodec       *state.KeyCodec
 
 	features []state.Feature
 	logger   logger.Logger
@@ -110,7 +117,7 @@ type StateStore struct {
 func NewRedisStateStore(logger logger.Logger) state.Store {
 	s := &StateStore{
 		json:     jsoniter.ConfigFastest,
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeaturePartialUpdate},
 		logger:   logger,
 	}
 	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
@@ -127,21 +134,32 @@ func (r *StateStore) Ping() error {
 }
 
 // Init does metadata and connection parsing.
-func (r *StateStore) Init(metadata state.Metadata) error {
+func (r *StateStore) Init(metadata state.Metadata) (err error) {
+	// The errors of the Redis client may quote the password, such as when it's part of the host URL
+	redactor := redact.New(metadata.Properties, nil)
+	r.logger = redactor.Logger(r.logger)
+	defer func() {
+		err = redactor.Error(err)
+	}()
+
 	m, err := rediscomponent.ParseRedisMetadata(metadata.Properties)
 	if err != nil {
 		return err
 	}
 	r.metadata = m
+	if r.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	defaultSettings := rediscomponent.Settings{RedisMaxRetries: m.MaxRetries, RedisMaxRetryInterval: rediscomponent.Duration(m.MaxRetryBackoff)}
 	r.client, r.clientSettings, err = rediscomponent.ParseClientFromProperties(metadata.Properties, &defaultSettings)
 	if err != nil {
 		return err
 	}
+	r.doer = rediscomponent.NewDoer(r.client, r.clientSettings)
 
 	// check for query schemas
-	if r.querySchemas, err = parseQuerySchemas(m.QueryIndexes); err != nil {
+	if r.querySchemas, err = parseQuerySchemas(m.QueryIndexes, r.keyCodec.FixedPrefix()); err != nil {
 		return fmt.Errorf("redis store: error parsing query index schema: %v", err)
 	}
 
@@ -209,12 +227,12 @@ func (r *StateStore) Delete(req *state.DeleteRequest) error {
 	}
 
 	var delQuery string
-	if contentType, ok := req.Metadata[daprmetadata.ContentType]; ok && contentType == contenttype.JSONContentType {
+	if r.isJSON(req.Metadata) {
 		delQuery = delJSONQuery
 	} else {
 		delQuery = delDefaultQuery
 	}
-	_, err = r.client.Do(r.ctx, "EVAL", delQuery, 1, req.Key, *req.ETag).Result()
+	_, err = r.do(r.ctx, "EVAL", delQuery, 1, r.keyCodec.Encode(req.Key), *req.ETag).Result()
 	if err != nil {
 		return state.NewETagError(state.ETagMismatch, err)
 	}
@@ -223,7 +241,7 @@ func (r *StateStore) Delete(req *state.DeleteRequest) error {
 }
 
 func (r *StateStore) directGet(req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.Do(r.ctx, "GET", req.Key).Result()
+	res, err := r.do(r.ctx, "GET", req.Key).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +258,7 @@ func (r *StateStore) directGet(req *state.GetRequest) (*state.GetResponse, error
 }
 
 func (r *StateStore) getDefault(req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.Do(r.ctx, "HGETALL", req.Key).Result() // Prefer values with ETags
+	res, err := r.do(r.ctx, "HGETALL", req.Key).Result() // Prefer values with ETags
 	if err != nil {
 		return r.directGet(req) // Falls back to original get for backward compats.
 	}
@@ -264,7 +282,7 @@ func (r *StateStore) getDefault(req *state.GetRequest) (*state.GetResponse, erro
 }
 
 func (r *StateStore) getJSON(req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.Do(r.ctx, "JSON.GET", req.Key).Result()
+	res, err := r.do(r.ctx, "JSON.GET", req.Key).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -302,9 +320,17 @@ func (r *StateStore) getJSON(req *state.GetRequest) (*state.GetResponse, error)
 
 // Get retrieves state from redis with a key.
 func (r *StateStore) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	if contentType, ok := req.Metadata[daprmetadata.ContentType]; ok && contentType == contenttype.JSONContentType {
+	req = r.keyCodec.EncodeGet(req)
+
+	if r.isJSON(req.Metadata) {
+		if _, ok := req.Metadata[jsonPathMetadataKey]; ok {
+			return r.getJSONPath(req)
+		}
 		return r.getJSON(req)
 	}
+	if _, ok := req.Metadata[jsonPathMetadataKey]; ok {
+		return nil, errJSONPathNotJSON
+	}
 
 	return r.getDefault(req)
 }
@@ -320,6 +346,7 @@ func (r *StateStore) Set(req *state.SetRequest) error {
 	if err != nil {
 		return err
 	}
+	req = r.keyCodec.EncodeSet(req)
 	ver, err := r.parseETag(req)
 	if err != nil {
 		return err
@@ -328,6 +355,15 @@ func (r *StateStore) Set(req *state.SetRequest) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse ttl from metadata: %s", err)
 	}
+	if _, ok := req.Metadata[jsonPathMetadataKey]; ok {
+		if !r.isJSON(req.Metadata) {
+			return errJSONPathNotJSON
+		}
+		if ttl != nil {
+			return fmt.Errorf("the %s metadata can't be set with %s", ttlInSeconds, jsonPathMetadataKey)
+		}
+		return r.setJSONPath(req, ver)
+	}
 	// apply global TTL
 	if ttl == nil {
 		ttl = r.metadata.TTLInSeconds
@@ -340,7 +376,7 @@ func (r *StateStore) Set(req *state.SetRequest) error {
 
 	var bt []byte
 	var setQuery string
-	if contentType, ok := req.Metadata[daprmetadata.ContentType]; ok && contentType == contenttype.JSONContentType {
+	if r.isJSON(req.Metadata) {
 		setQuery = setJSONQuery
 		bt, _ = utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
 	} else {
@@ -348,7 +384,7 @@ func (r *StateStore) Set(req *state.SetRequest) error {
 		bt, _ = utils.Marshal(req.Value, r.json.Marshal)
 	}
 
-	err = r.client.Do(r.ctx, "EVAL", setQuery, 1, req.Key, ver, bt, firstWrite).Err()
+	err = r.do(r.ctx, "EVAL", setQuery, 1, req.Key, ver, bt, firstWrite).Err()
 	if err != nil {
 		if req.ETag != nil {
 			return state.NewETagError(state.ETagMismatch, err)
@@ -358,14 +394,14 @@ func (r *StateStore) Set(req *state.SetRequest) error {
 	}
 
 	if ttl != nil && *ttl > 0 {
-		_, err = r.client.Do(r.ctx, "EXPIRE", req.Key, *ttl).Result()
+		_, err = r.do(r.ctx, "EXPIRE", req.Key, *ttl).Result()
 		if err != nil {
 			return fmt.Errorf("failed to set key %s ttl: %s", req.Key, err)
 		}
 	}
 
 	if ttl != nil && *ttl <= 0 {
-		_, err = r.client.Do(r.ctx, "PERSIST", req.Key).Result()
+		_, err = r.do(r.ctx, "PERSIST", req.Key).Result()
 		if err != nil {
 			return fmt.Errorf("failed to persist key %s: %s", req.Key, err)
 		}
@@ -385,7 +421,7 @@ func (r *StateStore) Set(req *state.SetRequest) error {
 func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
 	var setQuery, delQuery string
 	var isJSON bool
-	if contentType, ok := request.Metadata[daprmetadata.ContentType]; ok && contentType == contenttype.JSONContentType {
+	if r.isJSON(request.Metadata) {
 		isJSON = true
 		setQuery = setJSONQuery
 		delQuery = delJSONQuery
@@ -398,6 +434,7 @@ func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
 	for _, o := range request.Operations {
 		if o.Operation == state.Upsert {
 			req := o.Request.(state.SetRequest)
+			req.Key = r.keyCodec.Encode(req.Key)
 			ver, err := r.parseETag(&req)
 			if err != nil {
 				return err
@@ -429,7 +466,7 @@ func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
 				etag := "0"
 				req.ETag = &etag
 			}
-			pipe.Do(r.ctx, "EVAL", delQuery, 1, req.Key, *req.ETag)
+			pipe.Do(r.ctx, "EVAL", delQuery, 1, r.keyCodec.Encode(req.Key), *req.ETag)
 		}
 	}
 
@@ -438,6 +475,117 @@ func (r *StateStore) Multi(request *state.TransactionalStateRequest) error {
 	return err
 }
 
+// PartialUpdate patches a JSON document. Implements state.PartialUpdater.
+// JSON Patch add, replace and remove operations on RedisJSON documents are applied in Redis by a script.
+// Otherwise, the document is read and written back in a transaction watching the key, using RedisJSON commands
+// with the JSON content type, so a concurrent write makes the update fail instead of being lost.
+func (r *StateStore) PartialUpdate(req *state.PartialUpdateRequest) error {
+	req = r.keyCodec.EncodePartialUpdate(req)
+	hasETag := req.ETag != nil && *req.ETag != ""
+	isJSON := r.isJSON(req.Metadata)
+
+	// RedisJSON documents are patched in Redis when RedisJSON commands can apply the patch
+	if patchType, err := req.GetPatchType(); isJSON && err == nil && patchType == state.JSONPatch {
+		ops, err := state.ParseJSONPatch(req.Patch)
+		if err == nil && isNativeJSONPatch(ops) {
+			return r.patchJSON(req, ops)
+		}
+	}
+
+	update := func(tx *redis.Tx) error {
+		var (
+			data    []byte
+			version *string
+		)
+		if isJSON {
+			cmd := redis.NewCmd(r.ctx, "JSON.GET", req.Key)
+			_ = tx.Process(r.ctx, cmd)
+			res, err := cmd.Text()
+			if err != nil {
+				if errors.Is(err, redis.Nil) {
+					return fmt.Errorf("%w: %s", state.ErrKeyNotFound, req.Key)
+				}
+				return err
+			}
+
+			var entry jsonEntry
+			if err = r.json.UnmarshalFromString(res, &entry); err != nil {
+				return err
+			}
+			if entry.Version != nil {
+				version = ptr.Of(strconv.Itoa(*entry.Version))
+			}
+			if data, err = r.json.Marshal(entry.Data); err != nil {
+				return err
+			}
+		} else {
+			res, err := tx.HGetAll(r.ctx, req.Key).Result()
+			if err != nil {
+				return err
+			}
+			val, ok := res["data"]
+			if !ok {
+				return fmt.Errorf("%w: %s", state.ErrKeyNotFound, req.Key)
+			}
+			data = []byte(val)
+			if v, ok := res["version"]; ok {
+				version = ptr.Of(v)
+			}
+		}
+
+		if hasETag && (version == nil || *version != *req.ETag) {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+
+		patched, err := state.ApplyPatch(data, req)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+			if isJSON {
+				pipe.Do(r.ctx, "JSON.SET", req.Key, ".data", string(patched))
+				pipe.Do(r.ctx, "JSON.NUMINCRBY", req.Key, ".version", 1)
+			} else {
+				pipe.HSet(r.ctx, req.Key, "data", string(patched))
+				pipe.HIncrBy(r.ctx, req.Key, "version", 1)
+			}
+
+			return nil
+		})
+
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.client.Watch(r.ctx, update, req.Key)
+		if errors.Is(err, redis.TxFailedErr) {
+			// The key was modified after it was read
+			if hasETag {
+				return state.NewETagError(state.ETagMismatch, err)
+			}
+			if attempt < maxPartialUpdateAttempts {
+				continue
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to partially update key %s: %w", req.Key, err)
+		}
+
+		return nil
+	}
+}
+
+// isJSON returns true if the values are stored as RedisJSON documents, for all the requests with the json valueType,
+// or for the requests with the JSON content type.
+func (r *StateStore) isJSON(reqMetadata map[string]string) bool {
+	if r.metadata.ValueType == rediscomponent.ValueTypeJSON {
+		return true
+	}
+	contentType, ok := reqMetadata[daprmetadata.ContentType]
+	return ok && contentType == contenttype.JSONContentType
+}
+
 func (r *StateStore) registerSchemas() error {
 	for name, elem := range r.querySchemas {
 		r.logger.Infof("redis: create query index %s", name)
@@ -528,7 +676,7 @@ func (r *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error
 	}
 
 	return &state.QueryResponse{
-		Results: data,
+		Results: r.keyCodec.DecodeItems(data),
 		Token:   token,
 	}, nil
 }
@@ -536,12 +684,32 @@ func (r *StateStore) Query(req *state.QueryRequest) (*state.QueryResponse, error
 func (r *StateStore) Close() error {
 	r.cancel()
 
+	if batcher, ok := r.doer.(*rediscomponent.PipelineBatcher); ok {
+		batcher.Close()
+	}
+
 	return r.client.Close()
 }
 
+// do executes a single command, through the pipeline batcher if enabled.
+func (r *StateStore) do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	if r.doer == nil {
+		return r.client.Do(ctx, args...)
+	}
+
+	return r.doer.Do(ctx, args...)
+}
+
 func (r *StateStore) GetComponentMetadata() map[string]string {
 	metadataStruct := rediscomponent.Settings{}
 	metadataInfo := map[string]string{}
 	daprmetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (r *StateStore) ComponentProfile() daprmetadata.ComponentProfile {
+	return daprmetadata.ComponentProfile{
+		Capabilities: append(state.FeatureCapabilities(r.Features()), daprmetadata.CapabilityTTL),
+		AuthProfiles: []daprmetadata.AuthProfile{daprmetadata.AuthProfileNone, daprmetadata.AuthProfilePassword, daprmetadata.AuthProfileSPIFFE},
+	}
+}
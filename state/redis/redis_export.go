@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dapr/components-contrib/contenttype"
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	daprmetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+// Export scans the keys of the database, with the cursor of the scan as the token of the next page.
+// A key can be exported more than once when the keys change during the scan.
+// Keys that weren't set by the state store, which aren't hashes, JSON documents or strings, are skipped.
+func (r *StateStore) Export(req *state.ExportRequest) (*state.ExportResponse, error) {
+	if r.clientSettings != nil && r.clientSettings.RedisType == rediscomponent.ClusterType {
+		return nil, errors.New("redis store: export is not supported by redis cluster")
+	}
+
+	var cursor uint64
+	if req.Token != "" {
+		var err error
+		cursor, err = strconv.ParseUint(req.Token, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis store: invalid export token %q", req.Token)
+		}
+	}
+	count := int64(req.Limit)
+	if count <= 0 {
+		count = 10
+	}
+
+	keys, next, err := r.client.Scan(r.ctx, cursor, "*", count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &state.ExportResponse{Items: make([]state.ExportItem, 0, len(keys))}
+	if next != 0 {
+		res.Token = strconv.FormatUint(next, 10)
+	}
+	for _, key := range keys {
+		if _, ok := r.keyCodec.Decode(key); !ok {
+			continue
+		}
+		item, err := r.exportKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("redis store: failed to export key %s: %w", key, err)
+		}
+		if item != nil {
+			res.Items = append(res.Items, *item)
+		}
+	}
+	return res, nil
+}
+
+// exportKey returns the item of a key, or nil if the key was deleted or isn't a state.
+func (r *StateStore) exportKey(key string) (*state.ExportItem, error) {
+	keyType, err := r.client.Type(r.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		res         *state.GetResponse
+		req         = &state.GetRequest{Key: key}
+		contentType *string
+		metadata    map[string]string
+	)
+	switch keyType {
+	case "hash":
+		vals, err := r.do(r.ctx, "HGETALL", key).Slice()
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) == 0 {
+			return nil, nil
+		}
+		data, version, err := r.getKeyVersion(vals)
+		if err != nil {
+			r.logger.Debugf("Skipping export of hash %s: %v", key, err)
+			return nil, nil
+		}
+		res = &state.GetResponse{Data: []byte(data), ETag: version}
+	case "ReJSON-RL":
+		res, err = r.getJSON(req)
+		if err != nil {
+			return nil, err
+		}
+		// The content type makes the store set the item as a JSON document when it's imported into Redis
+		contentType = ptr.Of(contenttype.JSONContentType)
+		metadata = map[string]string{daprmetadata.ContentType: contenttype.JSONContentType}
+	case "string":
+		res, err = r.directGet(req)
+		if err != nil {
+			return nil, err
+		}
+	case "none":
+		// Deleted since it was scanned
+		return nil, nil
+	default:
+		r.logger.Debugf("Skipping export of key %s of type %s", key, keyType)
+		return nil, nil
+	}
+	if res.Data == nil {
+		return nil, nil
+	}
+
+	exportedKey, _ := r.keyCodec.Decode(key)
+	item := &state.ExportItem{Key: exportedKey, Data: res.Data, ETag: res.ETag, ContentType: contentType, Metadata: metadata}
+	ttl, err := r.client.PTTL(r.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		if item.Metadata == nil {
+			item.Metadata = map[string]string{}
+		}
+		// Round up, so the item doesn't expire before it's imported
+		item.Metadata[ttlInSeconds] = strconv.FormatInt((ttl.Milliseconds()+999)/1000, 10)
+	}
+	return item, nil
+}
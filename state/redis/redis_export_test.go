@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestExport(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client: c,
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	for i := 0; i < 20; i++ {
+		err := ss.Set(&state.SetRequest{Key: fmt.Sprintf("key-%d", i), Value: i})
+		require.NoError(t, err)
+	}
+	err := ss.Set(&state.SetRequest{Key: "ttl", Value: "v", Metadata: map[string]string{ttlInSeconds: "100"}})
+	require.NoError(t, err)
+	// Keys that weren't set by the state store
+	s.Set("plain", "value")
+	s.Lpush("list", "value")
+	s.HSet("hash", "field", "value")
+
+	items := map[string]state.ExportItem{}
+	token := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100)
+		res, err := ss.Export(&state.ExportRequest{Token: token, Limit: 5})
+		require.NoError(t, err)
+		for _, item := range res.Items {
+			items[item.Key] = item
+		}
+		token = res.Token
+		if token == "" {
+			break
+		}
+	}
+
+	assert.Len(t, items, 22)
+	assert.Equal(t, []byte("7"), items["key-7"].Data)
+	assert.Equal(t, "1", *items["key-7"].ETag)
+	assert.Nil(t, items["key-7"].Metadata)
+	assert.Equal(t, "100", items["ttl"].Metadata[ttlInSeconds])
+	assert.Equal(t, []byte("value"), items["plain"].Data)
+	assert.Nil(t, items["plain"].ETag)
+
+	s.FastForward(50 * time.Second)
+	item, err := ss.exportKey("ttl")
+	require.NoError(t, err)
+	assert.Equal(t, "50", item.Metadata[ttlInSeconds])
+
+	_, err = ss.Export(&state.ExportRequest{Token: "invalid"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/utils"
+)
+
+const (
+	// jsonPathMetadataKey is the request metadata of Get and Set with a JSONPath, such as $.address.city,
+	// to read or write a part of a value stored as a RedisJSON document.
+	jsonPathMetadataKey = "jsonPath"
+
+	// Path of the value in the RedisJSON documents, which also contain its version.
+	jsonDataPath    = "$.data"
+	jsonVersionPath = "$.version"
+
+	setJSONPathQuery = `
+	local etag = redis.pcall("JSON.GET", KEYS[1], ".version");
+	if not etag or type(etag) == "table" then
+	  return error("failed to set key " .. KEYS[1] .. ": key not found")
+	end;
+	if ARGV[1] ~= "0" and etag ~= ARGV[1] then
+	  return error("failed to set key " .. KEYS[1])
+	end;
+	if not redis.call("JSON.SET", KEYS[1], ARGV[2], ARGV[3]) then
+	  return error("failed to set key " .. KEYS[1] .. ": path not found")
+	end;
+	return redis.call("JSON.NUMINCRBY", KEYS[1], ".version", 1)`
+
+	// patchJSONQuery applies add, replace and remove operations on independent paths of a RedisJSON document.
+	// ARGV[1] is the ETag, or an empty string, followed by the operation, path and value of each operation.
+	// All operations are checked before any is applied, as Lua scripts aren't rolled back on errors.
+	patchJSONQuery = `
+	local etag = redis.pcall("JSON.GET", KEYS[1], ".version");
+	if not etag or type(etag) == "table" then
+	  return error("key not found");
+	end;
+	if ARGV[1] ~= "" and etag ~= ARGV[1] then
+	  return error("etag mismatch");
+	end;
+	for i = 2, #ARGV, 4 do
+	  local target = ARGV[i + 1];
+	  local want = nil;
+	  if ARGV[i] == "add" then
+	    target = ARGV[i + 2];
+	    want = "object";
+	  end;
+	  local types = redis.call("JSON.TYPE", KEYS[1], target);
+	  if type(types) ~= "table" or #types == 0 or (want and types[1] ~= want) then
+	    return error("path not found: " .. target);
+	  end;
+	end;
+	for i = 2, #ARGV, 4 do
+	  if ARGV[i] == "remove" then
+	    redis.call("JSON.DEL", KEYS[1], ARGV[i + 1]);
+	  else
+	    redis.call("JSON.SET", KEYS[1], ARGV[i + 1], ARGV[i + 3]);
+	  end;
+	end;
+	return redis.call("JSON.NUMINCRBY", KEYS[1], ".version", 1)`
+)
+
+var errJSONPathNotJSON = fmt.Errorf("the %s metadata requires values stored as RedisJSON documents, with the json valueType or the JSON content type", jsonPathMetadataKey)
+
+// getJSONPath reads the part of the value at the JSONPath of the request metadata, along with the version of the value.
+// If the path matches several parts of the value, they are returned as a JSON array.
+func (r *StateStore) getJSONPath(req *state.GetRequest) (*state.GetResponse, error) {
+	path, err := toDataPath(req.Metadata[jsonPathMetadataKey])
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.do(r.ctx, "JSON.GET", req.Key, path, jsonVersionPath).Result()
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return &state.GetResponse{}, nil
+	}
+	str, ok := res.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid result")
+	}
+
+	return parseJSONPathResult(str, path)
+}
+
+// parseJSONPathResult parses the result of JSON.GET with the path of the value and the path of the version,
+// which has the values matched by each path.
+func parseJSONPathResult(res string, path string) (*state.GetResponse, error) {
+	var matches map[string][]jsoniter.RawMessage
+	if err := jsoniter.UnmarshalFromString(res, &matches); err != nil {
+		return nil, err
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	switch values := matches[path]; len(values) {
+	case 0:
+		return &state.GetResponse{}, nil
+	case 1:
+		data = values[0]
+	default:
+		data, err = jsoniter.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &state.GetResponse{Data: data}
+	if versions := matches[jsonVersionPath]; len(versions) == 1 {
+		version := string(versions[0])
+		resp.ETag = &version
+	}
+
+	return resp, nil
+}
+
+// setJSONPath writes the value to the JSONPath of the request metadata, in an existing value.
+func (r *StateStore) setJSONPath(req *state.SetRequest, ver int) error {
+	path, err := toDataPath(req.Metadata[jsonPathMetadataKey])
+	if err != nil {
+		return err
+	}
+	bt, err := utils.Marshal(req.Value, r.json.Marshal)
+	if err != nil {
+		return err
+	}
+
+	err = r.do(r.ctx, "EVAL", setJSONPathQuery, 1, req.Key, ver, path, bt).Err()
+	if err != nil {
+		if req.ETag != nil {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
+
+		return fmt.Errorf("failed to set path %s of key %s: %s", path, req.Key, err)
+	}
+
+	return nil
+}
+
+// toDataPath returns the path in the RedisJSON document of a JSONPath of the value.
+func toDataPath(path string) (string, error) {
+	if path == "$" {
+		return jsonDataPath, nil
+	}
+	if !strings.HasPrefix(path, "$.") && !strings.HasPrefix(path, "$[") {
+		return "", fmt.Errorf("invalid %s metadata %s: must be a JSONPath starting with $", jsonPathMetadataKey, path)
+	}
+
+	return jsonDataPath + path[1:], nil
+}
+
+// patchJSON applies a JSON Patch to a RedisJSON document in Redis, without reading the document.
+func (r *StateStore) patchJSON(req *state.PartialUpdateRequest, ops []state.PatchOperation) error {
+	etag := ""
+	if req.ETag != nil {
+		etag = *req.ETag
+	}
+	args := []interface{}{"EVAL", patchJSONQuery, 1, req.Key, etag}
+	for _, op := range ops {
+		tokens, err := state.SplitJSONPointer(op.Path)
+		if err != nil {
+			return err
+		}
+		args = append(args, string(op.Op), toJSONDocumentPath(tokens), toJSONDocumentPath(tokens[:len(tokens)-1]), string(op.Value))
+	}
+
+	err := r.do(r.ctx, args...).Err()
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "key not found"):
+			if etag != "" {
+				return state.NewETagError(state.ETagMismatch, err)
+			}
+			return fmt.Errorf("%w: %s", state.ErrKeyNotFound, req.Key)
+		case strings.Contains(err.Error(), "etag mismatch"):
+			return state.NewETagError(state.ETagMismatch, err)
+		case strings.Contains(err.Error(), "path not found"):
+			return fmt.Errorf("failed to apply JSON patch: %w", err)
+		}
+		return fmt.Errorf("failed to partially update key %s: %w", req.Key, err)
+	}
+
+	return nil
+}
+
+// isNativeJSONPatch returns true if a JSON Patch can be applied by RedisJSON commands: it has only add, replace and
+// remove operations on members of objects, and no operation depends on the result of another.
+// Array indexes aren't supported, as add inserts into arrays, which JSON.SET doesn't.
+func isNativeJSONPatch(ops []state.PatchOperation) bool {
+	paths := make([][]string, len(ops))
+	for i, op := range ops {
+		if op.Op != state.PatchOpAdd && op.Op != state.PatchOpReplace && op.Op != state.PatchOpRemove {
+			return false
+		}
+		tokens, err := state.SplitJSONPointer(op.Path)
+		if err != nil || len(tokens) == 0 {
+			return false
+		}
+		for _, t := range tokens {
+			if t == "-" {
+				return false
+			}
+			if _, err = strconv.Atoi(t); err == nil {
+				return false
+			}
+		}
+		paths[i] = tokens
+	}
+
+	// Operations on the same path, or on a path inside another, can't be checked before they're applied
+	for i := range paths {
+		for j := range paths {
+			if i != j && isPathPrefix(paths[i], paths[j]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isPathPrefix returns true if the path is the other path, or the path of one of its parents.
+func isPathPrefix(path, other []string) bool {
+	if len(path) > len(other) {
+		return false
+	}
+	for i := range path {
+		if path[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// toJSONDocumentPath returns the JSONPath, in the RedisJSON document, of the reference tokens of a JSON Pointer in the value.
+func toJSONDocumentPath(tokens []string) string {
+	var b strings.Builder
+	b.WriteString(jsonDataPath)
+	for _, t := range tokens {
+		b.WriteString("[")
+		b.WriteString(strconv.Quote(t))
+		b.WriteString("]")
+	}
+
+	return b.String()
+}
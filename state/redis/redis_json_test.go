@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestToDataPath(t *testing.T) {
+	path, err := toDataPath("$")
+	require.NoError(t, err)
+	assert.Equal(t, "$.data", path)
+
+	path, err = toDataPath("$.address.city")
+	require.NoError(t, err)
+	assert.Equal(t, "$.data.address.city", path)
+
+	path, err = toDataPath("$[0]")
+	require.NoError(t, err)
+	assert.Equal(t, "$.data[0]", path)
+
+	_, err = toDataPath("address.city")
+	assert.Error(t, err)
+
+	_, err = toDataPath("$version")
+	assert.Error(t, err)
+}
+
+func TestParseJSONPathResult(t *testing.T) {
+	t.Run("single match", func(t *testing.T) {
+		res, err := parseJSONPathResult(`{"$.data.city":["Seattle"],"$.version":[3]}`, "$.data.city")
+		require.NoError(t, err)
+		assert.Equal(t, `"Seattle"`, string(res.Data))
+		assert.Equal(t, ptr.Of("3"), res.ETag)
+	})
+
+	t.Run("several matches", func(t *testing.T) {
+		res, err := parseJSONPathResult(`{"$.data..city":["Seattle","Paris"],"$.version":[3]}`, "$.data..city")
+		require.NoError(t, err)
+		assert.JSONEq(t, `["Seattle","Paris"]`, string(res.Data))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		res, err := parseJSONPathResult(`{"$.data.zip":[],"$.version":[3]}`, "$.data.zip")
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		assert.Nil(t, res.ETag)
+	})
+}
+
+func TestValueType(t *testing.T) {
+	m, err := rediscomponent.ParseRedisMetadata(map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, rediscomponent.ValueTypeString, m.ValueType)
+
+	m, err = rediscomponent.ParseRedisMetadata(map[string]string{"valueType": "json"})
+	require.NoError(t, err)
+	assert.Equal(t, rediscomponent.ValueTypeJSON, m.ValueType)
+
+	m, err = rediscomponent.ParseRedisMetadata(map[string]string{"valueFormat": "json"})
+	require.NoError(t, err)
+	assert.Equal(t, rediscomponent.ValueTypeJSON, m.ValueType)
+
+	_, err = rediscomponent.ParseRedisMetadata(map[string]string{"valueType": "hash"})
+	assert.Error(t, err)
+
+	ss := &StateStore{metadata: m}
+	assert.True(t, ss.isJSON(nil))
+	ss.metadata.ValueType = rediscomponent.ValueTypeString
+	assert.False(t, ss.isJSON(nil))
+	assert.True(t, ss.isJSON(map[string]string{"contentType": "application/json"}))
+}
+
+func TestJSONPathWithoutJSON(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client: c,
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	_, err := ss.Get(&state.GetRequest{Key: "key", Metadata: map[string]string{"jsonPath": "$.name"}})
+	assert.ErrorIs(t, err, errJSONPathNotJSON)
+
+	err = ss.Set(&state.SetRequest{Key: "key", Value: "v", Metadata: map[string]string{"jsonPath": "$.name"}})
+	assert.ErrorIs(t, err, errJSONPathNotJSON)
+}
+
+func TestIsNativeJSONPatch(t *testing.T) {
+	native := func(patch string) bool {
+		ops, err := state.ParseJSONPatch([]byte(patch))
+		require.NoError(t, err)
+		return isNativeJSONPatch(ops)
+	}
+
+	assert.True(t, native(`[{"op":"add","path":"/a","value":1},{"op":"replace","path":"/b/c","value":2},{"op":"remove","path":"/d"}]`))
+	assert.False(t, native(`[{"op":"move","from":"/a","path":"/b"}]`), "move")
+	assert.False(t, native(`[{"op":"add","path":"/tags/-","value":"x"}]`), "append to array")
+	assert.False(t, native(`[{"op":"replace","path":"/tags/0","value":"x"}]`), "array index")
+	assert.False(t, native(`[{"op":"replace","path":"","value":{}}]`), "whole document")
+	assert.False(t, native(`[{"op":"add","path":"/a","value":{}},{"op":"add","path":"/a/b","value":1}]`), "dependent operations")
+	assert.False(t, native(`[{"op":"add","path":"/a","value":1},{"op":"remove","path":"/a"}]`), "same path")
+}
+
+func TestToJSONDocumentPath(t *testing.T) {
+	assert.Equal(t, "$.data", toJSONDocumentPath(nil))
+	assert.Equal(t, `$.data["size"]["radius"]`, toJSONDocumentPath([]string{"size", "radius"}))
+	assert.Equal(t, `$.data["a\"b"]`, toJSONDocumentPath([]string{`a"b`}))
+}
@@ -35,7 +35,9 @@ type querySchemaElem struct {
 
 type querySchemas map[string]*querySchemaElem
 
-func parseQuerySchemas(content string) (querySchemas, error) {
+// parseQuerySchemas returns the query indexes of the JSON content. The indexes only contain the keys starting with
+// keyPrefix if it isn't empty.
+func parseQuerySchemas(content string, keyPrefix string) (querySchemas, error) {
 	if len(content) == 0 {
 		return nil, nil
 	}
@@ -55,8 +57,12 @@ func parseQuerySchemas(content string) (querySchemas, error) {
 		}
 		elem := &querySchemaElem{
 			keys:   make(map[string]string),
-			schema: []interface{}{"FT.CREATE", schema.Name, "ON", "JSON", "SCHEMA"},
+			schema: []interface{}{"FT.CREATE", schema.Name, "ON", "JSON"},
 		}
+		if keyPrefix != "" {
+			elem.schema = append(elem.schema, "PREFIX", "1", keyPrefix)
+		}
+		elem.schema = append(elem.schema, "SCHEMA")
 		for id, indx := range schema.Indexes {
 			if err := validateIndex(schema.Name, indx); err != nil {
 				return nil, err
@@ -7,7 +7,7 @@ import (
 )
 
 func TestParsingEmptySchema(t *testing.T) {
-	schemas, err := parseQuerySchemas("")
+	schemas, err := parseQuerySchemas("", "")
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(schemas))
 }
@@ -37,7 +37,7 @@ func TestParsingSingleSchema(t *testing.T) {
         ]
     }
 ]`
-	schemas, err := parseQuerySchemas(content)
+	schemas, err := parseQuerySchemas(content, "")
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(schemas))
 	assert.Equal(t,
@@ -84,7 +84,7 @@ func TestParsingMultiSchema(t *testing.T) {
         ]
     }
 ]`
-	schemas, err := parseQuerySchemas(content)
+	schemas, err := parseQuerySchemas(content, "")
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(schemas))
 	assert.Equal(t,
@@ -142,7 +142,18 @@ func TestParsingSchemaErrors(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		_, err := parseQuerySchemas(test.content)
+		_, err := parseQuerySchemas(test.content, "")
 		assert.EqualError(t, err, test.err)
 	}
 }
+
+func TestParsingSchemaWithKeyPrefix(t *testing.T) {
+	schemas, err := parseQuerySchemas(`[{"name": "schema1", "indexes": [{"key": "state", "type": "TEXT"}]}]`, "statestore/")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]interface{}{
+			"FT.CREATE", "schema1", "ON", "JSON", "PREFIX", "1", "statestore/", "SCHEMA",
+			"$.data.state", "AS", "var0", "TEXT", "SORTABLE",
+		},
+		schemas["schema1"].schema)
+}
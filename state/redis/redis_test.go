@@ -23,8 +23,10 @@ import (
 	redis "github.com/go-redis/redis/v8"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	daprmetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
@@ -532,6 +534,105 @@ func TestGetMetadata(t *testing.T) {
 	assert.Equal(t, metadataInfo["idleCheckFrequency"], "redis.Duration")
 }
 
+func TestPartialUpdate(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client: c,
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	err := ss.Set(&state.SetRequest{
+		Key:   "weapon",
+		Value: map[string]interface{}{"name": "deathstar", "size": map[string]interface{}{"radius": 60}},
+	})
+	assert.Equal(t, nil, err)
+
+	t.Run("json patch", func(t *testing.T) {
+		err := ss.PartialUpdate(&state.PartialUpdateRequest{
+			Key:   "weapon",
+			Patch: []byte(`[{"op":"replace","path":"/size/radius","value":80}]`),
+			ETag:  ptr.Of("1"),
+		})
+		assert.Equal(t, nil, err)
+
+		res, err := ss.Get(&state.GetRequest{Key: "weapon"})
+		assert.Equal(t, nil, err)
+		assert.JSONEq(t, `{"name":"deathstar","size":{"radius":80}}`, string(res.Data))
+		assert.Equal(t, ptr.Of("2"), res.ETag)
+	})
+
+	t.Run("merge patch", func(t *testing.T) {
+		err := ss.PartialUpdate(&state.PartialUpdateRequest{
+			Key:       "weapon",
+			Patch:     []byte(`{"name":"deathstar2","size":null}`),
+			PatchType: state.MergePatch,
+		})
+		assert.Equal(t, nil, err)
+
+		res, err := ss.Get(&state.GetRequest{Key: "weapon"})
+		assert.Equal(t, nil, err)
+		assert.JSONEq(t, `{"name":"deathstar2"}`, string(res.Data))
+		assert.Equal(t, ptr.Of("3"), res.ETag)
+	})
+
+	t.Run("etag mismatch", func(t *testing.T) {
+		err := ss.PartialUpdate(&state.PartialUpdateRequest{
+			Key:   "weapon",
+			Patch: []byte(`[{"op":"remove","path":"/name"}]`),
+			ETag:  ptr.Of("1"),
+		})
+		var etagErr *state.ETagError
+		assert.ErrorAs(t, err, &etagErr)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		err := ss.PartialUpdate(&state.PartialUpdateRequest{
+			Key:   "missing",
+			Patch: []byte(`[{"op":"remove","path":"/name"}]`),
+		})
+		assert.ErrorIs(t, err, state.ErrKeyNotFound)
+	})
+}
+
+func TestKeyTemplate(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	keyCodec, err := state.NewKeyCodec(state.Metadata{Base: daprmetadata.Base{
+		Name:       "statestore",
+		Properties: map[string]string{"keyTemplate": "{storename}:{appid}:{key}"},
+	}})
+	require.NoError(t, err)
+	ss := &StateStore{
+		client:   c,
+		json:     jsoniter.ConfigFastest,
+		logger:   logger.NewLogger("test"),
+		keyCodec: keyCodec,
+	}
+	ss.ctx, ss.cancel = context.WithCancel(context.Background())
+
+	err = ss.Set(&state.SetRequest{Key: "app1||weapon", Value: "deathstar"})
+	require.NoError(t, err)
+	assert.True(t, s.Exists("statestore:app1:weapon"))
+
+	res, err := ss.Get(&state.GetRequest{Key: "app1||weapon"})
+	require.NoError(t, err)
+	assert.Equal(t, `"deathstar"`, string(res.Data))
+
+	exported, err := ss.Export(&state.ExportRequest{})
+	require.NoError(t, err)
+	require.Len(t, exported.Items, 1)
+	assert.Equal(t, "app1||weapon", exported.Items[0].Key)
+
+	err = ss.Delete(&state.DeleteRequest{Key: "app1||weapon"})
+	require.NoError(t, err)
+	assert.False(t, s.Exists("statestore:app1:weapon"))
+}
+
 func setupMiniredis() (*miniredis.Miniredis, *redis.Client) {
 	s, err := miniredis.Run()
 	if err != nil {
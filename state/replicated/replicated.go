@@ -0,0 +1,468 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replicated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultReplayQueueSize   = 1000
+	defaultRetryInterval     = 5 * time.Second
+	defaultMaxRetries        = 10
+	defaultReconcileInterval = time.Minute
+
+	modeAsync = "async"
+	modeSync  = "sync"
+)
+
+// Store is a state store writing to a primary store, and mirroring the writes to secondary stores.
+// Writes are mirrored asynchronously, or before returning in sync mode, by copying the current value of their keys from
+// the primary store: concurrent writes of a key may be mirrored in any order, the secondary stores end up with the value
+// of the primary store. The keys of the writes that couldn't be mirrored are reconciled periodically the same way.
+// Reads are always served by the primary store.
+type Store struct {
+	state.DefaultBulkStore
+
+	primary     state.Store
+	secondaries []*secondary
+	resolver    state.StoreResolver
+	metadata    replicatedMetadata
+	features    []state.Feature
+	logger      logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type replicatedMetadata struct {
+	// Component name of the primary store.
+	PrimaryStore string `mapstructure:"primaryStore"`
+	// Component names of the secondary stores, comma-separated.
+	SecondaryStores []string `mapstructure:"secondaryStores"`
+	// Number of writes queued for each secondary store; writes are dropped when the queue is full.
+	ReplayQueueSize int `mapstructure:"replayQueueSize"`
+	// Interval between the attempts of a failed write to a secondary store.
+	RetryInterval time.Duration `mapstructure:"retryInterval"`
+	// Attempts of a failed write to a secondary store before it's dropped. Negative values retry forever.
+	MaxRetries int `mapstructure:"maxRetries"`
+	// "async" (default) to mirror writes in the background, or "sync" to mirror writes before returning.
+	// In sync mode, a write failing on a secondary store returns an error, though it's applied to the primary store.
+	ReplicationMode string `mapstructure:"replicationMode"`
+	// Interval of the reconciliation of the keys whose writes couldn't be mirrored. 0 disables reconciliation.
+	ReconcileInterval time.Duration `mapstructure:"reconcileInterval"`
+}
+
+// mirroredWrite is the keys of a write applied to the primary store, with the metadata of their requests.
+// Their values are read from the primary store when the write is mirrored.
+type mirroredWrite struct {
+	keys     []mirroredKey
+	metadata map[string]string
+}
+
+type mirroredKey struct {
+	key      string
+	metadata map[string]string
+}
+
+// secondary mirrors writes to a secondary store, in order.
+// A failed write is retried before the next ones, which wait in the replay queue.
+type secondary struct {
+	name  string
+	store state.Store
+	queue chan *mirroredWrite
+	// Serializes the synchronous writes with each other and with the reconciliation, so that a value read from the
+	// primary store is never written after a value read later
+	writeLock sync.Mutex
+
+	// Keys whose writes couldn't be mirrored, with the metadata of their last request
+	dirtyLock sync.Mutex
+	dirty     map[string]map[string]string
+}
+
+// NewReplicatedStateStore returns a new replicated state store.
+func NewReplicatedStateStore(logger logger.Logger) state.Store {
+	s := &Store{logger: logger}
+	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
+
+	return s
+}
+
+// SetStoreResolver sets the resolver of the primary and secondary stores.
+func (s *Store) SetStoreResolver(resolver state.StoreResolver) {
+	s.resolver = resolver
+}
+
+// Init parses the metadata, resolves the stores and starts mirroring.
+func (s *Store) Init(meta state.Metadata) error {
+	m := replicatedMetadata{
+		ReplayQueueSize:   defaultReplayQueueSize,
+		RetryInterval:     defaultRetryInterval,
+		MaxRetries:        defaultMaxRetries,
+		ReplicationMode:   modeAsync,
+		ReconcileInterval: defaultReconcileInterval,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+	if m.PrimaryStore == "" {
+		return errors.New("replicated state store: missing primaryStore")
+	}
+	if m.ReplayQueueSize <= 0 {
+		return errors.New("replicated state store: replayQueueSize must be positive")
+	}
+	if m.ReplicationMode != modeAsync && m.ReplicationMode != modeSync {
+		return fmt.Errorf("replicated state store: invalid replicationMode %s", m.ReplicationMode)
+	}
+	if m.ReconcileInterval < 0 {
+		return errors.New("replicated state store: reconcileInterval must not be negative")
+	}
+	if s.resolver == nil {
+		return errors.New("replicated state store: no resolver for the referenced stores")
+	}
+
+	s.primary, err = s.resolver(m.PrimaryStore)
+	if err != nil {
+		return fmt.Errorf("replicated state store: %w", err)
+	}
+	s.secondaries = make([]*secondary, 0, len(m.SecondaryStores))
+	for _, name := range m.SecondaryStores {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == m.PrimaryStore {
+			return fmt.Errorf("replicated state store: %s can't be both primary and secondary", name)
+		}
+		store, err := s.resolver(name)
+		if err != nil {
+			return fmt.Errorf("replicated state store: %w", err)
+		}
+		s.secondaries = append(s.secondaries, &secondary{
+			name:  name,
+			store: store,
+			queue: make(chan *mirroredWrite, m.ReplayQueueSize),
+			dirty: map[string]map[string]string{},
+		})
+	}
+	if len(s.secondaries) == 0 {
+		return errors.New("replicated state store: missing secondaryStores")
+	}
+	s.metadata = m
+
+	s.features = make([]state.Feature, 0, 2)
+	for _, f := range s.primary.Features() {
+		if f == state.FeatureETag || f == state.FeatureTransactional {
+			s.features = append(s.features, f)
+		}
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, sec := range s.secondaries {
+		s.wg.Add(1)
+		go s.mirror(sec)
+	}
+
+	return nil
+}
+
+func (s *Store) Features() []state.Feature {
+	return s.features
+}
+
+func (s *Store) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	return s.primary.Get(req)
+}
+
+func (s *Store) Set(req *state.SetRequest) error {
+	mirrored := &mirroredWrite{keys: []mirroredKey{{key: req.Key, metadata: req.Metadata}}}
+	if err := s.primary.Set(req); err != nil {
+		return err
+	}
+
+	return s.replicate(mirrored)
+}
+
+func (s *Store) Delete(req *state.DeleteRequest) error {
+	mirrored := &mirroredWrite{keys: []mirroredKey{{key: req.Key, metadata: req.Metadata}}}
+	if err := s.primary.Delete(req); err != nil {
+		return err
+	}
+
+	return s.replicate(mirrored)
+}
+
+// Multi is only called by the runtime if the primary store is transactional, as features are passed through.
+func (s *Store) Multi(request *state.TransactionalStateRequest) error {
+	tx, ok := s.primary.(state.TransactionalStore)
+	if !ok {
+		return errors.New("replicated state store: the primary store doesn't support transactions")
+	}
+	mirrored := mirroredRequest(request)
+	if err := tx.Multi(request); err != nil {
+		return err
+	}
+
+	return s.replicate(mirrored)
+}
+
+// replicate mirrors a write applied to the primary store to all secondary stores: it queues the write in async mode,
+// or applies it in sync mode, returning the errors of the secondary stores.
+func (s *Store) replicate(req *mirroredWrite) error {
+	if s.metadata.ReplicationMode == modeSync {
+		return s.replicateSync(req)
+	}
+
+	for _, sec := range s.secondaries {
+		select {
+		case sec.queue <- req:
+		default:
+			s.logger.Errorf("replicated state store: replay queue of secondary store %s is full, dropping write", sec.name)
+			sec.markDirty(req)
+		}
+	}
+
+	return nil
+}
+
+// replicateSync applies a write to all secondary stores in parallel.
+func (s *Store) replicateSync(req *mirroredWrite) error {
+	errs := make([]error, len(s.secondaries))
+	var wg sync.WaitGroup
+	wg.Add(len(s.secondaries))
+	for i, sec := range s.secondaries {
+		go func(i int, sec *secondary) {
+			defer wg.Done()
+
+			sec.writeLock.Lock()
+			err := copyRequest(s.primary, sec.store, req)
+			sec.writeLock.Unlock()
+			if err != nil {
+				sec.markDirty(req)
+				errs[i] = fmt.Errorf("replicated state store: write applied to the primary store but not to secondary store %s: %w", sec.name, err)
+			}
+		}(i, sec)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mirroredRequest returns the keys of a request to mirror. The keys are copied before the write, as stores may replace
+// the operations of the request.
+func mirroredRequest(req *state.TransactionalStateRequest) *mirroredWrite {
+	mirrored := &mirroredWrite{
+		keys:     make([]mirroredKey, 0, len(req.Operations)),
+		metadata: req.Metadata,
+	}
+	for _, op := range req.Operations {
+		switch r := op.Request.(type) {
+		case state.SetRequest:
+			mirrored.keys = append(mirrored.keys, mirroredKey{key: r.Key, metadata: r.Metadata})
+		case state.DeleteRequest:
+			mirrored.keys = append(mirrored.keys, mirroredKey{key: r.Key, metadata: r.Metadata})
+		}
+	}
+
+	return mirrored
+}
+
+// markDirty records the keys of a write that couldn't be mirrored, for reconciliation.
+func (sec *secondary) markDirty(req *mirroredWrite) {
+	for _, k := range req.keys {
+		sec.markKeyDirty(k.key, k.metadata)
+	}
+}
+
+func (sec *secondary) markKeyDirty(key string, md map[string]string) {
+	sec.dirtyLock.Lock()
+	sec.dirty[key] = md
+	sec.dirtyLock.Unlock()
+}
+
+// takeDirty returns the keys to reconcile, and clears them.
+func (sec *secondary) takeDirty() map[string]map[string]string {
+	sec.dirtyLock.Lock()
+	defer sec.dirtyLock.Unlock()
+
+	dirty := sec.dirty
+	sec.dirty = map[string]map[string]string{}
+
+	return dirty
+}
+
+func (s *Store) mirror(sec *secondary) {
+	defer s.wg.Done()
+
+	// A nil channel never fires, when reconciliation is disabled
+	var reconcile <-chan time.Time
+	if s.metadata.ReconcileInterval > 0 {
+		ticker := time.NewTicker(s.metadata.ReconcileInterval)
+		defer ticker.Stop()
+		reconcile = ticker.C
+	}
+
+	for {
+		select {
+		case <-reconcile:
+			s.reconcile(sec)
+		case <-s.ctx.Done():
+			if pending := len(sec.queue); pending > 0 {
+				s.logger.Warnf("replicated state store: %d writes not mirrored to secondary store %s", pending, sec.name)
+			}
+			return
+		case req := <-sec.queue:
+			s.apply(sec, req)
+		}
+	}
+}
+
+// apply mirrors a request to a secondary store, retrying failures.
+func (s *Store) apply(sec *secondary, req *mirroredWrite) {
+	for attempt := 0; ; attempt++ {
+		err := copyRequest(s.primary, sec.store, req)
+		if err == nil {
+			return
+		}
+		if s.metadata.MaxRetries >= 0 && attempt >= s.metadata.MaxRetries {
+			s.logger.Errorf("replicated state store: dropping write to secondary store %s after %d attempts: %v", sec.name, attempt+1, err)
+			sec.markDirty(req)
+			return
+		}
+		s.logger.Warnf("replicated state store: failed to write to secondary store %s, retrying in %v: %v", sec.name, s.metadata.RetryInterval, err)
+
+		select {
+		case <-time.After(s.metadata.RetryInterval):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcile copies the current value of the keys whose writes couldn't be mirrored from the primary store to the
+// secondary store, deleting the keys that don't exist in the primary store. Keys that fail are reconciled again later.
+// In async mode, it runs in the mirroring goroutine, so the writes queued afterwards are applied after it.
+func (s *Store) reconcile(sec *secondary) {
+	dirty := sec.takeDirty()
+	if len(dirty) == 0 {
+		return
+	}
+	s.logger.Infof("replicated state store: reconciling %d keys of secondary store %s", len(dirty), sec.name)
+
+	sec.writeLock.Lock()
+	defer sec.writeLock.Unlock()
+
+	for key, md := range dirty {
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		err := copyRequest(s.primary, sec.store, &mirroredWrite{keys: []mirroredKey{{key: key, metadata: md}}})
+		if err != nil {
+			s.logger.Warnf("replicated state store: failed to reconcile key %s of secondary store %s: %v", key, sec.name, err)
+			sec.markKeyDirty(key, md)
+		}
+	}
+}
+
+// copyRequest writes the current value in the primary store of the keys of a mirrored request to a secondary store,
+// deleting the keys that don't exist in the primary store. The values are written without concurrency control, as
+// ETags of the primary store don't apply to the secondary stores.
+func copyRequest(primary state.Store, store state.Store, req *mirroredWrite) error {
+	ops := make([]state.TransactionalStateOperation, len(req.keys))
+	for i, k := range req.keys {
+		res, err := primary.Get(&state.GetRequest{Key: k.key, Metadata: k.metadata})
+		if err != nil {
+			return err
+		}
+		if res == nil || res.Data == nil {
+			ops[i] = state.TransactionalStateOperation{Operation: state.Delete, Request: state.DeleteRequest{
+				Key: k.key, Metadata: k.metadata,
+			}}
+		} else {
+			ops[i] = state.TransactionalStateOperation{Operation: state.Upsert, Request: state.SetRequest{
+				Key: k.key, Value: res.Data, Metadata: k.metadata, ContentType: res.ContentType,
+			}}
+		}
+	}
+
+	if len(ops) > 1 {
+		if tx, ok := store.(state.TransactionalStore); ok && state.FeatureTransactional.IsPresent(store.Features()) {
+			return tx.Multi(&state.TransactionalStateRequest{Operations: ops, Metadata: req.metadata})
+		}
+	}
+
+	// Operations already applied are applied again on retries, which is idempotent without concurrency control
+	for _, op := range ops {
+		var err error
+		switch r := op.Request.(type) {
+		case state.SetRequest:
+			err = store.Set(&r)
+		case state.DeleteRequest:
+			err = store.Delete(&r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) Ping() error {
+	return state.Ping(s.primary)
+}
+
+// Close stops mirroring. It doesn't close the referenced stores, which are components of their own.
+func (s *Store) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+
+	return nil
+}
+
+func (s *Store) GetComponentMetadata() map[string]string {
+	metadataStruct := replicatedMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+// ComponentProfile declares the capabilities passed through from the primary store, once initialized.
+func (s *Store) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replicated
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+// pausedStore blocks the writes of a value after applying them, until resumed.
+type pausedStore struct {
+	state.Store
+	value  string
+	resume chan struct{}
+}
+
+func (p *pausedStore) Set(req *state.SetRequest) error {
+	err := p.Store.Set(req)
+	if req.Value == p.value {
+		<-p.resume
+	}
+
+	return err
+}
+
+// flakyStore fails the first writes.
+type flakyStore struct {
+	state.Store
+	failures atomic.Int32
+}
+
+func (f *flakyStore) Set(req *state.SetRequest) error {
+	if f.failures.Add(-1) >= 0 {
+		return errors.New("unavailable")
+	}
+
+	return f.Store.Set(req)
+}
+
+func newInMemoryStore(t *testing.T) state.Store {
+	t.Helper()
+
+	s := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, s.Init(state.Metadata{}))
+
+	return s
+}
+
+func newReplicatedStore(t *testing.T, stores map[string]state.Store, props map[string]string) (*Store, error) {
+	t.Helper()
+
+	s := NewReplicatedStateStore(logger.NewLogger("test")).(*Store)
+	s.SetStoreResolver(state.NewMapStoreResolver(stores))
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: props}})
+	if err == nil {
+		t.Cleanup(func() { s.Close() })
+	}
+
+	return s, err
+}
+
+func getValue(t *testing.T, store state.Store, key string) string {
+	t.Helper()
+
+	res, err := store.Get(&state.GetRequest{Key: key})
+	require.NoError(t, err)
+
+	return string(res.Data)
+}
+
+func TestInit(t *testing.T) {
+	stores := map[string]state.Store{"primary": newInMemoryStore(t), "secondary": newInMemoryStore(t)}
+
+	t.Run("valid metadata", func(t *testing.T) {
+		s, err := newReplicatedStore(t, stores, map[string]string{
+			"primaryStore": "primary", "secondaryStores": "secondary", "replayQueueSize": "10", "retryInterval": "1s", "maxRetries": "-1",
+		})
+		require.NoError(t, err)
+		assert.Len(t, s.secondaries, 1)
+		assert.Equal(t, 10, cap(s.secondaries[0].queue))
+		assert.Equal(t, time.Second, s.metadata.RetryInterval)
+		assert.Equal(t, -1, s.metadata.MaxRetries)
+		assert.Equal(t, []state.Feature{state.FeatureETag, state.FeatureTransactional}, s.Features())
+		assert.Equal(t, []metadata.Capability{metadata.CapabilityETag, metadata.CapabilityTransactions}, s.ComponentProfile().Capabilities)
+	})
+
+	t.Run("missing secondary stores", func(t *testing.T) {
+		_, err := newReplicatedStore(t, stores, map[string]string{"primaryStore": "primary"})
+		assert.Error(t, err)
+	})
+
+	t.Run("primary as secondary", func(t *testing.T) {
+		_, err := newReplicatedStore(t, stores, map[string]string{"primaryStore": "primary", "secondaryStores": "primary"})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown store", func(t *testing.T) {
+		_, err := newReplicatedStore(t, stores, map[string]string{"primaryStore": "primary", "secondaryStores": "secondary,other"})
+		assert.Error(t, err)
+	})
+}
+
+func TestReplication(t *testing.T) {
+	primary := newInMemoryStore(t)
+	secondary1 := newInMemoryStore(t)
+	secondary2 := &flakyStore{Store: newInMemoryStore(t)}
+	secondary2.failures.Store(2)
+
+	s, err := newReplicatedStore(t, map[string]state.Store{"primary": primary, "s1": secondary1, "s2": secondary2}, map[string]string{
+		"primaryStore": "primary", "secondaryStores": "s1, s2", "retryInterval": "10ms",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set(&state.SetRequest{Key: "a", Value: "1"}))
+	assert.Equal(t, `"1"`, getValue(t, s, "a"))
+
+	// Writes guarded by ETags of the primary store are mirrored without them
+	res, err := primary.Get(&state.GetRequest{Key: "a"})
+	require.NoError(t, err)
+	require.NoError(t, s.Set(&state.SetRequest{Key: "a", Value: "2", ETag: res.ETag}))
+	require.NoError(t, s.Multi(&state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{
+			{Operation: state.Upsert, Request: state.SetRequest{Key: "b", Value: "3"}},
+			{Operation: state.Delete, Request: state.DeleteRequest{Key: "c"}},
+		},
+	}))
+
+	// The flaky store receives the writes in order once they're replayed
+	for _, sec := range []state.Store{secondary1, secondary2} {
+		assert.Eventually(t, func() bool {
+			return getValue(t, sec, "a") == `"2"` && getValue(t, sec, "b") == `"3"`
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// Failed writes to the primary store are not mirrored
+	err = s.Set(&state.SetRequest{Key: "a", Value: "4", ETag: res.ETag})
+	require.Error(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, `"2"`, getValue(t, secondary1, "a"))
+}
+
+func TestDroppedWrites(t *testing.T) {
+	secondary := &flakyStore{Store: newInMemoryStore(t)}
+	secondary.failures.Store(1)
+
+	s, err := newReplicatedStore(t, map[string]state.Store{"primary": newInMemoryStore(t), "secondary": secondary}, map[string]string{
+		"primaryStore": "primary", "secondaryStores": "secondary", "retryInterval": "10ms", "maxRetries": "0",
+	})
+	require.NoError(t, err)
+
+	// The first write fails once and is dropped, the second one is mirrored
+	require.NoError(t, s.Set(&state.SetRequest{Key: "a", Value: "1"}))
+	require.NoError(t, s.Set(&state.SetRequest{Key: "b", Value: "2"}))
+	assert.Eventually(t, func() bool {
+		return getValue(t, secondary, "b") == `"2"`
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, getValue(t, secondary, "a"))
+}
+
+func TestSyncReplication(t *testing.T) {
+	secondary := &flakyStore{Store: newInMemoryStore(t)}
+	secondary.failures.Store(1)
+
+	s, err := newReplicatedStore(t, map[string]state.Store{"primary": newInMemoryStore(t), "secondary": secondary}, map[string]string{
+		"primaryStore": "primary", "secondaryStores": "secondary", "replicationMode": "sync", "reconcileInterval": "0",
+	})
+	require.NoError(t, err)
+
+	// The write is applied to the primary store, and the error of the secondary store is returned
+	err = s.Set(&state.SetRequest{Key: "a", Value: "1"})
+	require.ErrorContains(t, err, "secondary store secondary")
+	assert.Equal(t, `"1"`, getValue(t, s, "a"))
+	assert.Empty(t, getValue(t, secondary, "a"))
+
+	// Writes are mirrored before returning
+	require.NoError(t, s.Set(&state.SetRequest{Key: "b", Value: "2"}))
+	assert.Equal(t, `"2"`, getValue(t, secondary, "b"))
+
+	_, err = newReplicatedStore(t, map[string]state.Store{"primary": newInMemoryStore(t), "secondary": secondary}, map[string]string{
+		"primaryStore": "primary", "secondaryStores": "secondary", "replicationMode": "other",
+	})
+	assert.ErrorContains(t, err, "invalid replicationMode")
+}
+
+func TestReconciliation(t *testing.T) {
+	primary := newInMemoryStore(t)
+	secondary := &flakyStore{Store: newInMemoryStore(t)}
+	secondary.failures.Store(2)
+
+	s, err := newReplicatedStore(t, map[string]state.Store{"primary": primary, "secondary": secondary}, map[string]string{
+		"primaryStore": "primary", "secondaryStores": "secondary", "maxRetries": "0", "reconcileInterval": "20ms",
+	})
+	require.NoError(t, err)
+	require.NoError(t, secondary.Store.Set(&state.SetRequest{Key: "b", Value: "old"}))
+
+	// Both writes are dropped, and reconciled from the primary store
+	require.NoError(t, s.Set(&state.SetRequest{Key: "a", Value: "1"}))
+	require.NoError(t, s.Set(&state.SetRequest{Key: "b", Value: "2"}))
+	require.NoError(t, primary.Delete(&state.DeleteRequest{Key: "b"}))
+
+	assert.Eventually(t, func() bool {
+		return getValue(t, secondary, "a") == `"1"` && getValue(t, secondary, "b") == ""
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestConcurrentWritesOfKey(t *testing.T) {
+	for _, mode := range []string{"async", "sync"} {
+		t.Run(mode, func(t *testing.T) {
+			primary := &pausedStore{Store: newInMemoryStore(t), value: "1", resume: make(chan struct{})}
+			secondary := newInMemoryStore(t)
+			s, err := newReplicatedStore(t, map[string]state.Store{"primary": primary, "secondary": secondary}, map[string]string{
+				"primaryStore": "primary", "secondaryStores": "secondary", "replicationMode": mode, "reconcileInterval": "0",
+			})
+			require.NoError(t, err)
+
+			// The first write is applied to the primary store first, but mirrored after the second one
+			done := make(chan error)
+			go func() {
+				done <- s.Set(&state.SetRequest{Key: "a", Value: "1"})
+			}()
+			assert.Eventually(t, func() bool {
+				return getValue(t, primary, "a") == `"1"`
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, s.Set(&state.SetRequest{Key: "a", Value: "2"}))
+			close(primary.resume)
+			require.NoError(t, <-done)
+
+			assert.Eventually(t, func() bool {
+				return getValue(t, secondary, "a") == `"2"`
+			}, 5*time.Second, 10*time.Millisecond)
+			time.Sleep(50 * time.Millisecond)
+			assert.Equal(t, `"2"`, getValue(t, secondary, "a"))
+		})
+	}
+}
@@ -77,6 +77,26 @@ type SetStateOption struct {
 	Consistency string `json:"consistency"`           // "eventual, strong"
 }
 
+// PartialUpdateRequest is the object describing a partial update of a JSON document.
+type PartialUpdateRequest struct {
+	Key string `json:"key"`
+	// Patch is either a JSON Patch (RFC 6902) or a JSON Merge Patch (RFC 7396) document, as indicated by PatchType.
+	Patch     []byte            `json:"patch"`
+	PatchType PatchType         `json:"patchType,omitempty"`
+	ETag      *string           `json:"etag,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// GetKey gets the Key on a PartialUpdateRequest.
+func (r PartialUpdateRequest) GetKey() string {
+	return r.Key
+}
+
+// GetMetadata gets the Metadata on a PartialUpdateRequest.
+func (r PartialUpdateRequest) GetMetadata() map[string]string {
+	return r.Metadata
+}
+
 // OperationType describes a CRUD operation performed against a state store.
 type OperationType string
 
@@ -109,3 +129,14 @@ type QueryRequest struct {
 	Query    query.Query       `json:"query"`
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
+
+// KeysRequest is the request of a page of the keys of a state store.
+type KeysRequest struct {
+	// Prefix of the keys; all the keys are listed when it's empty.
+	Prefix string `json:"prefix,omitempty"`
+	// Maximum number of keys of the page; stores return at most their maximum page size when it's 0.
+	Limit int `json:"limit,omitempty"`
+	// Token of the page, empty for the first page.
+	Token    string            `json:"token,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
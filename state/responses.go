@@ -38,6 +38,13 @@ type QueryResponse struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// KeysResponse is a page of the keys of a state store.
+type KeysResponse struct {
+	Keys []string `json:"keys"`
+	// Token of the next page, empty when all the keys have been listed.
+	Token string `json:"token,omitempty"`
+}
+
 // QueryItem is an object representing a single entry in query results.
 type QueryItem struct {
 	Key         string  `json:"key"`
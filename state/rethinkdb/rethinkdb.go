@@ -39,6 +39,7 @@ const (
 type RethinkDB struct {
 	session  *r.Session
 	config   *stateConfig
+	keyCodec *state.KeyCodec
 	features []state.Feature
 	logger   logger.Logger
 }
@@ -72,6 +73,9 @@ func (s *RethinkDB) Init(metadata state.Metadata) error {
 	if err != nil {
 		return errors.Wrap(err, "unable to parse metadata properties")
 	}
+	if s.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
 
 	// in case someone runs Init multiple times
 	if s.session != nil && s.session.IsConnected() {
@@ -152,7 +156,7 @@ func (s *RethinkDB) Get(req *state.GetRequest) (*state.GetResponse, error) {
 		return nil, errors.New("invalid state request, missing key")
 	}
 
-	c, err := r.Table(s.config.Table).Get(req.Key).Run(s.session)
+	c, err := r.Table(s.config.Table).Get(s.keyCodec.Encode(req.Key)).Run(s.session)
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting record from the database")
 	}
@@ -211,7 +215,7 @@ func (s *RethinkDB) BulkSet(req []state.SetRequest) error {
 		}
 
 		docs[i] = &stateRecord{
-			ID:   v.Key,
+			ID:   s.keyCodec.Encode(v.Key),
 			TS:   time.Now().UTC().UnixNano(),
 			Data: v.Value,
 			Hash: etag,
@@ -269,7 +273,7 @@ func (s *RethinkDB) Delete(req *state.DeleteRequest) error {
 func (s *RethinkDB) BulkDelete(req []state.DeleteRequest) error {
 	list := make([]string, 0)
 	for _, d := range req {
-		list = append(list, d.Key)
+		list = append(list, s.keyCodec.Encode(d.Key))
 	}
 
 	c, err := r.Table(s.config.Table).GetAll(r.Args(list)).Delete().Run(s.session)
@@ -337,3 +341,9 @@ func (s *RethinkDB) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (s *RethinkDB) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone, metadata.AuthProfilePassword},
+	}
+}
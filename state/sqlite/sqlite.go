@@ -0,0 +1,551 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+// SQLite state store.
+// Writes go through a single connection, as SQLite allows only one writer at a time, while reads use a separate pool
+// of read-only connections, which in WAL mode don't block and aren't blocked by the writer.
+type SQLite struct {
+	metadata sqliteMetadata
+	timeout  time.Duration
+	keyCodec *state.KeyCodec
+
+	// Connection used for writes
+	writeDB *sql.DB
+	// Pool of connections used for reads; this is writeDB for in-memory databases
+	readDB *sql.DB
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger logger.Logger
+}
+
+// NewSQLiteStateStore creates a new instance of the SQLite state store.
+func NewSQLiteStateStore(logger logger.Logger) state.Store {
+	return &SQLite{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init opens the connections to the database and ensures that the state table exists.
+func (s *SQLite) Init(meta state.Metadata) error {
+	s.logger.Debug("Initializing SQLite state store")
+
+	s.metadata = newSQLiteMetadata()
+	err := s.metadata.InitWithMetadata(meta.Properties)
+	if err != nil {
+		return err
+	}
+	s.timeout, err = metadata.GetOperationTimeout(meta.Properties, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	s.keyCodec, err = state.NewKeyCodec(meta)
+	if err != nil {
+		return err
+	}
+
+	s.writeDB, err = openWriteDB(&s.metadata)
+	if err != nil {
+		return err
+	}
+
+	if s.metadata.Rekey != "" {
+		// Re-encrypt the database before the readers connect with the new key
+		err = s.rekey()
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.metadata.IsInMemory() {
+		// Each connection to an in-memory database has its own database
+		s.readDB = s.writeDB
+	} else {
+		s.readDB, err = openDB(&s.metadata, true)
+		if err != nil {
+			return fmt.Errorf("failed to open the database: %w", err)
+		}
+		s.readDB.SetMaxOpenConns(s.metadata.MaxReadConnections)
+		s.readDB.SetMaxIdleConns(s.metadata.MaxReadConnections)
+	}
+
+	// Connecting the writer first creates the database and switches it to WAL mode
+	err = s.Ping()
+	if err != nil {
+		return err
+	}
+
+	err = s.ensureStateTable()
+	if err != nil {
+		return err
+	}
+
+	if s.metadata.UseWAL() && s.metadata.WALCheckpointInterval > 0 {
+		s.wg.Add(1)
+		go s.checkpointLoop()
+	}
+
+	return nil
+}
+
+// Re-encrypts the database with the key of the rekey metadata, which new connections then use.
+// If the database can't be read with encryptionKey but can with rekey, it was already re-encrypted, for example
+// before the component restarted with the same metadata, and it's used as is.
+func (s *SQLite) rekey() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := checkKey(ctx, s.writeDB)
+	if err != nil {
+		s.writeDB.Close()
+		rekeyed := s.metadata
+		rekeyed.EncryptionKey = rekeyed.Rekey
+		s.writeDB, err = openWriteDB(&rekeyed)
+		if err != nil {
+			return err
+		}
+		err = checkKey(ctx, s.writeDB)
+		if err != nil {
+			return fmt.Errorf("failed to read the database with either encryptionKey or rekey: %w", err)
+		}
+		s.logger.Info("SQLite database is already encrypted with the rekey key")
+		s.metadata.EncryptionKey = s.metadata.Rekey
+		return nil
+	}
+
+	_, err = s.writeDB.ExecContext(ctx, "PRAGMA rekey = "+quoteString(s.metadata.Rekey))
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt the database: %w", err)
+	}
+	s.metadata.EncryptionKey = s.metadata.Rekey
+
+	return nil
+}
+
+// Opens the connection used for writes.
+func openWriteDB(m *sqliteMetadata) (*sql.DB, error) {
+	db, err := openDB(m, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	// Keep the connection open, or in-memory databases would be lost
+	db.SetConnMaxIdleTime(0)
+	db.SetConnMaxLifetime(0)
+	return db, nil
+}
+
+// Reads the schema of the database, which fails if the database is encrypted with a different key.
+func checkKey(ctx context.Context, db *sql.DB) error {
+	var n int
+	return db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master").Scan(&n)
+}
+
+// Ping the database.
+func (s *SQLite) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := s.writeDB.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ping the database: %w", err)
+	}
+	if s.readDB != s.writeDB {
+		err = s.readDB.PingContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to ping the database: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) ensureStateTable() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	// Note that the table name is sanitized
+	//nolint:gosec
+	_, err := s.writeDB.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT NOT NULL PRIMARY KEY,
+		value TEXT NOT NULL,
+		is_binary BOOLEAN NOT NULL,
+		etag TEXT NOT NULL,
+		update_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`, s.metadata.TableName))
+	if err != nil {
+		return fmt.Errorf("failed to create the state table: %w", err)
+	}
+
+	return nil
+}
+
+// checkpointLoop checkpoints the WAL periodically, so it doesn't grow when the automatic checkpoints can't complete
+// because of long-running readers.
+func (s *SQLite) checkpointLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.metadata.WALCheckpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			err := s.checkpoint()
+			if err != nil {
+				s.logger.Warnf("Failed to checkpoint the SQLite WAL: %v", err)
+			}
+		}
+	}
+}
+
+func (s *SQLite) checkpoint() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var busy, logPages, checkpointedPages int
+	// Note that the checkpoint mode is validated
+	err := s.writeDB.QueryRowContext(ctx, "PRAGMA wal_checkpoint("+s.metadata.WALCheckpointMode+")").
+		Scan(&busy, &logPages, &checkpointedPages)
+	if err != nil {
+		return err
+	}
+	s.logger.Debugf("SQLite WAL checkpoint: busy=%d, log pages=%d, checkpointed pages=%d", busy, logPages, checkpointedPages)
+
+	return nil
+}
+
+// Features returns the features available in this state store.
+func (s *SQLite) Features() []state.Feature {
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
+}
+
+// Get returns an entity from the store.
+func (s *SQLite) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	if req.Key == "" {
+		return nil, errors.New("missing key in get operation")
+	}
+	req = s.keyCodec.EncodeGet(req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var (
+		value    []byte
+		isBinary bool
+		etag     string
+	)
+	//nolint:gosec
+	err := s.readDB.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT value, is_binary, etag FROM %s WHERE key = ?", s.metadata.TableName),
+		req.Key).Scan(&value, &isBinary, &etag)
+	if err != nil {
+		// If no rows exist, return an empty response, otherwise return an error.
+		if errors.Is(err, sql.ErrNoRows) {
+			return &state.GetResponse{}, nil
+		}
+
+		return nil, err
+	}
+
+	if isBinary {
+		var str string
+		err = json.Unmarshal(value, &str)
+		if err != nil {
+			return nil, err
+		}
+		value, err = base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &state.GetResponse{
+		Data:     value,
+		ETag:     &etag,
+		Metadata: req.Metadata,
+	}, nil
+}
+
+// BulkGet performs a bulks get operations.
+func (s *SQLite) BulkGet(req []state.GetRequest) (bool, []state.BulkGetResponse, error) {
+	// by default, the store doesn't support bulk get
+	// return false so daprd will fallback to call get() method one by one
+	return false, nil, nil
+}
+
+// Set adds or updates an entity in the store.
+func (s *SQLite) Set(req *state.SetRequest) error {
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		return s.setValue(ctx, tx, req)
+	})
+}
+
+// BulkSet adds or updates multiple entities in the store, in a transaction.
+func (s *SQLite) BulkSet(req []state.SetRequest) error {
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		for i := range req {
+			err := s.setValue(ctx, tx, &req[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes an entity from the store.
+func (s *SQLite) Delete(req *state.DeleteRequest) error {
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		return s.deleteValue(ctx, tx, req)
+	})
+}
+
+// BulkDelete removes multiple entities from the store, in a transaction.
+func (s *SQLite) BulkDelete(req []state.DeleteRequest) error {
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		for i := range req {
+			err := s.deleteValue(ctx, tx, &req[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Multi handles multiple operations in a transaction.
+func (s *SQLite) Multi(request *state.TransactionalStateRequest) error {
+	return s.withTx(func(ctx context.Context, tx *sql.Tx) error {
+		for _, o := range request.Operations {
+			switch o.Operation {
+			case state.Upsert:
+				req, ok := o.Request.(state.SetRequest)
+				if !ok {
+					return errors.New("expecting set request")
+				}
+				err := s.setValue(ctx, tx, &req)
+				if err != nil {
+					return err
+				}
+			case state.Delete:
+				req, ok := o.Request.(state.DeleteRequest)
+				if !ok {
+					return errors.New("expecting delete request")
+				}
+				err := s.deleteValue(ctx, tx, &req)
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported operation: %s", o.Operation)
+			}
+		}
+		return nil
+	})
+}
+
+// withTx runs fn in a transaction on the writer connection, committing it if fn succeeds.
+func (s *SQLite) withTx(fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	tx, err := s.writeDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction is committed
+		_ = tx.Rollback()
+	}()
+
+	err = fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLite) setValue(ctx context.Context, tx *sql.Tx, req *state.SetRequest) error {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return err
+	}
+
+	if req.Key == "" {
+		return errors.New("missing key in set operation")
+	}
+	req = s.keyCodec.EncodeSet(req)
+
+	var v any
+	isBinary := false
+	switch x := req.Value.(type) {
+	case string:
+		if x == "" {
+			return errors.New("empty string is not allowed in set operation")
+		}
+		v = x
+	case []byte:
+		isBinary = true
+		v = base64.StdEncoding.EncodeToString(x)
+	default:
+		v = x
+	}
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	etagObj, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("failed to generate etag: %w", err)
+	}
+	etag := etagObj.String()
+
+	var result sql.Result
+	hasETag := req.ETag != nil && *req.ETag != ""
+	// Note that the table name is sanitized
+	//nolint:gosec
+	switch {
+	case hasETag:
+		// When an etag is provided do an update - no insert
+		result, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`UPDATE %s SET value = ?, is_binary = ?, etag = ?, update_time = CURRENT_TIMESTAMP WHERE key = ? AND etag = ?`,
+			s.metadata.TableName), string(value), isBinary, etag, req.Key, *req.ETag)
+	case req.Options.Concurrency == state.FirstWrite:
+		// With first-write-wins and no etag, we can insert the row only if it doesn't exist
+		result, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`INSERT OR IGNORE INTO %s (key, value, is_binary, etag) VALUES (?, ?, ?, ?)`,
+			s.metadata.TableName), req.Key, string(value), isBinary, etag)
+	default:
+		result, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %s (key, value, is_binary, etag) VALUES (?, ?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, is_binary = excluded.is_binary, etag = excluded.etag, update_time = CURRENT_TIMESTAMP`,
+			s.metadata.TableName), req.Key, string(value), isBinary, etag)
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		if hasETag || req.Options.Concurrency == state.FirstWrite {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+		return errors.New("no item was updated")
+	}
+
+	return nil
+}
+
+func (s *SQLite) deleteValue(ctx context.Context, tx *sql.Tx, req *state.DeleteRequest) error {
+	if req.Key == "" {
+		return errors.New("missing key in delete operation")
+	}
+	req = s.keyCodec.EncodeDelete(req)
+
+	var (
+		result sql.Result
+		err    error
+	)
+	hasETag := req.ETag != nil && *req.ETag != ""
+	// Note that the table name is sanitized
+	//nolint:gosec
+	if hasETag {
+		result, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE key = ? AND etag = ?`,
+			s.metadata.TableName), req.Key, *req.ETag)
+	} else {
+		result, err = tx.ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE key = ?`,
+			s.metadata.TableName), req.Key)
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 && hasETag {
+		return state.NewETagError(state.ETagMismatch, nil)
+	}
+
+	return nil
+}
+
+// Close stops the background checkpoints and closes the connections.
+func (s *SQLite) Close() error {
+	if s.writeDB == nil {
+		return nil
+	}
+
+	close(s.closeCh)
+	s.wg.Wait()
+
+	var err error
+	if s.readDB != nil && s.readDB != s.writeDB {
+		err = s.readDB.Close()
+	}
+	if wErr := s.writeDB.Close(); wErr != nil {
+		err = wErr
+	}
+	s.writeDB = nil
+	s.readDB = nil
+
+	return err
+}
+
+func (s *SQLite) GetComponentMetadata() map[string]string {
+	metadataStruct := sqliteMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
+	return metadataInfo
+}
+
+func (s *SQLite) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
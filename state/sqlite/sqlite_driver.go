@@ -0,0 +1,38 @@
+//go:build !sqlcipher
+// +build !sqlcipher
+
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	// Blank import for the underlying SQLite driver.
+	_ "modernc.org/sqlite"
+)
+
+// Opens the database with the pure-Go SQLite driver, which can't encrypt databases.
+func openDB(m *sqliteMetadata, readOnly bool) (*sql.DB, error) {
+	if m.EncryptionKey != "" {
+		// Fail rather than silently storing the state unencrypted
+		return nil, errors.New("encryptionKey requires the SQLite state store to be built with the sqlcipher tag")
+	}
+
+	if readOnly {
+		return sql.Open("sqlite", m.ReaderDSN())
+	}
+	return sql.Open("sqlite", m.WriterDSN())
+}
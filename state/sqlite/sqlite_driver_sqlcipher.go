@@ -0,0 +1,100 @@
+//go:build sqlcipher
+// +build sqlcipher
+
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Opens the database with the cgo SQLite driver, which must be linked with SQLCipher to encrypt databases, for
+// example with the libsqlite3 tag and CGO_LDFLAGS="-lsqlcipher".
+func openDB(m *sqliteMetadata, readOnly bool) (*sql.DB, error) {
+	dsn := m.ConnectionString
+	if !readOnly {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "_txlock=immediate"
+	}
+
+	drv := &sqlite3.SQLiteDriver{
+		// The pragmas are set when connecting, rather than in the DSN, as the key must be set before anything reads
+		// the database
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if m.EncryptionKey != "" {
+				err := setKey(conn, m.EncryptionKey)
+				if err != nil {
+					return err
+				}
+			}
+			for _, p := range m.Pragmas(readOnly) {
+				_, err := conn.Exec("PRAGMA "+p, nil)
+				if err != nil {
+					return fmt.Errorf("failed to set pragma %s: %w", p, err)
+				}
+			}
+			return nil
+		},
+	}
+
+	return sql.OpenDB(&connector{driver: drv, dsn: dsn}), nil
+}
+
+func setKey(conn *sqlite3.SQLiteConn, key string) error {
+	// Without SQLCipher, SQLite ignores the key pragma and the database would be stored unencrypted
+	rows, err := conn.Query("PRAGMA cipher_version", nil)
+	if err != nil {
+		return fmt.Errorf("failed to query the SQLCipher version: %w", err)
+	}
+	dest := make([]driver.Value, 1)
+	err = rows.Next(dest)
+	rows.Close()
+	if errors.Is(err, io.EOF) {
+		return errors.New("encryptionKey requires SQLite to be linked with SQLCipher")
+	} else if err != nil {
+		return fmt.Errorf("failed to query the SQLCipher version: %w", err)
+	}
+
+	_, err = conn.Exec("PRAGMA key = "+quoteString(key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to set the encryption key: %w", err)
+	}
+	return nil
+}
+
+// Connector of a driver that has a connect hook, which sql.Open can't use as the driver is registered by name.
+type connector struct {
+	driver *sqlite3.SQLiteDriver
+	dsn    string
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,86 @@
+//go:build sqlcipher
+// +build sqlcipher
+
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestEncryption(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	open := func(props map[string]string) (*SQLite, error) {
+		props["connectionString"] = dbPath
+		s := NewSQLiteStateStore(logger.NewLogger("test")).(*SQLite)
+		return s, s.Init(state.Metadata{Base: metadata.Base{Properties: props}})
+	}
+
+	s, err := open(map[string]string{"encryptionKey": "secret"})
+	require.NoError(t, err)
+	err = s.Set(&state.SetRequest{Key: "k1", Value: "plaintext-value"})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	t.Run("database is encrypted", func(t *testing.T) {
+		data, err := os.ReadFile(dbPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "SQLite format 3")
+		assert.NotContains(t, string(data), "plaintext-value")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		s, err := open(map[string]string{"encryptionKey": "wrong"})
+		assert.Error(t, err)
+		s.Close()
+	})
+
+	t.Run("rekey", func(t *testing.T) {
+		s, err := open(map[string]string{"encryptionKey": "secret", "rekey": "new'secret"})
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+
+		s, err = open(map[string]string{"encryptionKey": "new'secret"})
+		require.NoError(t, err)
+		defer s.Close()
+		res, err := s.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Equal(t, `"plaintext-value"`, string(res.Data))
+	})
+
+	t.Run("rekey again after restart", func(t *testing.T) {
+		s, err := open(map[string]string{"encryptionKey": "secret", "rekey": "new'secret"})
+		require.NoError(t, err)
+		defer s.Close()
+		res, err := s.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Equal(t, `"plaintext-value"`, string(res.Data))
+	})
+
+	t.Run("rekey with wrong keys", func(t *testing.T) {
+		s, err := open(map[string]string{"encryptionKey": "wrong", "rekey": "also-wrong"})
+		assert.Error(t, err)
+		s.Close()
+	})
+}
@@ -0,0 +1,37 @@
+//go:build !sqlcipher
+// +build !sqlcipher
+
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestEncryptionRequiresSQLCipher(t *testing.T) {
+	s := NewSQLiteStateStore(logger.NewLogger("test")).(*SQLite)
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"connectionString": filepath.Join(t.TempDir(), "test.db"),
+		"encryptionKey":    "secret",
+	}}})
+	assert.ErrorContains(t, err, "sqlcipher")
+}
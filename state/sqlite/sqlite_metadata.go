@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	defaultTableName          = "state"
+	defaultTimeout            = 20 * time.Second
+	defaultBusyTimeout        = 2 * time.Second
+	defaultMaxReadConnections = 4
+	defaultWALAutoCheckpoint  = 1000
+	defaultWALCheckpointMode  = "PASSIVE"
+
+	errMissingConnectionString = "missing connection string"
+)
+
+type sqliteMetadata struct {
+	// Path of the database file, or a "file:" URI.
+	ConnectionString string
+	TableName        string
+
+	// Time SQLite waits for a lock held by another connection before failing with SQLITE_BUSY.
+	BusyTimeout time.Duration
+	// Maximum number of bytes of the database file to memory-map; 0 disables memory-mapped I/O.
+	MmapSize int64
+	// Size of the pool of read-only connections; writes always use a single connection.
+	MaxReadConnections int
+	// Disables the write-ahead log, using the rollback journal instead.
+	DisableWAL bool
+	// Number of pages in the WAL after which SQLite checkpoints automatically; 0 disables automatic checkpoints.
+	WALAutoCheckpoint int
+	// Interval of the checkpoints run in the background; 0 disables them.
+	WALCheckpointInterval time.Duration
+	// Mode of the checkpoints run in the background: PASSIVE, FULL, RESTART or TRUNCATE.
+	WALCheckpointMode string
+	// Key to encrypt the database with SQLCipher; this requires the component to be built with the sqlcipher tag.
+	EncryptionKey string
+	// New key to re-encrypt the database with, after opening it with encryptionKey.
+	// A database that is already encrypted with this key is opened as is, so the metadata can be kept across restarts.
+	Rekey string
+}
+
+func newSQLiteMetadata() sqliteMetadata {
+	return sqliteMetadata{
+		TableName:          defaultTableName,
+		BusyTimeout:        defaultBusyTimeout,
+		MaxReadConnections: defaultMaxReadConnections,
+		WALAutoCheckpoint:  defaultWALAutoCheckpoint,
+		WALCheckpointMode:  defaultWALCheckpointMode,
+	}
+}
+
+func (m *sqliteMetadata) InitWithMetadata(props map[string]string) error {
+	err := metadata.DecodeMetadata(props, m)
+	if err != nil {
+		return err
+	}
+
+	if m.ConnectionString == "" {
+		return errors.New(errMissingConnectionString)
+	}
+	if m.Rekey != "" && m.EncryptionKey == "" {
+		return errors.New("rekey requires the current encryptionKey of the database")
+	}
+	if !validIdentifier(m.TableName) {
+		return fmt.Errorf("table name '%s' is not valid", m.TableName)
+	}
+	if m.BusyTimeout < 0 {
+		return fmt.Errorf("invalid busyTimeout: %v", m.BusyTimeout)
+	}
+	if m.MmapSize < 0 {
+		return fmt.Errorf("invalid mmapSize: %d", m.MmapSize)
+	}
+	if m.MaxReadConnections < 1 {
+		return fmt.Errorf("invalid maxReadConnections: %d", m.MaxReadConnections)
+	}
+	if m.WALAutoCheckpoint < 0 {
+		return fmt.Errorf("invalid walAutoCheckpoint: %d", m.WALAutoCheckpoint)
+	}
+	if m.WALCheckpointInterval < 0 {
+		return fmt.Errorf("invalid walCheckpointInterval: %v", m.WALCheckpointInterval)
+	}
+	m.WALCheckpointMode = strings.ToUpper(m.WALCheckpointMode)
+	switch m.WALCheckpointMode {
+	case "PASSIVE", "FULL", "RESTART", "TRUNCATE":
+	default:
+		return fmt.Errorf("invalid walCheckpointMode: %s", m.WALCheckpointMode)
+	}
+
+	return nil
+}
+
+// IsInMemory returns true if the database is in memory, in which case each connection would have its own database.
+func (m *sqliteMetadata) IsInMemory() bool {
+	return m.ConnectionString == ":memory:" ||
+		strings.HasPrefix(m.ConnectionString, "file::memory:") ||
+		strings.Contains(m.ConnectionString, "mode=memory")
+}
+
+// UseWAL returns true if the database uses the write-ahead log.
+func (m *sqliteMetadata) UseWAL() bool {
+	return !m.DisableWAL && !m.IsInMemory()
+}
+
+// WriterDSN returns the DSN of the connection used for writes.
+func (m *sqliteMetadata) WriterDSN() string {
+	// Transactions take the write lock immediately, so they wait on busy_timeout rather than failing when upgrading
+	// from a read lock
+	return m.dsn(url.Values{
+		"_txlock": []string{"immediate"},
+		"_pragma": m.Pragmas(false),
+	})
+}
+
+// ReaderDSN returns the DSN of the connections used for reads.
+func (m *sqliteMetadata) ReaderDSN() string {
+	// Pragmas are set in the DSN so they're applied to every connection of the pools
+	return m.dsn(url.Values{"_pragma": m.Pragmas(true)})
+}
+
+// Pragmas returns the pragmas set on each connection, such as "busy_timeout(2000)".
+func (m *sqliteMetadata) Pragmas(readOnly bool) []string {
+	var pragmas []string
+	if readOnly {
+		pragmas = append(pragmas, "query_only(1)")
+	}
+	pragmas = append(pragmas, "busy_timeout("+strconv.FormatInt(m.BusyTimeout.Milliseconds(), 10)+")")
+	if m.UseWAL() {
+		pragmas = append(pragmas, "journal_mode(WAL)")
+		// With WAL, NORMAL is durable against application crashes and much faster than FULL
+		pragmas = append(pragmas, "synchronous(NORMAL)")
+		pragmas = append(pragmas, "wal_autocheckpoint("+strconv.Itoa(m.WALAutoCheckpoint)+")")
+	}
+	if m.MmapSize > 0 {
+		pragmas = append(pragmas, "mmap_size("+strconv.FormatInt(m.MmapSize, 10)+")")
+	}
+
+	return pragmas
+}
+
+func (m *sqliteMetadata) dsn(params url.Values) string {
+	sep := "?"
+	if strings.Contains(m.ConnectionString, "?") {
+		sep = "&"
+	}
+	return m.ConnectionString + sep + params.Encode()
+}
+
+// Validates an identifier, such as a table name.
+func validIdentifier(v string) bool {
+	if v == "" {
+		return false
+	}
+
+	// Loop through the string as byte slice as we only care about ASCII characters
+	b := []byte(v)
+	for i := 0; i < len(b); i++ {
+		if (b[i] >= '0' && b[i] <= '9') ||
+			(b[i] >= 'a' && b[i] <= 'z') ||
+			(b[i] >= 'A' && b[i] <= 'Z') ||
+			b[i] == '_' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Quotes a string literal, such as a key in a pragma.
+func quoteString(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
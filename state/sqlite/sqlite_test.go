@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := newSQLiteMetadata()
+		err := m.InitWithMetadata(map[string]string{"connectionString": "data.db"})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTableName, m.TableName)
+		assert.Equal(t, defaultBusyTimeout, m.BusyTimeout)
+		assert.Equal(t, defaultMaxReadConnections, m.MaxReadConnections)
+		assert.Equal(t, defaultWALAutoCheckpoint, m.WALAutoCheckpoint)
+		assert.Equal(t, "PASSIVE", m.WALCheckpointMode)
+		assert.True(t, m.UseWAL())
+	})
+
+	t.Run("tuning", func(t *testing.T) {
+		m := newSQLiteMetadata()
+		err := m.InitWithMetadata(map[string]string{
+			"connectionString":      "data.db",
+			"busyTimeout":           "5s",
+			"mmapSize":              "268435456",
+			"maxReadConnections":    "8",
+			"walAutoCheckpoint":     "0",
+			"walCheckpointInterval": "1m",
+			"walCheckpointMode":     "truncate",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, m.BusyTimeout)
+		assert.Equal(t, int64(268435456), m.MmapSize)
+		assert.Equal(t, 8, m.MaxReadConnections)
+		assert.Equal(t, 0, m.WALAutoCheckpoint)
+		assert.Equal(t, time.Minute, m.WALCheckpointInterval)
+		assert.Equal(t, "TRUNCATE", m.WALCheckpointMode)
+
+		q := dsnQuery(t, m.WriterDSN())
+		assert.Equal(t, "immediate", q.Get("_txlock"))
+		assert.Equal(t, []string{"busy_timeout(5000)", "journal_mode(WAL)", "synchronous(NORMAL)", "wal_autocheckpoint(0)", "mmap_size(268435456)"}, q["_pragma"])
+
+		q = dsnQuery(t, m.ReaderDSN())
+		assert.Empty(t, q.Get("_txlock"))
+		assert.Contains(t, q["_pragma"], "query_only(1)")
+	})
+
+	t.Run("in-memory database doesn't use WAL", func(t *testing.T) {
+		m := newSQLiteMetadata()
+		err := m.InitWithMetadata(map[string]string{"connectionString": "file:test?mode=memory&cache=shared"})
+		require.NoError(t, err)
+		assert.True(t, m.IsInMemory())
+		assert.False(t, m.UseWAL())
+		assert.True(t, strings.HasPrefix(m.WriterDSN(), "file:test?mode=memory&cache=shared&"))
+		assert.NotContains(t, dsnQuery(t, m.WriterDSN())["_pragma"], "journal_mode(WAL)")
+	})
+
+	invalid := map[string]map[string]string{
+		"missing connection string": {},
+		"invalid table name":        {"connectionString": "data.db", "tableName": "state;"},
+		"invalid read connections":  {"connectionString": "data.db", "maxReadConnections": "0"},
+		"invalid checkpoint mode":   {"connectionString": "data.db", "walCheckpointMode": "NOW"},
+		"negative mmap size":        {"connectionString": "data.db", "mmapSize": "-1"},
+		"rekey without key":         {"connectionString": "data.db", "rekey": "secret"},
+	}
+	for name, props := range invalid {
+		t.Run(name, func(t *testing.T) {
+			m := newSQLiteMetadata()
+			assert.Error(t, m.InitWithMetadata(props))
+		})
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	s := newTestStore(t, map[string]string{
+		"walCheckpointInterval": "10ms",
+		"mmapSize":              "1048576",
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		err := s.Set(&state.SetRequest{Key: "k1", Value: map[string]string{"a": "b"}})
+		require.NoError(t, err)
+		err = s.Set(&state.SetRequest{Key: "k2", Value: []byte("binary")})
+		require.NoError(t, err)
+
+		res, err := s.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"b"}`, string(res.Data))
+		require.NotNil(t, res.ETag)
+
+		res, err = s.Get(&state.GetRequest{Key: "k2"})
+		require.NoError(t, err)
+		assert.Equal(t, "binary", string(res.Data))
+
+		res, err = s.Get(&state.GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("etags", func(t *testing.T) {
+		res, err := s.Get(&state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+
+		bad := "bad"
+		err = s.Set(&state.SetRequest{Key: "k1", Value: "v", ETag: &bad})
+		assert.ErrorAs(t, err, new(*state.ETagError))
+
+		err = s.Set(&state.SetRequest{Key: "k1", Value: "v", ETag: res.ETag})
+		require.NoError(t, err)
+
+		err = s.Set(&state.SetRequest{Key: "k1", Value: "v", Options: state.SetStateOption{Concurrency: state.FirstWrite}})
+		assert.ErrorAs(t, err, new(*state.ETagError))
+
+		err = s.Delete(&state.DeleteRequest{Key: "k1", ETag: &bad})
+		assert.ErrorAs(t, err, new(*state.ETagError))
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		err := s.Multi(&state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				{Operation: state.Upsert, Request: state.SetRequest{Key: "k3", Value: "v3"}},
+				{Operation: state.Delete, Request: state.DeleteRequest{Key: "k2"}},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err := s.Get(&state.GetRequest{Key: "k3"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v3"`, string(res.Data))
+		res, err = s.Get(&state.GetRequest{Key: "k2"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("failed bulk set is rolled back", func(t *testing.T) {
+		err := s.BulkSet([]state.SetRequest{
+			{Key: "k4", Value: "v4"},
+			{Key: "", Value: "v"},
+		})
+		require.Error(t, err)
+
+		res, err := s.Get(&state.GetRequest{Key: "k4"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("reads use read-only connections in WAL mode", func(t *testing.T) {
+		var mode string
+		err := s.readDB.QueryRow("PRAGMA journal_mode").Scan(&mode)
+		require.NoError(t, err)
+		assert.Equal(t, "wal", mode)
+
+		_, err = s.readDB.Exec("DELETE FROM state")
+		assert.Error(t, err)
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		assert.NoError(t, s.checkpoint())
+	})
+}
+
+func TestSQLiteInMemory(t *testing.T) {
+	s := &SQLite{
+		logger:  logger.NewLogger("test"),
+		closeCh: make(chan struct{}),
+	}
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"connectionString": ":memory:",
+	}}})
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Same(t, s.writeDB, s.readDB)
+
+	err = s.Set(&state.SetRequest{Key: "k1", Value: "v1"})
+	require.NoError(t, err)
+	res, err := s.Get(&state.GetRequest{Key: "k1"})
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(res.Data))
+}
+
+func newTestStore(t *testing.T, props map[string]string) *SQLite {
+	t.Helper()
+
+	props["connectionString"] = filepath.Join(t.TempDir(), "test.db")
+	s := NewSQLiteStateStore(logger.NewLogger("test")).(*SQLite)
+	err := s.Init(state.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.Close())
+	})
+
+	return s
+}
+
+func dsnQuery(t *testing.T, dsn string) url.Values {
+	t.Helper()
+
+	_, query, _ := strings.Cut(dsn, "?")
+	q, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	return q
+}
@@ -105,6 +105,7 @@ type SQLServer struct {
 	keyType           KeyType
 	keyLength         int
 	indexedProperties []IndexedProperty
+	keyCodec          *state.KeyCodec
 	migratorFactory   func(*SQLServer) migrator
 
 	bulkDeleteCommand        string
@@ -169,6 +170,12 @@ func (s *SQLServer) Init(metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	if s.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return err
+	}
+	if s.keyCodec != nil && s.keyType != StringKeyType {
+		return fmt.Errorf("the %s metadata requires the %s key type", state.KeyTemplateMetadataKey, StringKeyType)
+	}
 
 	migration := s.migratorFactory(s)
 	mr, err := migration.executeMigrations()
@@ -432,9 +439,9 @@ func (s *SQLServer) executeDelete(db dbExecutor, req *state.DeleteRequest) error
 			return state.NewETagError(state.ETagInvalid, err)
 		}
 
-		res, err = db.Exec(s.deleteWithETagCommand, sql.Named(keyColumnName, req.Key), sql.Named(rowVersionColumnName, b))
+		res, err = db.Exec(s.deleteWithETagCommand, sql.Named(keyColumnName, s.keyCodec.Encode(req.Key)), sql.Named(rowVersionColumnName, b))
 	} else {
-		res, err = db.Exec(s.deleteWithoutETagCommand, sql.Named(keyColumnName, req.Key))
+		res, err = db.Exec(s.deleteWithoutETagCommand, sql.Named(keyColumnName, s.keyCodec.Encode(req.Key)))
 	}
 
 	// err represents errors thrown by the stored procedure or the database itself
@@ -493,7 +500,7 @@ func (s *SQLServer) executeBulkDelete(db dbExecutor, req []state.DeleteRequest)
 				return state.NewETagError(state.ETagInvalid, err)
 			}
 		}
-		values[i] = TvpDeleteTableStringKey{ID: d.Key, RowVersion: etag}
+		values[i] = TvpDeleteTableStringKey{ID: s.keyCodec.Encode(d.Key), RowVersion: etag}
 	}
 
 	itemsToDelete := mssql.TVP{
@@ -522,7 +529,7 @@ func (s *SQLServer) executeBulkDelete(db dbExecutor, req []state.DeleteRequest)
 
 // Get returns an entity from store.
 func (s *SQLServer) Get(req *state.GetRequest) (*state.GetResponse, error) {
-	rows, err := s.db.Query(s.getCommand, sql.Named(keyColumnName, req.Key))
+	rows, err := s.db.Query(s.getCommand, sql.Named(keyColumnName, s.keyCodec.Encode(req.Key)))
 	if err != nil {
 		return nil, err
 	}
@@ -586,9 +593,9 @@ func (s *SQLServer) executeSet(db dbExecutor, req *state.SetRequest) error {
 
 	var res sql.Result
 	if req.Options.Concurrency == state.FirstWrite {
-		res, err = db.Exec(s.upsertCommand, sql.Named(keyColumnName, req.Key), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 1))
+		res, err = db.Exec(s.upsertCommand, sql.Named(keyColumnName, s.keyCodec.Encode(req.Key)), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 1))
 	} else {
-		res, err = db.Exec(s.upsertCommand, sql.Named(keyColumnName, req.Key), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 0))
+		res, err = db.Exec(s.upsertCommand, sql.Named(keyColumnName, s.keyCodec.Encode(req.Key)), sql.Named("Data", string(bytes)), etag, sql.Named("FirstWrite", 0))
 	}
 
 	if err != nil {
@@ -635,3 +642,10 @@ func (s *SQLServer) BulkSet(req []state.SetRequest) error {
 func (s *SQLServer) GetComponentMetadata() map[string]string {
 	return map[string]string{}
 }
+
+func (s *SQLServer) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileConnectionString},
+	}
+}
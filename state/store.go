@@ -99,3 +99,13 @@ func (b *DefaultBulkStore) BulkDelete(req []DeleteRequest) error {
 type Querier interface {
 	Query(req *QueryRequest) (*QueryResponse, error)
 }
+
+// KeysLister is an interface to list the keys of a state store, to build migration and cleanup tools.
+type KeysLister interface {
+	Keys(req *KeysRequest) (*KeysResponse, error)
+}
+
+// PartialUpdater is an interface to update parts of a JSON document without replacing it.
+type PartialUpdater interface {
+	PartialUpdate(req *PartialUpdateRequest) error
+}
@@ -114,7 +114,8 @@ type Conn interface {
 // StateStore is a state store.
 type StateStore struct {
 	*config
-	conn Conn
+	conn     Conn
+	keyCodec *state.KeyCodec
 
 	features []state.Feature
 	logger   logger.Logger
@@ -139,6 +140,9 @@ func (s *StateStore) Init(metadata state.Metadata) (err error) {
 	if c, err = newConfig(metadata.Properties); err != nil {
 		return
 	}
+	if s.keyCodec, err = state.NewKeyCodec(metadata); err != nil {
+		return
+	}
 
 	conn, _, err := zk.Connect(c.servers, c.sessionTimeout,
 		zk.WithMaxBufferSize(c.maxBufferSize), zk.WithMaxConnBufferSize(c.maxConnBufferSize))
@@ -356,6 +360,7 @@ func (s *StateStore) newSetDataRequest(req *state.SetRequest) (*zk.SetDataReques
 }
 
 func (s *StateStore) prefixedKey(key string) string {
+	key = s.keyCodec.Encode(key)
 	if s.config == nil {
 		return key
 	}
@@ -389,3 +394,10 @@ func (s *StateStore) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo)
 	return metadataInfo
 }
+
+func (s *StateStore) ComponentProfile() metadata.ComponentProfile {
+	return metadata.ComponentProfile{
+		Capabilities: state.FeatureCapabilities(s.Features()),
+		AuthProfiles: []metadata.AuthProfile{metadata.AuthProfileNone},
+	}
+}
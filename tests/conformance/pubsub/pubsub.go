@@ -120,6 +120,18 @@ func ConformanceTests(t *testing.T, props map[string]string, ps pubsub.PubSub, c
 		}
 	})
 
+	// Components declaring ordered delivery are always checked for in-order processing
+	checkInOrderProcessing := config.CheckInOrderProcessing
+	t.Run("guarantees", func(t *testing.T) {
+		guarantees, err := pubsub.GetGuarantees(ps)
+		require.NoError(t, err, "expected valid guarantees in the component metadata")
+		t.Logf("component %s declares %s ordering and %s delivery", config.ComponentName, guarantees.Ordering, guarantees.Delivery)
+		if guarantees.IsOrdered() {
+			assert.True(t, config.CheckInOrderProcessing, "component declares %s ordering, in-order processing checking can't be disabled", guarantees.Ordering)
+			checkInOrderProcessing = true
+		}
+	})
+
 	// Generate a unique ID for this run to isolate messages to this test
 	// and prevent messages still stored in a locally running broker
 	// from being considered as part of this test.
@@ -419,7 +431,7 @@ func ConformanceTests(t *testing.T, props map[string]string, ps pubsub.PubSub, c
 					waiting = false
 				}
 			}
-			assert.False(t, config.CheckInOrderProcessing && outOfOrder, "received messages out of order")
+			assert.False(t, checkInOrderProcessing && outOfOrder, "received messages out of order")
 			assert.Empty(t, awaitingMessages, "expected to read %v messages", config.MessageCount)
 		})
 	}
@@ -444,7 +456,7 @@ func ConformanceTests(t *testing.T, props map[string]string, ps pubsub.PubSub, c
 					waiting = false
 				}
 			}
-			assert.False(t, config.CheckInOrderProcessing && outOfOrder, "received messages out of order")
+			assert.False(t, checkInOrderProcessing && outOfOrder, "received messages out of order")
 			assert.Empty(t, awaitingMessagesBulk, "expected to read %v messages", config.MessageCount)
 		})
 	}